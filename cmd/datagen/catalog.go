@@ -0,0 +1,160 @@
+package main
+
+// categorySpec describes a top-level category and the products generated under its subcategories.
+// Category names and descriptions match db/01_categories.sql so that IDs line up with the seed data.
+type categorySpec struct {
+	Name          string
+	Description   string
+	LifestyleTag  string              // Tag given to customers who mostly buy from this category
+	Consumable    bool                // Products are re-bought, in larger quantities
+	Features      map[string][]string // Feature values drawn for every product in the category
+	Subcategories []subcategorySpec
+}
+
+// subcategorySpec describes one leaf category that products are assigned to
+type subcategorySpec struct {
+	Name        string
+	Description string
+	Nouns       []string // Product nouns used in names and search queries
+	Brands      []string
+	BasePrice   float64 // Median price in yen
+	Tags        []string
+}
+
+var catalogSpecs = []categorySpec{
+	{
+		Name: "electronics", Description: "電子機器・デバイス", LifestyleTag: "tech_enthusiast",
+		Features: map[string][]string{"color": {"black", "white", "silver", "blue"}, "warranty": {"1year", "2years"}},
+		Subcategories: []subcategorySpec{
+			{Name: "smartphones", Description: "スマートフォン", Nouns: []string{"スマートフォン"}, Brands: []string{"Apple", "Samsung", "Google", "Sony", "Sharp", "Xiaomi"}, BasePrice: 90000, Tags: []string{"smartphone", "mobile"}},
+			{Name: "laptops", Description: "ノートパソコン", Nouns: []string{"ノートパソコン", "ノートPC"}, Brands: []string{"Apple", "Lenovo", "Dell", "HP", "ASUS", "Microsoft"}, BasePrice: 150000, Tags: []string{"laptop", "computer"}},
+			{Name: "headphones", Description: "ヘッドフォン・イヤホン", Nouns: []string{"ワイヤレスイヤホン", "ヘッドフォン"}, Brands: []string{"Sony", "Apple", "Bose", "JBL", "Audio-Technica"}, BasePrice: 25000, Tags: []string{"audio", "wireless"}},
+			{Name: "tablets", Description: "タブレット", Nouns: []string{"タブレット"}, Brands: []string{"Apple", "Samsung", "Lenovo", "Amazon"}, BasePrice: 70000, Tags: []string{"tablet", "mobile"}},
+			{Name: "smartwatch", Description: "スマートウォッチ", Nouns: []string{"スマートウォッチ"}, Brands: []string{"Apple", "Garmin", "Fitbit", "Samsung"}, BasePrice: 40000, Tags: []string{"wearable", "fitness"}},
+			{Name: "cameras", Description: "カメラ・撮影機器", Nouns: []string{"ミラーレスカメラ", "アクションカメラ"}, Brands: []string{"Canon", "Sony", "Nikon", "Fujifilm", "GoPro"}, BasePrice: 120000, Tags: []string{"camera", "photography"}},
+			{Name: "gaming_console", Description: "ゲーム機", Nouns: []string{"ゲーム機", "コントローラー"}, Brands: []string{"Nintendo", "Sony", "Microsoft"}, BasePrice: 45000, Tags: []string{"gaming", "console"}},
+		},
+	},
+	{
+		Name: "fashion", Description: "ファッション・アパレル", LifestyleTag: "fashion_lover",
+		Features: map[string][]string{"color": {"black", "navy", "beige", "gray", "white"}, "size": {"S", "M", "L", "XL"}},
+		Subcategories: []subcategorySpec{
+			{Name: "mens_fashion", Description: "メンズファッション", Nouns: []string{"メンズジャケット", "メンズシャツ"}, Brands: []string{"UNIQLO", "ZARA", "GAP", "BEAMS"}, BasePrice: 6000, Tags: []string{"mens", "apparel"}},
+			{Name: "womens_fashion", Description: "レディースファッション", Nouns: []string{"ワンピース", "レディースコート"}, Brands: []string{"UNIQLO", "ZARA", "H&M", "SNIDEL"}, BasePrice: 8000, Tags: []string{"womens", "apparel"}},
+			{Name: "shoes", Description: "靴・シューズ", Nouns: []string{"スニーカー", "レザーシューズ"}, Brands: []string{"Nike", "Adidas", "New Balance", "ASICS"}, BasePrice: 12000, Tags: []string{"shoes", "footwear"}},
+			{Name: "accessories", Description: "アクセサリー", Nouns: []string{"ネックレス", "腕時計"}, Brands: []string{"Coach", "4℃", "Casio", "Seiko"}, BasePrice: 15000, Tags: []string{"accessory", "gift"}},
+			{Name: "bags", Description: "バッグ・かばん", Nouns: []string{"リュック", "トートバッグ"}, Brands: []string{"Coach", "Anello", "Porter", "MUJI"}, BasePrice: 10000, Tags: []string{"bag", "daily"}},
+		},
+	},
+	{
+		Name: "home", Description: "家具・インテリア", LifestyleTag: "minimalist",
+		Features: map[string][]string{"color": {"white", "natural", "walnut", "black"}, "material": {"wood", "steel", "fabric"}},
+		Subcategories: []subcategorySpec{
+			{Name: "furniture", Description: "家具", Nouns: []string{"ソファ", "デスク", "チェア"}, Brands: []string{"IKEA", "NITORI", "MUJI", "Karimoku"}, BasePrice: 30000, Tags: []string{"furniture", "interior"}},
+			{Name: "kitchenware", Description: "キッチン用品", Nouns: []string{"フライパン", "電気ケトル", "包丁"}, Brands: []string{"T-fal", "Zojirushi", "Balmuda", "Global"}, BasePrice: 6000, Tags: []string{"kitchen", "cooking"}},
+			{Name: "bedding", Description: "寝具", Nouns: []string{"枕", "掛け布団"}, Brands: []string{"NITORI", "Nishikawa", "MUJI"}, BasePrice: 8000, Tags: []string{"bedding", "sleep"}},
+			{Name: "lighting", Description: "照明器具", Nouns: []string{"デスクライト", "シーリングライト"}, Brands: []string{"Panasonic", "IKEA", "IRIS OHYAMA"}, BasePrice: 7000, Tags: []string{"lighting", "interior"}},
+			{Name: "storage", Description: "収納用品", Nouns: []string{"収納ボックス", "シェルフ"}, Brands: []string{"MUJI", "IKEA", "IRIS OHYAMA"}, BasePrice: 3000, Tags: []string{"storage", "organizing"}},
+		},
+	},
+	{
+		Name: "books", Description: "書籍・雑誌", LifestyleTag: "bookworm",
+		Features: map[string][]string{"format": {"paperback", "hardcover", "ebook"}, "language": {"ja", "en"}},
+		Subcategories: []subcategorySpec{
+			{Name: "fiction", Description: "小説・文学", Nouns: []string{"長編小説", "短編集"}, Brands: []string{"新潮社", "講談社", "文藝春秋"}, BasePrice: 1600, Tags: []string{"novel", "reading"}},
+			{Name: "business", Description: "ビジネス書", Nouns: []string{"ビジネス書", "マネジメント入門"}, Brands: []string{"ダイヤモンド社", "日経BP", "東洋経済"}, BasePrice: 1800, Tags: []string{"business", "reading"}},
+			{Name: "technical", Description: "技術書", Nouns: []string{"プログラミング入門", "技術解説書"}, Brands: []string{"オライリー", "技術評論社", "翔泳社"}, BasePrice: 3500, Tags: []string{"technical", "programming"}},
+			{Name: "manga", Description: "漫画", Nouns: []string{"コミック", "漫画全巻セット"}, Brands: []string{"集英社", "講談社", "小学館"}, BasePrice: 700, Tags: []string{"manga", "comic"}},
+			{Name: "magazine", Description: "雑誌", Nouns: []string{"月刊誌", "ムック"}, Brands: []string{"マガジンハウス", "宝島社"}, BasePrice: 900, Tags: []string{"magazine", "reading"}},
+		},
+	},
+	{
+		Name: "sports", Description: "スポーツ・アウトドア", LifestyleTag: "outdoor",
+		Features: map[string][]string{"color": {"black", "red", "blue", "green"}, "level": {"beginner", "intermediate", "advanced"}},
+		Subcategories: []subcategorySpec{
+			{Name: "running", Description: "ランニング", Nouns: []string{"ランニングシューズ", "ランニングウェア"}, Brands: []string{"ASICS", "Nike", "Adidas", "Mizuno"}, BasePrice: 12000, Tags: []string{"running", "fitness"}},
+			{Name: "fitness", Description: "フィットネス", Nouns: []string{"ヨガマット", "ダンベル"}, Brands: []string{"Reebok", "IROTEC", "Manduka"}, BasePrice: 5000, Tags: []string{"fitness", "training"}},
+			{Name: "outdoor", Description: "アウトドア", Nouns: []string{"テント", "寝袋", "キャンプチェア"}, Brands: []string{"Coleman", "Snow Peak", "The North Face", "Montbell"}, BasePrice: 15000, Tags: []string{"outdoor", "camping"}},
+			{Name: "team_sports", Description: "チームスポーツ", Nouns: []string{"サッカーボール", "バスケットボール"}, Brands: []string{"Molten", "Mikasa", "Adidas"}, BasePrice: 4000, Tags: []string{"team_sports", "ball"}},
+			{Name: "water_sports", Description: "ウォータースポーツ", Nouns: []string{"ラッシュガード", "ゴーグル"}, Brands: []string{"Speedo", "Arena", "Quiksilver"}, BasePrice: 5000, Tags: []string{"swimming", "summer"}},
+		},
+	},
+	{
+		Name: "beauty", Description: "美容・コスメ", LifestyleTag: "beauty", Consumable: true,
+		Features: map[string][]string{"skin_type": {"all", "dry", "oily", "sensitive"}, "volume": {"30ml", "50ml", "150ml"}},
+		Subcategories: []subcategorySpec{
+			{Name: "skincare", Description: "スキンケア", Nouns: []string{"化粧水", "美容液", "日焼け止め"}, Brands: []string{"Shiseido", "SK-II", "Hada Labo", "Curel"}, BasePrice: 4000, Tags: []string{"skincare", "cosmetics"}},
+			{Name: "makeup", Description: "メイクアップ", Nouns: []string{"リップ", "ファンデーション"}, Brands: []string{"MAC", "Shiseido", "KATE", "CANMAKE"}, BasePrice: 3000, Tags: []string{"makeup", "cosmetics"}},
+			{Name: "hair_care", Description: "ヘアケア", Nouns: []string{"シャンプー", "ヘアオイル"}, Brands: []string{"Kérastase", "TSUBAKI", "Moroccanoil"}, BasePrice: 2500, Tags: []string{"hair_care", "cosmetics"}},
+			{Name: "fragrance", Description: "香水・フレグランス", Nouns: []string{"オードトワレ", "ボディミスト"}, Brands: []string{"Jo Malone", "Chanel", "SHIRO"}, BasePrice: 9000, Tags: []string{"fragrance", "gift"}},
+		},
+	},
+	{
+		Name: "food", Description: "食品・飲料", LifestyleTag: "health_conscious", Consumable: true,
+		Features: map[string][]string{"weight": {"200g", "500g", "1kg"}, "origin": {"domestic", "imported"}},
+		Subcategories: []subcategorySpec{
+			{Name: "organic_food", Description: "オーガニック食品", Nouns: []string{"有機米", "オーガニック野菜セット"}, Brands: []string{"らでぃっしゅぼーや", "Oisix", "大地を守る会"}, BasePrice: 3000, Tags: []string{"organic", "food"}},
+			{Name: "beverages", Description: "飲料", Nouns: []string{"コーヒー豆", "緑茶", "炭酸水"}, Brands: []string{"UCC", "伊藤園", "KALDI"}, BasePrice: 1500, Tags: []string{"beverage", "food"}},
+			{Name: "snacks", Description: "スナック・お菓子", Nouns: []string{"チョコレート", "ミックスナッツ"}, Brands: []string{"Meiji", "Calbee", "Lotte"}, BasePrice: 800, Tags: []string{"snack", "food"}},
+			{Name: "supplements", Description: "サプリメント", Nouns: []string{"プロテイン", "マルチビタミン"}, Brands: []string{"SAVAS", "DHC", "Myprotein"}, BasePrice: 3500, Tags: []string{"supplement", "health"}},
+		},
+	},
+	{
+		Name: "toys", Description: "おもちゃ・ゲーム", LifestyleTag: "gamer",
+		Features: map[string][]string{"age": {"3+", "6+", "12+", "18+"}, "players": {"1", "2-4", "2-6"}},
+		Subcategories: []subcategorySpec{
+			{Name: "board_games", Description: "ボードゲーム", Nouns: []string{"ボードゲーム", "カードゲーム"}, Brands: []string{"Hasbro", "Kosmos", "Arclight"}, BasePrice: 4000, Tags: []string{"board_game", "family"}},
+			{Name: "video_games", Description: "ビデオゲーム", Nouns: []string{"ゲームソフト"}, Brands: []string{"Nintendo", "Square Enix", "Capcom", "Bandai Namco"}, BasePrice: 6500, Tags: []string{"video_game", "gaming"}},
+			{Name: "educational_toys", Description: "知育玩具", Nouns: []string{"ブロック", "知育パズル"}, Brands: []string{"LEGO", "KUMON", "Ed.Inter"}, BasePrice: 5000, Tags: []string{"educational", "kids"}},
+			{Name: "action_figures", Description: "フィギュア", Nouns: []string{"フィギュア", "プラモデル"}, Brands: []string{"Bandai", "Good Smile Company", "Kotobukiya"}, BasePrice: 8000, Tags: []string{"figure", "collectible"}},
+		},
+	},
+	{
+		Name: "health", Description: "ヘルス・医療", LifestyleTag: "health_conscious", Consumable: true,
+		Features: map[string][]string{"type": {"digital", "manual"}, "certification": {"medical", "general"}},
+		Subcategories: []subcategorySpec{
+			{Name: "medical_devices", Description: "医療機器", Nouns: []string{"血圧計", "体温計"}, Brands: []string{"OMRON", "Terumo", "Tanita"}, BasePrice: 6000, Tags: []string{"medical", "health"}},
+			{Name: "wellness", Description: "ウェルネス", Nouns: []string{"マッサージ器", "アロマディフューザー"}, Brands: []string{"Panasonic", "MTG", "無印良品"}, BasePrice: 9000, Tags: []string{"wellness", "relax"}},
+			{Name: "vitamins", Description: "ビタミン・栄養", Nouns: []string{"ビタミンC", "鉄分サプリ"}, Brands: []string{"DHC", "FANCL", "Nature Made"}, BasePrice: 1500, Tags: []string{"vitamin", "health"}},
+		},
+	},
+	{
+		Name: "automotive", Description: "自動車・バイク", LifestyleTag: "practical",
+		Features: map[string][]string{"compatibility": {"universal", "sedan", "suv"}, "color": {"black", "gray"}},
+		Subcategories: []subcategorySpec{
+			{Name: "car_accessories", Description: "カー用品", Nouns: []string{"ドライブレコーダー", "スマホホルダー"}, Brands: []string{"Comtec", "Yupiteru", "Anker"}, BasePrice: 12000, Tags: []string{"car", "accessory"}},
+			{Name: "maintenance", Description: "メンテナンス用品", Nouns: []string{"カーワックス", "エンジンオイル"}, Brands: []string{"Soft99", "Castrol", "Prostaff"}, BasePrice: 3000, Tags: []string{"car", "maintenance"}},
+		},
+	},
+}
+
+// Customer attributes, matching the value sets used by db/02_customers_extended.sql
+var (
+	firstNamesMale   = []string{"太郎", "次郎", "健太", "大輔", "翔太", "和也", "直樹", "拓也", "裕介", "雄大", "誠", "浩", "剛", "悠斗", "蓮"}
+	firstNamesFemale = []string{"花子", "美咲", "愛子", "麻衣", "由美", "千尋", "理恵", "涼子", "香織", "優子", "直子", "陽菜", "結衣", "さくら", "葵"}
+	lastNames        = []string{"田中", "佐藤", "山田", "渡辺", "鈴木", "高橋", "伊藤", "小林", "中村", "加藤", "吉田", "松本", "井上", "木村", "山本", "斎藤", "清水", "山口", "石川", "森"}
+	emailDomains     = []string{"example.com", "test.jp", "demo.co.jp", "sample.net"}
+	prefectures      = []string{"Tokyo", "Osaka", "Kanagawa", "Aichi", "Saitama", "Chiba", "Hyogo", "Hokkaido", "Fukuoka", "Shizuoka", "Hiroshima", "Kyoto", "Miyagi"}
+	cities           = []string{"Central", "North", "South", "East", "West", "Shibuya", "Shinjuku", "Umeda", "Namba", "Yokohama", "Sakae", "Tenjin"}
+	extraLifestyle   = []string{"urban", "professional", "student", "family", "trendy", "eco_friendly", "creative", "practical"}
+	paymentMethods   = []string{"credit_card", "bank_transfer", "mobile_payment"}
+	modelSuffixes    = []string{"Pro", "Lite", "Plus", "Air", "Max", "Mini", "SE", "Classic"}
+)
+
+// Review wording by rating, lowest first
+var (
+	reviewTitles = [][]string{
+		{"期待外れでした", "おすすめできません"},
+		{"いまひとつです", "少し残念でした"},
+		{"普通です", "価格相応です"},
+		{"良い商品です", "使いやすいです", "満足しています"},
+		{"大満足です", "おすすめです", "期待以上でした"},
+	}
+	reviewContents = [][]string{
+		{"すぐに壊れてしまいました。", "説明と実物がかなり違いました。"},
+		{"品質にばらつきがあるように感じます。", "使い勝手が思っていたより悪かったです。"},
+		{"可もなく不可もなくといった印象です。", "値段を考えれば妥当だと思います。"},
+		{"デザインも機能も満足しています。", "日常的に活用しています。", "価格に見合った良い商品だと思います。"},
+		{"品質が高く、期待を上回る性能でした。", "友人にもおすすめしたい商品です。", "リピート購入を考えています。"},
+	}
+)
@@ -0,0 +1,1000 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// Options controls the scale and time range of the generated data
+type Options struct {
+	Seed                int64
+	Customers           int
+	ProductsPerCategory int       // Products generated in each subcategory
+	Days                int       // Length of the order history
+	End                 time.Time // Latest timestamp in the data
+	OrdersPerCustomer   float64   // Mean orders of an average customer over the whole history
+	SessionsPerCustomer float64   // Mean browsing sessions of an average customer over the whole history
+}
+
+// Summary counts the generated rows
+type Summary struct {
+	Categories    int
+	Products      int
+	Customers     int
+	Orders        int
+	OrderItems    int
+	Reviews       int
+	Activities    int
+	CartItems     int
+	WishlistItems int
+}
+
+type category struct {
+	ID          int
+	ParentID    *int // nil for top-level categories
+	Name        string
+	Description string
+}
+
+type product struct {
+	ID            uuid.UUID
+	Name          string
+	Description   string
+	CategoryID    int
+	Brand         string
+	SKU           string
+	Price         float64
+	OriginalPrice *float64 // nil when not discounted
+	Stock         int
+	Weight        float64
+	Features      string
+	Tags          []string
+	Active        bool
+	CreatedAt     time.Time
+
+	quality     float64 // Latent mean review rating
+	appeal      float64 // Latent attractiveness independent of the customer
+	ratingSum   int
+	ratingCount int
+	recentUnits int // Units sold in the last 90 days, for popularity_score
+}
+
+type customer struct {
+	ID                  uuid.UUID
+	Email               string
+	FirstName           string
+	LastName            string
+	Phone               string
+	Gender              string
+	BirthDate           time.Time
+	Location            string
+	PreferredCategories []int64
+	PriceRangeMin       float64
+	PriceRangeMax       float64
+	PreferredBrands     []string
+	LifestyleTags       []string
+	CreatedAt           time.Time
+
+	affinity   []float64 // Latent purchase probability per subcategory
+	brands     map[string]bool
+	budget     float64 // Latent typical spend per item in yen
+	activity   float64 // Latent multiplier on order and browsing frequency
+	premium    bool
+	owned      map[int]bool  // Product indexes bought so far
+	ownedBySub map[int][]int // Product indexes bought so far, by subcategory index
+	orders     []int         // Order indexes, oldest first
+	totalSpent float64
+	orderCount int
+}
+
+type order struct {
+	ID          uuid.UUID
+	Customer    int
+	Number      string
+	Status      string
+	Payment     string
+	OrderedAt   time.Time
+	ShippedAt   *time.Time
+	DeliveredAt *time.Time
+	Items       []orderItem
+	Subtotal    float64
+	Tax         float64
+	Shipping    float64
+	Total       float64
+}
+
+type orderItem struct {
+	ID        uuid.UUID
+	Product   int
+	Quantity  int
+	UnitPrice float64
+}
+
+type review struct {
+	ID        uuid.UUID
+	Product   int
+	Customer  int
+	Order     uuid.UUID
+	Rating    int
+	Title     string
+	Content   string
+	Helpful   int
+	Votes     int
+	CreatedAt time.Time
+}
+
+type activity struct {
+	Type        string
+	Product     int // -1 for searches
+	SearchQuery string
+	SessionID   uuid.UUID
+	CreatedAt   time.Time
+}
+
+type savedItem struct {
+	Customer int
+	Product  int
+	Quantity int
+	AddedAt  time.Time
+}
+
+// Generator builds a reproducible synthetic e-commerce dataset. Every random choice is drawn
+// from one seeded source in a fixed order, so the same options always produce the same rows.
+type Generator struct {
+	opts  Options
+	rng   *rand.Rand
+	start time.Time
+
+	categories    []category
+	subcategories []*subcategorySpec // Indexed like productsBySub
+	subTop        []int              // Top-level category index of each subcategory
+	subIDs        []int              // Category ID of each subcategory
+	products      []*product
+	productsBySub [][]int
+	customers     []*customer
+	orders        []*order
+	reviews       []review
+	summary       Summary
+}
+
+// NewGenerator creates a generator for the given options
+func NewGenerator(opts Options) *Generator {
+	return &Generator{
+		opts:  opts,
+		rng:   rand.New(rand.NewSource(opts.Seed)),
+		start: opts.End.AddDate(0, 0, -opts.Days),
+	}
+}
+
+// Generate builds the dataset and writes it to the sink in foreign key order
+func (g *Generator) Generate(sink Sink) (*Summary, error) {
+	g.buildCatalog()
+	g.buildCustomers()
+	g.buildOrders()
+
+	writers := []func(Sink) error{
+		g.writeCategories,
+		g.writeProducts,
+		g.writeCustomers,
+		g.writeOrders,
+		g.writeOrderItems,
+		g.writeReviews,
+	}
+	for _, write := range writers {
+		if err := write(sink); err != nil {
+			return nil, err
+		}
+	}
+
+	// Activities are streamed; carts and wishlists are collected from them
+	carts, wishlists, err := g.writeActivities(sink)
+	if err != nil {
+		return nil, err
+	}
+	if err := g.writeSavedItems(sink, "cart_items", carts); err != nil {
+		return nil, err
+	}
+	if err := g.writeSavedItems(sink, "wishlist_items", wishlists); err != nil {
+		return nil, err
+	}
+
+	return &g.summary, nil
+}
+
+// buildCatalog creates categories with the seed data's IDs (top-level categories first, then
+// subcategories in order) and the products under each subcategory
+func (g *Generator) buildCatalog() {
+	for i, spec := range catalogSpecs {
+		g.categories = append(g.categories, category{ID: i + 1, Name: spec.Name, Description: spec.Description})
+	}
+
+	for top := range catalogSpecs {
+		spec := &catalogSpecs[top]
+		for s := range spec.Subcategories {
+			sub := &spec.Subcategories[s]
+			id := len(g.categories) + 1
+			parentID := top + 1
+			g.categories = append(g.categories, category{ID: id, ParentID: &parentID, Name: sub.Name, Description: sub.Description})
+			g.subcategories = append(g.subcategories, sub)
+			g.subTop = append(g.subTop, top)
+			g.subIDs = append(g.subIDs, id)
+		}
+	}
+
+	g.productsBySub = make([][]int, len(g.subcategories))
+	for s, sub := range g.subcategories {
+		for i := 0; i < g.opts.ProductsPerCategory; i++ {
+			g.productsBySub[s] = append(g.productsBySub[s], len(g.products))
+			g.products = append(g.products, g.newProduct(s, sub))
+		}
+	}
+}
+
+// newProduct draws one product of a subcategory
+func (g *Generator) newProduct(s int, sub *subcategorySpec) *product {
+	spec := &catalogSpecs[g.subTop[s]]
+	brand := pick(g.rng, sub.Brands)
+	noun := pick(g.rng, sub.Nouns)
+	model := fmt.Sprintf("%c%d", 'A'+rune(g.rng.Intn(26)), 100+g.rng.Intn(900))
+	if g.rng.Float64() < 0.4 {
+		model += " " + pick(g.rng, modelSuffixes)
+	}
+
+	price := roundPrice(sub.BasePrice * math.Exp(g.rng.NormFloat64()*0.45))
+	tier, description := "standard", "定番モデル"
+	switch {
+	case price > sub.BasePrice*1.3:
+		tier, description = "premium", "上位モデル"
+	case price < sub.BasePrice*0.75:
+		tier, description = "budget", "お手頃モデル"
+	}
+
+	p := &product{
+		ID:          g.newUUID(),
+		Name:        fmt.Sprintf("%s %s %s", brand, noun, model),
+		Description: fmt.Sprintf("%sの%s。%sの%s", brand, noun, sub.Description, description),
+		CategoryID:  g.subIDs[s],
+		Brand:       brand,
+		SKU:         fmt.Sprintf("%s-%06d", strings.ToUpper(sub.Name[:3]), len(g.products)+1),
+		Price:       price,
+		Stock:       g.rng.Intn(200),
+		Weight:      math.Round((0.1+g.rng.Float64()*4.9)*100) / 100,
+		Tags:        append(append([]string{}, sub.Tags...), tier),
+		Active:      g.rng.Float64() >= 0.03,
+		quality:     clamp(3.8+g.rng.NormFloat64()*0.5, 2.5, 4.9),
+		appeal:      math.Exp(g.rng.NormFloat64()),
+	}
+
+	if g.rng.Float64() < 0.35 {
+		originalPrice := roundPrice(price * (1.1 + g.rng.Float64()*0.3))
+		p.OriginalPrice = &originalPrice
+	}
+	if g.rng.Float64() < 0.05 {
+		p.Stock = 0
+	}
+
+	// Most products predate the history; the rest launch during it
+	if g.rng.Float64() < 0.8 {
+		p.CreatedAt = g.start.Add(-randomDuration(g.rng, 365*24*time.Hour))
+	} else {
+		p.CreatedAt = g.start.Add(randomDuration(g.rng, g.opts.End.Sub(g.start)*9/10))
+	}
+
+	features := map[string]interface{}{"model_year": p.CreatedAt.Year()}
+	keys := make([]string, 0, len(spec.Features))
+	for key := range spec.Features {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		features[key] = pick(g.rng, spec.Features[key])
+	}
+	data, _ := json.Marshal(features)
+	p.Features = string(data)
+
+	return p
+}
+
+// buildCustomers draws customers with latent category, brand and price preferences. The
+// declared preferences stored on the customer row are a noisy view of the latent ones.
+func (g *Generator) buildCustomers() {
+	for i := 0; i < g.opts.Customers; i++ {
+		c := &customer{
+			ID:         g.newUUID(),
+			Gender:     "male",
+			LastName:   pick(g.rng, lastNames),
+			brands:     make(map[string]bool),
+			owned:      make(map[int]bool),
+			ownedBySub: make(map[int][]int),
+			activity:   math.Exp(g.rng.NormFloat64() * 0.7),
+		}
+
+		switch r := g.rng.Float64(); {
+		case r < 0.49:
+			c.FirstName = pick(g.rng, firstNamesMale)
+		case r < 0.98:
+			c.Gender = "female"
+			c.FirstName = pick(g.rng, firstNamesFemale)
+		default:
+			c.Gender = "other"
+			c.FirstName = pick(g.rng, firstNamesFemale)
+		}
+
+		c.Email = fmt.Sprintf("user%06d@%s", i+1, pick(g.rng, emailDomains))
+		c.Phone = fmt.Sprintf("%s-%04d-%04d", pick(g.rng, []string{"090", "080", "070"}), g.rng.Intn(10000), g.rng.Intn(10000))
+		age := 18 + g.rng.Intn(50)
+		c.BirthDate = g.opts.End.AddDate(-age, 0, -g.rng.Intn(365)).Truncate(24 * time.Hour)
+		location, _ := json.Marshal(map[string]string{"prefecture": pick(g.rng, prefectures), "city": pick(g.rng, cities)})
+		c.Location = string(location)
+		c.CreatedAt = g.start.Add(randomDuration(g.rng, g.opts.End.Sub(g.start)*8/10))
+
+		// Older customers spend more per item; a small premium segment spends much more
+		c.budget = 8000 * math.Exp(g.rng.NormFloat64()*0.7) * (1 + float64(age-18)/40)
+		if g.rng.Float64() < 0.1 {
+			c.premium = true
+			c.budget *= 2.5
+		}
+		c.PriceRangeMin = roundTo(c.budget*0.2, 1000)
+		c.PriceRangeMax = roundTo(c.budget*4, 1000)
+
+		favorites := g.drawAffinity(c)
+		g.drawDeclaredPreferences(c, favorites)
+
+		g.customers = append(g.customers, c)
+	}
+}
+
+// drawAffinity gives the customer two to four favorite subcategories, mostly within one
+// top-level category, and returns them by descending weight
+func (g *Generator) drawAffinity(c *customer) []int {
+	primary := g.rng.Intn(len(catalogSpecs))
+	var sameTop []int
+	for s, top := range g.subTop {
+		if top == primary {
+			sameTop = append(sameTop, s)
+		}
+	}
+
+	count := 2 + g.rng.Intn(3)
+	weights := make(map[int]float64)
+	var favorites []int
+	for len(favorites) < count {
+		s := g.rng.Intn(len(g.subcategories))
+		if g.rng.Float64() < 0.7 {
+			s = pick(g.rng, sameTop)
+		}
+		if _, ok := weights[s]; ok {
+			continue
+		}
+		weights[s] = g.rng.ExpFloat64()
+		favorites = append(favorites, s)
+	}
+	sort.SliceStable(favorites, func(i, j int) bool {
+		return weights[favorites[i]] > weights[favorites[j]]
+	})
+
+	// 85% of purchases come from favorites, the rest from anywhere
+	var total float64
+	for _, s := range favorites {
+		total += weights[s]
+	}
+	c.affinity = make([]float64, len(g.subcategories))
+	for s := range c.affinity {
+		c.affinity[s] = 0.15 / float64(len(g.subcategories))
+	}
+	for _, s := range favorites {
+		c.affinity[s] += 0.85 * weights[s] / total
+	}
+
+	return favorites
+}
+
+// drawDeclaredPreferences fills the preference columns of the customer row
+func (g *Generator) drawDeclaredPreferences(c *customer, favorites []int) {
+	for i, s := range favorites {
+		if i >= 3 {
+			break
+		}
+		id := g.subIDs[s]
+		if i > 0 && g.rng.Float64() < 0.2 {
+			id = pick(g.rng, g.subIDs)
+		}
+		c.PreferredCategories = append(c.PreferredCategories, int64(id))
+	}
+
+	for i, s := range favorites {
+		if i >= 2 {
+			break
+		}
+		brand := pick(g.rng, g.subcategories[s].Brands)
+		if !c.brands[brand] {
+			c.brands[brand] = true
+			c.PreferredBrands = append(c.PreferredBrands, brand)
+		}
+	}
+	if g.rng.Float64() < 0.3 {
+		brand := pick(g.rng, pick(g.rng, g.subcategories).Brands)
+		if !c.brands[brand] {
+			c.brands[brand] = true
+			c.PreferredBrands = append(c.PreferredBrands, brand)
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, s := range favorites {
+		tag := catalogSpecs[g.subTop[s]].LifestyleTag
+		if !seen[tag] {
+			seen[tag] = true
+			c.LifestyleTags = append(c.LifestyleTags, tag)
+		}
+	}
+	if tag := pick(g.rng, extraLifestyle); g.rng.Float64() < 0.6 && !seen[tag] {
+		c.LifestyleTags = append(c.LifestyleTags, tag)
+	}
+	if c.premium {
+		c.LifestyleTags = append(c.LifestyleTags, "premium")
+	}
+}
+
+// buildOrders draws each customer's orders over their lifetime, then numbers all orders
+// chronologically and derives reviews, customer totals and product popularity from them
+func (g *Generator) buildOrders() {
+	history := g.opts.End.Sub(g.start)
+
+	for ci, c := range g.customers {
+		lifetime := g.opts.End.Sub(c.CreatedAt)
+		count := poisson(g.rng, g.opts.OrdersPerCustomer*c.activity*float64(lifetime)/float64(history))
+
+		times := make([]time.Time, count)
+		for i := range times {
+			times[i] = c.CreatedAt.Add(randomDuration(g.rng, lifetime))
+		}
+		sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+
+		for _, orderedAt := range times {
+			if o := g.newOrder(ci, c, orderedAt); o != nil {
+				g.orders = append(g.orders, o)
+			}
+		}
+	}
+
+	sort.SliceStable(g.orders, func(i, j int) bool {
+		return g.orders[i].OrderedAt.Before(g.orders[j].OrderedAt)
+	})
+
+	recentSince := g.opts.End.AddDate(0, 0, -90)
+	for oi, o := range g.orders {
+		o.Number = fmt.Sprintf("ORD-%08d", oi+1)
+		c := g.customers[o.Customer]
+		c.orders = append(c.orders, oi)
+
+		if o.Status == "cancelled" || o.Status == "returned" {
+			continue
+		}
+		c.totalSpent += o.Total
+		c.orderCount++
+
+		for _, item := range o.Items {
+			if !o.OrderedAt.Before(recentSince) {
+				g.products[item.Product].recentUnits += item.Quantity
+			}
+			if o.Status == "delivered" && g.rng.Float64() < 0.3 {
+				g.addReview(o, item)
+			}
+		}
+	}
+}
+
+// newOrder draws the items and fulfilment state of one order, or nil when no product fits
+func (g *Generator) newOrder(ci int, c *customer, orderedAt time.Time) *order {
+	o := &order{
+		ID:        g.newUUID(),
+		Customer:  ci,
+		OrderedAt: orderedAt,
+		Payment:   paymentMethods[weightedIndex(g.rng, []float64{0.7, 0.2, 0.1})],
+	}
+
+	inOrder := make(map[int]bool)
+	itemCount := 1 + poisson(g.rng, 0.5)
+	if itemCount > 5 {
+		itemCount = 5
+	}
+	for len(o.Items) < itemCount {
+		s := weightedIndex(g.rng, c.affinity)
+		p := g.choosePurchase(c, s, orderedAt)
+		if p < 0 || inOrder[p] {
+			// Give up on the remaining items rather than retrying indefinitely
+			if len(o.Items) > 0 || p < 0 {
+				break
+			}
+			continue
+		}
+		inOrder[p] = true
+
+		quantity := 1
+		if catalogSpecs[g.subTop[s]].Consumable {
+			quantity += g.rng.Intn(3)
+		} else if g.rng.Float64() < 0.05 {
+			quantity = 2
+		}
+		o.Items = append(o.Items, orderItem{ID: g.newUUID(), Product: p, Quantity: quantity, UnitPrice: g.products[p].Price})
+
+		if !c.owned[p] {
+			c.owned[p] = true
+			c.ownedBySub[s] = append(c.ownedBySub[s], p)
+		}
+	}
+	if len(o.Items) == 0 {
+		return nil
+	}
+
+	for _, item := range o.Items {
+		o.Subtotal += item.UnitPrice * float64(item.Quantity)
+	}
+	o.Tax = math.Round(o.Subtotal * 0.1)
+	if o.Subtotal < 5000 {
+		o.Shipping = 500
+	}
+	o.Total = o.Subtotal + o.Tax + o.Shipping
+
+	g.fulfil(o)
+	return o
+}
+
+// choosePurchase picks a product of the subcategory for the customer. Consumables are often
+// re-bought; otherwise products are weighted by appeal, brand preference and price fit.
+func (g *Generator) choosePurchase(c *customer, s int, at time.Time) int {
+	if owned := c.ownedBySub[s]; len(owned) > 0 && catalogSpecs[g.subTop[s]].Consumable && g.rng.Float64() < 0.4 {
+		return pick(g.rng, owned)
+	}
+
+	candidates := g.productsBySub[s]
+	weights := make([]float64, len(candidates))
+	var total float64
+	for i, p := range candidates {
+		product := g.products[p]
+		if product.CreatedAt.After(at) {
+			continue
+		}
+
+		// Log-normal price fit around the customer's budget
+		distance := math.Log(product.Price / c.budget)
+		weight := product.appeal * math.Exp(-distance*distance/2)
+		if c.brands[product.Brand] {
+			weight *= 2
+		}
+		if c.owned[p] {
+			weight *= 0.05
+		}
+		weights[i] = weight
+		total += weight
+	}
+	if total == 0 {
+		return -1
+	}
+
+	return candidates[weightedIndex(g.rng, weights)]
+}
+
+// fulfil sets the order status and shipping timestamps from its age at the end of the history
+func (g *Generator) fulfil(o *order) {
+	age := g.opts.End.Sub(o.OrderedAt)
+	day := 24 * time.Hour
+
+	shippedAt := o.OrderedAt.Add(day + randomDuration(g.rng, day))
+	deliveredAt := shippedAt.Add(day + randomDuration(g.rng, 2*day))
+
+	switch {
+	case age < day:
+		o.Status = "pending"
+	case age < 2*day:
+		o.Status = "confirmed"
+	case age < 3*day:
+		o.Status = "processing"
+	case age < 7*day || deliveredAt.After(g.opts.End):
+		o.Status = "shipped"
+		o.ShippedAt = &shippedAt
+	default:
+		switch r := g.rng.Float64(); {
+		case r < 0.03:
+			o.Status = "cancelled"
+		case r < 0.06:
+			o.Status = "returned"
+			o.ShippedAt = &shippedAt
+			o.DeliveredAt = &deliveredAt
+		default:
+			o.Status = "delivered"
+			o.ShippedAt = &shippedAt
+			o.DeliveredAt = &deliveredAt
+		}
+	}
+}
+
+// addReview draws a verified review whose rating centres on the product's latent quality
+func (g *Generator) addReview(o *order, item orderItem) {
+	createdAt := o.DeliveredAt.Add(randomDuration(g.rng, 14*24*time.Hour))
+	if createdAt.After(g.opts.End) {
+		return
+	}
+
+	p := g.products[item.Product]
+	rating := int(clamp(math.Round(p.quality+g.rng.NormFloat64()*0.8), 1, 5))
+	votes := poisson(g.rng, 3)
+
+	g.reviews = append(g.reviews, review{
+		ID:        g.newUUID(),
+		Product:   item.Product,
+		Customer:  o.Customer,
+		Order:     o.ID,
+		Rating:    rating,
+		Title:     pick(g.rng, reviewTitles[rating-1]),
+		Content:   pick(g.rng, reviewContents[rating-1]),
+		Votes:     votes,
+		Helpful:   g.rng.Intn(votes + 1),
+		CreatedAt: createdAt,
+	})
+	p.ratingSum += rating
+	p.ratingCount++
+}
+
+func (g *Generator) writeCategories(sink Sink) error {
+	g.summary.Categories = len(g.categories)
+	return sink.WriteTable("categories", []string{"id", "parent_id", "name", "description"}, func(emit func(values ...interface{}) error) error {
+		for _, c := range g.categories {
+			if err := emit(c.ID, nullable(c.ParentID), c.Name, c.Description); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (g *Generator) writeProducts(sink Sink) error {
+	// popularity_score scales recent units sold logarithmically to 0-100
+	var maxUnits int
+	for _, p := range g.products {
+		if p.recentUnits > maxUnits {
+			maxUnits = p.recentUnits
+		}
+	}
+
+	g.summary.Products = len(g.products)
+	columns := []string{"id", "name", "description", "category_id", "price", "original_price", "brand", "sku", "stock_quantity",
+		"weight", "features", "tags", "rating_average", "rating_count", "popularity_score", "is_active", "created_at", "updated_at"}
+	return sink.WriteTable("products", columns, func(emit func(values ...interface{}) error) error {
+		for _, p := range g.products {
+			var ratingAverage float64
+			if p.ratingCount > 0 {
+				ratingAverage = math.Round(float64(p.ratingSum)/float64(p.ratingCount)*100) / 100
+			}
+			var popularity int
+			if maxUnits > 0 {
+				popularity = int(math.Round(100 * math.Log1p(float64(p.recentUnits)) / math.Log1p(float64(maxUnits))))
+			}
+
+			if err := emit(p.ID, p.Name, p.Description, p.CategoryID, p.Price, nullable(p.OriginalPrice), p.Brand, p.SKU, p.Stock,
+				p.Weight, p.Features, pq.Array(p.Tags), ratingAverage, p.ratingCount, popularity, p.Active, p.CreatedAt, p.CreatedAt); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (g *Generator) writeCustomers(sink Sink) error {
+	g.summary.Customers = len(g.customers)
+	columns := []string{"id", "email", "first_name", "last_name", "phone", "date_of_birth", "gender", "preferred_language",
+		"preferred_categories", "price_range_min", "price_range_max", "preferred_brands", "location", "lifestyle_tags",
+		"is_premium", "total_spent", "order_count", "created_at", "updated_at"}
+	return sink.WriteTable("customers", columns, func(emit func(values ...interface{}) error) error {
+		for _, c := range g.customers {
+			premium := c.premium || c.totalSpent > 300000
+			if err := emit(c.ID, c.Email, c.FirstName, c.LastName, c.Phone, c.BirthDate.Format("2006-01-02"), c.Gender, "ja",
+				pq.Int64Array(c.PreferredCategories), c.PriceRangeMin, c.PriceRangeMax, pq.Array(c.PreferredBrands), c.Location,
+				pq.Array(c.LifestyleTags), premium, math.Round(c.totalSpent*100)/100, c.orderCount, c.CreatedAt, c.CreatedAt); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (g *Generator) writeOrders(sink Sink) error {
+	g.summary.Orders = len(g.orders)
+	columns := []string{"id", "customer_id", "order_number", "status", "subtotal", "tax_amount", "shipping_fee", "discount_amount",
+		"total_amount", "payment_method", "shipping_address", "ordered_at", "shipped_at", "delivered_at", "created_at", "updated_at"}
+	return sink.WriteTable("orders", columns, func(emit func(values ...interface{}) error) error {
+		for _, o := range g.orders {
+			c := g.customers[o.Customer]
+			updatedAt := o.OrderedAt
+			if o.DeliveredAt != nil {
+				updatedAt = *o.DeliveredAt
+			} else if o.ShippedAt != nil {
+				updatedAt = *o.ShippedAt
+			}
+
+			if err := emit(o.ID, c.ID, o.Number, o.Status, o.Subtotal, o.Tax, o.Shipping, 0.0,
+				o.Total, o.Payment, c.Location, o.OrderedAt, nullable(o.ShippedAt), nullable(o.DeliveredAt), o.OrderedAt, updatedAt); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (g *Generator) writeOrderItems(sink Sink) error {
+	columns := []string{"id", "order_id", "product_id", "quantity", "unit_price", "total_price", "created_at"}
+	return sink.WriteTable("order_items", columns, func(emit func(values ...interface{}) error) error {
+		for _, o := range g.orders {
+			for _, item := range o.Items {
+				g.summary.OrderItems++
+				if err := emit(item.ID, o.ID, g.products[item.Product].ID, item.Quantity, item.UnitPrice,
+					item.UnitPrice*float64(item.Quantity), o.OrderedAt); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+func (g *Generator) writeReviews(sink Sink) error {
+	g.summary.Reviews = len(g.reviews)
+	columns := []string{"id", "product_id", "customer_id", "order_id", "rating", "title", "content", "is_verified_purchase",
+		"helpful_votes", "total_votes", "created_at", "updated_at"}
+	return sink.WriteTable("product_reviews", columns, func(emit func(values ...interface{}) error) error {
+		for _, r := range g.reviews {
+			if err := emit(r.ID, g.products[r.Product].ID, g.customers[r.Customer].ID, r.Order, r.Rating, r.Title, r.Content, true,
+				r.Helpful, r.Votes, r.CreatedAt, r.CreatedAt); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// writeActivities streams each customer's activities in time order: views and add-to-cart
+// events leading up to every order, plus browsing sessions in favored subcategories that
+// include searches and wishlist and cart additions. It returns the cart and wishlist items
+// left at the end of the history.
+func (g *Generator) writeActivities(sink Sink) ([]savedItem, []savedItem, error) {
+	var carts, wishlists []savedItem
+
+	columns := []string{"id", "customer_id", "activity_type", "product_id", "search_query", "session_id", "created_at"}
+	err := sink.WriteTable("customer_activities", columns, func(emit func(values ...interface{}) error) error {
+		for ci, c := range g.customers {
+			activities, cart, wishlist := g.customerActivities(ci, c)
+			carts = append(carts, cart...)
+			wishlists = append(wishlists, wishlist...)
+
+			for _, a := range activities {
+				var productID, searchQuery interface{}
+				if a.Product >= 0 {
+					productID = g.products[a.Product].ID
+				}
+				if a.SearchQuery != "" {
+					searchQuery = a.SearchQuery
+				}
+
+				g.summary.Activities++
+				if err := emit(g.newUUID(), c.ID, a.Type, productID, searchQuery, a.SessionID, a.CreatedAt); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return carts, wishlists, nil
+}
+
+// customerActivities draws one customer's activities and their resulting cart and wishlist
+func (g *Generator) customerActivities(ci int, c *customer) ([]activity, []savedItem, []savedItem) {
+	var activities []activity
+	var cart, wishlist []savedItem
+	inCart := make(map[int]bool)
+	inWishlist := make(map[int]bool)
+	cartSince := g.opts.End.AddDate(0, 0, -14)
+
+	for _, oi := range c.orders {
+		o := g.orders[oi]
+		session := g.newUUID()
+		for _, item := range o.Items {
+			viewedAt := o.OrderedAt.Add(-randomDuration(g.rng, 3*time.Hour))
+			activities = append(activities,
+				activity{Type: "view", Product: item.Product, SessionID: session, CreatedAt: viewedAt},
+				activity{Type: "add_to_cart", Product: item.Product, SessionID: session, CreatedAt: viewedAt.Add(randomDuration(g.rng, o.OrderedAt.Sub(viewedAt)))},
+			)
+		}
+	}
+
+	lifetime := g.opts.End.Sub(c.CreatedAt)
+	sessions := poisson(g.rng, g.opts.SessionsPerCustomer*c.activity*float64(lifetime)/float64(g.opts.End.Sub(g.start)))
+	for i := 0; i < sessions; i++ {
+		// Skew sessions towards the recent end of the customer's lifetime
+		at := c.CreatedAt.Add(time.Duration(math.Sqrt(g.rng.Float64()) * float64(lifetime)))
+		session := g.newUUID()
+		s := weightedIndex(g.rng, c.affinity)
+		sub := g.subcategories[s]
+
+		if g.rng.Float64() < 0.4 {
+			query := pick(g.rng, sub.Nouns)
+			if g.rng.Float64() < 0.3 {
+				query = pick(g.rng, sub.Brands) + " " + query
+			}
+			activities = append(activities, activity{Type: "search", Product: -1, SearchQuery: query, SessionID: session, CreatedAt: at})
+		}
+
+		views := 1 + poisson(g.rng, 1.5)
+		for v := 0; v < views; v++ {
+			at = at.Add(randomDuration(g.rng, 5*time.Minute))
+			if at.After(g.opts.End) {
+				break
+			}
+			p := g.browse(s, at)
+			if p < 0 {
+				break
+			}
+			activities = append(activities, activity{Type: "view", Product: p, SessionID: session, CreatedAt: at})
+
+			switch r := g.rng.Float64(); {
+			case r < 0.08 && !c.owned[p] && !inWishlist[p]:
+				inWishlist[p] = true
+				activities = append(activities, activity{Type: "wishlist_add", Product: p, SessionID: session, CreatedAt: at})
+				wishlist = append(wishlist, savedItem{Customer: ci, Product: p, AddedAt: at})
+			case r < 0.14 && !c.owned[p] && !inCart[p] && !inWishlist[p]:
+				activities = append(activities, activity{Type: "add_to_cart", Product: p, SessionID: session, CreatedAt: at})
+				if at.Before(cartSince) {
+					// Older carts were abandoned and cleared
+					activities = append(activities, activity{Type: "remove_from_cart", Product: p, SessionID: session, CreatedAt: at.Add(time.Minute)})
+					continue
+				}
+				inCart[p] = true
+				cart = append(cart, savedItem{Customer: ci, Product: p, Quantity: 1 + g.rng.Intn(2), AddedAt: at})
+			}
+		}
+	}
+
+	sort.SliceStable(activities, func(i, j int) bool {
+		return activities[i].CreatedAt.Before(activities[j].CreatedAt)
+	})
+
+	return activities, cart, wishlist
+}
+
+// browse picks a product in the subcategory weighted by appeal only
+func (g *Generator) browse(s int, at time.Time) int {
+	candidates := g.productsBySub[s]
+	weights := make([]float64, len(candidates))
+	var total float64
+	for i, p := range candidates {
+		if product := g.products[p]; product.Active && !product.CreatedAt.After(at) {
+			weights[i] = product.appeal
+			total += weights[i]
+		}
+	}
+	if total == 0 {
+		return -1
+	}
+	return candidates[weightedIndex(g.rng, weights)]
+}
+
+func (g *Generator) writeSavedItems(sink Sink, table string, items []savedItem) error {
+	columns := []string{"id", "customer_id", "product_id", "added_at"}
+	if table == "cart_items" {
+		columns = []string{"id", "customer_id", "product_id", "quantity", "added_at", "updated_at"}
+		g.summary.CartItems = len(items)
+	} else {
+		g.summary.WishlistItems = len(items)
+	}
+
+	return sink.WriteTable(table, columns, func(emit func(values ...interface{}) error) error {
+		for _, item := range items {
+			values := []interface{}{g.newUUID(), g.customers[item.Customer].ID, g.products[item.Product].ID}
+			if table == "cart_items" {
+				values = append(values, item.Quantity, item.AddedAt, item.AddedAt)
+			} else {
+				values = append(values, item.AddedAt)
+			}
+			if err := emit(values...); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// newUUID draws a version 4 UUID from the seeded source
+func (g *Generator) newUUID() uuid.UUID {
+	return uuid.Must(uuid.NewRandomFromReader(g.rng))
+}
+
+// nullable dereferences optional values so that nil pointers are written as NULL
+func nullable[T any](value *T) interface{} {
+	if value == nil {
+		return nil
+	}
+	return *value
+}
+
+// pick returns a uniformly chosen element
+func pick[T any](rng *rand.Rand, values []T) T {
+	return values[rng.Intn(len(values))]
+}
+
+// weightedIndex returns an index with probability proportional to its weight
+func weightedIndex(rng *rand.Rand, weights []float64) int {
+	var total float64
+	for _, w := range weights {
+		total += w
+	}
+
+	target := rng.Float64() * total
+	for i, w := range weights {
+		if target < w {
+			return i
+		}
+		target -= w
+	}
+
+	// Rounding can leave the target just past the last positive weight
+	for i := len(weights) - 1; i >= 0; i-- {
+		if weights[i] > 0 {
+			return i
+		}
+	}
+	return len(weights) - 1
+}
+
+// poisson draws a Poisson-distributed count, using a normal approximation for large means
+func poisson(rng *rand.Rand, mean float64) int {
+	if mean <= 0 {
+		return 0
+	}
+	if mean > 30 {
+		return int(math.Max(0, math.Round(mean+rng.NormFloat64()*math.Sqrt(mean))))
+	}
+
+	limit := math.Exp(-mean)
+	count, product := 0, rng.Float64()
+	for product > limit {
+		count++
+		product *= rng.Float64()
+	}
+	return count
+}
+
+// randomDuration returns a uniformly drawn duration in [0, max)
+func randomDuration(rng *rand.Rand, max time.Duration) time.Duration {
+	if max <= 0 {
+		return 0
+	}
+	return time.Duration(rng.Int63n(int64(max)))
+}
+
+// roundPrice rounds yen prices to 10 below 1,000 and to 100 above
+func roundPrice(price float64) float64 {
+	if price < 1000 {
+		return math.Max(10, roundTo(price, 10))
+	}
+	return roundTo(price, 100)
+}
+
+func roundTo(value, unit float64) float64 {
+	return math.Round(value/unit) * unit
+}
+
+func clamp(value, min, max float64) float64 {
+	return math.Max(min, math.Min(max, value))
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// memorySink keeps every row as its CSV fields, keyed by table
+type memorySink struct {
+	tables map[string][][]string
+	order  []string
+}
+
+func (s *memorySink) WriteTable(table string, columns []string, fill func(emit func(values ...interface{}) error) error) error {
+	if s.tables == nil {
+		s.tables = make(map[string][][]string)
+	}
+	s.order = append(s.order, table)
+
+	return fill(func(values ...interface{}) error {
+		if len(values) != len(columns) {
+			panic("column count mismatch in " + table)
+		}
+		row := make([]string, len(values))
+		for i, value := range values {
+			field, err := csvField(value)
+			if err != nil {
+				return err
+			}
+			row[i] = field
+		}
+		s.tables[table] = append(s.tables[table], row)
+		return nil
+	})
+}
+
+func generateSmall(t *testing.T, seed int64) (*memorySink, *Generator) {
+	t.Helper()
+	generator := NewGenerator(Options{
+		Seed:                seed,
+		Customers:           50,
+		ProductsPerCategory: 5,
+		Days:                365,
+		End:                 time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+		OrdersPerCustomer:   6,
+		SessionsPerCustomer: 10,
+	})
+
+	sink := &memorySink{}
+	if _, err := generator.Generate(sink); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return sink, generator
+}
+
+func TestGeneratorIsReproducible(t *testing.T) {
+	first, _ := generateSmall(t, 7)
+	second, _ := generateSmall(t, 7)
+	other, _ := generateSmall(t, 8)
+
+	if !reflect.DeepEqual(first.tables, second.tables) {
+		t.Error("Expected the same seed to produce identical rows")
+	}
+	if reflect.DeepEqual(first.tables["orders"], other.tables["orders"]) {
+		t.Error("Expected a different seed to produce different orders")
+	}
+	if !reflect.DeepEqual(first.order, generatedTables) {
+		t.Errorf("Expected tables in load order %v, got %v", generatedTables, first.order)
+	}
+}
+
+func TestGeneratorConsistency(t *testing.T) {
+	sink, generator := generateSmall(t, 7)
+
+	if len(sink.tables["orders"]) == 0 || len(sink.tables["customer_activities"]) == 0 {
+		t.Fatal("Expected orders and activities to be generated")
+	}
+
+	delivered := make(map[string]bool)
+	for _, o := range generator.orders {
+		if o.Status == "delivered" {
+			delivered[o.ID.String()] = true
+		}
+		if o.OrderedAt.After(generator.opts.End) || o.OrderedAt.Before(generator.customers[o.Customer].CreatedAt) {
+			t.Errorf("Order %s at %s is outside the customer's lifetime", o.Number, o.OrderedAt)
+		}
+		for _, item := range o.Items {
+			if generator.products[item.Product].CreatedAt.After(o.OrderedAt) {
+				t.Errorf("Order %s contains a product launched after it was placed", o.Number)
+			}
+		}
+	}
+
+	for _, r := range generator.reviews {
+		if !delivered[r.Order.String()] {
+			t.Errorf("Review %s references an order that was not delivered", r.ID)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Sink receives generated rows table by table, in foreign key order
+type Sink interface {
+	// WriteTable writes the rows produced by fill; fill calls emit once per row
+	WriteTable(table string, columns []string, fill func(emit func(values ...interface{}) error) error) error
+}
+
+// generatedTables lists every table the generator writes, in load order
+var generatedTables = []string{
+	"categories",
+	"products",
+	"customers",
+	"orders",
+	"order_items",
+	"product_reviews",
+	"customer_activities",
+	"cart_items",
+	"wishlist_items",
+}
+
+// CopySink bulk-loads rows into Postgres with COPY inside a single transaction
+type CopySink struct {
+	tx *sql.Tx
+}
+
+// NewCopySink starts the load transaction. When truncate is set, the generated tables and every
+// table referencing them are emptied first.
+func NewCopySink(ctx context.Context, db *sql.DB, truncate bool) (*CopySink, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	if truncate {
+		if _, err := tx.ExecContext(ctx, "TRUNCATE "+strings.Join(generatedTables, ", ")+" RESTART IDENTITY CASCADE"); err != nil {
+			tx.Rollback()
+			return nil, fmt.Errorf("failed to truncate tables: %w", err)
+		}
+	}
+
+	return &CopySink{tx: tx}, nil
+}
+
+// WriteTable streams the rows through COPY FROM STDIN
+func (s *CopySink) WriteTable(table string, columns []string, fill func(emit func(values ...interface{}) error) error) error {
+	stmt, err := s.tx.Prepare(pq.CopyIn(table, columns...))
+	if err != nil {
+		return fmt.Errorf("failed to prepare copy into %s: %w", table, err)
+	}
+	defer stmt.Close()
+
+	if err := fill(func(values ...interface{}) error {
+		_, err := stmt.Exec(values...)
+		return err
+	}); err != nil {
+		return fmt.Errorf("failed to copy into %s: %w", table, err)
+	}
+
+	if _, err := stmt.Exec(); err != nil {
+		return fmt.Errorf("failed to flush copy into %s: %w", table, err)
+	}
+
+	return nil
+}
+
+// Commit advances the categories sequence past the explicit IDs and commits the load
+func (s *CopySink) Commit(ctx context.Context) error {
+	if _, err := s.tx.ExecContext(ctx, "SELECT setval('categories_id_seq', (SELECT MAX(id) FROM categories))"); err != nil {
+		s.tx.Rollback()
+		return fmt.Errorf("failed to reset categories sequence: %w", err)
+	}
+
+	if err := s.tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit load: %w", err)
+	}
+
+	return nil
+}
+
+// Rollback abandons the load
+func (s *CopySink) Rollback() error {
+	return s.tx.Rollback()
+}
+
+// CSVSink writes one CSV file per table plus a psql script that loads them in order
+type CSVSink struct {
+	dir    string
+	loaded []string
+}
+
+// NewCSVSink creates the output directory
+func NewCSVSink(dir string) (*CSVSink, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create output directory: %w", err)
+	}
+	return &CSVSink{dir: dir}, nil
+}
+
+// WriteTable writes <table>.csv with a header row. NULL is written as an empty field.
+func (s *CSVSink) WriteTable(table string, columns []string, fill func(emit func(values ...interface{}) error) error) error {
+	file, err := os.Create(filepath.Join(s.dir, table+".csv"))
+	if err != nil {
+		return fmt.Errorf("failed to create %s.csv: %w", table, err)
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	if err := writer.Write(columns); err != nil {
+		return fmt.Errorf("failed to write %s.csv header: %w", table, err)
+	}
+
+	record := make([]string, len(columns))
+	if err := fill(func(values ...interface{}) error {
+		for i, value := range values {
+			field, err := csvField(value)
+			if err != nil {
+				return err
+			}
+			record[i] = field
+		}
+		return writer.Write(record)
+	}); err != nil {
+		return fmt.Errorf("failed to write %s.csv: %w", table, err)
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("failed to flush %s.csv: %w", table, err)
+	}
+
+	s.loaded = append(s.loaded, fmt.Sprintf("\\copy %s (%s) FROM '%s.csv' WITH (FORMAT csv, HEADER true)", table, strings.Join(columns, ", "), table))
+	return nil
+}
+
+// Commit writes load.sql, which runs the \copy commands from the output directory
+func (s *CSVSink) Commit(ctx context.Context) error {
+	script := "BEGIN;\n" +
+		strings.Join(s.loaded, "\n") + "\n" +
+		"SELECT setval('categories_id_seq', (SELECT MAX(id) FROM categories));\n" +
+		"COMMIT;\n"
+
+	if err := os.WriteFile(filepath.Join(s.dir, "load.sql"), []byte(script), 0644); err != nil {
+		return fmt.Errorf("failed to write load.sql: %w", err)
+	}
+	return nil
+}
+
+// csvField formats a value the way COPY's CSV format reads it back
+func csvField(value interface{}) (string, error) {
+	if valuer, ok := value.(driver.Valuer); ok {
+		converted, err := valuer.Value()
+		if err != nil {
+			return "", err
+		}
+		value = converted
+	}
+
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case []byte:
+		return string(v), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case time.Time:
+		return v.Format(time.RFC3339), nil
+	default:
+		return fmt.Sprint(v), nil
+	}
+}
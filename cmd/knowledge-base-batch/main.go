@@ -12,11 +12,14 @@ import (
 	"strings"
 	"time"
 
+	dbRepository "ec-recommend/internal/repository/db"
 	"ec-recommend/internal/repository/db/models"
+	"ec-recommend/internal/service"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	awsconfig "github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/google/uuid"
 	_ "github.com/lib/pq"
 	"github.com/volatiletech/sqlboiler/v4/queries/qm"
 )
@@ -28,6 +31,7 @@ type Config struct {
 	S3KeyPrefix  string
 	BatchSize    int
 	EnableDebug  bool
+	SyncMode     string // "full" or "popularity" (only products with significant popularity changes)
 }
 
 type ProductKnowledgeDocument struct {
@@ -108,6 +112,7 @@ func loadConfig() *Config {
 		S3KeyPrefix:  getEnvOrDefault("S3_KEY_PREFIX", "products/"),
 		BatchSize:    getIntEnvOrDefault("BATCH_SIZE", 100),
 		EnableDebug:  getBoolEnvOrDefault("ENABLE_DEBUG", true),
+		SyncMode:     getEnvOrDefault("SYNC_MODE", "full"),
 	}
 }
 
@@ -137,16 +142,18 @@ func getBoolEnvOrDefault(key string, defaultValue bool) bool {
 }
 
 type KnowledgeBaseBatchProcessor struct {
-	db       *sql.DB
-	uploader *S3Uploader
-	config   *Config
+	db             *sql.DB
+	uploader       *S3Uploader
+	config         *Config
+	popularityRepo service.PopularityRepositoryInterface
 }
 
 func NewKnowledgeBaseBatchProcessor(db *sql.DB, uploader *S3Uploader, config *Config) *KnowledgeBaseBatchProcessor {
 	return &KnowledgeBaseBatchProcessor{
-		db:       db,
-		uploader: uploader,
-		config:   config,
+		db:             db,
+		uploader:       uploader,
+		config:         config,
+		popularityRepo: dbRepository.NewPopularityRepository(db),
 	}
 }
 
@@ -154,11 +161,31 @@ func (p *KnowledgeBaseBatchProcessor) ProcessAllProducts(ctx context.Context) er
 	log.Println("Starting product data extraction...")
 
 	// アクティブな商品のみを取得
-	products, err := models.Products(
+	mods := []qm.QueryMod{
 		qm.Where("is_active = ?", true),
 		qm.Load("Category"),
 		qm.Load("ProductReviews", qm.OrderBy("created_at DESC"), qm.Limit(10)), // 最新10件のレビュー
-	).All(ctx, p.db)
+	}
+
+	// 人気スコアが大きく変化した商品のみ再同期
+	if p.config.SyncMode == "popularity" {
+		pending, err := p.popularityRepo.GetPendingKnowledgeBaseSync(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to fetch products pending sync: %w", err)
+		}
+		if len(pending) == 0 {
+			log.Println("No products pending popularity re-sync")
+			return nil
+		}
+
+		ids := make([]interface{}, len(pending))
+		for i, id := range pending {
+			ids[i] = id.String()
+		}
+		mods = append(mods, qm.WhereIn("id IN ?", ids...))
+	}
+
+	products, err := models.Products(mods...).All(ctx, p.db)
 
 	if err != nil {
 		return fmt.Errorf("failed to fetch products: %w", err)
@@ -169,6 +196,7 @@ func (p *KnowledgeBaseBatchProcessor) ProcessAllProducts(ctx context.Context) er
 	// バッチ処理でアップロード
 	successCount := 0
 	errorCount := 0
+	var syncedIDs []uuid.UUID
 
 	for i, product := range products {
 		if p.config.EnableDebug {
@@ -191,6 +219,9 @@ func (p *KnowledgeBaseBatchProcessor) ProcessAllProducts(ctx context.Context) er
 		}
 
 		successCount++
+		if id, err := uuid.Parse(product.ID); err == nil {
+			syncedIDs = append(syncedIDs, id)
+		}
 
 		// バッチサイズごとに進捗を出力
 		if (i+1)%p.config.BatchSize == 0 {
@@ -200,6 +231,12 @@ func (p *KnowledgeBaseBatchProcessor) ProcessAllProducts(ctx context.Context) er
 	}
 
 	log.Printf("Batch processing completed. Success: %d, Error: %d", successCount, errorCount)
+
+	// アップロードに成功した商品の人気スコア変更を同期済みにする
+	if err := p.popularityRepo.MarkKnowledgeBaseSynced(ctx, syncedIDs, time.Now()); err != nil {
+		return fmt.Errorf("failed to mark popularity changes as synced: %w", err)
+	}
+
 	return nil
 }
 
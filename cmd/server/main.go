@@ -66,28 +66,126 @@ func main() {
 	bedrockClient := bedrockruntime.NewFromConfig(awsCfg)
 	bedrockAgentClient := bedrockagentruntime.NewFromConfig(awsCfg)
 
+	// Load recommendation tuning (blend profiles etc.)
+	tuning, err := service.LoadRecommendationTuning(cfg.RecommendationTuningPath)
+	if err != nil {
+		log.Fatalf("Failed to load recommendation tuning: %v", err)
+	}
+
 	// Initialize V1 repositories
 	bedrockRepo := bedrockRepository.NewBedrockClient(bedrockClient, cfg.BedrockModelID)
 	recommendationRepo := dbRepository.NewRecommendationRepository(db)
 
 	// Initialize V1 recommendation service
-	recommendationService := service.NewRecommendationService(recommendationRepo, bedrockRepo, cfg.BedrockModelID)
+	recommendationService := service.NewRecommendationService(recommendationRepo, bedrockRepo, cfg.BedrockModelID, tuning)
 
 	// Initialize V2 repositories
 	recommendationRepoV2 := dbRepository.NewRecommendationRepositoryV2(db)
 	bedrockRepoV2 := bedrockRepository.NewBedrockKnowledgeBaseService(bedrockAgentClient, bedrockClient, cfg.KnowledgeBaseID, cfg.BedrockModelID, cfg.EmbeddingModelID)
 
 	// Initialize V2 services (Enhanced RAG-based)
-	recommendationServiceV2 := service.NewRecommendationServiceV2(recommendationRepoV2, bedrockRepoV2, bedrockRepo, cfg.BedrockModelID, cfg.KnowledgeBaseID, cfg.EmbeddingModelID)
+	recommendationServiceV2 := service.NewRecommendationServiceV2(recommendationRepoV2, bedrockRepoV2, bedrockRepo, cfg.BedrockModelID, cfg.KnowledgeBaseID, cfg.EmbeddingModelID, tuning)
+
+	// Initialize merchandising rules
+	merchandisingRepo := dbRepository.NewMerchandisingRuleRepository(db)
+	merchandisingService := service.NewMerchandisingService(merchandisingRepo)
+
+	// Strategy bandit shared by both services so interactions reward decisions from either
+	strategyBandit := service.NewStrategyBandit(dbRepository.NewStrategyBanditRepository(db), tuning.Bandit)
+	recommendationService.SetStrategyBandit(strategyBandit)
+	recommendationServiceV2.SetStrategyBandit(strategyBandit)
+
+	// A/B experiments from the tuning file and the database
+	experimentService := service.NewExperimentService(dbRepository.NewExperimentRepository(db), tuning.Experiments)
+	recommendationService.SetExperimentService(experimentService)
+	recommendationServiceV2.SetExperimentService(experimentService)
+
+	// Session-sequence next-item recommender learned from browsing sessions
+	sessionSequenceRecommender := service.NewSessionSequenceRecommender(dbRepository.NewSessionSequenceRepository(db), tuning.SessionSequence)
+	recommendationServiceV2.SetSessionSequenceRecommender(sessionSequenceRecommender)
+
+	// Seasonal demand indices learned from order history (prompt context, KB filters and ranking boost)
+	seasonalityService := service.NewSeasonalityService(dbRepository.NewSeasonalityRepository(db), tuning.Seasonality)
+	recommendationServiceV2.SetSeasonalityService(seasonalityService)
+
+	// Regional popularity from customer locations ("popular near you" and the hybrid location boost)
+	regionalPopularityService := service.NewRegionalPopularityService(dbRepository.NewRegionalPopularityRepository(db), tuning.RegionalPopularity)
+	recommendationServiceV2.SetRegionalPopularityService(regionalPopularityService)
+
+	// "Not interested" feedback: suppressions and negative preferences shared by all recommendation services
+	feedbackService := service.NewFeedbackService(dbRepository.NewFeedbackRepository(db), tuning.Feedback)
+	recommendationService.SetFeedbackService(feedbackService)
+	recommendationServiceV2.SetFeedbackService(feedbackService)
+
+	// Impression logging and per-context frequency caps
+	impressionService := service.NewImpressionService(dbRepository.NewImpressionRepository(db), tuning.FrequencyCap)
+	recommendationService.SetImpressionService(impressionService)
+	recommendationServiceV2.SetImpressionService(impressionService)
+
+	// Search query normalization: width folding, spelling correction and the synonym dictionary
+	queryNormalizer := service.NewQueryNormalizer(dbRepository.NewSearchSynonymRepository(db), tuning.QueryNormalization)
+	recommendationServiceV2.SetQueryNormalizer(queryNormalizer)
+
+	// Lexical product search: the "keyword" search type and the knowledge base fallback
+	lexicalSearchService := service.NewLexicalSearchService(dbRepository.NewLexicalSearchRepository(db), tuning.LexicalSearch)
+	recommendationServiceV2.SetLexicalSearchService(lexicalSearchService)
+
+	// Replenishment predictions for consumable products
+	replenishmentService := service.NewReplenishmentService(dbRepository.NewReplenishmentRepository(db), tuning.Replenishment)
+	replenishmentService.SetFeedbackService(feedbackService)
+
+	// Budget-constrained "complete the set" bundles
+	bundleService := service.NewBundleService(dbRepository.NewBundleRepository(db), recommendationRepoV2, bedrockRepo, tuning.Bundle)
+	bundleService.SetFeedbackService(feedbackService)
+
+	// Search box completions from the search log and the catalog
+	suggestService := service.NewSuggestService(dbRepository.NewSuggestRepository(db), tuning.Suggest)
+	suggestService.SetFeedbackService(feedbackService)
+
+	// Load the learning-to-rank model if configured; without one the stage only computes features
+	var rankingModel *service.RankingModel
+	if tuning.LearningToRank.ModelPath != "" {
+		rankingModel, err = service.LoadRankingModel(tuning.LearningToRank.ModelPath)
+		if err != nil {
+			log.Fatalf("Failed to load ranking model: %v", err)
+		}
+	}
+
+	// Register post-ranking stages (applied in order after ranking in both services)
+	merchandisingStage := service.NewMerchandisingRuleStage(merchandisingRepo, recommendationRepoV2)
+	seasonalityStage := service.NewSeasonalityReranker(seasonalityService)
+	priceSensitivityStage := service.NewPriceSensitivityReranker(dbRepository.NewPriceSensitivityRepository(db), tuning.PriceSensitivity)
+	feedbackStage := service.NewFeedbackSuppressionStage(feedbackService)
+	frequencyCapStage := service.NewFrequencyCapStage(impressionService)
+	recommendationService.AddPostRankingStage(feedbackStage)
+	recommendationService.AddPostRankingStage(seasonalityStage)
+	recommendationService.AddPostRankingStage(priceSensitivityStage)
+	recommendationService.AddPostRankingStage(frequencyCapStage)
+	recommendationService.AddPostRankingStage(service.NewDiversityReranker(tuning.Diversity, nil))
+	recommendationService.AddPostRankingStage(merchandisingStage)
+	recommendationServiceV2.AddPostRankingStage(feedbackStage)
+	recommendationServiceV2.AddPostRankingStage(service.NewLTRReranker(rankingModel))
+	recommendationServiceV2.AddPostRankingStage(seasonalityStage)
+	recommendationServiceV2.AddPostRankingStage(priceSensitivityStage)
+	recommendationServiceV2.AddPostRankingStage(frequencyCapStage)
+	recommendationServiceV2.AddPostRankingStage(service.NewDiversityReranker(tuning.Diversity, bedrockRepoV2))
+	recommendationServiceV2.AddPostRankingStage(merchandisingStage)
 
 	// Initialize handlers
 	chatHandler := handler.NewChatHandler(bedrockRepo)
 	healthHandler := handler.NewHealthHandler()
 	recommendationHandler := handler.NewRecommendationHandler(recommendationService)
 	recommendationHandlerV2 := handler.NewRecommendationHandlerV2(recommendationServiceV2)
+	merchandisingHandler := handler.NewMerchandisingHandler(merchandisingService)
+	experimentHandler := handler.NewExperimentHandler(experimentService)
+	replenishmentHandler := handler.NewReplenishmentHandler(replenishmentService)
+	bundleHandler := handler.NewBundleHandler(bundleService)
+	feedbackHandler := handler.NewFeedbackHandler(feedbackService)
+	suggestHandler := handler.NewSuggestHandler(suggestService)
+	searchSynonymHandler := handler.NewSearchSynonymHandler(queryNormalizer)
 
 	// Setup router
-	routerEngine := router.SetupRouter(chatHandler, healthHandler, recommendationHandler, recommendationHandlerV2)
+	routerEngine := router.SetupRouter(chatHandler, healthHandler, recommendationHandler, recommendationHandlerV2, merchandisingHandler, experimentHandler, replenishmentHandler, bundleHandler, feedbackHandler, suggestHandler, searchSynonymHandler)
 
 	// Create HTTP server
 	server := &http.Server{
@@ -113,6 +211,12 @@ func main() {
 		log.Println("Available endpoints:")
 		log.Println("  V1 API: /api/v1/recommendations")
 		log.Println("  V2 API: /api/v2/recommendations (Enhanced RAG-based)")
+		log.Println("  Experiments: /api/v2/experiments")
+		log.Println("  Replenishment: /api/v2/customers/:customer_id/replenishment")
+		log.Println("  Search suggest: /api/v2/search/suggest")
+		log.Println("  Feedback: /api/v2/recommendations/feedback")
+		log.Println("  Admin: /api/v2/admin/merchandising-rules")
+		log.Println("  Admin: /api/v2/admin/search-synonyms")
 		log.Println("  Health: /health")
 
 		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
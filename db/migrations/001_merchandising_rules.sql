@@ -0,0 +1,33 @@
+-- Adds the merchandising rules applied after ranking. Safe to run more than once:
+--   psql "$DATABASE_URL" -f db/migrations/001_merchandising_rules.sql
+
+BEGIN;
+
+-- Merchandising rules applied after ranking
+CREATE TABLE IF NOT EXISTS merchandising_rules (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    is_active BOOLEAN DEFAULT true,
+    priority INTEGER DEFAULT 0,
+    context_types VARCHAR(50)[], -- empty matches all contexts
+    category_ids INTEGER[], -- empty matches all categories
+    customer_segments VARCHAR(20)[], -- 'premium', 'new', 'standard'; empty matches all segments
+    starts_at TIMESTAMP WITH TIME ZONE,
+    ends_at TIMESTAMP WITH TIME ZONE,
+    action_type VARCHAR(20) NOT NULL CHECK (action_type IN ('pin', 'boost', 'bury', 'block_product', 'block_brand')),
+    target_product_id UUID REFERENCES products(id) ON DELETE CASCADE,
+    target_brand VARCHAR(100),
+    target_category_id INTEGER REFERENCES categories(id),
+    factor DECIMAL(5,2),
+    slot INTEGER,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_merchandising_rules_active ON merchandising_rules(is_active, priority DESC);
+
+DROP TRIGGER IF EXISTS update_merchandising_rules_updated_at ON merchandising_rules;
+CREATE TRIGGER update_merchandising_rules_updated_at BEFORE UPDATE ON merchandising_rules FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+COMMIT;
@@ -0,0 +1,21 @@
+-- Adds the ranking feature log used to train the learning-to-rank model. Safe to run more than once:
+--   psql "$DATABASE_URL" -f db/migrations/002_recommendation_feature_logs.sql
+
+BEGIN;
+
+-- Ranking features of returned recommendations for learning-to-rank training
+CREATE TABLE IF NOT EXISTS recommendation_feature_logs (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    recommendation_id UUID NOT NULL, -- recommendation_logs.id
+    customer_id UUID NOT NULL REFERENCES customers(id),
+    product_id UUID NOT NULL REFERENCES products(id),
+    context_type VARCHAR(50),
+    position INTEGER NOT NULL,
+    features JSONB NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_recommendation_feature_logs_recommendation ON recommendation_feature_logs(recommendation_id);
+CREATE INDEX IF NOT EXISTS idx_recommendation_feature_logs_created ON recommendation_feature_logs(created_at DESC);
+
+COMMIT;
@@ -0,0 +1,33 @@
+-- Adds the strategy bandit posteriors and the decisions taken for "auto" recommendations. Safe to run more than once:
+--   psql "$DATABASE_URL" -f db/migrations/003_strategy_bandit.sql
+
+BEGIN;
+
+-- Thompson sampling posteriors per service, context and recommendation type
+CREATE TABLE IF NOT EXISTS strategy_bandit_arms (
+    service VARCHAR(10) NOT NULL, -- 'v1', 'v2'
+    context_type VARCHAR(50) NOT NULL,
+    arm VARCHAR(50) NOT NULL, -- recommendation type
+    successes DOUBLE PRECISION NOT NULL DEFAULT 0,
+    failures DOUBLE PRECISION NOT NULL DEFAULT 0,
+    pulls INTEGER NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (service, context_type, arm)
+);
+
+-- Strategies chosen by the bandit for "auto" recommendations
+CREATE TABLE IF NOT EXISTS strategy_bandit_decisions (
+    recommendation_id UUID PRIMARY KEY, -- recommendation_logs.id
+    customer_id UUID NOT NULL REFERENCES customers(id),
+    service VARCHAR(10) NOT NULL,
+    context_type VARCHAR(50) NOT NULL,
+    arm VARCHAR(50) NOT NULL,
+    propensity DOUBLE PRECISION NOT NULL,
+    reward DOUBLE PRECISION NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    rewarded_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_strategy_bandit_decisions_created ON strategy_bandit_decisions(created_at DESC);
+
+COMMIT;
@@ -0,0 +1,27 @@
+-- Adds A/B experiments. Stamped algorithm versions such as 'hybrid_v1.0;exp=<experiment_id>/<variant>'
+-- exceed the original VARCHAR(20), so the column is widened. Safe to run more than once:
+--   psql "$DATABASE_URL" -f db/migrations/004_experiments.sql
+
+BEGIN;
+
+ALTER TABLE recommendation_logs ALTER COLUMN algorithm_version TYPE VARCHAR(100);
+
+-- A/B experiments; variants override strategy, blend weights, prompt template version and model ID
+CREATE TABLE IF NOT EXISTS experiments (
+    id VARCHAR(64) PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    is_active BOOLEAN DEFAULT true,
+    service VARCHAR(10), -- 'v1', 'v2'; NULL applies to both
+    context_types VARCHAR(50)[], -- empty matches all contexts
+    variants JSONB NOT NULL, -- [{"name": "control", "weight": 50, ...}, ...]
+    starts_at TIMESTAMP WITH TIME ZONE,
+    ends_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+DROP TRIGGER IF EXISTS update_experiments_updated_at ON experiments;
+CREATE TRIGGER update_experiments_updated_at BEFORE UPDATE ON experiments FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+COMMIT;
@@ -0,0 +1,8 @@
+-- Indexes activities by session for the session-sequence recommender. Safe to run more than once:
+--   psql "$DATABASE_URL" -f db/migrations/005_customer_activities_session_index.sql
+
+BEGIN;
+
+CREATE INDEX IF NOT EXISTS idx_activities_session ON customer_activities(session_id, created_at);
+
+COMMIT;
@@ -0,0 +1,9 @@
+-- Adds the gift flag to orders. Existing orders keep the default, so their products count as
+-- the customer's own, as before. Safe to run more than once:
+--   psql "$DATABASE_URL" -f db/migrations/006_orders_is_gift.sql
+
+BEGIN;
+
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS is_gift BOOLEAN NOT NULL DEFAULT false; -- ordered for someone else; gift products are not treated as owned when recommending gifts
+
+COMMIT;
@@ -0,0 +1,25 @@
+-- Adds the popularity recomputation history. Safe to run more than once:
+--   psql "$DATABASE_URL" -f db/migrations/007_product_popularity_history.sql
+
+BEGIN;
+
+-- Recomputed popularity scores; significant changes are flagged for knowledge base re-sync
+CREATE TABLE IF NOT EXISTS product_popularity_history (
+    id BIGSERIAL PRIMARY KEY,
+    product_id UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    previous_score INTEGER NOT NULL,
+    popularity_score INTEGER NOT NULL,
+    raw_score DOUBLE PRECISION NOT NULL, -- weighted sum of the decayed signals
+    orders DOUBLE PRECISION NOT NULL DEFAULT 0, -- decayed units ordered
+    views DOUBLE PRECISION NOT NULL DEFAULT 0,
+    carts DOUBLE PRECISION NOT NULL DEFAULT 0,
+    wishlists DOUBLE PRECISION NOT NULL DEFAULT 0,
+    significant BOOLEAN NOT NULL DEFAULT false,
+    kb_synced_at TIMESTAMP WITH TIME ZONE,
+    computed_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_popularity_history_product ON product_popularity_history(product_id, computed_at DESC);
+CREATE INDEX IF NOT EXISTS idx_product_popularity_history_pending_sync ON product_popularity_history(product_id) WHERE significant = true AND kb_synced_at IS NULL;
+
+COMMIT;
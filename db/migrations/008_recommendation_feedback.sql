@@ -0,0 +1,25 @@
+-- Adds "not interested" dismissals of recommended products. Safe to run more than once:
+--   psql "$DATABASE_URL" -f db/migrations/008_recommendation_feedback.sql
+
+BEGIN;
+
+-- "Not interested" dismissals of recommended products
+CREATE TABLE IF NOT EXISTS recommendation_feedback (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    customer_id UUID NOT NULL REFERENCES customers(id) ON DELETE CASCADE,
+    product_id UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    recommendation_id UUID, -- recommendation_logs.id the product was shown in
+    reason_code VARCHAR(30) NOT NULL CHECK (reason_code IN ('already_own', 'not_my_taste', 'too_expensive', 'not_relevant', 'other')),
+    scope VARCHAR(20) NOT NULL DEFAULT 'product' CHECK (scope IN ('product', 'brand', 'category')),
+    brand VARCHAR(100), -- brand of the product when dismissed
+    category_id INTEGER REFERENCES categories(id), -- category of the product when dismissed
+    context_type VARCHAR(50),
+    comment TEXT,
+    suppress_until TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_recommendation_feedback_customer ON recommendation_feedback(customer_id, suppress_until DESC);
+CREATE INDEX IF NOT EXISTS idx_recommendation_feedback_recommendation ON recommendation_feedback(recommendation_id, product_id);
+
+COMMIT;
@@ -0,0 +1,21 @@
+-- Adds recommendation impressions for frequency capping. Safe to run more than once:
+--   psql "$DATABASE_URL" -f db/migrations/009_recommendation_impressions.sql
+
+BEGIN;
+
+-- Products returned to a customer by a recommendation request, for frequency capping
+CREATE TABLE IF NOT EXISTS recommendation_impressions (
+    id BIGSERIAL PRIMARY KEY,
+    recommendation_id UUID NOT NULL, -- recommendation_logs.id
+    customer_id UUID NOT NULL REFERENCES customers(id) ON DELETE CASCADE,
+    product_id UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    context_type VARCHAR(50) NOT NULL,
+    position INTEGER NOT NULL,
+    clicked_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_recommendation_impressions_customer ON recommendation_impressions(customer_id, context_type, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_recommendation_impressions_recommendation ON recommendation_impressions(recommendation_id, product_id);
+
+COMMIT;
@@ -0,0 +1,26 @@
+-- Adds the served recommendation items explanations are reconstructed from. Safe to run more than once:
+--   psql "$DATABASE_URL" -f db/migrations/010_recommendation_items.sql
+
+BEGIN;
+
+-- Recommendations as served, for reconstructing explanations
+CREATE TABLE IF NOT EXISTS recommendation_items (
+    id UUID PRIMARY KEY, -- recommendation_item_id returned with the product
+    recommendation_log_id UUID NOT NULL, -- recommendation_logs.id
+    customer_id UUID NOT NULL REFERENCES customers(id) ON DELETE CASCADE,
+    product_id UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    recommendation_type VARCHAR(50) NOT NULL,
+    context_type VARCHAR(50) NOT NULL,
+    algorithm_version VARCHAR(100),
+    strategies TEXT[] NOT NULL DEFAULT '{}',
+    score_breakdown JSONB NOT NULL,
+    filters JSONB NOT NULL,
+    product_snapshot JSONB NOT NULL, -- product as served, including relevance context
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_recommendation_items_log ON recommendation_items(recommendation_log_id, position);
+CREATE INDEX IF NOT EXISTS idx_recommendation_items_customer ON recommendation_items(customer_id, created_at DESC);
+
+COMMIT;
@@ -0,0 +1,21 @@
+-- Adds the search query synonym dictionary. Safe to run more than once:
+--   psql "$DATABASE_URL" -f db/migrations/011_search_synonyms.sql
+
+BEGIN;
+
+-- Search query dictionary managed through the admin API; matching terms are rewritten before retrieval
+CREATE TABLE IF NOT EXISTS search_synonyms (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    term VARCHAR(100) NOT NULL,
+    normalized_term VARCHAR(100) NOT NULL UNIQUE, -- width-folded, lowercased hiragana form of term
+    replacement VARCHAR(200) NOT NULL,
+    synonym_type VARCHAR(20) NOT NULL DEFAULT 'synonym' CHECK (synonym_type IN ('synonym', 'abbreviation', 'misspelling')),
+    is_active BOOLEAN DEFAULT true,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+DROP TRIGGER IF EXISTS update_search_synonyms_updated_at ON search_synonyms;
+CREATE TRIGGER update_search_synonyms_updated_at BEFORE UPDATE ON search_synonyms FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+COMMIT;
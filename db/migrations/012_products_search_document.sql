@@ -0,0 +1,43 @@
+-- Adds the lexical search document to databases created before it was part of schema.sql,
+-- and backfills it for existing products. Safe to run more than once:
+--   psql "$DATABASE_URL" -f db/migrations/012_products_search_document.sql
+
+BEGIN;
+
+CREATE EXTENSION IF NOT EXISTS "pg_trgm"; -- For lexical product search
+
+ALTER TABLE products ADD COLUMN IF NOT EXISTS search_document TEXT; -- Folded name, brand, tags, feature values and description for lexical search (maintained by trigger)
+
+-- Lexical search document: NFKC folds full-width and half-width forms, then letters are lowercased
+-- and katakana is folded to hiragana, matching the application's search text normalization
+CREATE OR REPLACE FUNCTION update_products_search_document()
+RETURNS TRIGGER AS $$
+BEGIN
+    NEW.search_document = translate(
+        lower(normalize(concat_ws(' ',
+            NEW.name,
+            NEW.brand,
+            array_to_string(NEW.tags, ' '),
+            CASE WHEN jsonb_typeof(NEW.features) = 'object'
+                THEN (SELECT string_agg(value, ' ') FROM jsonb_each_text(NEW.features)) END,
+            NEW.description
+        ), NFKC)),
+        'ァアィイゥウェエォオカガキギクグケゲコゴサザシジスズセゼソゾタダチヂッツヅテデトドナニヌネノハバパヒビピフブプヘベペホボポマミムメモャヤュユョヨラリルレロヮワヰヱヲンヴヵヶヽヾ',
+        'ぁあぃいぅうぇえぉおかがきぎくぐけげこごさざしじすずせぜそぞただちぢっつづてでとどなにぬねのはばぱひびぴふぶぷへべぺほぼぽまみむめもゃやゅゆょよらりるれろゎわゐゑをんゔゕゖゝゞ'
+    );
+    RETURN NEW;
+END;
+$$ language 'plpgsql';
+
+DROP TRIGGER IF EXISTS update_products_search_document ON products;
+CREATE TRIGGER update_products_search_document BEFORE INSERT OR UPDATE OF name, brand, tags, features, description ON products FOR EACH ROW EXECUTE FUNCTION update_products_search_document();
+
+-- Backfill through the trigger. updated_at is left alone so the backfill does not look like
+-- a catalog change to the knowledge base sync.
+ALTER TABLE products DISABLE TRIGGER update_products_updated_at;
+UPDATE products SET name = name WHERE search_document IS NULL;
+ALTER TABLE products ENABLE TRIGGER update_products_updated_at;
+
+CREATE INDEX IF NOT EXISTS idx_products_search_document ON products USING GIN(search_document gin_trgm_ops);
+
+COMMIT;
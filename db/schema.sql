@@ -3,6 +3,7 @@
 -- Enable extensions for better data types and functions
 CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
 CREATE EXTENSION IF NOT EXISTS "vector"; -- For future vector similarity search
+CREATE EXTENSION IF NOT EXISTS "pg_trgm"; -- For lexical product search
 
 -- Categories table for product categorization
 CREATE TABLE categories (
@@ -33,6 +34,7 @@ CREATE TABLE products (
     rating_count INTEGER DEFAULT 0,
     popularity_score INTEGER DEFAULT 0, -- For trending products
     is_active BOOLEAN DEFAULT true,
+    search_document TEXT, -- Folded name, brand, tags, feature values and description for lexical search (maintained by trigger)
     created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
 );
@@ -74,6 +76,7 @@ CREATE TABLE orders (
     total_amount DECIMAL(10,2) NOT NULL CHECK (total_amount >= 0),
     payment_method VARCHAR(50),
     shipping_address JSONB,
+    is_gift BOOLEAN NOT NULL DEFAULT false, -- ordered for someone else; gift products are not treated as owned when recommending gifts
     notes TEXT,
     ordered_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
     shipped_at TIMESTAMP WITH TIME ZONE,
@@ -155,11 +158,158 @@ CREATE TABLE recommendation_logs (
     recommended_products UUID[],
     clicked_products UUID[],
     purchased_products UUID[],
-    algorithm_version VARCHAR(20),
+    algorithm_version VARCHAR(100), -- e.g. 'hybrid_v1.0;exp=<experiment_id>/<variant>'
     confidence_scores DECIMAL(3,2)[],
     created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
 );
 
+-- Ranking features of returned recommendations for learning-to-rank training
+CREATE TABLE recommendation_feature_logs (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    recommendation_id UUID NOT NULL, -- recommendation_logs.id
+    customer_id UUID NOT NULL REFERENCES customers(id),
+    product_id UUID NOT NULL REFERENCES products(id),
+    context_type VARCHAR(50),
+    position INTEGER NOT NULL,
+    features JSONB NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- A/B experiments; variants override strategy, blend weights, prompt template version and model ID
+CREATE TABLE experiments (
+    id VARCHAR(64) PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    is_active BOOLEAN DEFAULT true,
+    service VARCHAR(10), -- 'v1', 'v2'; NULL applies to both
+    context_types VARCHAR(50)[], -- empty matches all contexts
+    variants JSONB NOT NULL, -- [{"name": "control", "weight": 50, ...}, ...]
+    starts_at TIMESTAMP WITH TIME ZONE,
+    ends_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Thompson sampling posteriors per service, context and recommendation type
+CREATE TABLE strategy_bandit_arms (
+    service VARCHAR(10) NOT NULL, -- 'v1', 'v2'
+    context_type VARCHAR(50) NOT NULL,
+    arm VARCHAR(50) NOT NULL, -- recommendation type
+    successes DOUBLE PRECISION NOT NULL DEFAULT 0,
+    failures DOUBLE PRECISION NOT NULL DEFAULT 0,
+    pulls INTEGER NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (service, context_type, arm)
+);
+
+-- Strategies chosen by the bandit for "auto" recommendations
+CREATE TABLE strategy_bandit_decisions (
+    recommendation_id UUID PRIMARY KEY, -- recommendation_logs.id
+    customer_id UUID NOT NULL REFERENCES customers(id),
+    service VARCHAR(10) NOT NULL,
+    context_type VARCHAR(50) NOT NULL,
+    arm VARCHAR(50) NOT NULL,
+    propensity DOUBLE PRECISION NOT NULL,
+    reward DOUBLE PRECISION NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    rewarded_at TIMESTAMP WITH TIME ZONE
+);
+
+-- Merchandising rules applied after ranking
+CREATE TABLE merchandising_rules (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    is_active BOOLEAN DEFAULT true,
+    priority INTEGER DEFAULT 0,
+    context_types VARCHAR(50)[], -- empty matches all contexts
+    category_ids INTEGER[], -- empty matches all categories
+    customer_segments VARCHAR(20)[], -- 'premium', 'new', 'standard'; empty matches all segments
+    starts_at TIMESTAMP WITH TIME ZONE,
+    ends_at TIMESTAMP WITH TIME ZONE,
+    action_type VARCHAR(20) NOT NULL CHECK (action_type IN ('pin', 'boost', 'bury', 'block_product', 'block_brand')),
+    target_product_id UUID REFERENCES products(id) ON DELETE CASCADE,
+    target_brand VARCHAR(100),
+    target_category_id INTEGER REFERENCES categories(id),
+    factor DECIMAL(5,2),
+    slot INTEGER,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Search query dictionary managed through the admin API; matching terms are rewritten before retrieval
+CREATE TABLE search_synonyms (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    term VARCHAR(100) NOT NULL,
+    normalized_term VARCHAR(100) NOT NULL UNIQUE, -- width-folded, lowercased hiragana form of term
+    replacement VARCHAR(200) NOT NULL,
+    synonym_type VARCHAR(20) NOT NULL DEFAULT 'synonym' CHECK (synonym_type IN ('synonym', 'abbreviation', 'misspelling')),
+    is_active BOOLEAN DEFAULT true,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Recomputed popularity scores; significant changes are flagged for knowledge base re-sync
+CREATE TABLE product_popularity_history (
+    id BIGSERIAL PRIMARY KEY,
+    product_id UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    previous_score INTEGER NOT NULL,
+    popularity_score INTEGER NOT NULL,
+    raw_score DOUBLE PRECISION NOT NULL, -- weighted sum of the decayed signals
+    orders DOUBLE PRECISION NOT NULL DEFAULT 0, -- decayed units ordered
+    views DOUBLE PRECISION NOT NULL DEFAULT 0,
+    carts DOUBLE PRECISION NOT NULL DEFAULT 0,
+    wishlists DOUBLE PRECISION NOT NULL DEFAULT 0,
+    significant BOOLEAN NOT NULL DEFAULT false,
+    kb_synced_at TIMESTAMP WITH TIME ZONE,
+    computed_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- "Not interested" dismissals of recommended products
+CREATE TABLE recommendation_feedback (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    customer_id UUID NOT NULL REFERENCES customers(id) ON DELETE CASCADE,
+    product_id UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    recommendation_id UUID, -- recommendation_logs.id the product was shown in
+    reason_code VARCHAR(30) NOT NULL CHECK (reason_code IN ('already_own', 'not_my_taste', 'too_expensive', 'not_relevant', 'other')),
+    scope VARCHAR(20) NOT NULL DEFAULT 'product' CHECK (scope IN ('product', 'brand', 'category')),
+    brand VARCHAR(100), -- brand of the product when dismissed
+    category_id INTEGER REFERENCES categories(id), -- category of the product when dismissed
+    context_type VARCHAR(50),
+    comment TEXT,
+    suppress_until TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Products returned to a customer by a recommendation request, for frequency capping
+CREATE TABLE recommendation_impressions (
+    id BIGSERIAL PRIMARY KEY,
+    recommendation_id UUID NOT NULL, -- recommendation_logs.id
+    customer_id UUID NOT NULL REFERENCES customers(id) ON DELETE CASCADE,
+    product_id UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    context_type VARCHAR(50) NOT NULL,
+    position INTEGER NOT NULL,
+    clicked_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Recommendations as served, for reconstructing explanations
+CREATE TABLE recommendation_items (
+    id UUID PRIMARY KEY, -- recommendation_item_id returned with the product
+    recommendation_log_id UUID NOT NULL, -- recommendation_logs.id
+    customer_id UUID NOT NULL REFERENCES customers(id) ON DELETE CASCADE,
+    product_id UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    recommendation_type VARCHAR(50) NOT NULL,
+    context_type VARCHAR(50) NOT NULL,
+    algorithm_version VARCHAR(100),
+    strategies TEXT[] NOT NULL DEFAULT '{}',
+    score_breakdown JSONB NOT NULL,
+    filters JSONB NOT NULL,
+    product_snapshot JSONB NOT NULL, -- product as served, including relevance context
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
 -- Indexes for performance optimization
 CREATE INDEX idx_products_category ON products(category_id);
 CREATE INDEX idx_products_price ON products(price);
@@ -168,6 +318,8 @@ CREATE INDEX idx_products_popularity ON products(popularity_score DESC);
 CREATE INDEX idx_products_active ON products(is_active);
 CREATE INDEX idx_products_tags ON products USING GIN(tags);
 CREATE INDEX idx_products_features ON products USING GIN(features);
+-- Multibyte trigrams need a database locale that classifies Japanese characters as letters (not C)
+CREATE INDEX idx_products_search_document ON products USING GIN(search_document gin_trgm_ops);
 
 CREATE INDEX idx_customers_email ON customers(email);
 CREATE INDEX idx_customers_preferred_categories ON customers USING GIN(preferred_categories);
@@ -188,6 +340,7 @@ CREATE INDEX idx_activities_customer ON customer_activities(customer_id);
 CREATE INDEX idx_activities_type ON customer_activities(activity_type);
 CREATE INDEX idx_activities_product ON customer_activities(product_id);
 CREATE INDEX idx_activities_created ON customer_activities(created_at DESC);
+CREATE INDEX idx_activities_session ON customer_activities(session_id, created_at);
 
 CREATE INDEX idx_cart_customer ON cart_items(customer_id);
 CREATE INDEX idx_wishlist_customer ON wishlist_items(customer_id);
@@ -196,6 +349,22 @@ CREATE INDEX idx_recommendation_logs_customer ON recommendation_logs(customer_id
 CREATE INDEX idx_recommendation_logs_type ON recommendation_logs(recommendation_type);
 CREATE INDEX idx_recommendation_logs_created ON recommendation_logs(created_at DESC);
 
+CREATE INDEX idx_recommendation_feature_logs_recommendation ON recommendation_feature_logs(recommendation_id);
+CREATE INDEX idx_recommendation_feature_logs_created ON recommendation_feature_logs(created_at DESC);
+
+CREATE INDEX idx_strategy_bandit_decisions_created ON strategy_bandit_decisions(created_at DESC);
+
+CREATE INDEX idx_merchandising_rules_active ON merchandising_rules(is_active, priority DESC);
+
+CREATE INDEX idx_product_popularity_history_product ON product_popularity_history(product_id, computed_at DESC);
+CREATE INDEX idx_product_popularity_history_pending_sync ON product_popularity_history(product_id) WHERE significant = true AND kb_synced_at IS NULL;
+CREATE INDEX idx_recommendation_feedback_customer ON recommendation_feedback(customer_id, suppress_until DESC);
+CREATE INDEX idx_recommendation_feedback_recommendation ON recommendation_feedback(recommendation_id, product_id);
+CREATE INDEX idx_recommendation_impressions_customer ON recommendation_impressions(customer_id, context_type, created_at DESC);
+CREATE INDEX idx_recommendation_impressions_recommendation ON recommendation_impressions(recommendation_id, product_id);
+CREATE INDEX idx_recommendation_items_log ON recommendation_items(recommendation_log_id, position);
+CREATE INDEX idx_recommendation_items_customer ON recommendation_items(customer_id, created_at DESC);
+
 -- Triggers for automatic timestamp updates
 CREATE OR REPLACE FUNCTION update_updated_at_column()
 RETURNS TRIGGER AS $$
@@ -211,6 +380,32 @@ CREATE TRIGGER update_customers_updated_at BEFORE UPDATE ON customers FOR EACH R
 CREATE TRIGGER update_orders_updated_at BEFORE UPDATE ON orders FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
 CREATE TRIGGER update_reviews_updated_at BEFORE UPDATE ON product_reviews FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
 CREATE TRIGGER update_cart_updated_at BEFORE UPDATE ON cart_items FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+CREATE TRIGGER update_experiments_updated_at BEFORE UPDATE ON experiments FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+CREATE TRIGGER update_merchandising_rules_updated_at BEFORE UPDATE ON merchandising_rules FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+CREATE TRIGGER update_search_synonyms_updated_at BEFORE UPDATE ON search_synonyms FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Lexical search document: NFKC folds full-width and half-width forms, then letters are lowercased
+-- and katakana is folded to hiragana, matching the application's search text normalization
+CREATE OR REPLACE FUNCTION update_products_search_document()
+RETURNS TRIGGER AS $$
+BEGIN
+    NEW.search_document = translate(
+        lower(normalize(concat_ws(' ',
+            NEW.name,
+            NEW.brand,
+            array_to_string(NEW.tags, ' '),
+            CASE WHEN jsonb_typeof(NEW.features) = 'object'
+                THEN (SELECT string_agg(value, ' ') FROM jsonb_each_text(NEW.features)) END,
+            NEW.description
+        ), NFKC)),
+        'ァアィイゥウェエォオカガキギクグケゲコゴサザシジスズセゼソゾタダチヂッツヅテデトドナニヌネノハバパヒビピフブプヘベペホボポマミムメモャヤュユョヨラリルレロヮワヰヱヲンヴヵヶヽヾ',
+        'ぁあぃいぅうぇえぉおかがきぎくぐけげこごさざしじすずせぜそぞただちぢっつづてでとどなにぬねのはばぱひびぴふぶぷへべぺほぼぽまみむめもゃやゅゆょよらりるれろゎわゐゑをんゔゕゖゝゞ'
+    );
+    RETURN NEW;
+END;
+$$ language 'plpgsql';
+
+CREATE TRIGGER update_products_search_document BEFORE INSERT OR UPDATE OF name, brand, tags, features, description ON products FOR EACH ROW EXECUTE FUNCTION update_products_search_document();
 
 -- Views for common recommendation queries
 CREATE VIEW customer_purchase_summary AS
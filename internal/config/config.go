@@ -33,6 +33,9 @@ type Config struct {
 	OpenSearchPassword  string `json:"opensearch_password"`
 	OpenSearchIndexName string `json:"opensearch_index_name"`
 
+	// Recommendation tuning configuration
+	RecommendationTuningPath string `json:"recommendation_tuning_path"`
+
 	// Logging configuration
 	LogLevel string `json:"log_level"`
 }
@@ -59,6 +62,9 @@ func Load() (*Config, error) {
 		OpenSearchPassword:  getEnvWithDefault("OPENSEARCH_PASSWORD", ""),
 		OpenSearchIndexName: getEnvWithDefault("OPENSEARCH_INDEX_NAME", "product-vectors"),
 
+		// Recommendation tuning configuration
+		RecommendationTuningPath: getEnvWithDefault("RECOMMENDATION_TUNING_PATH", ""),
+
 		LogLevel: getEnvWithDefault("LOG_LEVEL", "info"),
 	}
 
@@ -0,0 +1,40 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RecommendationTypeAuto lets the strategy bandit choose the recommendation type per request
+const RecommendationTypeAuto = "auto"
+
+// BanditArmStats holds the accumulated rewards of one strategy for a service and context
+type BanditArmStats struct {
+	Service     string  `json:"service"`
+	ContextType string  `json:"context_type"`
+	Arm         string  `json:"arm"`       // Recommendation type, e.g. "collaborative"
+	Successes   float64 `json:"successes"` // Sum of rewards
+	Failures    float64 `json:"failures"`  // Sum of (1 - reward)
+	Pulls       int     `json:"pulls"`
+}
+
+// BanditDecision records a strategy chosen by the bandit for a logged recommendation
+type BanditDecision struct {
+	RecommendationID uuid.UUID `json:"recommendation_id"`
+	CustomerID       uuid.UUID `json:"customer_id"`
+	Service          string    `json:"service"`
+	ContextType      string    `json:"context_type"`
+	Arm              string    `json:"arm"`
+	Propensity       float64   `json:"propensity"` // Probability that the policy chose this arm
+	Reward           float64   `json:"reward"`     // Highest reward observed so far
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+// StrategySelection describes how an "auto" request chose its recommendation type
+type StrategySelection struct {
+	Policy     string   `json:"policy"` // "thompson_sampling"
+	Arm        string   `json:"arm"`
+	Propensity float64  `json:"propensity"`
+	Candidates []string `json:"candidates"`
+}
@@ -0,0 +1,68 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Bundle item roles
+const (
+	BundleRoleAnchor     = "anchor"     // Seed or cart product the bundle is built around
+	BundleRoleComplement = "complement" // Main complementary product from another category
+	BundleRoleAccessory  = "accessory"  // Low-priced add-on relative to the anchors
+)
+
+// Budget sources of a bundle
+const (
+	BundleBudgetSourceRequest       = "request"
+	BundleBudgetSourcePriceRangeMax = "price_range_max"
+	BundleBudgetSourceNone          = "none"
+)
+
+// BundleRequest represents a request to complete a set around a seed product or a cart
+type BundleRequest struct {
+	CustomerID         uuid.UUID   `json:"customer_id" binding:"required"`
+	SeedProductID      *uuid.UUID  `json:"seed_product_id,omitempty"`
+	ProductIDs         []uuid.UUID `json:"product_ids,omitempty"`         // Explicit cart contents
+	UseCart            bool        `json:"use_cart,omitempty"`            // Use the customer's saved cart
+	Budget             *float64    `json:"budget,omitempty"`              // Total bundle budget; defaults to the customer's price_range_max
+	MaxItems           int         `json:"max_items,omitempty"`           // Complementary items to add
+	IncludeExplanation bool        `json:"include_explanation,omitempty"` // Generate an LLM explanation of the bundle
+}
+
+// BundleCoPurchase counts how often a product was bought in the same order as the anchors
+type BundleCoPurchase struct {
+	ProductID  uuid.UUID `json:"product_id"`
+	CategoryID int       `json:"category_id"`
+	Count      int       `json:"count"`
+}
+
+// CategoryCoPurchase counts orders containing both an anchor category and another category
+type CategoryCoPurchase struct {
+	CategoryID int `json:"category_id"`
+	Count      int `json:"count"`
+}
+
+// BundleItem is a product in a bundle with its role
+type BundleItem struct {
+	Product   ProductRecommendationV2 `json:"product"`
+	Role      string                  `json:"role"`      // "anchor", "complement" or "accessory"
+	Relevance float64                 `json:"relevance"` // Combined relevance used by the solver; 0 for anchors
+}
+
+// BundleResponse represents a budget-constrained bundle
+type BundleResponse struct {
+	RecommendationID uuid.UUID    `json:"recommendation_id"`
+	CustomerID       uuid.UUID    `json:"customer_id"`
+	Items            []BundleItem `json:"items"`
+	Budget           *float64     `json:"budget,omitempty"`
+	BudgetSource     string       `json:"budget_source"` // "request", "price_range_max" or "none"
+	BundleTotal      float64      `json:"bundle_total"`
+	OriginalTotal    float64      `json:"original_total"` // Total at original prices
+	DiscountAmount   float64      `json:"discount_amount"`
+	DiscountPercent  float64      `json:"discount_percent"`
+	TotalRelevance   float64      `json:"total_relevance"`
+	Explanation      string       `json:"explanation,omitempty"`
+	GeneratedAt      time.Time    `json:"generated_at"`
+}
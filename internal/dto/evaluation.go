@@ -0,0 +1,47 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EvaluationOrderLine is one purchased product from order history used for offline evaluation
+type EvaluationOrderLine struct {
+	OrderID    uuid.UUID `json:"order_id"`
+	CustomerID uuid.UUID `json:"customer_id"`
+	ProductID  uuid.UUID `json:"product_id"`
+	CategoryID int       `json:"category_id"`
+	Status     string    `json:"status"`
+	UnitPrice  float64   `json:"unit_price"`
+	Quantity   int       `json:"quantity"`
+	OrderedAt  time.Time `json:"ordered_at"`
+}
+
+// EvaluationReport is the result of a time-split replay of historical orders
+type EvaluationReport struct {
+	GeneratedAt     time.Time            `json:"generated_at"`
+	Cutoff          time.Time            `json:"cutoff"`       // Strategies only see orders before this time
+	HorizonDays     int                  `json:"horizon_days"` // Purchases within this many days after the cutoff are ground truth
+	K               int                  `json:"k"`
+	LLMMode         string               `json:"llm_mode"` // "stub" for reproducible runs, "bedrock" for live model calls
+	Customers       int                  `json:"customers"`
+	TrainOrderLines int                  `json:"train_order_lines"`
+	TestOrderLines  int                  `json:"test_order_lines"`
+	CatalogSize     int                  `json:"catalog_size"`
+	Strategies      []StrategyEvaluation `json:"strategies"`
+}
+
+// StrategyEvaluation holds averaged top-K metrics for one strategy
+type StrategyEvaluation struct {
+	Strategy     string  `json:"strategy"`
+	Evaluated    int     `json:"evaluated"` // Customers the strategy produced a list for
+	Failed       int     `json:"failed"`    // Customers the strategy returned an error for; averaged in with zero relevance
+	PrecisionAtK float64 `json:"precision_at_k"`
+	RecallAtK    float64 `json:"recall_at_k"`
+	NDCG         float64 `json:"ndcg"`
+	MAP          float64 `json:"map"`
+	Coverage     float64 `json:"coverage"`  // Share of the active catalog recommended to anyone
+	Novelty      float64 `json:"novelty"`   // Mean self-information of recommended products in bits
+	Diversity    float64 `json:"diversity"` // Mean share of recommended pairs in different categories
+}
@@ -0,0 +1,84 @@
+package dto
+
+import (
+	"time"
+)
+
+// Experiment definition sources
+const (
+	ExperimentSourceConfig   = "config"
+	ExperimentSourceDatabase = "database"
+)
+
+// Experiment is an A/B test that splits customers deterministically across variants
+type Experiment struct {
+	ID           string              `json:"id"` // Stable identifier stamped into algorithm_version
+	Name         string              `json:"name"`
+	Description  string              `json:"description,omitempty"`
+	IsActive     bool                `json:"is_active"`
+	Service      string              `json:"service,omitempty"`       // "v1", "v2"; empty applies to both
+	ContextTypes []string            `json:"context_types,omitempty"` // Empty matches all contexts
+	Variants     []ExperimentVariant `json:"variants"`
+	StartsAt     *time.Time          `json:"starts_at,omitempty"`
+	EndsAt       *time.Time          `json:"ends_at,omitempty"`
+	Source       string              `json:"source,omitempty"` // "config" or "database"
+}
+
+// ExperimentVariant overrides recommendation settings for the customers assigned to it.
+// Empty fields keep the request or service defaults, so a variant with no overrides is a control.
+type ExperimentVariant struct {
+	Name                  string             `json:"name"`
+	Weight                float64            `json:"weight,omitempty"`              // Relative traffic share; all zero splits evenly
+	RecommendationType    string             `json:"recommendation_type,omitempty"` // e.g. "hybrid", "collaborative", "auto"
+	StrategyWeights       map[string]float64 `json:"strategy_weights,omitempty"`    // Hybrid blend weights
+	FusionMethod          string             `json:"fusion_method,omitempty"`
+	PromptTemplateVersion string             `json:"prompt_template_version,omitempty"` // V2 prompt template version
+	ModelID               string             `json:"model_id,omitempty"`                // Bedrock model used for AI explanations
+}
+
+// ExperimentAssignment identifies the variant a customer was assigned to
+type ExperimentAssignment struct {
+	ExperimentID string            `json:"experiment_id"`
+	Variant      string            `json:"variant"`
+	Overrides    ExperimentVariant `json:"-"`
+}
+
+// ExperimentListResponse represents the list of experiments
+type ExperimentListResponse struct {
+	Experiments []Experiment `json:"experiments"`
+	Total       int          `json:"total"`
+}
+
+// ExperimentVariantCounts holds raw outcome counts of a variant from recommendation logs
+type ExperimentVariantCounts struct {
+	Variant     string `json:"variant"`
+	Impressions int    `json:"impressions"` // Logged recommendation responses
+	Clicks      int    `json:"clicks"`      // Responses with at least one click
+	Conversions int    `json:"conversions"` // Responses with at least one purchase
+}
+
+// ConfidenceInterval is a two-sided interval for a rate
+type ConfidenceInterval struct {
+	Lower float64 `json:"lower"`
+	Upper float64 `json:"upper"`
+}
+
+// ExperimentVariantResult reports rates of a variant with confidence intervals
+type ExperimentVariantResult struct {
+	ExperimentVariantCounts
+	CTR           float64            `json:"ctr"`
+	CTRInterval   ConfidenceInterval `json:"ctr_interval"`
+	CVR           float64            `json:"cvr"`
+	CVRInterval   ConfidenceInterval `json:"cvr_interval"`
+	CTRLift       *float64           `json:"ctr_lift,omitempty"` // Relative to the first (control) variant
+	CVRLift       *float64           `json:"cvr_lift,omitempty"`
+	TrafficWeight float64            `json:"traffic_weight"` // Configured share of customers
+}
+
+// ExperimentResults represents the outcome of an experiment per variant
+type ExperimentResults struct {
+	Experiment      Experiment                `json:"experiment"`
+	Variants        []ExperimentVariantResult `json:"variants"`
+	ConfidenceLevel float64                   `json:"confidence_level"`
+	GeneratedAt     time.Time                 `json:"generated_at"`
+}
@@ -0,0 +1,50 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Feedback reason codes
+const (
+	FeedbackReasonAlreadyOwn   = "already_own"
+	FeedbackReasonNotMyTaste   = "not_my_taste"
+	FeedbackReasonTooExpensive = "too_expensive"
+	FeedbackReasonNotRelevant  = "not_relevant"
+	FeedbackReasonOther        = "other"
+)
+
+// Feedback suppression scopes
+const (
+	FeedbackScopeProduct  = "product"
+	FeedbackScopeBrand    = "brand"
+	FeedbackScopeCategory = "category"
+)
+
+// RecommendationFeedbackRequest represents a "not interested" dismissal of a recommended product
+type RecommendationFeedbackRequest struct {
+	CustomerID       uuid.UUID  `json:"customer_id" binding:"required"`
+	ProductID        uuid.UUID  `json:"product_id" binding:"required"`
+	RecommendationID *uuid.UUID `json:"recommendation_id,omitempty"` // Recommendation (session) the product was shown in
+	ReasonCode       string     `json:"reason_code" binding:"required"`
+	Scope            string     `json:"scope,omitempty"` // "product" (default), "brand" or "category"
+	ContextType      string     `json:"context_type,omitempty"`
+	Comment          string     `json:"comment,omitempty"`
+}
+
+// RecommendationFeedback represents a recorded dismissal and the suppression it causes
+type RecommendationFeedback struct {
+	ID               uuid.UUID  `json:"id"`
+	CustomerID       uuid.UUID  `json:"customer_id"`
+	ProductID        uuid.UUID  `json:"product_id"`
+	RecommendationID *uuid.UUID `json:"recommendation_id,omitempty"`
+	ReasonCode       string     `json:"reason_code"`
+	Scope            string     `json:"scope"`
+	Brand            string     `json:"brand,omitempty"` // Brand of the product when dismissed
+	CategoryID       int        `json:"category_id"`     // Category of the product when dismissed
+	ContextType      string     `json:"context_type,omitempty"`
+	Comment          string     `json:"comment,omitempty"`
+	SuppressUntil    time.Time  `json:"suppress_until"`
+	CreatedAt        time.Time  `json:"created_at"`
+}
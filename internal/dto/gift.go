@@ -0,0 +1,47 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// IntentGiftSuggestion is the query intent for gift searches produced by query analysis
+const IntentGiftSuggestion = "gift_suggestion"
+
+// GiftRecipient describes who a gift is for
+type GiftRecipient struct {
+	AgeRange     string   `json:"age_range,omitempty"`    // e.g. "20s", "60+"
+	Gender       string   `json:"gender,omitempty"`       // "male", "female", "other"
+	Relationship string   `json:"relationship,omitempty"` // e.g. "parent", "partner", "friend", "colleague", "boss", "child"
+	Interests    []string `json:"interests,omitempty"`
+}
+
+// GiftRequest represents a request for gift recommendations
+type GiftRequest struct {
+	CustomerID        uuid.UUID     `json:"customer_id" binding:"required"` // The giver
+	Recipient         GiftRecipient `json:"recipient"`
+	Occasion          string        `json:"occasion,omitempty"` // e.g. "birthday", "お中元", "お歳暮", "christmas", "mothers_day"
+	BudgetMin         *float64      `json:"budget_min,omitempty"`
+	BudgetMax         *float64      `json:"budget_max,omitempty"`
+	CategoryID        *int          `json:"category_id,omitempty"`
+	Message           string        `json:"message,omitempty"`           // Free-text description of the recipient or the gift
+	UseGiverHistory   bool          `json:"use_giver_history,omitempty"` // Use the giver's preferred brands as a taste hint
+	Limit             int           `json:"limit,omitempty"`
+	EnableExplanation bool          `json:"enable_explanation,omitempty"` // Generate gift-specific reasons with the LLM
+}
+
+// GiftResponse represents gift recommendations for a recipient
+type GiftResponse struct {
+	RecommendationID uuid.UUID                 `json:"recommendation_id"`
+	CustomerID       uuid.UUID                 `json:"customer_id"`
+	Recipient        GiftRecipient             `json:"recipient"`
+	Occasion         string                    `json:"occasion,omitempty"`
+	BudgetMin        *float64                  `json:"budget_min,omitempty"`
+	BudgetMax        *float64                  `json:"budget_max,omitempty"`
+	Query            string                    `json:"query"`          // Knowledge base query built from the recipient description
+	ExcludedOwned    int                       `json:"excluded_owned"` // Candidates dropped because the giver already bought them
+	Recommendations  []ProductRecommendationV2 `json:"recommendations"`
+	ProcessingTimeMs int64                     `json:"processing_time_ms"`
+	GeneratedAt      time.Time                 `json:"generated_at"`
+}
@@ -0,0 +1,32 @@
+package dto
+
+import "github.com/google/uuid"
+
+// LexicalSearchFilter restricts lexical search candidates. Empty fields do not filter.
+type LexicalSearchFilter struct {
+	CategoryIDs        []int       `json:"category_ids,omitempty"`
+	ExcludeCategoryIDs []int       `json:"exclude_category_ids,omitempty"`
+	ExcludeProductIDs  []uuid.UUID `json:"exclude_product_ids,omitempty"`
+	PriceMin           *float64    `json:"price_min,omitempty"`
+	PriceMax           *float64    `json:"price_max,omitempty"`
+	RatingMin          *float64    `json:"rating_min,omitempty"`
+}
+
+// LexicalDocument is a product's searchable text, returned as a lexical search candidate
+type LexicalDocument struct {
+	ProductID   uuid.UUID `json:"product_id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Brand       string    `json:"brand"`
+	Tags        []string  `json:"tags"`
+	Features    string    `json:"features"` // Feature values joined by spaces
+	Popularity  int       `json:"popularity"`
+	Length      int       `json:"length"` // Characters in the whole search document
+}
+
+// LexicalCorpusStats holds the collection statistics BM25 needs for a set of terms
+type LexicalCorpusStats struct {
+	DocumentCount     int            `json:"document_count"`
+	AverageLength     float64        `json:"average_length"`
+	DocumentFrequency map[string]int `json:"document_frequency"` // Documents containing each term
+}
@@ -0,0 +1,72 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Merchandising rule action types
+const (
+	MerchandisingActionPin          = "pin"
+	MerchandisingActionBoost        = "boost"
+	MerchandisingActionBury         = "bury"
+	MerchandisingActionBlockProduct = "block_product"
+	MerchandisingActionBlockBrand   = "block_brand"
+)
+
+// MerchandisingRule represents a merchandiser-defined rule applied after ranking
+type MerchandisingRule struct {
+	ID          uuid.UUID               `json:"id"`
+	Name        string                  `json:"name"`
+	Description string                  `json:"description,omitempty"`
+	IsActive    bool                    `json:"is_active"`
+	Priority    int                     `json:"priority"` // Higher priority rules win conflicts with lower priority rules
+	Conditions  MerchandisingConditions `json:"conditions"`
+	Action      MerchandisingAction     `json:"action"`
+	CreatedAt   time.Time               `json:"created_at"`
+	UpdatedAt   time.Time               `json:"updated_at"`
+}
+
+// MerchandisingConditions restricts when a rule fires; empty fields match everything
+type MerchandisingConditions struct {
+	ContextTypes     []string   `json:"context_types,omitempty"`     // "homepage", "product_page", "cart", ...
+	CategoryIDs      []int      `json:"category_ids,omitempty"`      // Restricts the rule to products in these categories
+	CustomerSegments []string   `json:"customer_segments,omitempty"` // "premium", "new", "standard"
+	StartsAt         *time.Time `json:"starts_at,omitempty"`
+	EndsAt           *time.Time `json:"ends_at,omitempty"`
+}
+
+// MerchandisingAction describes what a rule does to the ranked list
+type MerchandisingAction struct {
+	Type       string     `json:"type"`                  // "pin", "boost", "bury", "block_product", "block_brand"
+	ProductID  *uuid.UUID `json:"product_id,omitempty"`  // Target product for pin, boost, bury and block_product
+	Brand      string     `json:"brand,omitempty"`       // Target brand for boost, bury and block_brand
+	CategoryID *int       `json:"category_id,omitempty"` // Target category for boost and bury
+	Factor     float64    `json:"factor,omitempty"`      // Multiplier for boost (> 1) or divisor for bury (> 1)
+	Slot       int        `json:"slot,omitempty"`        // 1-based position for pin
+}
+
+// MerchandisingRuleRequest represents a request to create or update a merchandising rule
+type MerchandisingRuleRequest struct {
+	Name        string                  `json:"name" binding:"required"`
+	Description string                  `json:"description,omitempty"`
+	IsActive    *bool                   `json:"is_active,omitempty"` // Defaults to true
+	Priority    int                     `json:"priority,omitempty"`
+	Conditions  MerchandisingConditions `json:"conditions"`
+	Action      MerchandisingAction     `json:"action"`
+}
+
+// MerchandisingRuleListResponse represents the list of merchandising rules
+type MerchandisingRuleListResponse struct {
+	Rules []MerchandisingRule `json:"rules"`
+	Total int                 `json:"total"`
+}
+
+// FiredRule records a merchandising rule that changed a recommendation response
+type FiredRule struct {
+	RuleID     uuid.UUID   `json:"rule_id"`
+	Name       string      `json:"name"`
+	Action     string      `json:"action"`
+	ProductIDs []uuid.UUID `json:"product_ids,omitempty"` // Products affected by the rule
+}
@@ -0,0 +1,34 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PopularitySignals holds time-decayed interaction counts for one product
+type PopularitySignals struct {
+	ProductID uuid.UUID `json:"product_id"`
+	Orders    float64   `json:"orders"`    // Decayed units ordered
+	Views     float64   `json:"views"`     // Decayed product views
+	Carts     float64   `json:"carts"`     // Decayed add-to-cart events
+	Wishlists float64   `json:"wishlists"` // Decayed wishlist adds
+}
+
+// ProductPopularity is a recomputed popularity score for one product
+type ProductPopularity struct {
+	ProductID     uuid.UUID         `json:"product_id"`
+	PreviousScore int               `json:"previous_score"`
+	Score         int               `json:"score"`
+	RawScore      float64           `json:"raw_score"` // Weighted sum of the decayed signals
+	Signals       PopularitySignals `json:"signals"`
+	Significant   bool              `json:"significant"` // Changed enough to re-sync knowledge base metadata
+}
+
+// PopularityRunSummary summarizes one popularity recomputation
+type PopularityRunSummary struct {
+	ComputedAt  time.Time `json:"computed_at"`
+	Products    int       `json:"products"`    // Active products scored
+	Changed     int       `json:"changed"`     // Products whose popularity_score was updated
+	Significant int       `json:"significant"` // Changes flagged for knowledge base re-sync
+}
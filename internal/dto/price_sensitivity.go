@@ -0,0 +1,28 @@
+package dto
+
+// Price sensitivity segments
+const (
+	PriceSegmentDealSeeker = "deal_seeker"
+	PriceSegmentNeutral    = "neutral"
+	PriceSegmentPremium    = "premium"
+)
+
+// PricePoint is one purchased order item compared with its list price and category median
+type PricePoint struct {
+	CategoryID          int     `json:"category_id"`
+	UnitPrice           float64 `json:"unit_price"`            // Price paid
+	ListPrice           float64 `json:"list_price"`            // Original price, or the regular price when not discounted
+	CategoryMedianPrice float64 `json:"category_median_price"` // Median price of products in the category
+	Quantity            int     `json:"quantity"`
+}
+
+// PriceSensitivity summarizes how price-driven a customer's purchases are
+type PriceSensitivity struct {
+	Score            float64 `json:"score"`              // -1 (premium-leaning) to 1 (deal-seeking), shrunk towards 0 for few purchases
+	Segment          string  `json:"segment"`            // "deal_seeker", "neutral" or "premium"
+	DiscountShare    float64 `json:"discount_share"`     // Share of units bought below list price
+	AvgDiscountDepth float64 `json:"avg_discount_depth"` // Mean discount of units bought below list price
+	PriceIndex       float64 `json:"price_index"`        // Mean paid price relative to the category median (1 = median)
+	Purchases        int     `json:"purchases"`
+	IsPremium        bool    `json:"is_premium"`
+}
@@ -0,0 +1,20 @@
+package dto
+
+import (
+	"github.com/google/uuid"
+)
+
+// CandidateFeatures holds the ranking features logged for a recommended product
+type CandidateFeatures struct {
+	ProductID uuid.UUID          `json:"product_id"`
+	Position  int                `json:"position"` // 1-based position in the returned list
+	Features  map[string]float64 `json:"features"`
+}
+
+// RankingTrainingExample is a logged candidate joined with its observed interaction label
+type RankingTrainingExample struct {
+	RecommendationID uuid.UUID          `json:"recommendation_id"`
+	ProductID        uuid.UUID          `json:"product_id"`
+	Features         map[string]float64 `json:"features"`
+	Label            int                `json:"label"` // 0 = no interaction, 1 = clicked, 2 = purchased
+}
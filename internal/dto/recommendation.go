@@ -9,12 +9,13 @@ import (
 // RecommendationRequest represents a request for product recommendations
 type RecommendationRequest struct {
 	CustomerID         uuid.UUID  `json:"customer_id" binding:"required"`
-	RecommendationType string     `json:"recommendation_type,omitempty"` // "similar", "collaborative", "content_based", "hybrid"
+	RecommendationType string     `json:"recommendation_type,omitempty"` // "similar", "collaborative", "content_based", "hybrid", "auto"
 	ContextType        string     `json:"context_type,omitempty"`        // "homepage", "product_page", "cart", "checkout"
 	ProductID          *uuid.UUID `json:"product_id,omitempty"`          // For product-based recommendations
 	CategoryID         *int       `json:"category_id,omitempty"`         // For category-based recommendations
 	Limit              int        `json:"limit,omitempty"`               // Number of recommendations to return (default: 10)
 	ExcludeOwned       bool       `json:"exclude_owned,omitempty"`       // Exclude already purchased products
+	Diversity          *float64   `json:"diversity,omitempty"`           // Diversity trade-off from 0 (relevance only) to 1 (maximum diversity)
 }
 
 // RecommendationResponse represents the response containing product recommendations
@@ -48,12 +49,18 @@ type ProductRecommendation struct {
 
 // RecommendationMetadata contains additional information about the recommendation process
 type RecommendationMetadata struct {
-	AlgorithmVersion string    `json:"algorithm_version"`
-	ProcessingTimeMs int64     `json:"processing_time_ms"`
-	TotalProducts    int       `json:"total_products"`
-	FilteredProducts int       `json:"filtered_products"`
-	AIModelUsed      string    `json:"ai_model_used,omitempty"`
-	SessionID        uuid.UUID `json:"session_id,omitempty"`
+	AlgorithmVersion  string                `json:"algorithm_version"`
+	ProcessingTimeMs  int64                 `json:"processing_time_ms"`
+	TotalProducts     int                   `json:"total_products"`
+	FilteredProducts  int                   `json:"filtered_products"`
+	AIModelUsed       string                `json:"ai_model_used,omitempty"`
+	SessionID         uuid.UUID             `json:"session_id,omitempty"`
+	BlendProfile      string                `json:"blend_profile,omitempty"`
+	StrategyWeights   map[string]float64    `json:"strategy_weights,omitempty"`
+	FusionMethod      string                `json:"fusion_method,omitempty"`
+	FiredRules        []FiredRule           `json:"fired_rules,omitempty"`
+	StrategySelection *StrategySelection    `json:"strategy_selection,omitempty"` // Set for "auto" recommendations
+	Experiment        *ExperimentAssignment `json:"experiment,omitempty"`         // Set when the customer is in an A/B experiment
 }
 
 // CustomerProfile represents customer data used for recommendations
@@ -70,6 +77,8 @@ type CustomerProfile struct {
 	OrderCount          int            `json:"order_count"`
 	PurchaseHistory     []PurchaseItem `json:"purchase_history,omitempty"`
 	RecentActivities    []ActivityItem `json:"recent_activities,omitempty"`
+	DislikedCategories  []int          `json:"disliked_categories,omitempty"` // From "not interested" feedback
+	DislikedBrands      []string       `json:"disliked_brands,omitempty"`     // From "not interested" feedback
 }
 
 // PurchaseItem represents a purchased product in customer history
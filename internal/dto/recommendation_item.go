@@ -0,0 +1,47 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RecommendationItem is a served recommendation, stored so that it can be explained later
+type RecommendationItem struct {
+	ID                  uuid.UUID                    `json:"id"`                    // recommendation_item_id returned with the product
+	RecommendationLogID uuid.UUID                    `json:"recommendation_log_id"` // Session ID of the response the item was served in
+	CustomerID          uuid.UUID                    `json:"customer_id"`
+	ProductID           uuid.UUID                    `json:"product_id"`
+	Position            int                          `json:"position"` // 1-based position in the returned list
+	RecommendationType  string                       `json:"recommendation_type"`
+	ContextType         string                       `json:"context_type"`
+	AlgorithmVersion    string                       `json:"algorithm_version"`
+	Strategies          []string                     `json:"strategies"` // Strategies that produced the product
+	ScoreBreakdown      RecommendationScoreBreakdown `json:"score_breakdown"`
+	Filters             RecommendationFilters        `json:"filters"`
+	Product             ProductRecommendationV2      `json:"product"` // Product as served, including relevance context
+	CreatedAt           time.Time                    `json:"created_at"`
+}
+
+// RecommendationScoreBreakdown records how a served recommendation was scored
+type RecommendationScoreBreakdown struct {
+	FinalScore            float64            `json:"final_score"`                      // Confidence score after post-ranking
+	RetrievalScore        float64            `json:"retrieval_score"`                  // Score before post-ranking stages
+	RetrievalRank         int                `json:"retrieval_rank"`                   // 1-based rank before post-ranking stages; 0 if added by a stage
+	SimilarityScore       float64            `json:"similarity_score,omitempty"`       // Vector similarity score
+	StrategyContributions map[string]float64 `json:"strategy_contributions,omitempty"` // Share of the fused score by strategy
+	Features              map[string]float64 `json:"features,omitempty"`               // Ranking features computed by the learning-to-rank stage
+}
+
+// RecommendationFilters records the request filters a recommendation was generated under
+type RecommendationFilters struct {
+	QueryText       string             `json:"query_text,omitempty"`
+	ProductID       *uuid.UUID         `json:"product_id,omitempty"`
+	CategoryID      *int               `json:"category_id,omitempty"`
+	PriceRangeMin   *float64           `json:"price_range_min,omitempty"`
+	PriceRangeMax   *float64           `json:"price_range_max,omitempty"`
+	ExcludeOwned    bool               `json:"exclude_owned,omitempty"`
+	BlendProfile    string             `json:"blend_profile,omitempty"`
+	FusionMethod    string             `json:"fusion_method,omitempty"`
+	StrategyWeights map[string]float64 `json:"strategy_weights,omitempty"`
+}
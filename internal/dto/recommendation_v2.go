@@ -9,7 +9,7 @@ import (
 // RecommendationRequestV2 represents an enhanced request for product recommendations using RAG and vector search
 type RecommendationRequestV2 struct {
 	CustomerID         uuid.UUID           `json:"customer_id" binding:"required"`
-	RecommendationType string              `json:"recommendation_type,omitempty"`  // "hybrid", "semantic", "collaborative", "vector_search", "knowledge_based"
+	RecommendationType string              `json:"recommendation_type,omitempty"`  // "hybrid", "semantic", "collaborative", "vector_search", "knowledge_based", "session_sequence", "regional", "auto"
 	ContextType        string              `json:"context_type,omitempty"`         // "homepage", "product_page", "cart", "checkout", "search_results"
 	QueryText          string              `json:"query_text,omitempty"`           // Natural language query for semantic search
 	ProductID          *uuid.UUID          `json:"product_id,omitempty"`           // For product-based recommendations
@@ -20,6 +20,11 @@ type RecommendationRequestV2 struct {
 	ExcludeOwned       bool                `json:"exclude_owned,omitempty"`        // Exclude already purchased products
 	EnableExplanation  bool                `json:"enable_explanation,omitempty"`   // Include AI-generated explanations
 	VectorSearchConfig *VectorSearchConfig `json:"vector_search_config,omitempty"` // Advanced vector search configuration
+	StrategyWeights    map[string]float64  `json:"strategy_weights,omitempty"`     // Per-request hybrid strategy weight overrides
+	FusionMethod       string              `json:"fusion_method,omitempty"`        // "rrf", "normalized_score", "round_robin"
+	Diversity          *float64            `json:"diversity,omitempty"`            // Diversity trade-off from 0 (relevance only) to 1 (maximum diversity)
+	BrowsingSessionID  *uuid.UUID          `json:"browsing_session_id,omitempty"`  // Current browsing session (customer_activities.session_id) for session-aware strategies
+	RecentProductIDs   []uuid.UUID         `json:"recent_product_ids,omitempty"`   // Products viewed in the current session, oldest first
 }
 
 // VectorSearchConfig represents configuration for vector search operations
@@ -28,6 +33,7 @@ type VectorSearchConfig struct {
 	SearchType          string                 `json:"search_type,omitempty"`          // "semantic", "hybrid", "keyword"
 	RerankerEnabled     bool                   `json:"reranker_enabled,omitempty"`     // Enable result reranking
 	MetadataFilters     map[string]interface{} `json:"metadata_filters,omitempty"`     // Additional metadata-based filters
+	HybridWeights       map[string]float64     `json:"hybrid_weights,omitempty"`       // "lexical" and "vector" score weights for hybrid search
 }
 
 // RecommendationResponseV2 represents the enhanced response containing product recommendations with RAG capabilities
@@ -44,25 +50,26 @@ type RecommendationResponseV2 struct {
 
 // ProductRecommendationV2 represents an enhanced product recommendation with AI-powered features
 type ProductRecommendationV2 struct {
-	ProductID        uuid.UUID          `json:"product_id"`
-	Name             string             `json:"name"`
-	Description      string             `json:"description,omitempty"`
-	Price            float64            `json:"price"`
-	OriginalPrice    *float64           `json:"original_price,omitempty"`
-	Brand            string             `json:"brand,omitempty"`
-	CategoryID       int                `json:"category_id"`
-	CategoryName     string             `json:"category_name"`
-	RatingAverage    float64            `json:"rating_average"`
-	RatingCount      int                `json:"rating_count"`
-	PopularityScore  int                `json:"popularity_score"`
-	ConfidenceScore  float64            `json:"confidence_score"` // AI-generated confidence
-	SimilarityScore  float64            `json:"similarity_score"` // Vector similarity score
-	Reason           string             `json:"reason"`           // AI-generated explanation
-	Tags             []string           `json:"tags,omitempty"`
-	ImageURL         string             `json:"image_url,omitempty"`
-	VectorMetadata   *VectorMetadata    `json:"vector_metadata,omitempty"`
-	AIInsights       *ProductAIInsights `json:"ai_insights,omitempty"`
-	RelevanceContext []RelevanceContext `json:"relevance_context,omitempty"`
+	RecommendationItemID *uuid.UUID         `json:"recommendation_item_id,omitempty"` // Stable ID of the served recommendation, used for explanations
+	ProductID            uuid.UUID          `json:"product_id"`
+	Name                 string             `json:"name"`
+	Description          string             `json:"description,omitempty"`
+	Price                float64            `json:"price"`
+	OriginalPrice        *float64           `json:"original_price,omitempty"`
+	Brand                string             `json:"brand,omitempty"`
+	CategoryID           int                `json:"category_id"`
+	CategoryName         string             `json:"category_name"`
+	RatingAverage        float64            `json:"rating_average"`
+	RatingCount          int                `json:"rating_count"`
+	PopularityScore      int                `json:"popularity_score"`
+	ConfidenceScore      float64            `json:"confidence_score"` // AI-generated confidence
+	SimilarityScore      float64            `json:"similarity_score"` // Vector similarity score
+	Reason               string             `json:"reason"`           // AI-generated explanation
+	Tags                 []string           `json:"tags,omitempty"`
+	ImageURL             string             `json:"image_url,omitempty"`
+	VectorMetadata       *VectorMetadata    `json:"vector_metadata,omitempty"`
+	AIInsights           *ProductAIInsights `json:"ai_insights,omitempty"`
+	RelevanceContext     []RelevanceContext `json:"relevance_context,omitempty"`
 }
 
 // VectorMetadata contains metadata about vector search results
@@ -104,7 +111,7 @@ type TrendAnalysis struct {
 
 // RelevanceContext explains why this product is relevant to the customer
 type RelevanceContext struct {
-	ContextType string  `json:"context_type"` // "purchase_history", "browsing_behavior", "semantic_match", "collaborative"
+	ContextType string  `json:"context_type"` // "purchase_history", "browsing_behavior", "semantic_match", "collaborative", "price_sensitivity", "seasonal", "regional", "gift_appeal"
 	Explanation string  `json:"explanation"`
 	Confidence  float64 `json:"confidence"`
 	SourceData  string  `json:"source_data,omitempty"`
@@ -112,18 +119,24 @@ type RelevanceContext struct {
 
 // RecommendationMetadataV2 contains enhanced metadata about the recommendation process
 type RecommendationMetadataV2 struct {
-	AlgorithmVersion   string              `json:"algorithm_version"`
-	ProcessingTimeMs   int64               `json:"processing_time_ms"`
-	TotalProducts      int                 `json:"total_products"`
-	FilteredProducts   int                 `json:"filtered_products"`
-	AIModelUsed        string              `json:"ai_model_used,omitempty"`
-	EmbeddingModel     string              `json:"embedding_model,omitempty"`
-	SessionID          uuid.UUID           `json:"session_id,omitempty"`
-	KnowledgeBaseUsed  bool                `json:"knowledge_base_used"`
-	VectorSearchUsed   bool                `json:"vector_search_used"`
-	SemanticSearchUsed bool                `json:"semantic_search_used"`
-	SearchStrategies   []string            `json:"search_strategies,omitempty"`
-	PerformanceMetrics *PerformanceMetrics `json:"performance_metrics,omitempty"`
+	AlgorithmVersion   string                `json:"algorithm_version"`
+	ProcessingTimeMs   int64                 `json:"processing_time_ms"`
+	TotalProducts      int                   `json:"total_products"`
+	FilteredProducts   int                   `json:"filtered_products"`
+	AIModelUsed        string                `json:"ai_model_used,omitempty"`
+	EmbeddingModel     string                `json:"embedding_model,omitempty"`
+	SessionID          uuid.UUID             `json:"session_id,omitempty"`
+	KnowledgeBaseUsed  bool                  `json:"knowledge_base_used"`
+	VectorSearchUsed   bool                  `json:"vector_search_used"`
+	SemanticSearchUsed bool                  `json:"semantic_search_used"`
+	SearchStrategies   []string              `json:"search_strategies,omitempty"`
+	BlendProfile       string                `json:"blend_profile,omitempty"`
+	StrategyWeights    map[string]float64    `json:"strategy_weights,omitempty"`
+	FusionMethod       string                `json:"fusion_method,omitempty"`
+	FiredRules         []FiredRule           `json:"fired_rules,omitempty"`
+	StrategySelection  *StrategySelection    `json:"strategy_selection,omitempty"` // Set for "auto" recommendations
+	Experiment         *ExperimentAssignment `json:"experiment,omitempty"`         // Set when the customer is in an A/B experiment
+	PerformanceMetrics *PerformanceMetrics   `json:"performance_metrics,omitempty"`
 }
 
 // PerformanceMetrics contains performance analytics
@@ -167,11 +180,16 @@ type SemanticCluster struct {
 type QueryUnderstanding struct {
 	OriginalQuery   string            `json:"original_query"`
 	ProcessedQuery  string            `json:"processed_query"`
+	QueryRewrites   []QueryRewrite    `json:"query_rewrites,omitempty"`
 	Intent          string            `json:"intent"`
 	Entities        []ExtractedEntity `json:"entities,omitempty"`
 	Sentiment       string            `json:"sentiment,omitempty"`
 	Complexity      string            `json:"complexity"` // "simple", "medium", "complex"
 	RequiredContext []string          `json:"required_context,omitempty"`
+
+	SearchType         string             `json:"search_type,omitempty"`          // Retrieval that served the query: "semantic", "hybrid" or "keyword"
+	HybridQueryType    string             `json:"hybrid_query_type,omitempty"`    // "keyword" or "natural_language", selecting default hybrid weights
+	HybridSearchWeight map[string]float64 `json:"hybrid_search_weight,omitempty"` // Applied "lexical" and "vector" weights
 }
 
 // SemanticSearchRequest represents a request for semantic search
@@ -183,6 +201,9 @@ type SemanticSearchRequest struct {
 	PriceRangeMax   *float64   `json:"price_range_max,omitempty"`
 	Limit           int        `json:"limit,omitempty"`
 	IncludeMetadata bool       `json:"include_metadata,omitempty"`
+	SearchType      string     `json:"search_type,omitempty"` // "semantic" (vector only), "hybrid" or "keyword"; the knowledge base's default search when empty
+
+	HybridWeights map[string]float64 `json:"hybrid_weights,omitempty"` // "lexical" and "vector" score weights for hybrid search
 }
 
 // SemanticSearchResponse represents the response from semantic search
@@ -203,14 +224,18 @@ type SearchMetadata struct {
 	FilterApplied    map[string]interface{} `json:"filters_applied,omitempty"`
 	RerankerUsed     bool                   `json:"reranker_used"`
 	CacheUsed        bool                   `json:"cache_used"`
+
+	HybridQueryType    string             `json:"hybrid_query_type,omitempty"`    // "keyword" or "natural_language", selecting default hybrid weights
+	HybridSearchWeight map[string]float64 `json:"hybrid_search_weight,omitempty"` // Applied "lexical" and "vector" weights
 }
 
 // VectorSimilarityRequest represents a request for vector similarity search
 type VectorSimilarityRequest struct {
-	ProductID           uuid.UUID `json:"product_id" binding:"required"`
-	Limit               int       `json:"limit,omitempty"`
-	IncludeMetadata     bool      `json:"include_metadata,omitempty"`
-	SimilarityThreshold float64   `json:"similarity_threshold,omitempty"`
+	ProductID           uuid.UUID  `json:"product_id" binding:"required"`
+	CustomerID          *uuid.UUID `json:"customer_id,omitempty"` // Leaves out the customer's dismissed products
+	Limit               int        `json:"limit,omitempty"`
+	IncludeMetadata     bool       `json:"include_metadata,omitempty"`
+	SimilarityThreshold float64    `json:"similarity_threshold,omitempty"`
 }
 
 // VectorSimilarityResponse represents the response from vector similarity search
@@ -265,24 +290,28 @@ type RecommendationExplanationResponse struct {
 	Explanation        string                    `json:"explanation"`
 	FactorsConsidered  []ExplanationFactor       `json:"factors_considered"`
 	AlternativeOptions []ProductRecommendationV2 `json:"alternative_options,omitempty"`
+	Recommendation     *RecommendationItem       `json:"recommendation,omitempty"` // The recommendation as it was served
 	GeneratedAt        time.Time                 `json:"generated_at"`
 }
 
 // ExplanationFactor represents a factor considered in the recommendation
 type ExplanationFactor struct {
-	Factor      string  `json:"factor"`
-	Weight      float64 `json:"weight"`
-	Impact      string  `json:"impact"` // "positive", "negative", "neutral"
-	Description string  `json:"description"`
-	Confidence  float64 `json:"confidence"`
+	Factor       string  `json:"factor"`
+	Weight       float64 `json:"weight"` // Share of the total absolute attribution
+	Impact       string  `json:"impact"` // "positive", "negative", "neutral"
+	Description  string  `json:"description"`
+	Confidence   float64 `json:"confidence"`
+	Contribution float64 `json:"contribution"` // Signed additive contribution to the score
+	Value        float64 `json:"value"`        // Scoring input the contribution was computed from
 }
 
 // TrendingProductsRequestV2 represents a request for trending products with AI insights
 type TrendingProductsRequestV2 struct {
-	CategoryID      *int   `json:"category_id,omitempty"`
-	TimeRange       string `json:"time_range,omitempty"` // "daily", "weekly", "monthly"
-	Limit           int    `json:"limit,omitempty"`
-	IncludeInsights bool   `json:"include_insights,omitempty"`
+	CategoryID      *int       `json:"category_id,omitempty"`
+	CustomerID      *uuid.UUID `json:"customer_id,omitempty"` // Leaves out the customer's dismissed products
+	TimeRange       string     `json:"time_range,omitempty"`  // "daily", "weekly", "monthly"
+	Limit           int        `json:"limit,omitempty"`
+	IncludeInsights bool       `json:"include_insights,omitempty"`
 }
 
 // TrendingProductsResponseV2 represents the response for trending products with AI insights
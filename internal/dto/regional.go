@@ -0,0 +1,39 @@
+package dto
+
+import (
+	"github.com/google/uuid"
+)
+
+// Customer location sources, from most to least preferred
+const (
+	LocationSourceProfile         = "profile"          // customers.location
+	LocationSourceShippingAddress = "shipping_address" // Most recent orders.shipping_address
+)
+
+// CustomerLocation is the region a customer is served from
+type CustomerLocation struct {
+	Prefecture string `json:"prefecture"`
+	City       string `json:"city,omitempty"`
+	Source     string `json:"source"` // "profile" or "shipping_address"
+}
+
+// RegionalDemand is the demand for a product from customers in one prefecture
+type RegionalDemand struct {
+	Prefecture string    `json:"prefecture"`
+	ProductID  uuid.UUID `json:"product_id"`
+	CategoryID int       `json:"category_id"`
+	Units      float64   `json:"units"`
+	Customers  int       `json:"customers"`
+}
+
+// RegionalPopularity describes how popular a product is in a prefecture
+type RegionalPopularity struct {
+	Prefecture    string    `json:"prefecture"`
+	ProductID     uuid.UUID `json:"product_id"`
+	CategoryID    int       `json:"category_id"`
+	Units         float64   `json:"units"`
+	Customers     int       `json:"customers"`
+	RegionalShare float64   `json:"regional_share"` // Smoothed share of the prefecture's units
+	Lift          float64   `json:"lift"`           // Regional share relative to the national share (1 = same as nationally)
+	Score         float64   `json:"score"`
+}
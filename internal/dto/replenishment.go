@@ -0,0 +1,75 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Replenishment status values
+const (
+	ReplenishmentStatusOverdue  = "overdue"
+	ReplenishmentStatusDue      = "due"
+	ReplenishmentStatusUpcoming = "upcoming"
+)
+
+// Replenishment interval sources, from most to least specific
+const (
+	ReplenishmentSourceCustomerProduct  = "customer_product"  // The customer's own repurchases of the product
+	ReplenishmentSourceCustomerCategory = "customer_category" // The customer's repurchases within the category
+	ReplenishmentSourcePopulation       = "population"        // Typical interval of all customers for the product or category
+)
+
+// PurchaseEvent is one product purchased in one order, with quantities summed per order
+type PurchaseEvent struct {
+	CustomerID   uuid.UUID `json:"customer_id"`
+	ProductID    uuid.UUID `json:"product_id"`
+	ProductName  string    `json:"product_name"`
+	CategoryID   int       `json:"category_id"`
+	CategoryName string    `json:"category_name"`
+	Brand        string    `json:"brand,omitempty"`
+	Price        float64   `json:"price"`
+	Quantity     int       `json:"quantity"`
+	OrderedAt    time.Time `json:"ordered_at"`
+}
+
+// RepurchaseIntervalPrior is the median per-unit repurchase interval of a product across all customers
+type RepurchaseIntervalPrior struct {
+	ProductID    uuid.UUID `json:"product_id"`
+	CategoryID   int       `json:"category_id"`
+	IntervalDays float64   `json:"interval_days"` // Median days between repurchases per unit bought
+	Samples      int       `json:"samples"`
+}
+
+// ReplenishmentPrediction estimates when a customer will need to reorder a consumable product
+type ReplenishmentPrediction struct {
+	ProductID         uuid.UUID `json:"product_id"`
+	ProductName       string    `json:"product_name"`
+	CategoryID        int       `json:"category_id"`
+	CategoryName      string    `json:"category_name"`
+	Brand             string    `json:"brand,omitempty"`
+	Price             float64   `json:"price"`
+	LastPurchasedAt   time.Time `json:"last_purchased_at"`
+	LastQuantity      int       `json:"last_quantity"`
+	PurchaseCount     int       `json:"purchase_count"`
+	IntervalDays      float64   `json:"interval_days"` // Expected days the last purchase lasts
+	ExpectedReorderAt time.Time `json:"expected_reorder_at"`
+	DaysUntilReorder  float64   `json:"days_until_reorder"` // Negative when overdue
+	Status            string    `json:"status"`             // "overdue", "due" or "upcoming"
+	IntervalSource    string    `json:"interval_source"`    // "customer_product", "customer_category" or "population"
+	Confidence        float64   `json:"confidence"`
+}
+
+// ReplenishmentResponse is the "time to reorder" list of a customer
+type ReplenishmentResponse struct {
+	CustomerID  uuid.UUID                 `json:"customer_id"`
+	Items       []ReplenishmentPrediction `json:"items"`
+	HorizonDays int                       `json:"horizon_days"`
+	GeneratedAt time.Time                 `json:"generated_at"`
+}
+
+// ReplenishmentDueCustomer is a customer due to reorder a product, as exported for campaigns
+type ReplenishmentDueCustomer struct {
+	CustomerID uuid.UUID `json:"customer_id"`
+	ReplenishmentPrediction
+}
@@ -0,0 +1,70 @@
+package dto
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Search synonym types
+const (
+	SearchSynonymTypeSynonym      = "synonym"      // Another word for the same thing (ヘッドホン → ヘッドフォン)
+	SearchSynonymTypeAbbreviation = "abbreviation" // A shortened form (スマホ → スマートフォン)
+	SearchSynonymTypeMisspelling  = "misspelling"  // A known misspelling (スマートフオン → スマートフォン)
+)
+
+// QueryRewriteSpellingCorrection marks a rewrite found by edit distance to the catalog vocabulary
+const QueryRewriteSpellingCorrection = "spelling_correction"
+
+// ErrSearchSynonymExists is returned when another dictionary entry already has the same normalized term
+var ErrSearchSynonymExists = errors.New("a search synonym for this term already exists")
+
+// ErrInvalidSearchSynonym is returned when a dictionary entry's term or replacement is unusable after normalization
+var ErrInvalidSearchSynonym = errors.New("invalid search synonym")
+
+// SearchSynonym is a dictionary entry that rewrites a term in search queries before retrieval
+type SearchSynonym struct {
+	ID             uuid.UUID `json:"id"`
+	Term           string    `json:"term"`
+	NormalizedTerm string    `json:"normalized_term"` // Width-folded, lowercased hiragana form the entry matches on
+	Replacement    string    `json:"replacement"`
+	Type           string    `json:"type"` // "synonym", "abbreviation" or "misspelling"
+	IsActive       bool      `json:"is_active"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// SearchSynonymRequest represents a request to create or update a search synonym
+type SearchSynonymRequest struct {
+	Term        string `json:"term" binding:"required"`
+	Replacement string `json:"replacement" binding:"required"`
+	Type        string `json:"type,omitempty"`      // Defaults to "synonym"
+	IsActive    *bool  `json:"is_active,omitempty"` // Defaults to true
+}
+
+// SearchSynonymListResponse represents the list of search synonyms
+type SearchSynonymListResponse struct {
+	Synonyms []SearchSynonym `json:"synonyms"`
+	Total    int             `json:"total"`
+}
+
+// VocabularyEntry is catalog text used to learn the spelling of search words
+type VocabularyEntry struct {
+	Text   string  `json:"text"`
+	Weight float64 `json:"weight"`
+}
+
+// QueryRewrite records one change made to a search query
+type QueryRewrite struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Type string `json:"type"` // A search synonym type or "spelling_correction"
+}
+
+// NormalizedQuery is a search query after width folding, spelling correction and dictionary rewrites
+type NormalizedQuery struct {
+	Original   string         `json:"original"`
+	Normalized string         `json:"normalized"` // Sent to knowledge base retrieval and lexical search
+	Rewrites   []QueryRewrite `json:"rewrites,omitempty"`
+}
@@ -0,0 +1,48 @@
+package dto
+
+import (
+	"time"
+)
+
+// Seasonal index key types
+const (
+	SeasonalKeyCategory = "category"
+	SeasonalKeyTag      = "tag"
+)
+
+// WeeklyDemand is the number of units sold for a category or tag in one week of one year.
+// Weeks are 7-day buckets of the day of year, 1..52 with the last days folded into week 52.
+type WeeklyDemand struct {
+	KeyType    string  `json:"key_type"` // "category" or "tag"
+	Key        string  `json:"key"`      // Category name or tag
+	CategoryID int     `json:"category_id,omitempty"`
+	Year       int     `json:"year"`
+	Week       int     `json:"week"`
+	Units      float64 `json:"units"`
+}
+
+// SeasonalIndex is the demand of a category or tag relative to its yearly average (1 = usual)
+type SeasonalIndex struct {
+	KeyType    string  `json:"key_type"`
+	Key        string  `json:"key"`
+	CategoryID int     `json:"category_id,omitempty"`
+	Index      float64 `json:"index"`
+	Support    float64 `json:"support"` // Units sold over the lookback window
+}
+
+// ActiveSeasonalEvent is a seasonal event (e.g. お中元, お歳暮) in or approaching its window
+type ActiveSeasonalEvent struct {
+	Name           string          `json:"name"`
+	StartsAt       time.Time       `json:"starts_at"`
+	EndsAt         time.Time       `json:"ends_at"`
+	PeakCategories []SeasonalIndex `json:"peak_categories,omitempty"`
+	PeakTags       []SeasonalIndex `json:"peak_tags,omitempty"`
+}
+
+// SeasonalContext summarizes learned seasonality at a point in time
+type SeasonalContext struct {
+	Season           string                `json:"season"` // Calendar season label (春/夏/秋/冬)
+	ActiveEvents     []ActiveSeasonalEvent `json:"active_events,omitempty"`
+	RisingCategories []SeasonalIndex       `json:"rising_categories,omitempty"`
+	RisingTags       []SeasonalIndex       `json:"rising_tags,omitempty"`
+}
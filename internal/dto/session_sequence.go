@@ -0,0 +1,14 @@
+package dto
+
+import (
+	"github.com/google/uuid"
+)
+
+// ProductTransition counts how often one product was followed by another within a browsing session
+type ProductTransition struct {
+	FromProductID  uuid.UUID `json:"from_product_id"`
+	ToProductID    uuid.UUID `json:"to_product_id"`
+	FromCategoryID int       `json:"from_category_id"`
+	ToCategoryID   int       `json:"to_category_id"`
+	Count          int       `json:"count"`
+}
@@ -0,0 +1,58 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Suggestion sources
+const (
+	SuggestionTypeQuery    = "query"    // Past search queries of all customers
+	SuggestionTypeProduct  = "product"  // Product names
+	SuggestionTypeBrand    = "brand"    // Brand names
+	SuggestionTypeCategory = "category" // Category names
+)
+
+// SuggestTerm is a completion candidate loaded from the catalog or the search log
+type SuggestTerm struct {
+	Text        string     `json:"text"`
+	Type        string     `json:"type"`
+	Aliases     []string   `json:"aliases,omitempty"` // Other names that also match, e.g. the English category name
+	ProductID   *uuid.UUID `json:"product_id,omitempty"`
+	Brand       string     `json:"brand,omitempty"` // Brand of a product or brand term, used for feedback suppression
+	CategoryID  *int       `json:"category_id,omitempty"`
+	CategoryIDs []int      `json:"category_ids,omitempty"` // Categories used for personalization, including parents
+	Popularity  float64    `json:"popularity"`             // Distinct searchers, popularity_score or summed popularity_score
+}
+
+// CategoryInteraction counts a customer's interactions with the products of one category
+type CategoryInteraction struct {
+	CategoryID int  `json:"category_id"`
+	ParentID   *int `json:"parent_id,omitempty"`
+	Purchases  int  `json:"purchases"`
+	CartAdds   int  `json:"cart_adds"`
+	Views      int  `json:"views"`
+	Preferred  bool `json:"preferred"` // Listed in customers.preferred_categories
+}
+
+// Suggestion is one completion of a search box query
+type Suggestion struct {
+	Text       string     `json:"text"`
+	Type       string     `json:"type"` // "query", "product", "brand" or "category"
+	ProductID  *uuid.UUID `json:"product_id,omitempty"`
+	CategoryID *int       `json:"category_id,omitempty"`
+	MatchedOn  string     `json:"matched_on"` // Normalized text, word or reading the query matched
+	Score      float64    `json:"score"`
+	Boost      float64    `json:"personalization_boost,omitempty"` // Score increase from the customer's category affinity
+}
+
+// SuggestResponse lists completions of a search box query
+type SuggestResponse struct {
+	Query           string       `json:"query"`
+	NormalizedQuery string       `json:"normalized_query"`
+	CustomerID      *uuid.UUID   `json:"customer_id,omitempty"`
+	Suggestions     []Suggestion `json:"suggestions"`
+	Personalized    bool         `json:"personalized"`
+	GeneratedAt     time.Time    `json:"generated_at"`
+}
@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"ec-recommend/internal/dto"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// BundleHandler handles "complete the set" bundle requests
+type BundleHandler struct {
+	bundleService BundleServiceInterface
+}
+
+// NewBundleHandler creates a new bundle handler instance
+func NewBundleHandler(bundleService BundleServiceInterface) *BundleHandler {
+	return &BundleHandler{
+		bundleService: bundleService,
+	}
+}
+
+// BuildBundle handles POST /api/v2/recommendations/bundle
+// @Summary Build a budget-constrained bundle
+// @Description Complete a set around a seed product or cart with complementary products from other categories, staying within the budget (defaults to the customer's price_range_max)
+// @Tags recommendations-v2
+// @Accept json
+// @Produce json
+// @Param request body dto.BundleRequest true "Bundle request"
+// @Success 200 {object} dto.BundleResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v2/recommendations/bundle [post]
+func (h *BundleHandler) BuildBundle(c *gin.Context) {
+	var req dto.BundleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Bad Request",
+			Message: "invalid request body: " + err.Error(),
+		})
+		return
+	}
+
+	// Validate required fields
+	if req.CustomerID == uuid.Nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Bad Request",
+			Message: "customer_id is required",
+		})
+		return
+	}
+	if req.SeedProductID == nil && len(req.ProductIDs) == 0 && !req.UseCart {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Bad Request",
+			Message: "seed_product_id, product_ids or use_cart is required",
+		})
+		return
+	}
+	if req.Budget != nil && *req.Budget <= 0 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Bad Request",
+			Message: "budget must be positive",
+		})
+		return
+	}
+	if req.MaxItems < 0 || req.MaxItems > 10 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Bad Request",
+			Message: "max_items must be between 1 and 10",
+		})
+		return
+	}
+
+	response, err := h.bundleService.BuildBundle(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Internal Server Error",
+			Message: "failed to build bundle: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
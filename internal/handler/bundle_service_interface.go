@@ -0,0 +1,13 @@
+package handler
+
+import (
+	"context"
+	"ec-recommend/internal/dto"
+)
+
+// BundleServiceInterface defines the interface for budget-constrained bundle building
+// This interface is defined in the handler package as it is consumed by handlers
+type BundleServiceInterface interface {
+	// BuildBundle completes a set around a seed product or cart within a budget
+	BuildBundle(ctx context.Context, req *dto.BundleRequest) (*dto.BundleResponse, error)
+}
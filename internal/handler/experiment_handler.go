@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ExperimentHandler handles A/B experiment requests
+type ExperimentHandler struct {
+	experimentService ExperimentServiceInterface
+}
+
+// NewExperimentHandler creates a new experiment handler instance
+func NewExperimentHandler(experimentService ExperimentServiceInterface) *ExperimentHandler {
+	return &ExperimentHandler{
+		experimentService: experimentService,
+	}
+}
+
+// ListExperiments handles GET /api/v2/experiments
+// @Summary List experiments
+// @Description Return all A/B experiments defined in the tuning file or the database
+// @Tags experiments
+// @Produce json
+// @Success 200 {object} dto.ExperimentListResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v2/experiments [get]
+func (h *ExperimentHandler) ListExperiments(c *gin.Context) {
+	response, err := h.experimentService.ListExperiments(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Internal Server Error",
+			Message: "failed to list experiments: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetExperimentResults handles GET /api/v2/experiments/{id}/results
+// @Summary Get experiment results
+// @Description Return click-through and conversion rates per variant with 95% confidence intervals
+// @Tags experiments
+// @Produce json
+// @Param id path string true "Experiment ID"
+// @Success 200 {object} dto.ExperimentResults
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v2/experiments/{id}/results [get]
+func (h *ExperimentHandler) GetExperimentResults(c *gin.Context) {
+	results, err := h.experimentService.GetExperimentResults(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Internal Server Error",
+			Message: "failed to get experiment results: " + err.Error(),
+		})
+		return
+	}
+	if results == nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error:   "Not Found",
+			Message: "experiment not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, results)
+}
@@ -0,0 +1,16 @@
+package handler
+
+import (
+	"context"
+	"ec-recommend/internal/dto"
+)
+
+// ExperimentServiceInterface defines the interface for A/B experiment reporting
+// This interface is defined in the handler package as it is consumed by handlers
+type ExperimentServiceInterface interface {
+	// ListExperiments returns all configured and stored experiments
+	ListExperiments(ctx context.Context) (*dto.ExperimentListResponse, error)
+
+	// GetExperimentResults returns per-variant CTR and CVR, or nil if the experiment does not exist
+	GetExperimentResults(ctx context.Context, experimentID string) (*dto.ExperimentResults, error)
+}
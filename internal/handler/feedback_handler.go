@@ -0,0 +1,140 @@
+package handler
+
+import (
+	"ec-recommend/internal/dto"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// FeedbackHandler handles negative recommendation feedback requests
+type FeedbackHandler struct {
+	feedbackService FeedbackServiceInterface
+}
+
+// NewFeedbackHandler creates a new feedback handler instance
+func NewFeedbackHandler(feedbackService FeedbackServiceInterface) *FeedbackHandler {
+	return &FeedbackHandler{
+		feedbackService: feedbackService,
+	}
+}
+
+// RecordFeedback handles POST /api/v2/recommendations/feedback
+// @Summary Dismiss a recommended product
+// @Description Record a "not interested" dismissal that hides the product, its brand or its category from recommendations for a configured period
+// @Tags recommendations-v2
+// @Accept json
+// @Produce json
+// @Param request body dto.RecommendationFeedbackRequest true "Feedback"
+// @Success 201 {object} dto.RecommendationFeedback
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v2/recommendations/feedback [post]
+func (h *FeedbackHandler) RecordFeedback(c *gin.Context) {
+	var req dto.RecommendationFeedbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Bad Request",
+			Message: "invalid request body: " + err.Error(),
+		})
+		return
+	}
+
+	if msg := validateFeedbackRequest(&req); msg != "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Bad Request",
+			Message: msg,
+		})
+		return
+	}
+
+	feedback, err := h.feedbackService.RecordFeedback(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Internal Server Error",
+			Message: "failed to record feedback: " + err.Error(),
+		})
+		return
+	}
+	if feedback == nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error:   "Not Found",
+			Message: "product not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, feedback)
+}
+
+// DeleteFeedback handles DELETE /api/v2/recommendations/feedback/{feedback_id}
+// @Summary Withdraw a dismissal
+// @Description Delete a customer's "not interested" dismissal so the product can be recommended again
+// @Tags recommendations-v2
+// @Produce json
+// @Param feedback_id path string true "Feedback UUID"
+// @Param customer_id query string true "Customer UUID"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v2/recommendations/feedback/{feedback_id} [delete]
+func (h *FeedbackHandler) DeleteFeedback(c *gin.Context) {
+	feedbackID, err := uuid.Parse(c.Param("feedback_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Bad Request",
+			Message: "invalid feedback_id format",
+		})
+		return
+	}
+
+	customerID, err := uuid.Parse(c.Query("customer_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Bad Request",
+			Message: "invalid or missing customer_id",
+		})
+		return
+	}
+
+	deleted, err := h.feedbackService.DeleteFeedback(c.Request.Context(), feedbackID, customerID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Internal Server Error",
+			Message: "failed to delete feedback: " + err.Error(),
+		})
+		return
+	}
+	if !deleted {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error:   "Not Found",
+			Message: "feedback not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Message: "feedback deleted successfully",
+	})
+}
+
+// validateFeedbackRequest checks the reason code and scope, returning an error message or ""
+func validateFeedbackRequest(req *dto.RecommendationFeedbackRequest) string {
+	switch req.ReasonCode {
+	case dto.FeedbackReasonAlreadyOwn, dto.FeedbackReasonNotMyTaste, dto.FeedbackReasonTooExpensive,
+		dto.FeedbackReasonNotRelevant, dto.FeedbackReasonOther:
+	default:
+		return "reason_code must be one of already_own, not_my_taste, too_expensive, not_relevant, other"
+	}
+
+	switch req.Scope {
+	case "", dto.FeedbackScopeProduct, dto.FeedbackScopeBrand, dto.FeedbackScopeCategory:
+	default:
+		return "scope must be one of product, brand, category"
+	}
+
+	return ""
+}
@@ -0,0 +1,18 @@
+package handler
+
+import (
+	"context"
+	"ec-recommend/internal/dto"
+
+	"github.com/google/uuid"
+)
+
+// FeedbackServiceInterface defines the interface for "not interested" recommendation feedback
+// This interface is defined in the handler package as it is consumed by handlers
+type FeedbackServiceInterface interface {
+	// RecordFeedback stores a dismissal, returning nil if the product does not exist
+	RecordFeedback(ctx context.Context, req *dto.RecommendationFeedbackRequest) (*dto.RecommendationFeedback, error)
+
+	// DeleteFeedback withdraws a customer's dismissal, reporting whether it existed
+	DeleteFeedback(ctx context.Context, feedbackID, customerID uuid.UUID) (bool, error)
+}
@@ -0,0 +1,269 @@
+package handler
+
+import (
+	"ec-recommend/internal/dto"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// MerchandisingHandler handles merchandising rule administration requests
+type MerchandisingHandler struct {
+	merchandisingService MerchandisingServiceInterface
+}
+
+// NewMerchandisingHandler creates a new merchandising handler instance
+func NewMerchandisingHandler(merchandisingService MerchandisingServiceInterface) *MerchandisingHandler {
+	return &MerchandisingHandler{
+		merchandisingService: merchandisingService,
+	}
+}
+
+// ListRules handles GET /api/v2/admin/merchandising-rules
+// @Summary List merchandising rules
+// @Description Return all merchandising rules ordered by priority
+// @Tags merchandising
+// @Produce json
+// @Success 200 {object} dto.MerchandisingRuleListResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v2/admin/merchandising-rules [get]
+func (h *MerchandisingHandler) ListRules(c *gin.Context) {
+	response, err := h.merchandisingService.ListRules(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Internal Server Error",
+			Message: "failed to list merchandising rules: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetRule handles GET /api/v2/admin/merchandising-rules/{rule_id}
+// @Summary Get a merchandising rule
+// @Description Return a single merchandising rule
+// @Tags merchandising
+// @Produce json
+// @Param rule_id path string true "Rule UUID"
+// @Success 200 {object} dto.MerchandisingRule
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v2/admin/merchandising-rules/{rule_id} [get]
+func (h *MerchandisingHandler) GetRule(c *gin.Context) {
+	ruleID, ok := parseRuleID(c)
+	if !ok {
+		return
+	}
+
+	rule, err := h.merchandisingService.GetRule(c.Request.Context(), ruleID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Internal Server Error",
+			Message: "failed to get merchandising rule: " + err.Error(),
+		})
+		return
+	}
+	if rule == nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error:   "Not Found",
+			Message: "merchandising rule not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+// CreateRule handles POST /api/v2/admin/merchandising-rules
+// @Summary Create a merchandising rule
+// @Description Create a rule that pins, boosts, buries or blocks products after ranking
+// @Tags merchandising
+// @Accept json
+// @Produce json
+// @Param request body dto.MerchandisingRuleRequest true "Merchandising rule"
+// @Success 201 {object} dto.MerchandisingRule
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v2/admin/merchandising-rules [post]
+func (h *MerchandisingHandler) CreateRule(c *gin.Context) {
+	req, ok := bindMerchandisingRuleRequest(c)
+	if !ok {
+		return
+	}
+
+	rule, err := h.merchandisingService.CreateRule(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Internal Server Error",
+			Message: "failed to create merchandising rule: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, rule)
+}
+
+// UpdateRule handles PUT /api/v2/admin/merchandising-rules/{rule_id}
+// @Summary Update a merchandising rule
+// @Description Replace an existing merchandising rule
+// @Tags merchandising
+// @Accept json
+// @Produce json
+// @Param rule_id path string true "Rule UUID"
+// @Param request body dto.MerchandisingRuleRequest true "Merchandising rule"
+// @Success 200 {object} dto.MerchandisingRule
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v2/admin/merchandising-rules/{rule_id} [put]
+func (h *MerchandisingHandler) UpdateRule(c *gin.Context) {
+	ruleID, ok := parseRuleID(c)
+	if !ok {
+		return
+	}
+
+	req, ok := bindMerchandisingRuleRequest(c)
+	if !ok {
+		return
+	}
+
+	rule, err := h.merchandisingService.UpdateRule(c.Request.Context(), ruleID, req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Internal Server Error",
+			Message: "failed to update merchandising rule: " + err.Error(),
+		})
+		return
+	}
+	if rule == nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error:   "Not Found",
+			Message: "merchandising rule not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+// DeleteRule handles DELETE /api/v2/admin/merchandising-rules/{rule_id}
+// @Summary Delete a merchandising rule
+// @Description Delete a merchandising rule
+// @Tags merchandising
+// @Produce json
+// @Param rule_id path string true "Rule UUID"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v2/admin/merchandising-rules/{rule_id} [delete]
+func (h *MerchandisingHandler) DeleteRule(c *gin.Context) {
+	ruleID, ok := parseRuleID(c)
+	if !ok {
+		return
+	}
+
+	deleted, err := h.merchandisingService.DeleteRule(c.Request.Context(), ruleID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Internal Server Error",
+			Message: "failed to delete merchandising rule: " + err.Error(),
+		})
+		return
+	}
+	if !deleted {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error:   "Not Found",
+			Message: "merchandising rule not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Message: "merchandising rule deleted successfully",
+	})
+}
+
+// parseRuleID parses the rule_id path parameter, writing a 400 response on failure
+func parseRuleID(c *gin.Context) (uuid.UUID, bool) {
+	ruleID, err := uuid.Parse(c.Param("rule_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Bad Request",
+			Message: "invalid rule_id format",
+		})
+		return uuid.Nil, false
+	}
+
+	return ruleID, true
+}
+
+// bindMerchandisingRuleRequest binds and validates a rule request, writing a 400 response on failure
+func bindMerchandisingRuleRequest(c *gin.Context) (*dto.MerchandisingRuleRequest, bool) {
+	var req dto.MerchandisingRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Bad Request",
+			Message: "invalid request body: " + err.Error(),
+		})
+		return nil, false
+	}
+
+	if message := validateMerchandisingRule(&req); message != "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Bad Request",
+			Message: message,
+		})
+		return nil, false
+	}
+
+	return &req, true
+}
+
+// validateMerchandisingRule checks that the rule action has the targets it needs.
+// Returns an error message, or an empty string if the rule is valid.
+func validateMerchandisingRule(req *dto.MerchandisingRuleRequest) string {
+	action := req.Action
+
+	switch action.Type {
+	case dto.MerchandisingActionPin:
+		if action.ProductID == nil {
+			return "action.product_id is required for pin rules"
+		}
+		if action.Slot < 1 {
+			return "action.slot must be a positive integer for pin rules"
+		}
+	case dto.MerchandisingActionBoost, dto.MerchandisingActionBury:
+		if action.ProductID == nil && action.Brand == "" && action.CategoryID == nil {
+			return "action.product_id, action.brand or action.category_id is required for boost and bury rules"
+		}
+		if action.Factor <= 1 {
+			return "action.factor must be greater than 1 for boost and bury rules"
+		}
+	case dto.MerchandisingActionBlockProduct:
+		if action.ProductID == nil {
+			return "action.product_id is required for block_product rules"
+		}
+	case dto.MerchandisingActionBlockBrand:
+		if action.Brand == "" {
+			return "action.brand is required for block_brand rules"
+		}
+	default:
+		return "action.type must be one of pin, boost, bury, block_product, block_brand"
+	}
+
+	for _, segment := range req.Conditions.CustomerSegments {
+		if segment != "premium" && segment != "new" && segment != "standard" {
+			return "conditions.customer_segments must contain only premium, new or standard"
+		}
+	}
+
+	if req.Conditions.StartsAt != nil && req.Conditions.EndsAt != nil && !req.Conditions.StartsAt.Before(*req.Conditions.EndsAt) {
+		return "conditions.starts_at must be before conditions.ends_at"
+	}
+
+	return ""
+}
@@ -0,0 +1,27 @@
+package handler
+
+import (
+	"context"
+	"ec-recommend/internal/dto"
+
+	"github.com/google/uuid"
+)
+
+// MerchandisingServiceInterface defines the interface for merchandising rule management
+// This interface is defined in the handler package as it is consumed by handlers
+type MerchandisingServiceInterface interface {
+	// ListRules returns all merchandising rules
+	ListRules(ctx context.Context) (*dto.MerchandisingRuleListResponse, error)
+
+	// GetRule returns a merchandising rule by ID, or nil if it does not exist
+	GetRule(ctx context.Context, ruleID uuid.UUID) (*dto.MerchandisingRule, error)
+
+	// CreateRule creates a merchandising rule
+	CreateRule(ctx context.Context, req *dto.MerchandisingRuleRequest) (*dto.MerchandisingRule, error)
+
+	// UpdateRule replaces a merchandising rule, returning nil if it does not exist
+	UpdateRule(ctx context.Context, ruleID uuid.UUID, req *dto.MerchandisingRuleRequest) (*dto.MerchandisingRule, error)
+
+	// DeleteRule deletes a merchandising rule, reporting whether it existed
+	DeleteRule(ctx context.Context, ruleID uuid.UUID) (bool, error)
+}
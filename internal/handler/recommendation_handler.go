@@ -28,12 +28,13 @@ func NewRecommendationHandler(recommendationService RecommendationServiceInterfa
 // @Accept json
 // @Produce json
 // @Param customer_id query string true "Customer UUID"
-// @Param recommendation_type query string false "Type of recommendation (similar, collaborative, content_based, hybrid)" default(hybrid)
+// @Param recommendation_type query string false "Type of recommendation (similar, collaborative, content_based, hybrid, auto)" default(hybrid)
 // @Param context_type query string false "Context where recommendations are shown (homepage, product_page, cart, checkout)" default(homepage)
 // @Param product_id query string false "Product UUID for similar product recommendations"
 // @Param category_id query int false "Category ID for category-based recommendations"
 // @Param limit query int false "Number of recommendations to return" default(10)
 // @Param exclude_owned query bool false "Exclude already purchased products" default(false)
+// @Param diversity query number false "Diversity trade-off from 0 (relevance only) to 1 (maximum diversity)"
 // @Success 200 {object} dto.RecommendationResponse
 // @Failure 400 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
@@ -119,6 +120,19 @@ func (h *RecommendationHandler) GetRecommendations(c *gin.Context) {
 		req.ExcludeOwned = excludeOwned
 	}
 
+	// Parse diversity
+	if diversityStr := c.Query("diversity"); diversityStr != "" {
+		diversity, err := strconv.ParseFloat(diversityStr, 64)
+		if err != nil || diversity < 0 || diversity > 1 {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "Bad Request",
+				Message: "diversity must be a number between 0 and 1",
+			})
+			return
+		}
+		req.Diversity = &diversity
+	}
+
 	// Get recommendations
 	response, err := h.recommendationService.GetRecommendations(c.Request.Context(), req)
 	if err != nil {
@@ -170,6 +184,15 @@ func (h *RecommendationHandler) PostRecommendations(c *gin.Context) {
 		req.Limit = 100
 	}
 
+	// Validate diversity
+	if req.Diversity != nil && (*req.Diversity < 0 || *req.Diversity > 1) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Bad Request",
+			Message: "diversity must be a number between 0 and 1",
+		})
+		return
+	}
+
 	// Get recommendations
 	response, err := h.recommendationService.GetRecommendations(c.Request.Context(), &req)
 	if err != nil {
@@ -230,6 +253,7 @@ func (h *RecommendationHandler) GetCustomerProfile(c *gin.Context) {
 // @Tags products
 // @Produce json
 // @Param product_id path string true "Product UUID"
+// @Param customer_id query string false "Customer UUID whose dismissed products are left out"
 // @Param limit query int false "Number of similar products to return" default(10)
 // @Success 200 {object} []dto.ProductRecommendation
 // @Failure 400 {object} ErrorResponse
@@ -260,7 +284,12 @@ func (h *RecommendationHandler) GetSimilarProducts(c *gin.Context) {
 		limit = parsedLimit
 	}
 
-	products, err := h.recommendationService.GetSimilarProducts(c.Request.Context(), productID, limit)
+	customerID, ok := parseOptionalCustomerID(c)
+	if !ok {
+		return
+	}
+
+	products, err := h.recommendationService.GetSimilarProducts(c.Request.Context(), productID, customerID, limit)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
 			Error:   "Internal Server Error",
@@ -278,6 +307,7 @@ func (h *RecommendationHandler) GetSimilarProducts(c *gin.Context) {
 // @Tags products
 // @Produce json
 // @Param category_id query int false "Category ID to filter trending products"
+// @Param customer_id query string false "Customer UUID whose dismissed products are left out"
 // @Param limit query int false "Number of trending products to return" default(10)
 // @Success 200 {object} []dto.ProductRecommendation
 // @Failure 400 {object} ErrorResponse
@@ -312,7 +342,12 @@ func (h *RecommendationHandler) GetTrendingProducts(c *gin.Context) {
 		limit = parsedLimit
 	}
 
-	products, err := h.recommendationService.GetTrendingProducts(c.Request.Context(), categoryID, limit)
+	customerID, ok := parseOptionalCustomerID(c)
+	if !ok {
+		return
+	}
+
+	products, err := h.recommendationService.GetTrendingProducts(c.Request.Context(), categoryID, customerID, limit)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
 			Error:   "Internal Server Error",
@@ -376,6 +411,25 @@ func (h *RecommendationHandler) LogRecommendationInteraction(c *gin.Context) {
 	})
 }
 
+// parseOptionalCustomerID parses the optional customer_id query parameter, writing a 400 response on failure
+func parseOptionalCustomerID(c *gin.Context) (*uuid.UUID, bool) {
+	customerIDStr := c.Query("customer_id")
+	if customerIDStr == "" {
+		return nil, true
+	}
+
+	customerID, err := uuid.Parse(customerIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Bad Request",
+			Message: "invalid customer_id format",
+		})
+		return nil, false
+	}
+
+	return &customerID, true
+}
+
 // ErrorResponse represents an error response
 type ErrorResponse struct {
 	Error   string `json:"error"`
@@ -2,8 +2,11 @@ package handler
 
 import (
 	"ec-recommend/internal/dto"
+	"ec-recommend/internal/service"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -28,7 +31,7 @@ func NewRecommendationHandlerV2(recommendationServiceV2 RecommendationServiceV2I
 // @Accept json
 // @Produce json
 // @Param customer_id query string true "Customer UUID"
-// @Param recommendation_type query string false "Type of recommendation (hybrid, semantic, collaborative, vector_search, knowledge_based)" default(hybrid)
+// @Param recommendation_type query string false "Type of recommendation (hybrid, semantic, collaborative, vector_search, knowledge_based, session_sequence, regional, auto)" default(hybrid)
 // @Param context_type query string false "Context where recommendations are shown (homepage, product_page, cart, checkout, search_results)" default(homepage)
 // @Param query_text query string false "Natural language query for semantic search (e.g., 'Find products similar to wireless headphones for running')"
 // @Param product_id query string false "Product UUID for similar product recommendations"
@@ -38,6 +41,11 @@ func NewRecommendationHandlerV2(recommendationServiceV2 RecommendationServiceV2I
 // @Param limit query int false "Number of recommendations to return" default(10)
 // @Param exclude_owned query bool false "Exclude already purchased products" default(false)
 // @Param enable_explanation query bool false "Include AI-generated explanations for recommendations" default(true)
+// @Param strategy_weights query string false "Hybrid strategy weight overrides (e.g., 'semantic:0.5,collaborative:0.3')"
+// @Param fusion_method query string false "Rank fusion method for hybrid recommendations (rrf, normalized_score, round_robin)"
+// @Param diversity query number false "Diversity trade-off from 0 (relevance only) to 1 (maximum diversity)"
+// @Param browsing_session_id query string false "Current browsing session UUID for session_sequence recommendations"
+// @Param recent_product_ids query string false "Comma-separated product UUIDs viewed in the current session, oldest first"
 // @Success 200 {object} dto.RecommendationResponseV2
 // @Failure 400 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
@@ -172,6 +180,71 @@ func (h *RecommendationHandlerV2) GetRecommendationsV2(c *gin.Context) {
 		req.EnableExplanation = enableExplanation
 	}
 
+	// Parse strategy_weights
+	if strategyWeightsStr := c.Query("strategy_weights"); strategyWeightsStr != "" {
+		strategyWeights, err := parseStrategyWeights(strategyWeightsStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "Bad Request",
+				Message: err.Error(),
+			})
+			return
+		}
+		req.StrategyWeights = strategyWeights
+	}
+
+	// Parse fusion_method
+	if fusionMethod := c.Query("fusion_method"); fusionMethod != "" {
+		if !service.IsValidFusionMethod(fusionMethod) {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "Bad Request",
+				Message: "fusion_method must be one of rrf, normalized_score, round_robin",
+			})
+			return
+		}
+		req.FusionMethod = fusionMethod
+	}
+
+	// Parse diversity
+	if diversityStr := c.Query("diversity"); diversityStr != "" {
+		diversity, err := strconv.ParseFloat(diversityStr, 64)
+		if err != nil || diversity < 0 || diversity > 1 {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "Bad Request",
+				Message: "diversity must be a number between 0 and 1",
+			})
+			return
+		}
+		req.Diversity = &diversity
+	}
+
+	// Parse browsing session for session-aware strategies
+	if sessionIDStr := c.Query("browsing_session_id"); sessionIDStr != "" {
+		sessionID, err := uuid.Parse(sessionIDStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "Bad Request",
+				Message: "invalid browsing_session_id format",
+			})
+			return
+		}
+		req.BrowsingSessionID = &sessionID
+	}
+
+	if recentStr := c.Query("recent_product_ids"); recentStr != "" {
+		for _, idStr := range strings.Split(recentStr, ",") {
+			productID, err := uuid.Parse(strings.TrimSpace(idStr))
+			if err != nil {
+				c.JSON(http.StatusBadRequest, ErrorResponse{
+					Error:   "Bad Request",
+					Message: "recent_product_ids must be comma-separated UUIDs",
+				})
+				return
+			}
+			req.RecentProductIDs = append(req.RecentProductIDs, productID)
+		}
+	}
+
 	// Get advanced recommendations
 	response, err := h.recommendationServiceV2.GetRecommendationsV2(c.Request.Context(), req)
 	if err != nil {
@@ -232,6 +305,53 @@ func (h *RecommendationHandlerV2) PostRecommendationsV2(c *gin.Context) {
 		return
 	}
 
+	// Validate strategy weights
+	for strategy, weight := range req.StrategyWeights {
+		if weight < 0 {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "Bad Request",
+				Message: fmt.Sprintf("strategy_weights.%s must not be negative", strategy),
+			})
+			return
+		}
+	}
+
+	// Validate fusion method
+	if req.FusionMethod != "" && !service.IsValidFusionMethod(req.FusionMethod) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Bad Request",
+			Message: "fusion_method must be one of rrf, normalized_score, round_robin",
+		})
+		return
+	}
+
+	// Validate vector search type
+	if req.VectorSearchConfig != nil && req.VectorSearchConfig.SearchType != "" && !isValidSearchType(req.VectorSearchConfig.SearchType) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Bad Request",
+			Message: "vector_search_config.search_type must be one of semantic, hybrid, keyword",
+		})
+		return
+	}
+	if req.VectorSearchConfig != nil {
+		if err := validateHybridWeights("vector_search_config.hybrid_weights", req.VectorSearchConfig.HybridWeights); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "Bad Request",
+				Message: err.Error(),
+			})
+			return
+		}
+	}
+
+	// Validate diversity
+	if req.Diversity != nil && (*req.Diversity < 0 || *req.Diversity > 1) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Bad Request",
+			Message: "diversity must be a number between 0 and 1",
+		})
+		return
+	}
+
 	// Get advanced recommendations
 	response, err := h.recommendationServiceV2.GetRecommendationsV2(c.Request.Context(), &req)
 	if err != nil {
@@ -245,6 +365,71 @@ func (h *RecommendationHandlerV2) PostRecommendationsV2(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
+// GetGiftRecommendations handles POST /api/v2/recommendations/gift
+// @Summary Get gift recommendations for a recipient
+// @Description Recommend gifts for a described recipient (age range, gender, relationship, occasion, budget), excluding products the giver already bought
+// @Tags recommendations-v2
+// @Accept json
+// @Produce json
+// @Param request body dto.GiftRequest true "Gift recommendation request"
+// @Success 200 {object} dto.GiftResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v2/recommendations/gift [post]
+func (h *RecommendationHandlerV2) GetGiftRecommendations(c *gin.Context) {
+	var req dto.GiftRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Bad Request",
+			Message: "invalid request body: " + err.Error(),
+		})
+		return
+	}
+
+	if req.CustomerID == uuid.Nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Bad Request",
+			Message: "customer_id is required",
+		})
+		return
+	}
+
+	// Set defaults
+	if req.Limit <= 0 {
+		req.Limit = 10
+	}
+	if req.Limit > 100 {
+		req.Limit = 100
+	}
+
+	// Validate budget
+	if (req.BudgetMin != nil && *req.BudgetMin < 0) || (req.BudgetMax != nil && *req.BudgetMax < 0) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Bad Request",
+			Message: "budget_min and budget_max must not be negative",
+		})
+		return
+	}
+	if req.BudgetMin != nil && req.BudgetMax != nil && *req.BudgetMin > *req.BudgetMax {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Bad Request",
+			Message: "budget_min cannot be greater than budget_max",
+		})
+		return
+	}
+
+	response, err := h.recommendationServiceV2.GetGiftRecommendations(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Internal Server Error",
+			Message: "failed to get gift recommendations: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
 // GetSemanticSearch handles GET /api/v2/search/semantic
 // @Summary Perform semantic search using knowledge base
 // @Description Search products using natural language queries with semantic understanding
@@ -257,6 +442,8 @@ func (h *RecommendationHandlerV2) PostRecommendationsV2(c *gin.Context) {
 // @Param price_range_min query float64 false "Minimum price range for filtering"
 // @Param price_range_max query float64 false "Maximum price range for filtering"
 // @Param limit query int false "Number of results to return" default(10)
+// @Param search_type query string false "Retrieval method (semantic, hybrid, keyword)" default(semantic)
+// @Param hybrid_weights query string false "Hybrid search weights as side:weight pairs (e.g., lexical:0.4,vector:0.6)"
 // @Success 200 {object} dto.SemanticSearchResponse
 // @Failure 400 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
@@ -341,6 +528,31 @@ func (h *RecommendationHandlerV2) GetSemanticSearch(c *gin.Context) {
 		req.Limit = limit
 	}
 
+	// Parse search type
+	if searchType := c.Query("search_type"); searchType != "" {
+		if !isValidSearchType(searchType) {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "Bad Request",
+				Message: "search_type must be one of semantic, hybrid, keyword",
+			})
+			return
+		}
+		req.SearchType = searchType
+	}
+
+	// Parse hybrid_weights
+	if hybridWeightsStr := c.Query("hybrid_weights"); hybridWeightsStr != "" {
+		hybridWeights, err := parseHybridWeights(hybridWeightsStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "Bad Request",
+				Message: err.Error(),
+			})
+			return
+		}
+		req.HybridWeights = hybridWeights
+	}
+
 	// Perform semantic search
 	response, err := h.recommendationServiceV2.SemanticSearch(c.Request.Context(), req)
 	if err != nil {
@@ -360,6 +572,7 @@ func (h *RecommendationHandlerV2) GetSemanticSearch(c *gin.Context) {
 // @Tags vector-search
 // @Produce json
 // @Param product_id path string true "Product UUID"
+// @Param customer_id query string false "Customer UUID whose dismissed products are left out"
 // @Param limit query int false "Number of similar products to return" default(10)
 // @Param include_metadata query bool false "Include additional metadata in results" default(true)
 // @Success 200 {object} dto.VectorSimilarityResponse
@@ -410,6 +623,12 @@ func (h *RecommendationHandlerV2) GetVectorSimilarProducts(c *gin.Context) {
 		req.IncludeMetadata = includeMetadata
 	}
 
+	customerID, ok := parseOptionalCustomerID(c)
+	if !ok {
+		return
+	}
+	req.CustomerID = customerID
+
 	// Get vector similar products
 	response, err := h.recommendationServiceV2.GetVectorSimilarProducts(c.Request.Context(), req)
 	if err != nil {
@@ -493,13 +712,14 @@ func (h *RecommendationHandlerV2) GetKnowledgeBasedRecommendations(c *gin.Contex
 
 // GetRecommendationExplanation handles GET /api/v2/recommendations/{recommendation_id}/explanation
 // @Summary Get detailed explanation for a specific recommendation
-// @Description Retrieve AI-generated explanation for why a product was recommended
+// @Description Reconstruct why a product was recommended from the scores, strategies and filters recorded when it was served
 // @Tags explanations
 // @Produce json
-// @Param recommendation_id path string true "Recommendation UUID"
+// @Param recommendation_id path string true "recommendation_item_id of a served recommendation"
 // @Param customer_id query string true "Customer UUID"
 // @Success 200 {object} dto.RecommendationExplanationResponse
 // @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /api/v2/recommendations/{recommendation_id}/explanation [get]
 func (h *RecommendationHandlerV2) GetRecommendationExplanation(c *gin.Context) {
@@ -540,16 +760,74 @@ func (h *RecommendationHandlerV2) GetRecommendationExplanation(c *gin.Context) {
 		})
 		return
 	}
+	if response == nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error:   "Not Found",
+			Message: "recommendation not found",
+		})
+		return
+	}
 
 	c.JSON(http.StatusOK, response)
 }
 
+// LogRecommendationInteraction handles POST /api/v2/recommendations/interactions
+// @Summary Log customer interaction with V2 recommendations
+// @Description Log clicks and purchases of products served by a V2 recommendation; rewards the strategy bandit arm and clears frequency caps for clicked products
+// @Tags recommendations-v2
+// @Accept json
+// @Produce json
+// @Param interaction body dto.RecommendationAnalytics true "Recommendation interaction data; recommendation_id is the metadata.session_id of the V2 response"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v2/recommendations/interactions [post]
+func (h *RecommendationHandlerV2) LogRecommendationInteraction(c *gin.Context) {
+	var analytics dto.RecommendationAnalytics
+	if err := c.ShouldBindJSON(&analytics); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Bad Request",
+			Message: "invalid request body: " + err.Error(),
+		})
+		return
+	}
+
+	if analytics.CustomerID == uuid.Nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Bad Request",
+			Message: "customer_id is required",
+		})
+		return
+	}
+
+	if analytics.RecommendationID == uuid.Nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Bad Request",
+			Message: "recommendation_id is required",
+		})
+		return
+	}
+
+	if err := h.recommendationServiceV2.LogRecommendationInteraction(c.Request.Context(), &analytics); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Internal Server Error",
+			Message: "failed to log interaction: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Message: "interaction logged successfully",
+	})
+}
+
 // GetTrendingProductsV2 handles GET /api/v2/products/trending
 // @Summary Get trending products with enhanced AI insights
 // @Description Retrieve currently trending products with AI-powered trend analysis
 // @Tags trending-v2
 // @Produce json
 // @Param category_id query int false "Category ID to filter trending products"
+// @Param customer_id query string false "Customer UUID whose dismissed products are left out"
 // @Param time_range query string false "Time range for trend analysis (daily, weekly, monthly)" default(weekly)
 // @Param limit query int false "Number of trending products to return" default(10)
 // @Param include_insights query bool false "Include AI-generated trend insights" default(true)
@@ -618,6 +896,12 @@ func (h *RecommendationHandlerV2) GetTrendingProductsV2(c *gin.Context) {
 		req.IncludeInsights = includeInsights
 	}
 
+	customerID, ok := parseOptionalCustomerID(c)
+	if !ok {
+		return
+	}
+	req.CustomerID = customerID
+
 	// Get trending products with AI insights
 	response, err := h.recommendationServiceV2.GetTrendingProductsV2(c.Request.Context(), req)
 	if err != nil {
@@ -630,3 +914,66 @@ func (h *RecommendationHandlerV2) GetTrendingProductsV2(c *gin.Context) {
 
 	c.JSON(http.StatusOK, response)
 }
+
+// parseStrategyWeights parses a comma-separated list of strategy:weight pairs
+// (e.g., "semantic:0.5,collaborative:0.3") into a weight map.
+// Returns an error if a pair is malformed or a weight is negative.
+func parseStrategyWeights(value string) (map[string]float64, error) {
+	return parseWeights("strategy_weights", "strategy", value)
+}
+
+// parseHybridWeights parses a comma-separated list of lexical and vector weights
+// (e.g., "lexical:0.4,vector:0.6") into a weight map.
+// Returns an error if a pair is malformed, a key is unknown or a weight is negative.
+func parseHybridWeights(value string) (map[string]float64, error) {
+	weights, err := parseWeights("hybrid_weights", "side", value)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateHybridWeights("hybrid_weights", weights); err != nil {
+		return nil, err
+	}
+	return weights, nil
+}
+
+// parseWeights parses a comma-separated list of key:weight pairs for the named parameter
+func parseWeights(param, key, value string) (map[string]float64, error) {
+	weights := make(map[string]float64)
+	for _, pair := range strings.Split(value, ",") {
+		parts := strings.SplitN(strings.TrimSpace(pair), ":", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+			return nil, fmt.Errorf("%s must be a comma-separated list of %s:weight pairs", param, key)
+		}
+
+		weight, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+		if err != nil || weight < 0 {
+			return nil, fmt.Errorf("%s.%s must be a valid non-negative number", param, strings.TrimSpace(parts[0]))
+		}
+		weights[strings.TrimSpace(parts[0])] = weight
+	}
+
+	return weights, nil
+}
+
+// validateHybridWeights checks that hybrid weights only use the lexical and vector keys and are not negative
+func validateHybridWeights(param string, weights map[string]float64) error {
+	for side, weight := range weights {
+		if side != "lexical" && side != "vector" {
+			return fmt.Errorf("%s keys must be lexical or vector", param)
+		}
+		if weight < 0 {
+			return fmt.Errorf("%s.%s must be a valid non-negative number", param, side)
+		}
+	}
+	return nil
+}
+
+// isValidSearchType reports whether the retrieval method is supported
+func isValidSearchType(searchType string) bool {
+	switch searchType {
+	case "semantic", "hybrid", "keyword":
+		return true
+	default:
+		return false
+	}
+}
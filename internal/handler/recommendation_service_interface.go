@@ -19,11 +19,11 @@ type RecommendationServiceInterface interface {
 	// LogRecommendationInteraction logs customer interactions with recommendations
 	LogRecommendationInteraction(ctx context.Context, analytics *dto.RecommendationAnalytics) error
 
-	// GetSimilarProducts finds products similar to a given product
-	GetSimilarProducts(ctx context.Context, productID uuid.UUID, limit int) ([]dto.ProductRecommendation, error)
+	// GetSimilarProducts finds products similar to a given product, leaving out the customer's dismissals
+	GetSimilarProducts(ctx context.Context, productID uuid.UUID, customerID *uuid.UUID, limit int) ([]dto.ProductRecommendation, error)
 
-	// GetTrendingProducts returns currently trending products
-	GetTrendingProducts(ctx context.Context, categoryID *int, limit int) ([]dto.ProductRecommendation, error)
+	// GetTrendingProducts returns currently trending products, leaving out the customer's dismissals
+	GetTrendingProducts(ctx context.Context, categoryID *int, customerID *uuid.UUID, limit int) ([]dto.ProductRecommendation, error)
 
 	// GetPersonalizedRecommendations generates AI-powered personalized recommendations
 	GetPersonalizedRecommendations(ctx context.Context, profile *dto.CustomerProfile, limit int) ([]dto.ProductRecommendation, error)
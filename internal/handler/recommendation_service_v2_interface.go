@@ -22,7 +22,11 @@ type RecommendationServiceV2Interface interface {
 	// GetKnowledgeBasedRecommendations generates recommendations based on comprehensive knowledge base analysis
 	GetKnowledgeBasedRecommendations(ctx context.Context, req *dto.KnowledgeBasedRecommendationRequest) (*dto.KnowledgeBasedRecommendationResponse, error)
 
-	// GetRecommendationExplanation provides detailed explanation for a specific recommendation
+	// GetGiftRecommendations recommends gifts for a described recipient, excluding the giver's own purchases
+	GetGiftRecommendations(ctx context.Context, req *dto.GiftRequest) (*dto.GiftResponse, error)
+
+	// GetRecommendationExplanation explains a served recommendation by its recommendation_item_id,
+	// returning nil if the customer was never served it
 	GetRecommendationExplanation(ctx context.Context, recommendationID, customerID uuid.UUID) (*dto.RecommendationExplanationResponse, error)
 
 	// GetTrendingProductsV2 returns trending products with AI-powered insights
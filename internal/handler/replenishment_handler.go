@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ReplenishmentHandler handles replenishment prediction requests
+type ReplenishmentHandler struct {
+	replenishmentService ReplenishmentServiceInterface
+}
+
+// NewReplenishmentHandler creates a new replenishment handler instance
+func NewReplenishmentHandler(replenishmentService ReplenishmentServiceInterface) *ReplenishmentHandler {
+	return &ReplenishmentHandler{
+		replenishmentService: replenishmentService,
+	}
+}
+
+// GetReplenishment handles GET /api/v2/customers/{customer_id}/replenishment
+// @Summary Get the "time to reorder" list of a customer
+// @Description Estimate when the customer will run out of consumables they bought, from their repurchase intervals and quantities
+// @Tags customers
+// @Produce json
+// @Param customer_id path string true "Customer UUID"
+// @Param horizon_days query int false "Include products expected to be reordered within this many days" default(30)
+// @Param limit query int false "Number of products to return" default(10)
+// @Success 200 {object} dto.ReplenishmentResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v2/customers/{customer_id}/replenishment [get]
+func (h *ReplenishmentHandler) GetReplenishment(c *gin.Context) {
+	customerID, err := uuid.Parse(c.Param("customer_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Bad Request",
+			Message: "invalid customer_id format",
+		})
+		return
+	}
+
+	// Parse limit
+	limit := 10
+	if limitStr := c.Query("limit"); limitStr != "" {
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 || limit > 100 {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "Bad Request",
+				Message: "limit must be a positive integer between 1 and 100",
+			})
+			return
+		}
+	}
+
+	// Parse horizon; zero uses the configured default
+	horizonDays := 0
+	if horizonStr := c.Query("horizon_days"); horizonStr != "" {
+		horizonDays, err = strconv.Atoi(horizonStr)
+		if err != nil || horizonDays <= 0 || horizonDays > 365 {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "Bad Request",
+				Message: "horizon_days must be a positive integer between 1 and 365",
+			})
+			return
+		}
+	}
+
+	response, err := h.replenishmentService.GetReplenishmentRecommendations(c.Request.Context(), customerID, horizonDays, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Internal Server Error",
+			Message: "failed to get replenishment recommendations: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,15 @@
+package handler
+
+import (
+	"context"
+	"ec-recommend/internal/dto"
+
+	"github.com/google/uuid"
+)
+
+// ReplenishmentServiceInterface defines the interface for consumable replenishment predictions
+// This interface is defined in the handler package as it is consumed by handlers
+type ReplenishmentServiceInterface interface {
+	// GetReplenishmentRecommendations returns the consumables a customer is expected to reorder within horizonDays
+	GetReplenishmentRecommendations(ctx context.Context, customerID uuid.UUID, horizonDays, limit int) (*dto.ReplenishmentResponse, error)
+}
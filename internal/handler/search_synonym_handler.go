@@ -0,0 +1,267 @@
+package handler
+
+import (
+	"ec-recommend/internal/dto"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// SearchSynonymHandler handles search synonym administration and query normalization previews
+type SearchSynonymHandler struct {
+	searchSynonymService SearchSynonymServiceInterface
+}
+
+// NewSearchSynonymHandler creates a new search synonym handler instance
+func NewSearchSynonymHandler(searchSynonymService SearchSynonymServiceInterface) *SearchSynonymHandler {
+	return &SearchSynonymHandler{
+		searchSynonymService: searchSynonymService,
+	}
+}
+
+// ListSynonyms handles GET /api/v2/admin/search-synonyms
+// @Summary List search synonyms
+// @Description Return all synonym, abbreviation and misspelling entries ordered by term
+// @Tags search
+// @Produce json
+// @Success 200 {object} dto.SearchSynonymListResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v2/admin/search-synonyms [get]
+func (h *SearchSynonymHandler) ListSynonyms(c *gin.Context) {
+	response, err := h.searchSynonymService.ListSynonyms(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Internal Server Error",
+			Message: "failed to list search synonyms: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetSynonym handles GET /api/v2/admin/search-synonyms/{synonym_id}
+// @Summary Get a search synonym
+// @Description Return a single search synonym
+// @Tags search
+// @Produce json
+// @Param synonym_id path string true "Synonym UUID"
+// @Success 200 {object} dto.SearchSynonym
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v2/admin/search-synonyms/{synonym_id} [get]
+func (h *SearchSynonymHandler) GetSynonym(c *gin.Context) {
+	synonymID, ok := parseSynonymID(c)
+	if !ok {
+		return
+	}
+
+	synonym, err := h.searchSynonymService.GetSynonym(c.Request.Context(), synonymID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Internal Server Error",
+			Message: "failed to get search synonym: " + err.Error(),
+		})
+		return
+	}
+	if synonym == nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error:   "Not Found",
+			Message: "search synonym not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, synonym)
+}
+
+// CreateSynonym handles POST /api/v2/admin/search-synonyms
+// @Summary Create a search synonym
+// @Description Create an entry that rewrites a term in search queries before retrieval
+// @Tags search
+// @Accept json
+// @Produce json
+// @Param request body dto.SearchSynonymRequest true "Search synonym"
+// @Success 201 {object} dto.SearchSynonym
+// @Failure 400 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v2/admin/search-synonyms [post]
+func (h *SearchSynonymHandler) CreateSynonym(c *gin.Context) {
+	req, ok := bindSearchSynonymRequest(c)
+	if !ok {
+		return
+	}
+
+	synonym, err := h.searchSynonymService.CreateSynonym(c.Request.Context(), req)
+	if err != nil {
+		writeSearchSynonymError(c, "failed to create search synonym: ", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, synonym)
+}
+
+// UpdateSynonym handles PUT /api/v2/admin/search-synonyms/{synonym_id}
+// @Summary Update a search synonym
+// @Description Replace an existing search synonym
+// @Tags search
+// @Accept json
+// @Produce json
+// @Param synonym_id path string true "Synonym UUID"
+// @Param request body dto.SearchSynonymRequest true "Search synonym"
+// @Success 200 {object} dto.SearchSynonym
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v2/admin/search-synonyms/{synonym_id} [put]
+func (h *SearchSynonymHandler) UpdateSynonym(c *gin.Context) {
+	synonymID, ok := parseSynonymID(c)
+	if !ok {
+		return
+	}
+
+	req, ok := bindSearchSynonymRequest(c)
+	if !ok {
+		return
+	}
+
+	synonym, err := h.searchSynonymService.UpdateSynonym(c.Request.Context(), synonymID, req)
+	if err != nil {
+		writeSearchSynonymError(c, "failed to update search synonym: ", err)
+		return
+	}
+	if synonym == nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error:   "Not Found",
+			Message: "search synonym not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, synonym)
+}
+
+// DeleteSynonym handles DELETE /api/v2/admin/search-synonyms/{synonym_id}
+// @Summary Delete a search synonym
+// @Description Delete a search synonym
+// @Tags search
+// @Produce json
+// @Param synonym_id path string true "Synonym UUID"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v2/admin/search-synonyms/{synonym_id} [delete]
+func (h *SearchSynonymHandler) DeleteSynonym(c *gin.Context) {
+	synonymID, ok := parseSynonymID(c)
+	if !ok {
+		return
+	}
+
+	deleted, err := h.searchSynonymService.DeleteSynonym(c.Request.Context(), synonymID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Internal Server Error",
+			Message: "failed to delete search synonym: " + err.Error(),
+		})
+		return
+	}
+	if !deleted {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error:   "Not Found",
+			Message: "search synonym not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Message: "search synonym deleted successfully",
+	})
+}
+
+// NormalizeQuery handles GET /api/v2/admin/search-synonyms/normalize
+// @Summary Preview query normalization
+// @Description Show how a search query is folded, spelling-corrected and rewritten before retrieval
+// @Tags search
+// @Produce json
+// @Param q query string true "Search query"
+// @Success 200 {object} dto.NormalizedQuery
+// @Failure 400 {object} ErrorResponse
+// @Router /api/v2/admin/search-synonyms/normalize [get]
+func (h *SearchSynonymHandler) NormalizeQuery(c *gin.Context) {
+	query := strings.TrimSpace(c.Query("q"))
+	if query == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Bad Request",
+			Message: "q is required",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, h.searchSynonymService.Normalize(c.Request.Context(), query))
+}
+
+// parseSynonymID parses the synonym_id path parameter, writing a 400 response on failure
+func parseSynonymID(c *gin.Context) (uuid.UUID, bool) {
+	synonymID, err := uuid.Parse(c.Param("synonym_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Bad Request",
+			Message: "invalid synonym_id format",
+		})
+		return uuid.Nil, false
+	}
+
+	return synonymID, true
+}
+
+// bindSearchSynonymRequest binds and validates a synonym request, writing a 400 response on failure
+func bindSearchSynonymRequest(c *gin.Context) (*dto.SearchSynonymRequest, bool) {
+	var req dto.SearchSynonymRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Bad Request",
+			Message: "invalid request body: " + err.Error(),
+		})
+		return nil, false
+	}
+
+	switch req.Type {
+	case "", dto.SearchSynonymTypeSynonym, dto.SearchSynonymTypeAbbreviation, dto.SearchSynonymTypeMisspelling:
+	default:
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Bad Request",
+			Message: "type must be one of synonym, abbreviation, misspelling",
+		})
+		return nil, false
+	}
+
+	return &req, true
+}
+
+// writeSearchSynonymError maps dictionary validation and conflict errors to 400 and 409 responses
+func writeSearchSynonymError(c *gin.Context, prefix string, err error) {
+	switch {
+	case errors.Is(err, dto.ErrInvalidSearchSynonym):
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Bad Request",
+			Message: err.Error(),
+		})
+	case errors.Is(err, dto.ErrSearchSynonymExists):
+		c.JSON(http.StatusConflict, ErrorResponse{
+			Error:   "Conflict",
+			Message: err.Error(),
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Internal Server Error",
+			Message: prefix + err.Error(),
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package handler
+
+import (
+	"context"
+	"ec-recommend/internal/dto"
+
+	"github.com/google/uuid"
+)
+
+// SearchSynonymServiceInterface defines the interface for search query normalization and its synonym dictionary
+// This interface is defined in the handler package as it is consumed by handlers
+type SearchSynonymServiceInterface interface {
+	// ListSynonyms returns all search synonyms
+	ListSynonyms(ctx context.Context) (*dto.SearchSynonymListResponse, error)
+
+	// GetSynonym returns a search synonym by ID, or nil if it does not exist
+	GetSynonym(ctx context.Context, synonymID uuid.UUID) (*dto.SearchSynonym, error)
+
+	// CreateSynonym creates a search synonym
+	CreateSynonym(ctx context.Context, req *dto.SearchSynonymRequest) (*dto.SearchSynonym, error)
+
+	// UpdateSynonym replaces a search synonym, returning nil if it does not exist
+	UpdateSynonym(ctx context.Context, synonymID uuid.UUID, req *dto.SearchSynonymRequest) (*dto.SearchSynonym, error)
+
+	// DeleteSynonym deletes a search synonym, reporting whether it existed
+	DeleteSynonym(ctx context.Context, synonymID uuid.UUID) (bool, error)
+
+	// Normalize applies width folding, spelling correction and synonym rewrites to a search query
+	Normalize(ctx context.Context, query string) *dto.NormalizedQuery
+}
@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// maxSuggestQueryLength bounds the search box input accepted for completion
+const maxSuggestQueryLength = 100
+
+// SuggestHandler handles search box completion requests
+type SuggestHandler struct {
+	suggestService SuggestServiceInterface
+}
+
+// NewSuggestHandler creates a new suggest handler instance
+func NewSuggestHandler(suggestService SuggestServiceInterface) *SuggestHandler {
+	return &SuggestHandler{
+		suggestService: suggestService,
+	}
+}
+
+// GetSuggestions handles GET /api/v2/search/suggest
+// @Summary Complete a search box query
+// @Description Suggest popular search queries, product names, brands and categories starting with the query. Hiragana, katakana, full-width and half-width input match alike, kanji names also match their readings, and a customer's category affinity raises their categories.
+// @Tags search
+// @Produce json
+// @Param q query string true "Text typed into the search box"
+// @Param customer_id query string false "Customer UUID for personalization"
+// @Param limit query int false "Number of completions to return" default(10)
+// @Success 200 {object} dto.SuggestResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v2/search/suggest [get]
+func (h *SuggestHandler) GetSuggestions(c *gin.Context) {
+	query := strings.TrimSpace(c.Query("q"))
+	if query == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Bad Request",
+			Message: "q parameter is required",
+		})
+		return
+	}
+	if utf8.RuneCountInString(query) > maxSuggestQueryLength {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Bad Request",
+			Message: "q must be at most 100 characters",
+		})
+		return
+	}
+
+	// Parse optional customer ID for personalization
+	var customerID *uuid.UUID
+	if customerIDStr := c.Query("customer_id"); customerIDStr != "" {
+		parsed, err := uuid.Parse(customerIDStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "Bad Request",
+				Message: "invalid customer_id format",
+			})
+			return
+		}
+		customerID = &parsed
+	}
+
+	// Parse limit; zero uses the configured default
+	limit := 0
+	if limitStr := c.Query("limit"); limitStr != "" {
+		var err error
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 || limit > 50 {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "Bad Request",
+				Message: "limit must be a positive integer between 1 and 50",
+			})
+			return
+		}
+	}
+
+	response, err := h.suggestService.Suggest(c.Request.Context(), query, customerID, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Internal Server Error",
+			Message: "failed to get suggestions: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,15 @@
+package handler
+
+import (
+	"context"
+	"ec-recommend/internal/dto"
+
+	"github.com/google/uuid"
+)
+
+// SuggestServiceInterface defines the interface for search box completions
+// This interface is defined in the handler package as it is consumed by handlers
+type SuggestServiceInterface interface {
+	// Suggest returns completions of the query, personalized when a customer is given
+	Suggest(ctx context.Context, query string, customerID *uuid.UUID, limit int) (*dto.SuggestResponse, error)
+}
@@ -129,6 +129,17 @@ func (bc *BedrockClient) GenerateResponse(ctx context.Context, prompt string) (*
 	return response, nil
 }
 
+// GenerateResponseWithModel generates a response using the given model instead of the configured one
+func (bc *BedrockClient) GenerateResponseWithModel(ctx context.Context, prompt, modelID string) (*types.AIResponse, error) {
+	if modelID == "" || modelID == bc.modelID {
+		return bc.GenerateResponse(ctx, prompt)
+	}
+
+	override := *bc
+	override.modelID = modelID
+	return override.GenerateResponse(ctx, prompt)
+}
+
 // prepareRequest prepares the request body based on the model type
 func (bc *BedrockClient) prepareRequest(prompt string) ([]byte, error) {
 	// For Claude models
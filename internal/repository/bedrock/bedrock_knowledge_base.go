@@ -64,6 +64,13 @@ This approach maximizes the value of the CSV batch metadata while ensuring
 high-performance and precise product recommendations.
 */
 
+// Knowledge base retrieval returns defaultNumberOfResults unless a result count is requested,
+// and at most maxNumberOfResults, the Retrieve API's limit
+const (
+	defaultNumberOfResults = 10
+	maxNumberOfResults     = 100
+)
+
 // BedrockKnowledgeBaseService implements the BedrockKnowledgeBaseInterface
 type BedrockKnowledgeBaseService struct {
 	agentClient      *bedrockagentruntime.Client
@@ -92,11 +99,22 @@ func NewBedrockKnowledgeBaseService(
 func (bkb *BedrockKnowledgeBaseService) QueryKnowledgeBase(ctx context.Context, query string, filters map[string]interface{}) (*service.RAGResponse, error) {
 	startTime := time.Now()
 
+	// Use hybrid search for better results unless vector search alone is requested
+	searchType := types.SearchTypeHybrid
+	if filters[service.RetrievalSearchTypeFilter] == "semantic" {
+		searchType = types.SearchTypeSemantic
+	}
+
+	numberOfResults := defaultNumberOfResults
+	if count, ok := filters[service.RetrievalResultCountFilter].(int); ok && count > 0 {
+		numberOfResults = min(count, maxNumberOfResults)
+	}
+
 	// Build advanced retrieval configuration with metadata filtering
 	retrievalConfig := &types.KnowledgeBaseRetrievalConfiguration{
 		VectorSearchConfiguration: &types.KnowledgeBaseVectorSearchConfiguration{
-			NumberOfResults:    aws.Int32(10),
-			OverrideSearchType: types.SearchTypeHybrid, // Use hybrid search for better results
+			NumberOfResults:    aws.Int32(int32(numberOfResults)),
+			OverrideSearchType: searchType,
 		},
 	}
 
@@ -438,6 +456,16 @@ func extractModelIDFromARN(arn string) string {
 func (bkb *BedrockKnowledgeBaseService) GetProductsWithSemanticSearch(ctx context.Context, query string, limit int, filters map[string]interface{}) (*service.RAGSemanticSearchResponse, error) {
 	startTime := time.Now()
 
+	// Retrieve as many results as requested rather than the knowledge base default
+	if limit > 0 {
+		retrievalFilters := make(map[string]interface{}, len(filters)+1)
+		for k, v := range filters {
+			retrievalFilters[k] = v
+		}
+		retrievalFilters[service.RetrievalResultCountFilter] = limit
+		filters = retrievalFilters
+	}
+
 	// Use the enhanced QueryKnowledgeBase method with proper metadata filtering
 	ragResponse, err := bkb.QueryKnowledgeBase(ctx, query, filters)
 	if err != nil {
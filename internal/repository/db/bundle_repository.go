@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"ec-recommend/internal/dto"
+	"ec-recommend/internal/service"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// BundleRepository implements the BundleRepositoryInterface
+type BundleRepository struct {
+	db *sql.DB
+}
+
+// NewBundleRepository creates a new bundle repository instance
+func NewBundleRepository(db *sql.DB) service.BundleRepositoryInterface {
+	return &BundleRepository{
+		db: db,
+	}
+}
+
+// GetCoPurchasedProducts returns active products bought in the same orders as the anchors
+func (r *BundleRepository) GetCoPurchasedProducts(ctx context.Context, anchorIDs []uuid.UUID, limit int) ([]dto.BundleCoPurchase, error) {
+	if len(anchorIDs) == 0 {
+		return []dto.BundleCoPurchase{}, nil
+	}
+
+	query := `
+		SELECT oi.product_id, p.category_id, COUNT(DISTINCT oi.order_id) AS orders
+		FROM order_items anchor
+		JOIN orders o ON o.id = anchor.order_id
+		JOIN order_items oi ON oi.order_id = anchor.order_id
+		JOIN products p ON p.id = oi.product_id
+		WHERE anchor.product_id = ANY($1::uuid[])
+			AND oi.product_id <> ALL($1::uuid[])
+			AND o.status NOT IN ('cancelled', 'returned')
+			AND p.is_active = true
+			AND p.stock_quantity > 0
+		GROUP BY oi.product_id, p.category_id
+		ORDER BY orders DESC, oi.product_id
+		LIMIT $2
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(uuidStrings(anchorIDs)), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query co-purchased products: %w", err)
+	}
+	defer rows.Close()
+
+	var coPurchases []dto.BundleCoPurchase
+	for rows.Next() {
+		var cp dto.BundleCoPurchase
+		if err := rows.Scan(&cp.ProductID, &cp.CategoryID, &cp.Count); err != nil {
+			return nil, fmt.Errorf("failed to scan co-purchased product: %w", err)
+		}
+		coPurchases = append(coPurchases, cp)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate co-purchased products: %w", err)
+	}
+
+	return coPurchases, nil
+}
+
+// GetCategoryCoPurchases returns categories bought in the same orders as the given categories
+func (r *BundleRepository) GetCategoryCoPurchases(ctx context.Context, categoryIDs []int, limit int) ([]dto.CategoryCoPurchase, error) {
+	if len(categoryIDs) == 0 {
+		return []dto.CategoryCoPurchase{}, nil
+	}
+
+	query := `
+		SELECT p.category_id, COUNT(DISTINCT oi.order_id) AS orders
+		FROM order_items anchor
+		JOIN orders o ON o.id = anchor.order_id
+		JOIN products ap ON ap.id = anchor.product_id
+		JOIN order_items oi ON oi.order_id = anchor.order_id
+		JOIN products p ON p.id = oi.product_id
+		WHERE ap.category_id = ANY($1)
+			AND p.category_id <> ALL($1)
+			AND o.status NOT IN ('cancelled', 'returned')
+		GROUP BY p.category_id
+		ORDER BY orders DESC, p.category_id
+		LIMIT $2
+	`
+
+	ids := make([]int64, len(categoryIDs))
+	for i, id := range categoryIDs {
+		ids[i] = int64(id)
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Int64Array(ids), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query category co-purchases: %w", err)
+	}
+	defer rows.Close()
+
+	var coPurchases []dto.CategoryCoPurchase
+	for rows.Next() {
+		var cc dto.CategoryCoPurchase
+		if err := rows.Scan(&cc.CategoryID, &cc.Count); err != nil {
+			return nil, fmt.Errorf("failed to scan category co-purchase: %w", err)
+		}
+		coPurchases = append(coPurchases, cc)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate category co-purchases: %w", err)
+	}
+
+	return coPurchases, nil
+}
+
+// GetCartProductIDs returns the products in a customer's cart, oldest first
+func (r *BundleRepository) GetCartProductIDs(ctx context.Context, customerID uuid.UUID) ([]uuid.UUID, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT product_id FROM cart_items WHERE customer_id = $1 ORDER BY added_at
+	`, customerID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to query cart items: %w", err)
+	}
+	defer rows.Close()
+
+	var productIDs []uuid.UUID
+	for rows.Next() {
+		var productID uuid.UUID
+		if err := rows.Scan(&productID); err != nil {
+			return nil, fmt.Errorf("failed to scan cart item: %w", err)
+		}
+		productIDs = append(productIDs, productID)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate cart items: %w", err)
+	}
+
+	return productIDs, nil
+}
@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"ec-recommend/internal/dto"
+	"ec-recommend/internal/service"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EvaluationRepository implements the EvaluationRepositoryInterface
+type EvaluationRepository struct {
+	db *sql.DB
+}
+
+// NewEvaluationRepository creates a new evaluation repository instance
+func NewEvaluationRepository(db *sql.DB) service.EvaluationRepositoryInterface {
+	return &EvaluationRepository{
+		db: db,
+	}
+}
+
+// GetOrderLines returns order items ordered in [since, until), oldest first.
+// Orders of every status are returned so that callers decide what counts as a purchase.
+func (r *EvaluationRepository) GetOrderLines(ctx context.Context, since, until time.Time) ([]dto.EvaluationOrderLine, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT o.id, o.customer_id, oi.product_id, p.category_id, o.status,
+		       oi.unit_price, oi.quantity, o.ordered_at
+		FROM order_items oi
+		JOIN orders o ON oi.order_id = o.id
+		JOIN products p ON oi.product_id = p.id
+		WHERE o.ordered_at >= $1 AND o.ordered_at < $2
+		ORDER BY o.ordered_at, o.id, oi.product_id
+	`, since, until)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query order lines: %w", err)
+	}
+	defer rows.Close()
+
+	var lines []dto.EvaluationOrderLine
+	for rows.Next() {
+		var line dto.EvaluationOrderLine
+		if err := rows.Scan(
+			&line.OrderID,
+			&line.CustomerID,
+			&line.ProductID,
+			&line.CategoryID,
+			&line.Status,
+			&line.UnitPrice,
+			&line.Quantity,
+			&line.OrderedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan order line: %w", err)
+		}
+		lines = append(lines, line)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate order lines: %w", err)
+	}
+
+	return lines, nil
+}
+
+// GetCatalogCategories returns the category of every active product
+func (r *EvaluationRepository) GetCatalogCategories(ctx context.Context) (map[uuid.UUID]int, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, category_id
+		FROM products
+		WHERE is_active = true
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query catalog categories: %w", err)
+	}
+	defer rows.Close()
+
+	categories := make(map[uuid.UUID]int)
+	for rows.Next() {
+		var productID uuid.UUID
+		var categoryID int
+		if err := rows.Scan(&productID, &categoryID); err != nil {
+			return nil, fmt.Errorf("failed to scan catalog category: %w", err)
+		}
+		categories[productID] = categoryID
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate catalog categories: %w", err)
+	}
+
+	return categories, nil
+}
@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"ec-recommend/internal/dto"
+	"ec-recommend/internal/service"
+	"encoding/json"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// ExperimentRepository implements the ExperimentRepositoryInterface
+type ExperimentRepository struct {
+	db *sql.DB
+}
+
+// NewExperimentRepository creates a new experiment repository instance
+func NewExperimentRepository(db *sql.DB) service.ExperimentRepositoryInterface {
+	return &ExperimentRepository{
+		db: db,
+	}
+}
+
+// ListExperiments returns experiments defined in the database
+func (r *ExperimentRepository) ListExperiments(ctx context.Context) ([]dto.Experiment, error) {
+	query := `
+		SELECT id, name, description, is_active, service, context_types, variants, starts_at, ends_at
+		FROM experiments
+		ORDER BY id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query experiments: %w", err)
+	}
+	defer rows.Close()
+
+	var experiments []dto.Experiment
+	for rows.Next() {
+		var experiment dto.Experiment
+		var description, serviceName sql.NullString
+		var isActive sql.NullBool
+		var contextTypes pq.StringArray
+		var variants []byte
+		var startsAt, endsAt sql.NullTime
+
+		if err := rows.Scan(&experiment.ID, &experiment.Name, &description, &isActive, &serviceName,
+			&contextTypes, &variants, &startsAt, &endsAt); err != nil {
+			return nil, fmt.Errorf("failed to scan experiment: %w", err)
+		}
+
+		if err := json.Unmarshal(variants, &experiment.Variants); err != nil {
+			return nil, fmt.Errorf("failed to parse variants of experiment %s: %w", experiment.ID, err)
+		}
+
+		experiment.Description = description.String
+		experiment.IsActive = isActive.Valid && isActive.Bool
+		experiment.Service = serviceName.String
+		experiment.ContextTypes = contextTypes
+		if startsAt.Valid {
+			experiment.StartsAt = &startsAt.Time
+		}
+		if endsAt.Valid {
+			experiment.EndsAt = &endsAt.Time
+		}
+
+		experiments = append(experiments, experiment)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate experiments: %w", err)
+	}
+
+	return experiments, nil
+}
+
+// GetExperimentVariantCounts aggregates recommendation logs stamped with the experiment per variant.
+// Logs carry the assignment in algorithm_version as "<version>;exp=<experiment_id>/<variant>".
+func (r *ExperimentRepository) GetExperimentVariantCounts(ctx context.Context, experimentID string) ([]dto.ExperimentVariantCounts, error) {
+	query := `
+		SELECT
+			split_part(split_part(algorithm_version, ';exp=' || $1 || '/', 2), ';', 1) AS variant,
+			COUNT(*) AS impressions,
+			COUNT(*) FILTER (WHERE cardinality(clicked_products) > 0) AS clicks,
+			COUNT(*) FILTER (WHERE cardinality(purchased_products) > 0) AS conversions
+		FROM recommendation_logs
+		WHERE position(';exp=' || $1 || '/' IN algorithm_version) > 0
+		GROUP BY variant
+		ORDER BY variant
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, experimentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query experiment counts: %w", err)
+	}
+	defer rows.Close()
+
+	var counts []dto.ExperimentVariantCounts
+	for rows.Next() {
+		var c dto.ExperimentVariantCounts
+		if err := rows.Scan(&c.Variant, &c.Impressions, &c.Clicks, &c.Conversions); err != nil {
+			return nil, fmt.Errorf("failed to scan experiment counts: %w", err)
+		}
+		counts = append(counts, c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate experiment counts: %w", err)
+	}
+
+	return counts, nil
+}
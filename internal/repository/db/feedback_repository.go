@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"ec-recommend/internal/dto"
+	"ec-recommend/internal/service"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FeedbackRepository implements the FeedbackRepositoryInterface
+type FeedbackRepository struct {
+	db *sql.DB
+}
+
+// NewFeedbackRepository creates a new feedback repository instance
+func NewFeedbackRepository(db *sql.DB) service.FeedbackRepositoryInterface {
+	return &FeedbackRepository{
+		db: db,
+	}
+}
+
+const feedbackColumns = `id, customer_id, product_id, recommendation_id, reason_code, scope,
+	COALESCE(brand, ''), category_id, COALESCE(context_type, ''), COALESCE(comment, ''), suppress_until, created_at`
+
+// CreateFeedback stores a dismissal with the product's current brand and category,
+// returning nil if the product does not exist
+func (r *FeedbackRepository) CreateFeedback(ctx context.Context, feedback *dto.RecommendationFeedback) (*dto.RecommendationFeedback, error) {
+	var recommendationID interface{}
+	if feedback.RecommendationID != nil {
+		recommendationID = feedback.RecommendationID.String()
+	}
+
+	query := `
+		INSERT INTO recommendation_feedback (
+			customer_id, product_id, recommendation_id, reason_code, scope,
+			brand, category_id, context_type, comment, suppress_until
+		)
+		SELECT $1, p.id, $3, $4, $5, p.brand, p.category_id, NULLIF($6, ''), NULLIF($7, ''), $8
+		FROM products p
+		WHERE p.id = $2
+		RETURNING ` + feedbackColumns
+
+	created, err := scanFeedback(r.db.QueryRowContext(ctx, query,
+		feedback.CustomerID.String(), feedback.ProductID.String(), recommendationID, feedback.ReasonCode, feedback.Scope,
+		feedback.ContextType, feedback.Comment, feedback.SuppressUntil,
+	))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to create feedback: %w", err)
+	}
+
+	return created, nil
+}
+
+// DeleteFeedback deletes a customer's dismissal, reporting whether it existed
+func (r *FeedbackRepository) DeleteFeedback(ctx context.Context, feedbackID, customerID uuid.UUID) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM recommendation_feedback WHERE id = $1 AND customer_id = $2`,
+		feedbackID.String(), customerID.String())
+	if err != nil {
+		return false, fmt.Errorf("failed to delete feedback: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	return affected > 0, nil
+}
+
+// GetActiveFeedback returns a customer's dismissals that still suppress at the given time
+func (r *FeedbackRepository) GetActiveFeedback(ctx context.Context, customerID uuid.UUID, now time.Time) ([]dto.RecommendationFeedback, error) {
+	return r.queryFeedback(ctx, `
+		SELECT `+feedbackColumns+`
+		FROM recommendation_feedback
+		WHERE customer_id = $1 AND suppress_until > $2
+		ORDER BY created_at DESC
+	`, customerID.String(), now)
+}
+
+// GetFeedbackSince returns a customer's dismissals created since the given time
+func (r *FeedbackRepository) GetFeedbackSince(ctx context.Context, customerID uuid.UUID, since time.Time) ([]dto.RecommendationFeedback, error) {
+	return r.queryFeedback(ctx, `
+		SELECT `+feedbackColumns+`
+		FROM recommendation_feedback
+		WHERE customer_id = $1 AND created_at >= $2
+		ORDER BY created_at DESC
+	`, customerID.String(), since)
+}
+
+func (r *FeedbackRepository) queryFeedback(ctx context.Context, query string, args ...interface{}) ([]dto.RecommendationFeedback, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query feedback: %w", err)
+	}
+	defer rows.Close()
+
+	var feedback []dto.RecommendationFeedback
+	for rows.Next() {
+		f, err := scanFeedback(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan feedback: %w", err)
+		}
+		feedback = append(feedback, *f)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate feedback: %w", err)
+	}
+
+	return feedback, nil
+}
+
+func scanFeedback(row rowScanner) (*dto.RecommendationFeedback, error) {
+	var f dto.RecommendationFeedback
+	var recommendationID uuid.NullUUID
+	err := row.Scan(
+		&f.ID, &f.CustomerID, &f.ProductID, &recommendationID, &f.ReasonCode, &f.Scope,
+		&f.Brand, &f.CategoryID, &f.ContextType, &f.Comment, &f.SuppressUntil, &f.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if recommendationID.Valid {
+		f.RecommendationID = &recommendationID.UUID
+	}
+
+	return &f, nil
+}
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"ec-recommend/internal/service"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// ImpressionRepository implements the ImpressionRepositoryInterface
+type ImpressionRepository struct {
+	db *sql.DB
+}
+
+// NewImpressionRepository creates a new impression repository instance
+func NewImpressionRepository(db *sql.DB) service.ImpressionRepositoryInterface {
+	return &ImpressionRepository{
+		db: db,
+	}
+}
+
+// RecordImpressions stores one impression per returned product with its position in the list
+func (r *ImpressionRepository) RecordImpressions(ctx context.Context, recommendationID, customerID uuid.UUID, contextType string, productIDs []uuid.UUID) error {
+	if len(productIDs) == 0 {
+		return nil
+	}
+
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO recommendation_impressions (recommendation_id, customer_id, product_id, context_type, position)
+		SELECT $1, $2, p.id, $3, p.position
+		FROM UNNEST($4::uuid[]) WITH ORDINALITY AS p(id, position)
+	`, recommendationID.String(), customerID.String(), contextType, pq.Array(uuidStrings(productIDs)))
+	if err != nil {
+		return fmt.Errorf("failed to insert impressions: %w", err)
+	}
+
+	return nil
+}
+
+// MarkClicked marks the impressions of the given products in a recommendation as clicked
+func (r *ImpressionRepository) MarkClicked(ctx context.Context, recommendationID uuid.UUID, productIDs []uuid.UUID, clickedAt time.Time) error {
+	if len(productIDs) == 0 {
+		return nil
+	}
+
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE recommendation_impressions
+		SET clicked_at = $3
+		WHERE recommendation_id = $1 AND product_id = ANY($2::uuid[]) AND clicked_at IS NULL
+	`, recommendationID.String(), pq.Array(uuidStrings(productIDs)), clickedAt)
+	if err != nil {
+		return fmt.Errorf("failed to mark impressions clicked: %w", err)
+	}
+
+	return nil
+}
+
+// GetUnclickedImpressionCounts returns, for the given products shown to the customer in the context
+// since the given time and never clicked in that period, how many times each was shown
+func (r *ImpressionRepository) GetUnclickedImpressionCounts(ctx context.Context, customerID uuid.UUID, contextType string, since time.Time, productIDs []uuid.UUID) (map[uuid.UUID]int, error) {
+	counts := make(map[uuid.UUID]int)
+	if len(productIDs) == 0 {
+		return counts, nil
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT product_id, COUNT(*)
+		FROM recommendation_impressions
+		WHERE customer_id = $1 AND context_type = $2 AND created_at >= $3 AND product_id = ANY($4::uuid[])
+		GROUP BY product_id
+		HAVING COUNT(clicked_at) = 0
+	`, customerID.String(), contextType, since, pq.Array(uuidStrings(productIDs)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query impression counts: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var productID uuid.UUID
+		var count int
+		if err := rows.Scan(&productID, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan impression count: %w", err)
+		}
+		counts[productID] = count
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate impression counts: %w", err)
+	}
+
+	return counts, nil
+}
@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"ec-recommend/internal/dto"
+	"ec-recommend/internal/service"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// LexicalSearchRepository implements the LexicalSearchRepositoryInterface
+type LexicalSearchRepository struct {
+	db *sql.DB
+}
+
+// NewLexicalSearchRepository creates a new lexical search repository instance
+func NewLexicalSearchRepository(db *sql.DB) service.LexicalSearchRepositoryInterface {
+	return &LexicalSearchRepository{
+		db: db,
+	}
+}
+
+// GetLexicalCandidates matches terms as substrings of the products' search documents, which the
+// trigram index on search_document serves without tokenizing Japanese text
+func (r *LexicalSearchRepository) GetLexicalCandidates(ctx context.Context, terms []string, filter dto.LexicalSearchFilter, limit int) ([]dto.LexicalDocument, error) {
+	query := `
+		SELECT
+			p.id,
+			p.name,
+			COALESCE(p.description, ''),
+			COALESCE(p.brand, ''),
+			COALESCE(p.tags, '{}'),
+			COALESCE(CASE WHEN jsonb_typeof(p.features) = 'object'
+				THEN (SELECT string_agg(value, ' ') FROM jsonb_each_text(p.features)) END, ''),
+			COALESCE(p.popularity_score, 0),
+			char_length(p.search_document)
+		FROM products p
+		WHERE p.is_active = true
+			AND p.search_document LIKE ANY($1)
+			AND (cardinality($2::int[]) = 0 OR p.category_id = ANY($2))
+			AND NOT (p.category_id = ANY($3::int[]))
+			AND NOT (p.id = ANY($4::uuid[]))
+			AND ($5::numeric IS NULL OR p.price >= $5)
+			AND ($6::numeric IS NULL OR p.price <= $6)
+			AND ($7::numeric IS NULL OR p.rating_average >= $7)
+		ORDER BY
+			(SELECT COUNT(*) FROM UNNEST($1::text[]) AS pattern WHERE p.search_document LIKE pattern) DESC,
+			p.popularity_score DESC NULLS LAST,
+			p.id
+		LIMIT $8
+	`
+
+	rows, err := r.db.QueryContext(ctx, query,
+		pq.Array(likePatterns(terms)),
+		pq.Array(intsOrEmpty(filter.CategoryIDs)),
+		pq.Array(intsOrEmpty(filter.ExcludeCategoryIDs)),
+		pq.Array(uuidStrings(filter.ExcludeProductIDs)),
+		filter.PriceMin,
+		filter.PriceMax,
+		filter.RatingMin,
+		limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query lexical candidates: %w", err)
+	}
+	defer rows.Close()
+
+	var documents []dto.LexicalDocument
+	for rows.Next() {
+		var d dto.LexicalDocument
+		var productID string
+		var tags pq.StringArray
+		if err := rows.Scan(&productID, &d.Name, &d.Description, &d.Brand, &tags, &d.Features, &d.Popularity, &d.Length); err != nil {
+			return nil, fmt.Errorf("failed to scan lexical candidate: %w", err)
+		}
+		if d.ProductID, err = uuid.Parse(productID); err != nil {
+			return nil, fmt.Errorf("failed to parse product ID: %w", err)
+		}
+		d.Tags = tags
+		documents = append(documents, d)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate lexical candidates: %w", err)
+	}
+
+	return documents, nil
+}
+
+// GetLexicalCorpusStats counts documents per term with the same substring match as the candidates
+func (r *LexicalSearchRepository) GetLexicalCorpusStats(ctx context.Context, terms []string) (*dto.LexicalCorpusStats, error) {
+	query := `
+		SELECT
+			COUNT(*),
+			COALESCE(AVG(char_length(search_document)), 0)::float8,
+			ARRAY(
+				SELECT COUNT(p.id)
+				FROM UNNEST($1::text[]) WITH ORDINALITY AS t(pattern, ord)
+				LEFT JOIN products p ON p.is_active = true AND p.search_document LIKE t.pattern
+				GROUP BY t.ord
+				ORDER BY t.ord
+			)
+		FROM products
+		WHERE is_active = true AND search_document IS NOT NULL
+	`
+
+	stats := &dto.LexicalCorpusStats{DocumentFrequency: make(map[string]int, len(terms))}
+	var frequencies pq.Int64Array
+	err := r.db.QueryRowContext(ctx, query, pq.Array(likePatterns(terms))).Scan(&stats.DocumentCount, &stats.AverageLength, &frequencies)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get lexical corpus stats: %w", err)
+	}
+
+	for i, term := range terms {
+		if i < len(frequencies) {
+			stats.DocumentFrequency[term] = int(frequencies[i])
+		}
+	}
+
+	return stats, nil
+}
+
+// likePatterns wraps each term in % wildcards, escaping LIKE metacharacters in the term
+func likePatterns(terms []string) []string {
+	escaper := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	patterns := make([]string, len(terms))
+	for i, term := range terms {
+		patterns[i] = "%" + escaper.Replace(term) + "%"
+	}
+	return patterns
+}
+
+// intsOrEmpty returns a non-nil slice so pq.Array encodes an empty array rather than NULL
+func intsOrEmpty(values []int) []int {
+	if values == nil {
+		return []int{}
+	}
+	return values
+}
@@ -0,0 +1,256 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"ec-recommend/internal/dto"
+	"ec-recommend/internal/service"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// MerchandisingRuleRepository implements the MerchandisingRuleRepositoryInterface
+type MerchandisingRuleRepository struct {
+	db *sql.DB
+}
+
+// NewMerchandisingRuleRepository creates a new merchandising rule repository instance
+func NewMerchandisingRuleRepository(db *sql.DB) service.MerchandisingRuleRepositoryInterface {
+	return &MerchandisingRuleRepository{
+		db: db,
+	}
+}
+
+const merchandisingRuleColumns = `
+	id, name, description, is_active, priority,
+	context_types, category_ids, customer_segments, starts_at, ends_at,
+	action_type, target_product_id, target_brand, target_category_id, factor, slot,
+	created_at, updated_at
+`
+
+// ListMerchandisingRules returns all rules ordered by priority
+func (r *MerchandisingRuleRepository) ListMerchandisingRules(ctx context.Context) ([]dto.MerchandisingRule, error) {
+	query := `SELECT ` + merchandisingRuleColumns + ` FROM merchandising_rules ORDER BY priority DESC, created_at`
+
+	return r.queryRules(ctx, query)
+}
+
+// GetActiveMerchandisingRules returns active rules whose date range includes the given time
+func (r *MerchandisingRuleRepository) GetActiveMerchandisingRules(ctx context.Context, at time.Time) ([]dto.MerchandisingRule, error) {
+	query := `SELECT ` + merchandisingRuleColumns + `
+		FROM merchandising_rules
+		WHERE is_active = true
+			AND (starts_at IS NULL OR starts_at <= $1)
+			AND (ends_at IS NULL OR ends_at > $1)
+		ORDER BY priority DESC, created_at
+	`
+
+	return r.queryRules(ctx, query, at)
+}
+
+// GetMerchandisingRule returns a rule by ID, or nil if it does not exist
+func (r *MerchandisingRuleRepository) GetMerchandisingRule(ctx context.Context, ruleID uuid.UUID) (*dto.MerchandisingRule, error) {
+	query := `SELECT ` + merchandisingRuleColumns + ` FROM merchandising_rules WHERE id = $1`
+
+	rule, err := scanMerchandisingRule(r.db.QueryRowContext(ctx, query, ruleID.String()))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get merchandising rule: %w", err)
+	}
+
+	return rule, nil
+}
+
+// CreateMerchandisingRule stores a new rule and returns it with generated fields populated
+func (r *MerchandisingRuleRepository) CreateMerchandisingRule(ctx context.Context, rule *dto.MerchandisingRule) (*dto.MerchandisingRule, error) {
+	query := `
+		INSERT INTO merchandising_rules (
+			name, description, is_active, priority,
+			context_types, category_ids, customer_segments, starts_at, ends_at,
+			action_type, target_product_id, target_brand, target_category_id, factor, slot
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+		RETURNING ` + merchandisingRuleColumns
+
+	created, err := scanMerchandisingRule(r.db.QueryRowContext(ctx, query, merchandisingRuleArgs(rule)...))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create merchandising rule: %w", err)
+	}
+
+	return created, nil
+}
+
+// UpdateMerchandisingRule replaces an existing rule, returning nil if it does not exist
+func (r *MerchandisingRuleRepository) UpdateMerchandisingRule(ctx context.Context, rule *dto.MerchandisingRule) (*dto.MerchandisingRule, error) {
+	query := `
+		UPDATE merchandising_rules SET
+			name = $1, description = $2, is_active = $3, priority = $4,
+			context_types = $5, category_ids = $6, customer_segments = $7, starts_at = $8, ends_at = $9,
+			action_type = $10, target_product_id = $11, target_brand = $12, target_category_id = $13, factor = $14, slot = $15
+		WHERE id = $16
+		RETURNING ` + merchandisingRuleColumns
+
+	args := append(merchandisingRuleArgs(rule), rule.ID.String())
+	updated, err := scanMerchandisingRule(r.db.QueryRowContext(ctx, query, args...))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to update merchandising rule: %w", err)
+	}
+
+	return updated, nil
+}
+
+// DeleteMerchandisingRule removes a rule, reporting whether it existed
+func (r *MerchandisingRuleRepository) DeleteMerchandisingRule(ctx context.Context, ruleID uuid.UUID) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM merchandising_rules WHERE id = $1`, ruleID.String())
+	if err != nil {
+		return false, fmt.Errorf("failed to delete merchandising rule: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	return affected > 0, nil
+}
+
+func (r *MerchandisingRuleRepository) queryRules(ctx context.Context, query string, args ...interface{}) ([]dto.MerchandisingRule, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query merchandising rules: %w", err)
+	}
+	defer rows.Close()
+
+	rules := []dto.MerchandisingRule{}
+	for rows.Next() {
+		rule, err := scanMerchandisingRule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan merchandising rule: %w", err)
+		}
+		rules = append(rules, *rule)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate merchandising rules: %w", err)
+	}
+
+	return rules, nil
+}
+
+// rowScanner is implemented by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanMerchandisingRule(row rowScanner) (*dto.MerchandisingRule, error) {
+	var rule dto.MerchandisingRule
+	var description, targetBrand sql.NullString
+	var isActive sql.NullBool
+	var priority, targetCategoryID, slot sql.NullInt64
+	var categoryIDs pq.Int64Array
+	var startsAt, endsAt sql.NullTime
+	var targetProductID sql.NullString
+	var factor sql.NullFloat64
+
+	err := row.Scan(
+		&rule.ID,
+		&rule.Name,
+		&description,
+		&isActive,
+		&priority,
+		pq.Array(&rule.Conditions.ContextTypes),
+		&categoryIDs,
+		pq.Array(&rule.Conditions.CustomerSegments),
+		&startsAt,
+		&endsAt,
+		&rule.Action.Type,
+		&targetProductID,
+		&targetBrand,
+		&targetCategoryID,
+		&factor,
+		&slot,
+		&rule.CreatedAt,
+		&rule.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	rule.Description = description.String
+	rule.IsActive = isActive.Valid && isActive.Bool
+	rule.Priority = int(priority.Int64)
+	for _, id := range categoryIDs {
+		rule.Conditions.CategoryIDs = append(rule.Conditions.CategoryIDs, int(id))
+	}
+	if startsAt.Valid {
+		rule.Conditions.StartsAt = &startsAt.Time
+	}
+	if endsAt.Valid {
+		rule.Conditions.EndsAt = &endsAt.Time
+	}
+	if targetProductID.Valid {
+		productID, err := uuid.Parse(targetProductID.String)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse target product UUID: %w", err)
+		}
+		rule.Action.ProductID = &productID
+	}
+	rule.Action.Brand = targetBrand.String
+	if targetCategoryID.Valid {
+		categoryID := int(targetCategoryID.Int64)
+		rule.Action.CategoryID = &categoryID
+	}
+	rule.Action.Factor = factor.Float64
+	rule.Action.Slot = int(slot.Int64)
+
+	return &rule, nil
+}
+
+func merchandisingRuleArgs(rule *dto.MerchandisingRule) []interface{} {
+	categoryIDs := make(pq.Int64Array, len(rule.Conditions.CategoryIDs))
+	for i, id := range rule.Conditions.CategoryIDs {
+		categoryIDs[i] = int64(id)
+	}
+
+	var targetProductID, targetBrand, targetCategoryID, factor, slot interface{}
+	if rule.Action.ProductID != nil {
+		targetProductID = rule.Action.ProductID.String()
+	}
+	if rule.Action.Brand != "" {
+		targetBrand = rule.Action.Brand
+	}
+	if rule.Action.CategoryID != nil {
+		targetCategoryID = *rule.Action.CategoryID
+	}
+	if rule.Action.Factor != 0 {
+		factor = rule.Action.Factor
+	}
+	if rule.Action.Slot != 0 {
+		slot = rule.Action.Slot
+	}
+
+	return []interface{}{
+		rule.Name,
+		rule.Description,
+		rule.IsActive,
+		rule.Priority,
+		pq.Array(rule.Conditions.ContextTypes),
+		categoryIDs,
+		pq.Array(rule.Conditions.CustomerSegments),
+		rule.Conditions.StartsAt,
+		rule.Conditions.EndsAt,
+		rule.Action.Type,
+		targetProductID,
+		targetBrand,
+		targetCategoryID,
+		factor,
+		slot,
+	}
+}
@@ -0,0 +1,252 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"ec-recommend/internal/dto"
+	"ec-recommend/internal/service"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// PopularityRepository implements the PopularityRepositoryInterface
+type PopularityRepository struct {
+	db *sql.DB
+}
+
+// NewPopularityRepository creates a new popularity repository instance
+func NewPopularityRepository(db *sql.DB) service.PopularityRepositoryInterface {
+	return &PopularityRepository{
+		db: db,
+	}
+}
+
+// GetPopularityScores returns the current popularity_score of every active product
+func (r *PopularityRepository) GetPopularityScores(ctx context.Context) (map[uuid.UUID]int, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, COALESCE(popularity_score, 0)
+		FROM products
+		WHERE is_active = true
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query popularity scores: %w", err)
+	}
+	defer rows.Close()
+
+	scores := make(map[uuid.UUID]int)
+	for rows.Next() {
+		var productID uuid.UUID
+		var score int
+		if err := rows.Scan(&productID, &score); err != nil {
+			return nil, fmt.Errorf("failed to scan popularity score: %w", err)
+		}
+		scores[productID] = score
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate popularity scores: %w", err)
+	}
+
+	return scores, nil
+}
+
+// GetKnowledgeBaseSyncedScores returns, for products with score changes not yet re-synced,
+// the popularity_score their knowledge base document was last synced with. Every sync marks all
+// of a product's history, so the previous score of its oldest unsynced change is the synced score.
+func (r *PopularityRepository) GetKnowledgeBaseSyncedScores(ctx context.Context) (map[uuid.UUID]int, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT DISTINCT ON (product_id) product_id, previous_score
+		FROM product_popularity_history
+		WHERE kb_synced_at IS NULL
+		ORDER BY product_id, computed_at, id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query knowledge base synced scores: %w", err)
+	}
+	defer rows.Close()
+
+	scores := make(map[uuid.UUID]int)
+	for rows.Next() {
+		var productID uuid.UUID
+		var score int
+		if err := rows.Scan(&productID, &score); err != nil {
+			return nil, fmt.Errorf("failed to scan knowledge base synced score: %w", err)
+		}
+		scores[productID] = score
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate knowledge base synced scores: %w", err)
+	}
+
+	return scores, nil
+}
+
+// GetPopularitySignals returns order, view, cart and wishlist counts since the given time,
+// each event weighted by exp(-ln2 * age / halfLifeDays) relative to now.
+// Orders count units from orders that were not cancelled or returned; the other signals
+// come from customer_activities so that removed cart and wishlist items still count.
+func (r *PopularityRepository) GetPopularitySignals(ctx context.Context, now, since time.Time, halfLifeDays float64) ([]dto.PopularitySignals, error) {
+	query := `
+		WITH events AS (
+			SELECT oi.product_id, 'order' AS signal, oi.quantity::float8 AS weight, o.ordered_at AS occurred_at
+			FROM order_items oi
+			JOIN orders o ON o.id = oi.order_id
+			WHERE o.ordered_at >= $2
+				AND o.status NOT IN ('cancelled', 'returned')
+			UNION ALL
+			SELECT ca.product_id, ca.activity_type, 1, ca.created_at
+			FROM customer_activities ca
+			WHERE ca.created_at >= $2
+				AND ca.product_id IS NOT NULL
+				AND ca.activity_type IN ('view', 'add_to_cart', 'wishlist_add')
+		), decayed AS (
+			SELECT
+				e.product_id,
+				e.signal,
+				e.weight * EXP(-LN(2) * GREATEST(EXTRACT(EPOCH FROM ($1::timestamptz - e.occurred_at)), 0) / 86400.0 / $3) AS value
+			FROM events e
+			JOIN products p ON p.id = e.product_id
+			WHERE p.is_active = true
+		)
+		SELECT
+			product_id,
+			COALESCE(SUM(value) FILTER (WHERE signal = 'order'), 0),
+			COALESCE(SUM(value) FILTER (WHERE signal = 'view'), 0),
+			COALESCE(SUM(value) FILTER (WHERE signal = 'add_to_cart'), 0),
+			COALESCE(SUM(value) FILTER (WHERE signal = 'wishlist_add'), 0)
+		FROM decayed
+		GROUP BY product_id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, now, since, halfLifeDays)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query popularity signals: %w", err)
+	}
+	defer rows.Close()
+
+	var signals []dto.PopularitySignals
+	for rows.Next() {
+		var s dto.PopularitySignals
+		if err := rows.Scan(&s.ProductID, &s.Orders, &s.Views, &s.Carts, &s.Wishlists); err != nil {
+			return nil, fmt.Errorf("failed to scan popularity signals: %w", err)
+		}
+		signals = append(signals, s)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate popularity signals: %w", err)
+	}
+
+	return signals, nil
+}
+
+// SavePopularityScores writes changed scores to products and appends them to the history
+func (r *PopularityRepository) SavePopularityScores(ctx context.Context, changes []dto.ProductPopularity, computedAt time.Time) error {
+	if len(changes) == 0 {
+		return nil
+	}
+
+	productIDs := make([]uuid.UUID, len(changes))
+	previousScores := make(pq.Int64Array, len(changes))
+	scores := make(pq.Int64Array, len(changes))
+	rawScores := make(pq.Float64Array, len(changes))
+	orders := make(pq.Float64Array, len(changes))
+	views := make(pq.Float64Array, len(changes))
+	carts := make(pq.Float64Array, len(changes))
+	wishlists := make(pq.Float64Array, len(changes))
+	significant := make(pq.BoolArray, len(changes))
+	for i, change := range changes {
+		productIDs[i] = change.ProductID
+		previousScores[i] = int64(change.PreviousScore)
+		scores[i] = int64(change.Score)
+		rawScores[i] = change.RawScore
+		orders[i] = change.Signals.Orders
+		views[i] = change.Signals.Views
+		carts[i] = change.Signals.Carts
+		wishlists[i] = change.Signals.Wishlists
+		significant[i] = change.Significant
+	}
+	ids := pq.Array(uuidStrings(productIDs))
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE products p SET popularity_score = c.score
+		FROM UNNEST($1::uuid[], $2::int[]) AS c(id, score)
+		WHERE p.id = c.id
+	`, ids, scores)
+	if err != nil {
+		return fmt.Errorf("failed to update popularity scores: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO product_popularity_history
+			(product_id, previous_score, popularity_score, raw_score, orders, views, carts, wishlists, significant, computed_at)
+		SELECT c.id, c.previous_score, c.score, c.raw_score, c.orders, c.views, c.carts, c.wishlists, c.significant, $10
+		FROM UNNEST($1::uuid[], $2::int[], $3::int[], $4::float8[], $5::float8[], $6::float8[], $7::float8[], $8::float8[], $9::bool[])
+			AS c(id, previous_score, score, raw_score, orders, views, carts, wishlists, significant)
+	`, ids, previousScores, scores, rawScores, orders, views, carts, wishlists, significant, computedAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert popularity history: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit popularity scores: %w", err)
+	}
+
+	return nil
+}
+
+// GetPendingKnowledgeBaseSync returns products with significant score changes not yet re-synced
+func (r *PopularityRepository) GetPendingKnowledgeBaseSync(ctx context.Context) ([]uuid.UUID, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT DISTINCT product_id
+		FROM product_popularity_history
+		WHERE significant = true AND kb_synced_at IS NULL
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query pending knowledge base sync: %w", err)
+	}
+	defer rows.Close()
+
+	var productIDs []uuid.UUID
+	for rows.Next() {
+		var productID uuid.UUID
+		if err := rows.Scan(&productID); err != nil {
+			return nil, fmt.Errorf("failed to scan pending knowledge base sync: %w", err)
+		}
+		productIDs = append(productIDs, productID)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate pending knowledge base sync: %w", err)
+	}
+
+	return productIDs, nil
+}
+
+// MarkKnowledgeBaseSynced records that the products' knowledge base documents were re-synced.
+// Insignificant changes are marked too, so that the current score becomes the synced baseline.
+func (r *PopularityRepository) MarkKnowledgeBaseSynced(ctx context.Context, productIDs []uuid.UUID, syncedAt time.Time) error {
+	if len(productIDs) == 0 {
+		return nil
+	}
+
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE product_popularity_history SET kb_synced_at = $2
+		WHERE product_id = ANY($1::uuid[]) AND kb_synced_at IS NULL
+	`, pq.Array(uuidStrings(productIDs)), syncedAt)
+	if err != nil {
+		return fmt.Errorf("failed to mark knowledge base sync: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"ec-recommend/internal/dto"
+	"ec-recommend/internal/service"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// PriceSensitivityRepository implements the PriceSensitivityRepositoryInterface
+type PriceSensitivityRepository struct {
+	db *sql.DB
+}
+
+// NewPriceSensitivityRepository creates a new price sensitivity repository instance
+func NewPriceSensitivityRepository(db *sql.DB) service.PriceSensitivityRepositoryInterface {
+	return &PriceSensitivityRepository{
+		db: db,
+	}
+}
+
+// GetCustomerPricePoints returns the customer's purchased items with list and category median prices.
+// The list price is the product's original price when it exceeds the regular price.
+func (r *PriceSensitivityRepository) GetCustomerPricePoints(ctx context.Context, customerID uuid.UUID, since time.Time) ([]dto.PricePoint, error) {
+	query := `
+		WITH medians AS (
+			SELECT category_id, percentile_cont(0.5) WITHIN GROUP (ORDER BY price) AS median_price
+			FROM products
+			GROUP BY category_id
+		)
+		SELECT
+			p.category_id,
+			oi.unit_price,
+			GREATEST(COALESCE(p.original_price, p.price), p.price, oi.unit_price) AS list_price,
+			COALESCE(m.median_price, p.price) AS median_price,
+			oi.quantity
+		FROM order_items oi
+		JOIN orders o ON o.id = oi.order_id
+		JOIN products p ON p.id = oi.product_id
+		LEFT JOIN medians m ON m.category_id = p.category_id
+		WHERE o.customer_id = $1
+			AND o.status NOT IN ('cancelled', 'returned')
+			AND o.ordered_at >= $2
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, customerID.String(), since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query customer price points: %w", err)
+	}
+	defer rows.Close()
+
+	var points []dto.PricePoint
+	for rows.Next() {
+		var p dto.PricePoint
+		if err := rows.Scan(&p.CategoryID, &p.UnitPrice, &p.ListPrice, &p.CategoryMedianPrice, &p.Quantity); err != nil {
+			return nil, fmt.Errorf("failed to scan customer price point: %w", err)
+		}
+		points = append(points, p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate customer price points: %w", err)
+	}
+
+	return points, nil
+}
+
+// GetCategoryMedianPrices returns the median active product price per category
+func (r *PriceSensitivityRepository) GetCategoryMedianPrices(ctx context.Context, categoryIDs []int) (map[int]float64, error) {
+	medians := make(map[int]float64, len(categoryIDs))
+	if len(categoryIDs) == 0 {
+		return medians, nil
+	}
+
+	ids := make([]int64, len(categoryIDs))
+	for i, id := range categoryIDs {
+		ids[i] = int64(id)
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT category_id, percentile_cont(0.5) WITHIN GROUP (ORDER BY price)
+		FROM products
+		WHERE category_id = ANY($1) AND is_active = true
+		GROUP BY category_id
+	`, pq.Int64Array(ids))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query category median prices: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var categoryID int
+		var median float64
+		if err := rows.Scan(&categoryID, &median); err != nil {
+			return nil, fmt.Errorf("failed to scan category median price: %w", err)
+		}
+		medians[categoryID] = median
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate category median prices: %w", err)
+	}
+
+	return medians, nil
+}
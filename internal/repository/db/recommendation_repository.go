@@ -282,14 +282,16 @@ func (r *RecommendationRepository) GetProductsInPriceRange(ctx context.Context,
 }
 
 // LogRecommendation logs a recommendation event
-func (r *RecommendationRepository) LogRecommendation(ctx context.Context, customerID uuid.UUID, recommendationType, contextType string, productIDs []uuid.UUID, sessionID uuid.UUID) error {
+func (r *RecommendationRepository) LogRecommendation(ctx context.Context, customerID uuid.UUID, recommendationType, contextType string, productIDs []uuid.UUID, sessionID uuid.UUID, algorithmVersion string) error {
 	// Convert UUID slice to string slice for PostgreSQL array
 	stringIDs := make([]string, len(productIDs))
 	for i, id := range productIDs {
 		stringIDs[i] = id.String()
 	}
 
+	// The session ID doubles as the log ID so clients can report interactions with it
 	log := &models.RecommendationLog{
+		ID:                  sessionID.String(),
 		CustomerID:          customerID.String(),
 		SessionID:           null.StringFrom(sessionID.String()),
 		RecommendationType:  recommendationType,
@@ -297,40 +299,41 @@ func (r *RecommendationRepository) LogRecommendation(ctx context.Context, custom
 		RecommendedProducts: stringIDs,
 		ClickedProducts:     []string{},
 		PurchasedProducts:   []string{},
-		AlgorithmVersion:    null.StringFrom("v1.0"),
+		AlgorithmVersion:    null.StringFrom(algorithmVersion),
 	}
 
 	return log.Insert(ctx, r.db, boil.Infer())
 }
 
-// LogRecommendationInteraction logs interaction analytics
+// LogRecommendationInteraction merges clicked and purchased products into an existing recommendation log.
+// Products already recorded are kept, so repeated reports never lose earlier click or purchase labels.
 func (r *RecommendationRepository) LogRecommendationInteraction(ctx context.Context, analytics *dto.RecommendationAnalytics) error {
-	// Convert UUID slices to string slices
-	recommendedProducts := make([]string, len(analytics.RecommendedProducts))
-	for i, id := range analytics.RecommendedProducts {
-		recommendedProducts[i] = id.String()
-	}
+	query := `
+		UPDATE recommendation_logs SET
+			clicked_products = ARRAY(SELECT DISTINCT unnest(COALESCE(clicked_products, '{}') || $3::uuid[])),
+			purchased_products = ARRAY(SELECT DISTINCT unnest(COALESCE(purchased_products, '{}') || $4::uuid[]))
+		WHERE id = $1 AND customer_id = $2
+	`
 
-	clickedProducts := make([]string, len(analytics.ClickedProducts))
-	for i, id := range analytics.ClickedProducts {
-		clickedProducts[i] = id.String()
+	result, err := r.db.ExecContext(ctx, query,
+		analytics.RecommendationID.String(),
+		analytics.CustomerID.String(),
+		pq.Array(uuidStrings(analytics.ClickedProducts)),
+		pq.Array(uuidStrings(analytics.PurchasedProducts)),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update recommendation log: %w", err)
 	}
 
-	purchasedProducts := make([]string, len(analytics.PurchasedProducts))
-	for i, id := range analytics.PurchasedProducts {
-		purchasedProducts[i] = id.String()
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
 	}
-
-	log := &models.RecommendationLog{
-		ID:                  analytics.RecommendationID.String(),
-		CustomerID:          analytics.CustomerID.String(),
-		RecommendedProducts: recommendedProducts,
-		ClickedProducts:     clickedProducts,
-		PurchasedProducts:   purchasedProducts,
+	if affected == 0 {
+		return fmt.Errorf("recommendation log not found: %s", analytics.RecommendationID)
 	}
 
-	_, err := log.Update(ctx, r.db, boil.Infer())
-	return err
+	return nil
 }
 
 // GetCustomersWithSimilarPurchases finds customers with similar purchase patterns
@@ -6,6 +6,7 @@ import (
 	"ec-recommend/internal/dto"
 	"ec-recommend/internal/repository/db/models"
 	"ec-recommend/internal/service"
+	"encoding/json"
 	"fmt"
 	"time"
 
@@ -143,6 +144,40 @@ func (r *RecommendationRepositoryV2) GetCustomerPurchaseHistory(ctx context.Cont
 	return purchases, nil
 }
 
+// GetGiftOnlyProductIDs returns the products the customer only ever ordered as gifts. A product also
+// ordered for themselves is left out, since the customer owns it either way.
+func (r *RecommendationRepositoryV2) GetGiftOnlyProductIDs(ctx context.Context, customerID uuid.UUID) ([]uuid.UUID, error) {
+	db := r.db.(*sql.DB)
+	rows, err := db.QueryContext(ctx, `
+		SELECT oi.product_id
+		FROM order_items oi
+		JOIN orders o ON o.id = oi.order_id
+		WHERE o.customer_id = $1
+			AND o.status = 'delivered'
+		GROUP BY oi.product_id
+		HAVING BOOL_AND(o.is_gift)
+	`, customerID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to query gift-only products: %w", err)
+	}
+	defer rows.Close()
+
+	var productIDs []uuid.UUID
+	for rows.Next() {
+		var productID uuid.UUID
+		if err := rows.Scan(&productID); err != nil {
+			return nil, fmt.Errorf("failed to scan gift-only product: %w", err)
+		}
+		productIDs = append(productIDs, productID)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate gift-only products: %w", err)
+	}
+
+	return productIDs, nil
+}
+
 // GetCustomerActivities retrieves customer's recent activities
 func (r *RecommendationRepositoryV2) GetCustomerActivities(ctx context.Context, customerID uuid.UUID, limit int) ([]dto.ActivityItem, error) {
 	activities, err := models.CustomerActivities(
@@ -289,6 +324,281 @@ func (r *RecommendationRepositoryV2) convertToProductRecommendationsV2(products
 	return recommendations
 }
 
+// LogRecommendation logs a recommendation event.
+// The session ID is used as the log ID so clients can report interactions with the session ID they received.
+func (r *RecommendationRepositoryV2) LogRecommendation(ctx context.Context, customerID uuid.UUID, recommendationType, contextType string, productIDs []uuid.UUID, sessionID uuid.UUID, algorithmVersion string) error {
+	query := `
+		INSERT INTO recommendation_logs (
+			id, customer_id, session_id, recommendation_type, context_type,
+			recommended_products, clicked_products, purchased_products, algorithm_version
+		) VALUES ($1, $2, $1, $3, $4, $5::uuid[], '{}', '{}', $6)
+	`
+
+	db := r.db.(*sql.DB)
+	_, err := db.ExecContext(ctx, query, sessionID.String(), customerID.String(), recommendationType, contextType, pq.Array(uuidStrings(productIDs)), algorithmVersion)
+	if err != nil {
+		return fmt.Errorf("failed to insert recommendation log: %w", err)
+	}
+
+	return nil
+}
+
+// LogRecommendationInteraction merges clicked and purchased products into an existing recommendation log
+func (r *RecommendationRepositoryV2) LogRecommendationInteraction(ctx context.Context, analytics *dto.RecommendationAnalytics) error {
+	query := `
+		UPDATE recommendation_logs SET
+			clicked_products = ARRAY(SELECT DISTINCT unnest(COALESCE(clicked_products, '{}') || $3::uuid[])),
+			purchased_products = ARRAY(SELECT DISTINCT unnest(COALESCE(purchased_products, '{}') || $4::uuid[]))
+		WHERE id = $1 AND customer_id = $2
+	`
+
+	db := r.db.(*sql.DB)
+	result, err := db.ExecContext(ctx, query,
+		analytics.RecommendationID.String(),
+		analytics.CustomerID.String(),
+		pq.Array(uuidStrings(analytics.ClickedProducts)),
+		pq.Array(uuidStrings(analytics.PurchasedProducts)),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update recommendation log: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("recommendation log not found: %s", analytics.RecommendationID)
+	}
+
+	return nil
+}
+
+// LogCandidateFeatures stores ranking features for the products returned by a recommendation
+func (r *RecommendationRepositoryV2) LogCandidateFeatures(ctx context.Context, recommendationID, customerID uuid.UUID, contextType string, candidates []dto.CandidateFeatures) error {
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	productIDs := make([]string, len(candidates))
+	positions := make([]int64, len(candidates))
+	features := make([]string, len(candidates))
+	for i, candidate := range candidates {
+		encoded, err := json.Marshal(candidate.Features)
+		if err != nil {
+			return fmt.Errorf("failed to marshal ranking features: %w", err)
+		}
+		productIDs[i] = candidate.ProductID.String()
+		positions[i] = int64(candidate.Position)
+		features[i] = string(encoded)
+	}
+
+	query := `
+		INSERT INTO recommendation_feature_logs (recommendation_id, customer_id, context_type, product_id, position, features)
+		SELECT $1, $2, $3, unnest($4::uuid[]), unnest($5::int[]), unnest($6::jsonb[])
+	`
+
+	db := r.db.(*sql.DB)
+	_, err := db.ExecContext(ctx, query, recommendationID.String(), customerID.String(), contextType, pq.Array(productIDs), pq.Int64Array(positions), pq.Array(features))
+	if err != nil {
+		return fmt.Errorf("failed to insert ranking features: %w", err)
+	}
+
+	return nil
+}
+
+// GetRankingTrainingExamples joins logged ranking features with the interactions recorded for them.
+// Purchases are labeled 2, clicks 1 and other impressions 0; products dismissed as
+// "not interested" in the same recommendation are labeled 0 regardless of interactions.
+func (r *RecommendationRepositoryV2) GetRankingTrainingExamples(ctx context.Context, since time.Time, limit int) ([]dto.RankingTrainingExample, error) {
+	query := `
+		SELECT
+			f.recommendation_id, f.product_id, f.features,
+			CASE
+				WHEN EXISTS (
+					SELECT 1 FROM recommendation_feedback fb
+					WHERE fb.recommendation_id = f.recommendation_id AND fb.product_id = f.product_id
+				) THEN 0
+				WHEN f.product_id = ANY(rl.purchased_products) THEN 2
+				WHEN f.product_id = ANY(rl.clicked_products) THEN 1
+				ELSE 0
+			END AS label
+		FROM recommendation_feature_logs f
+		JOIN recommendation_logs rl ON rl.id = f.recommendation_id
+		WHERE f.created_at >= $1
+		ORDER BY f.created_at
+		LIMIT $2
+	`
+
+	db := r.db.(*sql.DB)
+	rows, err := db.QueryContext(ctx, query, since, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query ranking training examples: %w", err)
+	}
+	defer rows.Close()
+
+	var examples []dto.RankingTrainingExample
+	for rows.Next() {
+		var example dto.RankingTrainingExample
+		var features []byte
+		if err := rows.Scan(&example.RecommendationID, &example.ProductID, &features, &example.Label); err != nil {
+			return nil, fmt.Errorf("failed to scan ranking training example: %w", err)
+		}
+		if err := json.Unmarshal(features, &example.Features); err != nil {
+			return nil, fmt.Errorf("failed to parse ranking features: %w", err)
+		}
+		examples = append(examples, example)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate ranking training examples: %w", err)
+	}
+
+	return examples, nil
+}
+
+// SaveRecommendationItems stores served recommendations with their scores, filters and product snapshots
+func (r *RecommendationRepositoryV2) SaveRecommendationItems(ctx context.Context, items []dto.RecommendationItem) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	db := r.db.(*sql.DB)
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO recommendation_items (
+			id, recommendation_log_id, customer_id, product_id, position, recommendation_type, context_type,
+			algorithm_version, strategies, score_breakdown, filters, product_snapshot
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare recommendation item insert: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, item := range items {
+		scoreBreakdown, err := json.Marshal(item.ScoreBreakdown)
+		if err != nil {
+			return fmt.Errorf("failed to marshal score breakdown: %w", err)
+		}
+		filters, err := json.Marshal(item.Filters)
+		if err != nil {
+			return fmt.Errorf("failed to marshal filters: %w", err)
+		}
+		product, err := json.Marshal(item.Product)
+		if err != nil {
+			return fmt.Errorf("failed to marshal product snapshot: %w", err)
+		}
+
+		_, err = stmt.ExecContext(ctx,
+			item.ID.String(), item.RecommendationLogID.String(), item.CustomerID.String(), item.ProductID.String(),
+			item.Position, item.RecommendationType, item.ContextType, item.AlgorithmVersion,
+			pq.Array(item.Strategies), string(scoreBreakdown), string(filters), string(product),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert recommendation item: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit recommendation items: %w", err)
+	}
+
+	return nil
+}
+
+const recommendationItemColumns = `id, recommendation_log_id, customer_id, product_id, position, recommendation_type,
+	context_type, algorithm_version, strategies, score_breakdown, filters, product_snapshot, created_at`
+
+// GetRecommendationItem returns a customer's served recommendation, or nil if it does not exist
+func (r *RecommendationRepositoryV2) GetRecommendationItem(ctx context.Context, itemID, customerID uuid.UUID) (*dto.RecommendationItem, error) {
+	db := r.db.(*sql.DB)
+	item, err := scanRecommendationItem(db.QueryRowContext(ctx, `
+		SELECT `+recommendationItemColumns+`
+		FROM recommendation_items
+		WHERE id = $1 AND customer_id = $2
+	`, itemID.String(), customerID.String()))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get recommendation item: %w", err)
+	}
+
+	return item, nil
+}
+
+// GetRecommendationItemsByLog returns the recommendations served in one response, in position order
+func (r *RecommendationRepositoryV2) GetRecommendationItemsByLog(ctx context.Context, recommendationLogID uuid.UUID) ([]dto.RecommendationItem, error) {
+	db := r.db.(*sql.DB)
+	rows, err := db.QueryContext(ctx, `
+		SELECT `+recommendationItemColumns+`
+		FROM recommendation_items
+		WHERE recommendation_log_id = $1
+		ORDER BY position
+	`, recommendationLogID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to query recommendation items: %w", err)
+	}
+	defer rows.Close()
+
+	var items []dto.RecommendationItem
+	for rows.Next() {
+		item, err := scanRecommendationItem(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan recommendation item: %w", err)
+		}
+		items = append(items, *item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate recommendation items: %w", err)
+	}
+
+	return items, nil
+}
+
+func scanRecommendationItem(row rowScanner) (*dto.RecommendationItem, error) {
+	var item dto.RecommendationItem
+	var strategies pq.StringArray
+	var scoreBreakdown, filters, product []byte
+	err := row.Scan(
+		&item.ID, &item.RecommendationLogID, &item.CustomerID, &item.ProductID, &item.Position, &item.RecommendationType,
+		&item.ContextType, &item.AlgorithmVersion, &strategies, &scoreBreakdown, &filters, &product, &item.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	item.Strategies = strategies
+	if err := json.Unmarshal(scoreBreakdown, &item.ScoreBreakdown); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal score breakdown: %w", err)
+	}
+	if err := json.Unmarshal(filters, &item.Filters); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal filters: %w", err)
+	}
+	if err := json.Unmarshal(product, &item.Product); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal product snapshot: %w", err)
+	}
+
+	return &item, nil
+}
+
+// uuidStrings converts UUIDs to strings for PostgreSQL array parameters
+func uuidStrings(ids []uuid.UUID) []string {
+	result := make([]string, len(ids))
+	for i, id := range ids {
+		result[i] = id.String()
+	}
+
+	return result
+}
+
 // ==== TODO: Below methods are placeholder implementations ====
 
 // GetTrendingProductsV2 - TODO: Implement enhanced trending products analysis
@@ -309,18 +619,6 @@ func (r *RecommendationRepositoryV2) GetMarketAnalysis(ctx context.Context, cate
 	return nil, fmt.Errorf("market analysis not implemented yet")
 }
 
-// LogRecommendation - TODO: Implement enhanced recommendation logging
-func (r *RecommendationRepositoryV2) LogRecommendation(ctx context.Context, customerID uuid.UUID, recommendationType, contextType string, productIDs []uuid.UUID, sessionID uuid.UUID) error {
-	// TODO: Implement enhanced recommendation logging with session tracking
-	return fmt.Errorf("recommendation logging not implemented yet")
-}
-
-// LogRecommendationInteraction - TODO: Implement recommendation interaction analytics
-func (r *RecommendationRepositoryV2) LogRecommendationInteraction(ctx context.Context, analytics *dto.RecommendationAnalytics) error {
-	// TODO: Implement interaction analytics logging
-	return fmt.Errorf("recommendation interaction logging not implemented yet")
-}
-
 // LogSemanticSearch - TODO: Implement semantic search logging
 func (r *RecommendationRepositoryV2) LogSemanticSearch(ctx context.Context, customerID *uuid.UUID, query string, results []uuid.UUID, processingTimeMs int64) error {
 	// TODO: Implement semantic search result logging
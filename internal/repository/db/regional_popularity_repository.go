@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"ec-recommend/internal/dto"
+	"ec-recommend/internal/service"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RegionalPopularityRepository implements the RegionalPopularityRepositoryInterface
+type RegionalPopularityRepository struct {
+	db *sql.DB
+}
+
+// NewRegionalPopularityRepository creates a new regional popularity repository instance
+func NewRegionalPopularityRepository(db *sql.DB) service.RegionalPopularityRepositoryInterface {
+	return &RegionalPopularityRepository{
+		db: db,
+	}
+}
+
+// GetCustomerLocation returns the customer's prefecture from customers.location, falling back to
+// the most recent shipping address; nil when neither is known
+func (r *RegionalPopularityRepository) GetCustomerLocation(ctx context.Context, customerID uuid.UUID) (*dto.CustomerLocation, error) {
+	query := `
+		SELECT
+			NULLIF(BTRIM(c.location->>'prefecture'), ''),
+			c.location->>'city',
+			NULLIF(BTRIM(so.shipping_address->>'prefecture'), ''),
+			so.shipping_address->>'city'
+		FROM customers c
+		LEFT JOIN LATERAL (
+			SELECT o.shipping_address
+			FROM orders o
+			WHERE o.customer_id = c.id
+				AND o.shipping_address ? 'prefecture'
+			ORDER BY o.ordered_at DESC
+			LIMIT 1
+		) so ON true
+		WHERE c.id = $1
+	`
+
+	var profilePrefecture, profileCity, shippingPrefecture, shippingCity sql.NullString
+	err := r.db.QueryRowContext(ctx, query, customerID.String()).Scan(&profilePrefecture, &profileCity, &shippingPrefecture, &shippingCity)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query customer location: %w", err)
+	}
+
+	switch {
+	case profilePrefecture.Valid:
+		return &dto.CustomerLocation{Prefecture: profilePrefecture.String, City: profileCity.String, Source: dto.LocationSourceProfile}, nil
+	case shippingPrefecture.Valid:
+		return &dto.CustomerLocation{Prefecture: shippingPrefecture.String, City: shippingCity.String, Source: dto.LocationSourceShippingAddress}, nil
+	default:
+		return nil, nil
+	}
+}
+
+// GetRegionalDemand aggregates units ordered per prefecture and product since the given time.
+// An order is attributed to the customer's profile prefecture, or to its shipping address when the profile has none.
+func (r *RegionalPopularityRepository) GetRegionalDemand(ctx context.Context, since time.Time) ([]dto.RegionalDemand, error) {
+	query := `
+		SELECT region.prefecture, oi.product_id, p.category_id, SUM(oi.quantity), COUNT(DISTINCT o.customer_id)
+		FROM order_items oi
+		JOIN orders o ON o.id = oi.order_id
+		JOIN customers c ON c.id = o.customer_id
+		JOIN products p ON p.id = oi.product_id
+		CROSS JOIN LATERAL (
+			SELECT COALESCE(
+				NULLIF(BTRIM(c.location->>'prefecture'), ''),
+				NULLIF(BTRIM(o.shipping_address->>'prefecture'), '')
+			) AS prefecture
+		) region
+		WHERE o.ordered_at >= $1
+			AND o.status NOT IN ('cancelled', 'returned')
+			AND region.prefecture IS NOT NULL
+			AND p.is_active = true
+		GROUP BY region.prefecture, oi.product_id, p.category_id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query regional demand: %w", err)
+	}
+	defer rows.Close()
+
+	var demand []dto.RegionalDemand
+	for rows.Next() {
+		var d dto.RegionalDemand
+		if err := rows.Scan(&d.Prefecture, &d.ProductID, &d.CategoryID, &d.Units, &d.Customers); err != nil {
+			return nil, fmt.Errorf("failed to scan regional demand: %w", err)
+		}
+		demand = append(demand, d)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate regional demand: %w", err)
+	}
+
+	return demand, nil
+}
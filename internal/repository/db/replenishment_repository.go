@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"ec-recommend/internal/dto"
+	"ec-recommend/internal/service"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// ReplenishmentRepository implements the ReplenishmentRepositoryInterface
+type ReplenishmentRepository struct {
+	db *sql.DB
+}
+
+// NewReplenishmentRepository creates a new replenishment repository instance
+func NewReplenishmentRepository(db *sql.DB) service.ReplenishmentRepositoryInterface {
+	return &ReplenishmentRepository{
+		db: db,
+	}
+}
+
+// consumablePurchasesQuery aggregates order items of consumable categories per order and product.
+// $1 is the start date and $2 the category names; the parent category name also matches.
+const consumablePurchasesQuery = `
+	SELECT
+		o.customer_id,
+		oi.product_id,
+		p.name,
+		p.category_id,
+		c.name,
+		COALESCE(p.brand, ''),
+		p.price,
+		SUM(oi.quantity)::int AS quantity,
+		o.ordered_at
+	FROM order_items oi
+	JOIN orders o ON o.id = oi.order_id
+	JOIN products p ON p.id = oi.product_id
+	JOIN categories c ON c.id = p.category_id
+	LEFT JOIN categories pc ON pc.id = c.parent_id
+	WHERE o.status NOT IN ('cancelled', 'returned')
+		AND o.ordered_at >= $1
+		AND (c.name = ANY($2) OR pc.name = ANY($2))
+`
+
+// GetConsumablePurchases returns purchases in the given categories, ordered by customer and order date
+func (r *ReplenishmentRepository) GetConsumablePurchases(ctx context.Context, customerID *uuid.UUID, categoryNames []string, since time.Time) ([]dto.PurchaseEvent, error) {
+	query := consumablePurchasesQuery
+	args := []interface{}{since, pq.Array(categoryNames)}
+	if customerID != nil {
+		query += " AND o.customer_id = $3"
+		args = append(args, customerID.String())
+	}
+	query += `
+		GROUP BY o.id, o.customer_id, oi.product_id, p.name, p.category_id, c.name, p.brand, p.price, o.ordered_at
+		ORDER BY o.customer_id, o.ordered_at
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query consumable purchases: %w", err)
+	}
+	defer rows.Close()
+
+	var events []dto.PurchaseEvent
+	for rows.Next() {
+		var e dto.PurchaseEvent
+		if err := rows.Scan(&e.CustomerID, &e.ProductID, &e.ProductName, &e.CategoryID, &e.CategoryName,
+			&e.Brand, &e.Price, &e.Quantity, &e.OrderedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan consumable purchase: %w", err)
+		}
+		events = append(events, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate consumable purchases: %w", err)
+	}
+
+	return events, nil
+}
+
+// GetRepurchaseIntervalPriors returns the median per-unit repurchase interval of each consumable product
+func (r *ReplenishmentRepository) GetRepurchaseIntervalPriors(ctx context.Context, categoryNames []string, since time.Time) ([]dto.RepurchaseIntervalPrior, error) {
+	query := `
+		WITH purchases AS (` + consumablePurchasesQuery + `
+			GROUP BY o.id, o.customer_id, oi.product_id, p.name, p.category_id, c.name, p.brand, p.price, o.ordered_at
+		), gaps AS (
+			SELECT
+				product_id,
+				category_id,
+				EXTRACT(EPOCH FROM ordered_at - LAG(ordered_at) OVER w) / 86400.0 AS gap_days,
+				LAG(quantity) OVER w AS previous_quantity
+			FROM purchases
+			WINDOW w AS (PARTITION BY customer_id, product_id ORDER BY ordered_at)
+		)
+		SELECT product_id, category_id, percentile_cont(0.5) WITHIN GROUP (ORDER BY gap_days / previous_quantity), COUNT(*)
+		FROM gaps
+		WHERE gap_days >= 1 -- purchases on the same day are one stock-up
+			AND previous_quantity > 0
+		GROUP BY product_id, category_id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, since, pq.Array(categoryNames))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query repurchase interval priors: %w", err)
+	}
+	defer rows.Close()
+
+	var priors []dto.RepurchaseIntervalPrior
+	for rows.Next() {
+		var p dto.RepurchaseIntervalPrior
+		if err := rows.Scan(&p.ProductID, &p.CategoryID, &p.IntervalDays, &p.Samples); err != nil {
+			return nil, fmt.Errorf("failed to scan repurchase interval prior: %w", err)
+		}
+		priors = append(priors, p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate repurchase interval priors: %w", err)
+	}
+
+	return priors, nil
+}
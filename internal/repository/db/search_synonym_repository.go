@@ -0,0 +1,195 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"ec-recommend/internal/dto"
+	"ec-recommend/internal/service"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// SearchSynonymRepository implements the SearchSynonymRepositoryInterface
+type SearchSynonymRepository struct {
+	db *sql.DB
+}
+
+// NewSearchSynonymRepository creates a new search synonym repository instance
+func NewSearchSynonymRepository(db *sql.DB) service.SearchSynonymRepositoryInterface {
+	return &SearchSynonymRepository{
+		db: db,
+	}
+}
+
+const searchSynonymColumns = `id, term, normalized_term, replacement, synonym_type, is_active, created_at, updated_at`
+
+// ListSearchSynonyms returns all dictionary entries ordered by term
+func (r *SearchSynonymRepository) ListSearchSynonyms(ctx context.Context) ([]dto.SearchSynonym, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+searchSynonymColumns+` FROM search_synonyms ORDER BY normalized_term`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query search synonyms: %w", err)
+	}
+	defer rows.Close()
+
+	synonyms := []dto.SearchSynonym{}
+	for rows.Next() {
+		synonym, err := scanSearchSynonym(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan search synonym: %w", err)
+		}
+		synonyms = append(synonyms, *synonym)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate search synonyms: %w", err)
+	}
+
+	return synonyms, nil
+}
+
+// GetSearchSynonym returns an entry by ID, or nil if it does not exist
+func (r *SearchSynonymRepository) GetSearchSynonym(ctx context.Context, synonymID uuid.UUID) (*dto.SearchSynonym, error) {
+	query := `SELECT ` + searchSynonymColumns + ` FROM search_synonyms WHERE id = $1`
+
+	synonym, err := scanSearchSynonym(r.db.QueryRowContext(ctx, query, synonymID.String()))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get search synonym: %w", err)
+	}
+
+	return synonym, nil
+}
+
+// CreateSearchSynonym stores a new entry and returns it with generated fields populated
+func (r *SearchSynonymRepository) CreateSearchSynonym(ctx context.Context, synonym *dto.SearchSynonym) (*dto.SearchSynonym, error) {
+	query := `
+		INSERT INTO search_synonyms (term, normalized_term, replacement, synonym_type, is_active)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING ` + searchSynonymColumns
+
+	created, err := scanSearchSynonym(r.db.QueryRowContext(ctx, query,
+		synonym.Term, synonym.NormalizedTerm, synonym.Replacement, synonym.Type, synonym.IsActive))
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, dto.ErrSearchSynonymExists
+		}
+		return nil, fmt.Errorf("failed to create search synonym: %w", err)
+	}
+
+	return created, nil
+}
+
+// UpdateSearchSynonym replaces an existing entry, returning nil if it does not exist
+func (r *SearchSynonymRepository) UpdateSearchSynonym(ctx context.Context, synonym *dto.SearchSynonym) (*dto.SearchSynonym, error) {
+	query := `
+		UPDATE search_synonyms SET
+			term = $1, normalized_term = $2, replacement = $3, synonym_type = $4, is_active = $5
+		WHERE id = $6
+		RETURNING ` + searchSynonymColumns
+
+	updated, err := scanSearchSynonym(r.db.QueryRowContext(ctx, query,
+		synonym.Term, synonym.NormalizedTerm, synonym.Replacement, synonym.Type, synonym.IsActive, synonym.ID.String()))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		if isUniqueViolation(err) {
+			return nil, dto.ErrSearchSynonymExists
+		}
+		return nil, fmt.Errorf("failed to update search synonym: %w", err)
+	}
+
+	return updated, nil
+}
+
+// DeleteSearchSynonym removes an entry, reporting whether it existed
+func (r *SearchSynonymRepository) DeleteSearchSynonym(ctx context.Context, synonymID uuid.UUID) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM search_synonyms WHERE id = $1`, synonymID.String())
+	if err != nil {
+		return false, fmt.Errorf("failed to delete search synonym: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	return affected > 0, nil
+}
+
+// GetSearchVocabulary returns catalog text weighted by popularity. Every product counts at least
+// once so new products are still spelled correctly.
+func (r *SearchSynonymRepository) GetSearchVocabulary(ctx context.Context) ([]dto.VocabularyEntry, error) {
+	query := `
+		WITH active AS (
+			SELECT name, brand, tags, category_id, GREATEST(COALESCE(popularity_score, 0), 1)::float8 AS weight
+			FROM products
+			WHERE is_active = true
+		)
+		SELECT name, weight FROM active
+		UNION ALL
+		SELECT brand, SUM(weight) FROM active WHERE BTRIM(COALESCE(brand, '')) <> '' GROUP BY brand
+		UNION ALL
+		SELECT tag, SUM(weight) FROM active CROSS JOIN LATERAL UNNEST(tags) AS tag GROUP BY tag
+		UNION ALL
+		SELECT c.description, COALESCE(SUM(a.weight), 1)
+		FROM categories c
+		LEFT JOIN active a ON a.category_id = c.id
+		WHERE BTRIM(COALESCE(c.description, '')) <> ''
+		GROUP BY c.id, c.description
+	`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query search vocabulary: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []dto.VocabularyEntry
+	for rows.Next() {
+		var e dto.VocabularyEntry
+		if err := rows.Scan(&e.Text, &e.Weight); err != nil {
+			return nil, fmt.Errorf("failed to scan search vocabulary: %w", err)
+		}
+		entries = append(entries, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate search vocabulary: %w", err)
+	}
+
+	return entries, nil
+}
+
+func scanSearchSynonym(row rowScanner) (*dto.SearchSynonym, error) {
+	var synonym dto.SearchSynonym
+	var isActive sql.NullBool
+
+	err := row.Scan(
+		&synonym.ID,
+		&synonym.Term,
+		&synonym.NormalizedTerm,
+		&synonym.Replacement,
+		&synonym.Type,
+		&isActive,
+		&synonym.CreatedAt,
+		&synonym.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	synonym.IsActive = isActive.Valid && isActive.Bool
+	return &synonym, nil
+}
+
+// isUniqueViolation reports whether err is a Postgres unique constraint violation
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"ec-recommend/internal/dto"
+	"ec-recommend/internal/service"
+	"fmt"
+	"time"
+)
+
+// SeasonalityRepository implements the SeasonalityRepositoryInterface
+type SeasonalityRepository struct {
+	db *sql.DB
+}
+
+// NewSeasonalityRepository creates a new seasonality repository instance
+func NewSeasonalityRepository(db *sql.DB) service.SeasonalityRepositoryInterface {
+	return &SeasonalityRepository{
+		db: db,
+	}
+}
+
+// GetWeeklyDemand returns units sold per year and week for every category and product tag since the given time.
+// Order dates are bucketed in Japan time so that events such as お中元 and golden week fall in the right week.
+// Days after the 52nd full week (December 31, and December 30 in leap years) wrap into week 1 of the next year,
+// matching the service's week bucketing.
+func (r *SeasonalityRepository) GetWeeklyDemand(ctx context.Context, since time.Time) ([]dto.WeeklyDemand, error) {
+	query := `
+		WITH dated AS (
+			SELECT
+				EXTRACT(YEAR FROM o.ordered_at AT TIME ZONE 'Asia/Tokyo')::int AS year,
+				EXTRACT(DOY FROM o.ordered_at AT TIME ZONE 'Asia/Tokyo')::int AS doy,
+				p.category_id,
+				c.name AS category_name,
+				p.tags,
+				oi.quantity
+			FROM order_items oi
+			JOIN orders o ON o.id = oi.order_id
+			JOIN products p ON p.id = oi.product_id
+			JOIN categories c ON c.id = p.category_id
+			WHERE o.ordered_at >= $1
+				AND o.status NOT IN ('cancelled', 'returned')
+		),
+		sold AS (
+			SELECT
+				CASE WHEN doy > 364 THEN year + 1 ELSE year END AS year,
+				CASE WHEN doy > 364 THEN 1 ELSE (doy - 1) / 7 + 1 END AS week,
+				category_id,
+				category_name,
+				tags,
+				quantity
+			FROM dated
+		)
+		SELECT 'category', category_name, category_id, year, week, SUM(quantity)
+		FROM sold
+		GROUP BY category_name, category_id, year, week
+		UNION ALL
+		SELECT 'tag', tag, 0, year, week, SUM(quantity)
+		FROM sold, UNNEST(tags) AS tag
+		GROUP BY tag, year, week
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query weekly demand: %w", err)
+	}
+	defer rows.Close()
+
+	var demand []dto.WeeklyDemand
+	for rows.Next() {
+		var d dto.WeeklyDemand
+		if err := rows.Scan(&d.KeyType, &d.Key, &d.CategoryID, &d.Year, &d.Week, &d.Units); err != nil {
+			return nil, fmt.Errorf("failed to scan weekly demand: %w", err)
+		}
+		demand = append(demand, d)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate weekly demand: %w", err)
+	}
+
+	return demand, nil
+}
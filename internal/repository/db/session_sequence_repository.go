@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"ec-recommend/internal/dto"
+	"ec-recommend/internal/service"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SessionSequenceRepository implements the SessionSequenceRepositoryInterface
+type SessionSequenceRepository struct {
+	db *sql.DB
+}
+
+// NewSessionSequenceRepository creates a new session sequence repository instance
+func NewSessionSequenceRepository(db *sql.DB) service.SessionSequenceRepositoryInterface {
+	return &SessionSequenceRepository{
+		db: db,
+	}
+}
+
+// sessionActivityTypes are the product activities that form a browsing sequence
+const sessionActivityTypes = `('view', 'add_to_cart', 'wishlist_add')`
+
+// GetSessionTransitions aggregates consecutive product activities within sessions since the given time
+func (r *SessionSequenceRepository) GetSessionTransitions(ctx context.Context, since time.Time) ([]dto.ProductTransition, error) {
+	query := `
+		WITH ordered AS (
+			SELECT
+				ca.product_id,
+				LEAD(ca.product_id) OVER (PARTITION BY ca.session_id ORDER BY ca.created_at, ca.id) AS next_product_id
+			FROM customer_activities ca
+			WHERE ca.session_id IS NOT NULL
+				AND ca.product_id IS NOT NULL
+				AND ca.activity_type IN ` + sessionActivityTypes + `
+				AND ca.created_at >= $1
+		)
+		SELECT o.product_id, o.next_product_id, p.category_id, np.category_id, COUNT(*)
+		FROM ordered o
+		JOIN products p ON p.id = o.product_id
+		JOIN products np ON np.id = o.next_product_id
+		WHERE o.next_product_id IS NOT NULL
+			AND o.next_product_id <> o.product_id
+			AND np.is_active = true
+		GROUP BY o.product_id, o.next_product_id, p.category_id, np.category_id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query session transitions: %w", err)
+	}
+	defer rows.Close()
+
+	var transitions []dto.ProductTransition
+	for rows.Next() {
+		var t dto.ProductTransition
+		if err := rows.Scan(&t.FromProductID, &t.ToProductID, &t.FromCategoryID, &t.ToCategoryID, &t.Count); err != nil {
+			return nil, fmt.Errorf("failed to scan session transition: %w", err)
+		}
+		transitions = append(transitions, t)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate session transitions: %w", err)
+	}
+
+	return transitions, nil
+}
+
+// GetSessionProducts returns the most recent products of a customer's session, oldest first
+func (r *SessionSequenceRepository) GetSessionProducts(ctx context.Context, customerID, sessionID uuid.UUID, limit int) ([]uuid.UUID, error) {
+	query := `
+		SELECT product_id FROM (
+			SELECT product_id, created_at, id
+			FROM customer_activities
+			WHERE customer_id = $1
+				AND session_id = $2
+				AND product_id IS NOT NULL
+				AND activity_type IN ` + sessionActivityTypes + `
+			ORDER BY created_at DESC, id DESC
+			LIMIT $3
+		) recent
+		ORDER BY created_at, id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, customerID.String(), sessionID.String(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query session products: %w", err)
+	}
+	defer rows.Close()
+
+	var productIDs []uuid.UUID
+	for rows.Next() {
+		var productID uuid.UUID
+		if err := rows.Scan(&productID); err != nil {
+			return nil, fmt.Errorf("failed to scan session product: %w", err)
+		}
+		productIDs = append(productIDs, productID)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate session products: %w", err)
+	}
+
+	return productIDs, nil
+}
@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"ec-recommend/internal/dto"
+	"ec-recommend/internal/service"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// StrategyBanditRepository implements the StrategyBanditRepositoryInterface
+type StrategyBanditRepository struct {
+	db *sql.DB
+}
+
+// NewStrategyBanditRepository creates a new strategy bandit repository instance
+func NewStrategyBanditRepository(db *sql.DB) service.StrategyBanditRepositoryInterface {
+	return &StrategyBanditRepository{
+		db: db,
+	}
+}
+
+// GetBanditArmStats returns the accumulated rewards of all arms for a service and context
+func (r *StrategyBanditRepository) GetBanditArmStats(ctx context.Context, serviceName, contextType string) ([]dto.BanditArmStats, error) {
+	query := `
+		SELECT service, context_type, arm, successes, failures, pulls
+		FROM strategy_bandit_arms
+		WHERE service = $1 AND context_type = $2
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, serviceName, contextType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query bandit arms: %w", err)
+	}
+	defer rows.Close()
+
+	var stats []dto.BanditArmStats
+	for rows.Next() {
+		var s dto.BanditArmStats
+		if err := rows.Scan(&s.Service, &s.ContextType, &s.Arm, &s.Successes, &s.Failures, &s.Pulls); err != nil {
+			return nil, fmt.Errorf("failed to scan bandit arm: %w", err)
+		}
+		stats = append(stats, s)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate bandit arms: %w", err)
+	}
+
+	return stats, nil
+}
+
+// RecordBanditDecision stores a decision and counts it as an unrewarded pull of its arm
+func (r *StrategyBanditRepository) RecordBanditDecision(ctx context.Context, decision *dto.BanditDecision) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO strategy_bandit_decisions (recommendation_id, customer_id, service, context_type, arm, propensity)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, decision.RecommendationID.String(), decision.CustomerID.String(), decision.Service, decision.ContextType, decision.Arm, decision.Propensity)
+	if err != nil {
+		return fmt.Errorf("failed to insert bandit decision: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO strategy_bandit_arms (service, context_type, arm, successes, failures, pulls)
+		VALUES ($1, $2, $3, 0, 1, 1)
+		ON CONFLICT (service, context_type, arm) DO UPDATE SET
+			failures = strategy_bandit_arms.failures + 1,
+			pulls = strategy_bandit_arms.pulls + 1,
+			updated_at = CURRENT_TIMESTAMP
+	`, decision.Service, decision.ContextType, decision.Arm)
+	if err != nil {
+		return fmt.Errorf("failed to update bandit arm: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit bandit decision: %w", err)
+	}
+
+	return nil
+}
+
+// ApplyBanditReward raises the reward of a decision and moves the difference from failures to successes.
+// Repeated interactions for the same recommendation only count the highest reward once.
+func (r *StrategyBanditRepository) ApplyBanditReward(ctx context.Context, recommendationID uuid.UUID, reward float64) (bool, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var serviceName, contextType, arm string
+	var current float64
+	err = tx.QueryRowContext(ctx, `
+		SELECT service, context_type, arm, reward
+		FROM strategy_bandit_decisions
+		WHERE recommendation_id = $1
+		FOR UPDATE
+	`, recommendationID.String()).Scan(&serviceName, &contextType, &arm, &current)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to get bandit decision: %w", err)
+	}
+
+	if reward <= current {
+		return true, nil
+	}
+	delta := reward - current
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE strategy_bandit_decisions SET reward = $2, rewarded_at = CURRENT_TIMESTAMP
+		WHERE recommendation_id = $1
+	`, recommendationID.String(), reward)
+	if err != nil {
+		return false, fmt.Errorf("failed to update bandit decision: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE strategy_bandit_arms SET
+			successes = successes + $4,
+			failures = GREATEST(failures - $4, 0),
+			updated_at = CURRENT_TIMESTAMP
+		WHERE service = $1 AND context_type = $2 AND arm = $3
+	`, serviceName, contextType, arm, delta)
+	if err != nil {
+		return false, fmt.Errorf("failed to update bandit arm: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit bandit reward: %w", err)
+	}
+
+	return true, nil
+}
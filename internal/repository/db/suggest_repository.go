@@ -0,0 +1,177 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"ec-recommend/internal/dto"
+	"ec-recommend/internal/service"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// SuggestRepository implements the SuggestRepositoryInterface
+type SuggestRepository struct {
+	db *sql.DB
+}
+
+// NewSuggestRepository creates a new suggest repository instance
+func NewSuggestRepository(db *sql.DB) service.SuggestRepositoryInterface {
+	return &SuggestRepository{
+		db: db,
+	}
+}
+
+// GetSuggestTerms returns frequent search queries and the names of products, brands and categories.
+// A query's popularity is the number of distinct customers who searched it, so repeated searches by
+// one customer neither publish nor promote it. Queries and brands are grouped case-insensitively;
+// a category is named by its Japanese description with the English name as an alias, and its
+// popularity includes the products of its subcategories.
+func (r *SuggestRepository) GetSuggestTerms(ctx context.Context, querySince time.Time, minQueryCount int) ([]dto.SuggestTerm, error) {
+	query := `
+		SELECT MIN(BTRIM(a.search_query)), 'query', ARRAY[]::text[], NULL::uuid, ''::text, NULL::int, ARRAY[]::int[],
+			COUNT(DISTINCT a.customer_id)::float8
+		FROM customer_activities a
+		WHERE a.activity_type = 'search'
+			AND a.created_at >= $1
+			AND BTRIM(COALESCE(a.search_query, '')) <> ''
+		GROUP BY LOWER(BTRIM(a.search_query))
+		HAVING COUNT(DISTINCT a.customer_id) >= $2
+
+		UNION ALL
+
+		SELECT p.name, 'product', ARRAY[]::text[], p.id, COALESCE(BTRIM(p.brand), ''), p.category_id,
+			ARRAY_REMOVE(ARRAY[p.category_id, c.parent_id], NULL), COALESCE(p.popularity_score, 0)::float8
+		FROM products p
+		JOIN categories c ON c.id = p.category_id
+		WHERE p.is_active = true
+
+		UNION ALL
+
+		SELECT MIN(BTRIM(p.brand)), 'brand', ARRAY[]::text[], NULL::uuid, MIN(BTRIM(p.brand)), NULL::int,
+			ARRAY_AGG(DISTINCT p.category_id), SUM(COALESCE(p.popularity_score, 0))::float8
+		FROM products p
+		WHERE p.is_active = true
+			AND BTRIM(COALESCE(p.brand, '')) <> ''
+		GROUP BY LOWER(BTRIM(p.brand))
+
+		UNION ALL
+
+		SELECT COALESCE(NULLIF(BTRIM(c.description), ''), c.name), 'category', ARRAY[REPLACE(c.name, '_', ' ')],
+			NULL::uuid, ''::text, c.id, ARRAY_REMOVE(ARRAY[c.id, c.parent_id], NULL),
+			COALESCE((
+				SELECT SUM(COALESCE(p.popularity_score, 0))
+				FROM products p
+				JOIN categories pc ON pc.id = p.category_id
+				WHERE p.is_active = true
+					AND (pc.id = c.id OR pc.parent_id = c.id)
+			), 0)::float8
+		FROM categories c
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, querySince, minQueryCount)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query suggest terms: %w", err)
+	}
+	defer rows.Close()
+
+	var terms []dto.SuggestTerm
+	for rows.Next() {
+		var t dto.SuggestTerm
+		var aliases pq.StringArray
+		var productID uuid.NullUUID
+		var categoryID sql.NullInt64
+		var categoryIDs pq.Int64Array
+		if err := rows.Scan(&t.Text, &t.Type, &aliases, &productID, &t.Brand, &categoryID, &categoryIDs, &t.Popularity); err != nil {
+			return nil, fmt.Errorf("failed to scan suggest term: %w", err)
+		}
+
+		t.Aliases = aliases
+		if productID.Valid {
+			id := productID.UUID
+			t.ProductID = &id
+		}
+		if categoryID.Valid {
+			id := int(categoryID.Int64)
+			t.CategoryID = &id
+		}
+		for _, id := range categoryIDs {
+			t.CategoryIDs = append(t.CategoryIDs, int(id))
+		}
+		terms = append(terms, t)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate suggest terms: %w", err)
+	}
+
+	return terms, nil
+}
+
+// GetCategoryInteractions returns a customer's purchases, cart additions and views per category since the given time
+func (r *SuggestRepository) GetCategoryInteractions(ctx context.Context, customerID uuid.UUID, since time.Time) ([]dto.CategoryInteraction, error) {
+	query := `
+		WITH signals AS (
+			SELECT p.category_id, SUM(oi.quantity)::int AS purchases, 0 AS cart_adds, 0 AS views
+			FROM order_items oi
+			JOIN orders o ON o.id = oi.order_id
+			JOIN products p ON p.id = oi.product_id
+			WHERE o.customer_id = $1
+				AND o.status NOT IN ('cancelled', 'returned')
+				AND o.ordered_at >= $2
+			GROUP BY p.category_id
+
+			UNION ALL
+
+			SELECT p.category_id, 0,
+				COUNT(*) FILTER (WHERE a.activity_type IN ('add_to_cart', 'wishlist_add'))::int,
+				COUNT(*) FILTER (WHERE a.activity_type = 'view')::int
+			FROM customer_activities a
+			JOIN products p ON p.id = a.product_id
+			WHERE a.customer_id = $1
+				AND a.created_at >= $2
+			GROUP BY p.category_id
+		), preferred AS (
+			SELECT UNNEST(COALESCE(preferred_categories, ARRAY[]::int[])) AS category_id
+			FROM customers
+			WHERE id = $1
+		)
+		SELECT c.id, c.parent_id,
+			COALESCE(SUM(s.purchases), 0)::int,
+			COALESCE(SUM(s.cart_adds), 0)::int,
+			COALESCE(SUM(s.views), 0)::int,
+			c.id IN (SELECT category_id FROM preferred)
+		FROM categories c
+		LEFT JOIN signals s ON s.category_id = c.id
+		WHERE c.id IN (SELECT category_id FROM signals UNION SELECT category_id FROM preferred)
+		GROUP BY c.id, c.parent_id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, customerID.String(), since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query category interactions: %w", err)
+	}
+	defer rows.Close()
+
+	var interactions []dto.CategoryInteraction
+	for rows.Next() {
+		var i dto.CategoryInteraction
+		var parentID sql.NullInt64
+		if err := rows.Scan(&i.CategoryID, &parentID, &i.Purchases, &i.CartAdds, &i.Views, &i.Preferred); err != nil {
+			return nil, fmt.Errorf("failed to scan category interaction: %w", err)
+		}
+		if parentID.Valid {
+			id := int(parentID.Int64)
+			i.ParentID = &id
+		}
+		interactions = append(interactions, i)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate category interactions: %w", err)
+	}
+
+	return interactions, nil
+}
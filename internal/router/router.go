@@ -13,6 +13,13 @@ func SetupRouter(
 	healthHandler *handler.HealthHandler,
 	recommendationHandler *handler.RecommendationHandler,
 	recommendationHandlerV2 *handler.RecommendationHandlerV2,
+	merchandisingHandler *handler.MerchandisingHandler,
+	experimentHandler *handler.ExperimentHandler,
+	replenishmentHandler *handler.ReplenishmentHandler,
+	bundleHandler *handler.BundleHandler,
+	feedbackHandler *handler.FeedbackHandler,
+	suggestHandler *handler.SuggestHandler,
+	searchSynonymHandler *handler.SearchSynonymHandler,
 ) *gin.Engine {
 	// Set Gin mode based on environment
 	gin.SetMode(gin.ReleaseMode)
@@ -70,6 +77,11 @@ func SetupRouter(
 			recommendations.GET("/semantic-search", recommendationHandlerV2.GetSemanticSearch)
 			recommendations.GET("/vector-similar/:product_id", recommendationHandlerV2.GetVectorSimilarProducts)
 			recommendations.GET("/knowledge-based", recommendationHandlerV2.GetKnowledgeBasedRecommendations)
+			recommendations.POST("/bundle", bundleHandler.BuildBundle)
+			recommendations.POST("/gift", recommendationHandlerV2.GetGiftRecommendations)
+			recommendations.POST("/interactions", recommendationHandlerV2.LogRecommendationInteraction)
+			recommendations.POST("/feedback", feedbackHandler.RecordFeedback)
+			recommendations.DELETE("/feedback/:feedback_id", feedbackHandler.DeleteFeedback)
 			recommendations.GET("/:recommendation_id/explanation", recommendationHandlerV2.GetRecommendationExplanation)
 		}
 
@@ -78,6 +90,41 @@ func SetupRouter(
 		{
 			products.GET("/trending", recommendationHandlerV2.GetTrendingProductsV2)
 		}
+
+		// Search box endpoints
+		search := v2.Group("/search")
+		{
+			search.GET("/suggest", suggestHandler.GetSuggestions)
+		}
+
+		// Customer endpoints
+		customers := v2.Group("/customers")
+		{
+			customers.GET("/:customer_id/replenishment", replenishmentHandler.GetReplenishment)
+		}
+
+		// A/B experiment endpoints
+		experiments := v2.Group("/experiments")
+		{
+			experiments.GET("", experimentHandler.ListExperiments)
+			experiments.GET("/:id/results", experimentHandler.GetExperimentResults)
+		}
+
+		// Admin endpoints
+		admin := v2.Group("/admin")
+		{
+			admin.GET("/merchandising-rules", merchandisingHandler.ListRules)
+			admin.POST("/merchandising-rules", merchandisingHandler.CreateRule)
+			admin.GET("/merchandising-rules/:rule_id", merchandisingHandler.GetRule)
+			admin.PUT("/merchandising-rules/:rule_id", merchandisingHandler.UpdateRule)
+			admin.DELETE("/merchandising-rules/:rule_id", merchandisingHandler.DeleteRule)
+			admin.GET("/search-synonyms", searchSynonymHandler.ListSynonyms)
+			admin.POST("/search-synonyms", searchSynonymHandler.CreateSynonym)
+			admin.GET("/search-synonyms/normalize", searchSynonymHandler.NormalizeQuery)
+			admin.GET("/search-synonyms/:synonym_id", searchSynonymHandler.GetSynonym)
+			admin.PUT("/search-synonyms/:synonym_id", searchSynonymHandler.UpdateSynonym)
+			admin.DELETE("/search-synonyms/:synonym_id", searchSynonymHandler.DeleteSynonym)
+		}
 	}
 
 	return router
@@ -0,0 +1,464 @@
+package service
+
+import (
+	"context"
+	"ec-recommend/internal/dto"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// BundleTuning configures the budget-constrained bundle builder
+type BundleTuning struct {
+	DefaultMaxItems       int     `json:"default_max_items"`       // Complementary items added when the request does not specify
+	CandidatesPerCategory int     `json:"candidates_per_category"` // Products considered per complementary category
+	MaxCategories         int     `json:"max_categories"`          // Complementary categories considered
+	AccessoryPriceRatio   float64 `json:"accessory_price_ratio"`   // Items cheaper than this share of the anchors are accessories
+}
+
+// Relevance weights of bundle candidates
+const (
+	bundleWeightCoPurchase     = 0.5
+	bundleWeightRating         = 0.2
+	bundleWeightPreferredCat   = 0.15
+	bundleWeightPreferredBrand = 0.15
+)
+
+// bundleCandidate is a complementary product with its combined relevance
+type bundleCandidate struct {
+	product   dto.ProductRecommendationV2
+	relevance float64
+}
+
+// bundleState is a partial bundle on the Pareto frontier of price and relevance
+type bundleState struct {
+	cost      float64
+	value     float64
+	parent    int // Previous state, or -1 for the empty bundle
+	candidate *bundleCandidate
+}
+
+// SolveBundleKnapsack picks at most one candidate per group and at most maxItems overall, maximizing
+// total relevance with a total price within budget. Groups are typically categories, so the bundle
+// spans different categories. A non-positive budget means no price limit.
+//
+// The solver keeps, for each item count, only partial bundles not dominated by a cheaper bundle with
+// at least the same relevance, which keeps prices exact without discretizing the budget.
+func SolveBundleKnapsack(groups [][]bundleCandidate, budget float64, maxItems int) []bundleCandidate {
+	if maxItems <= 0 || len(groups) == 0 {
+		return nil
+	}
+
+	states := []bundleState{{parent: -1}}
+	frontiers := make([][]int, maxItems+1)
+	frontiers[0] = []int{0}
+
+	for g := range groups {
+		next := make([][]int, maxItems+1)
+		for k := range frontiers {
+			next[k] = append(next[k], frontiers[k]...)
+		}
+
+		for k := maxItems - 1; k >= 0; k-- {
+			for _, from := range frontiers[k] {
+				for i := range groups[g] {
+					candidate := &groups[g][i]
+					cost := states[from].cost + candidate.product.Price
+					if budget > 0 && cost > budget+1e-9 {
+						continue
+					}
+					states = append(states, bundleState{
+						cost:      cost,
+						value:     states[from].value + candidate.relevance,
+						parent:    from,
+						candidate: candidate,
+					})
+					next[k+1] = append(next[k+1], len(states)-1)
+				}
+			}
+		}
+
+		for k := range next {
+			next[k] = paretoFrontier(states, next[k])
+		}
+		frontiers = next
+	}
+
+	best := 0
+	for _, frontier := range frontiers {
+		for _, s := range frontier {
+			if states[s].value > states[best].value {
+				best = s
+			}
+		}
+	}
+
+	var selected []bundleCandidate
+	for s := best; states[s].candidate != nil; s = states[s].parent {
+		selected = append(selected, *states[s].candidate)
+	}
+
+	sort.SliceStable(selected, func(i, j int) bool {
+		return selected[i].relevance > selected[j].relevance
+	})
+
+	return selected
+}
+
+// paretoFrontier keeps the states that no cheaper state matches in relevance, cheapest first
+func paretoFrontier(states []bundleState, indices []int) []int {
+	sort.SliceStable(indices, func(i, j int) bool {
+		a, b := states[indices[i]], states[indices[j]]
+		if a.cost != b.cost {
+			return a.cost < b.cost
+		}
+		return a.value > b.value
+	})
+
+	frontier := indices[:0]
+	bestValue := math.Inf(-1)
+	for _, s := range indices {
+		if states[s].value > bestValue {
+			frontier = append(frontier, s)
+			bestValue = states[s].value
+		}
+	}
+
+	return frontier
+}
+
+// BundleService builds "complete the set" bundles of complementary products within a budget
+type BundleService struct {
+	repo        BundleRepositoryInterface
+	productRepo RecommendationRepositoryV2Interface
+	chatService ChatServiceInterface
+	tuning      BundleTuning
+	feedback    *FeedbackService
+}
+
+// NewBundleService creates a new bundle service instance
+func NewBundleService(repo BundleRepositoryInterface, productRepo RecommendationRepositoryV2Interface, chatService ChatServiceInterface, tuning BundleTuning) *BundleService {
+	if tuning.DefaultMaxItems <= 0 {
+		tuning.DefaultMaxItems = 3
+	}
+	if tuning.CandidatesPerCategory <= 0 {
+		tuning.CandidatesPerCategory = 5
+	}
+	if tuning.MaxCategories <= 0 {
+		tuning.MaxCategories = 8
+	}
+	if tuning.AccessoryPriceRatio <= 0 {
+		tuning.AccessoryPriceRatio = 0.25
+	}
+
+	return &BundleService{
+		repo:        repo,
+		productRepo: productRepo,
+		chatService: chatService,
+		tuning:      tuning,
+	}
+}
+
+// SetFeedbackService excludes products the customer dismissed from bundle candidates
+func (s *BundleService) SetFeedbackService(feedback *FeedbackService) {
+	s.feedback = feedback
+}
+
+// BuildBundle completes a set around the seed product or cart with complementary products from
+// other categories, maximizing combined relevance within the budget
+func (s *BundleService) BuildBundle(ctx context.Context, req *dto.BundleRequest) (*dto.BundleResponse, error) {
+	maxItems := req.MaxItems
+	if maxItems <= 0 {
+		maxItems = s.tuning.DefaultMaxItems
+	}
+
+	profile, err := s.productRepo.GetCustomerByID(ctx, req.CustomerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get customer profile: %w", err)
+	}
+
+	anchors, err := s.loadAnchors(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &dto.BundleResponse{
+		RecommendationID: uuid.New(),
+		CustomerID:       req.CustomerID,
+		BudgetSource:     dto.BundleBudgetSourceNone,
+		GeneratedAt:      time.Now(),
+	}
+	if req.Budget != nil {
+		response.Budget, response.BudgetSource = req.Budget, dto.BundleBudgetSourceRequest
+	} else if profile.PriceRangeMax != nil && *profile.PriceRangeMax > 0 {
+		response.Budget, response.BudgetSource = profile.PriceRangeMax, dto.BundleBudgetSourcePriceRangeMax
+	}
+
+	var anchorTotal float64
+	for _, anchor := range anchors {
+		anchorTotal += anchor.Price
+		response.Items = append(response.Items, dto.BundleItem{Product: anchor, Role: dto.BundleRoleAnchor})
+	}
+
+	// The anchors count towards the budget; nothing is added once it is spent
+	remaining := 0.0
+	if response.Budget != nil {
+		remaining = *response.Budget - anchorTotal
+	}
+	if len(anchors) > 0 && (response.Budget == nil || remaining > 0) {
+		groups, err := s.candidateGroups(ctx, anchors, profile)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, candidate := range SolveBundleKnapsack(groups, remaining, maxItems) {
+			role := dto.BundleRoleComplement
+			if candidate.product.Price <= anchorTotal*s.tuning.AccessoryPriceRatio {
+				role = dto.BundleRoleAccessory
+			}
+			response.Items = append(response.Items, dto.BundleItem{
+				Product:   candidate.product,
+				Role:      role,
+				Relevance: candidate.relevance,
+			})
+			response.TotalRelevance += candidate.relevance
+		}
+	}
+
+	for _, item := range response.Items {
+		response.BundleTotal += item.Product.Price
+		if item.Product.OriginalPrice != nil && *item.Product.OriginalPrice > item.Product.Price {
+			response.OriginalTotal += *item.Product.OriginalPrice
+		} else {
+			response.OriginalTotal += item.Product.Price
+		}
+	}
+	response.DiscountAmount = response.OriginalTotal - response.BundleTotal
+	if response.OriginalTotal > 0 {
+		response.DiscountPercent = response.DiscountAmount / response.OriginalTotal * 100
+	}
+	if response.Items == nil {
+		response.Items = []dto.BundleItem{}
+	}
+
+	if req.IncludeExplanation && len(response.Items) > 1 && s.chatService != nil {
+		aiResponse, err := generateAIResponse(ctx, s.chatService, s.createBundleExplanationPrompt(response, profile))
+		if err != nil {
+			log.Printf("Warning: failed to generate bundle explanation: %v", err)
+		} else {
+			response.Explanation = strings.TrimSpace(aiResponse.Content)
+		}
+	}
+
+	productIDs := make([]uuid.UUID, len(response.Items))
+	for i, item := range response.Items {
+		productIDs[i] = item.Product.ProductID
+	}
+	if err := s.productRepo.LogRecommendation(ctx, req.CustomerID, "bundle", "bundle", productIDs, response.RecommendationID, "bundle_v1.0"); err != nil {
+		log.Printf("Warning: failed to log bundle recommendation: %v", err)
+	}
+
+	return response, nil
+}
+
+// loadAnchors returns the seed product, explicit products and saved cart of the request
+func (s *BundleService) loadAnchors(ctx context.Context, req *dto.BundleRequest) ([]dto.ProductRecommendationV2, error) {
+	var anchorIDs []uuid.UUID
+	if req.SeedProductID != nil {
+		anchorIDs = append(anchorIDs, *req.SeedProductID)
+	}
+	anchorIDs = append(anchorIDs, req.ProductIDs...)
+	if req.UseCart {
+		cartIDs, err := s.repo.GetCartProductIDs(ctx, req.CustomerID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get cart products: %w", err)
+		}
+		anchorIDs = append(anchorIDs, cartIDs...)
+	}
+
+	if len(anchorIDs) == 0 {
+		return nil, nil
+	}
+
+	products, err := s.productRepo.GetProductsByIDs(ctx, anchorIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get anchor products: %w", err)
+	}
+
+	// Keep the request order and drop duplicates
+	byID := make(map[uuid.UUID]dto.ProductRecommendationV2, len(products))
+	for _, p := range products {
+		byID[p.ProductID] = p
+	}
+	var anchors []dto.ProductRecommendationV2
+	for _, id := range anchorIDs {
+		if p, ok := byID[id]; ok {
+			anchors = append(anchors, p)
+			delete(byID, id)
+		}
+	}
+
+	return anchors, nil
+}
+
+// candidateGroups returns scored complementary candidates grouped by category, excluding anchor
+// categories and products the customer already owns. Co-purchased products come first; categories
+// often bought with the anchors fill in when co-purchase data is sparse.
+func (s *BundleService) candidateGroups(ctx context.Context, anchors []dto.ProductRecommendationV2, profile *dto.CustomerProfile) ([][]bundleCandidate, error) {
+	excludedProducts := make(map[uuid.UUID]bool)
+	excludedCategories := make(map[int]bool)
+	anchorIDs := make([]uuid.UUID, len(anchors))
+	var anchorCategories []int
+	for i, anchor := range anchors {
+		anchorIDs[i] = anchor.ProductID
+		excludedProducts[anchor.ProductID] = true
+		if !excludedCategories[anchor.CategoryID] {
+			excludedCategories[anchor.CategoryID] = true
+			anchorCategories = append(anchorCategories, anchor.CategoryID)
+		}
+	}
+
+	purchases, err := s.productRepo.GetCustomerPurchaseHistory(ctx, profile.CustomerID, 50)
+	if err != nil {
+		log.Printf("Warning: failed to get purchase history for bundle: %v", err)
+	}
+	for _, purchase := range purchases {
+		excludedProducts[purchase.ProductID] = true
+	}
+
+	limit := s.tuning.MaxCategories * s.tuning.CandidatesPerCategory
+	coPurchases, err := s.repo.GetCoPurchasedProducts(ctx, anchorIDs, limit*2)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get co-purchased products: %w", err)
+	}
+
+	// Co-purchase strength per product, normalized by the strongest
+	strength := make(map[uuid.UUID]float64)
+	var candidateIDs []uuid.UUID
+	perCategory := make(map[int]int)
+	maxCount := 0
+	for _, cp := range coPurchases {
+		if cp.Count > maxCount {
+			maxCount = cp.Count
+		}
+	}
+	for _, cp := range coPurchases {
+		if excludedProducts[cp.ProductID] || excludedCategories[cp.CategoryID] || perCategory[cp.CategoryID] >= s.tuning.CandidatesPerCategory {
+			continue
+		}
+		perCategory[cp.CategoryID]++
+		strength[cp.ProductID] = float64(cp.Count) / float64(maxCount)
+		candidateIDs = append(candidateIDs, cp.ProductID)
+	}
+
+	products, err := s.productRepo.GetProductsByIDs(ctx, candidateIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get co-purchased product details: %w", err)
+	}
+
+	// Fill categories frequently bought with the anchors but missing from co-purchases
+	if len(perCategory) < s.tuning.MaxCategories {
+		categoryCoPurchases, err := s.repo.GetCategoryCoPurchases(ctx, anchorCategories, s.tuning.MaxCategories*2)
+		if err != nil {
+			log.Printf("Warning: failed to get category co-purchases: %v", err)
+		}
+		maxCategoryCount := 0
+		for _, cc := range categoryCoPurchases {
+			if cc.Count > maxCategoryCount {
+				maxCategoryCount = cc.Count
+			}
+		}
+		for _, cc := range categoryCoPurchases {
+			if len(perCategory) >= s.tuning.MaxCategories {
+				break
+			}
+			if excludedCategories[cc.CategoryID] || perCategory[cc.CategoryID] > 0 {
+				continue
+			}
+			categoryProducts, err := s.productRepo.GetProductsByCategory(ctx, cc.CategoryID, s.tuning.CandidatesPerCategory)
+			if err != nil {
+				log.Printf("Warning: failed to get products for category %d: %v", cc.CategoryID, err)
+				continue
+			}
+			for _, p := range categoryProducts {
+				if excludedProducts[p.ProductID] {
+					continue
+				}
+				// Category-level evidence counts for half of direct co-purchases
+				strength[p.ProductID] = 0.5 * float64(cc.Count) / float64(maxCategoryCount)
+				products = append(products, p)
+				perCategory[cc.CategoryID]++
+			}
+		}
+	}
+
+	suppressions := s.feedback.suppressionsFor(ctx, &profile.CustomerID)
+
+	preferredCategories := make(map[int]bool, len(profile.PreferredCategories))
+	for _, c := range profile.PreferredCategories {
+		preferredCategories[c] = true
+	}
+
+	byCategory := make(map[int][]bundleCandidate)
+	var categoryOrder []int
+	for _, p := range products {
+		if excludedProducts[p.ProductID] || suppressions.Suppresses(p.ProductID, p.Brand, p.CategoryID) {
+			continue
+		}
+		excludedProducts[p.ProductID] = true
+
+		relevance := bundleWeightCoPurchase*strength[p.ProductID] + bundleWeightRating*p.RatingAverage/5
+		if preferredCategories[p.CategoryID] {
+			relevance += bundleWeightPreferredCat
+		}
+		if p.Brand != "" && contains(profile.PreferredBrands, p.Brand) {
+			relevance += bundleWeightPreferredBrand
+		}
+
+		if _, ok := byCategory[p.CategoryID]; !ok {
+			categoryOrder = append(categoryOrder, p.CategoryID)
+		}
+		byCategory[p.CategoryID] = append(byCategory[p.CategoryID], bundleCandidate{product: p, relevance: relevance})
+	}
+
+	groups := make([][]bundleCandidate, 0, len(categoryOrder))
+	for _, categoryID := range categoryOrder {
+		groups = append(groups, byCategory[categoryID])
+	}
+
+	return groups, nil
+}
+
+// createBundleExplanationPrompt asks the model to explain why the bundle items go well together
+func (s *BundleService) createBundleExplanationPrompt(bundle *dto.BundleResponse, profile *dto.CustomerProfile) string {
+	var items strings.Builder
+	for _, item := range bundle.Items {
+		items.WriteString(fmt.Sprintf("- [%s] %s (%s, %s): ¥%.0f\n", item.Role, item.Product.Name, item.Product.CategoryName, item.Product.Brand, item.Product.Price))
+	}
+
+	return fmt.Sprintf(`
+Explain in 2-3 sentences of Japanese why the following products make a good set for this customer.
+Mention how the complementary items relate to the anchor products. Do not mention internal scores.
+
+Bundle:
+%s
+Bundle Total: ¥%.0f (discount ¥%.0f)
+
+Customer Profile:
+- Preferred Categories: %v
+- Preferred Brands: %v
+- Lifestyle Tags: %v
+`,
+		items.String(),
+		bundle.BundleTotal,
+		bundle.DiscountAmount,
+		profile.PreferredCategories,
+		profile.PreferredBrands,
+		profile.LifestyleTags,
+	)
+}
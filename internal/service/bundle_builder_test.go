@@ -0,0 +1,68 @@
+package service
+
+import (
+	"ec-recommend/internal/dto"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestSolveBundleKnapsack(t *testing.T) {
+	candidate := func(name string, categoryID int, price, relevance float64) bundleCandidate {
+		return bundleCandidate{
+			product:   dto.ProductRecommendationV2{ProductID: uuid.New(), Name: name, CategoryID: categoryID, Price: price},
+			relevance: relevance,
+		}
+	}
+	names := func(selected []bundleCandidate) map[string]bool {
+		result := make(map[string]bool)
+		for _, c := range selected {
+			result[c.product.Name] = true
+		}
+		return result
+	}
+
+	groups := [][]bundleCandidate{
+		{candidate("case_premium", 1, 6000, 0.9), candidate("case_basic", 1, 2000, 0.6)},
+		{candidate("charger", 2, 3000, 0.7)},
+		{candidate("film", 3, 1000, 0.4)},
+	}
+
+	t.Run("maximizes relevance within the budget", func(t *testing.T) {
+		selected := SolveBundleKnapsack(groups, 6000, 3)
+
+		// case_premium alone (0.9) loses to case_basic + charger + film (1.7) at exactly 6000
+		got := names(selected)
+		if len(selected) != 3 || !got["case_basic"] || !got["charger"] || !got["film"] {
+			t.Errorf("Expected case_basic, charger and film, got %v", got)
+		}
+
+		var total float64
+		for _, c := range selected {
+			total += c.product.Price
+		}
+		if total > 6000 {
+			t.Errorf("Expected total within budget, got %.0f", total)
+		}
+	})
+
+	t.Run("picks at most one item per group and respects the item limit", func(t *testing.T) {
+		selected := SolveBundleKnapsack(groups, 100000, 2)
+		got := names(selected)
+		if len(selected) != 2 || !got["case_premium"] || !got["charger"] {
+			t.Errorf("Expected case_premium and charger, got %v", got)
+		}
+	})
+
+	t.Run("nothing fits a small budget", func(t *testing.T) {
+		if selected := SolveBundleKnapsack(groups, 999, 3); len(selected) != 0 {
+			t.Errorf("Expected an empty bundle, got %v", names(selected))
+		}
+	})
+
+	t.Run("no budget selects the best of each group", func(t *testing.T) {
+		if selected := SolveBundleKnapsack(groups, 0, 3); len(selected) != 3 || selected[0].product.Name != "case_premium" {
+			t.Errorf("Expected 3 items led by case_premium, got %v", names(selected))
+		}
+	})
+}
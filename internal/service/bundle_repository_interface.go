@@ -0,0 +1,21 @@
+package service
+
+import (
+	"context"
+	"ec-recommend/internal/dto"
+
+	"github.com/google/uuid"
+)
+
+// BundleRepositoryInterface defines the interface for bundle candidate data access
+// This interface is defined in the service package as it is consumed by services
+type BundleRepositoryInterface interface {
+	// GetCoPurchasedProducts returns active products bought in the same orders as the anchors, most frequent first
+	GetCoPurchasedProducts(ctx context.Context, anchorIDs []uuid.UUID, limit int) ([]dto.BundleCoPurchase, error)
+
+	// GetCategoryCoPurchases returns categories bought in the same orders as the given categories, most frequent first
+	GetCategoryCoPurchases(ctx context.Context, categoryIDs []int, limit int) ([]dto.CategoryCoPurchase, error)
+
+	// GetCartProductIDs returns the products in a customer's cart
+	GetCartProductIDs(ctx context.Context, customerID uuid.UUID) ([]uuid.UUID, error)
+}
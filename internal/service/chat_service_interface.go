@@ -14,4 +14,7 @@ type ChatServiceInterface interface {
 
 	// GenerateResponse generates a response for the given prompt
 	GenerateResponse(ctx context.Context, prompt string) (*types.AIResponse, error)
+
+	// GenerateResponseWithModel generates a response for the given prompt using a specific model
+	GenerateResponseWithModel(ctx context.Context, prompt, modelID string) (*types.AIResponse, error)
 }
@@ -0,0 +1,349 @@
+package service
+
+import (
+	"context"
+	"ec-recommend/internal/dto"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Similarity component weights used by attribute-based diversity similarity
+const (
+	diversityCategoryWeight = 0.3
+	diversityBrandWeight    = 0.3
+	diversityContentWeight  = 0.25
+	diversityPriceWeight    = 0.15
+)
+
+// Embedding cache defaults and the number of embeddings requested concurrently per batch
+const (
+	defaultEmbeddingCacheMinutes = 24 * 60
+	defaultEmbeddingCacheSize    = 5000
+	embeddingBatchSize           = 8
+)
+
+// DiversityTuning configures the MMR diversity re-ranking stage
+type DiversityTuning struct {
+	// DefaultDiversity is the MMR trade-off used when neither the request nor the context sets one
+	DefaultDiversity float64 `json:"default_diversity"`
+	// UseEmbeddings enables embedding similarity for the content component when an embedding provider is available
+	UseEmbeddings bool `json:"use_embeddings,omitempty"`
+	// EmbeddingCacheMinutes is how long a product embedding is reused before it is generated again
+	EmbeddingCacheMinutes int `json:"embedding_cache_minutes,omitempty"`
+	// EmbeddingCacheSize bounds the number of cached product embeddings
+	EmbeddingCacheSize int `json:"embedding_cache_size,omitempty"`
+	// Contexts holds per-context overrides and caps keyed by context type
+	Contexts map[string]DiversityContextTuning `json:"contexts,omitempty"`
+}
+
+// DiversityContextTuning holds diversity settings for a single context type
+type DiversityContextTuning struct {
+	Diversity      *float64 `json:"diversity,omitempty"`
+	MaxPerBrand    int      `json:"max_per_brand,omitempty"`    // 0 disables the cap
+	MaxPerCategory int      `json:"max_per_category,omitempty"` // 0 disables the cap
+}
+
+// EmbeddingProvider generates vector embeddings for text
+type EmbeddingProvider interface {
+	GetVectorEmbedding(ctx context.Context, text string) ([]float64, error)
+}
+
+// DiversityReranker re-ranks recommendations with maximal marginal relevance (MMR)
+// and enforces per-context brand and category caps.
+type DiversityReranker struct {
+	tuning     DiversityTuning
+	embedder   EmbeddingProvider
+	mu         sync.RWMutex
+	embeddings map[uuid.UUID]cachedEmbedding
+}
+
+// cachedEmbedding is a product embedding with the time it was generated
+type cachedEmbedding struct {
+	vector    []float64
+	fetchedAt time.Time
+}
+
+// NewDiversityReranker creates a diversity re-ranking stage.
+//
+// Parameters:
+//   - tuning: diversity defaults and per-context caps
+//   - embedder: optional embedding provider; nil uses attribute similarity only
+func NewDiversityReranker(tuning DiversityTuning, embedder EmbeddingProvider) *DiversityReranker {
+	if tuning.EmbeddingCacheMinutes <= 0 {
+		tuning.EmbeddingCacheMinutes = defaultEmbeddingCacheMinutes
+	}
+	if tuning.EmbeddingCacheSize <= 0 {
+		tuning.EmbeddingCacheSize = defaultEmbeddingCacheSize
+	}
+
+	return &DiversityReranker{
+		tuning:     tuning,
+		embedder:   embedder,
+		embeddings: make(map[uuid.UUID]cachedEmbedding),
+	}
+}
+
+// Name returns the stage identifier
+func (dr *DiversityReranker) Name() string {
+	return "diversity"
+}
+
+// enforcesPolicy marks the stage to run for gift results too
+func (dr *DiversityReranker) enforcesPolicy() {}
+
+// Apply greedily selects recommendations maximizing
+// (1 - diversity) * relevance - diversity * max similarity to already selected items.
+// Items that would exceed the brand or category cap for the context are dropped.
+func (dr *DiversityReranker) Apply(ctx context.Context, recommendations []dto.ProductRecommendationV2, rc *RankingContext) ([]dto.ProductRecommendationV2, error) {
+	contextTuning := dr.tuning.Contexts[rc.ContextType]
+
+	diversity := dr.tuning.DefaultDiversity
+	if contextTuning.Diversity != nil {
+		diversity = *contextTuning.Diversity
+	}
+	if rc.Diversity != nil {
+		diversity = *rc.Diversity
+	}
+	diversity = math.Max(0, math.Min(1, diversity))
+
+	if len(recommendations) < 2 || (diversity == 0 && contextTuning.MaxPerBrand == 0 && contextTuning.MaxPerCategory == 0) {
+		return recommendations, nil
+	}
+
+	var embeddings [][]float64
+	if dr.tuning.UseEmbeddings && dr.embedder != nil && diversity > 0 {
+		embeddings = dr.loadEmbeddings(ctx, recommendations)
+	}
+
+	var maxRelevance float64
+	for _, rec := range recommendations {
+		maxRelevance = math.Max(maxRelevance, rec.ConfidenceScore)
+	}
+
+	remaining := make([]int, len(recommendations))
+	for i := range recommendations {
+		remaining[i] = i
+	}
+	maxSimilarity := make([]float64, len(recommendations))
+	brandCounts := make(map[string]int)
+	categoryCounts := make(map[int]int)
+
+	selected := make([]dto.ProductRecommendationV2, 0, len(recommendations))
+	for len(remaining) > 0 {
+		bestPos := -1
+		bestScore := math.Inf(-1)
+		eligible := remaining[:0]
+
+		for _, idx := range remaining {
+			rec := recommendations[idx]
+			if contextTuning.MaxPerBrand > 0 && rec.Brand != "" && brandCounts[brandKey(rec.Brand)] >= contextTuning.MaxPerBrand {
+				continue
+			}
+			if contextTuning.MaxPerCategory > 0 && categoryCounts[rec.CategoryID] >= contextTuning.MaxPerCategory {
+				continue
+			}
+
+			relevance := 0.0
+			if maxRelevance > 0 {
+				relevance = rec.ConfidenceScore / maxRelevance
+			}
+			score := (1-diversity)*relevance - diversity*maxSimilarity[idx]
+			if score > bestScore {
+				bestScore = score
+				bestPos = len(eligible)
+			}
+			eligible = append(eligible, idx)
+		}
+
+		if bestPos < 0 {
+			break
+		}
+
+		chosen := eligible[bestPos]
+		remaining = append(eligible[:bestPos], eligible[bestPos+1:]...)
+
+		chosenRec := recommendations[chosen]
+		selected = append(selected, chosenRec)
+		brandCounts[brandKey(chosenRec.Brand)]++
+		categoryCounts[chosenRec.CategoryID]++
+
+		for _, idx := range remaining {
+			similarity := dr.similarity(recommendations, embeddings, chosen, idx)
+			if similarity > maxSimilarity[idx] {
+				maxSimilarity[idx] = similarity
+			}
+		}
+	}
+
+	return selected, nil
+}
+
+// similarity combines category, brand, content and price similarity between two recommendations.
+// The content component uses embedding cosine similarity when both embeddings are available,
+// otherwise tag overlap (Jaccard).
+func (dr *DiversityReranker) similarity(recommendations []dto.ProductRecommendationV2, embeddings [][]float64, i, j int) float64 {
+	a, b := recommendations[i], recommendations[j]
+
+	var similarity float64
+	if a.CategoryID == b.CategoryID {
+		similarity += diversityCategoryWeight
+	}
+	if a.Brand != "" && brandKey(a.Brand) == brandKey(b.Brand) {
+		similarity += diversityBrandWeight
+	}
+
+	if embeddings != nil && embeddings[i] != nil && embeddings[j] != nil {
+		similarity += diversityContentWeight * math.Max(0, cosineSimilarity(embeddings[i], embeddings[j]))
+	} else {
+		similarity += diversityContentWeight * tagJaccard(a.Tags, b.Tags)
+	}
+
+	if a.Price > 0 && b.Price > 0 {
+		similarity += diversityPriceWeight * (1 - math.Abs(a.Price-b.Price)/math.Max(a.Price, b.Price))
+	}
+
+	return similarity
+}
+
+// loadEmbeddings returns embeddings aligned with recommendations, using a bounded per-product cache.
+// Missing or expired embeddings are generated concurrently in batches; products whose embedding
+// cannot be generated get a nil entry.
+func (dr *DiversityReranker) loadEmbeddings(ctx context.Context, recommendations []dto.ProductRecommendationV2) [][]float64 {
+	embeddings := make([][]float64, len(recommendations))
+	ttl := time.Duration(dr.tuning.EmbeddingCacheMinutes) * time.Minute
+	now := time.Now()
+
+	var missing []int
+	dr.mu.RLock()
+	for i, rec := range recommendations {
+		if cached, ok := dr.embeddings[rec.ProductID]; ok && now.Sub(cached.fetchedAt) < ttl {
+			embeddings[i] = cached.vector
+			continue
+		}
+		missing = append(missing, i)
+	}
+	dr.mu.RUnlock()
+
+	for start := 0; start < len(missing); start += embeddingBatchSize {
+		end := start + embeddingBatchSize
+		if end > len(missing) {
+			end = len(missing)
+		}
+
+		var wg sync.WaitGroup
+		for _, idx := range missing[start:end] {
+			wg.Add(1)
+			go func(idx int) {
+				defer wg.Done()
+				rec := recommendations[idx]
+				text := strings.Join(append([]string{rec.Name, rec.Brand, rec.CategoryName}, rec.Tags...), " ")
+				embedding, err := dr.embedder.GetVectorEmbedding(ctx, text)
+				if err != nil {
+					log.Printf("Warning: failed to get embedding for product %s: %v", rec.ProductID, err)
+					return
+				}
+				embeddings[idx] = embedding
+			}(idx)
+		}
+		wg.Wait()
+	}
+
+	if len(missing) > 0 {
+		dr.mu.Lock()
+		for _, idx := range missing {
+			if embeddings[idx] != nil {
+				dr.embeddings[recommendations[idx].ProductID] = cachedEmbedding{vector: embeddings[idx], fetchedAt: now}
+			}
+		}
+		dr.evictEmbeddings(now, ttl)
+		dr.mu.Unlock()
+	}
+
+	return embeddings
+}
+
+// evictEmbeddings drops expired embeddings and, if the cache is still over its size bound,
+// the oldest ones. The caller must hold the write lock.
+func (dr *DiversityReranker) evictEmbeddings(now time.Time, ttl time.Duration) {
+	if len(dr.embeddings) <= dr.tuning.EmbeddingCacheSize {
+		return
+	}
+
+	for productID, cached := range dr.embeddings {
+		if now.Sub(cached.fetchedAt) >= ttl {
+			delete(dr.embeddings, productID)
+		}
+	}
+
+	excess := len(dr.embeddings) - dr.tuning.EmbeddingCacheSize
+	if excess <= 0 {
+		return
+	}
+
+	productIDs := make([]uuid.UUID, 0, len(dr.embeddings))
+	for productID := range dr.embeddings {
+		productIDs = append(productIDs, productID)
+	}
+	sort.Slice(productIDs, func(i, j int) bool {
+		return dr.embeddings[productIDs[i]].fetchedAt.Before(dr.embeddings[productIDs[j]].fetchedAt)
+	})
+	for _, productID := range productIDs[:excess] {
+		delete(dr.embeddings, productID)
+	}
+}
+
+// brandKey normalizes a brand name so that brand caps and similarity ignore case and surrounding spaces
+func brandKey(brand string) string {
+	return strings.ToLower(strings.TrimSpace(brand))
+}
+
+// tagJaccard returns the Jaccard similarity of two tag sets
+func tagJaccard(a, b []string) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+
+	set := make(map[string]bool, len(a))
+	for _, tag := range a {
+		set[tag] = true
+	}
+
+	intersection := 0
+	union := len(set)
+	for _, tag := range b {
+		if set[tag] {
+			intersection++
+			set[tag] = false
+		} else if _, seen := set[tag]; !seen {
+			union++
+			set[tag] = false
+		}
+	}
+
+	return float64(intersection) / float64(union)
+}
+
+// cosineSimilarity returns the cosine similarity of two vectors, or 0 for mismatched or zero vectors
+func cosineSimilarity(a, b []float64) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+
+	var dot, normA, normB float64
+	for i := range a {
+		dot += a[i] * b[i]
+		normA += a[i] * a[i]
+		normB += b[i] * b[i]
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"ec-recommend/internal/dto"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// fakeEmbeddingProvider returns a fixed embedding and counts the requests it serves
+type fakeEmbeddingProvider struct {
+	mu    sync.Mutex
+	calls int
+}
+
+func (f *fakeEmbeddingProvider) GetVectorEmbedding(ctx context.Context, text string) ([]float64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls++
+	return []float64{1, 0}, nil
+}
+
+func diversityCandidate(name, brand string, categoryID int, score float64) dto.ProductRecommendationV2 {
+	return dto.ProductRecommendationV2{ProductID: uuid.New(), Name: name, Brand: brand, CategoryID: categoryID, ConfidenceScore: score}
+}
+
+func recommendationNames(recommendations []dto.ProductRecommendationV2) []string {
+	names := make([]string, len(recommendations))
+	for i, rec := range recommendations {
+		names[i] = rec.Name
+	}
+	return names
+}
+
+func TestDiversityRerankerMMR(t *testing.T) {
+	candidates := []dto.ProductRecommendationV2{
+		diversityCandidate("acme-1", "Acme", 1, 0.9),
+		diversityCandidate("acme-2", "Acme", 1, 0.85),
+		diversityCandidate("other-1", "Other", 1, 0.5),
+		diversityCandidate("other-2", "Other", 1, 0.4),
+	}
+
+	tests := []struct {
+		name      string
+		diversity float64
+		want      []string
+	}{
+		{name: "relevance only keeps the input order", diversity: 0, want: []string{"acme-1", "acme-2", "other-1", "other-2"}},
+		{name: "high diversity interleaves brands", diversity: 0.8, want: []string{"acme-1", "other-1", "acme-2", "other-2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reranker := NewDiversityReranker(DiversityTuning{}, nil)
+			diversity := tt.diversity
+
+			reranked, err := reranker.Apply(context.Background(), candidates, &RankingContext{Diversity: &diversity})
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			got := recommendationNames(reranked)
+			if len(got) != len(tt.want) {
+				t.Fatalf("Expected %v, got %v", tt.want, got)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("Expected %v, got %v", tt.want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestDiversityRerankerDefaultTuningKeepsOrder(t *testing.T) {
+	reranker := NewDiversityReranker(DefaultRecommendationTuning().Diversity, nil)
+	candidates := []dto.ProductRecommendationV2{
+		diversityCandidate("acme-1", "Acme", 1, 0.9),
+		diversityCandidate("acme-2", "Acme", 1, 0.85),
+		diversityCandidate("other-1", "Other", 1, 0.5),
+	}
+
+	reranked, err := reranker.Apply(context.Background(), candidates, &RankingContext{ContextType: "homepage"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	got := recommendationNames(reranked)
+	if len(got) != 3 || got[0] != "acme-1" || got[1] != "acme-2" || got[2] != "other-1" {
+		t.Errorf("Expected the ranked order without a diversity setting, got %v", got)
+	}
+}
+
+func TestDiversityRerankerBrandCapIgnoresCase(t *testing.T) {
+	reranker := NewDiversityReranker(DiversityTuning{
+		Contexts: map[string]DiversityContextTuning{
+			"homepage": {MaxPerBrand: 1},
+		},
+	}, nil)
+	candidates := []dto.ProductRecommendationV2{
+		diversityCandidate("acme-1", "Acme", 1, 0.9),
+		diversityCandidate("acme-2", "ACME", 2, 0.8),
+		diversityCandidate("acme-3", " acme ", 3, 0.7),
+		diversityCandidate("other-1", "Other", 4, 0.6),
+	}
+
+	reranked, err := reranker.Apply(context.Background(), candidates, &RankingContext{ContextType: "homepage"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	got := recommendationNames(reranked)
+	if len(got) != 2 || got[0] != "acme-1" || got[1] != "other-1" {
+		t.Errorf("Expected one product per brand regardless of case, got %v", got)
+	}
+}
+
+func TestDiversityRerankerEmbeddingCache(t *testing.T) {
+	embedder := &fakeEmbeddingProvider{}
+	reranker := NewDiversityReranker(DiversityTuning{DefaultDiversity: 0.5, UseEmbeddings: true, EmbeddingCacheSize: 3}, embedder)
+
+	candidates := make([]dto.ProductRecommendationV2, embeddingBatchSize+2)
+	for i := range candidates {
+		candidates[i] = diversityCandidate("product", "", i, 1)
+	}
+
+	embeddings := reranker.loadEmbeddings(context.Background(), candidates)
+	for i, embedding := range embeddings {
+		if embedding == nil {
+			t.Errorf("Expected an embedding for candidate %d", i)
+		}
+	}
+	if embedder.calls != len(candidates) {
+		t.Errorf("Expected %d embedding requests, got %d", len(candidates), embedder.calls)
+	}
+	if len(reranker.embeddings) != 3 {
+		t.Errorf("Expected the cache to be bounded to 3 entries, got %d", len(reranker.embeddings))
+	}
+
+	// Cached products are not requested again
+	embedder.calls = 0
+	var cached []dto.ProductRecommendationV2
+	for _, rec := range candidates {
+		if _, ok := reranker.embeddings[rec.ProductID]; ok {
+			cached = append(cached, rec)
+		}
+	}
+	reranker.loadEmbeddings(context.Background(), cached)
+	if embedder.calls != 0 {
+		t.Errorf("Expected cached embeddings to be reused, got %d requests", embedder.calls)
+	}
+}
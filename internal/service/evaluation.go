@@ -0,0 +1,547 @@
+package service
+
+import (
+	"context"
+	"ec-recommend/internal/dto"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// evaluationExcludedStatuses are order statuses that do not count as purchases in the test window
+var evaluationExcludedStatuses = map[string]bool{
+	"cancelled": true,
+	"returned":  true,
+}
+
+// EvaluationSplit is order history split at a cutoff for time-split replay. Strategies are
+// only shown the train side; the test side says which products each customer went on to buy.
+type EvaluationSplit struct {
+	Cutoff         time.Time
+	Train          []dto.EvaluationOrderLine        // Delivered order lines before the cutoff, oldest first
+	TestLines      int                              // Purchased order lines in the test window
+	Relevant       map[uuid.UUID]map[uuid.UUID]bool // Products each customer bought in the test window but not before
+	Customers      []uuid.UUID                      // Customers with train history and at least one relevant product
+	Popularity     map[uuid.UUID]int                // Distinct train buyers per product
+	TrainCustomers int                              // Customers with at least one train order line
+	owned          map[uuid.UUID]map[uuid.UUID]bool // Products each customer bought before the cutoff
+	purchases      map[uuid.UUID][]dto.EvaluationOrderLine
+	categories     map[uuid.UUID]int
+}
+
+// NewEvaluationSplit splits order lines at the cutoff. Lines before the cutoff form the train
+// side, mirroring the live repository's use of delivered orders only. Lines from the cutoff on
+// are test purchases unless cancelled or returned; products the customer already owned are not
+// counted as relevant. Customers are sorted by ID and capped at maxCustomers (0 means no cap).
+func NewEvaluationSplit(lines []dto.EvaluationOrderLine, cutoff time.Time, maxCustomers int) *EvaluationSplit {
+	split := &EvaluationSplit{
+		Cutoff:     cutoff,
+		Relevant:   make(map[uuid.UUID]map[uuid.UUID]bool),
+		Popularity: make(map[uuid.UUID]int),
+		owned:      make(map[uuid.UUID]map[uuid.UUID]bool),
+		purchases:  make(map[uuid.UUID][]dto.EvaluationOrderLine),
+		categories: make(map[uuid.UUID]int),
+	}
+
+	var test []dto.EvaluationOrderLine
+	for _, line := range lines {
+		if evaluationExcludedStatuses[line.Status] {
+			continue
+		}
+		if !line.OrderedAt.Before(cutoff) {
+			test = append(test, line)
+			continue
+		}
+
+		addToSet(split.owned, line.CustomerID, line.ProductID)
+		if line.Status != "delivered" {
+			continue
+		}
+		split.Train = append(split.Train, line)
+		split.purchases[line.CustomerID] = append(split.purchases[line.CustomerID], line)
+		split.categories[line.ProductID] = line.CategoryID
+	}
+
+	buyers := make(map[uuid.UUID]map[uuid.UUID]bool)
+	for _, line := range split.Train {
+		addToSet(buyers, line.ProductID, line.CustomerID)
+	}
+	for productID, customers := range buyers {
+		split.Popularity[productID] = len(customers)
+	}
+	split.TrainCustomers = len(split.purchases)
+
+	for _, line := range test {
+		split.TestLines++
+		if split.owned[line.CustomerID][line.ProductID] {
+			continue
+		}
+		addToSet(split.Relevant, line.CustomerID, line.ProductID)
+	}
+
+	for customerID := range split.Relevant {
+		if len(split.purchases[customerID]) > 0 {
+			split.Customers = append(split.Customers, customerID)
+		}
+	}
+	sort.Slice(split.Customers, func(i, j int) bool {
+		return split.Customers[i].String() < split.Customers[j].String()
+	})
+	if maxCustomers > 0 && len(split.Customers) > maxCustomers {
+		split.Customers = split.Customers[:maxCustomers]
+	}
+
+	return split
+}
+
+// PopularRecommendations returns the most-bought train products the customer does not own,
+// restricted to the given catalog. It is the non-personalized baseline strategies are compared with.
+func (s *EvaluationSplit) PopularRecommendations(customerID uuid.UUID, catalog map[uuid.UUID]int, k int) []uuid.UUID {
+	var candidates []uuid.UUID
+	for productID := range s.Popularity {
+		if _, active := catalog[productID]; !active || s.owned[customerID][productID] {
+			continue
+		}
+		candidates = append(candidates, productID)
+	}
+	s.sortByPopularity(candidates)
+
+	if len(candidates) > k {
+		candidates = candidates[:k]
+	}
+	return candidates
+}
+
+// sortByPopularity orders products by distinct train buyers, breaking ties by ID
+func (s *EvaluationSplit) sortByPopularity(productIDs []uuid.UUID) {
+	sort.Slice(productIDs, func(i, j int) bool {
+		pi, pj := s.Popularity[productIDs[i]], s.Popularity[productIDs[j]]
+		if pi != pj {
+			return pi > pj
+		}
+		return productIDs[i].String() < productIDs[j].String()
+	})
+}
+
+// addToSet adds value to the set stored under key
+func addToSet(sets map[uuid.UUID]map[uuid.UUID]bool, key, value uuid.UUID) {
+	if sets[key] == nil {
+		sets[key] = make(map[uuid.UUID]bool)
+	}
+	sets[key][value] = true
+}
+
+// SnapshotRecommendationRepository answers the order-dependent queries of the recommendation
+// repository from the train side of an evaluation split, so that strategies replayed offline
+// cannot see purchases made after the cutoff. Product lookups fall through to the embedded
+// repository and therefore reflect the current catalog, ratings and popularity_score.
+type SnapshotRecommendationRepository struct {
+	RecommendationRepositoryInterface
+	split *EvaluationSplit
+}
+
+// NewSnapshotRecommendationRepository creates a repository restricted to the split's train side
+func NewSnapshotRecommendationRepository(base RecommendationRepositoryInterface, split *EvaluationSplit) *SnapshotRecommendationRepository {
+	return &SnapshotRecommendationRepository{
+		RecommendationRepositoryInterface: base,
+		split:                             split,
+	}
+}
+
+// GetCustomerByID returns the customer with spend and order counts recomputed from the train side
+func (r *SnapshotRecommendationRepository) GetCustomerByID(ctx context.Context, customerID uuid.UUID) (*dto.CustomerProfile, error) {
+	profile, err := r.RecommendationRepositoryInterface.GetCustomerByID(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	orders := make(map[uuid.UUID]bool)
+	profile.TotalSpent = 0
+	for _, line := range r.split.purchases[customerID] {
+		orders[line.OrderID] = true
+		profile.TotalSpent += line.UnitPrice * float64(line.Quantity)
+	}
+	profile.OrderCount = len(orders)
+
+	return profile, nil
+}
+
+// GetCustomerPurchaseHistory returns the customer's train purchases, most recent first
+func (r *SnapshotRecommendationRepository) GetCustomerPurchaseHistory(ctx context.Context, customerID uuid.UUID, limit int) ([]dto.PurchaseItem, error) {
+	lines := r.split.purchases[customerID]
+
+	history := make([]dto.PurchaseItem, 0, len(lines))
+	for i := len(lines) - 1; i >= 0 && len(history) < limit; i-- {
+		history = append(history, dto.PurchaseItem{
+			ProductID:   lines[i].ProductID,
+			CategoryID:  lines[i].CategoryID,
+			Price:       lines[i].UnitPrice,
+			Quantity:    lines[i].Quantity,
+			PurchasedAt: lines[i].OrderedAt,
+		})
+	}
+
+	return history, nil
+}
+
+// GetCustomerActivities returns the customer's activities from before the cutoff. Activities are
+// read from the embedded repository with a wider window, so a customer with many recent events
+// may get fewer than limit.
+func (r *SnapshotRecommendationRepository) GetCustomerActivities(ctx context.Context, customerID uuid.UUID, limit int) ([]dto.ActivityItem, error) {
+	activities, err := r.RecommendationRepositoryInterface.GetCustomerActivities(ctx, customerID, limit*10)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]dto.ActivityItem, 0, limit)
+	for _, activity := range activities {
+		if activity.CreatedAt.Before(r.split.Cutoff) && len(result) < limit {
+			result = append(result, activity)
+		}
+	}
+
+	return result, nil
+}
+
+// GetTrendingProducts returns the products with the most distinct train buyers
+func (r *SnapshotRecommendationRepository) GetTrendingProducts(ctx context.Context, categoryID *int, limit int) ([]dto.ProductRecommendation, error) {
+	var productIDs []uuid.UUID
+	for productID := range r.split.Popularity {
+		if categoryID != nil && r.split.categories[productID] != *categoryID {
+			continue
+		}
+		productIDs = append(productIDs, productID)
+	}
+	r.split.sortByPopularity(productIDs)
+
+	// Over-fetch because products deactivated since the cutoff are dropped by the lookup
+	if len(productIDs) > limit*2 {
+		productIDs = productIDs[:limit*2]
+	}
+
+	products, err := r.productsInOrder(ctx, productIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get trending products: %w", err)
+	}
+	if len(products) > limit {
+		products = products[:limit]
+	}
+
+	return products, nil
+}
+
+// GetCustomersWithSimilarPurchases returns customers sharing at least two train products with
+// the customer, ordered by the number of shared products
+func (r *SnapshotRecommendationRepository) GetCustomersWithSimilarPurchases(ctx context.Context, customerID uuid.UUID, limit int) ([]uuid.UUID, error) {
+	owned := make(map[uuid.UUID]bool)
+	for _, line := range r.split.purchases[customerID] {
+		owned[line.ProductID] = true
+	}
+
+	shared := make(map[uuid.UUID]map[uuid.UUID]bool)
+	for _, line := range r.split.Train {
+		if line.CustomerID != customerID && owned[line.ProductID] {
+			addToSet(shared, line.CustomerID, line.ProductID)
+		}
+	}
+
+	var similar []uuid.UUID
+	for otherID, products := range shared {
+		if len(products) >= 2 {
+			similar = append(similar, otherID)
+		}
+	}
+	sort.Slice(similar, func(i, j int) bool {
+		si, sj := len(shared[similar[i]]), len(shared[similar[j]])
+		if si != sj {
+			return si > sj
+		}
+		return similar[i].String() < similar[j].String()
+	})
+
+	if len(similar) > limit {
+		similar = similar[:limit]
+	}
+
+	return similar, nil
+}
+
+// GetPopularProductsAmongSimilarCustomers returns the products most similar customers bought
+// before the cutoff, excluding the given products
+func (r *SnapshotRecommendationRepository) GetPopularProductsAmongSimilarCustomers(ctx context.Context, similarCustomerIDs []uuid.UUID, excludeOwned []uuid.UUID, limit int) ([]dto.ProductRecommendation, error) {
+	if len(similarCustomerIDs) == 0 {
+		return []dto.ProductRecommendation{}, nil
+	}
+
+	excluded := make(map[uuid.UUID]bool, len(excludeOwned))
+	for _, productID := range excludeOwned {
+		excluded[productID] = true
+	}
+
+	buyers := make(map[uuid.UUID]map[uuid.UUID]bool)
+	for _, customerID := range similarCustomerIDs {
+		for _, line := range r.split.purchases[customerID] {
+			if !excluded[line.ProductID] {
+				addToSet(buyers, line.ProductID, customerID)
+			}
+		}
+	}
+
+	productIDs := make([]uuid.UUID, 0, len(buyers))
+	for productID := range buyers {
+		productIDs = append(productIDs, productID)
+	}
+
+	products, err := r.productsInOrder(ctx, productIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get popular products among similar customers: %w", err)
+	}
+
+	sort.SliceStable(products, func(i, j int) bool {
+		bi, bj := len(buyers[products[i].ProductID]), len(buyers[products[j].ProductID])
+		if bi != bj {
+			return bi > bj
+		}
+		return products[i].RatingAverage > products[j].RatingAverage
+	})
+
+	if len(products) > limit {
+		products = products[:limit]
+	}
+
+	return products, nil
+}
+
+// LogRecommendation does nothing so that offline replays leave no analytics behind
+func (r *SnapshotRecommendationRepository) LogRecommendation(ctx context.Context, customerID uuid.UUID, recommendationType, contextType string, productIDs []uuid.UUID, sessionID uuid.UUID, algorithmVersion string) error {
+	return nil
+}
+
+// LogRecommendationInteraction does nothing so that offline replays leave no analytics behind
+func (r *SnapshotRecommendationRepository) LogRecommendationInteraction(ctx context.Context, analytics *dto.RecommendationAnalytics) error {
+	return nil
+}
+
+// productsInOrder looks up products and returns them in the order of productIDs, which the
+// embedded repository does not preserve
+func (r *SnapshotRecommendationRepository) productsInOrder(ctx context.Context, productIDs []uuid.UUID) ([]dto.ProductRecommendation, error) {
+	products, err := r.RecommendationRepositoryInterface.GetProductsByIDs(ctx, productIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[uuid.UUID]dto.ProductRecommendation, len(products))
+	for _, product := range products {
+		byID[product.ProductID] = product
+	}
+
+	ordered := make([]dto.ProductRecommendation, 0, len(products))
+	for _, productID := range productIDs {
+		if product, ok := byID[productID]; ok {
+			ordered = append(ordered, product)
+		}
+	}
+
+	return ordered, nil
+}
+
+// RankingMetrics are top-K accuracy metrics for one customer's recommendation list
+type RankingMetrics struct {
+	Precision        float64
+	Recall           float64
+	NDCG             float64
+	AveragePrecision float64
+}
+
+// ComputeRankingMetrics scores the first k recommended products against the relevant set using
+// binary relevance. Average precision is normalized by min(|relevant|, k) so that a perfect
+// list scores 1 even when more than k products are relevant.
+func ComputeRankingMetrics(recommended []uuid.UUID, relevant map[uuid.UUID]bool, k int) RankingMetrics {
+	if k <= 0 || len(relevant) == 0 {
+		return RankingMetrics{}
+	}
+	if len(recommended) > k {
+		recommended = recommended[:k]
+	}
+
+	var hits int
+	var dcg, precisionSum float64
+	for i, productID := range recommended {
+		if !relevant[productID] {
+			continue
+		}
+		hits++
+		dcg += 1 / math.Log2(float64(i+2))
+		precisionSum += float64(hits) / float64(i+1)
+	}
+
+	ideal := len(relevant)
+	if ideal > k {
+		ideal = k
+	}
+	var idcg float64
+	for i := 0; i < ideal; i++ {
+		idcg += 1 / math.Log2(float64(i+2))
+	}
+
+	return RankingMetrics{
+		Precision:        float64(hits) / float64(k),
+		Recall:           float64(hits) / float64(len(relevant)),
+		NDCG:             dcg / idcg,
+		AveragePrecision: precisionSum / float64(ideal),
+	}
+}
+
+// IntraListDiversity returns the share of product pairs in the list that belong to different
+// categories. Lists with fewer than two products have no pairs and return 0.
+func IntraListDiversity(recommended []uuid.UUID, categories map[uuid.UUID]int) float64 {
+	if len(recommended) < 2 {
+		return 0
+	}
+
+	var pairs, different int
+	for i := 0; i < len(recommended); i++ {
+		for j := i + 1; j < len(recommended); j++ {
+			pairs++
+			if categories[recommended[i]] != categories[recommended[j]] {
+				different++
+			}
+		}
+	}
+
+	return float64(different) / float64(pairs)
+}
+
+// StrategyEvaluator accumulates per-customer results for one strategy
+type StrategyEvaluator struct {
+	strategy   string
+	k          int
+	split      *EvaluationSplit
+	catalog    map[uuid.UUID]int
+	evaluated  int
+	failed     int
+	totals     RankingMetrics
+	noveltySum float64
+	noveltyN   int
+	diversity  float64
+	diverseN   int
+	seen       map[uuid.UUID]bool
+}
+
+// NewStrategyEvaluator creates an evaluator for one strategy. The catalog maps every active
+// product to its category and is used for coverage and diversity.
+func NewStrategyEvaluator(strategy string, split *EvaluationSplit, catalog map[uuid.UUID]int, k int) *StrategyEvaluator {
+	return &StrategyEvaluator{
+		strategy: strategy,
+		k:        k,
+		split:    split,
+		catalog:  catalog,
+		seen:     make(map[uuid.UUID]bool),
+	}
+}
+
+// Add records the products recommended to a customer, best first
+func (e *StrategyEvaluator) Add(customerID uuid.UUID, recommended []uuid.UUID) {
+	if len(recommended) > e.k {
+		recommended = recommended[:e.k]
+	}
+
+	metrics := ComputeRankingMetrics(recommended, e.split.Relevant[customerID], e.k)
+	e.totals.Precision += metrics.Precision
+	e.totals.Recall += metrics.Recall
+	e.totals.NDCG += metrics.NDCG
+	e.totals.AveragePrecision += metrics.AveragePrecision
+	e.evaluated++
+
+	for _, productID := range recommended {
+		e.seen[productID] = true
+		// Self-information of the product's add-one smoothed share of train buyers
+		share := float64(e.split.Popularity[productID]+1) / float64(e.split.TrainCustomers+1)
+		e.noveltySum += -math.Log2(share)
+		e.noveltyN++
+	}
+
+	if len(recommended) >= 2 {
+		e.diversity += IntraListDiversity(recommended, e.catalog)
+		e.diverseN++
+	}
+}
+
+// AddFailure records a customer the strategy could not produce a list for
+func (e *StrategyEvaluator) AddFailure() {
+	e.failed++
+}
+
+// Result returns the averaged metrics. Failed customers count as empty lists with zero relevance,
+// so a strategy cannot improve its accuracy by failing on hard customers.
+func (e *StrategyEvaluator) Result() dto.StrategyEvaluation {
+	result := dto.StrategyEvaluation{
+		Strategy:  e.strategy,
+		Evaluated: e.evaluated,
+		Failed:    e.failed,
+	}
+
+	if attempted := e.evaluated + e.failed; attempted > 0 {
+		n := float64(attempted)
+		result.PrecisionAtK = e.totals.Precision / n
+		result.RecallAtK = e.totals.Recall / n
+		result.NDCG = e.totals.NDCG / n
+		result.MAP = e.totals.AveragePrecision / n
+	}
+
+	if len(e.catalog) > 0 {
+		var covered int
+		for productID := range e.seen {
+			if _, active := e.catalog[productID]; active {
+				covered++
+			}
+		}
+		result.Coverage = float64(covered) / float64(len(e.catalog))
+	}
+	if e.noveltyN > 0 {
+		result.Novelty = e.noveltySum / float64(e.noveltyN)
+	}
+	if e.diverseN > 0 {
+		result.Diversity = e.diversity / float64(e.diverseN)
+	}
+
+	return result
+}
+
+// FormatEvaluationMarkdown renders an evaluation report as a Markdown summary table
+func FormatEvaluationMarkdown(report *dto.EvaluationReport) string {
+	var builder strings.Builder
+
+	builder.WriteString("# Recommendation Offline Evaluation\n\n")
+	builder.WriteString(fmt.Sprintf("- Generated at: %s\n", report.GeneratedAt.Format(time.RFC3339)))
+	builder.WriteString(fmt.Sprintf("- Cutoff: %s (test horizon %d days)\n", report.Cutoff.Format(time.RFC3339), report.HorizonDays))
+	builder.WriteString(fmt.Sprintf("- K: %d\n", report.K))
+	builder.WriteString(fmt.Sprintf("- LLM mode: %s\n", report.LLMMode))
+	builder.WriteString(fmt.Sprintf("- Customers: %d\n", report.Customers))
+	builder.WriteString(fmt.Sprintf("- Order lines: %d train / %d test\n", report.TrainOrderLines, report.TestOrderLines))
+	builder.WriteString(fmt.Sprintf("- Active catalog: %d products\n\n", report.CatalogSize))
+
+	k := report.K
+	builder.WriteString(fmt.Sprintf("| Strategy | Evaluated | Failed | Precision@%d | Recall@%d | NDCG@%d | MAP@%d | Coverage | Novelty | Diversity |\n", k, k, k, k))
+	builder.WriteString("|---|---:|---:|---:|---:|---:|---:|---:|---:|---:|\n")
+	for _, strategy := range report.Strategies {
+		builder.WriteString(fmt.Sprintf("| %s | %d | %d | %.4f | %.4f | %.4f | %.4f | %.4f | %.2f | %.4f |\n",
+			strategy.Strategy,
+			strategy.Evaluated,
+			strategy.Failed,
+			strategy.PrecisionAtK,
+			strategy.RecallAtK,
+			strategy.NDCG,
+			strategy.MAP,
+			strategy.Coverage,
+			strategy.Novelty,
+			strategy.Diversity,
+		))
+	}
+
+	return builder.String()
+}
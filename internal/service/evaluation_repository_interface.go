@@ -0,0 +1,19 @@
+package service
+
+import (
+	"context"
+	"ec-recommend/internal/dto"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EvaluationRepositoryInterface defines queries for offline evaluation of recommendation strategies
+// This interface is defined in the service package as it is consumed by services
+type EvaluationRepositoryInterface interface {
+	// GetOrderLines returns order items ordered in [since, until), oldest first
+	GetOrderLines(ctx context.Context, since, until time.Time) ([]dto.EvaluationOrderLine, error)
+
+	// GetCatalogCategories returns the category of every active product
+	GetCatalogCategories(ctx context.Context) (map[uuid.UUID]int, error)
+}
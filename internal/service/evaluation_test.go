@@ -0,0 +1,233 @@
+package service
+
+import (
+	"context"
+	"ec-recommend/internal/dto"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// fakeCatalogRepository serves product lookups and customers for the snapshot repository;
+// every other method is left to the nil embedded interface
+type fakeCatalogRepository struct {
+	RecommendationRepositoryInterface
+	products map[uuid.UUID]dto.ProductRecommendation
+}
+
+func (f *fakeCatalogRepository) GetCustomerByID(ctx context.Context, customerID uuid.UUID) (*dto.CustomerProfile, error) {
+	return &dto.CustomerProfile{CustomerID: customerID, TotalSpent: 99999, OrderCount: 99}, nil
+}
+
+func (f *fakeCatalogRepository) GetProductsByIDs(ctx context.Context, productIDs []uuid.UUID) ([]dto.ProductRecommendation, error) {
+	var products []dto.ProductRecommendation
+	for _, productID := range productIDs {
+		if product, ok := f.products[productID]; ok {
+			products = append(products, product)
+		}
+	}
+	// Reverse to check that the snapshot restores the requested order
+	for i, j := 0, len(products)-1; i < j; i, j = i+1, j-1 {
+		products[i], products[j] = products[j], products[i]
+	}
+	return products, nil
+}
+
+func TestEvaluationSplit(t *testing.T) {
+	cutoff := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	before := cutoff.AddDate(0, 0, -10)
+	after := cutoff.AddDate(0, 0, 5)
+	alice, bob, carol := uuid.New(), uuid.New(), uuid.New()
+	owned, fresh, cancelled := uuid.New(), uuid.New(), uuid.New()
+
+	lines := []dto.EvaluationOrderLine{
+		{CustomerID: alice, ProductID: owned, Status: "delivered", OrderedAt: before},
+		{CustomerID: bob, ProductID: owned, Status: "delivered", OrderedAt: before},
+		{CustomerID: alice, ProductID: owned, Status: "shipped", OrderedAt: after}, // Repeat purchase
+		{CustomerID: alice, ProductID: fresh, Status: "processing", OrderedAt: after},
+		{CustomerID: alice, ProductID: cancelled, Status: "cancelled", OrderedAt: after},
+		{CustomerID: carol, ProductID: fresh, Status: "delivered", OrderedAt: after}, // No train history
+	}
+
+	split := NewEvaluationSplit(lines, cutoff, 0)
+
+	if len(split.Train) != 2 {
+		t.Errorf("Expected 2 train lines, got %d", len(split.Train))
+	}
+	if split.TestLines != 3 {
+		t.Errorf("Expected 3 test lines, got %d", split.TestLines)
+	}
+	if len(split.Customers) != 1 || split.Customers[0] != alice {
+		t.Fatalf("Expected only alice to be evaluated, got %v", split.Customers)
+	}
+	relevant := split.Relevant[alice]
+	if len(relevant) != 1 || !relevant[fresh] {
+		t.Errorf("Expected only the newly bought product to be relevant, got %v", relevant)
+	}
+	if split.Popularity[owned] != 2 || split.TrainCustomers != 2 {
+		t.Errorf("Expected 2 train buyers of 2 customers, got %d of %d", split.Popularity[owned], split.TrainCustomers)
+	}
+
+	t.Run("popularity baseline skips owned and inactive products", func(t *testing.T) {
+		other := uuid.New()
+		split.Popularity[other] = 1
+		catalog := map[uuid.UUID]int{owned: 1, fresh: 1}
+
+		if recs := split.PopularRecommendations(alice, catalog, 10); len(recs) != 0 {
+			t.Errorf("Expected no recommendations, got %v", recs)
+		}
+		catalog[other] = 1
+		if recs := split.PopularRecommendations(carol, catalog, 10); len(recs) != 2 || recs[0] != owned {
+			t.Errorf("Expected the most bought product first, got %v", recs)
+		}
+	})
+}
+
+func TestSnapshotRecommendationRepository(t *testing.T) {
+	ctx := context.Background()
+	cutoff := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	day := func(n int) time.Time { return cutoff.AddDate(0, 0, n) }
+
+	alice, bob, carol := uuid.New(), uuid.New(), uuid.New()
+	p1, p2, p3, p4 := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	order := uuid.New()
+
+	lines := []dto.EvaluationOrderLine{
+		{OrderID: order, CustomerID: alice, ProductID: p1, CategoryID: 1, Status: "delivered", UnitPrice: 1000, Quantity: 1, OrderedAt: day(-20)},
+		{OrderID: order, CustomerID: alice, ProductID: p2, CategoryID: 1, Status: "delivered", UnitPrice: 500, Quantity: 2, OrderedAt: day(-20)},
+		{CustomerID: bob, ProductID: p1, CategoryID: 1, Status: "delivered", OrderedAt: day(-15)},
+		{CustomerID: bob, ProductID: p2, CategoryID: 1, Status: "delivered", OrderedAt: day(-15)},
+		{CustomerID: bob, ProductID: p3, CategoryID: 2, Status: "delivered", OrderedAt: day(-15)},
+		{CustomerID: carol, ProductID: p1, CategoryID: 1, Status: "delivered", OrderedAt: day(-5)},
+		{CustomerID: carol, ProductID: p4, CategoryID: 2, Status: "delivered", OrderedAt: day(3)}, // After the cutoff
+	}
+	split := NewEvaluationSplit(lines, cutoff, 0)
+
+	base := &fakeCatalogRepository{products: map[uuid.UUID]dto.ProductRecommendation{
+		p1: {ProductID: p1, CategoryID: 1},
+		p2: {ProductID: p2, CategoryID: 1},
+		p3: {ProductID: p3, CategoryID: 2},
+		p4: {ProductID: p4, CategoryID: 2},
+	}}
+	repo := NewSnapshotRecommendationRepository(base, split)
+
+	t.Run("customer totals come from train orders", func(t *testing.T) {
+		profile, err := repo.GetCustomerByID(ctx, alice)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if profile.TotalSpent != 2000 || profile.OrderCount != 1 {
+			t.Errorf("Expected 2000 spent over 1 order, got %.0f over %d", profile.TotalSpent, profile.OrderCount)
+		}
+	})
+
+	t.Run("purchase history excludes the test window", func(t *testing.T) {
+		history, _ := repo.GetCustomerPurchaseHistory(ctx, carol, 50)
+		if len(history) != 1 || history[0].ProductID != p1 {
+			t.Errorf("Expected only the pre-cutoff purchase, got %v", history)
+		}
+	})
+
+	t.Run("trending ranks by train buyers", func(t *testing.T) {
+		trending, _ := repo.GetTrendingProducts(ctx, nil, 2)
+		if len(trending) != 2 || trending[0].ProductID != p1 || trending[1].ProductID != p2 {
+			t.Errorf("Expected p1 then p2, got %v", trending)
+		}
+
+		category := 2
+		trending, _ = repo.GetTrendingProducts(ctx, &category, 10)
+		if len(trending) != 1 || trending[0].ProductID != p3 {
+			t.Errorf("Expected only p3 in category 2 before the cutoff, got %v", trending)
+		}
+	})
+
+	t.Run("collaborative queries use train overlap", func(t *testing.T) {
+		similar, _ := repo.GetCustomersWithSimilarPurchases(ctx, alice, 20)
+		if len(similar) != 1 || similar[0] != bob {
+			t.Fatalf("Expected bob as the only customer sharing two products, got %v", similar)
+		}
+
+		products, _ := repo.GetPopularProductsAmongSimilarCustomers(ctx, similar, []uuid.UUID{p1, p2}, 10)
+		if len(products) != 1 || products[0].ProductID != p3 {
+			t.Errorf("Expected p3, got %v", products)
+		}
+	})
+}
+
+func TestComputeRankingMetrics(t *testing.T) {
+	a, b, c, d := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	relevant := map[uuid.UUID]bool{a: true, c: true}
+
+	metrics := ComputeRankingMetrics([]uuid.UUID{a, b, c, d}, relevant, 4)
+
+	if metrics.Precision != 0.5 {
+		t.Errorf("Expected precision 0.5, got %f", metrics.Precision)
+	}
+	if metrics.Recall != 1 {
+		t.Errorf("Expected recall 1, got %f", metrics.Recall)
+	}
+	expectedNDCG := (1 + 1/math.Log2(4)) / (1 + 1/math.Log2(3))
+	if math.Abs(metrics.NDCG-expectedNDCG) > 1e-9 {
+		t.Errorf("Expected NDCG %f, got %f", expectedNDCG, metrics.NDCG)
+	}
+	expectedAP := (1.0 + 2.0/3.0) / 2
+	if math.Abs(metrics.AveragePrecision-expectedAP) > 1e-9 {
+		t.Errorf("Expected average precision %f, got %f", expectedAP, metrics.AveragePrecision)
+	}
+
+	t.Run("perfect list scores 1 when more products are relevant than k", func(t *testing.T) {
+		metrics := ComputeRankingMetrics([]uuid.UUID{a, c}, map[uuid.UUID]bool{a: true, c: true, d: true}, 2)
+		if metrics.NDCG != 1 || metrics.AveragePrecision != 1 {
+			t.Errorf("Expected NDCG and AP of 1, got %f and %f", metrics.NDCG, metrics.AveragePrecision)
+		}
+	})
+}
+
+func TestStrategyEvaluator(t *testing.T) {
+	cutoff := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	customer := uuid.New()
+	popular, niche, bought := uuid.New(), uuid.New(), uuid.New()
+
+	var lines []dto.EvaluationOrderLine
+	for i := 0; i < 3; i++ {
+		lines = append(lines, dto.EvaluationOrderLine{CustomerID: uuid.New(), ProductID: popular, Status: "delivered", OrderedAt: cutoff.AddDate(0, 0, -1)})
+	}
+	lines = append(lines,
+		dto.EvaluationOrderLine{CustomerID: customer, ProductID: uuid.New(), Status: "delivered", OrderedAt: cutoff.AddDate(0, 0, -1)},
+		dto.EvaluationOrderLine{CustomerID: customer, ProductID: bought, Status: "delivered", OrderedAt: cutoff.AddDate(0, 0, 1)},
+	)
+	split := NewEvaluationSplit(lines, cutoff, 0)
+	catalog := map[uuid.UUID]int{popular: 1, niche: 2, bought: 1, uuid.New(): 3}
+
+	evaluator := NewStrategyEvaluator("test", split, catalog, 2)
+	evaluator.Add(customer, []uuid.UUID{bought, popular, niche}) // Trimmed to k
+	evaluator.AddFailure()
+	result := evaluator.Result()
+
+	if result.Evaluated != 1 || result.Failed != 1 {
+		t.Errorf("Expected 1 evaluated and 1 failed, got %d and %d", result.Evaluated, result.Failed)
+	}
+	// The failed customer counts as zero relevance
+	if result.PrecisionAtK != 0.25 || result.RecallAtK != 0.5 {
+		t.Errorf("Expected precision 0.25 and recall 0.5, got %f and %f", result.PrecisionAtK, result.RecallAtK)
+	}
+	if result.Coverage != 0.5 {
+		t.Errorf("Expected coverage 0.5, got %f", result.Coverage)
+	}
+	if result.Diversity != 0 {
+		t.Errorf("Expected no diversity for two products in one category, got %f", result.Diversity)
+	}
+	// bought: -log2(1/5), popular: -log2(4/5)
+	expectedNovelty := (math.Log2(5) + math.Log2(5.0/4.0)) / 2
+	if math.Abs(result.Novelty-expectedNovelty) > 1e-9 {
+		t.Errorf("Expected novelty %f, got %f", expectedNovelty, result.Novelty)
+	}
+
+	report := &dto.EvaluationReport{K: 2, Strategies: []dto.StrategyEvaluation{result}}
+	if markdown := FormatEvaluationMarkdown(report); !strings.Contains(markdown, "| test | 1 | 1 | 0.2500 | 0.5000 |") {
+		t.Errorf("Expected the strategy row in the Markdown report, got:\n%s", markdown)
+	}
+}
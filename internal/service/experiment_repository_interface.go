@@ -0,0 +1,16 @@
+package service
+
+import (
+	"context"
+	"ec-recommend/internal/dto"
+)
+
+// ExperimentRepositoryInterface defines persistence operations for A/B experiments
+// This interface is defined in the service package as it is consumed by services
+type ExperimentRepositoryInterface interface {
+	// ListExperiments returns experiments defined in the database
+	ListExperiments(ctx context.Context) ([]dto.Experiment, error)
+
+	// GetExperimentVariantCounts aggregates recommendation logs stamped with the experiment per variant
+	GetExperimentVariantCounts(ctx context.Context, experimentID string) ([]dto.ExperimentVariantCounts, error)
+}
@@ -0,0 +1,402 @@
+package service
+
+import (
+	"context"
+	"ec-recommend/internal/dto"
+	"ec-recommend/internal/types"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// experimentConfidenceZ is the normal quantile for 95% confidence intervals
+const experimentConfidenceZ = 1.959964
+
+// experimentBuckets is the resolution of deterministic traffic splitting
+const experimentBuckets = 10000
+
+// Identifier limits keep the stamped algorithm version within recommendation_logs.algorithm_version
+// (VARCHAR(100)): up to 30 characters of algorithm version, ";exp=", the experiment ID, "/" and the variant.
+const (
+	maxExperimentIDLength  = 40
+	maxVariantNameLength   = 24
+	maxAlgorithmVersionLen = 100
+)
+
+// experimentCacheTTL is how long the merged experiment list is reused for assignments
+const experimentCacheTTL = 30 * time.Second
+
+// ExperimentService assigns customers to A/B experiment variants and reports their results.
+// Experiments come from the tuning file and the experiments table; database definitions
+// override file definitions with the same ID.
+type ExperimentService struct {
+	repo       ExperimentRepositoryInterface
+	configured []dto.Experiment
+
+	mu       sync.Mutex
+	cached   []dto.Experiment
+	cachedAt time.Time
+}
+
+// NewExperimentService creates a new experiment service instance
+func NewExperimentService(repo ExperimentRepositoryInterface, configured []dto.Experiment) *ExperimentService {
+	experiments := make([]dto.Experiment, len(configured))
+	for i, experiment := range configured {
+		experiment.Source = dto.ExperimentSourceConfig
+		experiments[i] = experiment
+	}
+
+	return &ExperimentService{
+		repo:       repo,
+		configured: experiments,
+	}
+}
+
+// ValidateExperiment checks that an experiment can be assigned and stamped into logs
+func ValidateExperiment(experiment *dto.Experiment) error {
+	if experiment.ID == "" {
+		return fmt.Errorf("experiment id is required")
+	}
+	if len(experiment.ID) > maxExperimentIDLength {
+		return fmt.Errorf("experiment id %q must be at most %d characters", experiment.ID, maxExperimentIDLength)
+	}
+	if strings.ContainsAny(experiment.ID, ";/= ") {
+		return fmt.Errorf("experiment id %q must not contain ';', '/', '=' or spaces", experiment.ID)
+	}
+	if experiment.Service != "" && experiment.Service != BlendServiceV1 && experiment.Service != BlendServiceV2 {
+		return fmt.Errorf("experiment %s has unsupported service: %s", experiment.ID, experiment.Service)
+	}
+	if len(experiment.Variants) == 0 {
+		return fmt.Errorf("experiment %s must have at least one variant", experiment.ID)
+	}
+
+	names := make(map[string]bool, len(experiment.Variants))
+	for _, variant := range experiment.Variants {
+		if variant.Name == "" || strings.ContainsAny(variant.Name, ";/= ") {
+			return fmt.Errorf("experiment %s has invalid variant name %q", experiment.ID, variant.Name)
+		}
+		if len(variant.Name) > maxVariantNameLength {
+			return fmt.Errorf("experiment %s variant name %q must be at most %d characters", experiment.ID, variant.Name, maxVariantNameLength)
+		}
+		if names[variant.Name] {
+			return fmt.Errorf("experiment %s has duplicate variant %s", experiment.ID, variant.Name)
+		}
+		names[variant.Name] = true
+
+		if variant.Weight < 0 {
+			return fmt.Errorf("experiment %s variant %s has negative weight", experiment.ID, variant.Name)
+		}
+		if variant.FusionMethod != "" && !IsValidFusionMethod(variant.FusionMethod) {
+			return fmt.Errorf("experiment %s variant %s has unsupported fusion method: %s", experiment.ID, variant.Name, variant.FusionMethod)
+		}
+		for strategy, weight := range variant.StrategyWeights {
+			if weight < 0 {
+				return fmt.Errorf("experiment %s variant %s has negative weight for strategy %s", experiment.ID, variant.Name, strategy)
+			}
+		}
+	}
+
+	return nil
+}
+
+// ListExperiments returns all configured and stored experiments ordered by ID
+func (es *ExperimentService) ListExperiments(ctx context.Context) (*dto.ExperimentListResponse, error) {
+	experiments, err := es.experiments(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.ExperimentListResponse{
+		Experiments: experiments,
+		Total:       len(experiments),
+	}, nil
+}
+
+// GetExperiment returns an experiment by ID, or nil if it does not exist
+func (es *ExperimentService) GetExperiment(ctx context.Context, experimentID string) (*dto.Experiment, error) {
+	experiments, err := es.experiments(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range experiments {
+		if experiments[i].ID == experimentID {
+			return &experiments[i], nil
+		}
+	}
+
+	return nil, nil
+}
+
+// Assign returns the variant of the first running experiment that matches the request.
+// A request takes part in at most one experiment so variant overrides never conflict.
+//
+// Parameters:
+//   - serviceName: BlendServiceV1 or BlendServiceV2
+//   - contextType: request context type
+//   - customerID: customer being served; the same customer always gets the same variant
+//
+// Returns nil when no experiment applies.
+func (es *ExperimentService) Assign(ctx context.Context, serviceName, contextType string, customerID uuid.UUID) (*dto.ExperimentAssignment, error) {
+	experiments, err := es.cachedExperiments(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	for _, experiment := range experiments {
+		if !experimentApplies(experiment, serviceName, contextType, now) {
+			continue
+		}
+
+		variant := experiment.Variants[assignVariant(experiment, customerID)]
+		return &dto.ExperimentAssignment{
+			ExperimentID: experiment.ID,
+			Variant:      variant.Name,
+			Overrides:    variant,
+		}, nil
+	}
+
+	return nil, nil
+}
+
+// GetExperimentResults computes CTR and CVR per variant with Wilson score intervals.
+// Returns nil if the experiment does not exist.
+func (es *ExperimentService) GetExperimentResults(ctx context.Context, experimentID string) (*dto.ExperimentResults, error) {
+	experiment, err := es.GetExperiment(ctx, experimentID)
+	if err != nil {
+		return nil, err
+	}
+	if experiment == nil {
+		return nil, nil
+	}
+
+	counts, err := es.repo.GetExperimentVariantCounts(ctx, experimentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get experiment counts: %w", err)
+	}
+
+	countsByVariant := make(map[string]dto.ExperimentVariantCounts, len(counts))
+	for _, c := range counts {
+		countsByVariant[c.Variant] = c
+	}
+
+	shares := variantShares(*experiment)
+	results := &dto.ExperimentResults{
+		Experiment:      *experiment,
+		Variants:        make([]dto.ExperimentVariantResult, 0, len(experiment.Variants)),
+		ConfidenceLevel: 0.95,
+		GeneratedAt:     time.Now(),
+	}
+
+	for i, variant := range experiment.Variants {
+		c := countsByVariant[variant.Name]
+		c.Variant = variant.Name
+
+		result := dto.ExperimentVariantResult{
+			ExperimentVariantCounts: c,
+			TrafficWeight:           shares[i],
+		}
+		result.CTR, result.CTRInterval = wilsonInterval(c.Clicks, c.Impressions, experimentConfidenceZ)
+		result.CVR, result.CVRInterval = wilsonInterval(c.Conversions, c.Impressions, experimentConfidenceZ)
+
+		if i > 0 {
+			control := results.Variants[0]
+			result.CTRLift = relativeLift(result.CTR, control.CTR)
+			result.CVRLift = relativeLift(result.CVR, control.CVR)
+		}
+
+		results.Variants = append(results.Variants, result)
+	}
+
+	return results, nil
+}
+
+// cachedExperiments returns the merged experiment list, reloading it at most once per cache TTL.
+// A failed reload keeps serving the previous list and is retried after the next TTL.
+func (es *ExperimentService) cachedExperiments(ctx context.Context) ([]dto.Experiment, error) {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+
+	if !es.cachedAt.IsZero() && time.Since(es.cachedAt) < experimentCacheTTL {
+		return es.cached, nil
+	}
+
+	experiments, err := es.experiments(ctx)
+	es.cachedAt = time.Now()
+	if err != nil {
+		if es.cached != nil {
+			log.Printf("Warning: serving cached experiments: %v", err)
+			return es.cached, nil
+		}
+		return nil, err
+	}
+	es.cached = experiments
+
+	return experiments, nil
+}
+
+// experiments merges configured and stored experiments, skipping invalid database rows
+func (es *ExperimentService) experiments(ctx context.Context) ([]dto.Experiment, error) {
+	byID := make(map[string]dto.Experiment, len(es.configured))
+	for _, experiment := range es.configured {
+		byID[experiment.ID] = experiment
+	}
+
+	stored, err := es.repo.ListExperiments(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list experiments: %w", err)
+	}
+	for _, experiment := range stored {
+		if err := ValidateExperiment(&experiment); err != nil {
+			log.Printf("Warning: skipping invalid experiment: %v", err)
+			continue
+		}
+		experiment.Source = dto.ExperimentSourceDatabase
+		byID[experiment.ID] = experiment
+	}
+
+	experiments := make([]dto.Experiment, 0, len(byID))
+	for _, experiment := range byID {
+		experiments = append(experiments, experiment)
+	}
+	sort.Slice(experiments, func(i, j int) bool {
+		return experiments[i].ID < experiments[j].ID
+	})
+
+	return experiments, nil
+}
+
+// experimentApplies reports whether an experiment is running for the service and context
+func experimentApplies(experiment dto.Experiment, serviceName, contextType string, now time.Time) bool {
+	if !experiment.IsActive {
+		return false
+	}
+	if experiment.Service != "" && experiment.Service != serviceName {
+		return false
+	}
+	if experiment.StartsAt != nil && now.Before(*experiment.StartsAt) {
+		return false
+	}
+	if experiment.EndsAt != nil && !now.Before(*experiment.EndsAt) {
+		return false
+	}
+	if len(experiment.ContextTypes) > 0 && !contains(experiment.ContextTypes, contextType) {
+		return false
+	}
+
+	return true
+}
+
+// assignVariant maps a customer to a variant index using a hash of the experiment and customer IDs.
+// Hashing the experiment ID too keeps assignments independent across experiments.
+func assignVariant(experiment dto.Experiment, customerID uuid.UUID) int {
+	h := fnv.New64a()
+	h.Write([]byte(experiment.ID + ":" + customerID.String()))
+	point := float64(h.Sum64()%experimentBuckets) / experimentBuckets
+
+	var cumulative float64
+	for i, share := range variantShares(experiment) {
+		cumulative += share
+		if point < cumulative {
+			return i
+		}
+	}
+
+	return len(experiment.Variants) - 1
+}
+
+// variantShares normalizes variant weights to traffic shares; all-zero weights split evenly
+func variantShares(experiment dto.Experiment) []float64 {
+	shares := make([]float64, len(experiment.Variants))
+	var total float64
+	for _, variant := range experiment.Variants {
+		total += variant.Weight
+	}
+
+	for i, variant := range experiment.Variants {
+		if total > 0 {
+			shares[i] = variant.Weight / total
+		} else {
+			shares[i] = 1 / float64(len(experiment.Variants))
+		}
+	}
+
+	return shares
+}
+
+// StampAlgorithmVersion appends the experiment assignment to an algorithm version,
+// e.g. "hybrid_v1.0;exp=homepage_blend/treatment"
+func StampAlgorithmVersion(algorithmVersion string, assignment *dto.ExperimentAssignment) string {
+	if assignment == nil {
+		return algorithmVersion
+	}
+
+	stamped := fmt.Sprintf("%s;exp=%s/%s", algorithmVersion, assignment.ExperimentID, assignment.Variant)
+	if len(stamped) > maxAlgorithmVersionLen {
+		log.Printf("Warning: stamped algorithm version exceeds %d characters: %s", maxAlgorithmVersionLen, stamped)
+	}
+
+	return stamped
+}
+
+// wilsonInterval returns the observed rate and its Wilson score interval
+func wilsonInterval(successes, trials int, z float64) (float64, dto.ConfidenceInterval) {
+	if trials == 0 {
+		return 0, dto.ConfidenceInterval{}
+	}
+
+	n := float64(trials)
+	p := float64(successes) / n
+	denominator := 1 + z*z/n
+	center := (p + z*z/(2*n)) / denominator
+	margin := z * math.Sqrt(p*(1-p)/n+z*z/(4*n*n)) / denominator
+
+	return p, dto.ConfidenceInterval{
+		Lower: math.Max(0, center-margin),
+		Upper: math.Min(1, center+margin),
+	}
+}
+
+// relativeLift returns (rate - control) / control, or nil when the control rate is zero
+func relativeLift(rate, control float64) *float64 {
+	if control == 0 {
+		return nil
+	}
+
+	lift := (rate - control) / control
+	return &lift
+}
+
+type experimentContextKey struct{}
+
+// withExperimentAssignment attaches an assignment so nested helpers can honour variant overrides
+func withExperimentAssignment(ctx context.Context, assignment *dto.ExperimentAssignment) context.Context {
+	if assignment == nil {
+		return ctx
+	}
+
+	return context.WithValue(ctx, experimentContextKey{}, assignment)
+}
+
+// experimentAssignmentFromContext returns the assignment attached to the context, if any
+func experimentAssignmentFromContext(ctx context.Context) *dto.ExperimentAssignment {
+	assignment, _ := ctx.Value(experimentContextKey{}).(*dto.ExperimentAssignment)
+	return assignment
+}
+
+// generateAIResponse calls the chat service with the model of the request's experiment variant, if any
+func generateAIResponse(ctx context.Context, chatService ChatServiceInterface, prompt string) (*types.AIResponse, error) {
+	if assignment := experimentAssignmentFromContext(ctx); assignment != nil && assignment.Overrides.ModelID != "" {
+		return chatService.GenerateResponseWithModel(ctx, prompt, assignment.Overrides.ModelID)
+	}
+
+	return chatService.GenerateResponse(ctx, prompt)
+}
@@ -0,0 +1,174 @@
+package service
+
+import (
+	"context"
+	"ec-recommend/internal/dto"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// fakeExperimentRepository returns fixed experiments and counts
+type fakeExperimentRepository struct {
+	experiments []dto.Experiment
+	counts      []dto.ExperimentVariantCounts
+	lists       int
+}
+
+func (f *fakeExperimentRepository) ListExperiments(ctx context.Context) ([]dto.Experiment, error) {
+	f.lists++
+	return f.experiments, nil
+}
+
+func (f *fakeExperimentRepository) GetExperimentVariantCounts(ctx context.Context, experimentID string) ([]dto.ExperimentVariantCounts, error) {
+	return f.counts, nil
+}
+
+func TestExperimentService(t *testing.T) {
+	ctx := context.Background()
+	experiment := dto.Experiment{
+		ID:           "homepage_blend",
+		IsActive:     true,
+		Service:      BlendServiceV2,
+		ContextTypes: []string{"homepage"},
+		Variants: []dto.ExperimentVariant{
+			{Name: "control", Weight: 80},
+			{Name: "treatment", Weight: 20, RecommendationType: "collaborative"},
+		},
+	}
+
+	t.Run("assignment is deterministic and follows weights", func(t *testing.T) {
+		es := NewExperimentService(&fakeExperimentRepository{}, []dto.Experiment{experiment})
+
+		treatment := 0
+		for i := 0; i < 5000; i++ {
+			customerID := uuid.New()
+			first, err := es.Assign(ctx, BlendServiceV2, "homepage", customerID)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			second, _ := es.Assign(ctx, BlendServiceV2, "homepage", customerID)
+			if first.Variant != second.Variant {
+				t.Fatalf("Expected the same variant for the same customer, got %s and %s", first.Variant, second.Variant)
+			}
+			if first.Variant == "treatment" {
+				treatment++
+			}
+		}
+
+		if share := float64(treatment) / 5000; math.Abs(share-0.2) > 0.03 {
+			t.Errorf("Expected about 20%% of customers in treatment, got %.3f", share)
+		}
+	})
+
+	t.Run("non-matching requests are not assigned", func(t *testing.T) {
+		es := NewExperimentService(&fakeExperimentRepository{}, []dto.Experiment{experiment})
+
+		for _, tc := range []struct{ service, contextType string }{
+			{BlendServiceV1, "homepage"},
+			{BlendServiceV2, "cart"},
+		} {
+			assignment, err := es.Assign(ctx, tc.service, tc.contextType, uuid.New())
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if assignment != nil {
+				t.Errorf("Expected no assignment for %s/%s, got %s", tc.service, tc.contextType, assignment.ExperimentID)
+			}
+		}
+	})
+
+	t.Run("database definitions override configured ones", func(t *testing.T) {
+		stored := experiment
+		stored.IsActive = false
+		es := NewExperimentService(&fakeExperimentRepository{experiments: []dto.Experiment{stored}}, []dto.Experiment{experiment})
+
+		found, err := es.GetExperiment(ctx, experiment.ID)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if found.Source != dto.ExperimentSourceDatabase || found.IsActive {
+			t.Errorf("Expected inactive database experiment, got source %s active %v", found.Source, found.IsActive)
+		}
+	})
+
+	t.Run("results include rates and intervals", func(t *testing.T) {
+		repo := &fakeExperimentRepository{counts: []dto.ExperimentVariantCounts{
+			{Variant: "control", Impressions: 1000, Clicks: 100, Conversions: 10},
+			{Variant: "treatment", Impressions: 1000, Clicks: 150, Conversions: 20},
+		}}
+		es := NewExperimentService(repo, []dto.Experiment{experiment})
+
+		results, err := es.GetExperimentResults(ctx, experiment.ID)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if len(results.Variants) != 2 {
+			t.Fatalf("Expected 2 variants, got %d", len(results.Variants))
+		}
+
+		control, treatment := results.Variants[0], results.Variants[1]
+		if control.CTR != 0.1 || treatment.CVR != 0.02 {
+			t.Errorf("Expected control CTR 0.1 and treatment CVR 0.02, got %f and %f", control.CTR, treatment.CVR)
+		}
+		if control.CTRInterval.Lower >= control.CTR || control.CTRInterval.Upper <= control.CTR {
+			t.Errorf("Expected interval around CTR, got [%f, %f]", control.CTRInterval.Lower, control.CTRInterval.Upper)
+		}
+		if treatment.CTRLift == nil || math.Abs(*treatment.CTRLift-0.5) > 1e-9 {
+			t.Errorf("Expected CTR lift of 0.5, got %v", treatment.CTRLift)
+		}
+
+		missing, err := es.GetExperimentResults(ctx, "unknown")
+		if err != nil || missing != nil {
+			t.Errorf("Expected nil results for unknown experiment, got %v, %v", missing, err)
+		}
+	})
+
+	t.Run("algorithm version is stamped with the variant", func(t *testing.T) {
+		stamped := StampAlgorithmVersion("hybrid_v1.0", &dto.ExperimentAssignment{ExperimentID: "homepage_blend", Variant: "treatment"})
+		if stamped != "hybrid_v1.0;exp=homepage_blend/treatment" {
+			t.Errorf("Expected stamped version, got %s", stamped)
+		}
+		if StampAlgorithmVersion("hybrid_v1.0", nil) != "hybrid_v1.0" {
+			t.Error("Expected unchanged version without assignment")
+		}
+	})
+
+	t.Run("assignments reuse the cached experiment list", func(t *testing.T) {
+		repo := &fakeExperimentRepository{}
+		es := NewExperimentService(repo, []dto.Experiment{experiment})
+
+		for i := 0; i < 10; i++ {
+			if _, err := es.Assign(ctx, BlendServiceV2, "homepage", uuid.New()); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+		}
+		if repo.lists != 1 {
+			t.Errorf("Expected 1 experiment list query, got %d", repo.lists)
+		}
+	})
+
+	t.Run("identifiers are limited to fit the stamped algorithm version", func(t *testing.T) {
+		long := experiment
+		long.ID = strings.Repeat("e", maxExperimentIDLength+1)
+		if err := ValidateExperiment(&long); err == nil {
+			t.Error("Expected error for an overlong experiment id")
+		}
+
+		long = experiment
+		long.Variants = []dto.ExperimentVariant{{Name: strings.Repeat("v", maxVariantNameLength+1)}}
+		if err := ValidateExperiment(&long); err == nil {
+			t.Error("Expected error for an overlong variant name")
+		}
+
+		stamped := StampAlgorithmVersion("content_based_v1.0", &dto.ExperimentAssignment{
+			ExperimentID: strings.Repeat("e", maxExperimentIDLength),
+			Variant:      strings.Repeat("v", maxVariantNameLength),
+		})
+		if len(stamped) > maxAlgorithmVersionLen {
+			t.Errorf("Expected stamped version within %d characters, got %d", maxAlgorithmVersionLen, len(stamped))
+		}
+	})
+}
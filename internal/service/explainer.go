@@ -0,0 +1,214 @@
+package service
+
+import (
+	"ec-recommend/internal/dto"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// Explanation factors attributed by the deterministic explainer
+const (
+	ExplanationFactorCategoryAffinity = "category_affinity"
+	ExplanationFactorBrandMatch       = "brand_match"
+	ExplanationFactorPriceFit         = "price_fit"
+	ExplanationFactorCollaborative    = "collaborative"
+	ExplanationFactorSemantic         = "semantic"
+	ExplanationFactorPopularity       = "popularity"
+)
+
+// ExplanationFactorNames lists the attributed factors in display order for ties
+var ExplanationFactorNames = []string{
+	ExplanationFactorCategoryAffinity,
+	ExplanationFactorBrandMatch,
+	ExplanationFactorPriceFit,
+	ExplanationFactorCollaborative,
+	ExplanationFactorSemantic,
+	ExplanationFactorPopularity,
+}
+
+// explanationFactorLabels are the customer-facing names of the factors
+var explanationFactorLabels = map[string]string{
+	ExplanationFactorCategoryAffinity: "Category Affinity",
+	ExplanationFactorBrandMatch:       "Brand Match",
+	ExplanationFactorPriceFit:         "Price Fit",
+	ExplanationFactorCollaborative:    "Collaborative Evidence",
+	ExplanationFactorSemantic:         "Semantic Similarity",
+	ExplanationFactorPopularity:       "Popularity",
+}
+
+// ExplainerTuning configures the additive attribution model used for explanation factors.
+// Each factor contributes weight * (value - baseline), where values are scoring inputs in [-1, 1].
+type ExplainerTuning struct {
+	Weights   map[string]float64 `json:"weights"`   // Importance of each factor
+	Baselines map[string]float64 `json:"baselines"` // Value of a typical candidate; factors at their baseline contribute nothing
+}
+
+// DefaultExplainerTuning returns the explainer tuning used when none is configured
+func DefaultExplainerTuning() ExplainerTuning {
+	return ExplainerTuning{
+		Weights: map[string]float64{
+			ExplanationFactorCategoryAffinity: 0.25,
+			ExplanationFactorBrandMatch:       0.15,
+			ExplanationFactorPriceFit:         0.15,
+			ExplanationFactorCollaborative:    0.15,
+			ExplanationFactorSemantic:         0.2,
+			ExplanationFactorPopularity:       0.1,
+		},
+		Baselines: map[string]float64{
+			ExplanationFactorCategoryAffinity: 0.1,
+			ExplanationFactorPriceFit:         0.5,
+			ExplanationFactorPopularity:       0.3,
+		},
+	}
+}
+
+// maxPopularityScore is the upper bound of products.popularity_score
+const maxPopularityScore = 100.0
+
+// FactorAttribution is the additive contribution of one factor to an explained recommendation
+type FactorAttribution struct {
+	Factor       string
+	Value        float64 // Scoring input in [-1, 1]
+	Baseline     float64
+	Contribution float64 // Weight * (Value - Baseline)
+}
+
+// RecommendationExplainer attributes a recommendation to its scoring inputs without involving the LLM
+type RecommendationExplainer struct {
+	tuning ExplainerTuning
+}
+
+// NewRecommendationExplainer creates an explainer; factors without a configured weight use the default weight
+func NewRecommendationExplainer(tuning ExplainerTuning) *RecommendationExplainer {
+	defaults := DefaultExplainerTuning()
+	weights := make(map[string]float64, len(ExplanationFactorNames))
+	baselines := make(map[string]float64, len(ExplanationFactorNames))
+	for _, name := range ExplanationFactorNames {
+		weights[name] = defaults.Weights[name]
+		if weight, ok := tuning.Weights[name]; ok {
+			weights[name] = weight
+		}
+		baselines[name] = defaults.Baselines[name]
+		if baseline, ok := tuning.Baselines[name]; ok {
+			baselines[name] = baseline
+		}
+	}
+
+	return &RecommendationExplainer{
+		tuning: ExplainerTuning{Weights: weights, Baselines: baselines},
+	}
+}
+
+// ExplanationInputs derives the factor values from ranking features and the strategies that served the product.
+// retrievalScore is the product's score from its strategy, before blending and post-ranking; zero when the
+// product was not retrieved, e.g. when a merchandising rule pinned it.
+func ExplanationInputs(features map[string]float64, strategies []string, rec dto.ProductRecommendationV2, retrievalScore float64) map[string]float64 {
+	inputs := make(map[string]float64, len(ExplanationFactorNames))
+
+	inputs[ExplanationFactorCategoryAffinity] = features["category_affinity"] - features["category_disliked"]
+	inputs[ExplanationFactorBrandMatch] = features["brand_affinity"] - features["brand_disliked"]
+
+	// Price fit is 1 at the customer's reference price and halves with every doubling away from it
+	if relative := features["price_relative"]; relative > 0 {
+		inputs[ExplanationFactorPriceFit] = math.Min(relative, 1/relative)
+	}
+
+	// Single-strategy recommendations carry no per-strategy scores, so their retrieval score is the evidence
+	collaborative := features["collaborative_score"]
+	semantic := math.Max(features["semantic_score"], math.Max(features["vector_search_score"], features["knowledge_based_score"]))
+	semantic = math.Max(semantic, rec.SimilarityScore)
+	if features["strategy_count"] == 0 {
+		for _, strategy := range strategies {
+			switch strategy {
+			case StrategyCollaborative, "collaborative_filtering":
+				collaborative = math.Max(collaborative, retrievalScore)
+			case StrategySemantic, StrategyVectorSearch, StrategyKnowledgeBased, "semantic_search", "vector_similarity", "knowledge_base_rag":
+				semantic = math.Max(semantic, retrievalScore)
+			}
+		}
+	}
+	inputs[ExplanationFactorCollaborative] = math.Min(collaborative, 1)
+	inputs[ExplanationFactorSemantic] = math.Min(semantic, 1)
+
+	inputs[ExplanationFactorPopularity] = math.Min(features["popularity_log"]/math.Log1p(maxPopularityScore), 1)
+
+	return inputs
+}
+
+// Attribute computes the additive contribution of every factor, ordered by descending magnitude
+func (e *RecommendationExplainer) Attribute(inputs map[string]float64) []FactorAttribution {
+	attributions := make([]FactorAttribution, 0, len(ExplanationFactorNames))
+	for _, name := range ExplanationFactorNames {
+		baseline := e.tuning.Baselines[name]
+		attributions = append(attributions, FactorAttribution{
+			Factor:       name,
+			Value:        inputs[name],
+			Baseline:     baseline,
+			Contribution: e.tuning.Weights[name] * (inputs[name] - baseline),
+		})
+	}
+
+	sort.SliceStable(attributions, func(i, j int) bool {
+		return math.Abs(attributions[i].Contribution) > math.Abs(attributions[j].Contribution)
+	})
+
+	return attributions
+}
+
+// ExplainItem returns explanation factors for a served recommendation. Ranking features recorded when
+// the item was served are used when available; otherwise they are recomputed from the product snapshot
+// and the current profile, which lowers the reported confidence.
+func (e *RecommendationExplainer) ExplainItem(item *dto.RecommendationItem, profile *dto.CustomerProfile, now time.Time) []dto.ExplanationFactor {
+	features := item.ScoreBreakdown.Features
+	confidence := 1.0
+	if len(features) == 0 {
+		features = ExtractRankingFeatures(item.Product, profile, now)
+		confidence = 0.8
+	}
+
+	// The served confidence may come from re-ranking or the language model, not from the strategy
+	var retrievalScore float64
+	if item.ScoreBreakdown.RetrievalRank > 0 {
+		retrievalScore = item.ScoreBreakdown.RetrievalScore
+	}
+
+	return explanationFactors(e.Attribute(ExplanationInputs(features, item.Strategies, item.Product, retrievalScore)), confidence)
+}
+
+// explanationFactors converts attributions to factors whose weights are their share of the total attribution
+func explanationFactors(attributions []FactorAttribution, confidence float64) []dto.ExplanationFactor {
+	var total float64
+	for _, attribution := range attributions {
+		total += math.Abs(attribution.Contribution)
+	}
+
+	factors := make([]dto.ExplanationFactor, 0, len(attributions))
+	for _, attribution := range attributions {
+		weight := 0.0
+		if total > 0 {
+			weight = math.Abs(attribution.Contribution) / total
+		}
+
+		impact := "neutral"
+		switch {
+		case attribution.Contribution >= 0.001:
+			impact = "positive"
+		case attribution.Contribution <= -0.001:
+			impact = "negative"
+		}
+
+		factors = append(factors, dto.ExplanationFactor{
+			Factor:       explanationFactorLabels[attribution.Factor],
+			Weight:       weight,
+			Impact:       impact,
+			Description:  fmt.Sprintf("%s value %.2f against a baseline of %.2f contributes %+.3f", explanationFactorLabels[attribution.Factor], attribution.Value, attribution.Baseline, attribution.Contribution),
+			Confidence:   confidence,
+			Contribution: attribution.Contribution,
+			Value:        attribution.Value,
+		})
+	}
+
+	return factors
+}
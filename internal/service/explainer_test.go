@@ -0,0 +1,113 @@
+package service
+
+import (
+	"ec-recommend/internal/dto"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestRecommendationExplainer(t *testing.T) {
+	explainer := NewRecommendationExplainer(DefaultExplainerTuning())
+
+	t.Run("attributions add up to the score difference from the baseline", func(t *testing.T) {
+		inputs := map[string]float64{
+			ExplanationFactorCategoryAffinity: 0.9,
+			ExplanationFactorBrandMatch:       -1, // Disliked brand
+			ExplanationFactorPriceFit:         0.5,
+			ExplanationFactorSemantic:         0.6,
+			ExplanationFactorPopularity:       0.3,
+		}
+		tuning := DefaultExplainerTuning()
+
+		var expected, total float64
+		for _, name := range ExplanationFactorNames {
+			expected += tuning.Weights[name] * (inputs[name] - tuning.Baselines[name])
+		}
+		attributions := explainer.Attribute(inputs)
+		for _, attribution := range attributions {
+			total += attribution.Contribution
+		}
+		if math.Abs(total-expected) > 1e-9 {
+			t.Errorf("Expected contributions to sum to %.4f, got %.4f", expected, total)
+		}
+
+		factors := explanationFactors(attributions, 1)
+		if factors[0].Factor != "Category Affinity" || factors[0].Impact != "positive" {
+			t.Errorf("Expected category affinity to lead, got %+v", factors[0])
+		}
+		if factors[1].Factor != "Brand Match" || factors[1].Impact != "negative" {
+			t.Errorf("Expected the disliked brand to count against the product, got %+v", factors[1])
+		}
+
+		var weights float64
+		for _, factor := range factors {
+			weights += factor.Weight
+			if factor.Factor == "Price Fit" && factor.Impact != "neutral" {
+				t.Errorf("Expected a price fit at the baseline to be neutral, got %+v", factor)
+			}
+		}
+		if math.Abs(weights-1) > 1e-9 {
+			t.Errorf("Expected factor weights to sum to 1, got %.4f", weights)
+		}
+	})
+
+	t.Run("derives inputs from recorded features and serving strategies", func(t *testing.T) {
+		features := map[string]float64{
+			"category_affinity": 0.5,
+			"brand_affinity":    1,
+			"price_relative":    2,
+			"popularity_log":    math.Log1p(maxPopularityScore),
+		}
+		// Re-ranking and explanations changed the served confidence after retrieval
+		rec := dto.ProductRecommendationV2{ConfidenceScore: 0.95}
+		inputs := ExplanationInputs(features, []string{"collaborative_filtering"}, rec, 0.7)
+
+		if inputs[ExplanationFactorPriceFit] != 0.5 {
+			t.Errorf("Expected a price twice the reference to fit 0.5, got %.2f", inputs[ExplanationFactorPriceFit])
+		}
+		if inputs[ExplanationFactorCollaborative] != 0.7 || inputs[ExplanationFactorSemantic] != 0 {
+			t.Errorf("Expected the collaborative score as evidence, got %+v", inputs)
+		}
+		if inputs[ExplanationFactorPopularity] != 1 {
+			t.Errorf("Expected maximum popularity, got %.2f", inputs[ExplanationFactorPopularity])
+		}
+	})
+
+	t.Run("products that were not retrieved carry no strategy evidence", func(t *testing.T) {
+		item := &dto.RecommendationItem{
+			Strategies:     []string{"collaborative_filtering"},
+			Product:        dto.ProductRecommendationV2{ConfidenceScore: 1},
+			ScoreBreakdown: dto.RecommendationScoreBreakdown{FinalScore: 1, RetrievalScore: 1, Features: map[string]float64{"brand_affinity": 1}},
+		}
+
+		for _, factor := range explainer.ExplainItem(item, nil, time.Now()) {
+			if factor.Factor == "Collaborative Evidence" && factor.Value != 0 {
+				t.Errorf("Expected no collaborative evidence for a pinned product, got %+v", factor)
+			}
+		}
+	})
+
+	t.Run("recomputes features when none were recorded", func(t *testing.T) {
+		item := &dto.RecommendationItem{
+			ProductID: uuid.New(),
+			Product:   dto.ProductRecommendationV2{Brand: "Acme", CategoryID: 3, Price: 1000},
+		}
+		profile := &dto.CustomerProfile{PreferredBrands: []string{"acme"}}
+
+		factors := explainer.ExplainItem(item, profile, time.Now())
+		if len(factors) != len(ExplanationFactorNames) {
+			t.Fatalf("Expected %d factors, got %d", len(ExplanationFactorNames), len(factors))
+		}
+		for _, factor := range factors {
+			if factor.Confidence != 0.8 {
+				t.Errorf("Expected lower confidence for recomputed features, got %+v", factor)
+			}
+			if factor.Factor == "Brand Match" && factor.Value != 1 {
+				t.Errorf("Expected a brand match, got %+v", factor)
+			}
+		}
+	})
+}
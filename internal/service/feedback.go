@@ -0,0 +1,345 @@
+package service
+
+import (
+	"context"
+	"ec-recommend/internal/dto"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FeedbackTuning configures how "not interested" dismissals suppress recommendations
+type FeedbackTuning struct {
+	Disabled                bool `json:"disabled,omitempty"`
+	ProductSuppressionDays  int  `json:"product_suppression_days"`  // Period a dismissed product is hidden
+	BrandSuppressionDays    int  `json:"brand_suppression_days"`    // Period a brand is hidden after a brand-scoped dismissal
+	CategorySuppressionDays int  `json:"category_suppression_days"` // Period a category is hidden after a category-scoped dismissal
+	NegativeLookbackDays    int  `json:"negative_lookback_days"`    // Dismissals considered as negative preference signals
+	MinNegativeDismissals   int  `json:"min_negative_dismissals"`   // Taste dismissals of products in a brand or category before it counts as disliked
+}
+
+// DefaultFeedbackTuning returns the feedback tuning used when none is configured
+func DefaultFeedbackTuning() FeedbackTuning {
+	return FeedbackTuning{
+		ProductSuppressionDays:  90,
+		BrandSuppressionDays:    30,
+		CategorySuppressionDays: 14,
+		NegativeLookbackDays:    180,
+		MinNegativeDismissals:   2,
+	}
+}
+
+// ValidFeedbackReason reports whether a reason code is supported
+func ValidFeedbackReason(reason string) bool {
+	switch reason {
+	case dto.FeedbackReasonAlreadyOwn, dto.FeedbackReasonNotMyTaste, dto.FeedbackReasonTooExpensive,
+		dto.FeedbackReasonNotRelevant, dto.FeedbackReasonOther:
+		return true
+	}
+	return false
+}
+
+// ValidFeedbackScope reports whether a suppression scope is supported
+func ValidFeedbackScope(scope string) bool {
+	switch scope {
+	case dto.FeedbackScopeProduct, dto.FeedbackScopeBrand, dto.FeedbackScopeCategory:
+		return true
+	}
+	return false
+}
+
+// isTasteFeedback reports whether a dismissal says something about the customer's taste,
+// as opposed to ownership or price
+func isTasteFeedback(reason string) bool {
+	return reason == dto.FeedbackReasonNotMyTaste || reason == dto.FeedbackReasonNotRelevant
+}
+
+// FeedbackSuppressions holds the products, brands and categories a customer currently dismissed
+type FeedbackSuppressions struct {
+	products   map[uuid.UUID]bool
+	brands     map[string]bool
+	categories map[int]bool
+}
+
+// NewFeedbackSuppressions builds suppressions from active dismissals
+func NewFeedbackSuppressions(feedback []dto.RecommendationFeedback) *FeedbackSuppressions {
+	suppressions := &FeedbackSuppressions{
+		products:   make(map[uuid.UUID]bool),
+		brands:     make(map[string]bool),
+		categories: make(map[int]bool),
+	}
+
+	for _, f := range feedback {
+		suppressions.products[f.ProductID] = true
+		switch f.Scope {
+		case dto.FeedbackScopeBrand:
+			if f.Brand != "" {
+				suppressions.brands[strings.ToLower(f.Brand)] = true
+			}
+		case dto.FeedbackScopeCategory:
+			suppressions.categories[f.CategoryID] = true
+		}
+	}
+
+	return suppressions
+}
+
+// Empty reports whether nothing is suppressed
+func (s *FeedbackSuppressions) Empty() bool {
+	return s == nil || len(s.products)+len(s.brands)+len(s.categories) == 0
+}
+
+// Suppresses reports whether a product is hidden by a dismissal
+func (s *FeedbackSuppressions) Suppresses(productID uuid.UUID, brand string, categoryID int) bool {
+	if s.Empty() {
+		return false
+	}
+	return s.products[productID] || (brand != "" && s.brands[strings.ToLower(brand)]) || s.categories[categoryID]
+}
+
+// Filter removes dismissed products from a recommendation list
+func (s *FeedbackSuppressions) Filter(recommendations []dto.ProductRecommendationV2) []dto.ProductRecommendationV2 {
+	if s.Empty() {
+		return recommendations
+	}
+
+	kept := make([]dto.ProductRecommendationV2, 0, len(recommendations))
+	for _, rec := range recommendations {
+		if !s.Suppresses(rec.ProductID, rec.Brand, rec.CategoryID) {
+			kept = append(kept, rec)
+		}
+	}
+
+	return kept
+}
+
+// FeedbackService records negative feedback and turns it into suppressions and negative preferences
+type FeedbackService struct {
+	repo   FeedbackRepositoryInterface
+	tuning FeedbackTuning
+}
+
+// NewFeedbackService creates a new feedback service
+func NewFeedbackService(repo FeedbackRepositoryInterface, tuning FeedbackTuning) *FeedbackService {
+	defaults := DefaultFeedbackTuning()
+	if tuning.ProductSuppressionDays <= 0 {
+		tuning.ProductSuppressionDays = defaults.ProductSuppressionDays
+	}
+	if tuning.BrandSuppressionDays <= 0 {
+		tuning.BrandSuppressionDays = defaults.BrandSuppressionDays
+	}
+	if tuning.CategorySuppressionDays <= 0 {
+		tuning.CategorySuppressionDays = defaults.CategorySuppressionDays
+	}
+	if tuning.NegativeLookbackDays <= 0 {
+		tuning.NegativeLookbackDays = defaults.NegativeLookbackDays
+	}
+	if tuning.MinNegativeDismissals <= 0 {
+		tuning.MinNegativeDismissals = defaults.MinNegativeDismissals
+	}
+
+	return &FeedbackService{
+		repo:   repo,
+		tuning: tuning,
+	}
+}
+
+// RecordFeedback stores a dismissal and its suppression period, returning nil if the product does not exist
+func (fs *FeedbackService) RecordFeedback(ctx context.Context, req *dto.RecommendationFeedbackRequest) (*dto.RecommendationFeedback, error) {
+	scope := req.Scope
+	if scope == "" {
+		scope = dto.FeedbackScopeProduct
+	}
+
+	days := fs.tuning.ProductSuppressionDays
+	switch scope {
+	case dto.FeedbackScopeBrand:
+		days = fs.tuning.BrandSuppressionDays
+	case dto.FeedbackScopeCategory:
+		days = fs.tuning.CategorySuppressionDays
+	}
+
+	feedback, err := fs.repo.CreateFeedback(ctx, &dto.RecommendationFeedback{
+		CustomerID:       req.CustomerID,
+		ProductID:        req.ProductID,
+		RecommendationID: req.RecommendationID,
+		ReasonCode:       req.ReasonCode,
+		Scope:            scope,
+		ContextType:      req.ContextType,
+		Comment:          req.Comment,
+		SuppressUntil:    time.Now().AddDate(0, 0, days),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to record feedback: %w", err)
+	}
+
+	return feedback, nil
+}
+
+// DeleteFeedback withdraws a customer's dismissal, reporting whether it existed
+func (fs *FeedbackService) DeleteFeedback(ctx context.Context, feedbackID, customerID uuid.UUID) (bool, error) {
+	return fs.repo.DeleteFeedback(ctx, feedbackID, customerID)
+}
+
+// Suppressions returns what the customer currently dismissed; empty when the feature is disabled
+func (fs *FeedbackService) Suppressions(ctx context.Context, customerID uuid.UUID) (*FeedbackSuppressions, error) {
+	if fs.tuning.Disabled || customerID == uuid.Nil {
+		return NewFeedbackSuppressions(nil), nil
+	}
+
+	feedback, err := fs.repo.GetActiveFeedback(ctx, customerID, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get active feedback: %w", err)
+	}
+
+	return NewFeedbackSuppressions(feedback), nil
+}
+
+// suppressionsFor returns what the customer currently dismissed, for services that filter their
+// results outside the post-ranking pipeline. Suppression is best effort: without a feedback
+// service or customer, or when the dismissals cannot be loaded, nothing is suppressed.
+func (fs *FeedbackService) suppressionsFor(ctx context.Context, customerID *uuid.UUID) *FeedbackSuppressions {
+	if fs == nil || customerID == nil {
+		return nil
+	}
+
+	suppressions, err := fs.Suppressions(ctx, *customerID)
+	if err != nil {
+		log.Printf("Warning: failed to get feedback suppressions: %v", err)
+		return nil
+	}
+
+	return suppressions
+}
+
+// ApplyNegativePreferences records the brands and categories the customer dismissed for taste
+// reasons as disliked and removes them from the preferred brands and categories. A brand or
+// category counts as disliked after a dismissal scoped to it, or after MinNegativeDismissals
+// taste dismissals of its products.
+func (fs *FeedbackService) ApplyNegativePreferences(ctx context.Context, profile *dto.CustomerProfile) error {
+	if fs.tuning.Disabled || profile == nil {
+		return nil
+	}
+
+	since := time.Now().AddDate(0, 0, -fs.tuning.NegativeLookbackDays)
+	feedback, err := fs.repo.GetFeedbackSince(ctx, profile.CustomerID, since)
+	if err != nil {
+		return fmt.Errorf("failed to get feedback: %w", err)
+	}
+
+	profile.DislikedCategories, profile.DislikedBrands = dislikedFromFeedback(feedback, fs.tuning.MinNegativeDismissals)
+
+	disliked := make(map[string]bool, len(profile.DislikedBrands))
+	for _, brand := range profile.DislikedBrands {
+		disliked[strings.ToLower(brand)] = true
+	}
+	var brands []string
+	for _, brand := range profile.PreferredBrands {
+		if !disliked[strings.ToLower(brand)] {
+			brands = append(brands, brand)
+		}
+	}
+	profile.PreferredBrands = brands
+
+	var categories []int
+	for _, categoryID := range profile.PreferredCategories {
+		if !containsInt(profile.DislikedCategories, categoryID) {
+			categories = append(categories, categoryID)
+		}
+	}
+	profile.PreferredCategories = categories
+
+	return nil
+}
+
+// dislikedFromFeedback returns the categories and brands disliked according to taste dismissals
+func dislikedFromFeedback(feedback []dto.RecommendationFeedback, minDismissals int) ([]int, []string) {
+	categoryCounts := make(map[int]int)
+	brandCounts := make(map[string]int)
+	brandNames := make(map[string]string)
+
+	for _, f := range feedback {
+		if !isTasteFeedback(f.ReasonCode) {
+			continue
+		}
+
+		weight := 1
+		if f.Scope != dto.FeedbackScopeProduct {
+			weight = minDismissals // An explicit brand or category dismissal counts in full
+		}
+
+		if f.Scope != dto.FeedbackScopeBrand {
+			categoryCounts[f.CategoryID] += weight
+		}
+		if f.Brand != "" && f.Scope != dto.FeedbackScopeCategory {
+			key := strings.ToLower(f.Brand)
+			brandCounts[key] += weight
+			brandNames[key] = f.Brand
+		}
+	}
+
+	var categories []int
+	for categoryID, count := range categoryCounts {
+		if count >= minDismissals {
+			categories = append(categories, categoryID)
+		}
+	}
+	sort.Ints(categories)
+
+	var brands []string
+	for key, count := range brandCounts {
+		if count >= minDismissals {
+			brands = append(brands, brandNames[key])
+		}
+	}
+	sort.Strings(brands)
+
+	return categories, brands
+}
+
+// containsInt reports whether values contains value
+func containsInt(values []int, value int) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// FeedbackSuppressionStage removes products the customer dismissed, and products of brands
+// or categories they dismissed, while the suppression period lasts
+type FeedbackSuppressionStage struct {
+	feedback *FeedbackService
+}
+
+// NewFeedbackSuppressionStage creates a feedback suppression post-ranking stage
+func NewFeedbackSuppressionStage(feedback *FeedbackService) *FeedbackSuppressionStage {
+	return &FeedbackSuppressionStage{
+		feedback: feedback,
+	}
+}
+
+// Name returns the stage identifier
+func (fs *FeedbackSuppressionStage) Name() string {
+	return "feedback_suppression"
+}
+
+// enforcesPolicy marks the stage to run for gift results too
+func (fs *FeedbackSuppressionStage) enforcesPolicy() {}
+
+// Apply drops dismissed products from the list and records the suppressions in the ranking
+// context, so that later stages adding products respect them too
+func (fs *FeedbackSuppressionStage) Apply(ctx context.Context, recommendations []dto.ProductRecommendationV2, rc *RankingContext) ([]dto.ProductRecommendationV2, error) {
+	suppressions, err := fs.feedback.Suppressions(ctx, rc.CustomerID)
+	if err != nil {
+		return nil, err
+	}
+	rc.Suppressions = suppressions
+
+	return suppressions.Filter(recommendations), nil
+}
@@ -0,0 +1,26 @@
+package service
+
+import (
+	"context"
+	"ec-recommend/internal/dto"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FeedbackRepositoryInterface defines persistence for negative recommendation feedback
+// This interface is defined in the service package as it is consumed by services
+type FeedbackRepositoryInterface interface {
+	// CreateFeedback stores a dismissal with the product's current brand and category,
+	// returning nil if the product does not exist
+	CreateFeedback(ctx context.Context, feedback *dto.RecommendationFeedback) (*dto.RecommendationFeedback, error)
+
+	// DeleteFeedback deletes a customer's dismissal, reporting whether it existed
+	DeleteFeedback(ctx context.Context, feedbackID, customerID uuid.UUID) (bool, error)
+
+	// GetActiveFeedback returns a customer's dismissals that still suppress at the given time
+	GetActiveFeedback(ctx context.Context, customerID uuid.UUID, now time.Time) ([]dto.RecommendationFeedback, error)
+
+	// GetFeedbackSince returns a customer's dismissals created since the given time
+	GetFeedbackSince(ctx context.Context, customerID uuid.UUID, since time.Time) ([]dto.RecommendationFeedback, error)
+}
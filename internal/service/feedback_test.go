@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"ec-recommend/internal/dto"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// fakeFeedbackRepository serves fixed dismissals
+type fakeFeedbackRepository struct {
+	feedback []dto.RecommendationFeedback
+}
+
+func (f *fakeFeedbackRepository) CreateFeedback(ctx context.Context, feedback *dto.RecommendationFeedback) (*dto.RecommendationFeedback, error) {
+	f.feedback = append(f.feedback, *feedback)
+	return feedback, nil
+}
+
+func (f *fakeFeedbackRepository) DeleteFeedback(ctx context.Context, feedbackID, customerID uuid.UUID) (bool, error) {
+	return false, nil
+}
+
+func (f *fakeFeedbackRepository) GetActiveFeedback(ctx context.Context, customerID uuid.UUID, now time.Time) ([]dto.RecommendationFeedback, error) {
+	var active []dto.RecommendationFeedback
+	for _, feedback := range f.feedback {
+		if feedback.SuppressUntil.After(now) {
+			active = append(active, feedback)
+		}
+	}
+	return active, nil
+}
+
+func (f *fakeFeedbackRepository) GetFeedbackSince(ctx context.Context, customerID uuid.UUID, since time.Time) ([]dto.RecommendationFeedback, error) {
+	return f.feedback, nil
+}
+
+func TestFeedbackSuppression(t *testing.T) {
+	dismissed := uuid.New()
+	future := time.Now().Add(24 * time.Hour)
+	repo := &fakeFeedbackRepository{feedback: []dto.RecommendationFeedback{
+		{ProductID: dismissed, ReasonCode: dto.FeedbackReasonAlreadyOwn, Scope: dto.FeedbackScopeProduct, Brand: "Acme", CategoryID: 1, SuppressUntil: future},
+		{ProductID: uuid.New(), ReasonCode: dto.FeedbackReasonNotMyTaste, Scope: dto.FeedbackScopeBrand, Brand: "Loud", CategoryID: 2, SuppressUntil: future},
+		{ProductID: uuid.New(), ReasonCode: dto.FeedbackReasonNotRelevant, Scope: dto.FeedbackScopeCategory, CategoryID: 3, SuppressUntil: time.Now().Add(-time.Hour)},
+	}}
+	stage := NewFeedbackSuppressionStage(NewFeedbackService(repo, DefaultFeedbackTuning()))
+
+	kept := uuid.New()
+	recommendations := []dto.ProductRecommendationV2{
+		{ProductID: dismissed, Brand: "Acme", CategoryID: 1},
+		{ProductID: uuid.New(), Brand: "loud", CategoryID: 4}, // Brand dismissals match case-insensitively
+		{ProductID: kept, Brand: "Acme", CategoryID: 3},       // Expired category suppression
+	}
+
+	result, err := stage.Apply(context.Background(), recommendations, &RankingContext{CustomerID: uuid.New()})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(result) != 1 || result[0].ProductID != kept {
+		t.Errorf("Expected only the unsuppressed product, got %+v", result)
+	}
+}
+
+func TestApplyNegativePreferences(t *testing.T) {
+	repo := &fakeFeedbackRepository{feedback: []dto.RecommendationFeedback{
+		{ReasonCode: dto.FeedbackReasonNotMyTaste, Scope: dto.FeedbackScopeProduct, Brand: "Loud", CategoryID: 5},
+		{ReasonCode: dto.FeedbackReasonNotRelevant, Scope: dto.FeedbackScopeProduct, Brand: "Loud", CategoryID: 6},
+		{ReasonCode: dto.FeedbackReasonNotMyTaste, Scope: dto.FeedbackScopeCategory, Brand: "Calm", CategoryID: 7},
+		{ReasonCode: dto.FeedbackReasonTooExpensive, Scope: dto.FeedbackScopeBrand, Brand: "Posh", CategoryID: 1},
+	}}
+	feedbackService := NewFeedbackService(repo, DefaultFeedbackTuning())
+
+	profile := &dto.CustomerProfile{
+		CustomerID:          uuid.New(),
+		PreferredBrands:     []string{"Loud", "Posh", "Calm"},
+		PreferredCategories: []int{1, 5, 7},
+	}
+	if err := feedbackService.ApplyNegativePreferences(context.Background(), profile); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Two taste dismissals make Loud disliked; a single one in categories 5 and 6 is not enough,
+	// an explicit category dismissal is, and price dismissals say nothing about taste
+	if len(profile.DislikedBrands) != 1 || profile.DislikedBrands[0] != "Loud" {
+		t.Errorf("Expected disliked brands [Loud], got %v", profile.DislikedBrands)
+	}
+	if len(profile.DislikedCategories) != 1 || profile.DislikedCategories[0] != 7 {
+		t.Errorf("Expected disliked categories [7], got %v", profile.DislikedCategories)
+	}
+	if len(profile.PreferredBrands) != 2 || profile.PreferredBrands[0] != "Posh" || profile.PreferredBrands[1] != "Calm" {
+		t.Errorf("Expected preferred brands [Posh Calm], got %v", profile.PreferredBrands)
+	}
+	if len(profile.PreferredCategories) != 2 || profile.PreferredCategories[1] != 5 {
+		t.Errorf("Expected preferred categories [1 5], got %v", profile.PreferredCategories)
+	}
+}
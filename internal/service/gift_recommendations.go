@@ -0,0 +1,334 @@
+package service
+
+import (
+	"context"
+	"ec-recommend/internal/dto"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// giftRelationshipTerms maps recipient relationships to the wording used in knowledge base queries
+var giftRelationshipTerms = map[string]string{
+	"parent":      "両親",
+	"mother":      "母",
+	"father":      "父",
+	"partner":     "恋人・パートナー",
+	"spouse":      "妻・夫",
+	"friend":      "友人",
+	"colleague":   "同僚",
+	"boss":        "上司",
+	"child":       "子供",
+	"grandparent": "祖父母",
+}
+
+// giftOccasionTerms maps occasions to the wording used in knowledge base queries
+var giftOccasionTerms = map[string]string{
+	"birthday":     "誕生日",
+	"christmas":    "クリスマス",
+	"mothers_day":  "母の日",
+	"fathers_day":  "父の日",
+	"anniversary":  "記念日",
+	"wedding":      "結婚祝い",
+	"housewarming": "新築祝い",
+	"thank_you":    "お礼",
+	"valentine":    "バレンタイン",
+	"white_day":    "ホワイトデー",
+}
+
+// giftGenderTerms maps recipient genders to the wording used in knowledge base queries
+var giftGenderTerms = map[string]string{
+	"male":   "男性",
+	"female": "女性",
+}
+
+// GetGiftRecommendations recommends gifts for a described recipient. Candidates come from the
+// knowledge base with the budget and category as filters; products the giver already bought
+// for themselves are excluded, the policy post-ranking stages still apply, and reasons are
+// written for the recipient rather than the giver.
+func (rs *RecommendationServiceV2) GetGiftRecommendations(ctx context.Context, req *dto.GiftRequest) (*dto.GiftResponse, error) {
+	startTime := time.Now()
+
+	if req.Limit <= 0 {
+		req.Limit = 10
+	}
+
+	profile, err := rs.GetCustomerProfile(ctx, req.CustomerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get customer profile: %w", err)
+	}
+
+	query := buildGiftQuery(req, profile)
+	filters := buildGiftFilters(req)
+
+	// Over-fetch so that owned products and out-of-budget items can be dropped
+	ragResponse, err := rs.rag.GetProductsWithSemanticSearch(ctx, query, req.Limit*3, filters)
+	if err != nil {
+		return nil, fmt.Errorf("failed to perform gift search: %w", err)
+	}
+
+	candidates, err := rs.convertRAGResultsToProducts(ctx, ragResponse.Results, "gift_search")
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert gift search results: %w", err)
+	}
+
+	selfPurchases := rs.selfPurchases(ctx, req.CustomerID, profile.PurchaseHistory)
+	recommendations := rs.filterOwnedProductsV2(candidates, selfPurchases)
+	excludedOwned := len(candidates) - len(recommendations)
+
+	// The giver's ranking signals do not describe the recipient, so only the policy stages run:
+	// merchandising blocks, the giver's dismissals and exposure caps
+	rankingContext := &RankingContext{
+		Service:     BlendServiceV2,
+		CustomerID:  req.CustomerID,
+		ContextType: "gift",
+		CategoryID:  req.CategoryID,
+		Profile:     profile,
+		Limit:       req.Limit,
+		Excluded:    purchasedProducts(selfPurchases),
+	}
+	retrieval := snapshotRetrievalScores(recommendations)
+	recommendations = rs.postRanking.ApplyPolicy(ctx, recommendations, rankingContext)
+
+	// Filter after the policy stages so that pinned products also respect the budget
+	if req.BudgetMin != nil || req.BudgetMax != nil {
+		recommendations = rs.filterByPriceRange(recommendations, req.BudgetMin, req.BudgetMax)
+	}
+
+	if len(recommendations) > req.Limit {
+		recommendations = recommendations[:req.Limit]
+	}
+
+	for i := range recommendations {
+		recommendations[i].Reason = giftFallbackReason(req)
+	}
+
+	ranked := snapshotRankedScores(recommendations)
+	if req.EnableExplanation && len(recommendations) > 0 {
+		explained, err := rs.explainGiftRecommendations(ctx, recommendations, req)
+		if err != nil {
+			log.Printf("Warning: failed to generate gift explanations: %v", err)
+		} else {
+			recommendations = explained
+		}
+	}
+
+	sessionID := uuid.New()
+	productIDs := make([]uuid.UUID, len(recommendations))
+	for i, rec := range recommendations {
+		productIDs[i] = rec.ProductID
+	}
+
+	if err := rs.repo.LogRecommendation(ctx, req.CustomerID, "gift", "gift", productIDs, sessionID, "gift_v1.0"); err != nil {
+		log.Printf("Warning: failed to log gift recommendation: %v", err)
+	}
+
+	rs.saveRecommendationItems(ctx, recommendationItemContext{
+		RecommendationLogID: sessionID,
+		CustomerID:          req.CustomerID,
+		RecommendationType:  "gift",
+		ContextType:         "gift",
+		AlgorithmVersion:    "gift_v1.0",
+		Strategies:          []string{"knowledge_base_rag"},
+		Filters: dto.RecommendationFilters{
+			QueryText:     query,
+			PriceRangeMin: req.BudgetMin,
+			PriceRangeMax: req.BudgetMax,
+			ExcludeOwned:  true,
+		},
+		Retrieval: retrieval,
+		Ranked:    ranked,
+	}, recommendations)
+
+	if recommendations == nil {
+		recommendations = []dto.ProductRecommendationV2{}
+	}
+
+	return &dto.GiftResponse{
+		RecommendationID: sessionID,
+		CustomerID:       req.CustomerID,
+		Recipient:        req.Recipient,
+		Occasion:         req.Occasion,
+		BudgetMin:        req.BudgetMin,
+		BudgetMax:        req.BudgetMax,
+		Query:            query,
+		ExcludedOwned:    excludedOwned,
+		Recommendations:  recommendations,
+		ProcessingTimeMs: time.Since(startTime).Milliseconds(),
+		GeneratedAt:      time.Now(),
+	}, nil
+}
+
+// selfPurchases returns the purchases the customer made for themselves, leaving out products
+// they only ordered as gifts, which do not mean the product is unsuitable as a gift. The gift
+// orders are only loaded here, for gift requests. If they cannot be loaded every purchase counts.
+func (rs *RecommendationServiceV2) selfPurchases(ctx context.Context, customerID uuid.UUID, purchases []dto.PurchaseItem) []dto.PurchaseItem {
+	giftOnly, err := rs.repo.GetGiftOnlyProductIDs(ctx, customerID)
+	if err != nil {
+		log.Printf("Warning: failed to get gift-only products: %v", err)
+		return purchases
+	}
+	if len(giftOnly) == 0 {
+		return purchases
+	}
+
+	gifts := make(map[uuid.UUID]bool, len(giftOnly))
+	for _, productID := range giftOnly {
+		gifts[productID] = true
+	}
+
+	var own []dto.PurchaseItem
+	for _, purchase := range purchases {
+		if !gifts[purchase.ProductID] {
+			own = append(own, purchase)
+		}
+	}
+	return own
+}
+
+// buildGiftQuery builds a knowledge base query from the recipient description. The giver's
+// preferred brands are added as a taste hint only when the giver opted in.
+func buildGiftQuery(req *dto.GiftRequest, profile *dto.CustomerProfile) string {
+	terms := []string{"ギフト", "プレゼント"}
+
+	recipient := req.Recipient
+	if recipient.AgeRange != "" {
+		terms = append(terms, giftAgeTerm(recipient.AgeRange))
+	}
+	if term, ok := giftGenderTerms[recipient.Gender]; ok {
+		terms = append(terms, term)
+	}
+	if recipient.Relationship != "" {
+		terms = append(terms, giftTerm(giftRelationshipTerms, recipient.Relationship)+"へ")
+	}
+	if req.Occasion != "" {
+		terms = append(terms, giftTerm(giftOccasionTerms, req.Occasion))
+	}
+	if len(recipient.Interests) > 0 {
+		terms = append(terms, "趣味: "+strings.Join(recipient.Interests, "、"))
+	}
+	if req.Message != "" {
+		terms = append(terms, req.Message)
+	}
+	if req.UseGiverHistory && profile != nil && len(profile.PreferredBrands) > 0 {
+		brands := profile.PreferredBrands
+		if len(brands) > 3 {
+			brands = brands[:3]
+		}
+		terms = append(terms, "好みのブランド: "+strings.Join(brands, "、"))
+	}
+
+	return strings.Join(terms, " ")
+}
+
+// buildGiftFilters builds knowledge base filters from the budget and category. Unlike
+// buildPersonalizedFilters, the giver's own price range and preferences are not applied.
+func buildGiftFilters(req *dto.GiftRequest) map[string]interface{} {
+	filters := make(map[string]interface{})
+
+	if req.CategoryID != nil {
+		filters["category_id"] = *req.CategoryID
+	}
+	if req.BudgetMin != nil {
+		filters["price_min"] = *req.BudgetMin
+	}
+	if req.BudgetMax != nil {
+		filters["price_max"] = *req.BudgetMax
+	}
+
+	return filters
+}
+
+// giftAgeTerm converts age ranges such as "20s" or "60+" into query wording
+func giftAgeTerm(ageRange string) string {
+	switch {
+	case strings.HasSuffix(ageRange, "s"):
+		return strings.TrimSuffix(ageRange, "s") + "代"
+	case strings.HasSuffix(ageRange, "+"):
+		return strings.TrimSuffix(ageRange, "+") + "代以上"
+	default:
+		return ageRange
+	}
+}
+
+// giftTerm returns the mapped wording for a code, or the code itself for free-text values
+func giftTerm(terms map[string]string, value string) string {
+	if term, ok := terms[value]; ok {
+		return term
+	}
+	return value
+}
+
+// giftFallbackReason returns the reason used when no LLM explanation is generated
+func giftFallbackReason(req *dto.GiftRequest) string {
+	var parts []string
+	if req.Recipient.Relationship != "" {
+		parts = append(parts, giftTerm(giftRelationshipTerms, req.Recipient.Relationship)+"への")
+	}
+	if req.Occasion != "" {
+		parts = append(parts, giftTerm(giftOccasionTerms, req.Occasion)+"の")
+	}
+	return strings.Join(parts, "") + "ギフトにおすすめの商品です"
+}
+
+// explainGiftRecommendations generates gift-specific reasons through the gift prompt template
+func (rs *RecommendationServiceV2) explainGiftRecommendations(ctx context.Context, recommendations []dto.ProductRecommendationV2, req *dto.GiftRequest) ([]dto.ProductRecommendationV2, error) {
+	prompt, err := rs.promptGenerator.GenerateGiftPrompt(ctx, recommendations, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate gift prompt: %w", err)
+	}
+
+	response, err := generateAIResponse(ctx, rs.chatService, prompt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate AI response: %w", err)
+	}
+
+	return rs.parseGiftRecommendations(rs.extractJSONFromMarkdown(response.Content), recommendations)
+}
+
+// parseGiftRecommendations parses the gift recommendations array format
+func (rs *RecommendationServiceV2) parseGiftRecommendations(
+	content string,
+	originalRecommendations []dto.ProductRecommendationV2,
+) ([]dto.ProductRecommendationV2, error) {
+
+	var aiResponses []struct {
+		ProductID            string  `json:"product_id"`
+		RecommendationReason string  `json:"recommendation_reason"`
+		GiftAppeal           string  `json:"gift_appeal"`
+		ConfidenceScore      float64 `json:"confidence_score"`
+	}
+
+	if err := json.Unmarshal([]byte(content), &aiResponses); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal gift AI response: %w", err)
+	}
+
+	enhancedRecommendations := make([]dto.ProductRecommendationV2, len(originalRecommendations))
+	copy(enhancedRecommendations, originalRecommendations)
+
+	for i, rec := range enhancedRecommendations {
+		for _, aiResp := range aiResponses {
+			if aiResp.ProductID != rec.ProductID.String() {
+				continue
+			}
+			if aiResp.RecommendationReason != "" {
+				rec.Reason = aiResp.RecommendationReason
+			}
+			if aiResp.GiftAppeal != "" {
+				rec.RelevanceContext = append(rec.RelevanceContext, dto.RelevanceContext{
+					ContextType: "gift_appeal",
+					Explanation: aiResp.GiftAppeal,
+					Confidence:  aiResp.ConfidenceScore,
+				})
+			}
+			enhancedRecommendations[i] = rec
+			break
+		}
+	}
+
+	return enhancedRecommendations, nil
+}
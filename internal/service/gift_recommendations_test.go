@@ -0,0 +1,166 @@
+package service
+
+import (
+	"context"
+	"ec-recommend/internal/dto"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestGiftRecommendations(t *testing.T) {
+	budgetMax := 5000.0
+	req := &dto.GiftRequest{
+		Recipient: dto.GiftRecipient{
+			AgeRange:     "60+",
+			Gender:       "female",
+			Relationship: "mother",
+			Interests:    []string{"園芸"},
+		},
+		Occasion:  "mothers_day",
+		BudgetMax: &budgetMax,
+	}
+	profile := &dto.CustomerProfile{PreferredBrands: []string{"GadgetCo"}}
+
+	t.Run("builds the query from the recipient description", func(t *testing.T) {
+		query := buildGiftQuery(req, profile)
+		for _, term := range []string{"60代以上", "女性", "母へ", "母の日", "園芸"} {
+			if !strings.Contains(query, term) {
+				t.Errorf("Expected query to contain %q, got %q", term, query)
+			}
+		}
+		// The giver's taste is only used when opted in
+		if strings.Contains(query, "GadgetCo") {
+			t.Errorf("Expected no giver brands without use_giver_history, got %q", query)
+		}
+
+		withHistory := *req
+		withHistory.UseGiverHistory = true
+		if query := buildGiftQuery(&withHistory, profile); !strings.Contains(query, "GadgetCo") {
+			t.Errorf("Expected giver brands with use_giver_history, got %q", query)
+		}
+
+		filters := buildGiftFilters(req)
+		if filters["price_max"] != budgetMax {
+			t.Errorf("Expected price_max %.0f, got %v", budgetMax, filters["price_max"])
+		}
+		if _, ok := filters["price_min"]; ok {
+			t.Errorf("Expected no price_min filter, got %v", filters["price_min"])
+		}
+	})
+
+	t.Run("parses gift reasons and appeal", func(t *testing.T) {
+		rs := &RecommendationServiceV2{}
+		productID := uuid.New()
+		recommendations := []dto.ProductRecommendationV2{
+			{ProductID: productID, Reason: giftFallbackReason(req)},
+			{ProductID: uuid.New(), Reason: giftFallbackReason(req)},
+		}
+
+		content := `[{"product_id":"` + productID.String() + `","recommendation_reason":"ガーデニング好きのお母様に","gift_appeal":"名入れ対応","confidence_score":0.9}]`
+		parsed, err := rs.parseGiftRecommendations(content, recommendations)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if parsed[0].Reason != "ガーデニング好きのお母様に" {
+			t.Errorf("Expected the AI reason, got %q", parsed[0].Reason)
+		}
+		if len(parsed[0].RelevanceContext) != 1 || parsed[0].RelevanceContext[0].ContextType != "gift_appeal" {
+			t.Errorf("Expected gift_appeal relevance context, got %+v", parsed[0].RelevanceContext)
+		}
+		if parsed[1].Reason != "母への母の日のギフトにおすすめの商品です" {
+			t.Errorf("Expected the fallback reason to be kept, got %q", parsed[1].Reason)
+		}
+	})
+}
+
+// fakeGiftRepository serves a fixed giver, purchase history and products;
+// every other method is left to the nil embedded interface
+type fakeGiftRepository struct {
+	RecommendationRepositoryV2Interface
+	purchases []dto.PurchaseItem
+	giftOnly  []uuid.UUID
+	products  map[uuid.UUID]dto.ProductRecommendationV2
+}
+
+func (f *fakeGiftRepository) GetCustomerByID(ctx context.Context, customerID uuid.UUID) (*dto.CustomerProfile, error) {
+	return &dto.CustomerProfile{CustomerID: customerID, OrderCount: len(f.purchases)}, nil
+}
+
+func (f *fakeGiftRepository) GetCustomerPurchaseHistory(ctx context.Context, customerID uuid.UUID, limit int) ([]dto.PurchaseItem, error) {
+	return f.purchases, nil
+}
+
+func (f *fakeGiftRepository) GetGiftOnlyProductIDs(ctx context.Context, customerID uuid.UUID) ([]uuid.UUID, error) {
+	return f.giftOnly, nil
+}
+
+func (f *fakeGiftRepository) GetCustomerActivities(ctx context.Context, customerID uuid.UUID, limit int) ([]dto.ActivityItem, error) {
+	return nil, nil
+}
+
+func (f *fakeGiftRepository) GetProductsByIDs(ctx context.Context, productIDs []uuid.UUID) ([]dto.ProductRecommendationV2, error) {
+	var products []dto.ProductRecommendationV2
+	for _, productID := range productIDs {
+		if product, ok := f.products[productID]; ok {
+			products = append(products, product)
+		}
+	}
+	return products, nil
+}
+
+func (f *fakeGiftRepository) LogRecommendation(ctx context.Context, customerID uuid.UUID, recommendationType, contextType string, productIDs []uuid.UUID, sessionID uuid.UUID, algorithmVersion string) error {
+	return nil
+}
+
+func (f *fakeGiftRepository) SaveRecommendationItems(ctx context.Context, items []dto.RecommendationItem) error {
+	return nil
+}
+
+func TestGetGiftRecommendationsExclusionsAndPolicy(t *testing.T) {
+	ownUse := diversityCandidate("own-use", "Garden", 1, 0.9)
+	givenBefore := diversityCandidate("given-before", "Garden", 1, 0.8)
+	blocked := diversityCandidate("blocked", "Blocked", 1, 0.7)
+	plain := diversityCandidate("plain", "Garden", 1, 0.6)
+
+	repo := &fakeGiftRepository{
+		purchases: []dto.PurchaseItem{
+			{ProductID: ownUse.ProductID},
+			{ProductID: givenBefore.ProductID},
+		},
+		giftOnly: []uuid.UUID{givenBefore.ProductID},
+		products: map[uuid.UUID]dto.ProductRecommendationV2{},
+	}
+	var results []RAGSearchResult
+	for _, product := range []dto.ProductRecommendationV2{ownUse, givenBefore, blocked, plain} {
+		repo.products[product.ProductID] = product
+		results = append(results, RAGSearchResult{ProductID: product.ProductID, ConfidenceScore: product.ConfidenceScore})
+	}
+
+	seasonalityRepo := &fakeSeasonalityRepository{}
+	rs := NewRecommendationServiceV2(repo, &fakeRAG{response: &RAGSemanticSearchResponse{Results: results}}, nil, "", "", "", nil)
+	rs.AddPostRankingStage(NewSeasonalityReranker(NewSeasonalityService(seasonalityRepo, DefaultSeasonalityTuning())))
+	rs.AddPostRankingStage(NewMerchandisingRuleStage(&fakeMerchandisingRuleRepository{rules: []dto.MerchandisingRule{{
+		ID:     uuid.New(),
+		Name:   "block-brand",
+		Action: dto.MerchandisingAction{Type: dto.MerchandisingActionBlockBrand, Brand: "Blocked"},
+	}}}, &fakeProductLookup{}))
+
+	response, err := rs.GetGiftRecommendations(context.Background(), &dto.GiftRequest{CustomerID: uuid.New(), Limit: 10})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	got := recommendationNames(response.Recommendations)
+	want := []string{"given-before", "plain"}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+	if response.ExcludedOwned != 1 {
+		t.Errorf("Expected 1 excluded own purchase, got %d", response.ExcludedOwned)
+	}
+	if seasonalityRepo.calls != 0 {
+		t.Errorf("Expected personalizing stages to be skipped for gifts, got %d seasonality builds", seasonalityRepo.calls)
+	}
+}
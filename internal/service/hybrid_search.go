@@ -0,0 +1,281 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Hybrid search sides, used as weight keys
+const (
+	HybridSideLexical = "lexical"
+	HybridSideVector  = "vector"
+)
+
+// Hybrid query types, selecting the default weights for a query
+const (
+	HybridQueryTypeKeyword         = "keyword"
+	HybridQueryTypeNaturalLanguage = "natural_language"
+)
+
+// HybridSearchTuning configures the blend of lexical and vector search for "hybrid" searches
+type HybridSearchTuning struct {
+	Weights              map[string]float64            `json:"weights"`                 // Default "lexical" and "vector" weights
+	QueryTypeWeights     map[string]map[string]float64 `json:"query_type_weights"`      // Weights by query type, "keyword" or "natural_language"
+	KeywordQueryMaxTerms int                           `json:"keyword_query_max_terms"` // Queries with at most this many lexical terms are keyword queries
+	CandidateMultiplier  int                           `json:"candidate_multiplier"`    // Candidates fetched from each side per requested result
+}
+
+// DefaultHybridSearchTuning returns the hybrid search tuning used when none is configured
+func DefaultHybridSearchTuning() HybridSearchTuning {
+	return HybridSearchTuning{
+		Weights: map[string]float64{
+			HybridSideLexical: 0.3,
+			HybridSideVector:  0.7,
+		},
+		QueryTypeWeights: map[string]map[string]float64{
+			HybridQueryTypeKeyword: {
+				HybridSideLexical: 0.5,
+				HybridSideVector:  0.5,
+			},
+			HybridQueryTypeNaturalLanguage: {
+				HybridSideLexical: 0.2,
+				HybridSideVector:  0.8,
+			},
+		},
+		KeywordQueryMaxTerms: 2,
+		CandidateMultiplier:  2,
+	}
+}
+
+// HybridQueryType classifies a query by its number of lexical terms: short queries such as product
+// names are keyword queries, where exact matches matter more than for natural language descriptions.
+func HybridQueryType(query string, tuning HybridSearchTuning) string {
+	maxTerms := tuning.KeywordQueryMaxTerms
+	if maxTerms <= 0 {
+		maxTerms = DefaultHybridSearchTuning().KeywordQueryMaxTerms
+	}
+	if len(LexicalTerms(query)) <= maxTerms {
+		return HybridQueryTypeKeyword
+	}
+	return HybridQueryTypeNaturalLanguage
+}
+
+// ResolveHybridWeights returns the weights to blend a query's lexical and vector scores with,
+// normalized to sum to 1, and the query's type. Requested weights take precedence over the
+// query type's weights, which take precedence over the default weights.
+func ResolveHybridWeights(requested map[string]float64, query string, tuning HybridSearchTuning) (map[string]float64, string) {
+	queryType := HybridQueryType(query, tuning)
+
+	if weights, ok := normalizeHybridWeights(requested); ok {
+		return weights, queryType
+	}
+	if weights, ok := normalizeHybridWeights(tuning.QueryTypeWeights[queryType]); ok {
+		return weights, queryType
+	}
+	if weights, ok := normalizeHybridWeights(tuning.Weights); ok {
+		return weights, queryType
+	}
+
+	weights, _ := normalizeHybridWeights(DefaultHybridSearchTuning().Weights)
+	return weights, queryType
+}
+
+// ValidateHybridWeights checks that weights only use the "lexical" and "vector" keys and are not negative
+func ValidateHybridWeights(weights map[string]float64) error {
+	for side, weight := range weights {
+		if side != HybridSideLexical && side != HybridSideVector {
+			return fmt.Errorf("unknown hybrid weight %q, must be %q or %q", side, HybridSideLexical, HybridSideVector)
+		}
+		if weight < 0 {
+			return fmt.Errorf("hybrid weight %q must not be negative", side)
+		}
+	}
+	return nil
+}
+
+// normalizeHybridWeights scales weights to sum to 1, reporting false when they sum to nothing
+func normalizeHybridWeights(weights map[string]float64) (map[string]float64, bool) {
+	lexical := max(weights[HybridSideLexical], 0)
+	vector := max(weights[HybridSideVector], 0)
+	total := lexical + vector
+	if total <= 0 {
+		return nil, false
+	}
+
+	return map[string]float64{
+		HybridSideLexical: lexical / total,
+		HybridSideVector:  vector / total,
+	}, true
+}
+
+// BlendHybridResults merges vector and lexical results by a weighted sum of their min-max normalized
+// scores; a product missing from one side scores 0 there. When all of a side's results score the
+// same they normalize to 1. Ties go to the higher vector score.
+func BlendHybridResults(vector, lexical []RAGSearchResult, weights map[string]float64, limit int) []RAGSearchResult {
+	vectorScores := normalizedScores(vector)
+	lexicalScores := normalizedScores(lexical)
+
+	type blended struct {
+		result      RAGSearchResult
+		vectorScore float64
+		score       float64
+	}
+
+	var order []string
+	byProduct := make(map[string]*blended)
+	add := func(results []RAGSearchResult, scores []float64, side string) {
+		for i, result := range results {
+			key := result.ProductID.String()
+			entry, ok := byProduct[key]
+			if !ok {
+				entry = &blended{result: result}
+				entry.result.MatchedCriteria = nil
+				entry.result.Metadata = make(map[string]interface{}, len(result.Metadata)+2)
+				byProduct[key] = entry
+				order = append(order, key)
+			} else if result.ConfidenceScore > entry.result.ConfidenceScore {
+				entry.result.ConfidenceScore = result.ConfidenceScore
+			}
+
+			entry.score += weights[side] * scores[i]
+			if side == HybridSideVector {
+				entry.vectorScore = scores[i]
+			}
+			entry.result.Metadata[side+"_score"] = scores[i]
+			for k, v := range result.Metadata {
+				if _, exists := entry.result.Metadata[k]; !exists {
+					entry.result.Metadata[k] = v
+				}
+			}
+			for _, criterion := range result.MatchedCriteria {
+				if !contains(entry.result.MatchedCriteria, criterion) {
+					entry.result.MatchedCriteria = append(entry.result.MatchedCriteria, criterion)
+				}
+			}
+		}
+	}
+	add(vector, vectorScores, HybridSideVector)
+	add(lexical, lexicalScores, HybridSideLexical)
+
+	entries := make([]*blended, 0, len(order))
+	for _, key := range order {
+		entries = append(entries, byProduct[key])
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].score != entries[j].score {
+			return entries[i].score > entries[j].score
+		}
+		if entries[i].vectorScore != entries[j].vectorScore {
+			return entries[i].vectorScore > entries[j].vectorScore
+		}
+		return entries[i].result.ProductID.String() < entries[j].result.ProductID.String()
+	})
+	if limit > 0 && len(entries) > limit {
+		entries = entries[:limit]
+	}
+
+	results := make([]RAGSearchResult, len(entries))
+	for i, entry := range entries {
+		results[i] = entry.result
+		results[i].SimilarityScore = entry.score
+		results[i].DistanceScore = 1 - entry.score
+		results[i].SearchMethod = "hybrid_search"
+		results[i].RetrievalRank = i + 1
+	}
+
+	return results
+}
+
+// normalizedScores min-max normalizes the similarity scores of results
+func normalizedScores(results []RAGSearchResult) []float64 {
+	scores := make([]float64, len(results))
+	if len(results) == 0 {
+		return scores
+	}
+
+	low, high := results[0].SimilarityScore, results[0].SimilarityScore
+	for _, result := range results {
+		if result.SimilarityScore < low {
+			low = result.SimilarityScore
+		}
+		if result.SimilarityScore > high {
+			high = result.SimilarityScore
+		}
+	}
+	for i, result := range results {
+		scores[i] = 1
+		if high > low {
+			scores[i] = (result.SimilarityScore - low) / (high - low)
+		}
+	}
+
+	return scores
+}
+
+// hybridSearch runs vector search in the knowledge base and lexical search in parallel and blends
+// their results (see BlendHybridResults). If one side fails the other is served alone, with its
+// weight reported as 1; the applied weights are reported in the response's search metadata.
+func (rs *RecommendationServiceV2) hybridSearch(ctx context.Context, query string, limit int, filters map[string]interface{}, requestedWeights map[string]float64) (*RAGSemanticSearchResponse, error) {
+	startTime := time.Now()
+
+	weights, queryType := ResolveHybridWeights(requestedWeights, query, rs.hybridTuning)
+	candidates := limit * max(rs.hybridTuning.CandidateMultiplier, 1)
+
+	vectorFilters := make(map[string]interface{}, len(filters)+1)
+	for k, v := range filters {
+		vectorFilters[k] = v
+	}
+	vectorFilters[RetrievalSearchTypeFilter] = "semantic"
+
+	var vectorResponse, lexicalResponse *RAGSemanticSearchResponse
+	var vectorErr, lexicalErr error
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		vectorResponse, vectorErr = rs.rag.GetProductsWithSemanticSearch(ctx, query, candidates, vectorFilters)
+	}()
+	go func() {
+		defer wg.Done()
+		lexicalResponse, lexicalErr = rs.lexicalSearch.Search(ctx, query, candidates, filters)
+	}()
+	wg.Wait()
+
+	var vectorResults, lexicalResults []RAGSearchResult
+	switch {
+	case vectorErr != nil && lexicalErr != nil:
+		return nil, fmt.Errorf("failed to perform hybrid search: %w", vectorErr)
+	case vectorErr != nil:
+		log.Printf("Warning: vector search failed, serving hybrid search lexically: %v", vectorErr)
+		weights = map[string]float64{HybridSideLexical: 1, HybridSideVector: 0}
+		lexicalResults = lexicalResponse.Results
+	case lexicalErr != nil:
+		log.Printf("Warning: lexical search failed, serving hybrid search from vectors: %v", lexicalErr)
+		weights = map[string]float64{HybridSideLexical: 0, HybridSideVector: 1}
+		vectorResults = vectorResponse.Results
+	default:
+		vectorResults, lexicalResults = vectorResponse.Results, lexicalResponse.Results
+	}
+
+	results := BlendHybridResults(vectorResults, lexicalResults, weights, limit)
+
+	return &RAGSemanticSearchResponse{
+		Query:            query,
+		Results:          results,
+		TotalFound:       len(results),
+		ProcessingTimeMs: time.Since(startTime).Milliseconds(),
+		SearchMetadata: &RAGSearchMeta{
+			SearchType:         "hybrid",
+			EmbeddingModel:     rs.embeddingModelID,
+			KnowledgeBaseID:    rs.knowledgeBaseID,
+			SimilarityMetric:   "weighted_min_max",
+			FiltersApplied:     filters,
+			HybridSearchWeight: weights,
+			HybridQueryType:    queryType,
+		},
+	}, nil
+}
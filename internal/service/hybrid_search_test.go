@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestResolveHybridWeights(t *testing.T) {
+	tuning := DefaultHybridSearchTuning()
+
+	cases := []struct {
+		name      string
+		requested map[string]float64
+		query     string
+		tuning    HybridSearchTuning
+		queryType string
+		lexical   float64
+	}{
+		{"keyword queries use keyword weights", nil, "ワイヤレスイヤホン", tuning, HybridQueryTypeKeyword, 0.5},
+		{"long queries use natural language weights", nil, "赤い傘と黒い長靴が欲しいです", tuning, HybridQueryTypeNaturalLanguage, 0.2},
+		{"requested weights are normalized and take precedence", map[string]float64{"lexical": 1, "vector": 3}, "イヤホン", tuning, HybridQueryTypeKeyword, 0.25},
+		{"requested weights summing to zero are ignored", map[string]float64{"lexical": 0}, "イヤホン", tuning, HybridQueryTypeKeyword, 0.5},
+		{"missing tuning uses the default weights", nil, "イヤホン", HybridSearchTuning{}, HybridQueryTypeKeyword, 0.3},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			weights, queryType := ResolveHybridWeights(c.requested, c.query, c.tuning)
+			if queryType != c.queryType {
+				t.Errorf("Expected query type %s, got %s", c.queryType, queryType)
+			}
+			if math.Abs(weights[HybridSideLexical]-c.lexical) > 1e-9 || math.Abs(weights[HybridSideVector]-(1-c.lexical)) > 1e-9 {
+				t.Errorf("Expected lexical weight %v, got %v", c.lexical, weights)
+			}
+		})
+	}
+}
+
+func TestBlendHybridResults(t *testing.T) {
+	a, b, c, d := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	vector := []RAGSearchResult{
+		{ProductID: a, SimilarityScore: 0.9, MatchedCriteria: []string{"semantic_similarity"}},
+		{ProductID: b, SimilarityScore: 0.5, MatchedCriteria: []string{"semantic_similarity"}},
+		{ProductID: c, SimilarityScore: 0.1, MatchedCriteria: []string{"semantic_similarity"}},
+	}
+	lexical := []RAGSearchResult{
+		{ProductID: c, SimilarityScore: 1.0, MatchedCriteria: []string{"name"}, Metadata: map[string]interface{}{"bm25_score": 4.2}},
+		{ProductID: d, SimilarityScore: 0.5, MatchedCriteria: []string{"tags"}},
+	}
+
+	t.Run("blends normalized scores by weight", func(t *testing.T) {
+		results := BlendHybridResults(vector, lexical, map[string]float64{"lexical": 0.5, "vector": 0.5}, 0)
+
+		var order []uuid.UUID
+		for _, result := range results {
+			order = append(order, result.ProductID)
+		}
+		// a and c tie at 0.5; a wins on its vector score
+		if !reflect.DeepEqual(order, []uuid.UUID{a, c, b, d}) {
+			t.Fatalf("Expected order a, c, b, d, got %v", order)
+		}
+		if results[1].SimilarityScore != 0.5 || results[1].SearchMethod != "hybrid_search" || results[1].RetrievalRank != 2 {
+			t.Errorf("Expected c blended to 0.5 at rank 2, got %+v", results[1])
+		}
+		if !reflect.DeepEqual(results[1].MatchedCriteria, []string{"semantic_similarity", "name"}) {
+			t.Errorf("Expected both sides' criteria, got %v", results[1].MatchedCriteria)
+		}
+		if results[1].Metadata["lexical_score"] != 1.0 || results[1].Metadata["vector_score"] != 0.0 || results[1].Metadata["bm25_score"] != 4.2 {
+			t.Errorf("Expected side scores in the metadata, got %v", results[1].Metadata)
+		}
+	})
+
+	t.Run("weights decide between the sides", func(t *testing.T) {
+		results := BlendHybridResults(vector, lexical, map[string]float64{"lexical": 0.9, "vector": 0.1}, 2)
+		if len(results) != 2 || results[0].ProductID != c {
+			t.Errorf("Expected the best lexical match first of 2, got %+v", results)
+		}
+	})
+
+	t.Run("equal scores normalize to 1", func(t *testing.T) {
+		results := BlendHybridResults(vector[:1], nil, map[string]float64{"lexical": 0, "vector": 1}, 0)
+		if len(results) != 1 || results[0].SimilarityScore != 1 {
+			t.Errorf("Expected a single result scored 1, got %+v", results)
+		}
+	})
+}
+
+func TestHybridSearch(t *testing.T) {
+	ctx := context.Background()
+	documents := lexicalDocuments()
+	vectorResponse := &RAGSemanticSearchResponse{
+		Results: []RAGSearchResult{
+			{ProductID: documents[2].ProductID, SimilarityScore: 0.8},
+			{ProductID: documents[0].ProductID, SimilarityScore: 0.6},
+			{ProductID: documents[1].ProductID, SimilarityScore: 0.2},
+		},
+	}
+	newService := func(rag *fakeRAG, lexicalErr error) *RecommendationServiceV2 {
+		repo := &fakeLexicalSearchRepository{documents: documents, err: lexicalErr}
+		return &RecommendationServiceV2{
+			rag:           rag,
+			lexicalSearch: NewLexicalSearchService(repo, DefaultLexicalSearchTuning()),
+			hybridTuning:  DefaultHybridSearchTuning(),
+		}
+	}
+
+	t.Run("blends both sides and reports the weights", func(t *testing.T) {
+		rag := &fakeRAG{response: vectorResponse}
+		response, err := newService(rag, nil).searchProducts(ctx, "ワイヤレスイヤホン", 10, map[string]interface{}{"price_max": 5000.0}, "hybrid", nil)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if !isHybridSearch(response) || response.SearchMetadata.HybridQueryType != HybridQueryTypeKeyword {
+			t.Fatalf("Expected a keyword hybrid search, got %+v", response.SearchMetadata)
+		}
+		if !reflect.DeepEqual(response.SearchMetadata.HybridSearchWeight, map[string]float64{"lexical": 0.5, "vector": 0.5}) {
+			t.Errorf("Expected the keyword weights, got %v", response.SearchMetadata.HybridSearchWeight)
+		}
+		if rag.filters[RetrievalSearchTypeFilter] != "semantic" || rag.filters["price_max"] != 5000.0 {
+			t.Errorf("Expected a filtered vector only knowledge base search, got %v", rag.filters)
+		}
+		// documents[0] is the best lexical match and the second best vector match
+		if response.TotalFound != 3 || response.Results[0].ProductID != documents[0].ProductID {
+			t.Errorf("Expected documents[0] first of 3, got %+v", response.Results)
+		}
+	})
+
+	t.Run("a failed side is reported with no weight", func(t *testing.T) {
+		response, err := newService(&fakeRAG{err: errors.New("throttled")}, nil).hybridSearch(ctx, "イヤホン", 10, nil, map[string]float64{"vector": 1})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if !reflect.DeepEqual(response.SearchMetadata.HybridSearchWeight, map[string]float64{"lexical": 1, "vector": 0}) {
+			t.Errorf("Expected lexical search alone, got %v", response.SearchMetadata.HybridSearchWeight)
+		}
+	})
+
+	t.Run("both sides failing is an error", func(t *testing.T) {
+		if _, err := newService(&fakeRAG{err: errors.New("throttled")}, errors.New("db down")).hybridSearch(ctx, "イヤホン", 10, nil, nil); err == nil {
+			t.Error("Expected an error")
+		}
+	})
+
+	t.Run("the knowledge base search type follows the request", func(t *testing.T) {
+		rag := &fakeRAG{response: vectorResponse}
+		rs := &RecommendationServiceV2{rag: rag}
+
+		for _, searchType := range []string{"semantic", "hybrid"} {
+			if _, err := rs.searchProducts(ctx, "イヤホン", 10, nil, searchType, nil); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if rag.filters[RetrievalSearchTypeFilter] != searchType {
+				t.Errorf("Expected knowledge base search type %s, got %v", searchType, rag.filters)
+			}
+		}
+	})
+}
@@ -0,0 +1,22 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ImpressionRepositoryInterface defines persistence for recommendation impressions
+// This interface is defined in the service package as it is consumed by services
+type ImpressionRepositoryInterface interface {
+	// RecordImpressions stores one impression per returned product with its position in the list
+	RecordImpressions(ctx context.Context, recommendationID, customerID uuid.UUID, contextType string, productIDs []uuid.UUID) error
+
+	// MarkClicked marks the impressions of the given products in a recommendation as clicked
+	MarkClicked(ctx context.Context, recommendationID uuid.UUID, productIDs []uuid.UUID, clickedAt time.Time) error
+
+	// GetUnclickedImpressionCounts returns, for the given products shown to the customer in the context
+	// since the given time and never clicked in that period, how many times each was shown
+	GetUnclickedImpressionCounts(ctx context.Context, customerID uuid.UUID, contextType string, since time.Time, productIDs []uuid.UUID) (map[uuid.UUID]int, error)
+}
@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"ec-recommend/internal/dto"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FrequencyCap demotes products a customer keeps being shown without clicking
+type FrequencyCap struct {
+	MaxImpressions int     `json:"max_impressions"` // Impressions without a click before a product is demoted; 0 disables the cap
+	WindowDays     int     `json:"window_days"`     // Period impressions and clicks are counted over
+	Demotion       float64 `json:"demotion"`        // Multiplier applied to the confidence score of capped products
+}
+
+// FrequencyCapTuning configures impression-based fatigue demotion
+type FrequencyCapTuning struct {
+	Disabled bool                    `json:"disabled,omitempty"`
+	Default  FrequencyCap            `json:"default"`            // Cap for contexts without an override
+	Contexts map[string]FrequencyCap `json:"contexts,omitempty"` // Caps by context type, e.g. "homepage"
+}
+
+// DefaultFrequencyCapTuning returns the frequency cap tuning used when none is configured
+func DefaultFrequencyCapTuning() FrequencyCapTuning {
+	return FrequencyCapTuning{
+		Default: FrequencyCap{
+			MaxImpressions: 5,
+			WindowDays:     7,
+			Demotion:       0.3,
+		},
+	}
+}
+
+// CapFor returns the frequency cap of a context type
+func (t FrequencyCapTuning) CapFor(contextType string) FrequencyCap {
+	if contextCap, ok := t.Contexts[contextType]; ok {
+		return contextCap
+	}
+	return t.Default
+}
+
+// ApplyFrequencyCap demotes recommendations shown at least MaxImpressions times without a click
+// and re-sorts the list by confidence score. It returns the number of demoted products.
+func ApplyFrequencyCap(recommendations []dto.ProductRecommendationV2, unclicked map[uuid.UUID]int, frequencyCap FrequencyCap) int {
+	if frequencyCap.MaxImpressions <= 0 {
+		return 0
+	}
+
+	demoted := 0
+	for i := range recommendations {
+		if unclicked[recommendations[i].ProductID] >= frequencyCap.MaxImpressions {
+			recommendations[i].ConfidenceScore *= frequencyCap.Demotion
+			demoted++
+		}
+	}
+
+	if demoted > 0 {
+		sort.SliceStable(recommendations, func(i, j int) bool {
+			return recommendations[i].ConfidenceScore > recommendations[j].ConfidenceScore
+		})
+	}
+
+	return demoted
+}
+
+// ImpressionService records which products were shown to whom and which of them were clicked
+type ImpressionService struct {
+	repo   ImpressionRepositoryInterface
+	tuning FrequencyCapTuning
+}
+
+// NewImpressionService creates a new impression service
+func NewImpressionService(repo ImpressionRepositoryInterface, tuning FrequencyCapTuning) *ImpressionService {
+	return &ImpressionService{
+		repo:   repo,
+		tuning: tuning,
+	}
+}
+
+// RecordImpressions logs the products returned by a recommendation request
+func (is *ImpressionService) RecordImpressions(ctx context.Context, recommendationID, customerID uuid.UUID, contextType string, productIDs []uuid.UUID) error {
+	if customerID == uuid.Nil {
+		return nil
+	}
+
+	if err := is.repo.RecordImpressions(ctx, recommendationID, customerID, contextType, productIDs); err != nil {
+		return fmt.Errorf("failed to record impressions: %w", err)
+	}
+
+	return nil
+}
+
+// RecordInteraction marks the impressions of clicked products as clicked
+func (is *ImpressionService) RecordInteraction(ctx context.Context, analytics *dto.RecommendationAnalytics) error {
+	if err := is.repo.MarkClicked(ctx, analytics.RecommendationID, analytics.ClickedProducts, time.Now()); err != nil {
+		return fmt.Errorf("failed to record impression clicks: %w", err)
+	}
+
+	return nil
+}
+
+// FrequencyCapStage demotes products the customer has repeatedly been shown in the same context
+// without clicking
+type FrequencyCapStage struct {
+	impressions *ImpressionService
+}
+
+// NewFrequencyCapStage creates a frequency cap post-ranking stage
+func NewFrequencyCapStage(impressions *ImpressionService) *FrequencyCapStage {
+	return &FrequencyCapStage{
+		impressions: impressions,
+	}
+}
+
+// Name returns the stage identifier
+func (fc *FrequencyCapStage) Name() string {
+	return "frequency_cap"
+}
+
+// enforcesPolicy marks the stage to run for gift results too
+func (fc *FrequencyCapStage) enforcesPolicy() {}
+
+// Apply demotes fatigued products according to the cap of the request context
+func (fc *FrequencyCapStage) Apply(ctx context.Context, recommendations []dto.ProductRecommendationV2, rc *RankingContext) ([]dto.ProductRecommendationV2, error) {
+	frequencyCap := fc.impressions.tuning.CapFor(rc.ContextType)
+	if fc.impressions.tuning.Disabled || frequencyCap.MaxImpressions <= 0 || rc.CustomerID == uuid.Nil || len(recommendations) == 0 {
+		return recommendations, nil
+	}
+
+	productIDs := make([]uuid.UUID, len(recommendations))
+	for i, rec := range recommendations {
+		productIDs[i] = rec.ProductID
+	}
+
+	since := time.Now().AddDate(0, 0, -frequencyCap.WindowDays)
+	unclicked, err := fc.impressions.repo.GetUnclickedImpressionCounts(ctx, rc.CustomerID, rc.ContextType, since, productIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get impression counts: %w", err)
+	}
+
+	ApplyFrequencyCap(recommendations, unclicked, frequencyCap)
+
+	return recommendations, nil
+}
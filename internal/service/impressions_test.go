@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"ec-recommend/internal/dto"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// fakeImpressionRepository serves fixed unclicked impression counts per context
+type fakeImpressionRepository struct {
+	unclicked map[string]map[uuid.UUID]int
+	since     time.Time
+}
+
+func (f *fakeImpressionRepository) RecordImpressions(ctx context.Context, recommendationID, customerID uuid.UUID, contextType string, productIDs []uuid.UUID) error {
+	return nil
+}
+
+func (f *fakeImpressionRepository) MarkClicked(ctx context.Context, recommendationID uuid.UUID, productIDs []uuid.UUID, clickedAt time.Time) error {
+	return nil
+}
+
+func (f *fakeImpressionRepository) GetUnclickedImpressionCounts(ctx context.Context, customerID uuid.UUID, contextType string, since time.Time, productIDs []uuid.UUID) (map[uuid.UUID]int, error) {
+	f.since = since
+	return f.unclicked[contextType], nil
+}
+
+func TestFrequencyCapStage(t *testing.T) {
+	fatigued := uuid.New()
+	fresh := uuid.New()
+	tuning := DefaultFrequencyCapTuning()
+	tuning.Contexts = map[string]FrequencyCap{
+		"cart": {MaxImpressions: 3, WindowDays: 3, Demotion: 0.2},
+	}
+
+	repo := &fakeImpressionRepository{unclicked: map[string]map[uuid.UUID]int{
+		"homepage": {fatigued: 4},
+		"cart":     {fatigued: 4},
+	}}
+	stage := NewFrequencyCapStage(NewImpressionService(repo, tuning))
+
+	newRecommendations := func() []dto.ProductRecommendationV2 {
+		return []dto.ProductRecommendationV2{
+			{ProductID: fatigued, ConfidenceScore: 0.9},
+			{ProductID: fresh, ConfidenceScore: 0.5},
+		}
+	}
+
+	t.Run("keeps products below the context cap", func(t *testing.T) {
+		result, err := stage.Apply(context.Background(), newRecommendations(), &RankingContext{CustomerID: uuid.New(), ContextType: "homepage"})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if result[0].ProductID != fatigued || result[0].ConfidenceScore != 0.9 {
+			t.Errorf("Expected the product with 4 of 5 impressions to keep its rank, got %+v", result[0])
+		}
+	})
+
+	t.Run("demotes products over the context cap", func(t *testing.T) {
+		result, err := stage.Apply(context.Background(), newRecommendations(), &RankingContext{CustomerID: uuid.New(), ContextType: "cart"})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if result[0].ProductID != fresh || result[1].ProductID != fatigued {
+			t.Errorf("Expected the fatigued product to be demoted below the fresh one, got %+v", result)
+		}
+		if window := time.Since(repo.since); window < 71*time.Hour || window > 73*time.Hour {
+			t.Errorf("Expected the cart window of 3 days, got %v", window)
+		}
+	})
+
+	t.Run("skips anonymous customers", func(t *testing.T) {
+		result, err := stage.Apply(context.Background(), newRecommendations(), &RankingContext{ContextType: "cart"})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if result[0].ProductID != fatigued {
+			t.Errorf("Expected no demotion without a customer, got %+v", result)
+		}
+	})
+}
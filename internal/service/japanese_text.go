@@ -0,0 +1,222 @@
+package service
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// FoldSearchText unifies the forms of a query without changing its script: NFKC folds full-width
+// and half-width forms (ｽﾏﾎ → スマホ, ＡＢＣ → ABC), letters are lowercased and runs of whitespace
+// become a single space. The result is suitable for display and for knowledge base retrieval.
+func FoldSearchText(text string) string {
+	return strings.Join(strings.Fields(strings.ToLower(norm.NFKC.String(text))), " ")
+}
+
+// NormalizeSearchText folds text for Japanese prefix matching: FoldSearchText followed by folding
+// katakana to hiragana, so スマホ, すまほ and ｽﾏﾎ share one form. Runes map one to one, so rune
+// offsets in the folded text and the normalized text agree.
+func NormalizeSearchText(text string) string {
+	return strings.Map(katakanaToHiragana, FoldSearchText(text))
+}
+
+// katakanaToHiragana maps katakana with a hiragana counterpart (ァ-ヶ and the iteration marks) to it
+func katakanaToHiragana(r rune) rune {
+	switch {
+	case r >= 'ァ' && r <= 'ヶ', r == 'ヽ', r == 'ヾ':
+		return r - 0x60
+	default:
+		return r
+	}
+}
+
+// searchWords splits normalized text into words at spaces and the separators used in product and
+// category names (スカート・パンツ, T-shirt/Tops)
+func searchWords(normalized string) []string {
+	return strings.FieldsFunc(normalized, func(r rune) bool {
+		switch r {
+		case ' ', '・', '/', ',', '、', '|', '(', ')', '「', '」', '【', '】':
+			return true
+		}
+		return false
+	})
+}
+
+// ReadingDictionary transliterates kanji and Latin words in normalized text into hiragana readings
+type ReadingDictionary struct {
+	readings map[string][]string
+	keys     [][]rune // Longest first
+}
+
+// NewReadingDictionary normalizes the given word → readings pairs
+func NewReadingDictionary(readings map[string][]string) *ReadingDictionary {
+	dictionary := &ReadingDictionary{readings: make(map[string][]string, len(readings))}
+	for word, wordReadings := range readings {
+		key := NormalizeSearchText(word)
+		if key == "" {
+			continue
+		}
+		for _, reading := range wordReadings {
+			if normalized := NormalizeSearchText(reading); normalized != "" && normalized != key {
+				dictionary.readings[key] = append(dictionary.readings[key], normalized)
+			}
+		}
+		if len(dictionary.readings[key]) == 0 {
+			delete(dictionary.readings, key)
+		}
+	}
+	for key := range dictionary.readings {
+		dictionary.keys = append(dictionary.keys, []rune(key))
+	}
+	sort.Slice(dictionary.keys, func(i, j int) bool {
+		if len(dictionary.keys[i]) != len(dictionary.keys[j]) {
+			return len(dictionary.keys[i]) > len(dictionary.keys[j])
+		}
+		return string(dictionary.keys[i]) < string(dictionary.keys[j])
+	})
+	return dictionary
+}
+
+// Readings returns the kana readings of normalized text. Every reading of the whole text is returned
+// when the dictionary lists it; otherwise dictionary words are replaced left to right, longest first,
+// by their first reading (家具 → かぐ, オーガニック食品 → おーがにっくしょくひん). Latin words only
+// match whole words so "sharp" does not rewrite "sharpener". Nil when nothing was replaced.
+func (d *ReadingDictionary) Readings(normalized string) []string {
+	if d == nil || normalized == "" {
+		return nil
+	}
+	if readings, ok := d.readings[normalized]; ok {
+		return readings
+	}
+
+	runes := []rune(normalized)
+	var b strings.Builder
+	replaced := false
+	for i := 0; i < len(runes); {
+		var matched []rune
+		for _, key := range d.keys {
+			if hasRunePrefix(runes[i:], key) && atLatinWordBoundary(runes, i, i+len(key)) {
+				matched = key
+				break
+			}
+		}
+		if matched == nil {
+			b.WriteRune(runes[i])
+			i++
+			continue
+		}
+		b.WriteString(d.readings[string(matched)][0])
+		i += len(matched)
+		replaced = true
+	}
+
+	if !replaced {
+		return nil
+	}
+	return []string{b.String()}
+}
+
+// hasRunePrefix reports whether runes starts with prefix
+func hasRunePrefix(runes, prefix []rune) bool {
+	if len(prefix) > len(runes) {
+		return false
+	}
+	for i, r := range prefix {
+		if runes[i] != r {
+			return false
+		}
+	}
+	return true
+}
+
+// atLatinWordBoundary reports whether runes[start:end] does not cut a Latin word: when its first or
+// last rune is a Latin letter or digit, the neighbouring rune on that side must not be one
+func atLatinWordBoundary(runes []rune, start, end int) bool {
+	if isLatinWordRune(runes[start]) && start > 0 && isLatinWordRune(runes[start-1]) {
+		return false
+	}
+	if isLatinWordRune(runes[end-1]) && end < len(runes) && isLatinWordRune(runes[end]) {
+		return false
+	}
+	return true
+}
+
+// isLatinWordRune reports whether r is an ASCII letter or digit
+func isLatinWordRune(r rune) bool {
+	return r < utf8.RuneSelf && (unicode.IsLetter(r) || unicode.IsDigit(r))
+}
+
+// LexicalTerms splits a query into terms for lexical search. Japanese is written without spaces,
+// so words are further split where the script changes between kanji, kana and Latin text
+// (iPhoneケース → iphone, ケース). Single hiragana characters split off this way are particles or
+// okurigana (赤い, 傘の) and are dropped. Terms are normalized like NormalizeSearchText.
+func LexicalTerms(text string) []string {
+	seen := make(map[string]bool)
+	var terms []string
+	for _, word := range searchWords(FoldSearchText(text)) {
+		for _, segment := range scriptSegments([]rune(word)) {
+			if len(segment) == 1 && unicode.Is(unicode.Hiragana, segment[0]) {
+				continue
+			}
+			term := strings.Map(katakanaToHiragana, string(segment))
+			if !seen[term] {
+				seen[term] = true
+				terms = append(terms, term)
+			}
+		}
+	}
+	return terms
+}
+
+// Script classes used to segment Japanese words
+const (
+	scriptOther = iota
+	scriptKanji
+	scriptHiragana
+	scriptKatakana
+)
+
+// scriptSegments splits a word where its script class changes. The prolonged sound mark and
+// iteration marks continue the preceding segment, and segments without a letter or digit are dropped.
+func scriptSegments(word []rune) [][]rune {
+	var segments [][]rune
+	start, current := 0, -1
+	flush := func(end int) {
+		for _, r := range word[start:end] {
+			if unicode.IsLetter(r) || unicode.IsDigit(r) {
+				segments = append(segments, word[start:end])
+				return
+			}
+		}
+	}
+
+	for i, r := range word {
+		class := current
+		switch {
+		case r == 'ー' || r == '々' || r == 'ゝ' || r == 'ゞ' || r == 'ヽ' || r == 'ヾ':
+			if current < 0 {
+				class = scriptOther
+			}
+		case unicode.Is(unicode.Han, r):
+			class = scriptKanji
+		case unicode.Is(unicode.Hiragana, r):
+			class = scriptHiragana
+		case unicode.Is(unicode.Katakana, r):
+			class = scriptKatakana
+		default:
+			class = scriptOther
+		}
+		if current >= 0 && class != current {
+			flush(i)
+			start = i
+		}
+		current = class
+	}
+	if len(word) > 0 {
+		flush(len(word))
+	}
+	return segments
+}
@@ -0,0 +1,293 @@
+package service
+
+import (
+	"context"
+	"ec-recommend/internal/dto"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Lexical search fields, in the order matches are reported
+var lexicalFields = []string{"name", "brand", "tags", "features", "description"}
+
+// LexicalSearchTuning configures keyword search over the product catalog in Postgres
+type LexicalSearchTuning struct {
+	Fallback       bool               `json:"fallback"`        // Serve semantic searches lexically when the knowledge base fails or finds nothing
+	CandidateLimit int                `json:"candidate_limit"` // Products fetched for BM25 scoring, those matching the most terms first
+	MaxTerms       int                `json:"max_terms"`       // Query terms used; later terms are ignored
+	K1             float64            `json:"k1"`              // BM25 term frequency saturation
+	B              float64            `json:"b"`               // BM25 document length normalization, from 0 (none) to 1 (full)
+	FieldWeights   map[string]float64 `json:"field_weights"`   // Term frequency weight of "name", "brand", "tags", "features" and "description"
+}
+
+// DefaultLexicalSearchTuning returns the lexical search tuning used when none is configured
+func DefaultLexicalSearchTuning() LexicalSearchTuning {
+	return LexicalSearchTuning{
+		Fallback:       true,
+		CandidateLimit: 200,
+		MaxTerms:       8,
+		K1:             1.2,
+		B:              0.75,
+		FieldWeights: map[string]float64{
+			"name":        3.0,
+			"brand":       2.0,
+			"tags":        2.0,
+			"features":    1.0,
+			"description": 1.0,
+		},
+	}
+}
+
+// LexicalSearchService ranks products by BM25 over their name, brand, tags, feature values and
+// description. Query terms come from LexicalTerms, so Japanese queries without spaces still match.
+type LexicalSearchService struct {
+	repo   LexicalSearchRepositoryInterface
+	tuning LexicalSearchTuning
+}
+
+// NewLexicalSearchService creates a new lexical search service instance
+func NewLexicalSearchService(repo LexicalSearchRepositoryInterface, tuning LexicalSearchTuning) *LexicalSearchService {
+	defaults := DefaultLexicalSearchTuning()
+	if tuning.CandidateLimit <= 0 {
+		tuning.CandidateLimit = defaults.CandidateLimit
+	}
+	if tuning.MaxTerms <= 0 {
+		tuning.MaxTerms = defaults.MaxTerms
+	}
+	if tuning.FieldWeights == nil {
+		tuning.FieldWeights = defaults.FieldWeights
+	}
+
+	return &LexicalSearchService{
+		repo:   repo,
+		tuning: tuning,
+	}
+}
+
+// FallbackEnabled reports whether semantic searches should be served lexically when the knowledge base fails
+func (s *LexicalSearchService) FallbackEnabled() bool {
+	return s != nil && s.tuning.Fallback
+}
+
+// Search returns the products best matching the query's terms in the shape of a knowledge base
+// search, so results convert like semantic search results. The filters use the knowledge base
+// filter keys; only the hard constraints are applied (see LexicalFilterFromMap).
+func (s *LexicalSearchService) Search(ctx context.Context, query string, limit int, filters map[string]interface{}) (*RAGSemanticSearchResponse, error) {
+	startTime := time.Now()
+
+	response := &RAGSemanticSearchResponse{
+		Query:   query,
+		Results: []RAGSearchResult{},
+		SearchMetadata: &RAGSearchMeta{
+			SearchType:       "keyword",
+			SimilarityMetric: "bm25",
+			FiltersApplied:   filters,
+		},
+	}
+
+	terms := LexicalTerms(query)
+	if len(terms) > s.tuning.MaxTerms {
+		terms = terms[:s.tuning.MaxTerms]
+	}
+	if len(terms) == 0 || limit <= 0 {
+		return response, nil
+	}
+
+	candidates, err := s.repo.GetLexicalCandidates(ctx, terms, LexicalFilterFromMap(filters), s.tuning.CandidateLimit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get lexical candidates: %w", err)
+	}
+	if len(candidates) == 0 {
+		response.ProcessingTimeMs = time.Since(startTime).Milliseconds()
+		return response, nil
+	}
+
+	stats, err := s.repo.GetLexicalCorpusStats(ctx, terms)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get lexical corpus stats: %w", err)
+	}
+
+	matches := ScoreLexicalDocuments(candidates, terms, stats, s.tuning)
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+
+	for i, match := range matches {
+		similarity := match.Score / matches[0].Score
+		response.Results = append(response.Results, RAGSearchResult{
+			ProductID:       match.Document.ProductID,
+			DistanceScore:   1 - similarity,
+			SimilarityScore: similarity,
+			ConfidenceScore: float64(len(match.MatchedTerms)) / float64(len(terms)),
+			SearchMethod:    "keyword_search",
+			MatchedCriteria: match.MatchedFields,
+			Metadata: map[string]interface{}{
+				"bm25_score":    match.Score,
+				"matched_terms": match.MatchedTerms,
+			},
+			Source:        "postgres_lexical",
+			RetrievalRank: i + 1,
+		})
+	}
+	response.TotalFound = len(response.Results)
+	response.ProcessingTimeMs = time.Since(startTime).Milliseconds()
+
+	return response, nil
+}
+
+// LexicalMatch is a scored lexical search candidate
+type LexicalMatch struct {
+	Document      dto.LexicalDocument
+	Score         float64
+	MatchedTerms  []string
+	MatchedFields []string
+}
+
+// ScoreLexicalDocuments ranks candidates by BM25F: a term's frequency is summed over the fields
+// with their weights before saturation, and documents are normalized by the length of their whole
+// search document. Candidates matching no term are dropped; ties go to the more popular product.
+func ScoreLexicalDocuments(documents []dto.LexicalDocument, terms []string, stats *dto.LexicalCorpusStats, tuning LexicalSearchTuning) []LexicalMatch {
+	documentCount := len(documents)
+	averageLength := 0.0
+	if stats != nil {
+		documentCount = max(documentCount, stats.DocumentCount)
+		averageLength = stats.AverageLength
+	}
+
+	fieldTexts := make([]map[string]string, len(documents))
+	lengths := make([]float64, len(documents))
+	totalLength := 0.0
+	for i, d := range documents {
+		fieldTexts[i] = map[string]string{
+			"name":        NormalizeSearchText(d.Name),
+			"brand":       NormalizeSearchText(d.Brand),
+			"tags":        NormalizeSearchText(strings.Join(d.Tags, " ")),
+			"features":    NormalizeSearchText(d.Features),
+			"description": NormalizeSearchText(d.Description),
+		}
+		lengths[i] = float64(d.Length)
+		if d.Length <= 0 {
+			for _, text := range fieldTexts[i] {
+				lengths[i] += float64(len([]rune(text)))
+			}
+		}
+		totalLength += lengths[i]
+	}
+	if averageLength <= 0 && len(documents) > 0 {
+		averageLength = totalLength / float64(len(documents))
+	}
+
+	idf := make(map[string]float64, len(terms))
+	for _, term := range terms {
+		frequency := 1
+		if stats != nil && stats.DocumentFrequency[term] > 0 {
+			frequency = min(stats.DocumentFrequency[term], documentCount)
+		}
+		idf[term] = math.Log(1 + (float64(documentCount-frequency)+0.5)/(float64(frequency)+0.5))
+	}
+
+	var matches []LexicalMatch
+	for i, d := range documents {
+		normalization := tuning.K1
+		if averageLength > 0 {
+			normalization = tuning.K1 * (1 - tuning.B + tuning.B*lengths[i]/averageLength)
+		}
+
+		match := LexicalMatch{Document: d}
+		matchedFields := make(map[string]bool)
+		for _, term := range terms {
+			frequency := 0.0
+			for _, field := range lexicalFields {
+				if count := strings.Count(fieldTexts[i][field], term); count > 0 {
+					frequency += tuning.FieldWeights[field] * float64(count)
+					matchedFields[field] = true
+				}
+			}
+			if frequency <= 0 {
+				continue
+			}
+			match.Score += idf[term] * frequency * (tuning.K1 + 1) / (frequency + normalization)
+			match.MatchedTerms = append(match.MatchedTerms, term)
+		}
+		if match.Score <= 0 {
+			continue
+		}
+
+		for _, field := range lexicalFields {
+			if matchedFields[field] {
+				match.MatchedFields = append(match.MatchedFields, field)
+			}
+		}
+		matches = append(matches, match)
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		if matches[i].Document.Popularity != matches[j].Document.Popularity {
+			return matches[i].Document.Popularity > matches[j].Document.Popularity
+		}
+		return matches[i].Document.ProductID.String() < matches[j].Document.ProductID.String()
+	})
+
+	return matches
+}
+
+// LexicalFilterFromMap converts knowledge base filters into a lexical search filter. Only hard
+// constraints are kept: category_id, category_ids, exclude_category_ids, exclude_id, price_min,
+// price_max and rating_min. Preference signals such as preferred_brands only shape semantic results.
+func LexicalFilterFromMap(filters map[string]interface{}) dto.LexicalSearchFilter {
+	var filter dto.LexicalSearchFilter
+
+	if categoryID, ok := filterFloat(filters["category_id"]); ok {
+		filter.CategoryIDs = []int{int(categoryID)}
+	} else if categoryIDs, ok := filters["category_ids"].([]int); ok {
+		filter.CategoryIDs = categoryIDs
+	}
+	if categoryIDs, ok := filters["exclude_category_ids"].([]int); ok {
+		filter.ExcludeCategoryIDs = categoryIDs
+	}
+
+	switch excludeID := filters["exclude_id"].(type) {
+	case uuid.UUID:
+		filter.ExcludeProductIDs = []uuid.UUID{excludeID}
+	case string:
+		if id, err := uuid.Parse(excludeID); err == nil {
+			filter.ExcludeProductIDs = []uuid.UUID{id}
+		}
+	}
+
+	if priceMin, ok := filterFloat(filters["price_min"]); ok {
+		filter.PriceMin = &priceMin
+	}
+	if priceMax, ok := filterFloat(filters["price_max"]); ok {
+		filter.PriceMax = &priceMax
+	}
+	if ratingMin, ok := filterFloat(filters["rating_min"]); ok {
+		filter.RatingMin = &ratingMin
+	}
+
+	return filter
+}
+
+// filterFloat reads a numeric filter value
+func filterFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	default:
+		return 0, false
+	}
+}
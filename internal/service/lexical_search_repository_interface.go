@@ -0,0 +1,18 @@
+package service
+
+import (
+	"context"
+	"ec-recommend/internal/dto"
+)
+
+// LexicalSearchRepositoryInterface defines the interface for lexical product search data access
+// This interface is defined in the service package as it is consumed by services
+type LexicalSearchRepositoryInterface interface {
+	// GetLexicalCandidates returns active products whose search document contains at least one of the
+	// normalized terms, those containing the most terms first, up to limit
+	GetLexicalCandidates(ctx context.Context, terms []string, filter dto.LexicalSearchFilter, limit int) ([]dto.LexicalDocument, error)
+
+	// GetLexicalCorpusStats returns the number and average length of active product search documents,
+	// and how many of them contain each term
+	GetLexicalCorpusStats(ctx context.Context, terms []string) (*dto.LexicalCorpusStats, error)
+}
@@ -0,0 +1,203 @@
+package service
+
+import (
+	"context"
+	"ec-recommend/internal/dto"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestLexicalTerms(t *testing.T) {
+	cases := map[string][]string{
+		"ワイヤレスイヤホン":         {"わいやれすいやほん"},
+		"iPhoneケース　黒":       {"iphone", "けーす", "黒"},
+		"赤い傘の ｽﾆｰｶｰ":        {"赤", "傘", "すにーかー"},
+		"かわいい ノート・ペン":       {"かわいい", "のーと", "ぺん"},
+		"T-shirt / T-shirt": {"t-shirt"},
+		" - ":               nil,
+	}
+	for input, expected := range cases {
+		if got := LexicalTerms(input); !reflect.DeepEqual(got, expected) {
+			t.Errorf("Expected %v for %q, got %v", expected, input, got)
+		}
+	}
+}
+
+func lexicalDocuments() []dto.LexicalDocument {
+	return []dto.LexicalDocument{
+		{ProductID: uuid.MustParse("00000000-0000-0000-0000-000000000001"), Name: "ワイヤレスイヤホン Pro", Brand: "Sony", Tags: []string{"audio", "bluetooth"}, Popularity: 10},
+		{ProductID: uuid.MustParse("00000000-0000-0000-0000-000000000002"), Name: "有線ヘッドフォン", Description: "ワイヤレスイヤホンより高音質", Brand: "Sony", Popularity: 50},
+		{ProductID: uuid.MustParse("00000000-0000-0000-0000-000000000003"), Name: "スマートフォンケース", Features: "Bluetooth 5.3", Popularity: 90},
+	}
+}
+
+func TestScoreLexicalDocuments(t *testing.T) {
+	tuning := DefaultLexicalSearchTuning()
+	stats := &dto.LexicalCorpusStats{
+		DocumentCount:     100,
+		AverageLength:     30,
+		DocumentFrequency: map[string]int{"わいやれすいやほん": 2, "bluetooth": 2, "sony": 20},
+	}
+
+	t.Run("name matches outrank description matches", func(t *testing.T) {
+		matches := ScoreLexicalDocuments(lexicalDocuments(), []string{"わいやれすいやほん"}, stats, tuning)
+		if len(matches) != 2 || matches[0].Document.Name != "ワイヤレスイヤホン Pro" {
+			t.Fatalf("Expected the name match first of 2, got %+v", matches)
+		}
+		if !reflect.DeepEqual(matches[1].MatchedFields, []string{"description"}) {
+			t.Errorf("Expected a description match, got %v", matches[1].MatchedFields)
+		}
+	})
+
+	t.Run("rare terms weigh more than common ones", func(t *testing.T) {
+		matches := ScoreLexicalDocuments(lexicalDocuments(), []string{"sony", "bluetooth"}, stats, tuning)
+		if len(matches) != 3 || matches[0].Document.Name != "ワイヤレスイヤホン Pro" {
+			t.Fatalf("Expected the product matching both terms first, got %+v", matches)
+		}
+		if len(matches[0].MatchedTerms) != 2 {
+			t.Errorf("Expected 2 matched terms, got %v", matches[0].MatchedTerms)
+		}
+		// One tag match of a rare term beats one brand match of a common term
+		if matches[1].Document.Name != "スマートフォンケース" {
+			t.Errorf("Expected the rare feature match second, got %s", matches[1].Document.Name)
+		}
+	})
+
+	t.Run("documents without matches are dropped", func(t *testing.T) {
+		if matches := ScoreLexicalDocuments(lexicalDocuments(), []string{"がんぐ"}, stats, tuning); matches != nil {
+			t.Errorf("Expected no matches, got %+v", matches)
+		}
+	})
+}
+
+func TestLexicalFilterFromMap(t *testing.T) {
+	excludeID := uuid.New()
+	filter := LexicalFilterFromMap(map[string]interface{}{
+		"category_id":      11,
+		"category_ids":     []int{1, 2},
+		"exclude_id":       excludeID.String(),
+		"price_max":        5000.0,
+		"rating_min":       4.0,
+		"preferred_brands": []string{"Sony"},
+	})
+
+	if !reflect.DeepEqual(filter.CategoryIDs, []int{11}) {
+		t.Errorf("Expected category_id to take precedence, got %v", filter.CategoryIDs)
+	}
+	if len(filter.ExcludeProductIDs) != 1 || filter.ExcludeProductIDs[0] != excludeID {
+		t.Errorf("Expected the excluded product, got %v", filter.ExcludeProductIDs)
+	}
+	if filter.PriceMin != nil || filter.PriceMax == nil || *filter.PriceMax != 5000 || filter.RatingMin == nil {
+		t.Errorf("Expected only price_max and rating_min, got %+v", filter)
+	}
+}
+
+// fakeLexicalSearchRepository serves fixed candidates and statistics
+type fakeLexicalSearchRepository struct {
+	documents []dto.LexicalDocument
+	err       error
+	terms     []string
+	filter    dto.LexicalSearchFilter
+}
+
+func (r *fakeLexicalSearchRepository) GetLexicalCandidates(ctx context.Context, terms []string, filter dto.LexicalSearchFilter, limit int) ([]dto.LexicalDocument, error) {
+	r.terms, r.filter = terms, filter
+	return r.documents, r.err
+}
+
+func (r *fakeLexicalSearchRepository) GetLexicalCorpusStats(ctx context.Context, terms []string) (*dto.LexicalCorpusStats, error) {
+	return &dto.LexicalCorpusStats{DocumentCount: 10, AverageLength: 20, DocumentFrequency: map[string]int{}}, nil
+}
+
+// fakeRAG serves fixed knowledge base search results; other RAG methods are not used
+type fakeRAG struct {
+	RAGInterface
+	response *RAGSemanticSearchResponse
+	err      error
+	filters  map[string]interface{}
+}
+
+func (r *fakeRAG) GetProductsWithSemanticSearch(ctx context.Context, query string, limit int, filters map[string]interface{}) (*RAGSemanticSearchResponse, error) {
+	r.filters = filters
+	return r.response, r.err
+}
+
+func TestLexicalSearchService(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("returns results in knowledge base form", func(t *testing.T) {
+		repo := &fakeLexicalSearchRepository{documents: lexicalDocuments()}
+		service := NewLexicalSearchService(repo, DefaultLexicalSearchTuning())
+
+		response, err := service.Search(ctx, "ワイヤレスイヤホン", 1, map[string]interface{}{"price_max": 10000.0})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if response.TotalFound != 1 || response.SearchMetadata.SearchType != "keyword" {
+			t.Fatalf("Expected 1 keyword result, got %+v", response)
+		}
+		result := response.Results[0]
+		if result.SimilarityScore != 1 || result.SearchMethod != "keyword_search" || result.RetrievalRank != 1 {
+			t.Errorf("Expected the top result scored 1 at rank 1, got %+v", result)
+		}
+		if repo.filter.PriceMax == nil || !reflect.DeepEqual(repo.terms, []string{"わいやれすいやほん"}) {
+			t.Errorf("Expected the filter and terms to reach the repository, got %v %+v", repo.terms, repo.filter)
+		}
+	})
+
+	t.Run("queries without terms skip the database", func(t *testing.T) {
+		repo := &fakeLexicalSearchRepository{err: errors.New("db down")}
+		response, err := NewLexicalSearchService(repo, DefaultLexicalSearchTuning()).Search(ctx, "・", 10, nil)
+		if err != nil || response.TotalFound != 0 {
+			t.Errorf("Expected an empty response, got %+v %v", response, err)
+		}
+	})
+}
+
+func TestSearchProductsFallback(t *testing.T) {
+	ctx := context.Background()
+	lexical := NewLexicalSearchService(&fakeLexicalSearchRepository{documents: lexicalDocuments()}, DefaultLexicalSearchTuning())
+	semantic := &RAGSemanticSearchResponse{
+		Results:        []RAGSearchResult{{ProductID: uuid.New()}},
+		SearchMetadata: &RAGSearchMeta{SearchType: "semantic_search_with_metadata_filtering"},
+	}
+
+	cases := []struct {
+		name       string
+		rag        *fakeRAG
+		searchType string
+		keyword    bool
+	}{
+		{"knowledge base results are used", &fakeRAG{response: semantic}, "", false},
+		{"knowledge base errors fall back", &fakeRAG{err: errors.New("throttled")}, "", true},
+		{"empty knowledge base results fall back", &fakeRAG{response: &RAGSemanticSearchResponse{}}, "semantic", true},
+		{"keyword searches skip the knowledge base", &fakeRAG{err: errors.New("not called")}, "keyword", true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			rs := &RecommendationServiceV2{rag: c.rag, lexicalSearch: lexical}
+			response, err := rs.searchProducts(ctx, "イヤホン", 10, nil, c.searchType, nil)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if isKeywordSearch(response) != c.keyword {
+				t.Errorf("Expected keyword search %v, got %+v", c.keyword, response.SearchMetadata)
+			}
+		})
+	}
+
+	t.Run("errors surface without a fallback", func(t *testing.T) {
+		tuning := DefaultLexicalSearchTuning()
+		tuning.Fallback = false
+		rs := &RecommendationServiceV2{
+			rag:           &fakeRAG{err: errors.New("throttled")},
+			lexicalSearch: NewLexicalSearchService(&fakeLexicalSearchRepository{}, tuning),
+		}
+		if _, err := rs.searchProducts(ctx, "イヤホン", 10, nil, "", nil); err == nil {
+			t.Error("Expected an error")
+		}
+	})
+}
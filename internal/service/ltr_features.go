@@ -0,0 +1,146 @@
+package service
+
+import (
+	"ec-recommend/internal/dto"
+	"math"
+	"strings"
+	"time"
+)
+
+// RankingFeatureNames lists the features used by the learning-to-rank model, in vector order
+var RankingFeatureNames = []string{
+	"fused_score",
+	"semantic_score",
+	"vector_search_score",
+	"knowledge_based_score",
+	"collaborative_score",
+	"strategy_count",
+	"price_relative",
+	"discount_ratio",
+	"rating_average",
+	"rating_count_log",
+	"popularity_log",
+	"category_affinity",
+	"brand_affinity",
+	"category_recency",
+	"category_disliked",
+	"brand_disliked",
+}
+
+// categoryRecencyHalfLifeDays controls how quickly the category recency feature decays
+const categoryRecencyHalfLifeDays = 30.0
+
+// ExtractRankingFeatures computes ranking features for a candidate.
+//
+// Parameters:
+//   - rec: candidate recommendation; strategy scores are read from "strategy_*" relevance contexts
+//   - profile: customer profile used for price, category and brand affinity (may be nil)
+//   - now: reference time for recency features
+//
+// Returns a map keyed by the names in RankingFeatureNames.
+func ExtractRankingFeatures(rec dto.ProductRecommendationV2, profile *dto.CustomerProfile, now time.Time) map[string]float64 {
+	features := make(map[string]float64, len(RankingFeatureNames))
+	features["fused_score"] = rec.ConfidenceScore
+
+	for _, relevance := range rec.RelevanceContext {
+		strategy, ok := strings.CutPrefix(relevance.ContextType, "strategy_")
+		if !ok {
+			continue
+		}
+		features[strategy+"_score"] = relevance.Confidence
+		features["strategy_count"]++
+	}
+
+	if rec.OriginalPrice != nil && *rec.OriginalPrice > rec.Price && *rec.OriginalPrice > 0 {
+		features["discount_ratio"] = (*rec.OriginalPrice - rec.Price) / *rec.OriginalPrice
+	}
+	features["rating_average"] = rec.RatingAverage / 5
+	features["rating_count_log"] = math.Log1p(float64(rec.RatingCount))
+	features["popularity_log"] = math.Log1p(float64(rec.PopularityScore))
+
+	if profile == nil {
+		return features
+	}
+
+	if reference := referencePrice(profile); reference > 0 {
+		features["price_relative"] = rec.Price / reference
+	}
+
+	var categoryPurchases int
+	var lastCategoryPurchase time.Time
+	for _, purchase := range profile.PurchaseHistory {
+		if purchase.CategoryID != rec.CategoryID {
+			continue
+		}
+		categoryPurchases++
+		if purchase.PurchasedAt.After(lastCategoryPurchase) {
+			lastCategoryPurchase = purchase.PurchasedAt
+		}
+	}
+
+	affinity := 0.0
+	if len(profile.PurchaseHistory) > 0 {
+		affinity = float64(categoryPurchases) / float64(len(profile.PurchaseHistory))
+	}
+	for _, categoryID := range profile.PreferredCategories {
+		if categoryID == rec.CategoryID {
+			affinity += 0.5
+			break
+		}
+	}
+	features["category_affinity"] = math.Min(affinity, 1)
+
+	for _, brand := range profile.PreferredBrands {
+		if rec.Brand != "" && strings.EqualFold(brand, rec.Brand) {
+			features["brand_affinity"] = 1
+			break
+		}
+	}
+
+	// Negative preference signals from "not interested" feedback
+	if containsInt(profile.DislikedCategories, rec.CategoryID) {
+		features["category_disliked"] = 1
+	}
+	for _, brand := range profile.DislikedBrands {
+		if rec.Brand != "" && strings.EqualFold(brand, rec.Brand) {
+			features["brand_disliked"] = 1
+			break
+		}
+	}
+
+	if !lastCategoryPurchase.IsZero() {
+		days := now.Sub(lastCategoryPurchase).Hours() / 24
+		features["category_recency"] = math.Exp(-math.Ln2 * math.Max(days, 0) / categoryRecencyHalfLifeDays)
+	}
+
+	return features
+}
+
+// referencePrice returns the midpoint of the customer's price range,
+// falling back to their average purchase price
+func referencePrice(profile *dto.CustomerProfile) float64 {
+	if profile.PriceRangeMin != nil && profile.PriceRangeMax != nil && *profile.PriceRangeMax > 0 {
+		return (*profile.PriceRangeMin + *profile.PriceRangeMax) / 2
+	}
+
+	if len(profile.PurchaseHistory) == 0 {
+		return 0
+	}
+
+	var total float64
+	for _, purchase := range profile.PurchaseHistory {
+		total += purchase.Price
+	}
+
+	return total / float64(len(profile.PurchaseHistory))
+}
+
+// rankingFeatureVector converts a feature map to a vector ordered like the given names
+func rankingFeatureVector(features map[string]float64, names []string) []float64 {
+	vector := make([]float64, len(names))
+	for i, name := range names {
+		vector[i] = features[name]
+	}
+
+	return vector
+}
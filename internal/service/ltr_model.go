@@ -0,0 +1,452 @@
+package service
+
+import (
+	"ec-recommend/internal/dto"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"time"
+)
+
+// Supported learning-to-rank model types
+const (
+	RankingModelLogistic = "logistic"
+	RankingModelGBDT     = "gbdt"
+)
+
+// RankingModel is a trained pointwise ranking model serialized as JSON.
+// Scores are click/purchase probabilities in the [0, 1] range.
+type RankingModel struct {
+	Type             string           `json:"type"`
+	FeatureNames     []string         `json:"feature_names"`
+	Bias             float64          `json:"bias"`
+	Weights          []float64        `json:"weights,omitempty"`       // Logistic regression weights over standardized features
+	Means            []float64        `json:"means,omitempty"`         // Feature means used for standardization
+	Scales           []float64        `json:"scales,omitempty"`        // Feature standard deviations used for standardization
+	LearningRate     float64          `json:"learning_rate,omitempty"` // Shrinkage applied to each GBDT tree
+	Trees            []RegressionTree `json:"trees,omitempty"`
+	TrainedAt        time.Time        `json:"trained_at"`
+	TrainingExamples int              `json:"training_examples"`
+}
+
+// RegressionTree is a binary regression tree stored as a flat node list with the root at index 0
+type RegressionTree struct {
+	Nodes []TreeNode `json:"nodes"`
+}
+
+// TreeNode is a split node (Feature >= 0) or a leaf (Feature == -1)
+type TreeNode struct {
+	Feature   int     `json:"feature"`
+	Threshold float64 `json:"threshold,omitempty"` // Samples with value <= Threshold go left
+	Left      int     `json:"left,omitempty"`
+	Right     int     `json:"right,omitempty"`
+	Value     float64 `json:"value,omitempty"`
+}
+
+// RankerTrainingOptions configures offline ranker training
+type RankerTrainingOptions struct {
+	Iterations     int     // Gradient steps (logistic) or boosting rounds (gbdt)
+	LearningRate   float64 // Step size (logistic) or shrinkage (gbdt)
+	L2             float64 // L2 regularization for logistic regression
+	MaxDepth       int     // Maximum tree depth for gbdt
+	MinSamplesLeaf int     // Minimum samples per gbdt leaf
+}
+
+// RankingModelEvaluation summarizes model quality on a set of examples
+type RankingModelEvaluation struct {
+	Examples int     `json:"examples"`
+	LogLoss  float64 `json:"log_loss"`
+	AUC      float64 `json:"auc"`
+}
+
+// LoadRankingModel reads a ranking model from a JSON file
+func LoadRankingModel(path string) (*RankingModel, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ranking model: %w", err)
+	}
+
+	var model RankingModel
+	if err := json.Unmarshal(data, &model); err != nil {
+		return nil, fmt.Errorf("failed to parse ranking model: %w", err)
+	}
+
+	if model.Type != RankingModelLogistic && model.Type != RankingModelGBDT {
+		return nil, fmt.Errorf("unsupported ranking model type: %s", model.Type)
+	}
+	if model.Type == RankingModelLogistic && (len(model.Weights) != len(model.FeatureNames) || len(model.Means) != len(model.FeatureNames) || len(model.Scales) != len(model.FeatureNames)) {
+		return nil, fmt.Errorf("logistic ranking model parameters do not match its %d features", len(model.FeatureNames))
+	}
+	if model.Type == RankingModelGBDT {
+		for i, tree := range model.Trees {
+			if err := tree.validate(len(model.FeatureNames)); err != nil {
+				return nil, fmt.Errorf("invalid ranking model tree %d: %w", i, err)
+			}
+		}
+	}
+
+	return &model, nil
+}
+
+// Save writes the ranking model to a JSON file
+func (m *RankingModel) Save(path string) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal ranking model: %w", err)
+	}
+
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write ranking model: %w", err)
+	}
+
+	return nil
+}
+
+// Score returns the predicted interaction probability for a feature map
+func (m *RankingModel) Score(features map[string]float64) float64 {
+	return sigmoid(m.margin(rankingFeatureVector(features, m.FeatureNames)))
+}
+
+func (m *RankingModel) margin(x []float64) float64 {
+	margin := m.Bias
+
+	switch m.Type {
+	case RankingModelLogistic:
+		for i, weight := range m.Weights {
+			margin += weight * standardize(x[i], m.Means[i], m.Scales[i])
+		}
+	case RankingModelGBDT:
+		for _, tree := range m.Trees {
+			margin += m.LearningRate * tree.predict(x)
+		}
+	}
+
+	return margin
+}
+
+// validate checks that every node reachable from the root uses a known feature,
+// points at existing children and is reached only once, so prediction cannot index
+// out of range or loop forever
+func (t RegressionTree) validate(features int) error {
+	if len(t.Nodes) == 0 {
+		return nil
+	}
+
+	visited := make([]bool, len(t.Nodes))
+	stack := []int{0}
+	for len(stack) > 0 {
+		index := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		if visited[index] {
+			return fmt.Errorf("node %d is reachable more than once", index)
+		}
+		visited[index] = true
+
+		node := t.Nodes[index]
+		if node.Feature < 0 {
+			continue
+		}
+		if node.Feature >= features {
+			return fmt.Errorf("node %d splits on feature %d but the model has %d features", index, node.Feature, features)
+		}
+		for _, child := range []int{node.Left, node.Right} {
+			if child <= 0 || child >= len(t.Nodes) {
+				return fmt.Errorf("node %d has child index %d outside 1..%d", index, child, len(t.Nodes)-1)
+			}
+			stack = append(stack, child)
+		}
+	}
+
+	return nil
+}
+
+func (t RegressionTree) predict(x []float64) float64 {
+	if len(t.Nodes) == 0 {
+		return 0
+	}
+
+	node := t.Nodes[0]
+	for node.Feature >= 0 {
+		if x[node.Feature] <= node.Threshold {
+			node = t.Nodes[node.Left]
+		} else {
+			node = t.Nodes[node.Right]
+		}
+	}
+
+	return node.Value
+}
+
+// TrainRankingModel trains a pointwise ranker on logged examples.
+// Clicked and purchased examples are positives; purchases get double sample weight.
+//
+// Parameters:
+//   - modelType: "logistic" or "gbdt"
+//   - examples: labeled training examples
+//   - opts: training hyper-parameters
+//
+// Returns the trained model, or an error if the inputs are unusable.
+func TrainRankingModel(modelType string, examples []dto.RankingTrainingExample, opts RankerTrainingOptions) (*RankingModel, error) {
+	if len(examples) == 0 {
+		return nil, fmt.Errorf("no training examples")
+	}
+
+	x := make([][]float64, len(examples))
+	y := make([]float64, len(examples))
+	w := make([]float64, len(examples))
+	positives := 0
+	for i, example := range examples {
+		x[i] = rankingFeatureVector(example.Features, RankingFeatureNames)
+		w[i] = 1
+		if example.Label > 0 {
+			y[i] = 1
+			positives++
+		}
+		if example.Label > 1 {
+			w[i] = 2
+		}
+	}
+	if positives == 0 || positives == len(examples) {
+		return nil, fmt.Errorf("training examples must contain both positive and negative labels")
+	}
+
+	model := &RankingModel{
+		Type:             modelType,
+		FeatureNames:     append([]string(nil), RankingFeatureNames...),
+		TrainedAt:        time.Now(),
+		TrainingExamples: len(examples),
+	}
+
+	switch modelType {
+	case RankingModelLogistic:
+		trainLogistic(model, x, y, w, opts)
+	case RankingModelGBDT:
+		trainGBDT(model, x, y, w, opts)
+	default:
+		return nil, fmt.Errorf("unsupported ranking model type: %s", modelType)
+	}
+
+	return model, nil
+}
+
+// trainLogistic fits L2-regularized logistic regression with full-batch gradient descent
+func trainLogistic(model *RankingModel, x [][]float64, y, w []float64, opts RankerTrainingOptions) {
+	features := len(model.FeatureNames)
+	model.Means = make([]float64, features)
+	model.Scales = make([]float64, features)
+	for j := 0; j < features; j++ {
+		var sum, sumSquares float64
+		for i := range x {
+			sum += x[i][j]
+			sumSquares += x[i][j] * x[i][j]
+		}
+		mean := sum / float64(len(x))
+		model.Means[j] = mean
+		model.Scales[j] = math.Sqrt(math.Max(sumSquares/float64(len(x))-mean*mean, 0))
+	}
+
+	model.Weights = make([]float64, features)
+	var totalWeight float64
+	for _, weight := range w {
+		totalWeight += weight
+	}
+
+	gradient := make([]float64, features)
+	for iter := 0; iter < opts.Iterations; iter++ {
+		for j := range gradient {
+			gradient[j] = 0
+		}
+		var biasGradient float64
+
+		for i := range x {
+			errTerm := w[i] * (sigmoid(model.margin(x[i])) - y[i])
+			biasGradient += errTerm
+			for j := 0; j < features; j++ {
+				gradient[j] += errTerm * standardize(x[i][j], model.Means[j], model.Scales[j])
+			}
+		}
+
+		model.Bias -= opts.LearningRate * biasGradient / totalWeight
+		for j := 0; j < features; j++ {
+			model.Weights[j] -= opts.LearningRate * (gradient[j]/totalWeight + opts.L2*model.Weights[j])
+		}
+	}
+}
+
+// trainGBDT fits gradient-boosted regression trees on the logistic loss using Newton leaf values
+func trainGBDT(model *RankingModel, x [][]float64, y, w []float64, opts RankerTrainingOptions) {
+	var positiveWeight, totalWeight float64
+	for i := range y {
+		positiveWeight += w[i] * y[i]
+		totalWeight += w[i]
+	}
+	prior := positiveWeight / totalWeight
+	model.Bias = math.Log(prior / (1 - prior))
+	model.LearningRate = opts.LearningRate
+
+	margins := make([]float64, len(x))
+	for i := range margins {
+		margins[i] = model.Bias
+	}
+
+	gradients := make([]float64, len(x))
+	hessians := make([]float64, len(x))
+	indices := make([]int, len(x))
+	for round := 0; round < opts.Iterations; round++ {
+		for i := range x {
+			p := sigmoid(margins[i])
+			gradients[i] = w[i] * (y[i] - p)
+			hessians[i] = w[i] * p * (1 - p)
+			indices[i] = i
+		}
+
+		builder := &treeBuilder{x: x, gradients: gradients, hessians: hessians, opts: opts}
+		builder.build(indices, 0)
+		tree := RegressionTree{Nodes: builder.nodes}
+
+		for i := range x {
+			margins[i] += model.LearningRate * tree.predict(x[i])
+		}
+		model.Trees = append(model.Trees, tree)
+	}
+}
+
+// treeBuilder grows a single regression tree on gradient statistics
+type treeBuilder struct {
+	x         [][]float64
+	gradients []float64
+	hessians  []float64
+	opts      RankerTrainingOptions
+	nodes     []TreeNode
+}
+
+// build grows the subtree for the given samples and returns its node index
+func (b *treeBuilder) build(indices []int, depth int) int {
+	nodeIndex := len(b.nodes)
+	b.nodes = append(b.nodes, TreeNode{Feature: -1})
+
+	var gradientSum, hessianSum float64
+	for _, i := range indices {
+		gradientSum += b.gradients[i]
+		hessianSum += b.hessians[i]
+	}
+	b.nodes[nodeIndex].Value = gradientSum / (hessianSum + 1e-6)
+
+	if depth >= b.opts.MaxDepth || len(indices) < 2*b.opts.MinSamplesLeaf {
+		return nodeIndex
+	}
+
+	bestGain := 0.0
+	bestFeature := -1
+	var bestThreshold float64
+	parentScore := gradientSum * gradientSum / (hessianSum + 1e-6)
+
+	sorted := make([]int, len(indices))
+	for feature := range b.x[indices[0]] {
+		copy(sorted, indices)
+		sort.Slice(sorted, func(a, c int) bool {
+			return b.x[sorted[a]][feature] < b.x[sorted[c]][feature]
+		})
+
+		var leftGradient, leftHessian float64
+		for pos := 0; pos < len(sorted)-1; pos++ {
+			leftGradient += b.gradients[sorted[pos]]
+			leftHessian += b.hessians[sorted[pos]]
+
+			current, next := b.x[sorted[pos]][feature], b.x[sorted[pos+1]][feature]
+			if current == next || pos+1 < b.opts.MinSamplesLeaf || len(sorted)-pos-1 < b.opts.MinSamplesLeaf {
+				continue
+			}
+
+			rightGradient := gradientSum - leftGradient
+			rightHessian := hessianSum - leftHessian
+			gain := leftGradient*leftGradient/(leftHessian+1e-6) + rightGradient*rightGradient/(rightHessian+1e-6) - parentScore
+			if gain > bestGain {
+				bestGain = gain
+				bestFeature = feature
+				bestThreshold = (current + next) / 2
+			}
+		}
+	}
+
+	if bestFeature < 0 {
+		return nodeIndex
+	}
+
+	var left, right []int
+	for _, i := range indices {
+		if b.x[i][bestFeature] <= bestThreshold {
+			left = append(left, i)
+		} else {
+			right = append(right, i)
+		}
+	}
+
+	leftIndex := b.build(left, depth+1)
+	rightIndex := b.build(right, depth+1)
+	b.nodes[nodeIndex] = TreeNode{
+		Feature:   bestFeature,
+		Threshold: bestThreshold,
+		Left:      leftIndex,
+		Right:     rightIndex,
+	}
+
+	return nodeIndex
+}
+
+// EvaluateRankingModel computes log loss and ROC AUC of a model on labeled examples
+func EvaluateRankingModel(model *RankingModel, examples []dto.RankingTrainingExample) RankingModelEvaluation {
+	evaluation := RankingModelEvaluation{Examples: len(examples)}
+	if len(examples) == 0 {
+		return evaluation
+	}
+
+	type scored struct {
+		score    float64
+		positive bool
+	}
+	scores := make([]scored, len(examples))
+	for i, example := range examples {
+		p := math.Min(math.Max(model.Score(example.Features), 1e-7), 1-1e-7)
+		positive := example.Label > 0
+		if positive {
+			evaluation.LogLoss -= math.Log(p)
+		} else {
+			evaluation.LogLoss -= math.Log(1 - p)
+		}
+		scores[i] = scored{score: p, positive: positive}
+	}
+	evaluation.LogLoss /= float64(len(examples))
+
+	sort.Slice(scores, func(i, j int) bool {
+		return scores[i].score < scores[j].score
+	})
+	var positives, negatives, rankSum float64
+	for i, s := range scores {
+		if s.positive {
+			positives++
+			rankSum += float64(i + 1)
+		} else {
+			negatives++
+		}
+	}
+	if positives > 0 && negatives > 0 {
+		evaluation.AUC = (rankSum - positives*(positives+1)/2) / (positives * negatives)
+	}
+
+	return evaluation
+}
+
+func standardize(value, mean, scale float64) float64 {
+	if scale == 0 {
+		return 0
+	}
+	return (value - mean) / scale
+}
+
+func sigmoid(x float64) float64 {
+	return 1 / (1 + math.Exp(-x))
+}
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"ec-recommend/internal/dto"
+	"path/filepath"
+	"testing"
+)
+
+func TestTrainRankingModel(t *testing.T) {
+	// Clicks depend on category affinity; discount ratio is noise
+	var examples []dto.RankingTrainingExample
+	for i := 0; i < 200; i++ {
+		affinity := float64(i%10) / 10
+		label := 0
+		if affinity >= 0.5 {
+			label = 1
+		}
+		if affinity >= 0.8 {
+			label = 2
+		}
+		examples = append(examples, dto.RankingTrainingExample{
+			Features: map[string]float64{
+				"category_affinity": affinity,
+				"discount_ratio":    float64(i%7) / 7,
+			},
+			Label: label,
+		})
+	}
+
+	for _, modelType := range []string{RankingModelLogistic, RankingModelGBDT} {
+		t.Run(modelType+" separates positives from negatives", func(t *testing.T) {
+			model, err := TrainRankingModel(modelType, examples, RankerTrainingOptions{
+				Iterations:     200,
+				LearningRate:   0.3,
+				MaxDepth:       2,
+				MinSamplesLeaf: 5,
+			})
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			evaluation := EvaluateRankingModel(model, examples)
+			if evaluation.AUC < 0.95 {
+				t.Errorf("Expected AUC >= 0.95, got %f", evaluation.AUC)
+			}
+
+			high := model.Score(map[string]float64{"category_affinity": 0.9})
+			low := model.Score(map[string]float64{"category_affinity": 0.1})
+			if high <= low {
+				t.Errorf("Expected high affinity to score above low affinity, got %f <= %f", high, low)
+			}
+		})
+	}
+
+	t.Run("model round-trips through JSON", func(t *testing.T) {
+		model, err := TrainRankingModel(RankingModelGBDT, examples, RankerTrainingOptions{Iterations: 10, LearningRate: 0.3, MaxDepth: 2, MinSamplesLeaf: 5})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		path := filepath.Join(t.TempDir(), "model.json")
+		if err := model.Save(path); err != nil {
+			t.Fatalf("Expected no error saving model, got %v", err)
+		}
+
+		loaded, err := LoadRankingModel(path)
+		if err != nil {
+			t.Fatalf("Expected no error loading model, got %v", err)
+		}
+
+		features := map[string]float64{"category_affinity": 0.7}
+		if loaded.Score(features) != model.Score(features) {
+			t.Errorf("Expected loaded model to score %f, got %f", model.Score(features), loaded.Score(features))
+		}
+	})
+
+	t.Run("single-class examples are rejected", func(t *testing.T) {
+		_, err := TrainRankingModel(RankingModelLogistic, examples[:1], RankerTrainingOptions{Iterations: 1})
+		if err == nil {
+			t.Error("Expected error for single-class examples, got nil")
+		}
+	})
+}
+
+func TestLoadRankingModelRejectsMalformedTrees(t *testing.T) {
+	leaf := TreeNode{Feature: -1, Value: 0.1}
+
+	tests := []struct {
+		name  string
+		nodes []TreeNode
+	}{
+		{name: "child index out of range", nodes: []TreeNode{{Feature: 0, Left: 1, Right: 5}, leaf}},
+		{name: "feature index out of range", nodes: []TreeNode{{Feature: len(RankingFeatureNames), Left: 1, Right: 2}, leaf, leaf}},
+		{name: "child points back to the root", nodes: []TreeNode{{Feature: 0, Left: 1, Right: 0}, leaf}},
+		{name: "cycle between split nodes", nodes: []TreeNode{{Feature: 0, Left: 1, Right: 2}, {Feature: 0, Left: 2, Right: 3}, {Feature: 0, Left: 1, Right: 3}, leaf}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			model := &RankingModel{
+				Type:         RankingModelGBDT,
+				FeatureNames: append([]string(nil), RankingFeatureNames...),
+				LearningRate: 0.1,
+				Trees:        []RegressionTree{{Nodes: tt.nodes}},
+			}
+			path := filepath.Join(t.TempDir(), "model.json")
+			if err := model.Save(path); err != nil {
+				t.Fatalf("Expected no error saving model, got %v", err)
+			}
+
+			if _, err := LoadRankingModel(path); err == nil {
+				t.Error("Expected malformed tree to be rejected, got nil")
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"context"
+	"ec-recommend/internal/dto"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LearningToRankTuning configures feature logging and the learned reranker
+type LearningToRankTuning struct {
+	// ModelPath is the path to a model produced by cmd/ltr-train; empty disables reranking
+	ModelPath string `json:"model_path,omitempty"`
+	// LogFeatures stores ranking features of returned recommendations for offline training
+	LogFeatures bool `json:"log_features"`
+}
+
+// LTRReranker computes ranking features for every candidate and, when a model is loaded,
+// reorders candidates by the model's predicted interaction probability.
+// Computed features are stored in the ranking context so they can be logged for training.
+type LTRReranker struct {
+	model *RankingModel
+}
+
+// NewLTRReranker creates a learning-to-rank stage; a nil model only computes features
+func NewLTRReranker(model *RankingModel) *LTRReranker {
+	return &LTRReranker{
+		model: model,
+	}
+}
+
+// Name returns the stage identifier
+func (lr *LTRReranker) Name() string {
+	return "learning_to_rank"
+}
+
+// Apply computes features and reranks candidates with the model
+func (lr *LTRReranker) Apply(ctx context.Context, recommendations []dto.ProductRecommendationV2, rc *RankingContext) ([]dto.ProductRecommendationV2, error) {
+	now := time.Now()
+	if rc.Features == nil {
+		rc.Features = make(map[uuid.UUID]map[string]float64, len(recommendations))
+	}
+
+	for i := range recommendations {
+		features := ExtractRankingFeatures(recommendations[i], rc.Profile, now)
+		rc.Features[recommendations[i].ProductID] = features
+
+		if lr.model != nil {
+			recommendations[i].ConfidenceScore = lr.model.Score(features)
+		}
+	}
+
+	if lr.model != nil {
+		sort.SliceStable(recommendations, func(i, j int) bool {
+			return recommendations[i].ConfidenceScore > recommendations[j].ConfidenceScore
+		})
+	}
+
+	return recommendations, nil
+}
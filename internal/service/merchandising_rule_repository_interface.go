@@ -0,0 +1,36 @@
+package service
+
+import (
+	"context"
+	"ec-recommend/internal/dto"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MerchandisingRuleRepositoryInterface defines persistence operations for merchandising rules
+// This interface is defined in the service package as it is consumed by services
+type MerchandisingRuleRepositoryInterface interface {
+	// ListMerchandisingRules returns all rules ordered by priority
+	ListMerchandisingRules(ctx context.Context) ([]dto.MerchandisingRule, error)
+
+	// GetActiveMerchandisingRules returns active rules whose date range includes the given time
+	GetActiveMerchandisingRules(ctx context.Context, at time.Time) ([]dto.MerchandisingRule, error)
+
+	// GetMerchandisingRule returns a rule by ID, or nil if it does not exist
+	GetMerchandisingRule(ctx context.Context, ruleID uuid.UUID) (*dto.MerchandisingRule, error)
+
+	// CreateMerchandisingRule stores a new rule and returns it with generated fields populated
+	CreateMerchandisingRule(ctx context.Context, rule *dto.MerchandisingRule) (*dto.MerchandisingRule, error)
+
+	// UpdateMerchandisingRule replaces an existing rule, returning nil if it does not exist
+	UpdateMerchandisingRule(ctx context.Context, rule *dto.MerchandisingRule) (*dto.MerchandisingRule, error)
+
+	// DeleteMerchandisingRule removes a rule, reporting whether it existed
+	DeleteMerchandisingRule(ctx context.Context, ruleID uuid.UUID) (bool, error)
+}
+
+// ProductLookupInterface loads product details by ID for stages that inject products
+type ProductLookupInterface interface {
+	GetProductsByIDs(ctx context.Context, productIDs []uuid.UUID) ([]dto.ProductRecommendationV2, error)
+}
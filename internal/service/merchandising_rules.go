@@ -0,0 +1,275 @@
+package service
+
+import (
+	"context"
+	"ec-recommend/internal/dto"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MerchandisingRuleStage applies merchandiser-defined rules after ranking.
+// Blocks are applied first, then boosts and buries, then pins, so that pinned slots are final.
+// When rules of the same kind conflict, the higher priority rule wins: a product receives only
+// the highest priority boost or bury, and a slot or product is pinned by the highest priority pin.
+type MerchandisingRuleStage struct {
+	repo     MerchandisingRuleRepositoryInterface
+	products ProductLookupInterface
+}
+
+// NewMerchandisingRuleStage creates a merchandising post-ranking stage.
+//
+// Parameters:
+//   - repo: source of active merchandising rules
+//   - products: product lookup used to inject pinned products missing from the candidate list
+func NewMerchandisingRuleStage(repo MerchandisingRuleRepositoryInterface, products ProductLookupInterface) *MerchandisingRuleStage {
+	return &MerchandisingRuleStage{
+		repo:     repo,
+		products: products,
+	}
+}
+
+// Name returns the stage identifier
+func (ms *MerchandisingRuleStage) Name() string {
+	return "merchandising_rules"
+}
+
+// enforcesPolicy marks the stage to run for gift results too
+func (ms *MerchandisingRuleStage) enforcesPolicy() {}
+
+// Apply applies all active rules matching the request and records fired rules in the ranking context
+func (ms *MerchandisingRuleStage) Apply(ctx context.Context, recommendations []dto.ProductRecommendationV2, rc *RankingContext) ([]dto.ProductRecommendationV2, error) {
+	rules, err := ms.repo.GetActiveMerchandisingRules(ctx, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to load merchandising rules: %w", err)
+	}
+
+	// Conflicts are resolved by priority, with ties kept in repository order
+	sort.SliceStable(rules, func(i, j int) bool {
+		return rules[i].Priority > rules[j].Priority
+	})
+
+	var blocks, adjustments, pins []dto.MerchandisingRule
+	for _, rule := range rules {
+		if !merchandisingRuleMatches(rule, rc) {
+			continue
+		}
+		switch rule.Action.Type {
+		case dto.MerchandisingActionBlockProduct, dto.MerchandisingActionBlockBrand:
+			blocks = append(blocks, rule)
+		case dto.MerchandisingActionBoost, dto.MerchandisingActionBury:
+			if rule.Action.Factor > 0 {
+				adjustments = append(adjustments, rule)
+			}
+		case dto.MerchandisingActionPin:
+			if rule.Action.ProductID != nil && rule.Action.Slot > 0 {
+				pins = append(pins, rule)
+			}
+		}
+	}
+
+	for _, rule := range blocks {
+		var kept []dto.ProductRecommendationV2
+		var affected []uuid.UUID
+		for _, rec := range recommendations {
+			if merchandisingProductMatches(rule, rec) {
+				affected = append(affected, rec.ProductID)
+				continue
+			}
+			kept = append(kept, rec)
+		}
+		recommendations = kept
+		recordFiredRule(rc, rule, affected)
+	}
+
+	recommendations = applyScoreAdjustments(recommendations, adjustments, rc)
+	recommendations = ms.applyPins(ctx, recommendations, pins, blocks, rc)
+
+	return recommendations, nil
+}
+
+// applyScoreAdjustments boosts or buries matching products. Each product starts from a positional
+// score so the order produced by earlier stages is preserved for unaffected products.
+// Adjustments must be ordered by priority; only the first matching rule adjusts a product.
+func applyScoreAdjustments(recommendations []dto.ProductRecommendationV2, adjustments []dto.MerchandisingRule, rc *RankingContext) []dto.ProductRecommendationV2 {
+	if len(adjustments) == 0 || len(recommendations) == 0 {
+		return recommendations
+	}
+
+	positional := make(map[uuid.UUID]float64, len(recommendations))
+	for i, rec := range recommendations {
+		positional[rec.ProductID] = 1 - float64(i)/float64(len(recommendations))
+	}
+
+	adjusted := make(map[uuid.UUID]bool)
+	for _, rule := range adjustments {
+		multiplier := rule.Action.Factor
+		if rule.Action.Type == dto.MerchandisingActionBury {
+			multiplier = 1 / rule.Action.Factor
+		}
+
+		var affected []uuid.UUID
+		for i := range recommendations {
+			if !adjusted[recommendations[i].ProductID] && merchandisingProductMatches(rule, recommendations[i]) {
+				adjusted[recommendations[i].ProductID] = true
+				positional[recommendations[i].ProductID] *= multiplier
+				recommendations[i].ConfidenceScore *= multiplier
+				affected = append(affected, recommendations[i].ProductID)
+			}
+		}
+		recordFiredRule(rc, rule, affected)
+	}
+
+	sort.SliceStable(recommendations, func(i, j int) bool {
+		return positional[recommendations[i].ProductID] > positional[recommendations[j].ProductID]
+	})
+
+	return recommendations
+}
+
+// applyPins moves pinned products to their slots, loading products missing from the list.
+// Pins must be ordered by priority; a pin loses to a higher priority pin for the same slot or product,
+// and products matched by a block rule are never pinned. A loaded product is not injected when the
+// request excluded it or the customer dismissed it, since earlier filters would have dropped it.
+func (ms *MerchandisingRuleStage) applyPins(ctx context.Context, recommendations []dto.ProductRecommendationV2, pins []dto.MerchandisingRule, blocks []dto.MerchandisingRule, rc *RankingContext) []dto.ProductRecommendationV2 {
+	if len(pins) == 0 {
+		return recommendations
+	}
+
+	present := make(map[uuid.UUID]dto.ProductRecommendationV2, len(recommendations))
+	for _, rec := range recommendations {
+		present[rec.ProductID] = rec
+	}
+
+	var missing []uuid.UUID
+	for _, rule := range pins {
+		if _, ok := present[*rule.Action.ProductID]; !ok {
+			missing = append(missing, *rule.Action.ProductID)
+		}
+	}
+	if len(missing) > 0 && ms.products != nil {
+		loaded, err := ms.products.GetProductsByIDs(ctx, missing)
+		if err != nil {
+			log.Printf("Warning: failed to load pinned products: %v", err)
+		}
+		for _, rec := range loaded {
+			if rc.Excluded[rec.ProductID] || rc.Suppressions.Suppresses(rec.ProductID, rec.Brand, rec.CategoryID) {
+				continue
+			}
+			present[rec.ProductID] = rec
+		}
+	}
+
+	pinned := make(map[uuid.UUID]bool)
+	slots := make(map[int]bool)
+	var winners []dto.MerchandisingRule
+	for _, rule := range pins {
+		productID := *rule.Action.ProductID
+		rec, ok := present[productID]
+		if !ok || pinned[productID] || slots[rule.Action.Slot] || !merchandisingProductMatches(rule, rec) || merchandisingBlocked(blocks, rec) {
+			continue
+		}
+		pinned[productID] = true
+		slots[rule.Action.Slot] = true
+		winners = append(winners, rule)
+	}
+
+	sort.SliceStable(winners, func(i, j int) bool {
+		return winners[i].Action.Slot < winners[j].Action.Slot
+	})
+
+	for _, rule := range winners {
+		productID := *rule.Action.ProductID
+		rec := present[productID]
+
+		for i := range recommendations {
+			if recommendations[i].ProductID == productID {
+				recommendations = append(recommendations[:i], recommendations[i+1:]...)
+				break
+			}
+		}
+
+		slot := rule.Action.Slot - 1
+		if slot > len(recommendations) {
+			slot = len(recommendations)
+		}
+		recommendations = append(recommendations[:slot], append([]dto.ProductRecommendationV2{rec}, recommendations[slot:]...)...)
+		recordFiredRule(rc, rule, []uuid.UUID{productID})
+	}
+
+	return recommendations
+}
+
+// merchandisingRuleMatches reports whether a rule's request-level conditions match the request.
+// Category conditions are evaluated per product by merchandisingProductMatches.
+func merchandisingRuleMatches(rule dto.MerchandisingRule, rc *RankingContext) bool {
+	if len(rule.Conditions.ContextTypes) > 0 && !contains(rule.Conditions.ContextTypes, rc.ContextType) {
+		return false
+	}
+
+	if len(rule.Conditions.CustomerSegments) > 0 && !contains(rule.Conditions.CustomerSegments, CustomerSegment(rc.Profile)) {
+		return false
+	}
+
+	return true
+}
+
+// merchandisingProductMatches reports whether a rule targets a recommendation
+// and the recommendation's category satisfies the rule's category condition
+func merchandisingProductMatches(rule dto.MerchandisingRule, rec dto.ProductRecommendationV2) bool {
+	if !merchandisingTargetMatches(rule.Action, rec) {
+		return false
+	}
+
+	if len(rule.Conditions.CategoryIDs) == 0 {
+		return true
+	}
+	for _, categoryID := range rule.Conditions.CategoryIDs {
+		if categoryID == rec.CategoryID {
+			return true
+		}
+	}
+	return false
+}
+
+// merchandisingBlocked reports whether any block rule removes the recommendation
+func merchandisingBlocked(blocks []dto.MerchandisingRule, rec dto.ProductRecommendationV2) bool {
+	for _, rule := range blocks {
+		if merchandisingProductMatches(rule, rec) {
+			return true
+		}
+	}
+	return false
+}
+
+// merchandisingTargetMatches reports whether a recommendation is targeted by a rule action
+func merchandisingTargetMatches(action dto.MerchandisingAction, rec dto.ProductRecommendationV2) bool {
+	if action.ProductID != nil && *action.ProductID == rec.ProductID {
+		return true
+	}
+	if action.Brand != "" && strings.EqualFold(action.Brand, rec.Brand) {
+		return true
+	}
+	if action.CategoryID != nil && *action.CategoryID == rec.CategoryID {
+		return true
+	}
+	return false
+}
+
+// recordFiredRule notes a rule in the ranking context when it affected at least one product
+func recordFiredRule(rc *RankingContext, rule dto.MerchandisingRule, affected []uuid.UUID) {
+	if len(affected) == 0 {
+		return
+	}
+
+	rc.FiredRules = append(rc.FiredRules, dto.FiredRule{
+		RuleID:     rule.ID,
+		Name:       rule.Name,
+		Action:     rule.Action.Type,
+		ProductIDs: affected,
+	})
+}
@@ -0,0 +1,147 @@
+package service
+
+import (
+	"context"
+	"ec-recommend/internal/dto"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// fakeMerchandisingRuleRepository serves fixed active rules;
+// every other method is left to the nil embedded interface
+type fakeMerchandisingRuleRepository struct {
+	MerchandisingRuleRepositoryInterface
+	rules []dto.MerchandisingRule
+}
+
+func (f *fakeMerchandisingRuleRepository) GetActiveMerchandisingRules(ctx context.Context, at time.Time) ([]dto.MerchandisingRule, error) {
+	return f.rules, nil
+}
+
+// fakeProductLookup serves products that are not in the candidate list
+type fakeProductLookup struct {
+	products map[uuid.UUID]dto.ProductRecommendationV2
+}
+
+func (f *fakeProductLookup) GetProductsByIDs(ctx context.Context, productIDs []uuid.UUID) ([]dto.ProductRecommendationV2, error) {
+	var products []dto.ProductRecommendationV2
+	for _, productID := range productIDs {
+		if product, ok := f.products[productID]; ok {
+			products = append(products, product)
+		}
+	}
+	return products, nil
+}
+
+func TestMerchandisingRuleStage(t *testing.T) {
+	a := diversityCandidate("a", "Acme", 1, 0.9)
+	b := diversityCandidate("b", "Other", 2, 0.8)
+	c := diversityCandidate("c", "Other", 1, 0.7)
+	d := diversityCandidate("d", "Acme", 2, 0.6)
+	extra := diversityCandidate("extra", "Extra", 3, 0.5)
+
+	rule := func(name string, priority int, categoryIDs []int, action dto.MerchandisingAction) dto.MerchandisingRule {
+		return dto.MerchandisingRule{
+			ID:         uuid.New(),
+			Name:       name,
+			Priority:   priority,
+			Conditions: dto.MerchandisingConditions{CategoryIDs: categoryIDs},
+			Action:     action,
+		}
+	}
+
+	pinExtra := rule("pin-extra", 1, nil, dto.MerchandisingAction{Type: dto.MerchandisingActionPin, ProductID: &extra.ProductID, Slot: 1})
+
+	tests := []struct {
+		name     string
+		rules    []dto.MerchandisingRule
+		excluded map[uuid.UUID]bool
+		feedback []dto.RecommendationFeedback
+		want     []string
+	}{
+		{
+			name: "higher priority bury beats lower priority boost",
+			rules: []dto.MerchandisingRule{
+				rule("boost-d", 1, nil, dto.MerchandisingAction{Type: dto.MerchandisingActionBoost, ProductID: &d.ProductID, Factor: 10}),
+				rule("bury-d", 5, nil, dto.MerchandisingAction{Type: dto.MerchandisingActionBury, ProductID: &d.ProductID, Factor: 1.5}),
+			},
+			want: []string{"a", "b", "c", "d"},
+		},
+		{
+			name: "higher priority pin wins a contested slot",
+			rules: []dto.MerchandisingRule{
+				rule("pin-c", 1, nil, dto.MerchandisingAction{Type: dto.MerchandisingActionPin, ProductID: &c.ProductID, Slot: 1}),
+				rule("pin-d", 5, nil, dto.MerchandisingAction{Type: dto.MerchandisingActionPin, ProductID: &d.ProductID, Slot: 1}),
+			},
+			want: []string{"d", "a", "b", "c"},
+		},
+		{
+			name: "higher priority pin decides the slot of a product",
+			rules: []dto.MerchandisingRule{
+				rule("pin-extra-2", 1, nil, dto.MerchandisingAction{Type: dto.MerchandisingActionPin, ProductID: &extra.ProductID, Slot: 2}),
+				rule("pin-extra-3", 5, nil, dto.MerchandisingAction{Type: dto.MerchandisingActionPin, ProductID: &extra.ProductID, Slot: 3}),
+			},
+			want: []string{"a", "b", "extra", "c", "d"},
+		},
+		{
+			name: "category condition matches the product category",
+			rules: []dto.MerchandisingRule{
+				rule("block-acme-in-1", 1, []int{1}, dto.MerchandisingAction{Type: dto.MerchandisingActionBlockBrand, Brand: "acme"}),
+				rule("boost-other-in-1", 1, []int{1}, dto.MerchandisingAction{Type: dto.MerchandisingActionBoost, Brand: "Other", Factor: 10}),
+			},
+			want: []string{"c", "b", "d"},
+		},
+		{
+			name: "blocked products are not pinned",
+			rules: []dto.MerchandisingRule{
+				rule("block-extra", 1, nil, dto.MerchandisingAction{Type: dto.MerchandisingActionBlockBrand, Brand: "Extra"}),
+				rule("pin-extra", 5, nil, dto.MerchandisingAction{Type: dto.MerchandisingActionPin, ProductID: &extra.ProductID, Slot: 1}),
+			},
+			want: []string{"a", "b", "c", "d"},
+		},
+		{
+			name:     "excluded products are not injected by a pin",
+			rules:    []dto.MerchandisingRule{pinExtra},
+			excluded: map[uuid.UUID]bool{extra.ProductID: true},
+			want:     []string{"a", "b", "c", "d"},
+		},
+		{
+			name:     "dismissed products are not injected by a pin",
+			rules:    []dto.MerchandisingRule{pinExtra},
+			feedback: []dto.RecommendationFeedback{{ProductID: extra.ProductID}},
+			want:     []string{"a", "b", "c", "d"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stage := NewMerchandisingRuleStage(
+				&fakeMerchandisingRuleRepository{rules: tt.rules},
+				&fakeProductLookup{products: map[uuid.UUID]dto.ProductRecommendationV2{extra.ProductID: extra}},
+			)
+			candidates := []dto.ProductRecommendationV2{a, b, c, d}
+
+			rc := &RankingContext{ContextType: "homepage", Excluded: tt.excluded}
+			if tt.feedback != nil {
+				rc.Suppressions = NewFeedbackSuppressions(tt.feedback)
+			}
+
+			ranked, err := stage.Apply(context.Background(), candidates, rc)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			got := recommendationNames(ranked)
+			if len(got) != len(tt.want) {
+				t.Fatalf("Expected %v, got %v", tt.want, got)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("Expected %v, got %v", tt.want, got)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package service
+
+import (
+	"context"
+	"ec-recommend/internal/dto"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// MerchandisingService manages merchandising rules for the admin API
+type MerchandisingService struct {
+	repo MerchandisingRuleRepositoryInterface
+}
+
+// NewMerchandisingService creates a new merchandising service instance
+func NewMerchandisingService(repo MerchandisingRuleRepositoryInterface) *MerchandisingService {
+	return &MerchandisingService{
+		repo: repo,
+	}
+}
+
+// ListRules returns all merchandising rules
+func (ms *MerchandisingService) ListRules(ctx context.Context) (*dto.MerchandisingRuleListResponse, error) {
+	rules, err := ms.repo.ListMerchandisingRules(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list merchandising rules: %w", err)
+	}
+
+	return &dto.MerchandisingRuleListResponse{
+		Rules: rules,
+		Total: len(rules),
+	}, nil
+}
+
+// GetRule returns a merchandising rule by ID, or nil if it does not exist
+func (ms *MerchandisingService) GetRule(ctx context.Context, ruleID uuid.UUID) (*dto.MerchandisingRule, error) {
+	return ms.repo.GetMerchandisingRule(ctx, ruleID)
+}
+
+// CreateRule creates a merchandising rule from a validated request
+func (ms *MerchandisingService) CreateRule(ctx context.Context, req *dto.MerchandisingRuleRequest) (*dto.MerchandisingRule, error) {
+	rule, err := ms.repo.CreateMerchandisingRule(ctx, ruleFromRequest(uuid.Nil, req))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create merchandising rule: %w", err)
+	}
+
+	return rule, nil
+}
+
+// UpdateRule replaces a merchandising rule, returning nil if it does not exist
+func (ms *MerchandisingService) UpdateRule(ctx context.Context, ruleID uuid.UUID, req *dto.MerchandisingRuleRequest) (*dto.MerchandisingRule, error) {
+	rule, err := ms.repo.UpdateMerchandisingRule(ctx, ruleFromRequest(ruleID, req))
+	if err != nil {
+		return nil, fmt.Errorf("failed to update merchandising rule: %w", err)
+	}
+
+	return rule, nil
+}
+
+// DeleteRule deletes a merchandising rule, reporting whether it existed
+func (ms *MerchandisingService) DeleteRule(ctx context.Context, ruleID uuid.UUID) (bool, error) {
+	return ms.repo.DeleteMerchandisingRule(ctx, ruleID)
+}
+
+func ruleFromRequest(ruleID uuid.UUID, req *dto.MerchandisingRuleRequest) *dto.MerchandisingRule {
+	isActive := true
+	if req.IsActive != nil {
+		isActive = *req.IsActive
+	}
+
+	return &dto.MerchandisingRule{
+		ID:          ruleID,
+		Name:        req.Name,
+		Description: req.Description,
+		IsActive:    isActive,
+		Priority:    req.Priority,
+		Conditions:  req.Conditions,
+		Action:      req.Action,
+	}
+}
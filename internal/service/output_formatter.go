@@ -178,6 +178,51 @@ func (of *OutputFormatter) initializeSchemas() {
     "confidence_score": 0.82,
     "cross_sell_potential": 0.75
   }
+]`,
+	}
+
+	// Gift recommendations schema
+	of.schemas["gift_recommendations"] = OutputSchema{
+		Format: "json_array",
+		Fields: []SchemaField{
+			{
+				Name:        "product_id",
+				Type:        "string",
+				Required:    true,
+				Description: "ギフト商品のUUID",
+			},
+			{
+				Name:        "recommendation_reason",
+				Type:        "string",
+				Required:    true,
+				Description: "贈る相手の視点での推薦理由（120文字以内）",
+			},
+			{
+				Name:        "gift_appeal",
+				Type:        "string",
+				Required:    true,
+				Description: "贈答シーンや関係性に合う点（60文字以内）",
+			},
+			{
+				Name:        "confidence_score",
+				Type:        "float",
+				Required:    true,
+				Description: "ギフトとしての適合度（0.0-1.0の範囲）",
+			},
+		},
+		Constraints: []string{
+			"recommendation_reasonは120文字以内",
+			"gift_appealは60文字以内",
+			"confidence_scoreは0.0-1.0の範囲",
+			"入力された全ての商品について出力",
+		},
+		Example: `[
+  {
+    "product_id": "456e7890-e89b-12d3-a456-426614174002",
+    "recommendation_reason": "料理好きのご友人なら、毎日のキッチンで使うたびに贈り主を思い出してもらえます。",
+    "gift_appeal": "誕生日に気兼ねなく贈れる価格帯と上質さ",
+    "confidence_score": 0.85
+  }
 ]`,
 	}
 }
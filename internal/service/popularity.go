@@ -0,0 +1,160 @@
+package service
+
+import (
+	"context"
+	"ec-recommend/internal/dto"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PopularityTuning configures the time-decayed popularity_score recomputation
+type PopularityTuning struct {
+	LookbackDays      int     `json:"lookback_days"`      // Events older than this are ignored
+	HalfLifeDays      float64 `json:"half_life_days"`     // An event's weight halves every HalfLifeDays
+	OrderWeight       float64 `json:"order_weight"`       // Per decayed unit ordered
+	CartWeight        float64 `json:"cart_weight"`        // Per decayed add-to-cart
+	WishlistWeight    float64 `json:"wishlist_weight"`    // Per decayed wishlist add
+	ViewWeight        float64 `json:"view_weight"`        // Per decayed view
+	MaxScore          int     `json:"max_score"`          // Score of the most popular product
+	SignificantChange int     `json:"significant_change"` // Change since the last knowledge base sync that triggers a re-sync
+}
+
+// DefaultPopularityTuning returns the popularity tuning used when none is configured
+func DefaultPopularityTuning() PopularityTuning {
+	return PopularityTuning{
+		LookbackDays:      180,
+		HalfLifeDays:      14,
+		OrderWeight:       10,
+		CartWeight:        3,
+		WishlistWeight:    2,
+		ViewWeight:        0.2,
+		MaxScore:          100,
+		SignificantChange: 10,
+	}
+}
+
+// ComputePopularityScores scores products from their decayed signals and returns those whose
+// score differs from the current one, ordered by product ID.
+// The raw score is the weighted sum of the signals; scores are log-scaled against the most
+// popular product so that the long tail keeps distinguishable values:
+// score = MaxScore * ln(1 + raw) / ln(1 + max raw). Products without any recent signal decay to 0.
+// A change is significant when the score moved by SignificantChange since the knowledge base was
+// last synced, given by synced; products missing from synced are in sync at their current score,
+// so small changes over several runs add up instead of each being judged on its own.
+func ComputePopularityScores(signals []dto.PopularitySignals, current, synced map[uuid.UUID]int, tuning PopularityTuning) []dto.ProductPopularity {
+	raw := make(map[uuid.UUID]float64, len(signals))
+	byProduct := make(map[uuid.UUID]dto.PopularitySignals, len(signals))
+	maxRaw := 0.0
+	for _, s := range signals {
+		if _, active := current[s.ProductID]; !active {
+			continue
+		}
+		value := tuning.OrderWeight*s.Orders + tuning.CartWeight*s.Carts + tuning.WishlistWeight*s.Wishlists + tuning.ViewWeight*s.Views
+		raw[s.ProductID] = value
+		byProduct[s.ProductID] = s
+		maxRaw = math.Max(maxRaw, value)
+	}
+
+	var changes []dto.ProductPopularity
+	for productID, previous := range current {
+		score := 0
+		if maxRaw > 0 {
+			score = int(math.Round(float64(tuning.MaxScore) * math.Log1p(raw[productID]) / math.Log1p(maxRaw)))
+		}
+		if score == previous {
+			continue
+		}
+
+		baseline, ok := synced[productID]
+		if !ok {
+			baseline = previous
+		}
+		delta := score - baseline
+		if delta < 0 {
+			delta = -delta
+		}
+		signal := byProduct[productID]
+		signal.ProductID = productID
+		changes = append(changes, dto.ProductPopularity{
+			ProductID:     productID,
+			PreviousScore: previous,
+			Score:         score,
+			RawScore:      raw[productID],
+			Signals:       signal,
+			Significant:   delta >= tuning.SignificantChange,
+		})
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].ProductID.String() < changes[j].ProductID.String()
+	})
+
+	return changes
+}
+
+// PopularityService recomputes products.popularity_score from recent interactions
+type PopularityService struct {
+	repo   PopularityRepositoryInterface
+	tuning PopularityTuning
+}
+
+// NewPopularityService creates a new popularity service
+func NewPopularityService(repo PopularityRepositoryInterface, tuning PopularityTuning) *PopularityService {
+	defaults := DefaultPopularityTuning()
+	if tuning.LookbackDays <= 0 {
+		tuning.LookbackDays = defaults.LookbackDays
+	}
+	if tuning.HalfLifeDays <= 0 {
+		tuning.HalfLifeDays = defaults.HalfLifeDays
+	}
+	if tuning.MaxScore <= 0 {
+		tuning.MaxScore = defaults.MaxScore
+	}
+
+	return &PopularityService{
+		repo:   repo,
+		tuning: tuning,
+	}
+}
+
+// Recompute scores every active product as of now, writes changed scores and their history,
+// and flags significant changes for knowledge base re-sync
+func (ps *PopularityService) Recompute(ctx context.Context, now time.Time) (*dto.PopularityRunSummary, error) {
+	current, err := ps.repo.GetPopularityScores(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get popularity scores: %w", err)
+	}
+
+	synced, err := ps.repo.GetKnowledgeBaseSyncedScores(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get knowledge base synced scores: %w", err)
+	}
+
+	since := now.AddDate(0, 0, -ps.tuning.LookbackDays)
+	signals, err := ps.repo.GetPopularitySignals(ctx, now, since, ps.tuning.HalfLifeDays)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get popularity signals: %w", err)
+	}
+
+	changes := ComputePopularityScores(signals, current, synced, ps.tuning)
+	if err := ps.repo.SavePopularityScores(ctx, changes, now); err != nil {
+		return nil, fmt.Errorf("failed to save popularity scores: %w", err)
+	}
+
+	summary := &dto.PopularityRunSummary{
+		ComputedAt: now,
+		Products:   len(current),
+		Changed:    len(changes),
+	}
+	for _, change := range changes {
+		if change.Significant {
+			summary.Significant++
+		}
+	}
+
+	return summary, nil
+}
@@ -0,0 +1,34 @@
+package service
+
+import (
+	"context"
+	"ec-recommend/internal/dto"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PopularityRepositoryInterface defines queries for popularity score recomputation and its history
+// This interface is defined in the service package as it is consumed by services
+type PopularityRepositoryInterface interface {
+	// GetPopularityScores returns the current popularity_score of every active product
+	GetPopularityScores(ctx context.Context) (map[uuid.UUID]int, error)
+
+	// GetKnowledgeBaseSyncedScores returns, for products with score changes not yet re-synced,
+	// the popularity_score their knowledge base document was last synced with
+	GetKnowledgeBaseSyncedScores(ctx context.Context) (map[uuid.UUID]int, error)
+
+	// GetPopularitySignals returns order, view, cart and wishlist counts since the given time,
+	// each event weighted by exp(-ln2 * age / halfLifeDays) relative to now
+	GetPopularitySignals(ctx context.Context, now, since time.Time, halfLifeDays float64) ([]dto.PopularitySignals, error)
+
+	// SavePopularityScores writes changed scores to products and appends them to the history
+	SavePopularityScores(ctx context.Context, changes []dto.ProductPopularity, computedAt time.Time) error
+
+	// GetPendingKnowledgeBaseSync returns products with significant score changes not yet re-synced
+	GetPendingKnowledgeBaseSync(ctx context.Context) ([]uuid.UUID, error)
+
+	// MarkKnowledgeBaseSynced records that the products' knowledge base documents were re-synced,
+	// which makes their current score the baseline for later significance checks
+	MarkKnowledgeBaseSynced(ctx context.Context, productIDs []uuid.UUID, syncedAt time.Time) error
+}
@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"ec-recommend/internal/dto"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// fakePopularityRepository serves fixed scores and signals and records saved changes
+type fakePopularityRepository struct {
+	scores  map[uuid.UUID]int
+	synced  map[uuid.UUID]int
+	signals []dto.PopularitySignals
+	saved   []dto.ProductPopularity
+}
+
+func (f *fakePopularityRepository) GetPopularityScores(ctx context.Context) (map[uuid.UUID]int, error) {
+	return f.scores, nil
+}
+
+func (f *fakePopularityRepository) GetKnowledgeBaseSyncedScores(ctx context.Context) (map[uuid.UUID]int, error) {
+	return f.synced, nil
+}
+
+func (f *fakePopularityRepository) GetPopularitySignals(ctx context.Context, now, since time.Time, halfLifeDays float64) ([]dto.PopularitySignals, error) {
+	return f.signals, nil
+}
+
+func (f *fakePopularityRepository) SavePopularityScores(ctx context.Context, changes []dto.ProductPopularity, computedAt time.Time) error {
+	f.saved = append(f.saved, changes...)
+	return nil
+}
+
+func (f *fakePopularityRepository) GetPendingKnowledgeBaseSync(ctx context.Context) ([]uuid.UUID, error) {
+	return nil, nil
+}
+
+func (f *fakePopularityRepository) MarkKnowledgeBaseSynced(ctx context.Context, productIDs []uuid.UUID, syncedAt time.Time) error {
+	return nil
+}
+
+func TestPopularityRecomputation(t *testing.T) {
+	tuning := DefaultPopularityTuning()
+	bestseller := uuid.New()
+	browsed := uuid.New() // Viewed a lot but rarely bought
+	stale := uuid.New()   // No recent activity
+
+	current := map[uuid.UUID]int{bestseller: 95, browsed: 40, stale: 80}
+	signals := []dto.PopularitySignals{
+		{ProductID: bestseller, Orders: 30, Views: 200, Carts: 20, Wishlists: 5},
+		{ProductID: browsed, Orders: 1, Views: 150, Carts: 2},
+		{ProductID: uuid.New(), Orders: 50}, // Inactive products are ignored
+	}
+
+	t.Run("scales scores against the most popular product", func(t *testing.T) {
+		changes := ComputePopularityScores(signals, current, nil, tuning)
+		byProduct := make(map[uuid.UUID]dto.ProductPopularity)
+		for _, change := range changes {
+			byProduct[change.ProductID] = change
+		}
+
+		if change, ok := byProduct[bestseller]; !ok || change.Score != tuning.MaxScore {
+			t.Errorf("Expected the bestseller to score %d, got %+v", tuning.MaxScore, change)
+		}
+		if change := byProduct[stale]; change.Score != 0 || !change.Significant {
+			t.Errorf("Expected the stale product to decay to 0 significantly, got %+v", change)
+		}
+		if change := byProduct[browsed]; change.Score <= 0 || change.Score >= tuning.MaxScore {
+			t.Errorf("Expected an intermediate score for the browsed product, got %+v", change)
+		}
+		if len(changes) != 3 {
+			t.Errorf("Expected 3 changes, got %d", len(changes))
+		}
+	})
+
+	t.Run("skips unchanged scores and flags significant changes", func(t *testing.T) {
+		changes := ComputePopularityScores(signals, current, nil, tuning)
+		unchanged := make(map[uuid.UUID]int, len(changes))
+		for _, change := range changes {
+			unchanged[change.ProductID] = change.Score
+		}
+		// Nudge one score within the significance threshold
+		unchanged[bestseller] = tuning.MaxScore - 3
+
+		repo := &fakePopularityRepository{scores: unchanged, signals: signals}
+		summary, err := NewPopularityService(repo, tuning).Recompute(context.Background(), time.Now())
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if summary.Changed != 1 || summary.Significant != 0 {
+			t.Errorf("Expected 1 insignificant change, got %+v", summary)
+		}
+		if len(repo.saved) != 1 || repo.saved[0].ProductID != bestseller || repo.saved[0].PreviousScore != tuning.MaxScore-3 {
+			t.Errorf("Expected the bestseller change to be saved, got %+v", repo.saved)
+		}
+	})
+
+	t.Run("measures significance against the last knowledge base sync", func(t *testing.T) {
+		changes := ComputePopularityScores(signals, current, nil, tuning)
+		scores := make(map[uuid.UUID]int, len(changes))
+		for _, change := range changes {
+			scores[change.ProductID] = change.Score
+		}
+		// The score crept up over earlier runs, each change too small to re-sync
+		target := scores[browsed]
+		scores[browsed] = target - 3
+		synced := map[uuid.UUID]int{browsed: target - tuning.SignificantChange}
+
+		repo := &fakePopularityRepository{scores: scores, synced: synced, signals: signals}
+		summary, err := NewPopularityService(repo, tuning).Recompute(context.Background(), time.Now())
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if summary.Changed != 1 || summary.Significant != 1 {
+			t.Errorf("Expected 1 significant change, got %+v", summary)
+		}
+		if len(repo.saved) != 1 || repo.saved[0].PreviousScore != target-3 || !repo.saved[0].Significant {
+			t.Errorf("Expected the drift since the last sync to be significant, got %+v", repo.saved)
+		}
+	})
+}
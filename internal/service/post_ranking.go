@@ -0,0 +1,156 @@
+package service
+
+import (
+	"context"
+	"ec-recommend/internal/dto"
+	"log"
+
+	"github.com/google/uuid"
+)
+
+// RankingContext carries request-level information shared by post-ranking stages
+type RankingContext struct {
+	Service     string // "v1" or "v2"
+	CustomerID  uuid.UUID
+	ContextType string
+	CategoryID  *int
+	Profile     *dto.CustomerProfile
+	Limit       int
+	Diversity   *float64 // Per-request diversity knob (0 = relevance only, 1 = maximum diversity)
+
+	// Excluded holds products filtered out before ranking, such as owned products when the request
+	// excludes them; merchandising pins do not inject them back
+	Excluded map[uuid.UUID]bool
+
+	// Suppressions is set by the feedback suppression stage with the customer's current dismissals
+	Suppressions *FeedbackSuppressions
+
+	// FiredRules is populated by stages with the merchandising rules that changed the list
+	FiredRules []dto.FiredRule
+
+	// Features holds ranking features per product computed by the learning-to-rank stage
+	Features map[uuid.UUID]map[string]float64
+
+	// PriceSensitivity is the customer's price sensitivity estimated by the price sensitivity stage
+	PriceSensitivity *dto.PriceSensitivity
+}
+
+// purchasedProducts returns the set of products in a purchase history
+func purchasedProducts(purchases []dto.PurchaseItem) map[uuid.UUID]bool {
+	products := make(map[uuid.UUID]bool, len(purchases))
+	for _, purchase := range purchases {
+		products[purchase.ProductID] = true
+	}
+	return products
+}
+
+// PostRankingStage adjusts a ranked recommendation list after candidate generation and scoring.
+// Stages may reorder, rescore or drop recommendations; only explicit merchandising pins add products.
+type PostRankingStage interface {
+	// Name returns a short identifier used in logs
+	Name() string
+
+	// Apply returns the adjusted recommendation list
+	Apply(ctx context.Context, recommendations []dto.ProductRecommendationV2, rc *RankingContext) ([]dto.ProductRecommendationV2, error)
+}
+
+// policyStage is implemented by post-ranking stages that enforce policy (merchandising blocks,
+// feedback suppression, exposure and diversity caps) rather than personalize the ranking
+type policyStage interface {
+	enforcesPolicy()
+}
+
+// PostRankingPipeline runs post-ranking stages in registration order
+type PostRankingPipeline struct {
+	stages []PostRankingStage
+}
+
+// NewPostRankingPipeline creates a pipeline with the given stages
+func NewPostRankingPipeline(stages ...PostRankingStage) *PostRankingPipeline {
+	return &PostRankingPipeline{stages: stages}
+}
+
+// AddStage appends a stage to the end of the pipeline
+func (p *PostRankingPipeline) AddStage(stage PostRankingStage) {
+	p.stages = append(p.stages, stage)
+}
+
+// Apply runs every stage over the recommendations.
+// A failing stage is logged and skipped so that post-ranking never fails the request.
+func (p *PostRankingPipeline) Apply(ctx context.Context, recommendations []dto.ProductRecommendationV2, rc *RankingContext) []dto.ProductRecommendationV2 {
+	for _, stage := range p.stages {
+		adjusted, err := stage.Apply(ctx, recommendations, rc)
+		if err != nil {
+			log.Printf("Warning: post-ranking stage %s failed: %v", stage.Name(), err)
+			continue
+		}
+		recommendations = adjusted
+	}
+
+	return recommendations
+}
+
+// ApplyPolicy runs only the policy stages, for results ranked for someone other than the
+// customer (such as gifts) that must still respect blocks, suppressions and caps
+func (p *PostRankingPipeline) ApplyPolicy(ctx context.Context, recommendations []dto.ProductRecommendationV2, rc *RankingContext) []dto.ProductRecommendationV2 {
+	var policy PostRankingPipeline
+	for _, stage := range p.stages {
+		if _, ok := stage.(policyStage); ok {
+			policy.AddStage(stage)
+		}
+	}
+
+	return policy.Apply(ctx, recommendations, rc)
+}
+
+// toRecommendationsV2 converts V1 recommendations so they can run through the post-ranking pipeline
+func toRecommendationsV2(recommendations []dto.ProductRecommendation) []dto.ProductRecommendationV2 {
+	converted := make([]dto.ProductRecommendationV2, len(recommendations))
+	for i, rec := range recommendations {
+		converted[i] = dto.ProductRecommendationV2{
+			ProductID:       rec.ProductID,
+			Name:            rec.Name,
+			Description:     rec.Description,
+			Price:           rec.Price,
+			OriginalPrice:   rec.OriginalPrice,
+			Brand:           rec.Brand,
+			CategoryID:      rec.CategoryID,
+			CategoryName:    rec.CategoryName,
+			RatingAverage:   rec.RatingAverage,
+			RatingCount:     rec.RatingCount,
+			PopularityScore: rec.PopularityScore,
+			ConfidenceScore: rec.ConfidenceScore,
+			Reason:          rec.Reason,
+			Tags:            rec.Tags,
+			ImageURL:        rec.ImageURL,
+		}
+	}
+
+	return converted
+}
+
+// fromRecommendationsV2 converts post-ranked recommendations back to the V1 representation
+func fromRecommendationsV2(recommendations []dto.ProductRecommendationV2) []dto.ProductRecommendation {
+	converted := make([]dto.ProductRecommendation, len(recommendations))
+	for i, rec := range recommendations {
+		converted[i] = dto.ProductRecommendation{
+			ProductID:       rec.ProductID,
+			Name:            rec.Name,
+			Description:     rec.Description,
+			Price:           rec.Price,
+			OriginalPrice:   rec.OriginalPrice,
+			Brand:           rec.Brand,
+			CategoryID:      rec.CategoryID,
+			CategoryName:    rec.CategoryName,
+			RatingAverage:   rec.RatingAverage,
+			RatingCount:     rec.RatingCount,
+			PopularityScore: rec.PopularityScore,
+			ConfidenceScore: rec.ConfidenceScore,
+			Reason:          rec.Reason,
+			Tags:            rec.Tags,
+			ImageURL:        rec.ImageURL,
+		}
+	}
+
+	return converted
+}
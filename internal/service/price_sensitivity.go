@@ -0,0 +1,224 @@
+package service
+
+import (
+	"context"
+	"ec-recommend/internal/dto"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// PriceSensitivityTuning configures price-sensitivity estimation and the ranking adjustment
+type PriceSensitivityTuning struct {
+	Disabled             bool    `json:"disabled,omitempty"`
+	LookbackDays         int     `json:"lookback_days"`          // Purchase history used for the estimate
+	ShrinkagePurchases   int     `json:"shrinkage_purchases"`    // Purchases at which the estimate carries half weight
+	NeutralDiscountShare float64 `json:"neutral_discount_share"` // Share of discounted units of a typical customer
+	FullDiscountDepth    float64 `json:"full_discount_depth"`    // Discount treated as maximally attractive
+	SegmentThreshold     float64 `json:"segment_threshold"`      // Score beyond which a customer is a deal-seeker or premium
+	PremiumCustomerPull  float64 `json:"premium_customer_pull"`  // Minimum pull towards premium items for is_premium customers
+	MaxAdjustment        float64 `json:"max_adjustment"`         // Largest relative change to a confidence score
+}
+
+// DefaultPriceSensitivityTuning returns the price sensitivity tuning used when none is configured
+func DefaultPriceSensitivityTuning() PriceSensitivityTuning {
+	return PriceSensitivityTuning{
+		LookbackDays:         365,
+		ShrinkagePurchases:   5,
+		NeutralDiscountShare: 0.3,
+		FullDiscountDepth:    0.3,
+		SegmentThreshold:     0.3,
+		PremiumCustomerPull:  0.5,
+		MaxAdjustment:        0.2,
+	}
+}
+
+// EstimatePriceSensitivity scores how price-driven a customer is from the share and depth of
+// discounted units they bought and from what they paid relative to category medians.
+// The score is shrunk towards neutral for customers with few purchases.
+func EstimatePriceSensitivity(points []dto.PricePoint, isPremium bool, tuning PriceSensitivityTuning) *dto.PriceSensitivity {
+	sensitivity := &dto.PriceSensitivity{
+		Segment:    dto.PriceSegmentNeutral,
+		PriceIndex: 1,
+		Purchases:  len(points),
+		IsPremium:  isPremium,
+	}
+
+	var units, discountedUnits, depthSum, indexUnits, indexSum float64
+	for _, p := range points {
+		quantity := float64(p.Quantity)
+		if quantity <= 0 {
+			quantity = 1
+		}
+		units += quantity
+
+		if p.ListPrice > 0 {
+			if depth := (p.ListPrice - p.UnitPrice) / p.ListPrice; depth >= 0.01 {
+				discountedUnits += quantity
+				depthSum += depth * quantity
+			}
+		}
+		if p.CategoryMedianPrice > 0 {
+			indexUnits += quantity
+			indexSum += p.UnitPrice / p.CategoryMedianPrice * quantity
+		}
+	}
+	if units == 0 {
+		return sensitivity
+	}
+
+	sensitivity.DiscountShare = discountedUnits / units
+	if discountedUnits > 0 {
+		sensitivity.AvgDiscountDepth = depthSum / discountedUnits
+	}
+	if indexUnits > 0 {
+		sensitivity.PriceIndex = indexSum / indexUnits
+	}
+
+	// Deal component: discounted share relative to a typical customer, amplified by discount depth
+	neutral := tuning.NeutralDiscountShare
+	var deal float64
+	if sensitivity.DiscountShare >= neutral {
+		deal = (sensitivity.DiscountShare - neutral) / (1 - neutral)
+		deal *= 0.5 + 0.5*math.Min(1, sensitivity.AvgDiscountDepth/tuning.FullDiscountDepth)
+	} else {
+		deal = (sensitivity.DiscountShare - neutral) / neutral
+	}
+
+	// Price component: buying below the category median signals sensitivity, above it premium taste
+	price := clampUnit(1 - sensitivity.PriceIndex)
+
+	n := float64(len(points))
+	sensitivity.Score = clampUnit(0.5*deal+0.5*price) * n / (n + float64(tuning.ShrinkagePurchases))
+
+	switch {
+	case sensitivity.Score >= tuning.SegmentThreshold:
+		sensitivity.Segment = dto.PriceSegmentDealSeeker
+	case sensitivity.Score <= -tuning.SegmentThreshold:
+		sensitivity.Segment = dto.PriceSegmentPremium
+	}
+
+	return sensitivity
+}
+
+// PriceSensitivityAdjustment returns the relative adjustment in [-1, 1] for a product and why.
+// Deal-seekers are pulled towards discounted products and away from pricey ones; premium-leaning
+// and is_premium customers are pulled towards products priced above the category median.
+func PriceSensitivityAdjustment(rec dto.ProductRecommendationV2, categoryMedian float64, sensitivity *dto.PriceSensitivity, tuning PriceSensitivityTuning) (float64, string) {
+	var discount float64
+	if rec.OriginalPrice != nil && *rec.OriginalPrice > rec.Price {
+		discount = (*rec.OriginalPrice - rec.Price) / *rec.OriginalPrice
+	}
+	var relativePrice float64
+	if categoryMedian > 0 {
+		relativePrice = clampUnit(rec.Price/categoryMedian - 1)
+	}
+
+	var adjustment float64
+	var explanation string
+	if sensitivity.Score > 0 {
+		adjustment += sensitivity.Score * (math.Min(1, discount/tuning.FullDiscountDepth) - 0.5*math.Max(0, relativePrice))
+		if discount > 0 {
+			explanation = fmt.Sprintf("%.0f%% off for a deal-seeking customer", discount*100)
+		}
+	}
+
+	premiumPull := math.Max(0, -sensitivity.Score)
+	if sensitivity.IsPremium {
+		premiumPull = math.Max(premiumPull, tuning.PremiumCustomerPull)
+	}
+	if premiumPull > 0 {
+		adjustment += premiumPull * relativePrice
+		if relativePrice > 0 {
+			explanation = fmt.Sprintf("Premium pick priced %.0f%% above the category median", relativePrice*100)
+		}
+	}
+
+	adjustment = clampUnit(adjustment)
+	if explanation == "" {
+		explanation = fmt.Sprintf("Price fit for a %s customer", sensitivity.Segment)
+	}
+
+	return adjustment, explanation
+}
+
+// PriceSensitivityReranker adjusts confidence scores by the customer's price sensitivity.
+// The estimate is stored in the ranking context for later stages.
+type PriceSensitivityReranker struct {
+	repo   PriceSensitivityRepositoryInterface
+	tuning PriceSensitivityTuning
+}
+
+// NewPriceSensitivityReranker creates a price sensitivity post-ranking stage
+func NewPriceSensitivityReranker(repo PriceSensitivityRepositoryInterface, tuning PriceSensitivityTuning) *PriceSensitivityReranker {
+	return &PriceSensitivityReranker{
+		repo:   repo,
+		tuning: tuning,
+	}
+}
+
+// Name returns the stage identifier
+func (pr *PriceSensitivityReranker) Name() string {
+	return "price_sensitivity"
+}
+
+// Apply multiplies each confidence score by 1 + MaxAdjustment * adjustment, records the signal in
+// RelevanceContext and re-sorts by confidence
+func (pr *PriceSensitivityReranker) Apply(ctx context.Context, recommendations []dto.ProductRecommendationV2, rc *RankingContext) ([]dto.ProductRecommendationV2, error) {
+	if pr.tuning.Disabled || rc.Profile == nil || len(recommendations) == 0 {
+		return recommendations, nil
+	}
+
+	since := time.Now().AddDate(0, 0, -pr.tuning.LookbackDays)
+	points, err := pr.repo.GetCustomerPricePoints(ctx, rc.CustomerID, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get customer price points: %w", err)
+	}
+	sensitivity := EstimatePriceSensitivity(points, rc.Profile.IsPremium, pr.tuning)
+	rc.PriceSensitivity = sensitivity
+
+	if sensitivity.Score == 0 && !sensitivity.IsPremium {
+		return recommendations, nil
+	}
+
+	seen := make(map[int]bool)
+	var categoryIDs []int
+	for _, rec := range recommendations {
+		if !seen[rec.CategoryID] {
+			seen[rec.CategoryID] = true
+			categoryIDs = append(categoryIDs, rec.CategoryID)
+		}
+	}
+	medians, err := pr.repo.GetCategoryMedianPrices(ctx, categoryIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get category median prices: %w", err)
+	}
+
+	for i := range recommendations {
+		adjustment, explanation := PriceSensitivityAdjustment(recommendations[i], medians[recommendations[i].CategoryID], sensitivity, pr.tuning)
+		if math.Abs(adjustment) < 0.01 {
+			continue
+		}
+
+		recommendations[i].ConfidenceScore *= 1 + pr.tuning.MaxAdjustment*adjustment
+		recommendations[i].RelevanceContext = append(recommendations[i].RelevanceContext, dto.RelevanceContext{
+			ContextType: "price_sensitivity",
+			Explanation: explanation,
+			Confidence:  math.Abs(adjustment),
+			SourceData: fmt.Sprintf("segment=%s score=%.2f discount_share=%.2f price_index=%.2f adjustment=%.2f",
+				sensitivity.Segment, sensitivity.Score, sensitivity.DiscountShare, sensitivity.PriceIndex, adjustment),
+		})
+	}
+
+	sort.SliceStable(recommendations, func(i, j int) bool {
+		return recommendations[i].ConfidenceScore > recommendations[j].ConfidenceScore
+	})
+
+	return recommendations, nil
+}
+
+// clampUnit limits a value to [-1, 1]
+func clampUnit(value float64) float64 {
+	return math.Max(-1, math.Min(1, value))
+}
@@ -0,0 +1,19 @@
+package service
+
+import (
+	"context"
+	"ec-recommend/internal/dto"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PriceSensitivityRepositoryInterface defines the interface for purchase price data access
+// This interface is defined in the service package as it is consumed by services
+type PriceSensitivityRepositoryInterface interface {
+	// GetCustomerPricePoints returns the customer's purchased items since the given time with list and category median prices
+	GetCustomerPricePoints(ctx context.Context, customerID uuid.UUID, since time.Time) ([]dto.PricePoint, error)
+
+	// GetCategoryMedianPrices returns the median product price of each given category
+	GetCategoryMedianPrices(ctx context.Context, categoryIDs []int) (map[int]float64, error)
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"ec-recommend/internal/dto"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// fakePriceSensitivityRepository returns fixed price points and category medians
+type fakePriceSensitivityRepository struct {
+	points  []dto.PricePoint
+	medians map[int]float64
+}
+
+func (f *fakePriceSensitivityRepository) GetCustomerPricePoints(ctx context.Context, customerID uuid.UUID, since time.Time) ([]dto.PricePoint, error) {
+	return f.points, nil
+}
+
+func (f *fakePriceSensitivityRepository) GetCategoryMedianPrices(ctx context.Context, categoryIDs []int) (map[int]float64, error) {
+	return f.medians, nil
+}
+
+func TestPriceSensitivity(t *testing.T) {
+	ctx := context.Background()
+	tuning := DefaultPriceSensitivityTuning()
+	repeat := func(point dto.PricePoint, n int) []dto.PricePoint {
+		points := make([]dto.PricePoint, n)
+		for i := range points {
+			points[i] = point
+		}
+		return points
+	}
+	dealPoints := repeat(dto.PricePoint{CategoryID: 1, UnitPrice: 700, ListPrice: 1000, CategoryMedianPrice: 1000, Quantity: 1}, 20)
+	premiumPoints := repeat(dto.PricePoint{CategoryID: 1, UnitPrice: 2000, ListPrice: 2000, CategoryMedianPrice: 1000, Quantity: 1}, 20)
+
+	t.Run("segments customers by discounts and relative prices", func(t *testing.T) {
+		deal := EstimatePriceSensitivity(dealPoints, false, tuning)
+		if deal.Segment != dto.PriceSegmentDealSeeker || deal.DiscountShare != 1 {
+			t.Errorf("Expected deal_seeker with full discount share, got %s with %.2f", deal.Segment, deal.DiscountShare)
+		}
+
+		premium := EstimatePriceSensitivity(premiumPoints, false, tuning)
+		if premium.Segment != dto.PriceSegmentPremium || premium.PriceIndex != 2 {
+			t.Errorf("Expected premium with price index 2, got %s with %.2f", premium.Segment, premium.PriceIndex)
+		}
+
+		few := EstimatePriceSensitivity(dealPoints[:1], false, tuning)
+		if few.Score >= deal.Score {
+			t.Errorf("Expected a single purchase to be shrunk towards neutral, got %.2f vs %.2f", few.Score, deal.Score)
+		}
+	})
+
+	originalPrice := 1500.0
+	discounted := dto.ProductRecommendationV2{ProductID: uuid.New(), CategoryID: 1, Price: 1000, OriginalPrice: &originalPrice, ConfidenceScore: 0.8}
+	expensive := dto.ProductRecommendationV2{ProductID: uuid.New(), CategoryID: 1, Price: 2500, ConfidenceScore: 0.8}
+	rc := func(isPremium bool) *RankingContext {
+		return &RankingContext{CustomerID: uuid.New(), Profile: &dto.CustomerProfile{IsPremium: isPremium}}
+	}
+
+	t.Run("deal-seekers get discounted products first", func(t *testing.T) {
+		stage := NewPriceSensitivityReranker(&fakePriceSensitivityRepository{points: dealPoints, medians: map[int]float64{1: 1000}}, tuning)
+		rankingContext := rc(false)
+
+		ranked, err := stage.Apply(ctx, []dto.ProductRecommendationV2{expensive, discounted}, rankingContext)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if ranked[0].ProductID != discounted.ProductID {
+			t.Errorf("Expected discounted product first, got %s", ranked[0].ProductID)
+		}
+		if len(ranked[0].RelevanceContext) != 1 || ranked[0].RelevanceContext[0].ContextType != "price_sensitivity" {
+			t.Errorf("Expected price_sensitivity relevance context, got %+v", ranked[0].RelevanceContext)
+		}
+		if rankingContext.PriceSensitivity == nil || rankingContext.PriceSensitivity.Segment != dto.PriceSegmentDealSeeker {
+			t.Error("Expected the estimate in the ranking context")
+		}
+	})
+
+	t.Run("premium customers get premium products first", func(t *testing.T) {
+		stage := NewPriceSensitivityReranker(&fakePriceSensitivityRepository{medians: map[int]float64{1: 1000}}, tuning)
+
+		ranked, err := stage.Apply(ctx, []dto.ProductRecommendationV2{discounted, expensive}, rc(true))
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if ranked[0].ProductID != expensive.ProductID {
+			t.Errorf("Expected premium product first, got %s", ranked[0].ProductID)
+		}
+	})
+}
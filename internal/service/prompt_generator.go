@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"fmt"
+	"log"
 	"strings"
 	"time"
 
@@ -14,6 +15,7 @@ type PromptGenerator struct {
 	templates       map[string]*PromptTemplate
 	outputFormatter *OutputFormatter
 	config          *PromptConfig
+	seasonality     *SeasonalityService
 }
 
 // PromptConfig contains configuration for prompt generation
@@ -49,6 +51,11 @@ func NewPromptGenerator(config *PromptConfig) *PromptGenerator {
 	return pg
 }
 
+// SetSeasonality adds learned seasonal events and rising categories to the prompt context
+func (pg *PromptGenerator) SetSeasonality(seasonality *SeasonalityService) {
+	pg.seasonality = seasonality
+}
+
 // GenerateRecommendationPrompt generates a recommendation prompt based on context
 func (pg *PromptGenerator) GenerateRecommendationPrompt(
 	ctx context.Context,
@@ -63,8 +70,15 @@ func (pg *PromptGenerator) GenerateRecommendationPrompt(
 		return "", fmt.Errorf("failed to select template: %w", err)
 	}
 
+	// Use the template version requested by an experiment variant, if registered
+	if assignment := experimentAssignmentFromContext(ctx); assignment != nil && assignment.Overrides.PromptTemplateVersion != "" {
+		if versioned, ok := pg.templates[versionedTemplateKey(template.ID, assignment.Overrides.PromptTemplateVersion)]; ok {
+			template = versioned
+		}
+	}
+
 	// 2. Build context information
-	contextInfo := pg.buildContextInfo(profile, contextType)
+	contextInfo := pg.buildContextInfo(ctx, profile, contextType)
 
 	// 3. Select relevant examples if few-shot is enabled
 	var examples string
@@ -91,6 +105,78 @@ func (pg *PromptGenerator) GenerateRecommendationPrompt(
 	return prompt, nil
 }
 
+// GenerateGiftPrompt generates a prompt asking for gift-appropriate reasons from the recipient's point of view
+func (pg *PromptGenerator) GenerateGiftPrompt(
+	ctx context.Context,
+	products []dto.ProductRecommendationV2,
+	req *dto.GiftRequest,
+) (string, error) {
+
+	template, err := pg.selectTemplate("gift", nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to select template: %w", err)
+	}
+
+	// Use the template version requested by an experiment variant, if registered
+	if assignment := experimentAssignmentFromContext(ctx); assignment != nil && assignment.Overrides.PromptTemplateVersion != "" {
+		if versioned, ok := pg.templates[versionedTemplateKey(template.ID, assignment.Overrides.PromptTemplateVersion)]; ok {
+			template = versioned
+		}
+	}
+
+	var examples string
+	if pg.config.EnableFewShot && len(template.Examples) > 0 {
+		examples = pg.selectAndFormatExamples(template, nil, products)
+	}
+
+	// The giver's profile is deliberately left out; reasons are written for the recipient
+	variables := &PromptVariables{
+		CustomerProfile: pg.formatGiftRecipient(req),
+		Products:        pg.formatProducts(products),
+		OutputSchema:    pg.outputFormatter.FormatSchema(pg.getSchemaNameForContext("gift")),
+		Examples:        examples,
+		ContextInfo:     pg.buildContextInfo(ctx, nil, "gift"),
+	}
+
+	return pg.assemblePrompt(template, variables), nil
+}
+
+// formatGiftRecipient formats the gift recipient and occasion for prompt
+func (pg *PromptGenerator) formatGiftRecipient(req *dto.GiftRequest) string {
+	var builder strings.Builder
+	builder.WriteString("### 贈る相手\n\n")
+
+	recipient := req.Recipient
+	if recipient.AgeRange != "" {
+		builder.WriteString(fmt.Sprintf("**年代**: %s\n", recipient.AgeRange))
+	}
+	if recipient.Gender != "" {
+		builder.WriteString(fmt.Sprintf("**性別**: %s\n", recipient.Gender))
+	}
+	if recipient.Relationship != "" {
+		builder.WriteString(fmt.Sprintf("**関係性**: %s\n", recipient.Relationship))
+	}
+	if len(recipient.Interests) > 0 {
+		builder.WriteString(fmt.Sprintf("**趣味・関心**: %s\n", strings.Join(recipient.Interests, ", ")))
+	}
+	if req.Occasion != "" {
+		builder.WriteString(fmt.Sprintf("**贈答シーン**: %s\n", req.Occasion))
+	}
+	switch {
+	case req.BudgetMin != nil && req.BudgetMax != nil:
+		builder.WriteString(fmt.Sprintf("**予算**: %.0f円〜%.0f円\n", *req.BudgetMin, *req.BudgetMax))
+	case req.BudgetMax != nil:
+		builder.WriteString(fmt.Sprintf("**予算**: %.0f円以内\n", *req.BudgetMax))
+	case req.BudgetMin != nil:
+		builder.WriteString(fmt.Sprintf("**予算**: %.0f円以上\n", *req.BudgetMin))
+	}
+	if req.Message != "" {
+		builder.WriteString(fmt.Sprintf("**補足**: %s\n", req.Message))
+	}
+
+	return builder.String()
+}
+
 // selectTemplate selects the most appropriate template for the given context
 func (pg *PromptGenerator) selectTemplate(contextType string, profile *dto.CustomerProfile) (*PromptTemplate, error) {
 	templateID := pg.getTemplateIDForContext(contextType)
@@ -120,6 +206,8 @@ func (pg *PromptGenerator) getTemplateIDForContext(contextType string) string {
 		return "checkout_recommendations"
 	case "search_results":
 		return "search_recommendations"
+	case "gift":
+		return "gift_recommendations"
 	default:
 		return "default_recommendation"
 	}
@@ -132,13 +220,15 @@ func (pg *PromptGenerator) getSchemaNameForContext(contextType string) string {
 		return "homepage_recommendations"
 	case "product_page", "product_detail":
 		return "product_detail_recommendations"
+	case "gift":
+		return "gift_recommendations"
 	default:
 		return "product_recommendation"
 	}
 }
 
 // buildContextInfo builds context information string
-func (pg *PromptGenerator) buildContextInfo(profile *dto.CustomerProfile, contextType string) string {
+func (pg *PromptGenerator) buildContextInfo(ctx context.Context, profile *dto.CustomerProfile, contextType string) string {
 	var builder strings.Builder
 
 	builder.WriteString("## コンテキスト情報\n\n")
@@ -146,8 +236,7 @@ func (pg *PromptGenerator) buildContextInfo(profile *dto.CustomerProfile, contex
 	builder.WriteString(fmt.Sprintf("**現在時刻**: %s\n", time.Now().Format("2006年01月02日 15:04")))
 
 	// Add seasonal context
-	season := pg.getCurrentSeason()
-	builder.WriteString(fmt.Sprintf("**季節**: %s\n", season))
+	pg.writeSeasonalContext(ctx, &builder)
 
 	// Add personalization level specific information
 	if pg.config.PersonalizationLevel != "basic" {
@@ -324,22 +413,41 @@ func (pg *PromptGenerator) getContextDescription(contextType string) string {
 		return "チェックアウト画面"
 	case "search_results":
 		return "検索結果ページ"
+	case "gift":
+		return "ギフト選び中"
 	default:
 		return "サイト閲覧中"
 	}
 }
 
-func (pg *PromptGenerator) getCurrentSeason() string {
-	month := time.Now().Month()
-	switch {
-	case month >= 3 && month <= 5:
-		return "春"
-	case month >= 6 && month <= 8:
-		return "夏"
-	case month >= 9 && month <= 11:
-		return "秋"
-	default:
-		return "冬"
+// writeSeasonalContext writes the season together with learned seasonal events and rising demand
+func (pg *PromptGenerator) writeSeasonalContext(ctx context.Context, builder *strings.Builder) {
+	now := time.Now()
+	if pg.seasonality == nil {
+		builder.WriteString(fmt.Sprintf("**季節**: %s\n", calendarSeason(now)))
+		return
+	}
+
+	seasonal, err := pg.seasonality.Context(ctx, now)
+	if err != nil {
+		log.Printf("Warning: failed to get seasonal context: %v", err)
+		builder.WriteString(fmt.Sprintf("**季節**: %s\n", calendarSeason(now)))
+		return
+	}
+
+	builder.WriteString(fmt.Sprintf("**季節**: %s\n", seasonal.Season))
+	for _, event := range seasonal.ActiveEvents {
+		builder.WriteString(fmt.Sprintf("**季節イベント**: %s（%s〜%s）", event.Name, event.StartsAt.Format("01/02"), event.EndsAt.Format("01/02")))
+		if len(event.PeakCategories) > 0 {
+			builder.WriteString(fmt.Sprintf(" 需要が伸びるカテゴリ: %s", formatSeasonalIndices(event.PeakCategories)))
+		}
+		builder.WriteString("\n")
+	}
+	if len(seasonal.RisingCategories) > 0 {
+		builder.WriteString(fmt.Sprintf("**季節需要が高いカテゴリ**: %s\n", formatSeasonalIndices(seasonal.RisingCategories)))
+	}
+	if len(seasonal.RisingTags) > 0 {
+		builder.WriteString(fmt.Sprintf("**季節需要が高いタグ**: %s\n", formatSeasonalIndices(seasonal.RisingTags)))
 	}
 }
 
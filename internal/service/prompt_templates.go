@@ -1,5 +1,28 @@
 package service
 
+import (
+	"fmt"
+)
+
+// RegisterTemplateVersion registers an alternative version of a built-in template.
+// Versions are selected per request by experiment variants via prompt_template_version.
+func (pg *PromptGenerator) RegisterTemplateVersion(template PromptTemplate) error {
+	if template.ID == "" || template.Version == "" {
+		return fmt.Errorf("prompt template id and version are required")
+	}
+	if template.BasePrompt == "" {
+		return fmt.Errorf("prompt template %s@%s has no base prompt", template.ID, template.Version)
+	}
+
+	pg.templates[versionedTemplateKey(template.ID, template.Version)] = &template
+	return nil
+}
+
+// versionedTemplateKey returns the template map key of a specific template version
+func versionedTemplateKey(templateID, version string) string {
+	return templateID + "@" + version
+}
+
 // initializeTemplates initializes all prompt templates
 func (pg *PromptGenerator) initializeTemplates() {
 	// Default recommendation template
@@ -250,4 +273,51 @@ func (pg *PromptGenerator) initializeTemplates() {
 			},
 		},
 	}
+
+	// Gift recommendations template
+	pg.templates["gift_recommendations"] = &PromptTemplate{
+		ID:          "gift_recommendations",
+		Name:        "ギフト商品推薦",
+		Category:    "gift",
+		Version:     "1.0",
+		Description: "贈る相手と贈答シーンに合わせたギフト推薦",
+		BasePrompt: `あなたは日本の贈答文化に詳しいギフトコンシェルジュです。
+贈る相手の情報と贈答シーンを踏まえ、各商品がギフトとしてふさわしい理由を説明してください。
+
+{{.ContextInfo}}
+
+{{.CustomerProfile}}
+
+{{.Products}}
+
+## ギフト推薦の重点
+1. **相手への適合**: 年代・性別・関係性・趣味に合っているか
+2. **シーンへの適合**: 誕生日、お中元、お歳暮などの贈答マナーに沿っているか
+3. **予算への配慮**: 予算内で見栄えと満足感があるか
+4. **贈る側の気持ち**: 受け取った相手が喜ぶ具体的な場面
+
+推薦理由は購入者本人ではなく、贈る相手の視点で書いてください。
+
+{{.Examples}}
+
+{{.OutputSchema}}`,
+		Examples: []ExampleCase{
+			{
+				Input: `贈る相手：60代の女性、母親
+シーン：母の日、予算5,000円
+商品：オーガニックハーブティーセット`,
+				ExpectedOutput: `[
+  {
+    "product_id": "abc1234-e89b-12d3-a456-426614174004",
+    "recommendation_reason": "体にやさしいオーガニックのハーブティーは、健康を気遣うお母様へのいたわりが伝わります。",
+    "gift_appeal": "上品な箱入りで母の日の感謝の気持ちを添えやすい",
+    "confidence_score": 0.88
+  }
+]`,
+				Explanation: "相手の年代と健康志向、母の日の感謝というシーンを結びつけた理由",
+				Context:     "gift",
+				Priority:    1,
+			},
+		},
+	}
 }
@@ -0,0 +1,471 @@
+package service
+
+import (
+	"context"
+	"ec-recommend/internal/dto"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// QueryNormalizationTuning configures the search query normalization pipeline
+type QueryNormalizationTuning struct {
+	RefreshMinutes      int                 `json:"refresh_minutes"`       // Dictionary and vocabulary cache lifetime; admin changes apply immediately
+	SpellingCorrection  bool                `json:"spelling_correction"`   // Correct words close to a catalog word by edit distance
+	MinCorrectionLength int                 `json:"min_correction_length"` // Shorter words are never corrected
+	MaxEditDistance     int                 `json:"max_edit_distance"`     // Allowed edits for words of 8 or more characters; shorter words allow 1
+	Synonyms            []dto.SearchSynonym `json:"synonyms,omitempty"`    // Dictionary entries in addition to the database; the database wins on the same term
+}
+
+// DefaultQueryNormalizationTuning returns the query normalization tuning used when none is configured
+func DefaultQueryNormalizationTuning() QueryNormalizationTuning {
+	synonym := func(term, replacement, synonymType string) dto.SearchSynonym {
+		return dto.SearchSynonym{Term: term, Replacement: replacement, Type: synonymType, IsActive: true}
+	}
+
+	return QueryNormalizationTuning{
+		RefreshMinutes:      10,
+		SpellingCorrection:  true,
+		MinCorrectionLength: 4,
+		MaxEditDistance:     2,
+		Synonyms: []dto.SearchSynonym{
+			synonym("スマホ", "スマートフォン", dto.SearchSynonymTypeAbbreviation),
+			synonym("ノーパソ", "ノートパソコン", dto.SearchSynonymTypeAbbreviation),
+			synonym("ノートPC", "ノートパソコン", dto.SearchSynonymTypeSynonym),
+			synonym("ヘッドホン", "ヘッドフォン", dto.SearchSynonymTypeSynonym),
+			synonym("イヤフォン", "イヤホン", dto.SearchSynonymTypeSynonym),
+		},
+	}
+}
+
+// SynonymDictionary rewrites dictionary terms found in folded queries
+type SynonymDictionary struct {
+	entries map[string]dto.SearchSynonym // By normalized term
+	keys    [][]rune                     // Normalized terms, longest first
+}
+
+// NewSynonymDictionary indexes the active entries by normalized term. Later entries replace earlier
+// ones with the same term, and an inactive entry removes them.
+func NewSynonymDictionary(synonyms []dto.SearchSynonym) *SynonymDictionary {
+	dictionary := &SynonymDictionary{entries: make(map[string]dto.SearchSynonym)}
+	for _, synonym := range synonyms {
+		key := NormalizeSearchText(synonym.Term)
+		if key == "" {
+			continue
+		}
+		if !synonym.IsActive || FoldSearchText(synonym.Replacement) == "" {
+			delete(dictionary.entries, key)
+			continue
+		}
+		dictionary.entries[key] = synonym
+	}
+
+	for key := range dictionary.entries {
+		dictionary.keys = append(dictionary.keys, []rune(key))
+	}
+	sort.Slice(dictionary.keys, func(i, j int) bool {
+		if len(dictionary.keys[i]) != len(dictionary.keys[j]) {
+			return len(dictionary.keys[i]) > len(dictionary.keys[j])
+		}
+		return string(dictionary.keys[i]) < string(dictionary.keys[j])
+	})
+
+	return dictionary
+}
+
+// Contains reports whether a normalized word is a dictionary term
+func (d *SynonymDictionary) Contains(normalized string) bool {
+	_, ok := d.entries[normalized]
+	return ok
+}
+
+// Rewrite replaces dictionary terms in folded text left to right, longest first. Terms match in any
+// kana script (スマホ, すまほ), also inside longer words (スマホケース → スマートフォンケース), but
+// terms starting or ending with a Latin letter or digit only match at Latin word boundaries.
+// Replaced text is not rewritten again.
+func (d *SynonymDictionary) Rewrite(folded string) (string, []dto.QueryRewrite) {
+	runes := []rune(folded)
+	keys := []rune(strings.Map(katakanaToHiragana, folded))
+
+	var b strings.Builder
+	var rewrites []dto.QueryRewrite
+	for i := 0; i < len(runes); {
+		var matched []rune
+		for _, key := range d.keys {
+			if hasRunePrefix(keys[i:], key) && atLatinWordBoundary(keys, i, i+len(key)) {
+				matched = key
+				break
+			}
+		}
+		if matched == nil {
+			b.WriteRune(runes[i])
+			i++
+			continue
+		}
+
+		synonym := d.entries[string(matched)]
+		replacement := FoldSearchText(synonym.Replacement)
+		b.WriteString(replacement)
+		rewrites = append(rewrites, dto.QueryRewrite{From: string(runes[i : i+len(matched)]), To: replacement, Type: synonym.Type})
+		i += len(matched)
+	}
+
+	return b.String(), rewrites
+}
+
+// SpellingCorrector corrects words that are not in the catalog vocabulary to the closest word that is
+type SpellingCorrector struct {
+	words map[string]vocabularyWord // By normalized form
+}
+
+// vocabularyWord is a catalog word in its folded display form
+type vocabularyWord struct {
+	folded string
+	runes  []rune // Normalized form
+	weight float64
+}
+
+// NewSpellingCorrector learns the words of the catalog text. Only words written entirely in Latin
+// letters or entirely in kana are learned, since kanji words are not misspelled by typing.
+func NewSpellingCorrector(entries []dto.VocabularyEntry, minLength int) *SpellingCorrector {
+	corrector := &SpellingCorrector{words: make(map[string]vocabularyWord)}
+	for _, entry := range entries {
+		for _, folded := range searchWords(FoldSearchText(entry.Text)) {
+			normalized := strings.Map(katakanaToHiragana, folded)
+			if utf8.RuneCountInString(normalized) < minLength || !isCorrectableWord(normalized) {
+				continue
+			}
+			word := corrector.words[normalized]
+			if word.folded == "" {
+				word.folded, word.runes = folded, []rune(normalized)
+			}
+			word.weight += entry.Weight
+			corrector.words[normalized] = word
+		}
+	}
+	return corrector
+}
+
+// Correct returns the folded catalog word closest to the normalized word, or false when the word is
+// known or nothing is close enough. Candidates must share the first character; ties in distance go
+// to the more popular word.
+func (c *SpellingCorrector) Correct(normalized string, minLength, maxDistance int) (string, bool) {
+	runes := []rune(normalized)
+	if c == nil || len(runes) < minLength || !isCorrectableWord(normalized) {
+		return "", false
+	}
+	if _, ok := c.words[normalized]; ok {
+		return "", false
+	}
+
+	allowed := 1
+	if len(runes) >= 8 {
+		allowed = maxDistance
+	}
+
+	var best *vocabularyWord
+	bestDistance := allowed + 1
+	for key := range c.words {
+		word := c.words[key]
+		if word.runes[0] != runes[0] || abs(len(word.runes)-len(runes)) > allowed {
+			continue
+		}
+		distance := editDistance(runes, word.runes)
+		if distance > allowed {
+			continue
+		}
+		if distance < bestDistance || (distance == bestDistance && (word.weight > best.weight ||
+			(word.weight == best.weight && word.folded < best.folded))) {
+			best, bestDistance = &word, distance
+		}
+	}
+
+	if best == nil {
+		return "", false
+	}
+	return best.folded, true
+}
+
+// QueryNormalizer normalizes search queries for knowledge base retrieval and lexical search, and
+// manages the search synonym dictionary for the admin API
+type QueryNormalizer struct {
+	repo   SearchSynonymRepositoryInterface
+	tuning QueryNormalizationTuning
+
+	mu         sync.Mutex
+	dictionary *SynonymDictionary
+	corrector  *SpellingCorrector
+	loadedAt   time.Time
+	failedAt   time.Time
+	failure    error
+}
+
+// NewQueryNormalizer creates a new query normalizer instance
+func NewQueryNormalizer(repo SearchSynonymRepositoryInterface, tuning QueryNormalizationTuning) *QueryNormalizer {
+	defaults := DefaultQueryNormalizationTuning()
+	if tuning.RefreshMinutes <= 0 {
+		tuning.RefreshMinutes = defaults.RefreshMinutes
+	}
+	if tuning.MinCorrectionLength <= 0 {
+		tuning.MinCorrectionLength = defaults.MinCorrectionLength
+	}
+	if tuning.MaxEditDistance <= 0 {
+		tuning.MaxEditDistance = defaults.MaxEditDistance
+	}
+
+	// Entries in the tuning file are active; remove them to disable them
+	synonyms := make([]dto.SearchSynonym, len(tuning.Synonyms))
+	for i, synonym := range tuning.Synonyms {
+		synonym.IsActive = true
+		synonyms[i] = synonym
+	}
+	tuning.Synonyms = synonyms
+
+	return &QueryNormalizer{
+		repo:   repo,
+		tuning: tuning,
+	}
+}
+
+// Normalize folds full-width and half-width forms and letter case, corrects misspelled words and
+// rewrites dictionary terms. Without a normalizer, or when the dictionary cannot be loaded, the query
+// is only folded.
+func (n *QueryNormalizer) Normalize(ctx context.Context, query string) *dto.NormalizedQuery {
+	folded := FoldSearchText(query)
+	result := &dto.NormalizedQuery{Original: query, Normalized: folded}
+	if n == nil {
+		return result
+	}
+
+	dictionary, corrector, err := n.current(ctx)
+	if err != nil {
+		log.Printf("Warning: failed to load search dictionary: %v", err)
+		return result
+	}
+
+	// Correct whole words first so dictionary terms inside them can still be rewritten
+	var rewrites []dto.QueryRewrite
+	if n.tuning.SpellingCorrection {
+		words := strings.Split(folded, " ")
+		for i, word := range words {
+			normalized := strings.Map(katakanaToHiragana, word)
+			if dictionary.Contains(normalized) {
+				continue
+			}
+			if corrected, ok := corrector.Correct(normalized, n.tuning.MinCorrectionLength, n.tuning.MaxEditDistance); ok {
+				rewrites = append(rewrites, dto.QueryRewrite{From: word, To: corrected, Type: dto.QueryRewriteSpellingCorrection})
+				words[i] = corrected
+			}
+		}
+		folded = strings.Join(words, " ")
+	}
+
+	rewritten, dictionaryRewrites := dictionary.Rewrite(folded)
+	result.Normalized = rewritten
+	result.Rewrites = append(rewrites, dictionaryRewrites...)
+
+	return result
+}
+
+// ListSynonyms returns the dictionary entries stored in the database
+func (n *QueryNormalizer) ListSynonyms(ctx context.Context) (*dto.SearchSynonymListResponse, error) {
+	synonyms, err := n.repo.ListSearchSynonyms(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list search synonyms: %w", err)
+	}
+
+	return &dto.SearchSynonymListResponse{
+		Synonyms: synonyms,
+		Total:    len(synonyms),
+	}, nil
+}
+
+// GetSynonym returns a dictionary entry by ID, or nil if it does not exist
+func (n *QueryNormalizer) GetSynonym(ctx context.Context, synonymID uuid.UUID) (*dto.SearchSynonym, error) {
+	return n.repo.GetSearchSynonym(ctx, synonymID)
+}
+
+// CreateSynonym adds a dictionary entry. It returns dto.ErrInvalidSearchSynonym for an unusable entry
+// and dto.ErrSearchSynonymExists if the term is already defined.
+func (n *QueryNormalizer) CreateSynonym(ctx context.Context, req *dto.SearchSynonymRequest) (*dto.SearchSynonym, error) {
+	if err := ValidateSearchSynonym(req.Term, req.Replacement, req.Type); err != nil {
+		return nil, fmt.Errorf("%w: %v", dto.ErrInvalidSearchSynonym, err)
+	}
+
+	synonym, err := n.repo.CreateSearchSynonym(ctx, synonymFromRequest(uuid.Nil, req))
+	if err != nil {
+		return nil, err
+	}
+
+	n.invalidate()
+	return synonym, nil
+}
+
+// UpdateSynonym replaces a dictionary entry, returning nil if it does not exist
+func (n *QueryNormalizer) UpdateSynonym(ctx context.Context, synonymID uuid.UUID, req *dto.SearchSynonymRequest) (*dto.SearchSynonym, error) {
+	if err := ValidateSearchSynonym(req.Term, req.Replacement, req.Type); err != nil {
+		return nil, fmt.Errorf("%w: %v", dto.ErrInvalidSearchSynonym, err)
+	}
+
+	synonym, err := n.repo.UpdateSearchSynonym(ctx, synonymFromRequest(synonymID, req))
+	if err != nil {
+		return nil, err
+	}
+
+	n.invalidate()
+	return synonym, nil
+}
+
+// DeleteSynonym deletes a dictionary entry, reporting whether it existed
+func (n *QueryNormalizer) DeleteSynonym(ctx context.Context, synonymID uuid.UUID) (bool, error) {
+	deleted, err := n.repo.DeleteSearchSynonym(ctx, synonymID)
+	if err != nil {
+		return false, err
+	}
+
+	n.invalidate()
+	return deleted, nil
+}
+
+// current returns the dictionary and spelling corrector, reloading them when older than the refresh interval.
+// After a failed load the database is not queried again until the retry backoff has passed.
+func (n *QueryNormalizer) current(ctx context.Context) (*SynonymDictionary, *SpellingCorrector, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.dictionary != nil && time.Since(n.loadedAt) < time.Duration(n.tuning.RefreshMinutes)*time.Minute {
+		return n.dictionary, n.corrector, nil
+	}
+	if n.failure != nil && time.Since(n.failedAt) < modelRetryBackoff {
+		if n.dictionary != nil {
+			return n.dictionary, n.corrector, nil
+		}
+		return nil, nil, n.failure
+	}
+
+	synonyms, err := n.repo.ListSearchSynonyms(ctx)
+	if err == nil && n.tuning.SpellingCorrection {
+		var vocabulary []dto.VocabularyEntry
+		vocabulary, err = n.repo.GetSearchVocabulary(ctx)
+		if err == nil {
+			n.corrector = NewSpellingCorrector(vocabulary, n.tuning.MinCorrectionLength)
+		}
+	}
+	if err != nil {
+		n.failedAt = time.Now()
+		n.failure = err
+		// Keep serving the stale dictionary rather than failing requests
+		if n.dictionary != nil {
+			return n.dictionary, n.corrector, nil
+		}
+		return nil, nil, err
+	}
+	n.failure = nil
+
+	n.dictionary = NewSynonymDictionary(append(append([]dto.SearchSynonym{}, n.tuning.Synonyms...), synonyms...))
+	n.loadedAt = time.Now()
+
+	return n.dictionary, n.corrector, nil
+}
+
+// invalidate makes the next normalization reload the dictionary, even within the retry backoff
+func (n *QueryNormalizer) invalidate() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.loadedAt = time.Time{}
+	n.failure = nil
+}
+
+// ValidateSearchSynonym checks a dictionary entry's term, replacement and type
+func ValidateSearchSynonym(term, replacement, synonymType string) error {
+	normalizedTerm := NormalizeSearchText(term)
+	if normalizedTerm == "" || utf8.RuneCountInString(normalizedTerm) > 100 {
+		return fmt.Errorf("search synonym term must be 1 to 100 characters: %q", term)
+	}
+	normalizedReplacement := NormalizeSearchText(replacement)
+	if normalizedReplacement == "" || utf8.RuneCountInString(normalizedReplacement) > 200 {
+		return fmt.Errorf("search synonym replacement must be 1 to 200 characters: %q", replacement)
+	}
+	if normalizedReplacement == normalizedTerm {
+		return fmt.Errorf("search synonym replacement must differ from the term: %q", term)
+	}
+
+	switch synonymType {
+	case "", dto.SearchSynonymTypeSynonym, dto.SearchSynonymTypeAbbreviation, dto.SearchSynonymTypeMisspelling:
+		return nil
+	default:
+		return fmt.Errorf("search synonym type must be synonym, abbreviation or misspelling: %s", synonymType)
+	}
+}
+
+func synonymFromRequest(synonymID uuid.UUID, req *dto.SearchSynonymRequest) *dto.SearchSynonym {
+	isActive := true
+	if req.IsActive != nil {
+		isActive = *req.IsActive
+	}
+	synonymType := req.Type
+	if synonymType == "" {
+		synonymType = dto.SearchSynonymTypeSynonym
+	}
+
+	return &dto.SearchSynonym{
+		ID:             synonymID,
+		Term:           strings.TrimSpace(req.Term),
+		NormalizedTerm: NormalizeSearchText(req.Term),
+		Replacement:    strings.TrimSpace(req.Replacement),
+		Type:           synonymType,
+		IsActive:       isActive,
+	}
+}
+
+// isCorrectableWord reports whether a normalized word is written entirely in Latin letters or entirely in kana
+func isCorrectableWord(normalized string) bool {
+	latin, kana := true, true
+	for _, r := range normalized {
+		latin = latin && r < utf8.RuneSelf && unicode.IsLetter(r)
+		kana = kana && (unicode.Is(unicode.Hiragana, r) || r == 'ー')
+	}
+	return latin || kana
+}
+
+// editDistance returns the Damerau-Levenshtein distance (with adjacent transpositions) between two words
+func editDistance(a, b []rune) int {
+	previous2 := make([]int, len(b)+1)
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(min(previous[j]+1, current[j-1]+1), previous[j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				current[j] = min(current[j], previous2[j-2]+1)
+			}
+		}
+		previous2, previous, current = previous, current, previous2
+	}
+
+	return previous[len(b)]
+}
+
+// abs returns the absolute value of an integer
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -0,0 +1,201 @@
+package service
+
+import (
+	"context"
+	"ec-recommend/internal/dto"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestSynonymDictionary(t *testing.T) {
+	dictionary := NewSynonymDictionary(DefaultQueryNormalizationTuning().Synonyms)
+
+	t.Run("abbreviations are expanded in any kana script and width", func(t *testing.T) {
+		for _, query := range []string{"スマホ", "すまほ", "ｽﾏﾎ"} {
+			rewritten, rewrites := dictionary.Rewrite(FoldSearchText(query))
+			if rewritten != "スマートフォン" {
+				t.Errorf("Expected スマートフォン for %s, got %s", query, rewritten)
+			}
+			if len(rewrites) != 1 || rewrites[0].Type != dto.SearchSynonymTypeAbbreviation {
+				t.Errorf("Expected one abbreviation rewrite for %s, got %v", query, rewrites)
+			}
+		}
+	})
+
+	t.Run("terms inside longer words are rewritten", func(t *testing.T) {
+		if rewritten, _ := dictionary.Rewrite("スマホケース 黒"); rewritten != "スマートフォンケース 黒" {
+			t.Errorf("Expected スマートフォンケース 黒, got %s", rewritten)
+		}
+	})
+
+	t.Run("latin terms only match whole words", func(t *testing.T) {
+		if rewritten, _ := dictionary.Rewrite("ノートpc 15インチ"); rewritten != "ノートパソコン 15インチ" {
+			t.Errorf("Expected ノートパソコン 15インチ, got %s", rewritten)
+		}
+		if rewritten, rewrites := dictionary.Rewrite("ノートpcb"); rewritten != "ノートpcb" || rewrites != nil {
+			t.Errorf("Expected ノートpcb unchanged, got %s %v", rewritten, rewrites)
+		}
+	})
+
+	t.Run("later entries override and inactive entries remove earlier ones", func(t *testing.T) {
+		dictionary := NewSynonymDictionary([]dto.SearchSynonym{
+			{Term: "スマホ", Replacement: "スマートフォン", IsActive: true},
+			{Term: "すまほ", Replacement: "携帯電話", IsActive: true},
+			{Term: "ヘッドホン", Replacement: "ヘッドフォン", IsActive: true},
+			{Term: "ヘッドホン", Replacement: "ヘッドフォン", IsActive: false},
+		})
+		if rewritten, _ := dictionary.Rewrite("スマホ ヘッドホン"); rewritten != "携帯電話 ヘッドホン" {
+			t.Errorf("Expected 携帯電話 ヘッドホン, got %s", rewritten)
+		}
+	})
+}
+
+func TestSpellingCorrector(t *testing.T) {
+	corrector := NewSpellingCorrector([]dto.VocabularyEntry{
+		{Text: "Bluetooth ワイヤレスイヤホン", Weight: 50},
+		{Text: "スマートフォン 充電器", Weight: 80},
+		{Text: "Headphones", Weight: 10},
+		{Text: "Headphone", Weight: 5},
+	}, 4)
+
+	cases := map[string]string{
+		"bluetoth":   "bluetooth",
+		"blutooth":   "bluetooth",
+		"すまーとふぉn":    "",
+		"すまーとふおん":    "スマートフォン",
+		"headphonez": "headphones",
+		"わいやれすいやほ":   "ワイヤレスイヤホン",
+	}
+	for input, expected := range cases {
+		corrected, ok := corrector.Correct(input, 4, 2)
+		if expected == "" {
+			if ok {
+				t.Errorf("Expected no correction for %s, got %s", input, corrected)
+			}
+			continue
+		}
+		if !ok || corrected != expected {
+			t.Errorf("Expected %s for %s, got %q", expected, input, corrected)
+		}
+	}
+
+	t.Run("known, short, kanji and distant words are left alone", func(t *testing.T) {
+		for _, input := range []string{"bluetooth", "blu", "充電機", "headset"} {
+			if corrected, ok := corrector.Correct(input, 4, 2); ok {
+				t.Errorf("Expected no correction for %s, got %s", input, corrected)
+			}
+		}
+	})
+
+	t.Run("short words allow a single edit", func(t *testing.T) {
+		if corrected, ok := corrector.Correct("すまあとふおん", 4, 2); ok {
+			t.Errorf("Expected no correction for two edits of a short word, got %s", corrected)
+		}
+	})
+}
+
+// fakeSearchSynonymRepository serves fixed synonyms and vocabulary
+type fakeSearchSynonymRepository struct {
+	synonyms   []dto.SearchSynonym
+	vocabulary []dto.VocabularyEntry
+	listErr    error
+	listCalls  int
+	created    *dto.SearchSynonym
+}
+
+func (r *fakeSearchSynonymRepository) ListSearchSynonyms(ctx context.Context) ([]dto.SearchSynonym, error) {
+	r.listCalls++
+	return r.synonyms, r.listErr
+}
+
+func (r *fakeSearchSynonymRepository) GetSearchSynonym(ctx context.Context, synonymID uuid.UUID) (*dto.SearchSynonym, error) {
+	return nil, nil
+}
+
+func (r *fakeSearchSynonymRepository) CreateSearchSynonym(ctx context.Context, synonym *dto.SearchSynonym) (*dto.SearchSynonym, error) {
+	r.created = synonym
+	r.synonyms = append(r.synonyms, *synonym)
+	return synonym, nil
+}
+
+func (r *fakeSearchSynonymRepository) UpdateSearchSynonym(ctx context.Context, synonym *dto.SearchSynonym) (*dto.SearchSynonym, error) {
+	return nil, nil
+}
+
+func (r *fakeSearchSynonymRepository) DeleteSearchSynonym(ctx context.Context, synonymID uuid.UUID) (bool, error) {
+	return false, nil
+}
+
+func (r *fakeSearchSynonymRepository) GetSearchVocabulary(ctx context.Context) ([]dto.VocabularyEntry, error) {
+	return r.vocabulary, nil
+}
+
+func TestQueryNormalizer(t *testing.T) {
+	ctx := context.Background()
+	vocabulary := []dto.VocabularyEntry{{Text: "Bluetooth スマートフォン ケース", Weight: 10}}
+
+	t.Run("folds, corrects and rewrites the query", func(t *testing.T) {
+		repo := &fakeSearchSynonymRepository{vocabulary: vocabulary}
+		normalizer := NewQueryNormalizer(repo, DefaultQueryNormalizationTuning())
+
+		result := normalizer.Normalize(ctx, "ＢＬＵＥＴＯＴＨ　ｽﾏﾎ")
+		if result.Normalized != "bluetooth スマートフォン" {
+			t.Errorf("Expected bluetooth スマートフォン, got %s", result.Normalized)
+		}
+		if len(result.Rewrites) != 2 || result.Rewrites[0].Type != dto.QueryRewriteSpellingCorrection {
+			t.Errorf("Expected a spelling correction and an abbreviation, got %v", result.Rewrites)
+		}
+
+		normalizer.Normalize(ctx, "ケース")
+		if repo.listCalls != 1 {
+			t.Errorf("Expected the dictionary to be cached, got %d loads", repo.listCalls)
+		}
+	})
+
+	t.Run("admin changes apply immediately", func(t *testing.T) {
+		repo := &fakeSearchSynonymRepository{vocabulary: vocabulary}
+		normalizer := NewQueryNormalizer(repo, DefaultQueryNormalizationTuning())
+		normalizer.Normalize(ctx, "ケース")
+
+		_, err := normalizer.CreateSynonym(ctx, &dto.SearchSynonymRequest{Term: "ｹｰｽ", Replacement: "カバー"})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if repo.created.NormalizedTerm != "けーす" || repo.created.Type != dto.SearchSynonymTypeSynonym || !repo.created.IsActive {
+			t.Errorf("Expected an active synonym for けーす, got %+v", repo.created)
+		}
+		if result := normalizer.Normalize(ctx, "ケース"); result.Normalized != "カバー" {
+			t.Errorf("Expected カバー, got %s", result.Normalized)
+		}
+	})
+
+	t.Run("invalid entries are rejected", func(t *testing.T) {
+		normalizer := NewQueryNormalizer(&fakeSearchSynonymRepository{}, DefaultQueryNormalizationTuning())
+		_, err := normalizer.CreateSynonym(ctx, &dto.SearchSynonymRequest{Term: "スマホ", Replacement: "すまほ"})
+		if !errors.Is(err, dto.ErrInvalidSearchSynonym) {
+			t.Errorf("Expected ErrInvalidSearchSynonym, got %v", err)
+		}
+	})
+
+	t.Run("load errors fall back to folding and back off", func(t *testing.T) {
+		repo := &fakeSearchSynonymRepository{listErr: errors.New("db down")}
+		normalizer := NewQueryNormalizer(repo, DefaultQueryNormalizationTuning())
+		for i := 0; i < 3; i++ {
+			if result := normalizer.Normalize(ctx, "ｽﾏﾎ"); result.Normalized != "スマホ" {
+				t.Errorf("Expected スマホ, got %s", result.Normalized)
+			}
+		}
+		if repo.listCalls != 1 {
+			t.Errorf("Expected one load within the retry backoff, got %d", repo.listCalls)
+		}
+	})
+
+	t.Run("a nil normalizer only folds", func(t *testing.T) {
+		var normalizer *QueryNormalizer
+		if result := normalizer.Normalize(ctx, "  ＰＣ  ｹｰｽ "); result.Normalized != "pc ケース" {
+			t.Errorf("Expected pc ケース, got %s", result.Normalized)
+		}
+	})
+}
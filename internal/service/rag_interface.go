@@ -27,6 +27,15 @@ type RAGInterface interface {
 	GetProductsWithSemanticSearch(ctx context.Context, query string, limit int, filters map[string]interface{}) (*RAGSemanticSearchResponse, error)
 }
 
+// RetrievalSearchTypeFilter is the filters key selecting the knowledge base search type: "semantic" for
+// vector search only, or "hybrid" (the default) for the knowledge base's own vector and keyword search.
+// It is a retrieval option rather than a metadata filter.
+const RetrievalSearchTypeFilter = "search_type"
+
+// RetrievalResultCountFilter is the filters key setting how many results the knowledge base retrieves.
+// Like RetrievalSearchTypeFilter it is a retrieval option; GetProductsWithSemanticSearch sets it from its limit.
+const RetrievalResultCountFilter = "number_of_results"
+
 // RAGResponse represents the response from RAG query
 type RAGResponse struct {
 	Results           []KnowledgeBaseResult `json:"results"`
@@ -234,4 +243,5 @@ type RAGSearchMeta struct {
 	RerankerUsed       bool                   `json:"reranker_used"`
 	CacheUsed          bool                   `json:"cache_used"`
 	HybridSearchWeight map[string]float64     `json:"hybrid_search_weight,omitempty"`
+	HybridQueryType    string                 `json:"hybrid_query_type,omitempty"`
 }
@@ -0,0 +1,291 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// Fusion methods supported when merging candidate lists from multiple strategies
+const (
+	FusionReciprocalRank  = "rrf"
+	FusionNormalizedScore = "normalized_score"
+	FusionRoundRobin      = "round_robin"
+)
+
+// defaultFusionMethod is used when neither the blend profile nor the request specifies one
+const defaultFusionMethod = FusionReciprocalRank
+
+// rrfK is the rank offset used by reciprocal rank fusion; 60 is the value from the original RRF paper
+const rrfK = 60.0
+
+// RankedCandidate is a single product produced by a strategy together with its native score
+type RankedCandidate struct {
+	ProductID uuid.UUID
+	Score     float64
+}
+
+// RankedList is the ordered output of one recommendation strategy
+type RankedList struct {
+	Strategy   string
+	Weight     float64
+	Candidates []RankedCandidate
+}
+
+// StrategyContribution records how one strategy contributed to a fused product score
+type StrategyContribution struct {
+	Strategy    string
+	Rank        int     // 1-based rank within the strategy list
+	RawScore    float64 // score reported by the strategy
+	Contributed float64 // share of the fused score attributed to this strategy
+}
+
+// FusedCandidate is a product after rank fusion with its combined score in the [0, 1] range
+type FusedCandidate struct {
+	ProductID     uuid.UUID
+	Score         float64
+	Contributions []StrategyContribution
+}
+
+// IsValidFusionMethod reports whether the given fusion method is supported
+func IsValidFusionMethod(method string) bool {
+	switch method {
+	case FusionReciprocalRank, FusionNormalizedScore, FusionRoundRobin:
+		return true
+	default:
+		return false
+	}
+}
+
+// FuseRankedLists merges candidate lists from multiple strategies into a single ranking.
+// Products returned by several strategies accumulate evidence from each of them,
+// and every contributing strategy is recorded on the fused candidate.
+//
+// Parameters:
+//   - method: fusion method ("rrf", "normalized_score" or "round_robin"); empty uses RRF
+//   - lists: per-strategy ranked lists; lists with a non-positive weight are ignored
+//
+// Returns the fused candidates ordered by descending score, or an error for an unknown method.
+func FuseRankedLists(method string, lists []RankedList) ([]FusedCandidate, error) {
+	if method == "" {
+		method = defaultFusionMethod
+	}
+
+	active := make([]RankedList, 0, len(lists))
+	for _, list := range lists {
+		if list.Weight > 0 && len(list.Candidates) > 0 {
+			active = append(active, list)
+		}
+	}
+
+	var fused []FusedCandidate
+	switch method {
+	case FusionReciprocalRank:
+		fused = fuseReciprocalRank(active)
+	case FusionNormalizedScore:
+		fused = fuseNormalizedScore(active)
+	case FusionRoundRobin:
+		return fuseRoundRobin(active), nil
+	default:
+		return nil, fmt.Errorf("unsupported fusion method: %s", method)
+	}
+
+	sort.SliceStable(fused, func(i, j int) bool {
+		return fused[i].Score > fused[j].Score
+	})
+
+	return fused, nil
+}
+
+// fuseReciprocalRank scores each product by the weighted sum of 1/(k+rank) over the strategies
+// that returned it, normalized by the best achievable score so results stay within [0, 1].
+func fuseReciprocalRank(lists []RankedList) []FusedCandidate {
+	var maxScore float64
+	for _, list := range lists {
+		maxScore += list.Weight / (rrfK + 1)
+	}
+
+	acc := newFusionAccumulator()
+	for _, list := range lists {
+		for i, candidate := range list.Candidates {
+			contribution := list.Weight / (rrfK + float64(i+1)) / maxScore
+			acc.add(candidate, list.Strategy, i+1, contribution)
+		}
+	}
+
+	return acc.results()
+}
+
+// fuseNormalizedScore min-max normalizes scores within each strategy and combines them
+// as a weighted average, so strategies with different score scales are comparable.
+func fuseNormalizedScore(lists []RankedList) []FusedCandidate {
+	var totalWeight float64
+	for _, list := range lists {
+		totalWeight += list.Weight
+	}
+
+	acc := newFusionAccumulator()
+	for _, list := range lists {
+		minScore, maxScore := math.Inf(1), math.Inf(-1)
+		for _, candidate := range list.Candidates {
+			minScore = math.Min(minScore, candidate.Score)
+			maxScore = math.Max(maxScore, candidate.Score)
+		}
+
+		for i, candidate := range list.Candidates {
+			normalized := 1.0
+			if maxScore > minScore {
+				normalized = (candidate.Score - minScore) / (maxScore - minScore)
+			}
+			acc.add(candidate, list.Strategy, i+1, list.Weight*normalized/totalWeight)
+		}
+	}
+
+	return acc.results()
+}
+
+// fuseRoundRobin interleaves the strategy lists, taking one product from each strategy in turn
+// (highest weight first). A product already taken is skipped but still records the contribution.
+// Scores decrease linearly with the interleaved position.
+func fuseRoundRobin(lists []RankedList) []FusedCandidate {
+	ordered := make([]RankedList, len(lists))
+	copy(ordered, lists)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].Weight > ordered[j].Weight
+	})
+
+	acc := newFusionAccumulator()
+	positions := make([]int, len(ordered))
+	for {
+		progressed := false
+		for li, list := range ordered {
+			for positions[li] < len(list.Candidates) {
+				candidate := list.Candidates[positions[li]]
+				positions[li]++
+				progressed = true
+
+				_, seen := acc.index[candidate.ProductID]
+				acc.add(candidate, list.Strategy, positions[li], 0)
+				if !seen {
+					break
+				}
+			}
+		}
+		if !progressed {
+			break
+		}
+	}
+
+	fused := acc.results()
+	for i := range fused {
+		fused[i].Score = 1 - float64(i)/float64(len(fused))
+		for j := range fused[i].Contributions {
+			fused[i].Contributions[j].Contributed = fused[i].Score / float64(len(fused[i].Contributions))
+		}
+	}
+
+	return fused
+}
+
+// candidatePool gathers the recommendations of several strategies for fusion and keeps one product
+// per ID, into which every copy returned by any strategy is merged
+type candidatePool[T any] struct {
+	products map[uuid.UUID]T
+	identify func(T) (uuid.UUID, float64)
+	merge    func(kept, other T) T
+}
+
+// newCandidatePool creates a pool. identify returns a recommendation's product ID and strategy score;
+// merge folds the evidence of another copy of the same product into the kept one.
+func newCandidatePool[T any](identify func(T) (uuid.UUID, float64), merge func(kept, other T) T) *candidatePool[T] {
+	return &candidatePool[T]{
+		products: make(map[uuid.UUID]T),
+		identify: identify,
+		merge:    merge,
+	}
+}
+
+// rankedList orders a strategy's recommendations by score for fusion. A product the strategy returned
+// more than once is ranked once, at its best score, so fusion counts it a single time for the strategy.
+func (p *candidatePool[T]) rankedList(strategy string, weight float64, recs []T) RankedList {
+	sorted := make([]T, len(recs))
+	copy(sorted, recs)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		_, scoreI := p.identify(sorted[i])
+		_, scoreJ := p.identify(sorted[j])
+		return scoreI > scoreJ
+	})
+
+	list := RankedList{
+		Strategy:   strategy,
+		Weight:     weight,
+		Candidates: make([]RankedCandidate, 0, len(sorted)),
+	}
+	listed := make(map[uuid.UUID]bool, len(sorted))
+	for _, rec := range sorted {
+		productID, score := p.identify(rec)
+		if kept, exists := p.products[productID]; exists {
+			p.products[productID] = p.merge(kept, rec)
+		} else {
+			p.products[productID] = rec
+		}
+
+		if !listed[productID] {
+			listed[productID] = true
+			list.Candidates = append(list.Candidates, RankedCandidate{ProductID: productID, Score: score})
+		}
+	}
+
+	return list
+}
+
+// product returns the merged recommendation of a product
+func (p *candidatePool[T]) product(productID uuid.UUID) T {
+	return p.products[productID]
+}
+
+// mergeReasons joins the distinct reasons given for a product by different strategies
+func mergeReasons(kept, other string) string {
+	switch {
+	case other == "" || strings.Contains(kept, other):
+		return kept
+	case kept == "":
+		return other
+	default:
+		return kept + " / " + other
+	}
+}
+
+// fusionAccumulator collects contributions per product while preserving first-seen order
+type fusionAccumulator struct {
+	index map[uuid.UUID]int
+	items []FusedCandidate
+}
+
+func newFusionAccumulator() *fusionAccumulator {
+	return &fusionAccumulator{index: make(map[uuid.UUID]int)}
+}
+
+func (a *fusionAccumulator) add(candidate RankedCandidate, strategy string, rank int, contribution float64) {
+	i, ok := a.index[candidate.ProductID]
+	if !ok {
+		i = len(a.items)
+		a.index[candidate.ProductID] = i
+		a.items = append(a.items, FusedCandidate{ProductID: candidate.ProductID})
+	}
+
+	a.items[i].Score += contribution
+	a.items[i].Contributions = append(a.items[i].Contributions, StrategyContribution{
+		Strategy:    strategy,
+		Rank:        rank,
+		RawScore:    candidate.Score,
+		Contributed: contribution,
+	})
+}
+
+func (a *fusionAccumulator) results() []FusedCandidate {
+	return a.items
+}
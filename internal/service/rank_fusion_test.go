@@ -0,0 +1,163 @@
+package service
+
+import (
+	"ec-recommend/internal/dto"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestFuseRankedLists(t *testing.T) {
+	productA := uuid.New()
+	productB := uuid.New()
+	productC := uuid.New()
+
+	lists := []RankedList{
+		{
+			Strategy: StrategySemantic,
+			Weight:   0.5,
+			Candidates: []RankedCandidate{
+				{ProductID: productA, Score: 0.9},
+				{ProductID: productB, Score: 0.8},
+			},
+		},
+		{
+			Strategy: StrategyCollaborative,
+			Weight:   0.5,
+			Candidates: []RankedCandidate{
+				{ProductID: productC, Score: 40},
+				{ProductID: productB, Score: 35},
+			},
+		},
+	}
+
+	t.Run("reciprocal rank fusion combines evidence", func(t *testing.T) {
+		fused, err := FuseRankedLists(FusionReciprocalRank, lists)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		if len(fused) != 3 {
+			t.Fatalf("Expected 3 fused candidates, got %d", len(fused))
+		}
+
+		if fused[0].ProductID != productB {
+			t.Errorf("Expected product returned by both strategies to rank first, got %s", fused[0].ProductID)
+		}
+
+		if len(fused[0].Contributions) != 2 {
+			t.Errorf("Expected 2 contributions, got %d", len(fused[0].Contributions))
+		}
+
+		for _, candidate := range fused {
+			if candidate.Score <= 0 || candidate.Score > 1 {
+				t.Errorf("Expected score within (0, 1], got %f", candidate.Score)
+			}
+		}
+	})
+
+	t.Run("normalized score fusion is scale independent", func(t *testing.T) {
+		fused, err := FuseRankedLists(FusionNormalizedScore, lists)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		if fused[0].Score != 0.5 {
+			t.Errorf("Expected top score 0.5, got %f", fused[0].Score)
+		}
+
+		for _, candidate := range fused {
+			if candidate.ProductID == productB && candidate.Score != 0 {
+				t.Errorf("Expected last-ranked product in both lists to score 0, got %f", candidate.Score)
+			}
+		}
+	})
+
+	t.Run("round robin interleaves strategies", func(t *testing.T) {
+		fused, err := FuseRankedLists(FusionRoundRobin, lists)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		expected := []uuid.UUID{productA, productC, productB}
+		for i, id := range expected {
+			if fused[i].ProductID != id {
+				t.Errorf("Expected position %d to be %s, got %s", i, id, fused[i].ProductID)
+			}
+		}
+
+		if len(fused[2].Contributions) != 2 {
+			t.Errorf("Expected duplicate product to record both strategies, got %d", len(fused[2].Contributions))
+		}
+	})
+
+	t.Run("zero weight strategies are ignored", func(t *testing.T) {
+		weighted := []RankedList{lists[0], {Strategy: StrategyTrending, Weight: 0, Candidates: lists[1].Candidates}}
+		fused, err := FuseRankedLists(FusionReciprocalRank, weighted)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		if len(fused) != 2 {
+			t.Errorf("Expected 2 fused candidates, got %d", len(fused))
+		}
+	})
+
+	t.Run("unsupported method", func(t *testing.T) {
+		if _, err := FuseRankedLists("borda", lists); err == nil {
+			t.Error("Expected error for unsupported fusion method, got nil")
+		}
+	})
+}
+
+func TestCandidatePool(t *testing.T) {
+	productA := uuid.New()
+	productB := uuid.New()
+
+	pool := newCandidatePool(func(rec dto.ProductRecommendationV2) (uuid.UUID, float64) {
+		return rec.ProductID, rec.ConfidenceScore
+	}, mergeRecommendationEvidenceV2)
+
+	semantic := pool.rankedList(StrategySemantic, 0.5, []dto.ProductRecommendationV2{
+		{ProductID: productB, ConfidenceScore: 0.4, Reason: "Similar to your search"},
+		{ProductID: productA, ConfidenceScore: 0.9, Reason: "Matches your search"},
+		{ProductID: productB, ConfidenceScore: 0.7, Reason: "Similar to your search"},
+	})
+	collaborative := pool.rankedList(StrategyCollaborative, 0.5, []dto.ProductRecommendationV2{
+		{
+			ProductID:        productB,
+			ConfidenceScore:  0.8,
+			Reason:           "Bought by similar customers",
+			RelevanceContext: []dto.RelevanceContext{{ContextType: "similar_customers"}},
+		},
+	})
+
+	t.Run("ranks a product repeated in one list once", func(t *testing.T) {
+		if len(semantic.Candidates) != 2 {
+			t.Fatalf("Expected 2 semantic candidates, got %d", len(semantic.Candidates))
+		}
+		if semantic.Candidates[1].ProductID != productB || semantic.Candidates[1].Score != 0.7 {
+			t.Errorf("Expected product B at its best score 0.7, got %+v", semantic.Candidates[1])
+		}
+
+		fused, err := FuseRankedLists(FusionReciprocalRank, []RankedList{semantic, collaborative})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		for _, candidate := range fused {
+			if candidate.ProductID == productB && len(candidate.Contributions) != 2 {
+				t.Errorf("Expected 2 contributions for product B, got %d", len(candidate.Contributions))
+			}
+		}
+	})
+
+	t.Run("merges evidence from every strategy", func(t *testing.T) {
+		product := pool.product(productB)
+		if product.Reason != "Similar to your search / Bought by similar customers" {
+			t.Errorf("Expected both reasons, got %q", product.Reason)
+		}
+		if len(product.RelevanceContext) != 1 || product.RelevanceContext[0].ContextType != "similar_customers" {
+			t.Errorf("Expected the collaborative relevance context, got %+v", product.RelevanceContext)
+		}
+	})
+}
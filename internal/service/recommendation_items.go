@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"ec-recommend/internal/dto"
+	"log"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// retrievalScore is a product's score and rank before post-ranking stages changed them
+type retrievalScore struct {
+	score float64
+	rank  int
+}
+
+// snapshotRetrievalScores records scores and ranks before post-ranking stages run
+func snapshotRetrievalScores(recommendations []dto.ProductRecommendationV2) map[uuid.UUID]retrievalScore {
+	snapshot := make(map[uuid.UUID]retrievalScore, len(recommendations))
+	for i, rec := range recommendations {
+		snapshot[rec.ProductID] = retrievalScore{score: rec.ConfidenceScore, rank: i + 1}
+	}
+	return snapshot
+}
+
+// snapshotRankedScores records the scores post-ranking settled on, before AI explanations
+// replace them with the language model's own confidence
+func snapshotRankedScores(recommendations []dto.ProductRecommendationV2) map[uuid.UUID]float64 {
+	snapshot := make(map[uuid.UUID]float64, len(recommendations))
+	for _, rec := range recommendations {
+		snapshot[rec.ProductID] = rec.ConfidenceScore
+	}
+	return snapshot
+}
+
+// recommendationItemContext describes the response recommendation items are served in
+type recommendationItemContext struct {
+	RecommendationLogID uuid.UUID
+	CustomerID          uuid.UUID
+	RecommendationType  string
+	ContextType         string
+	AlgorithmVersion    string
+	Strategies          []string // Used for products that carry no per-strategy contributions
+	Filters             dto.RecommendationFilters
+	Retrieval           map[uuid.UUID]retrievalScore
+	Ranked              map[uuid.UUID]float64 // Final scores, when explanations may have replaced ConfidenceScore
+	Features            map[uuid.UUID]map[string]float64
+}
+
+// buildRecommendationItems assigns each served recommendation a stable ID and records how it was produced
+func buildRecommendationItems(ic recommendationItemContext, recommendations []dto.ProductRecommendationV2) []dto.RecommendationItem {
+	items := make([]dto.RecommendationItem, 0, len(recommendations))
+	for i := range recommendations {
+		itemID := uuid.New()
+		recommendations[i].RecommendationItemID = &itemID
+		rec := recommendations[i]
+
+		strategies, contributions := strategyContributions(rec)
+		if len(strategies) == 0 {
+			strategies = ic.Strategies
+		}
+
+		finalScore := rec.ConfidenceScore
+		if ranked, ok := ic.Ranked[rec.ProductID]; ok {
+			finalScore = ranked
+		}
+
+		breakdown := dto.RecommendationScoreBreakdown{
+			FinalScore:            finalScore,
+			RetrievalScore:        finalScore,
+			SimilarityScore:       rec.SimilarityScore,
+			StrategyContributions: contributions,
+			Features:              ic.Features[rec.ProductID],
+		}
+		if retrieval, ok := ic.Retrieval[rec.ProductID]; ok {
+			breakdown.RetrievalScore = retrieval.score
+			breakdown.RetrievalRank = retrieval.rank
+		}
+
+		items = append(items, dto.RecommendationItem{
+			ID:                  itemID,
+			RecommendationLogID: ic.RecommendationLogID,
+			CustomerID:          ic.CustomerID,
+			ProductID:           rec.ProductID,
+			Position:            i + 1,
+			RecommendationType:  ic.RecommendationType,
+			ContextType:         ic.ContextType,
+			AlgorithmVersion:    ic.AlgorithmVersion,
+			Strategies:          strategies,
+			ScoreBreakdown:      breakdown,
+			Filters:             ic.Filters,
+			Product:             rec,
+		})
+	}
+
+	return items
+}
+
+// strategyContributions returns the strategies recorded on a fused recommendation and their share of its score
+func strategyContributions(rec dto.ProductRecommendationV2) ([]string, map[string]float64) {
+	var strategies []string
+	var contributions map[string]float64
+	for _, rc := range rec.RelevanceContext {
+		strategy, ok := strings.CutPrefix(rc.ContextType, "strategy_")
+		if !ok {
+			continue
+		}
+		if contributions == nil {
+			contributions = make(map[string]float64)
+		}
+		strategies = append(strategies, strategy)
+		contributions[strategy] = rc.Confidence
+	}
+	return strategies, contributions
+}
+
+// saveRecommendationItems persists served recommendations; failures are logged and do not fail the request
+func (rs *RecommendationServiceV2) saveRecommendationItems(ctx context.Context, ic recommendationItemContext, recommendations []dto.ProductRecommendationV2) {
+	items := buildRecommendationItems(ic, recommendations)
+	if err := rs.repo.SaveRecommendationItems(ctx, items); err != nil {
+		log.Printf("Warning: failed to save recommendation items: %v", err)
+	}
+}
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"ec-recommend/internal/dto"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestBuildRecommendationItems(t *testing.T) {
+	fused := uuid.New()
+	pinned := uuid.New()
+	logID := uuid.New()
+
+	retrieved := []dto.ProductRecommendationV2{
+		{
+			ProductID:       fused,
+			ConfidenceScore: 0.8,
+			RelevanceContext: []dto.RelevanceContext{
+				{ContextType: "strategy_semantic", Explanation: "Ranked #1 by semantic strategy", Confidence: 0.5},
+				{ContextType: "strategy_collaborative", Explanation: "Ranked #3 by collaborative strategy", Confidence: 0.3},
+				{ContextType: "seasonal", Explanation: "In season", Confidence: 0.4},
+			},
+		},
+	}
+	retrieval := snapshotRetrievalScores(retrieved)
+
+	// Post-ranking demoted the fused product and a merchandising rule pinned another above it
+	served := []dto.ProductRecommendationV2{
+		{ProductID: pinned, ConfidenceScore: 1},
+		retrieved[0],
+	}
+	served[1].ConfidenceScore = 0.6
+
+	items := buildRecommendationItems(recommendationItemContext{
+		RecommendationLogID: logID,
+		RecommendationType:  "hybrid",
+		Strategies:          []string{"hybrid_rag"},
+		Retrieval:           retrieval,
+		Ranked:              snapshotRankedScores(served),
+	}, served)
+
+	if len(items) != 2 {
+		t.Fatalf("Expected 2 items, got %d", len(items))
+	}
+	for i, item := range items {
+		if served[i].RecommendationItemID == nil || *served[i].RecommendationItemID != item.ID {
+			t.Errorf("Expected served recommendation %d to carry item ID %s, got %v", i, item.ID, served[i].RecommendationItemID)
+		}
+		if item.RecommendationLogID != logID || item.Position != i+1 {
+			t.Errorf("Expected item %d in log %s at position %d, got %+v", i, logID, i+1, item)
+		}
+	}
+
+	t.Run("records strategy contributions and the pre-ranking score", func(t *testing.T) {
+		item := items[1]
+		if len(item.Strategies) != 2 || item.Strategies[0] != "semantic" {
+			t.Errorf("Expected the fused strategies, got %v", item.Strategies)
+		}
+		if item.ScoreBreakdown.RetrievalScore != 0.8 || item.ScoreBreakdown.RetrievalRank != 1 || item.ScoreBreakdown.FinalScore != 0.6 {
+			t.Errorf("Expected retrieval 0.8 at rank 1 and final 0.6, got %+v", item.ScoreBreakdown)
+		}
+		if item.ScoreBreakdown.StrategyContributions["collaborative"] != 0.3 {
+			t.Errorf("Expected the collaborative contribution of 0.3, got %v", item.ScoreBreakdown.StrategyContributions)
+		}
+	})
+
+	t.Run("keeps the ranked score when explanations replaced the confidence", func(t *testing.T) {
+		explained := []dto.ProductRecommendationV2{{ProductID: pinned, ConfidenceScore: 0.95}}
+		items := buildRecommendationItems(recommendationItemContext{
+			Ranked: map[uuid.UUID]float64{pinned: 0.4},
+		}, explained)
+		if items[0].ScoreBreakdown.FinalScore != 0.4 {
+			t.Errorf("Expected the ranked final score 0.4, got %v", items[0].ScoreBreakdown.FinalScore)
+		}
+	})
+
+	t.Run("falls back to request strategies for products added after retrieval", func(t *testing.T) {
+		item := items[0]
+		if len(item.Strategies) != 1 || item.Strategies[0] != "hybrid_rag" {
+			t.Errorf("Expected the request strategies, got %v", item.Strategies)
+		}
+		if item.ScoreBreakdown.RetrievalRank != 0 {
+			t.Errorf("Expected no retrieval rank for a pinned product, got %d", item.ScoreBreakdown.RetrievalRank)
+		}
+	})
+}
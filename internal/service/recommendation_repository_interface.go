@@ -23,7 +23,7 @@ type RecommendationRepositoryInterface interface {
 	GetProductsInPriceRange(ctx context.Context, minPrice, maxPrice float64, limit int) ([]dto.ProductRecommendation, error)
 
 	// Analytics methods
-	LogRecommendation(ctx context.Context, customerID uuid.UUID, recommendationType, contextType string, productIDs []uuid.UUID, sessionID uuid.UUID) error
+	LogRecommendation(ctx context.Context, customerID uuid.UUID, recommendationType, contextType string, productIDs []uuid.UUID, sessionID uuid.UUID, algorithmVersion string) error
 	LogRecommendationInteraction(ctx context.Context, analytics *dto.RecommendationAnalytics) error
 
 	// Collaborative filtering methods
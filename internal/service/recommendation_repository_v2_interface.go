@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"ec-recommend/internal/dto"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -13,6 +14,7 @@ type RecommendationRepositoryV2Interface interface {
 	// Enhanced customer and product methods
 	GetCustomerByID(ctx context.Context, customerID uuid.UUID) (*dto.CustomerProfile, error)
 	GetCustomerPurchaseHistory(ctx context.Context, customerID uuid.UUID, limit int) ([]dto.PurchaseItem, error)
+	GetGiftOnlyProductIDs(ctx context.Context, customerID uuid.UUID) ([]uuid.UUID, error)
 	GetCustomerActivities(ctx context.Context, customerID uuid.UUID, limit int) ([]dto.ActivityItem, error)
 	GetProductsByIDs(ctx context.Context, productIDs []uuid.UUID) ([]dto.ProductRecommendationV2, error)
 	GetProductsByCategory(ctx context.Context, categoryID int, limit int) ([]dto.ProductRecommendationV2, error)
@@ -23,10 +25,19 @@ type RecommendationRepositoryV2Interface interface {
 	GetMarketAnalysis(ctx context.Context, categoryID *int, timeRange string) (*dto.MarketAnalysis, error)
 
 	// Logging and analytics
-	LogRecommendation(ctx context.Context, customerID uuid.UUID, recommendationType, contextType string, productIDs []uuid.UUID, sessionID uuid.UUID) error
+	LogRecommendation(ctx context.Context, customerID uuid.UUID, recommendationType, contextType string, productIDs []uuid.UUID, sessionID uuid.UUID, algorithmVersion string) error
 	LogRecommendationInteraction(ctx context.Context, analytics *dto.RecommendationAnalytics) error
 	LogSemanticSearch(ctx context.Context, customerID *uuid.UUID, query string, results []uuid.UUID, processingTimeMs int64) error
 
+	// Learning-to-rank feature logging and training data
+	LogCandidateFeatures(ctx context.Context, recommendationID, customerID uuid.UUID, contextType string, candidates []dto.CandidateFeatures) error
+	GetRankingTrainingExamples(ctx context.Context, since time.Time, limit int) ([]dto.RankingTrainingExample, error)
+
+	// Served recommendation items for explanations
+	SaveRecommendationItems(ctx context.Context, items []dto.RecommendationItem) error
+	GetRecommendationItem(ctx context.Context, itemID, customerID uuid.UUID) (*dto.RecommendationItem, error)
+	GetRecommendationItemsByLog(ctx context.Context, recommendationLogID uuid.UUID) ([]dto.RecommendationItem, error)
+
 	// Cache management
 	GetCachedRecommendations(ctx context.Context, key string) ([]dto.ProductRecommendationV2, error)
 	SetCachedRecommendations(ctx context.Context, key string, recommendations []dto.ProductRecommendationV2, ttl int64) error
@@ -5,6 +5,7 @@ import (
 	"ec-recommend/internal/dto"
 	"encoding/json"
 	"fmt"
+	"log"
 	"sort"
 	"strings"
 	"time"
@@ -17,17 +18,56 @@ type RecommendationService struct {
 	repo        RecommendationRepositoryInterface
 	chatService ChatServiceInterface
 	modelID     string
+	blender     *StrategyBlender
+	postRanking *PostRankingPipeline
+	bandit      *StrategyBandit
+	experiments *ExperimentService
+	feedback    *FeedbackService
+	impressions *ImpressionService
 }
 
-// NewRecommendationService creates a new recommendation service instance
-func NewRecommendationService(repo RecommendationRepositoryInterface, chatService ChatServiceInterface, modelID string) *RecommendationService {
+// NewRecommendationService creates a new recommendation service instance.
+// A nil tuning falls back to the default blend weights.
+func NewRecommendationService(repo RecommendationRepositoryInterface, chatService ChatServiceInterface, modelID string, tuning *RecommendationTuning) *RecommendationService {
+	if tuning == nil {
+		tuning = DefaultRecommendationTuning()
+	}
+
 	return &RecommendationService{
 		repo:        repo,
 		chatService: chatService,
 		modelID:     modelID,
+		blender:     NewStrategyBlender(BlendServiceV1, defaultV1BlendWeights, tuning.BlendProfiles),
+		postRanking: NewPostRankingPipeline(),
 	}
 }
 
+// AddPostRankingStage registers a stage that runs after candidate generation and ranking.
+// Stages run in registration order.
+func (rs *RecommendationService) AddPostRankingStage(stage PostRankingStage) {
+	rs.postRanking.AddStage(stage)
+}
+
+// SetStrategyBandit enables the "auto" recommendation type
+func (rs *RecommendationService) SetStrategyBandit(bandit *StrategyBandit) {
+	rs.bandit = bandit
+}
+
+// SetExperimentService enables A/B experiment assignment
+func (rs *RecommendationService) SetExperimentService(experiments *ExperimentService) {
+	rs.experiments = experiments
+}
+
+// SetFeedbackService applies "not interested" dismissals to customer profiles as negative preferences
+func (rs *RecommendationService) SetFeedbackService(feedback *FeedbackService) {
+	rs.feedback = feedback
+}
+
+// SetImpressionService enables impression logging of returned products and click tracking for frequency caps
+func (rs *RecommendationService) SetImpressionService(impressions *ImpressionService) {
+	rs.impressions = impressions
+}
+
 // GetRecommendations generates product recommendations based on the request type
 func (rs *RecommendationService) GetRecommendations(ctx context.Context, req *dto.RecommendationRequest) (*dto.RecommendationResponse, error) {
 	startTime := time.Now()
@@ -49,8 +89,27 @@ func (rs *RecommendationService) GetRecommendations(ctx context.Context, req *dt
 		return nil, fmt.Errorf("failed to get customer profile: %w", err)
 	}
 
+	// Apply the customer's experiment variant, if any
+	assignment := rs.assignExperiment(ctx, req)
+	var variantWeights map[string]float64
+	if assignment != nil {
+		ctx = withExperimentAssignment(ctx, assignment)
+		if assignment.Overrides.RecommendationType != "" {
+			req.RecommendationType = assignment.Overrides.RecommendationType
+		}
+		variantWeights = assignment.Overrides.StrategyWeights
+	}
+
+	// Let the bandit choose the recommendation type for "auto" requests
+	var selection *dto.StrategySelection
+	if req.RecommendationType == dto.RecommendationTypeAuto {
+		selection = rs.selectStrategy(ctx, req)
+		req.RecommendationType = selection.Arm
+	}
+
 	var recommendations []dto.ProductRecommendation
 	var algorithmVersion string
+	var blend *BlendResult
 
 	// Generate recommendations based on type
 	switch req.RecommendationType {
@@ -58,7 +117,7 @@ func (rs *RecommendationService) GetRecommendations(ctx context.Context, req *dt
 		if req.ProductID == nil {
 			return nil, fmt.Errorf("product_id is required for similar recommendations")
 		}
-		recommendations, err = rs.GetSimilarProducts(ctx, *req.ProductID, req.Limit)
+		recommendations, err = rs.similarProducts(ctx, *req.ProductID, req.Limit)
 		algorithmVersion = "similar_v1.0"
 	case "collaborative":
 		recommendations, err = rs.getCollaborativeRecommendations(ctx, profile, req.Limit)
@@ -67,7 +126,11 @@ func (rs *RecommendationService) GetRecommendations(ctx context.Context, req *dt
 		recommendations, err = rs.getContentBasedRecommendations(ctx, profile, req.Limit)
 		algorithmVersion = "content_based_v1.0"
 	case "hybrid":
-		recommendations, err = rs.getHybridRecommendations(ctx, profile, req)
+		blend = rs.blender.Resolve(req.ContextType, profile, variantWeights)
+		if assignment != nil && assignment.Overrides.FusionMethod != "" {
+			blend.FusionMethod = assignment.Overrides.FusionMethod
+		}
+		recommendations, err = rs.getHybridRecommendations(ctx, profile, req, blend)
 		algorithmVersion = "hybrid_v1.0"
 	default:
 		return nil, fmt.Errorf("unsupported recommendation type: %s", req.RecommendationType)
@@ -76,12 +139,28 @@ func (rs *RecommendationService) GetRecommendations(ctx context.Context, req *dt
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate recommendations: %w", err)
 	}
+	algorithmVersion = StampAlgorithmVersion(algorithmVersion, assignment)
 
 	// Filter out owned products if requested
+	var excluded map[uuid.UUID]bool
 	if req.ExcludeOwned {
 		recommendations = rs.filterOwnedProducts(recommendations, profile.PurchaseHistory)
+		excluded = purchasedProducts(profile.PurchaseHistory)
 	}
 
+	// Apply post-ranking stages such as diversity re-ranking
+	rankingContext := &RankingContext{
+		Service:     BlendServiceV1,
+		CustomerID:  req.CustomerID,
+		ContextType: req.ContextType,
+		CategoryID:  req.CategoryID,
+		Profile:     profile,
+		Limit:       req.Limit,
+		Diversity:   req.Diversity,
+		Excluded:    excluded,
+	}
+	recommendations = fromRecommendationsV2(rs.postRanking.Apply(ctx, toRecommendationsV2(recommendations), rankingContext))
+
 	// Limit results
 	if len(recommendations) > req.Limit {
 		recommendations = recommendations[:req.Limit]
@@ -92,19 +171,14 @@ func (rs *RecommendationService) GetRecommendations(ctx context.Context, req *dt
 		fmt.Println(rec.Name)
 	}
 
-	// Generate AI-powered explanations and confidence scores
+	// Generate AI-powered explanations; the post-ranking order and scores are kept as served
 	recommendations, err = rs.enhanceWithAI(ctx, recommendations, profile, req.ContextType)
 	if err != nil {
 		// Log error but don't fail the request
 		fmt.Printf("Warning: failed to enhance recommendations with AI: %v\n", err)
-	} else {
-		// Sort recommendations by AI-enhanced confidence scores in descending order
-		sort.Slice(recommendations, func(i, j int) bool {
-			return recommendations[i].ConfidenceScore > recommendations[j].ConfidenceScore
-		})
 	}
 
-	fmt.Println("recommendations after AI enhancement and sorting", len(recommendations))
+	fmt.Println("recommendations after AI enhancement", len(recommendations))
 	for _, rec := range recommendations {
 		fmt.Printf("%s (confidence: %.3f)\n", rec.Name, rec.ConfidenceScore)
 	}
@@ -116,27 +190,52 @@ func (rs *RecommendationService) GetRecommendations(ctx context.Context, req *dt
 		productIDs[i] = rec.ProductID
 	}
 
-	err = rs.repo.LogRecommendation(ctx, req.CustomerID, req.RecommendationType, req.ContextType, productIDs, sessionID)
+	err = rs.repo.LogRecommendation(ctx, req.CustomerID, req.RecommendationType, req.ContextType, productIDs, sessionID, algorithmVersion)
 	if err != nil {
 		fmt.Printf("Warning: failed to log recommendation: %v\n", err)
 	}
 
+	if rs.impressions != nil {
+		if err := rs.impressions.RecordImpressions(ctx, sessionID, req.CustomerID, req.ContextType, productIDs); err != nil {
+			fmt.Printf("Warning: failed to record impressions: %v\n", err)
+		}
+	}
+
+	if selection != nil && selection.Policy == BanditPolicyThompsonSampling {
+		if err := rs.bandit.RecordDecision(ctx, sessionID, req.CustomerID, BlendServiceV1, req.ContextType, selection); err != nil {
+			fmt.Printf("Warning: failed to record strategy decision: %v\n", err)
+		}
+	}
+
 	processingTime := time.Since(startTime).Milliseconds()
 
+	metadata := dto.RecommendationMetadata{
+		AlgorithmVersion:  algorithmVersion,
+		ProcessingTimeMs:  processingTime,
+		TotalProducts:     len(recommendations),
+		FilteredProducts:  len(recommendations),
+		AIModelUsed:       rs.modelID,
+		SessionID:         sessionID,
+		FiredRules:        rankingContext.FiredRules,
+		StrategySelection: selection,
+		Experiment:        assignment,
+	}
+	if assignment != nil && assignment.Overrides.ModelID != "" {
+		metadata.AIModelUsed = assignment.Overrides.ModelID
+	}
+	if blend != nil {
+		metadata.BlendProfile = blend.ProfileName
+		metadata.StrategyWeights = blend.Weights
+		metadata.FusionMethod = blend.FusionMethod
+	}
+
 	return &dto.RecommendationResponse{
 		CustomerID:         req.CustomerID,
 		Recommendations:    recommendations,
 		RecommendationType: req.RecommendationType,
 		ContextType:        req.ContextType,
 		GeneratedAt:        time.Now(),
-		Metadata: dto.RecommendationMetadata{
-			AlgorithmVersion: algorithmVersion,
-			ProcessingTimeMs: processingTime,
-			TotalProducts:    len(recommendations),
-			FilteredProducts: len(recommendations),
-			AIModelUsed:      rs.modelID,
-			SessionID:        sessionID,
-		},
+		Metadata:           metadata,
 	}, nil
 }
 
@@ -161,16 +260,93 @@ func (rs *RecommendationService) GetCustomerProfile(ctx context.Context, custome
 	}
 	profile.RecentActivities = activities
 
+	// Dismissals feed back into the profile as disliked brands and categories
+	if rs.feedback != nil {
+		if err := rs.feedback.ApplyNegativePreferences(ctx, profile); err != nil {
+			log.Printf("Warning: failed to apply negative feedback preferences: %v", err)
+		}
+	}
+
 	return profile, nil
 }
 
 // LogRecommendationInteraction logs customer interactions with recommendations
+// and rewards the strategy the bandit chose for them, if any
 func (rs *RecommendationService) LogRecommendationInteraction(ctx context.Context, analytics *dto.RecommendationAnalytics) error {
-	return rs.repo.LogRecommendationInteraction(ctx, analytics)
+	if err := rs.repo.LogRecommendationInteraction(ctx, analytics); err != nil {
+		return err
+	}
+
+	if rs.bandit != nil {
+		if err := rs.bandit.RecordInteraction(ctx, analytics); err != nil {
+			fmt.Printf("Warning: failed to update strategy bandit: %v\n", err)
+		}
+	}
+
+	if rs.impressions != nil {
+		if err := rs.impressions.RecordInteraction(ctx, analytics); err != nil {
+			fmt.Printf("Warning: failed to record impression clicks: %v\n", err)
+		}
+	}
+
+	return nil
+}
+
+// assignExperiment returns the experiment variant for the request, or nil if none applies
+func (rs *RecommendationService) assignExperiment(ctx context.Context, req *dto.RecommendationRequest) *dto.ExperimentAssignment {
+	if rs.experiments == nil {
+		return nil
+	}
+
+	assignment, err := rs.experiments.Assign(ctx, BlendServiceV1, req.ContextType, req.CustomerID)
+	if err != nil {
+		fmt.Printf("Warning: failed to assign experiment: %v\n", err)
+		return nil
+	}
+
+	return assignment
+}
+
+// selectStrategy picks a recommendation type for an "auto" request.
+// Falls back to hybrid when no bandit is configured or selection fails.
+func (rs *RecommendationService) selectStrategy(ctx context.Context, req *dto.RecommendationRequest) *dto.StrategySelection {
+	arms := []string{"collaborative", "content_based", "hybrid"}
+	if req.ProductID != nil {
+		arms = append(arms, "similar")
+	}
+
+	fallback := &dto.StrategySelection{Policy: BanditPolicyFallback, Arm: "hybrid", Propensity: 1, Candidates: arms}
+	if rs.bandit == nil {
+		return fallback
+	}
+
+	selection, err := rs.bandit.SelectArm(ctx, BlendServiceV1, req.ContextType, arms)
+	if err != nil {
+		fmt.Printf("Warning: failed to select strategy: %v\n", err)
+		return fallback
+	}
+
+	return selection
 }
 
-// GetSimilarProducts finds products similar to a given product using content-based filtering
-func (rs *RecommendationService) GetSimilarProducts(ctx context.Context, productID uuid.UUID, limit int) ([]dto.ProductRecommendation, error) {
+// GetSimilarProducts finds products similar to a given product using content-based filtering,
+// leaving out products the customer dismissed when a customer is given
+func (rs *RecommendationService) GetSimilarProducts(ctx context.Context, productID uuid.UUID, customerID *uuid.UUID, limit int) ([]dto.ProductRecommendation, error) {
+	suppressions := rs.feedback.suppressionsFor(ctx, customerID)
+	if suppressions.Empty() {
+		return rs.similarProducts(ctx, productID, limit)
+	}
+
+	// Over-fetch so that dismissed products can be dropped
+	similarProducts, err := rs.similarProducts(ctx, productID, limit*2)
+	if err != nil {
+		return nil, err
+	}
+	return truncateRecommendations(filterSuppressedRecommendations(similarProducts, suppressions), limit), nil
+}
+
+// similarProducts finds products similar to a given product by shared tags
+func (rs *RecommendationService) similarProducts(ctx context.Context, productID uuid.UUID, limit int) ([]dto.ProductRecommendation, error) {
 	// Get the target product to extract its tags
 	products, err := rs.repo.GetProductsByIDs(ctx, []uuid.UUID{productID})
 	if err != nil || len(products) == 0 {
@@ -198,9 +374,39 @@ func (rs *RecommendationService) GetSimilarProducts(ctx context.Context, product
 	return similarProducts, nil
 }
 
-// GetTrendingProducts returns currently trending products
-func (rs *RecommendationService) GetTrendingProducts(ctx context.Context, categoryID *int, limit int) ([]dto.ProductRecommendation, error) {
-	return rs.repo.GetTrendingProducts(ctx, categoryID, limit)
+// GetTrendingProducts returns currently trending products, leaving out products the customer
+// dismissed when a customer is given
+func (rs *RecommendationService) GetTrendingProducts(ctx context.Context, categoryID *int, customerID *uuid.UUID, limit int) ([]dto.ProductRecommendation, error) {
+	suppressions := rs.feedback.suppressionsFor(ctx, customerID)
+	if suppressions.Empty() {
+		return rs.repo.GetTrendingProducts(ctx, categoryID, limit)
+	}
+
+	// Over-fetch so that dismissed products can be dropped
+	trending, err := rs.repo.GetTrendingProducts(ctx, categoryID, limit*2)
+	if err != nil {
+		return nil, err
+	}
+	return truncateRecommendations(filterSuppressedRecommendations(trending, suppressions), limit), nil
+}
+
+// filterSuppressedRecommendations removes dismissed products from a V1 recommendation list
+func filterSuppressedRecommendations(recommendations []dto.ProductRecommendation, suppressions *FeedbackSuppressions) []dto.ProductRecommendation {
+	kept := make([]dto.ProductRecommendation, 0, len(recommendations))
+	for _, rec := range recommendations {
+		if !suppressions.Suppresses(rec.ProductID, rec.Brand, rec.CategoryID) {
+			kept = append(kept, rec)
+		}
+	}
+	return kept
+}
+
+// truncateRecommendations returns at most limit recommendations
+func truncateRecommendations(recommendations []dto.ProductRecommendation, limit int) []dto.ProductRecommendation {
+	if len(recommendations) > limit {
+		return recommendations[:limit]
+	}
+	return recommendations
 }
 
 // GetPersonalizedRecommendations generates AI-powered personalized recommendations
@@ -209,7 +415,7 @@ func (rs *RecommendationService) GetPersonalizedRecommendations(ctx context.Cont
 	prompt := rs.createPersonalizationPrompt(profile)
 
 	// Get chat response
-	chatResponse, err := rs.chatService.GenerateResponse(ctx, prompt)
+	chatResponse, err := generateAIResponse(ctx, rs.chatService, prompt)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get chat response: %w", err)
 	}
@@ -283,58 +489,67 @@ func (rs *RecommendationService) getContentBasedRecommendations(ctx context.Cont
 	return uniqueProducts, nil
 }
 
-// getHybridRecommendations combines multiple recommendation strategies
-func (rs *RecommendationService) getHybridRecommendations(ctx context.Context, profile *dto.CustomerProfile, req *dto.RecommendationRequest) ([]dto.ProductRecommendation, error) {
-	var allRecommendations []dto.ProductRecommendation
-
-	// Get collaborative recommendations (40% weight)
-	collaborativeRecs, err := rs.getCollaborativeRecommendations(ctx, profile, req.Limit/2)
-	if err == nil {
-		for i := range collaborativeRecs {
-			collaborativeRecs[i].ConfidenceScore = collaborativeRecs[i].ConfidenceScore * 0.4
+// getHybridRecommendations combines multiple recommendation strategies using the resolved blend.
+// Strategy lists are merged with rank fusion so that scores on different scales remain comparable;
+// strategies with a zero weight are skipped entirely.
+func (rs *RecommendationService) getHybridRecommendations(ctx context.Context, profile *dto.CustomerProfile, req *dto.RecommendationRequest, blend *BlendResult) ([]dto.ProductRecommendation, error) {
+	var lists []RankedList
+	products := newCandidatePool(func(rec dto.ProductRecommendation) (uuid.UUID, float64) {
+		return rec.ProductID, rec.ConfidenceScore
+	}, mergeRecommendationEvidence)
+
+	if weight := blend.Weights[StrategyCollaborative]; weight > 0 {
+		collaborativeRecs, err := rs.getCollaborativeRecommendations(ctx, profile, req.Limit/2)
+		if err == nil {
+			lists = append(lists, products.rankedList(StrategyCollaborative, weight, collaborativeRecs))
 		}
-		allRecommendations = append(allRecommendations, collaborativeRecs...)
 	}
 
-	// Get content-based recommendations (40% weight)
-	contentRecs, err := rs.getContentBasedRecommendations(ctx, profile, req.Limit/2)
-	if err == nil {
-		for i := range contentRecs {
-			contentRecs[i].ConfidenceScore = contentRecs[i].ConfidenceScore * 0.4
+	if weight := blend.Weights[StrategyContentBased]; weight > 0 {
+		contentRecs, err := rs.getContentBasedRecommendations(ctx, profile, req.Limit/2)
+		if err == nil {
+			lists = append(lists, products.rankedList(StrategyContentBased, weight, contentRecs))
 		}
-		allRecommendations = append(allRecommendations, contentRecs...)
 	}
 
-	// Get trending products (20% weight)
-	var categoryID *int
-	if req.CategoryID != nil {
-		categoryID = req.CategoryID
-	} else if len(profile.PreferredCategories) > 0 {
-		categoryID = &profile.PreferredCategories[0]
-	}
+	if weight := blend.Weights[StrategyTrending]; weight > 0 {
+		var categoryID *int
+		if req.CategoryID != nil {
+			categoryID = req.CategoryID
+		} else if len(profile.PreferredCategories) > 0 {
+			categoryID = &profile.PreferredCategories[0]
+		}
 
-	trendingRecs, err := rs.GetTrendingProducts(ctx, categoryID, req.Limit/4)
-	if err == nil {
-		for i := range trendingRecs {
-			trendingRecs[i].ConfidenceScore = trendingRecs[i].ConfidenceScore * 0.2
+		trendingRecs, err := rs.repo.GetTrendingProducts(ctx, categoryID, req.Limit/4)
+		if err == nil {
+			lists = append(lists, products.rankedList(StrategyTrending, weight, trendingRecs))
 		}
-		allRecommendations = append(allRecommendations, trendingRecs...)
 	}
 
-	// Remove duplicates and sort by confidence score
-	uniqueProducts := rs.removeDuplicateProducts(allRecommendations)
-	sort.Slice(uniqueProducts, func(i, j int) bool {
-		return uniqueProducts[i].ConfidenceScore > uniqueProducts[j].ConfidenceScore
-	})
+	fused, err := FuseRankedLists(blend.FusionMethod, lists)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fuse strategy results: %w", err)
+	}
 
-	if len(uniqueProducts) > req.Limit {
-		uniqueProducts = uniqueProducts[:req.Limit]
+	recommendations := make([]dto.ProductRecommendation, 0, len(fused))
+	for _, candidate := range fused {
+		rec := products.product(candidate.ProductID)
+		rec.ConfidenceScore = candidate.Score
+		recommendations = append(recommendations, rec)
 	}
 
-	return uniqueProducts, nil
+	return recommendations, nil
+}
+
+// mergeRecommendationEvidence combines the reasons of another strategy's copy of a product
+func mergeRecommendationEvidence(kept, other dto.ProductRecommendation) dto.ProductRecommendation {
+	kept.Reason = mergeReasons(kept.Reason, other.Reason)
+	return kept
 }
 
-// enhanceWithAI adds AI-generated explanations and confidence scores
+// enhanceWithAI adds AI-generated explanations.
+// Ranking has already happened in the post-ranking stages, so the model's confidence
+// scores are not applied and the order and scores of the recommendations are left unchanged.
 func (rs *RecommendationService) enhanceWithAI(ctx context.Context, recommendations []dto.ProductRecommendation, profile *dto.CustomerProfile, contextType string) ([]dto.ProductRecommendation, error) {
 	if len(recommendations) == 0 {
 		return recommendations, nil
@@ -344,7 +559,7 @@ func (rs *RecommendationService) enhanceWithAI(ctx context.Context, recommendati
 	prompt := rs.createEnhancementPrompt(recommendations, profile, contextType)
 
 	// Get chat response
-	chatResponse, err := rs.chatService.GenerateResponse(ctx, prompt)
+	chatResponse, err := generateAIResponse(ctx, rs.chatService, prompt)
 	if err != nil {
 		return recommendations, err
 	}
@@ -749,11 +964,10 @@ func (rs *RecommendationService) parseAIEnhancements(content string, recommendat
 		}
 	}
 
-	// Apply enhancements to recommendations
+	// Apply explanations to recommendations; scores stay as ranked
 	for i := range recommendations {
 		productIDStr := recommendations[i].ProductID.String()
 		if enhancement, exists := enhancementMap[productIDStr]; exists {
-			recommendations[i].ConfidenceScore = enhancement.ConfidenceScore
 			recommendations[i].Reason = enhancement.Reason
 		}
 	}
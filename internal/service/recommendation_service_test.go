@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"ec-recommend/internal/dto"
+	"ec-recommend/internal/types"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// fakeV1Repository serves a fixed customer and category listing and records logged recommendations;
+// every other method is left to the nil embedded interface
+type fakeV1Repository struct {
+	RecommendationRepositoryInterface
+	categoryProducts []dto.ProductRecommendation
+	logged           []uuid.UUID
+}
+
+func (f *fakeV1Repository) GetCustomerByID(ctx context.Context, customerID uuid.UUID) (*dto.CustomerProfile, error) {
+	return &dto.CustomerProfile{CustomerID: customerID, PreferredCategories: []int{1}, OrderCount: 3}, nil
+}
+
+func (f *fakeV1Repository) GetCustomerPurchaseHistory(ctx context.Context, customerID uuid.UUID, limit int) ([]dto.PurchaseItem, error) {
+	return nil, nil
+}
+
+func (f *fakeV1Repository) GetCustomerActivities(ctx context.Context, customerID uuid.UUID, limit int) ([]dto.ActivityItem, error) {
+	return nil, nil
+}
+
+func (f *fakeV1Repository) GetProductsByCategory(ctx context.Context, categoryID int, limit int) ([]dto.ProductRecommendation, error) {
+	return f.categoryProducts, nil
+}
+
+func (f *fakeV1Repository) LogRecommendation(ctx context.Context, customerID uuid.UUID, recommendationType, contextType string, productIDs []uuid.UUID, sessionID uuid.UUID, algorithmVersion string) error {
+	f.logged = productIDs
+	return nil
+}
+
+// fakeChatService answers every prompt with the same content
+type fakeChatService struct {
+	content string
+}
+
+func (f *fakeChatService) Chat(ctx context.Context, req *dto.ChatRequest) (*dto.ChatResponse, error) {
+	return &dto.ChatResponse{}, nil
+}
+
+func (f *fakeChatService) GenerateResponse(ctx context.Context, prompt string) (*types.AIResponse, error) {
+	return &types.AIResponse{Content: f.content}, nil
+}
+
+func (f *fakeChatService) GenerateResponseWithModel(ctx context.Context, prompt, modelID string) (*types.AIResponse, error) {
+	return f.GenerateResponse(ctx, prompt)
+}
+
+func TestGetRecommendationsKeepsPostRankingOrder(t *testing.T) {
+	acme1 := dto.ProductRecommendation{ProductID: uuid.New(), Name: "acme-1", Brand: "Acme", CategoryID: 1, ConfidenceScore: 0.9}
+	acme2 := dto.ProductRecommendation{ProductID: uuid.New(), Name: "acme-2", Brand: "Acme", CategoryID: 1, ConfidenceScore: 0.85}
+	other1 := dto.ProductRecommendation{ProductID: uuid.New(), Name: "other-1", Brand: "Other", CategoryID: 1, ConfidenceScore: 0.5}
+	other2 := dto.ProductRecommendation{ProductID: uuid.New(), Name: "other-2", Brand: "Other", CategoryID: 1, ConfidenceScore: 0.4}
+
+	// The model scores the products in the opposite order of the ranking
+	type enhancement struct {
+		ProductID       string  `json:"product_id"`
+		ConfidenceScore float64 `json:"confidence_score"`
+		Reason          string  `json:"reason"`
+	}
+	var enhancements struct {
+		Enhancements []enhancement `json:"enhancements"`
+	}
+	for i, rec := range []dto.ProductRecommendation{acme1, acme2, other1, other2} {
+		enhancements.Enhancements = append(enhancements.Enhancements, enhancement{
+			ProductID:       rec.ProductID.String(),
+			ConfidenceScore: 0.1 * float64(i+1),
+			Reason:          "reason for " + rec.Name,
+		})
+	}
+	content, err := json.Marshal(enhancements)
+	if err != nil {
+		t.Fatalf("Failed to marshal enhancements: %v", err)
+	}
+
+	repo := &fakeV1Repository{categoryProducts: []dto.ProductRecommendation{acme1, acme2, other1, other2}}
+	rs := NewRecommendationService(repo, &fakeChatService{content: string(content)}, "test-model", nil)
+	rs.AddPostRankingStage(NewDiversityReranker(DiversityTuning{DefaultDiversity: 0.8}, nil))
+
+	resp, err := rs.GetRecommendations(context.Background(), &dto.RecommendationRequest{
+		CustomerID:         uuid.New(),
+		RecommendationType: "content_based",
+		Limit:              4,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// MMR interleaves the brands: acme-1, other-1, acme-2, other-2
+	want := []dto.ProductRecommendation{acme1, other1, acme2, other2}
+	if len(resp.Recommendations) != len(want) {
+		t.Fatalf("Expected %d recommendations, got %d", len(want), len(resp.Recommendations))
+	}
+	for i, rec := range resp.Recommendations {
+		if rec.ProductID != want[i].ProductID {
+			t.Errorf("Expected %s at position %d, got %s", want[i].Name, i, rec.Name)
+		}
+		if rec.ConfidenceScore != want[i].ConfidenceScore {
+			t.Errorf("Expected %s to keep its ranking score %f, got %f", rec.Name, want[i].ConfidenceScore, rec.ConfidenceScore)
+		}
+		if rec.Reason != "reason for "+rec.Name {
+			t.Errorf("Expected the AI explanation to be applied to %s, got %q", rec.Name, rec.Reason)
+		}
+		if repo.logged[i] != want[i].ProductID {
+			t.Errorf("Expected the served order to be logged, got %s at position %d", repo.logged[i], i)
+		}
+	}
+}
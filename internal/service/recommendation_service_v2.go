@@ -6,7 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
-	"sort"
+	"math"
 	"strings"
 	"time"
 
@@ -23,15 +23,35 @@ type RecommendationServiceV2 struct {
 	embeddingModelID string
 	promptGenerator  *PromptGenerator
 	outputFormatter  *OutputFormatter
+	blender          *StrategyBlender
+	postRanking      *PostRankingPipeline
+	logFeatures      bool
+	bandit           *StrategyBandit
+	experiments      *ExperimentService
+	sessionSequence  *SessionSequenceRecommender
+	seasonality      *SeasonalityService
+	regional         *RegionalPopularityService
+	explainer        *RecommendationExplainer
+	feedback         *FeedbackService
+	impressions      *ImpressionService
+	queryNormalizer  *QueryNormalizer
+	lexicalSearch    *LexicalSearchService
+	hybridTuning     HybridSearchTuning
 }
 
-// NewRecommendationServiceV2 creates a new enhanced recommendation service instance
+// NewRecommendationServiceV2 creates a new enhanced recommendation service instance.
+// A nil tuning falls back to the default blend weights.
 func NewRecommendationServiceV2(
 	repo RecommendationRepositoryV2Interface,
 	rag RAGInterface,
 	chatService ChatServiceInterface,
 	modelID, knowledgeBaseID, embeddingModelID string,
+	tuning *RecommendationTuning,
 ) *RecommendationServiceV2 {
+	if tuning == nil {
+		tuning = DefaultRecommendationTuning()
+	}
+
 	// Initialize prompt generator with default configuration
 	promptConfig := &PromptConfig{
 		MaxTokens:             2000,
@@ -42,6 +62,13 @@ func NewRecommendationServiceV2(
 		IncludeReasoningChain: false,
 	}
 
+	promptGenerator := NewPromptGenerator(promptConfig)
+	for _, template := range tuning.PromptTemplates {
+		if err := promptGenerator.RegisterTemplateVersion(template); err != nil {
+			log.Printf("Warning: failed to register prompt template: %v", err)
+		}
+	}
+
 	return &RecommendationServiceV2{
 		repo:             repo,
 		rag:              rag,
@@ -49,11 +76,70 @@ func NewRecommendationServiceV2(
 		modelID:          modelID,
 		knowledgeBaseID:  knowledgeBaseID,
 		embeddingModelID: embeddingModelID,
-		promptGenerator:  NewPromptGenerator(promptConfig),
+		promptGenerator:  promptGenerator,
 		outputFormatter:  NewOutputFormatter(),
+		blender:          NewStrategyBlender(BlendServiceV2, defaultV2BlendWeights, tuning.BlendProfiles),
+		postRanking:      NewPostRankingPipeline(),
+		logFeatures:      tuning.LearningToRank.LogFeatures,
+		explainer:        NewRecommendationExplainer(tuning.Explainer),
+		hybridTuning:     tuning.HybridSearch,
 	}
 }
 
+// AddPostRankingStage registers a stage that runs after candidate generation and ranking.
+// Stages run in registration order.
+func (rs *RecommendationServiceV2) AddPostRankingStage(stage PostRankingStage) {
+	rs.postRanking.AddStage(stage)
+}
+
+// SetStrategyBandit enables the "auto" recommendation type
+func (rs *RecommendationServiceV2) SetStrategyBandit(bandit *StrategyBandit) {
+	rs.bandit = bandit
+}
+
+// SetExperimentService enables A/B experiment assignment
+func (rs *RecommendationServiceV2) SetExperimentService(experiments *ExperimentService) {
+	rs.experiments = experiments
+}
+
+// SetSessionSequenceRecommender enables the session_sequence recommendation type and hybrid strategy
+func (rs *RecommendationServiceV2) SetSessionSequenceRecommender(recommender *SessionSequenceRecommender) {
+	rs.sessionSequence = recommender
+}
+
+// SetSeasonalityService replaces calendar-based seasonal filters, embedding context and prompt context
+// with seasonality learned from order history
+func (rs *RecommendationServiceV2) SetSeasonalityService(seasonality *SeasonalityService) {
+	rs.seasonality = seasonality
+	rs.promptGenerator.SetSeasonality(seasonality)
+}
+
+// SetRegionalPopularityService enables the regional recommendation type, the regional hybrid strategy
+// and the location boost applied to hybrid results
+func (rs *RecommendationServiceV2) SetRegionalPopularityService(regional *RegionalPopularityService) {
+	rs.regional = regional
+}
+
+// SetFeedbackService applies "not interested" dismissals to customer profiles as negative preferences
+func (rs *RecommendationServiceV2) SetFeedbackService(feedback *FeedbackService) {
+	rs.feedback = feedback
+}
+
+// SetImpressionService enables impression logging of returned products and click tracking for frequency caps
+func (rs *RecommendationServiceV2) SetImpressionService(impressions *ImpressionService) {
+	rs.impressions = impressions
+}
+
+// SetQueryNormalizer enables spelling correction and synonym rewrites of search queries before retrieval
+func (rs *RecommendationServiceV2) SetQueryNormalizer(normalizer *QueryNormalizer) {
+	rs.queryNormalizer = normalizer
+}
+
+// SetLexicalSearchService enables the "keyword" search type and the lexical fallback for knowledge base searches
+func (rs *RecommendationServiceV2) SetLexicalSearchService(lexicalSearch *LexicalSearchService) {
+	rs.lexicalSearch = lexicalSearch
+}
+
 // GetRecommendationsV2 generates advanced product recommendations using RAG and vector search
 func (rs *RecommendationServiceV2) GetRecommendationsV2(ctx context.Context, req *dto.RecommendationRequestV2) (*dto.RecommendationResponseV2, error) {
 	startTime := time.Now()
@@ -80,6 +166,30 @@ func (rs *RecommendationServiceV2) GetRecommendationsV2(ctx context.Context, req
 	var queryUnderstanding *dto.QueryUnderstanding
 	var searchStrategies []string
 	var performanceMetrics = &dto.PerformanceMetrics{}
+	var blend *BlendResult
+
+	// Apply the customer's experiment variant, if any; explicit request weights take precedence
+	assignment := rs.assignExperiment(ctx, req)
+	if assignment != nil {
+		ctx = withExperimentAssignment(ctx, assignment)
+		if assignment.Overrides.RecommendationType != "" {
+			req.RecommendationType = assignment.Overrides.RecommendationType
+		}
+		if req.StrategyWeights == nil {
+			req.StrategyWeights = assignment.Overrides.StrategyWeights
+		}
+		if req.FusionMethod == "" {
+			req.FusionMethod = assignment.Overrides.FusionMethod
+		}
+	}
+	algorithmVersion := StampAlgorithmVersion("rag_hybrid_v2.0", assignment)
+
+	// Let the bandit choose the recommendation type for "auto" requests
+	var selection *dto.StrategySelection
+	if req.RecommendationType == dto.RecommendationTypeAuto {
+		selection = rs.selectStrategy(ctx, req)
+		req.RecommendationType = selection.Arm
+	}
 
 	// Generate recommendations based on type
 	switch req.RecommendationType {
@@ -99,8 +209,18 @@ func (rs *RecommendationServiceV2) GetRecommendationsV2(ctx context.Context, req
 	case "collaborative":
 		recommendations, err = rs.generateCollaborativeRecommendations(ctx, req, profile)
 		searchStrategies = append(searchStrategies, "collaborative_filtering")
+	case "session_sequence":
+		recommendations, err = rs.generateSessionSequenceRecommendations(ctx, req)
+		searchStrategies = append(searchStrategies, "session_sequence")
+	case "regional":
+		recommendations, err = rs.generateRegionalRecommendations(ctx, req, rs.customerLocation(ctx, req.CustomerID))
+		searchStrategies = append(searchStrategies, "regional_popularity")
 	case "hybrid":
-		recommendations, semanticInsights, queryUnderstanding, err = rs.generateHybridRecommendations(ctx, req, profile, performanceMetrics)
+		blend = rs.blender.Resolve(req.ContextType, profile, req.StrategyWeights)
+		if req.FusionMethod != "" {
+			blend.FusionMethod = req.FusionMethod
+		}
+		recommendations, semanticInsights, queryUnderstanding, err = rs.generateHybridRecommendations(ctx, req, profile, blend, performanceMetrics)
 		searchStrategies = append(searchStrategies, "hybrid_rag", "semantic_search", "collaborative_filtering")
 	default:
 		return nil, fmt.Errorf("unsupported recommendation type: %s", req.RecommendationType)
@@ -110,9 +230,19 @@ func (rs *RecommendationServiceV2) GetRecommendationsV2(ctx context.Context, req
 		return nil, fmt.Errorf("failed to generate recommendations: %w", err)
 	}
 
+	// Gift queries recommend for someone else, so the giver's own purchases are excluded
+	// and explanations are written for the recipient
+	giftQuery := queryUnderstanding != nil && queryUnderstanding.Intent == dto.IntentGiftSuggestion
+
 	// Filter out owned products if requested
+	var excluded map[uuid.UUID]bool
 	if req.ExcludeOwned {
 		recommendations = rs.filterOwnedProductsV2(recommendations, profile.PurchaseHistory)
+		excluded = purchasedProducts(profile.PurchaseHistory)
+	} else if giftQuery {
+		selfPurchases := rs.selfPurchases(ctx, req.CustomerID, profile.PurchaseHistory)
+		recommendations = rs.filterOwnedProductsV2(recommendations, selfPurchases)
+		excluded = purchasedProducts(selfPurchases)
 	}
 
 	// Apply price range filters
@@ -120,13 +250,41 @@ func (rs *RecommendationServiceV2) GetRecommendationsV2(ctx context.Context, req
 		recommendations = rs.filterByPriceRange(recommendations, req.PriceRangeMin, req.PriceRangeMax)
 	}
 
+	// Apply post-ranking stages such as diversity re-ranking
+	rankingContext := &RankingContext{
+		Service:     BlendServiceV2,
+		CustomerID:  req.CustomerID,
+		ContextType: req.ContextType,
+		CategoryID:  req.CategoryID,
+		Profile:     profile,
+		Limit:       req.Limit,
+		Diversity:   req.Diversity,
+		Excluded:    excluded,
+	}
+	retrieval := snapshotRetrievalScores(recommendations)
+	recommendations = rs.postRanking.Apply(ctx, recommendations, rankingContext)
+
 	// Limit results
 	if len(recommendations) > req.Limit {
 		recommendations = recommendations[:req.Limit]
 	}
 
 	// Generate AI-powered explanations if requested
-	if req.EnableExplanation {
+	ranked := snapshotRankedScores(recommendations)
+	if req.EnableExplanation && giftQuery {
+		giftReq := &dto.GiftRequest{
+			CustomerID: req.CustomerID,
+			BudgetMin:  req.PriceRangeMin,
+			BudgetMax:  req.PriceRangeMax,
+			Message:    req.QueryText,
+		}
+		explained, err := rs.explainGiftRecommendations(ctx, recommendations, giftReq)
+		if err != nil {
+			log.Printf("Warning: failed to generate gift explanations: %v", err)
+		} else {
+			recommendations = explained
+		}
+	} else if req.EnableExplanation {
 		recommendations, err = rs.enhanceWithAdvancedAIExplanations(ctx, recommendations, profile, req.ContextType, performanceMetrics)
 		if err != nil {
 			log.Printf("Warning: failed to enhance recommendations with AI explanations: %v", err)
@@ -145,14 +303,101 @@ func (rs *RecommendationServiceV2) GetRecommendationsV2(ctx context.Context, req
 		productIDs[i] = rec.ProductID
 	}
 
-	err = rs.repo.LogRecommendation(ctx, req.CustomerID, req.RecommendationType, req.ContextType, productIDs, sessionID)
+	err = rs.repo.LogRecommendation(ctx, req.CustomerID, req.RecommendationType, req.ContextType, productIDs, sessionID, algorithmVersion)
 	if err != nil {
 		log.Printf("Warning: failed to log recommendation: %v", err)
 	}
 
+	// Store each served item so its explanation can be reconstructed later
+	itemContext := recommendationItemContext{
+		RecommendationLogID: sessionID,
+		CustomerID:          req.CustomerID,
+		RecommendationType:  req.RecommendationType,
+		ContextType:         req.ContextType,
+		AlgorithmVersion:    algorithmVersion,
+		Strategies:          searchStrategies,
+		Filters: dto.RecommendationFilters{
+			QueryText:       req.QueryText,
+			ProductID:       req.ProductID,
+			CategoryID:      req.CategoryID,
+			PriceRangeMin:   req.PriceRangeMin,
+			PriceRangeMax:   req.PriceRangeMax,
+			ExcludeOwned:    req.ExcludeOwned,
+			StrategyWeights: req.StrategyWeights,
+			FusionMethod:    req.FusionMethod,
+		},
+		Retrieval: retrieval,
+		Ranked:    ranked,
+		Features:  rankingContext.Features,
+	}
+	if blend != nil {
+		itemContext.Filters.BlendProfile = blend.ProfileName
+		itemContext.Filters.StrategyWeights = blend.Weights
+		itemContext.Filters.FusionMethod = blend.FusionMethod
+	}
+	rs.saveRecommendationItems(ctx, itemContext, recommendations)
+
+	if rs.impressions != nil {
+		if err := rs.impressions.RecordImpressions(ctx, sessionID, req.CustomerID, req.ContextType, productIDs); err != nil {
+			log.Printf("Warning: failed to record impressions: %v", err)
+		}
+	}
+
+	if selection != nil && selection.Policy == BanditPolicyThompsonSampling {
+		if err := rs.bandit.RecordDecision(ctx, sessionID, req.CustomerID, BlendServiceV2, req.ContextType, selection); err != nil {
+			log.Printf("Warning: failed to record strategy decision: %v", err)
+		}
+	}
+
+	// Log ranking features of the returned items for learning-to-rank training
+	if rs.logFeatures && len(rankingContext.Features) > 0 {
+		candidates := make([]dto.CandidateFeatures, 0, len(recommendations))
+		for i, rec := range recommendations {
+			features, ok := rankingContext.Features[rec.ProductID]
+			if !ok {
+				continue
+			}
+			candidates = append(candidates, dto.CandidateFeatures{
+				ProductID: rec.ProductID,
+				Position:  i + 1,
+				Features:  features,
+			})
+		}
+
+		if err := rs.repo.LogCandidateFeatures(ctx, sessionID, req.CustomerID, req.ContextType, candidates); err != nil {
+			log.Printf("Warning: failed to log ranking features: %v", err)
+		}
+	}
+
 	processingTime := time.Since(startTime).Milliseconds()
 	performanceMetrics.AIProcessingTimeMs = processingTime
 
+	metadata := dto.RecommendationMetadataV2{
+		AlgorithmVersion:   algorithmVersion,
+		ProcessingTimeMs:   processingTime,
+		TotalProducts:      len(recommendations),
+		FilteredProducts:   len(recommendations),
+		AIModelUsed:        rs.modelID,
+		EmbeddingModel:     rs.embeddingModelID,
+		SessionID:          sessionID,
+		KnowledgeBaseUsed:  contains(searchStrategies, "knowledge_base_rag"),
+		VectorSearchUsed:   contains(searchStrategies, "vector_similarity"),
+		SemanticSearchUsed: contains(searchStrategies, "semantic_search"),
+		SearchStrategies:   searchStrategies,
+		FiredRules:         rankingContext.FiredRules,
+		StrategySelection:  selection,
+		Experiment:         assignment,
+		PerformanceMetrics: performanceMetrics,
+	}
+	if assignment != nil && assignment.Overrides.ModelID != "" {
+		metadata.AIModelUsed = assignment.Overrides.ModelID
+	}
+	if blend != nil {
+		metadata.BlendProfile = blend.ProfileName
+		metadata.StrategyWeights = blend.Weights
+		metadata.FusionMethod = blend.FusionMethod
+	}
+
 	return &dto.RecommendationResponseV2{
 		CustomerID:         req.CustomerID,
 		Recommendations:    recommendations,
@@ -161,20 +406,7 @@ func (rs *RecommendationServiceV2) GetRecommendationsV2(ctx context.Context, req
 		GeneratedAt:        time.Now(),
 		SemanticInsights:   semanticInsights,
 		QueryUnderstanding: queryUnderstanding,
-		Metadata: dto.RecommendationMetadataV2{
-			AlgorithmVersion:   "rag_hybrid_v2.0",
-			ProcessingTimeMs:   processingTime,
-			TotalProducts:      len(recommendations),
-			FilteredProducts:   len(recommendations),
-			AIModelUsed:        rs.modelID,
-			EmbeddingModel:     rs.embeddingModelID,
-			SessionID:          sessionID,
-			KnowledgeBaseUsed:  contains(searchStrategies, "knowledge_base_rag"),
-			VectorSearchUsed:   contains(searchStrategies, "vector_similarity"),
-			SemanticSearchUsed: contains(searchStrategies, "semantic_search"),
-			SearchStrategies:   searchStrategies,
-			PerformanceMetrics: performanceMetrics,
-		},
+		Metadata:           metadata,
 	}, nil
 }
 
@@ -182,8 +414,9 @@ func (rs *RecommendationServiceV2) GetRecommendationsV2(ctx context.Context, req
 func (rs *RecommendationServiceV2) SemanticSearch(ctx context.Context, req *dto.SemanticSearchRequest) (*dto.SemanticSearchResponse, error) {
 	startTime := time.Now()
 
-	// Generate query understanding
-	queryUnderstanding, err := rs.analyzeQuery(ctx, req.Query)
+	// Normalize the query and generate query understanding
+	normalizedQuery := rs.queryNormalizer.Normalize(ctx, req.Query)
+	queryUnderstanding, err := rs.analyzeQuery(ctx, normalizedQuery)
 	if err != nil {
 		log.Printf("Warning: failed to analyze query: %v", err)
 	}
@@ -200,17 +433,41 @@ func (rs *RecommendationServiceV2) SemanticSearch(ctx context.Context, req *dto.
 		filters["price_max"] = *req.PriceRangeMax
 	}
 
-	// Perform semantic search using RAG Knowledge Base
-	ragResponse, err := rs.rag.GetProductsWithSemanticSearch(ctx, req.Query, req.Limit, filters)
+	// Perform semantic search using RAG Knowledge Base, or lexical search for keyword searches and as a fallback
+	ragResponse, err := rs.searchProducts(ctx, normalizedQuery.Normalized, req.Limit, filters, req.SearchType, req.HybridWeights)
 	if err != nil {
 		return nil, fmt.Errorf("failed to perform semantic search: %w", err)
 	}
 
+	searchMetadata := &dto.SearchMetadata{
+		SearchType:       "semantic",
+		EmbeddingModel:   rs.embeddingModelID,
+		SimilarityMetric: "cosine",
+		FilterApplied:    filters,
+		RerankerUsed:     false,
+		CacheUsed:        false,
+	}
+	searchMethod := "semantic_search"
+	if isKeywordSearch(ragResponse) {
+		searchMetadata.SearchType = "keyword"
+		searchMetadata.EmbeddingModel = ""
+		searchMetadata.SimilarityMetric = "bm25"
+		searchMethod = "keyword_search"
+	} else if isHybridSearch(ragResponse) {
+		searchMetadata.SearchType = "hybrid"
+		searchMetadata.SimilarityMetric = ragResponse.SearchMetadata.SimilarityMetric
+		searchMetadata.HybridQueryType = ragResponse.SearchMetadata.HybridQueryType
+		searchMetadata.HybridSearchWeight = ragResponse.SearchMetadata.HybridSearchWeight
+		searchMethod = "hybrid_search"
+	}
+	reportSearchType(queryUnderstanding, ragResponse)
+
 	// Convert RAG results to ProductRecommendationV2
-	results, err := rs.convertRAGResultsToProducts(ctx, ragResponse.Results, "semantic_search")
+	results, err := rs.convertRAGResultsToProducts(ctx, ragResponse.Results, searchMethod)
 	if err != nil {
 		return nil, fmt.Errorf("failed to convert semantic search results: %w", err)
 	}
+	results = rs.feedback.suppressionsFor(ctx, req.CustomerID).Filter(results)
 
 	// Log semantic search
 	resultIDs := make([]uuid.UUID, len(results))
@@ -230,17 +487,85 @@ func (rs *RecommendationServiceV2) SemanticSearch(ctx context.Context, req *dto.
 		TotalFound:         len(results),
 		ProcessingTimeMs:   processingTime,
 		QueryUnderstanding: queryUnderstanding,
-		SearchMetadata: &dto.SearchMetadata{
-			SearchType:       "semantic",
-			EmbeddingModel:   rs.embeddingModelID,
-			SimilarityMetric: "cosine",
-			FilterApplied:    filters,
-			RerankerUsed:     false,
-			CacheUsed:        false,
-		},
+		SearchMetadata:     searchMetadata,
 	}, nil
 }
 
+// searchProducts retrieves products for a normalized search query. The "keyword" search type uses
+// lexical search only, and "hybrid" blends lexical and vector search by the hybrid weights (see
+// hybridSearch). Otherwise the knowledge base is searched, vector only for "semantic", falling back
+// to lexical search when it fails or finds nothing.
+func (rs *RecommendationServiceV2) searchProducts(ctx context.Context, query string, limit int, filters map[string]interface{}, searchType string, hybridWeights map[string]float64) (*RAGSemanticSearchResponse, error) {
+	switch searchType {
+	case "keyword":
+		if rs.lexicalSearch == nil {
+			return nil, fmt.Errorf("keyword search is not configured")
+		}
+		return rs.lexicalSearch.Search(ctx, query, limit, filters)
+	case "hybrid":
+		if rs.lexicalSearch != nil {
+			return rs.hybridSearch(ctx, query, limit, filters, hybridWeights)
+		}
+	}
+
+	// Without lexical search, hybrid searches use the knowledge base's own hybrid search
+	kbFilters := filters
+	if searchType != "" {
+		kbFilters = make(map[string]interface{}, len(filters)+1)
+		for k, v := range filters {
+			kbFilters[k] = v
+		}
+		kbFilters[RetrievalSearchTypeFilter] = searchType
+	}
+
+	ragResponse, err := rs.rag.GetProductsWithSemanticSearch(ctx, query, limit, kbFilters)
+	if (err == nil && len(ragResponse.Results) > 0) || !rs.lexicalSearch.FallbackEnabled() {
+		return ragResponse, err
+	}
+	if err != nil {
+		log.Printf("Warning: knowledge base search failed, falling back to lexical search: %v", err)
+	} else {
+		log.Printf("Knowledge base found no products for %q, falling back to lexical search", query)
+	}
+
+	lexicalResponse, lexicalErr := rs.lexicalSearch.Search(ctx, query, limit, filters)
+	if lexicalErr != nil {
+		log.Printf("Warning: lexical search fallback failed: %v", lexicalErr)
+		return ragResponse, err
+	}
+
+	return lexicalResponse, nil
+}
+
+// isKeywordSearch reports whether a search response was served by lexical search
+func isKeywordSearch(response *RAGSemanticSearchResponse) bool {
+	return response.SearchMetadata != nil && response.SearchMetadata.SearchType == "keyword"
+}
+
+// isHybridSearch reports whether a search response blends lexical and vector search
+func isHybridSearch(response *RAGSemanticSearchResponse) bool {
+	return response.SearchMetadata != nil && response.SearchMetadata.SearchType == "hybrid"
+}
+
+// reportSearchType records the retrieval that served a query, and any hybrid weights applied, in
+// its query understanding
+func reportSearchType(understanding *dto.QueryUnderstanding, response *RAGSemanticSearchResponse) {
+	if understanding == nil {
+		return
+	}
+
+	switch {
+	case isKeywordSearch(response):
+		understanding.SearchType = "keyword"
+	case isHybridSearch(response):
+		understanding.SearchType = "hybrid"
+		understanding.HybridQueryType = response.SearchMetadata.HybridQueryType
+		understanding.HybridSearchWeight = response.SearchMetadata.HybridSearchWeight
+	default:
+		understanding.SearchType = "semantic"
+	}
+}
+
 // GetVectorSimilarProducts finds products similar to a given product using semantic similarity
 // This method now leverages AWS Bedrock Knowledge Base's automatic vectorization capabilities
 // instead of manually generating and passing vector embeddings
@@ -257,7 +582,7 @@ func (rs *RecommendationServiceV2) GetVectorSimilarProducts(ctx context.Context,
 
 	// Build comprehensive product description for semantic search
 	// The Knowledge Base will automatically convert this to vectors internally
-	comprehensiveProductText := rs.buildComprehensiveProductText(targetProduct)
+	comprehensiveProductText := rs.buildComprehensiveProductText(ctx, targetProduct)
 
 	// Create similarity query using product characteristics
 	similarityQuery := fmt.Sprintf("Find products similar to: %s", comprehensiveProductText)
@@ -269,8 +594,15 @@ func (rs *RecommendationServiceV2) GetVectorSimilarProducts(ctx context.Context,
 	filters := make(map[string]interface{})
 	filters["exclude_id"] = req.ProductID.String()
 
+	// Over-fetch so that the customer's dismissed products can be dropped
+	suppressions := rs.feedback.suppressionsFor(ctx, req.CustomerID)
+	searchLimit := req.Limit
+	if !suppressions.Empty() {
+		searchLimit *= 2
+	}
+
 	// Use enhanced semantic search which automatically handles vectorization
-	ragResponse, err := rs.rag.GetProductsWithSemanticSearch(ctx, similarityQuery, req.Limit, filters)
+	ragResponse, err := rs.rag.GetProductsWithSemanticSearch(ctx, similarityQuery, searchLimit, filters)
 	if err != nil {
 		return nil, fmt.Errorf("failed to perform semantic similarity search: %w", err)
 	}
@@ -299,6 +631,11 @@ func (rs *RecommendationServiceV2) GetVectorSimilarProducts(ctx context.Context,
 		}
 	}
 
+	similarProducts = suppressions.Filter(similarProducts)
+	if len(similarProducts) > req.Limit {
+		similarProducts = similarProducts[:req.Limit]
+	}
+
 	processingTime := time.Since(startTime).Milliseconds()
 
 	return &dto.VectorSimilarityResponse{
@@ -353,43 +690,55 @@ func (rs *RecommendationServiceV2) GetKnowledgeBasedRecommendations(ctx context.
 	}, nil
 }
 
-// GetRecommendationExplanation provides detailed explanation for a specific recommendation
+// GetRecommendationExplanation explains a served recommendation from what was recorded when it was served.
+// Returns nil if the customer was never served a recommendation with the given ID.
 func (rs *RecommendationServiceV2) GetRecommendationExplanation(ctx context.Context, recommendationID, customerID uuid.UUID) (*dto.RecommendationExplanationResponse, error) {
+	item, err := rs.repo.GetRecommendationItem(ctx, recommendationID, customerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get recommendation: %w", err)
+	}
+	if item == nil {
+		return nil, nil
+	}
+
 	// Get customer profile
 	profile, err := rs.GetCustomerProfile(ctx, customerID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get customer profile: %w", err)
 	}
 
-	// In a real implementation, you would retrieve the recommendation details from a database
-	// For now, we'll create a comprehensive explanation based on the customer profile
-
-	// Create AI prompt for explanation
-	prompt := rs.createExplanationPrompt(recommendationID, profile)
+	// Factor weights are computed deterministically; the LLM only writes prose over them
+	factors := rs.explainer.ExplainItem(item, profile, time.Now())
 
-	// Get AI-generated explanation
-	chatResponse, err := rs.chatService.GenerateResponse(ctx, prompt)
+	// Get AI-generated explanation, falling back to the reason given when the item was served
+	explanation := item.Product.Reason
+	chatResponse, err := generateAIResponse(ctx, rs.chatService, rs.createExplanationPrompt(item, profile, factors))
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate explanation: %w", err)
+		log.Printf("Warning: failed to generate explanation: %v", err)
+	} else {
+		explanation = chatResponse.Content
 	}
 
-	// Parse explanation factors
-	factors := rs.parseExplanationFactors(chatResponse.Content, profile)
-
-	// Get alternative options (simplified for this example)
-	alternatives, err := rs.repo.GetProductsByCategory(ctx, profile.PreferredCategories[0], 3)
+	// Alternatives are the other products served alongside the recommendation
+	alternatives := []dto.ProductRecommendationV2{}
+	served, err := rs.repo.GetRecommendationItemsByLog(ctx, item.RecommendationLogID)
 	if err != nil {
 		log.Printf("Warning: failed to get alternative products: %v", err)
-		alternatives = []dto.ProductRecommendationV2{}
+	}
+	for _, other := range served {
+		if other.ID != item.ID && len(alternatives) < 3 {
+			alternatives = append(alternatives, other.Product)
+		}
 	}
 
 	return &dto.RecommendationExplanationResponse{
 		RecommendationID:   recommendationID,
 		CustomerID:         customerID,
-		ProductID:          recommendationID, // Simplified assumption
-		Explanation:        chatResponse.Content,
+		ProductID:          item.ProductID,
+		Explanation:        explanation,
 		FactorsConsidered:  factors,
 		AlternativeOptions: alternatives,
+		Recommendation:     item,
 		GeneratedAt:        time.Now(),
 	}, nil
 }
@@ -406,12 +755,32 @@ func (rs *RecommendationServiceV2) GetTrendingProductsV2(ctx context.Context, re
 		req.TimeRange = "weekly"
 	}
 
+	// Over-fetch so that the customer's dismissed products can be dropped
+	suppressions := rs.feedback.suppressionsFor(ctx, req.CustomerID)
+	fetchLimit := req.Limit
+	if !suppressions.Empty() {
+		fetchLimit *= 2
+	}
+
 	// Get trending products
-	trendingProducts, err := rs.repo.GetTrendingProductsV2(ctx, req.CategoryID, req.TimeRange, req.Limit)
+	trendingProducts, err := rs.repo.GetTrendingProductsV2(ctx, req.CategoryID, req.TimeRange, fetchLimit)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get trending products: %w", err)
 	}
 
+	if !suppressions.Empty() {
+		kept := make([]dto.TrendingProductV2, 0, len(trendingProducts))
+		for _, product := range trendingProducts {
+			if !suppressions.Suppresses(product.ProductID, product.Brand, product.CategoryID) {
+				kept = append(kept, product)
+			}
+		}
+		trendingProducts = kept
+	}
+	if len(trendingProducts) > req.Limit {
+		trendingProducts = trendingProducts[:req.Limit]
+	}
+
 	var trendInsights *dto.TrendInsights
 	var marketAnalysis *dto.MarketAnalysis
 
@@ -420,7 +789,7 @@ func (rs *RecommendationServiceV2) GetTrendingProductsV2(ctx context.Context, re
 		// Generate basic trend insights for now
 		trendInsights = &dto.TrendInsights{
 			EmergingCategories:  []string{"Electronics", "Fashion"},
-			SeasonalFactors:     []string{"Holiday shopping", "Back to school"},
+			SeasonalFactors:     seasonalKeywords(rs.getSeasonalContext(ctx)),
 			MarketDrivers:       []string{"Consumer trends", "Price sensitivity"},
 		}
 
@@ -463,12 +832,83 @@ func (rs *RecommendationServiceV2) GetCustomerProfile(ctx context.Context, custo
 	}
 	profile.RecentActivities = activities
 
+	// Dismissals feed back into the profile as disliked brands and categories
+	if rs.feedback != nil {
+		if err := rs.feedback.ApplyNegativePreferences(ctx, profile); err != nil {
+			log.Printf("Warning: failed to apply negative feedback preferences: %v", err)
+		}
+	}
+
 	return profile, nil
 }
 
 // LogRecommendationInteraction logs customer interactions with recommendations for analytics
+// and rewards the strategy the bandit chose for them, if any
 func (rs *RecommendationServiceV2) LogRecommendationInteraction(ctx context.Context, analytics *dto.RecommendationAnalytics) error {
-	return rs.repo.LogRecommendationInteraction(ctx, analytics)
+	if err := rs.repo.LogRecommendationInteraction(ctx, analytics); err != nil {
+		return err
+	}
+
+	if rs.bandit != nil {
+		if err := rs.bandit.RecordInteraction(ctx, analytics); err != nil {
+			log.Printf("Warning: failed to update strategy bandit: %v", err)
+		}
+	}
+
+	if rs.impressions != nil {
+		if err := rs.impressions.RecordInteraction(ctx, analytics); err != nil {
+			log.Printf("Warning: failed to record impression clicks: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// assignExperiment returns the experiment variant for the request, or nil if none applies
+func (rs *RecommendationServiceV2) assignExperiment(ctx context.Context, req *dto.RecommendationRequestV2) *dto.ExperimentAssignment {
+	if rs.experiments == nil {
+		return nil
+	}
+
+	assignment, err := rs.experiments.Assign(ctx, BlendServiceV2, req.ContextType, req.CustomerID)
+	if err != nil {
+		log.Printf("Warning: failed to assign experiment: %v", err)
+		return nil
+	}
+
+	return assignment
+}
+
+// selectStrategy picks a recommendation type for an "auto" request.
+// Strategies that need inputs missing from the request are not considered.
+// Falls back to hybrid when no bandit is configured or selection fails.
+func (rs *RecommendationServiceV2) selectStrategy(ctx context.Context, req *dto.RecommendationRequestV2) *dto.StrategySelection {
+	arms := []string{"hybrid", "knowledge_based", "collaborative"}
+	if req.QueryText != "" {
+		arms = append(arms, "semantic")
+	}
+	if req.ProductID != nil {
+		arms = append(arms, "vector_search")
+	}
+	if rs.sessionSequence != nil && (req.BrowsingSessionID != nil || len(req.RecentProductIDs) > 0) {
+		arms = append(arms, "session_sequence")
+	}
+	if rs.regional != nil {
+		arms = append(arms, "regional")
+	}
+
+	fallback := &dto.StrategySelection{Policy: BanditPolicyFallback, Arm: "hybrid", Propensity: 1, Candidates: arms}
+	if rs.bandit == nil {
+		return fallback
+	}
+
+	selection, err := rs.bandit.SelectArm(ctx, BlendServiceV2, req.ContextType, arms)
+	if err != nil {
+		log.Printf("Warning: failed to select strategy: %v", err)
+		return fallback
+	}
+
+	return selection
 }
 
 // Private helper methods
@@ -494,7 +934,7 @@ func (rs *RecommendationServiceV2) generateSemanticRecommendations(ctx context.C
 		}
 
 		targetProduct := products[0]
-		comprehensiveProductText := rs.buildComprehensiveProductText(targetProduct)
+		comprehensiveProductText := rs.buildComprehensiveProductText(ctx, targetProduct)
 		queryText = fmt.Sprintf("Find products similar to: %s", comprehensiveProductText)
 		searchMethod = "semantic_similarity"
 
@@ -506,30 +946,50 @@ func (rs *RecommendationServiceV2) generateSemanticRecommendations(ctx context.C
 			return nil, nil, nil, fmt.Errorf("query_text is required for semantic recommendations")
 		}
 
-		queryText = req.QueryText
+		normalizedQuery := rs.queryNormalizer.Normalize(ctx, req.QueryText)
+		queryText = normalizedQuery.Normalized
 		searchMethod = "semantic_search"
 
 		// Analyze query for better understanding
 		var err error
-		queryUnderstanding, err = rs.analyzeQuery(ctx, req.QueryText)
+		queryUnderstanding, err = rs.analyzeQuery(ctx, normalizedQuery)
 		if err != nil {
 			log.Printf("Warning: failed to analyze query: %v", err)
 		}
 	}
 
 	// Build personalized filters based on customer profile
-	filters := rs.buildPersonalizedFilters(profile, req)
+	filters := rs.buildPersonalizedFilters(ctx, profile, req)
 
 	// For vector_search, exclude the target product
 	if req.RecommendationType == "vector_search" && req.ProductID != nil {
 		filters["exclude_id"] = req.ProductID.String()
 	}
 
-	// Perform semantic search using RAG Knowledge Base (handles both semantic and vector similarity)
-	ragResponse, err := rs.rag.GetProductsWithSemanticSearch(ctx, queryText, req.Limit*2, filters)
+	// Perform semantic search using RAG Knowledge Base (handles both semantic and vector similarity).
+	// Query searches may instead be served lexically, by request or as a fallback.
+	var ragResponse *RAGSemanticSearchResponse
+	var err error
+	if searchMethod == "semantic_search" {
+		searchType := ""
+		var hybridWeights map[string]float64
+		if req.VectorSearchConfig != nil {
+			searchType = req.VectorSearchConfig.SearchType
+			hybridWeights = req.VectorSearchConfig.HybridWeights
+		}
+		ragResponse, err = rs.searchProducts(ctx, queryText, req.Limit*2, filters, searchType, hybridWeights)
+	} else {
+		ragResponse, err = rs.rag.GetProductsWithSemanticSearch(ctx, queryText, req.Limit*2, filters)
+	}
 	if err != nil {
 		return nil, nil, nil, fmt.Errorf("failed to perform semantic search: %w", err)
 	}
+	if isKeywordSearch(ragResponse) {
+		searchMethod = "keyword_search"
+	} else if isHybridSearch(ragResponse) {
+		searchMethod = "hybrid_search"
+	}
+	reportSearchType(queryUnderstanding, ragResponse)
 
 	// Convert RAG results to ProductRecommendationV2
 	results, err := rs.convertRAGResultsToProducts(ctx, ragResponse.Results, searchMethod)
@@ -595,74 +1055,304 @@ func (rs *RecommendationServiceV2) generateCollaborativeRecommendations(ctx cont
 	return uniqueProducts, nil
 }
 
-func (rs *RecommendationServiceV2) generateHybridRecommendations(ctx context.Context, req *dto.RecommendationRequestV2, profile *dto.CustomerProfile, metrics *dto.PerformanceMetrics) ([]dto.ProductRecommendationV2, *dto.SemanticInsights, *dto.QueryUnderstanding, error) {
-	var allRecommendations []dto.ProductRecommendationV2
+// generateSessionSequenceRecommendations predicts what the customer will view next from the
+// recent products of the current session, using product transitions with category back-off.
+// The session is taken from recent_product_ids, then browsing_session_id, then product_id.
+func (rs *RecommendationServiceV2) generateSessionSequenceRecommendations(ctx context.Context, req *dto.RecommendationRequestV2) ([]dto.ProductRecommendationV2, error) {
+	if rs.sessionSequence == nil {
+		return nil, fmt.Errorf("session sequence recommender is not configured")
+	}
+
+	recentIDs := req.RecentProductIDs
+	if len(recentIDs) == 0 && req.BrowsingSessionID != nil {
+		sessionProducts, err := rs.sessionSequence.SessionProducts(ctx, req.CustomerID, *req.BrowsingSessionID)
+		if err != nil {
+			return nil, err
+		}
+		recentIDs = sessionProducts
+	}
+	if len(recentIDs) == 0 && req.ProductID != nil {
+		recentIDs = []uuid.UUID{*req.ProductID}
+	}
+	if len(recentIDs) == 0 {
+		return nil, fmt.Errorf("recent_product_ids, browsing_session_id or product_id is required for session_sequence recommendations")
+	}
+	if history := rs.sessionSequence.HistoryLength(); len(recentIDs) > history {
+		recentIDs = recentIDs[len(recentIDs)-history:]
+	}
+
+	recentProducts, err := rs.repo.GetProductsByIDs(ctx, recentIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session products: %w", err)
+	}
+	recentByID := make(map[uuid.UUID]dto.ProductRecommendationV2, len(recentProducts))
+	for _, product := range recentProducts {
+		recentByID[product.ProductID] = product
+	}
+
+	items := make([]SessionItem, 0, len(recentIDs))
+	for _, productID := range recentIDs {
+		items = append(items, SessionItem{ProductID: productID, CategoryID: recentByID[productID].CategoryID})
+	}
+
+	// Over-fetch so filters and post-ranking stages still have enough candidates
+	predictions, err := rs.sessionSequence.Predict(ctx, items, req.Limit*3)
+	if err != nil {
+		return nil, fmt.Errorf("failed to predict next products: %w", err)
+	}
+	if len(predictions) == 0 {
+		return []dto.ProductRecommendationV2{}, nil
+	}
+
+	predictedIDs := make([]uuid.UUID, len(predictions))
+	for i, prediction := range predictions {
+		predictedIDs[i] = prediction.ProductID
+	}
+	products, err := rs.repo.GetProductsByIDs(ctx, predictedIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get predicted products: %w", err)
+	}
+	productsByID := make(map[uuid.UUID]dto.ProductRecommendationV2, len(products))
+	for _, product := range products {
+		productsByID[product.ProductID] = product
+	}
+
+	maxScore := predictions[0].Score
+	recommendations := make([]dto.ProductRecommendationV2, 0, len(predictions))
+	for _, prediction := range predictions {
+		rec, ok := productsByID[prediction.ProductID]
+		if !ok {
+			continue
+		}
+
+		after := recentByID[prediction.AfterItemID].Name
+		explanation := fmt.Sprintf("Often viewed next after %s", after)
+		if prediction.Source == SequenceSourceCategory {
+			explanation = fmt.Sprintf("Popular next step from the category of %s", after)
+		}
+
+		rec.ConfidenceScore = relativeScore(prediction.Score, maxScore)
+		rec.Reason = explanation
+		rec.RelevanceContext = append(rec.RelevanceContext, dto.RelevanceContext{
+			ContextType: "browsing_behavior",
+			Explanation: explanation,
+			Confidence:  rec.ConfidenceScore,
+			SourceData:  fmt.Sprintf("source=%s after=%s score=%.4f", prediction.Source, prediction.AfterItemID, prediction.Score),
+		})
+		recommendations = append(recommendations, rec)
+	}
+
+	return recommendations, nil
+}
+
+// customerLocation returns the customer's location for regional strategies, or nil when unknown
+func (rs *RecommendationServiceV2) customerLocation(ctx context.Context, customerID uuid.UUID) *dto.CustomerLocation {
+	if rs.regional == nil {
+		return nil
+	}
+
+	location, err := rs.regional.CustomerLocation(ctx, customerID)
+	if err != nil {
+		log.Printf("Warning: failed to resolve customer location: %v", err)
+		return nil
+	}
+
+	return location
+}
+
+// generateRegionalRecommendations returns the products most popular among customers in the same
+// prefecture, favouring regional specialties. Customers without a known location get no results.
+func (rs *RecommendationServiceV2) generateRegionalRecommendations(ctx context.Context, req *dto.RecommendationRequestV2, location *dto.CustomerLocation) ([]dto.ProductRecommendationV2, error) {
+	if rs.regional == nil {
+		return nil, fmt.Errorf("regional popularity is not configured")
+	}
+	if location == nil {
+		return []dto.ProductRecommendationV2{}, nil
+	}
+
+	exclude := make(map[uuid.UUID]bool)
+	if req.ProductID != nil {
+		exclude[*req.ProductID] = true
+	}
+
+	// Over-fetch so filters and post-ranking stages still have enough candidates
+	popular, err := rs.regional.PopularNear(ctx, location.Prefecture, req.Limit*3, exclude)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get regional popularity: %w", err)
+	}
+	if len(popular) == 0 {
+		return []dto.ProductRecommendationV2{}, nil
+	}
+
+	productIDs := make([]uuid.UUID, len(popular))
+	for i, p := range popular {
+		productIDs[i] = p.ProductID
+	}
+	products, err := rs.repo.GetProductsByIDs(ctx, productIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get regional products: %w", err)
+	}
+	productsByID := make(map[uuid.UUID]dto.ProductRecommendationV2, len(products))
+	for _, product := range products {
+		productsByID[product.ProductID] = product
+	}
+
+	maxScore := popular[0].Score
+	recommendations := make([]dto.ProductRecommendationV2, 0, len(popular))
+	for _, p := range popular {
+		rec, ok := productsByID[p.ProductID]
+		if !ok || (req.CategoryID != nil && rec.CategoryID != *req.CategoryID) {
+			continue
+		}
+
+		explanation := fmt.Sprintf("Popular with customers in %s", location.Prefecture)
+		if p.Lift >= 1.5 {
+			explanation = fmt.Sprintf("Regional favourite in %s (%.1fx the national rate)", location.Prefecture, p.Lift)
+		}
+
+		rec.ConfidenceScore = relativeScore(p.Score, maxScore)
+		rec.Reason = explanation
+		rec.RelevanceContext = append(rec.RelevanceContext, dto.RelevanceContext{
+			ContextType: "regional",
+			Explanation: explanation,
+			Confidence:  rec.ConfidenceScore,
+			SourceData:  fmt.Sprintf("prefecture=%s source=%s units=%.0f customers=%d lift=%.2f", location.Prefecture, location.Source, p.Units, p.Customers, p.Lift),
+		})
+		recommendations = append(recommendations, rec)
+	}
+
+	return recommendations, nil
+}
+
+// generateHybridRecommendations combines semantic, vector, knowledge base and collaborative strategies
+// using the resolved blend. Strategy lists are merged with rank fusion, evidence from several strategies
+// is combined per product, and each contributing strategy is recorded in RelevanceContext.
+// Strategies with a zero weight are skipped entirely.
+func (rs *RecommendationServiceV2) generateHybridRecommendations(ctx context.Context, req *dto.RecommendationRequestV2, profile *dto.CustomerProfile, blend *BlendResult, metrics *dto.PerformanceMetrics) ([]dto.ProductRecommendationV2, *dto.SemanticInsights, *dto.QueryUnderstanding, error) {
+	var lists []RankedList
+	products := newCandidatePool(func(rec dto.ProductRecommendationV2) (uuid.UUID, float64) {
+		return rec.ProductID, rec.ConfidenceScore
+	}, mergeRecommendationEvidenceV2)
 	var semanticInsights *dto.SemanticInsights
 	var queryUnderstanding *dto.QueryUnderstanding
-	var err error
 
-	// 1. Semantic search (40% weight) if query provided
-	if req.QueryText != "" {
+	// 1. Semantic search if query provided
+	if weight := blend.Weights[StrategySemantic]; weight > 0 && req.QueryText != "" {
 		// Create a copy of the request for semantic search
 		semanticReq := *req
 		semanticReq.RecommendationType = "semantic"
 		semanticRecs, insights, understanding, err := rs.generateSemanticRecommendations(ctx, &semanticReq, profile, metrics)
 		if err == nil {
-			for i := range semanticRecs {
-				semanticRecs[i].ConfidenceScore = semanticRecs[i].ConfidenceScore * 0.4
-			}
-			allRecommendations = append(allRecommendations, semanticRecs...)
+			lists = append(lists, products.rankedList(StrategySemantic, weight, semanticRecs))
 			semanticInsights = insights
 			queryUnderstanding = understanding
 		}
 	}
 
-	// 2. Vector search (30% weight) if product ID provided
-	if req.ProductID != nil {
+	// 2. Vector search if product ID provided
+	if weight := blend.Weights[StrategyVectorSearch]; weight > 0 && req.ProductID != nil {
 		// Create a copy of the request for vector search
 		vectorReq := *req
 		vectorReq.RecommendationType = "vector_search"
 		vectorRecs, _, _, err := rs.generateSemanticRecommendations(ctx, &vectorReq, profile, metrics)
 		if err == nil {
-			for i := range vectorRecs {
-				vectorRecs[i].ConfidenceScore = vectorRecs[i].ConfidenceScore * 0.3
-			}
-			allRecommendations = append(allRecommendations, vectorRecs...)
+			lists = append(lists, products.rankedList(StrategyVectorSearch, weight, vectorRecs))
 		}
 	}
 
-	// 3. Knowledge-based recommendations (20% weight)
-	kbRecs, err := rs.generateKnowledgeBasedRecommendations(ctx, req, profile, metrics)
-	if err == nil {
-		for i := range kbRecs {
-			kbRecs[i].ConfidenceScore = kbRecs[i].ConfidenceScore * 0.2
+	// 3. Knowledge-based recommendations
+	if weight := blend.Weights[StrategyKnowledgeBased]; weight > 0 {
+		kbRecs, err := rs.generateKnowledgeBasedRecommendations(ctx, req, profile, metrics)
+		if err == nil {
+			lists = append(lists, products.rankedList(StrategyKnowledgeBased, weight, kbRecs))
 		}
-		allRecommendations = append(allRecommendations, kbRecs...)
 	}
 
-	// 4. Collaborative recommendations (10% weight)
-	collabRecs, err := rs.generateCollaborativeRecommendations(ctx, req, profile)
-	if err == nil {
-		for i := range collabRecs {
-			collabRecs[i].ConfidenceScore = collabRecs[i].ConfidenceScore * 0.1
+	// 4. Collaborative recommendations
+	if weight := blend.Weights[StrategyCollaborative]; weight > 0 {
+		collabRecs, err := rs.generateCollaborativeRecommendations(ctx, req, profile)
+		if err == nil {
+			lists = append(lists, products.rankedList(StrategyCollaborative, weight, collabRecs))
 		}
-		allRecommendations = append(allRecommendations, collabRecs...)
 	}
 
-	// Remove duplicates and sort by confidence score
-	uniqueProducts := rs.removeDuplicateProductsV2(allRecommendations)
-	sort.Slice(uniqueProducts, func(i, j int) bool {
-		return uniqueProducts[i].ConfidenceScore > uniqueProducts[j].ConfidenceScore
-	})
+	// 5. Next-item predictions from the current browsing session
+	if weight := blend.Weights[StrategySessionSequence]; weight > 0 && rs.sessionSequence != nil {
+		sessionRecs, err := rs.generateSessionSequenceRecommendations(ctx, req)
+		if err == nil {
+			lists = append(lists, products.rankedList(StrategySessionSequence, weight, sessionRecs))
+		}
+	}
 
-	if len(uniqueProducts) > req.Limit {
-		uniqueProducts = uniqueProducts[:req.Limit]
+	// 6. Products popular in the customer's prefecture
+	var location *dto.CustomerLocation
+	if rs.regional != nil {
+		location = rs.customerLocation(ctx, req.CustomerID)
+	}
+	if weight := blend.Weights[StrategyRegional]; weight > 0 && location != nil {
+		regionalRecs, err := rs.generateRegionalRecommendations(ctx, req, location)
+		if err == nil {
+			lists = append(lists, products.rankedList(StrategyRegional, weight, regionalRecs))
+		}
+	}
+
+	fused, err := FuseRankedLists(blend.FusionMethod, lists)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to fuse strategy results: %w", err)
+	}
+
+	recommendations := make([]dto.ProductRecommendationV2, 0, len(fused))
+	for _, candidate := range fused {
+		rec := products.product(candidate.ProductID)
+		rec.ConfidenceScore = candidate.Score
+		for _, contribution := range candidate.Contributions {
+			rec.RelevanceContext = append(rec.RelevanceContext, dto.RelevanceContext{
+				ContextType: "strategy_" + contribution.Strategy,
+				Explanation: fmt.Sprintf("Ranked #%d by %s strategy", contribution.Rank, contribution.Strategy),
+				Confidence:  contribution.Contributed,
+				SourceData:  fmt.Sprintf("fusion=%s raw_score=%.4f", blend.FusionMethod, contribution.RawScore),
+			})
+		}
+		recommendations = append(recommendations, rec)
 	}
 
-	return uniqueProducts, semanticInsights, queryUnderstanding, nil
+	// Boost products and categories that sell notably better in the customer's prefecture
+	if location != nil {
+		boosted, err := rs.regional.ApplyLocationBoost(ctx, location, recommendations)
+		if err != nil {
+			log.Printf("Warning: failed to apply location boost: %v", err)
+		} else {
+			recommendations = boosted
+		}
+	}
+
+	return recommendations, semanticInsights, queryUnderstanding, nil
 }
 
-func (rs *RecommendationServiceV2) analyzeQuery(ctx context.Context, query string) (*dto.QueryUnderstanding, error) {
+// relativeScore scales a strategy score by the best score of its list. When the best score is not
+// positive, as with empty or degenerate model scores, every product counts as fully confident
+// instead of producing NaN or infinite confidences.
+func relativeScore(score, maxScore float64) float64 {
+	if maxScore <= 0 || math.IsInf(maxScore, 0) || math.IsNaN(score) {
+		return 1
+	}
+	return score / maxScore
+}
+
+// mergeRecommendationEvidenceV2 combines the reasons, relevance context and similarity of another
+// strategy's copy of a product
+func mergeRecommendationEvidenceV2(kept, other dto.ProductRecommendationV2) dto.ProductRecommendationV2 {
+	kept.Reason = mergeReasons(kept.Reason, other.Reason)
+	kept.RelevanceContext = append(append([]dto.RelevanceContext(nil), kept.RelevanceContext...), other.RelevanceContext...)
+	kept.SimilarityScore = math.Max(kept.SimilarityScore, other.SimilarityScore)
+	if kept.AIInsights == nil {
+		kept.AIInsights = other.AIInsights
+	}
+	return kept
+}
+
+// analyzeQuery asks the model for the intent and entities of a normalized query
+func (rs *RecommendationServiceV2) analyzeQuery(ctx context.Context, normalizedQuery *dto.NormalizedQuery) (*dto.QueryUnderstanding, error) {
 	// Create AI prompt for query analysis
 	prompt := fmt.Sprintf(`
 Analyze the following user query for e-commerce product search:
@@ -683,9 +1373,9 @@ Please provide a JSON response with the following structure:
   "complexity": "string (simple, medium, complex)",
   "required_context": ["string array of required context"]
 }
-`, query)
+`, normalizedQuery.Normalized)
 
-	chatResponse, err := rs.chatService.GenerateResponse(ctx, prompt)
+	chatResponse, err := generateAIResponse(ctx, rs.chatService, prompt)
 	if err != nil {
 		return nil, err
 	}
@@ -695,21 +1385,20 @@ Please provide a JSON response with the following structure:
 	if err != nil {
 		// Fallback to basic analysis
 		understanding = dto.QueryUnderstanding{
-			OriginalQuery:  query,
-			ProcessedQuery: strings.ToLower(query),
-			Intent:         "product_search",
-			Sentiment:      "neutral",
-			Complexity:     "simple",
+			Intent:     "product_search",
+			Sentiment:  "neutral",
+			Complexity: "simple",
 		}
 	}
 
-	understanding.OriginalQuery = query
-	understanding.ProcessedQuery = strings.ToLower(query)
+	understanding.OriginalQuery = normalizedQuery.Original
+	understanding.ProcessedQuery = normalizedQuery.Normalized
+	understanding.QueryRewrites = normalizedQuery.Rewrites
 
 	return &understanding, nil
 }
 
-func (rs *RecommendationServiceV2) buildPersonalizedFilters(profile *dto.CustomerProfile, req *dto.RecommendationRequestV2) map[string]interface{} {
+func (rs *RecommendationServiceV2) buildPersonalizedFilters(ctx context.Context, profile *dto.CustomerProfile, req *dto.RecommendationRequestV2) map[string]interface{} {
 	filters := make(map[string]interface{})
 
 	// Apply category filters - enhanced with multiple categories support
@@ -738,13 +1427,13 @@ func (rs *RecommendationServiceV2) buildPersonalizedFilters(profile *dto.Custome
 	}
 
 	// Enhanced metadata filters based on customer profile and CSV batch metadata
-	rs.applyEnhancedMetadataFilters(filters, profile, req)
+	rs.applyEnhancedMetadataFilters(ctx, filters, profile, req)
 
 	return filters
 }
 
 // applyEnhancedMetadataFilters applies advanced metadata filtering based on CSV batch metadata structure
-func (rs *RecommendationServiceV2) applyEnhancedMetadataFilters(filters map[string]interface{}, profile *dto.CustomerProfile, req *dto.RecommendationRequestV2) {
+func (rs *RecommendationServiceV2) applyEnhancedMetadataFilters(ctx context.Context, filters map[string]interface{}, profile *dto.CustomerProfile, req *dto.RecommendationRequestV2) {
 	// Rating-based filtering for quality-conscious customers
 	if profile.IsPremium || profile.OrderCount > 5 {
 		filters["rating_min"] = 4.0      // Premium customers prefer high-rated products
@@ -784,7 +1473,7 @@ func (rs *RecommendationServiceV2) applyEnhancedMetadataFilters(filters map[stri
 	}
 
 	// Seasonal filtering
-	seasonalTags := rs.getCurrentSeasonalTags()
+	seasonalTags := rs.getCurrentSeasonalTags(ctx)
 	if len(seasonalTags) > 0 {
 		filters["seasonal_boost"] = seasonalTags
 	}
@@ -840,23 +1529,47 @@ func (rs *RecommendationServiceV2) applyContextSpecificFilters(filters map[strin
 	}
 }
 
-// getCurrentSeasonalTags returns relevant seasonal tags for filtering
-func (rs *RecommendationServiceV2) getCurrentSeasonalTags() []string {
-	now := time.Now()
-	month := now.Month()
+// getCurrentSeasonalTags returns the tags in seasonal demand for filtering, including the peak tags
+// of active events; no tags are returned when seasonality has not been learned
+func (rs *RecommendationServiceV2) getCurrentSeasonalTags(ctx context.Context) []string {
+	seasonal := rs.getSeasonalContext(ctx)
+	if seasonal == nil {
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	var tags []string
+	for _, event := range seasonal.ActiveEvents {
+		for _, tag := range event.PeakTags {
+			if !seen[tag.Key] {
+				seen[tag.Key] = true
+				tags = append(tags, tag.Key)
+			}
+		}
+	}
+	for _, tag := range seasonal.RisingTags {
+		if !seen[tag.Key] {
+			seen[tag.Key] = true
+			tags = append(tags, tag.Key)
+		}
+	}
 
-	switch {
-	case month >= 12 || month <= 2:
-		return []string{"winter", "holiday", "gift", "warm", "indoor"}
-	case month >= 3 && month <= 5:
-		return []string{"spring", "outdoor", "fresh", "renewal", "gardening"}
-	case month >= 6 && month <= 8:
-		return []string{"summer", "vacation", "outdoor", "travel", "cooling"}
-	case month >= 9 && month <= 11:
-		return []string{"autumn", "back-to-school", "cozy", "preparation", "harvest"}
-	default:
-		return []string{}
+	return tags
+}
+
+// getSeasonalContext returns the learned seasonal context, or nil when unavailable
+func (rs *RecommendationServiceV2) getSeasonalContext(ctx context.Context) *dto.SeasonalContext {
+	if rs.seasonality == nil {
+		return nil
+	}
+
+	seasonal, err := rs.seasonality.Context(ctx, time.Now())
+	if err != nil {
+		log.Printf("Warning: failed to get seasonal context: %v", err)
+		return nil
 	}
+
+	return seasonal
 }
 
 // extractRecentCategoryIDs extracts category IDs from recent purchase history
@@ -1029,7 +1742,7 @@ func (rs *RecommendationServiceV2) extractRecommendationsFromKB(ctx context.Cont
 		// Use the first KB result as a search query (extract key terms)
 		searchQuery := rs.extractKeyTermsFromText(kbResponse.Results[0].Content)
 		if searchQuery != "" {
-			filters := rs.buildPersonalizedFilters(profile, &dto.RecommendationRequestV2{})
+			filters := rs.buildPersonalizedFilters(ctx, profile, &dto.RecommendationRequestV2{})
 			semanticResults, err := rs.rag.GetProductsWithSemanticSearch(ctx, searchQuery, limit, filters)
 			if err == nil {
 				// Extract product IDs from semantic search results
@@ -1123,7 +1836,7 @@ func (rs *RecommendationServiceV2) enhanceWithAdvancedAIExplanations(
 	}
 
 	// 2. Generate AI response
-	response, err := rs.chatService.GenerateResponse(ctx, prompt)
+	response, err := generateAIResponse(ctx, rs.chatService, prompt)
 	if err != nil {
 		return recommendations, fmt.Errorf("failed to generate AI response: %w", err)
 	}
@@ -1161,6 +1874,8 @@ func (rs *RecommendationServiceV2) parseAdvancedAIResponse(
 		return rs.parseHomepageRecommendations(jsonContent, originalRecommendations)
 	case "product_page", "product_detail":
 		return rs.parseProductDetailRecommendations(jsonContent, originalRecommendations)
+	case "gift":
+		return rs.parseGiftRecommendations(jsonContent, originalRecommendations)
 	default:
 		return rs.parseDefaultRecommendations(jsonContent, originalRecommendations)
 	}
@@ -1335,23 +2050,9 @@ func (rs *RecommendationServiceV2) validateAdvancedRecommendations(
 	return validatedRecommendations
 }
 
-// getCurrentSeasonalContext returns seasonal keywords based on current time
-func (rs *RecommendationServiceV2) getCurrentSeasonalContext() string {
-	now := time.Now()
-	month := now.Month()
-
-	switch {
-	case month >= 12 || month <= 2:
-		return "winter holiday-season cold-weather gift-giving"
-	case month >= 3 && month <= 5:
-		return "spring renewal fresh-start outdoor-activities"
-	case month >= 6 && month <= 8:
-		return "summer vacation hot-weather outdoor-recreation"
-	case month >= 9 && month <= 11:
-		return "autumn back-to-school harvest-season preparation"
-	default:
-		return ""
-	}
+// getCurrentSeasonalContext returns active events and categories/tags in seasonal demand as keywords
+func (rs *RecommendationServiceV2) getCurrentSeasonalContext(ctx context.Context) string {
+	return strings.Join(seasonalKeywords(rs.getSeasonalContext(ctx)), " ")
 }
 
 // enhanceWithAIExplanations enhances recommendations using the new prompt generation system
@@ -1374,7 +2075,7 @@ func (rs *RecommendationServiceV2) enhanceWithAIExplanations(
 	}
 
 	// 2. Generate AI response
-	response, err := rs.chatService.GenerateResponse(ctx, prompt)
+	response, err := generateAIResponse(ctx, rs.chatService, prompt)
 	if err != nil {
 		return recommendations, fmt.Errorf("failed to generate AI response: %w", err)
 	}
@@ -1504,10 +2205,37 @@ func (rs *RecommendationServiceV2) parseAIEnhancementsV2(content string, recomme
 	return enhanced, nil
 }
 
-func (rs *RecommendationServiceV2) createExplanationPrompt(recommendationID uuid.UUID, profile *dto.CustomerProfile) string {
-	return fmt.Sprintf(`
-Provide a detailed explanation for why product ID %s was recommended to this customer:
+func (rs *RecommendationServiceV2) createExplanationPrompt(item *dto.RecommendationItem, profile *dto.CustomerProfile, factors []dto.ExplanationFactor) string {
+	var signals strings.Builder
+	for _, rc := range item.Product.RelevanceContext {
+		signals.WriteString(fmt.Sprintf("- %s: %s\n", rc.ContextType, rc.Explanation))
+	}
+
+	var attributions strings.Builder
+	for _, factor := range factors {
+		attributions.WriteString(fmt.Sprintf("- %s: %s, weight %.0f%% (%s)\n", factor.Factor, factor.Impact, factor.Weight*100, factor.Description))
+	}
 
+	return fmt.Sprintf(`
+Provide a detailed explanation for why this product was recommended to this customer:
+
+Product:
+- Name: %s
+- Brand: %s
+- Category: %s
+- Price: %.2f
+
+How it was recommended:
+- Recommendation Type: %s (context: %s)
+- Strategies: %v
+- Position: %d (rank %d before re-ranking)
+- Score: %.3f (%.3f before re-ranking)
+- Query: %s
+
+Recorded Signals:
+%s
+Factor Attribution (computed from the scoring inputs):
+%s
 Customer Profile:
 - Total Spent: %.2f
 - Order Count: %d
@@ -1518,12 +2246,26 @@ Customer Profile:
 Please explain:
 1. Why this product matches their preferences
 2. How it relates to their purchase history
-3. What specific factors influenced this recommendation
+3. What specific factors influenced this recommendation, following the factor attribution
 4. The confidence level of this recommendation
 
-Provide a comprehensive, personalized explanation.
+Only refer to the signals and factors above. Do not invent factors, weights or scores and do not
+change their order of importance. Respond with prose only.
 `,
-		recommendationID.String(),
+		item.Product.Name,
+		item.Product.Brand,
+		item.Product.CategoryName,
+		item.Product.Price,
+		item.RecommendationType,
+		item.ContextType,
+		item.Strategies,
+		item.Position,
+		item.ScoreBreakdown.RetrievalRank,
+		item.ScoreBreakdown.FinalScore,
+		item.ScoreBreakdown.RetrievalScore,
+		item.Filters.QueryText,
+		signals.String(),
+		attributions.String(),
 		profile.TotalSpent,
 		profile.OrderCount,
 		profile.PreferredCategories,
@@ -1532,49 +2274,6 @@ Provide a comprehensive, personalized explanation.
 	)
 }
 
-func (rs *RecommendationServiceV2) parseExplanationFactors(content string, profile *dto.CustomerProfile) []dto.ExplanationFactor {
-	// Simplified implementation - in reality, you would parse the AI response more sophisticatedly
-	factors := []dto.ExplanationFactor{
-		{
-			Factor:      "Purchase History",
-			Weight:      0.3,
-			Impact:      "positive",
-			Description: "Based on previous purchases in similar categories",
-			Confidence:  0.8,
-		},
-		{
-			Factor:      "Category Preference",
-			Weight:      0.25,
-			Impact:      "positive",
-			Description: "Matches preferred product categories",
-			Confidence:  0.9,
-		},
-		{
-			Factor:      "Price Range",
-			Weight:      0.2,
-			Impact:      "positive",
-			Description: "Within typical spending range",
-			Confidence:  0.7,
-		},
-		{
-			Factor:      "Brand Affinity",
-			Weight:      0.15,
-			Impact:      "positive",
-			Description: "From preferred or similar brands",
-			Confidence:  0.6,
-		},
-		{
-			Factor:      "Seasonal Trends",
-			Weight:      0.1,
-			Impact:      "neutral",
-			Description: "Trending product for current season",
-			Confidence:  0.5,
-		},
-	}
-
-	return factors
-}
-
 func (rs *RecommendationServiceV2) formatRecommendationsForAIV2(recommendations []dto.ProductRecommendationV2) string {
 	result := ""
 	for _, rec := range recommendations {
@@ -1626,7 +2325,7 @@ If no valid product IDs are found, return: []
 `, content)
 
 	// Use chat service to analyze content
-	chatResponse, err := rs.chatService.GenerateResponse(ctx, prompt)
+	chatResponse, err := generateAIResponse(ctx, rs.chatService, prompt)
 	if err != nil {
 		log.Printf("Warning: failed to extract product IDs from KB content: %v", err)
 		return []uuid.UUID{}
@@ -1845,8 +2544,10 @@ func (rs *RecommendationServiceV2) convertRAGResultsToProducts(ctx context.Conte
 				fullProduct.Reason = "Similar to your selected product based on vector analysis"
 			case "semantic_search":
 				fullProduct.Reason = "Matches your search query based on semantic understanding"
+			case "keyword_search":
+				fullProduct.Reason = "Matches the keywords in your search query"
 			case "hybrid_search":
-				fullProduct.Reason = "Recommended based on both similarity and semantic relevance"
+				fullProduct.Reason = "Matches your search query by both keywords and semantic understanding"
 			default:
 				fullProduct.Reason = fmt.Sprintf("Recommended using %s", searchMethod)
 			}
@@ -1859,7 +2560,7 @@ func (rs *RecommendationServiceV2) convertRAGResultsToProducts(ctx context.Conte
 }
 
 // buildComprehensiveProductText creates a comprehensive text representation of a product for vector embedding
-func (rs *RecommendationServiceV2) buildComprehensiveProductText(product dto.ProductRecommendationV2) string {
+func (rs *RecommendationServiceV2) buildComprehensiveProductText(ctx context.Context, product dto.ProductRecommendationV2) string {
 	var textComponents []string
 
 	// Primary product information (highest weight)
@@ -1940,8 +2641,8 @@ func (rs *RecommendationServiceV2) buildComprehensiveProductText(product dto.Pro
 		}
 	}
 
-	// Add seasonal context based on current time
-	seasonalContext := rs.getCurrentSeasonalContext()
+	// Add learned seasonal context
+	seasonalContext := rs.getCurrentSeasonalContext(ctx)
 	if seasonalContext != "" {
 		textComponents = append(textComponents, fmt.Sprintf("Seasonal Context: %s", seasonalContext))
 	}
@@ -0,0 +1,317 @@
+package service
+
+import (
+	"ec-recommend/internal/dto"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// RecommendationTuning holds file-based tuning parameters for the recommendation services.
+// It allows ranking behaviour to be adjusted without redeploying the application.
+type RecommendationTuning struct {
+	// BlendProfiles defines strategy weights per service, context type and customer segment
+	BlendProfiles []BlendProfile `json:"blend_profiles,omitempty"`
+
+	// Diversity configures MMR re-ranking and per-context brand/category caps
+	Diversity DiversityTuning `json:"diversity"`
+
+	// LearningToRank configures candidate feature logging and the learned reranker (V2)
+	LearningToRank LearningToRankTuning `json:"learning_to_rank"`
+
+	// Bandit configures Thompson sampling for the "auto" recommendation type
+	Bandit BanditTuning `json:"bandit"`
+
+	// SessionSequence configures the session-sequence next-item recommender (V2)
+	SessionSequence SessionSequenceTuning `json:"session_sequence"`
+
+	// Replenishment configures repurchase interval estimation for consumable products
+	Replenishment ReplenishmentTuning `json:"replenishment"`
+
+	// PriceSensitivity configures price-sensitivity-aware ranking
+	PriceSensitivity PriceSensitivityTuning `json:"price_sensitivity"`
+
+	// Bundle configures the budget-constrained bundle builder
+	Bundle BundleTuning `json:"bundle"`
+
+	// RegionalPopularity configures per-prefecture popularity and the hybrid location boost (V2)
+	RegionalPopularity RegionalPopularityTuning `json:"regional_popularity"`
+
+	// Seasonality configures seasonal demand indices learned from order history
+	Seasonality SeasonalityTuning `json:"seasonality"`
+
+	// Popularity configures the time-decayed popularity_score recomputation job
+	Popularity PopularityTuning `json:"popularity"`
+
+	// Feedback configures suppression periods and negative preferences from "not interested" dismissals
+	Feedback FeedbackTuning `json:"feedback"`
+
+	// FrequencyCap configures per-context demotion of products shown repeatedly without a click
+	FrequencyCap FrequencyCapTuning `json:"frequency_cap"`
+
+	// Explainer configures the additive attribution behind explanation factor weights (V2)
+	Explainer ExplainerTuning `json:"explainer"`
+
+	// Suggest configures search box completions
+	Suggest SuggestTuning `json:"suggest"`
+
+	// QueryNormalization configures spelling correction and the search synonym dictionary
+	QueryNormalization QueryNormalizationTuning `json:"query_normalization"`
+
+	// LexicalSearch configures BM25 keyword search over the catalog and the knowledge base fallback (V2)
+	LexicalSearch LexicalSearchTuning `json:"lexical_search"`
+
+	// HybridSearch configures the blend of lexical and vector search for hybrid searches (V2)
+	HybridSearch HybridSearchTuning `json:"hybrid_search"`
+
+	// Experiments defines A/B experiments in addition to those stored in the database
+	Experiments []dto.Experiment `json:"experiments,omitempty"`
+
+	// PromptTemplates registers alternative prompt template versions for experiments (V2)
+	PromptTemplates []PromptTemplate `json:"prompt_templates,omitempty"`
+}
+
+// DefaultRecommendationTuning returns the tuning used when no tuning file is configured
+func DefaultRecommendationTuning() *RecommendationTuning {
+	return &RecommendationTuning{
+		// Diversity re-ranking is opt-in: without a configured, context or request diversity the
+		// ranked order is kept
+		LearningToRank: LearningToRankTuning{
+			LogFeatures: true,
+		},
+		Bandit: BanditTuning{
+			PriorAlpha:        1,
+			PriorBeta:         1,
+			ClickReward:       0.5,
+			PurchaseReward:    1,
+			PropensitySamples: 1000,
+		},
+		SessionSequence: SessionSequenceTuning{
+			LookbackDays:               90,
+			RefreshMinutes:             60,
+			HistoryLength:              3,
+			HistoryDecay:               0.5,
+			MinSupport:                 3,
+			BackoffWeight:              0.3,
+			BackoffProductsPerCategory: 5,
+		},
+		Replenishment:    DefaultReplenishmentTuning(),
+		PriceSensitivity: DefaultPriceSensitivityTuning(),
+		Bundle: BundleTuning{
+			DefaultMaxItems:       3,
+			CandidatesPerCategory: 5,
+			MaxCategories:         8,
+			AccessoryPriceRatio:   0.25,
+		},
+		RegionalPopularity: DefaultRegionalPopularityTuning(),
+		Seasonality:        DefaultSeasonalityTuning(),
+		Popularity:         DefaultPopularityTuning(),
+		Feedback:           DefaultFeedbackTuning(),
+		FrequencyCap:       DefaultFrequencyCapTuning(),
+		Explainer:          DefaultExplainerTuning(),
+		Suggest:            DefaultSuggestTuning(),
+		QueryNormalization: DefaultQueryNormalizationTuning(),
+		LexicalSearch:      DefaultLexicalSearchTuning(),
+		HybridSearch:       DefaultHybridSearchTuning(),
+	}
+}
+
+// LoadRecommendationTuning loads recommendation tuning from a JSON file.
+//
+// Parameters:
+//   - path: path to the JSON tuning file; an empty path returns the default tuning
+//
+// Returns the parsed tuning, or an error if the file cannot be read or parsed.
+func LoadRecommendationTuning(path string) (*RecommendationTuning, error) {
+	if path == "" {
+		return DefaultRecommendationTuning(), nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read recommendation tuning file: %w", err)
+	}
+
+	tuning := DefaultRecommendationTuning()
+	if err := json.Unmarshal(data, tuning); err != nil {
+		return nil, fmt.Errorf("failed to parse recommendation tuning file: %w", err)
+	}
+
+	for i, profile := range tuning.BlendProfiles {
+		if err := ValidateBlendProfile(profile); err != nil {
+			return nil, fmt.Errorf("blend profile %d (%s): %w", i, profile.Name, err)
+		}
+	}
+
+	if tuning.Diversity.DefaultDiversity < 0 || tuning.Diversity.DefaultDiversity > 1 {
+		return nil, fmt.Errorf("diversity.default_diversity must be between 0 and 1")
+	}
+	if tuning.Diversity.EmbeddingCacheMinutes < 0 || tuning.Diversity.EmbeddingCacheSize < 0 {
+		return nil, fmt.Errorf("diversity.embedding_cache_minutes and embedding_cache_size must not be negative")
+	}
+	for contextType, contextTuning := range tuning.Diversity.Contexts {
+		if contextTuning.Diversity != nil && (*contextTuning.Diversity < 0 || *contextTuning.Diversity > 1) {
+			return nil, fmt.Errorf("diversity for context %s must be between 0 and 1", contextType)
+		}
+		if contextTuning.MaxPerBrand < 0 || contextTuning.MaxPerCategory < 0 {
+			return nil, fmt.Errorf("diversity caps for context %s must not be negative", contextType)
+		}
+	}
+
+	if tuning.Bandit.ClickReward < 0 || tuning.Bandit.ClickReward > 1 || tuning.Bandit.PurchaseReward < 0 || tuning.Bandit.PurchaseReward > 1 {
+		return nil, fmt.Errorf("bandit rewards must be between 0 and 1")
+	}
+
+	if tuning.SessionSequence.HistoryDecay < 0 || tuning.SessionSequence.HistoryDecay > 1 {
+		return nil, fmt.Errorf("session_sequence.history_decay must be between 0 and 1")
+	}
+	if tuning.SessionSequence.BackoffWeight < 0 || tuning.SessionSequence.MinSupport < 0 {
+		return nil, fmt.Errorf("session_sequence.backoff_weight and min_support must not be negative")
+	}
+
+	if tuning.Replenishment.MinIntervalDays < 0 || tuning.Replenishment.MaxIntervalDays < tuning.Replenishment.MinIntervalDays {
+		return nil, fmt.Errorf("replenishment.max_interval_days must not be below min_interval_days")
+	}
+
+	if tuning.PriceSensitivity.NeutralDiscountShare <= 0 || tuning.PriceSensitivity.NeutralDiscountShare >= 1 {
+		return nil, fmt.Errorf("price_sensitivity.neutral_discount_share must be between 0 and 1")
+	}
+	if tuning.PriceSensitivity.FullDiscountDepth <= 0 || tuning.PriceSensitivity.MaxAdjustment < 0 || tuning.PriceSensitivity.MaxAdjustment >= 1 {
+		return nil, fmt.Errorf("price_sensitivity.full_discount_depth must be positive and max_adjustment between 0 and 1")
+	}
+
+	if tuning.RegionalPopularity.PriorUnits < 0 || tuning.RegionalPopularity.LiftWeight < 0 || tuning.RegionalPopularity.MinLocationDelta < 0 {
+		return nil, fmt.Errorf("regional_popularity.prior_units, lift_weight and min_location_delta must not be negative")
+	}
+	if tuning.RegionalPopularity.LocationBoost < 0 || tuning.RegionalPopularity.LocationBoost >= 1 {
+		return nil, fmt.Errorf("regional_popularity.location_boost must be between 0 and 1")
+	}
+
+	if tuning.Seasonality.MinSupport < 0 || tuning.Seasonality.MinLift < 0 || tuning.Seasonality.LeadDays < 0 {
+		return nil, fmt.Errorf("seasonality.min_support, min_lift and lead_days must not be negative")
+	}
+	if tuning.Seasonality.MaxBoost < 0 || tuning.Seasonality.MaxBoost >= 1 {
+		return nil, fmt.Errorf("seasonality.max_boost must be between 0 and 1")
+	}
+	for _, event := range tuning.Seasonality.Events {
+		if err := ValidateSeasonalEvent(event); err != nil {
+			return nil, err
+		}
+	}
+
+	popularity := tuning.Popularity
+	if popularity.HalfLifeDays <= 0 || popularity.MaxScore <= 0 || popularity.SignificantChange < 0 {
+		return nil, fmt.Errorf("popularity.half_life_days and max_score must be positive and significant_change not negative")
+	}
+	if popularity.OrderWeight < 0 || popularity.CartWeight < 0 || popularity.WishlistWeight < 0 || popularity.ViewWeight < 0 {
+		return nil, fmt.Errorf("popularity signal weights must not be negative")
+	}
+
+	feedback := tuning.Feedback
+	if feedback.ProductSuppressionDays < 0 || feedback.BrandSuppressionDays < 0 || feedback.CategorySuppressionDays < 0 {
+		return nil, fmt.Errorf("feedback suppression days must not be negative")
+	}
+	if feedback.NegativeLookbackDays < 0 || feedback.MinNegativeDismissals < 0 {
+		return nil, fmt.Errorf("feedback.negative_lookback_days and min_negative_dismissals must not be negative")
+	}
+
+	frequencyCaps := map[string]FrequencyCap{"default": tuning.FrequencyCap.Default}
+	for contextType, frequencyCap := range tuning.FrequencyCap.Contexts {
+		frequencyCaps[contextType] = frequencyCap
+	}
+	for name, frequencyCap := range frequencyCaps {
+		if frequencyCap.MaxImpressions < 0 {
+			return nil, fmt.Errorf("frequency_cap %s: max_impressions must not be negative", name)
+		}
+		if frequencyCap.MaxImpressions > 0 && frequencyCap.WindowDays <= 0 {
+			return nil, fmt.Errorf("frequency_cap %s: window_days must be positive", name)
+		}
+		if frequencyCap.Demotion < 0 || frequencyCap.Demotion >= 1 {
+			return nil, fmt.Errorf("frequency_cap %s: demotion must be in [0, 1)", name)
+		}
+	}
+
+	for _, values := range []map[string]float64{tuning.Explainer.Weights, tuning.Explainer.Baselines} {
+		for factor, value := range values {
+			if _, ok := explanationFactorLabels[factor]; !ok {
+				return nil, fmt.Errorf("unknown explainer factor: %s", factor)
+			}
+			if value < 0 {
+				return nil, fmt.Errorf("explainer factor %s must not be negative", factor)
+			}
+		}
+	}
+
+	suggest := tuning.Suggest
+	for termType, weight := range suggest.TypeWeights {
+		if weight < 0 {
+			return nil, fmt.Errorf("suggest.type_weights.%s must not be negative", termType)
+		}
+	}
+	if suggest.WordMatchFactor < 0 || suggest.WordMatchFactor > 1 {
+		return nil, fmt.Errorf("suggest.word_match_factor must be between 0 and 1")
+	}
+	if suggest.PersonalizationWeight < 0 || suggest.ParentShare < 0 || suggest.ParentShare > 1 {
+		return nil, fmt.Errorf("suggest.personalization_weight must not be negative and parent_share must be between 0 and 1")
+	}
+	if suggest.PurchaseWeight < 0 || suggest.CartWeight < 0 || suggest.ViewWeight < 0 || suggest.PreferredWeight < 0 {
+		return nil, fmt.Errorf("suggest affinity weights must not be negative")
+	}
+
+	if tuning.QueryNormalization.MinCorrectionLength < 0 || tuning.QueryNormalization.MaxEditDistance < 0 {
+		return nil, fmt.Errorf("query_normalization.min_correction_length and max_edit_distance must not be negative")
+	}
+	for _, synonym := range tuning.QueryNormalization.Synonyms {
+		if err := ValidateSearchSynonym(synonym.Term, synonym.Replacement, synonym.Type); err != nil {
+			return nil, fmt.Errorf("query_normalization.synonyms: %w", err)
+		}
+	}
+
+	lexical := tuning.LexicalSearch
+	if lexical.K1 < 0 || lexical.B < 0 || lexical.B > 1 {
+		return nil, fmt.Errorf("lexical_search.k1 must not be negative and b must be between 0 and 1")
+	}
+	for field, weight := range lexical.FieldWeights {
+		if _, ok := DefaultLexicalSearchTuning().FieldWeights[field]; !ok {
+			return nil, fmt.Errorf("lexical_search.field_weights has unknown field: %s", field)
+		}
+		if weight < 0 {
+			return nil, fmt.Errorf("lexical_search.field_weights.%s must not be negative", field)
+		}
+	}
+
+	hybrid := tuning.HybridSearch
+	if err := ValidateHybridWeights(hybrid.Weights); err != nil {
+		return nil, fmt.Errorf("hybrid_search.weights: %w", err)
+	}
+	for queryType, weights := range hybrid.QueryTypeWeights {
+		if queryType != HybridQueryTypeKeyword && queryType != HybridQueryTypeNaturalLanguage {
+			return nil, fmt.Errorf("hybrid_search.query_type_weights has unknown query type: %s", queryType)
+		}
+		if err := ValidateHybridWeights(weights); err != nil {
+			return nil, fmt.Errorf("hybrid_search.query_type_weights.%s: %w", queryType, err)
+		}
+	}
+	if hybrid.KeywordQueryMaxTerms < 0 || hybrid.CandidateMultiplier < 0 {
+		return nil, fmt.Errorf("hybrid_search.keyword_query_max_terms and candidate_multiplier must not be negative")
+	}
+
+	experimentIDs := make(map[string]bool, len(tuning.Experiments))
+	for i := range tuning.Experiments {
+		if err := ValidateExperiment(&tuning.Experiments[i]); err != nil {
+			return nil, err
+		}
+		if experimentIDs[tuning.Experiments[i].ID] {
+			return nil, fmt.Errorf("duplicate experiment id: %s", tuning.Experiments[i].ID)
+		}
+		experimentIDs[tuning.Experiments[i].ID] = true
+	}
+
+	for _, template := range tuning.PromptTemplates {
+		if template.ID == "" || template.Version == "" || template.BasePrompt == "" {
+			return nil, fmt.Errorf("prompt templates require id, version and base_prompt")
+		}
+	}
+
+	return tuning, nil
+}
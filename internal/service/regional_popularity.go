@@ -0,0 +1,294 @@
+package service
+
+import (
+	"context"
+	"ec-recommend/internal/dto"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RegionalPopularityTuning configures regional popularity aggregates and the hybrid location boost
+type RegionalPopularityTuning struct {
+	Disabled         bool    `json:"disabled,omitempty"`
+	LookbackDays     int     `json:"lookback_days"`      // Order window used for the aggregates
+	RefreshMinutes   int     `json:"refresh_minutes"`    // Aggregate rebuild interval
+	PriorUnits       float64 `json:"prior_units"`        // Pseudo-units pulling small prefectures towards national shares
+	LiftWeight       float64 `json:"lift_weight"`        // Exponent of the lift in the "popular near you" score (0 = plain regional share)
+	MaxLift          float64 `json:"max_lift"`           // Cap on lifts so that one-off purchases cannot dominate
+	MinProductUnits  float64 `json:"min_product_units"`  // Regional units before a product's own lift is trusted over its category's
+	LocationBoost    float64 `json:"location_boost"`     // Largest relative change to a hybrid confidence score
+	MinLocationDelta float64 `json:"min_location_delta"` // Lift deviation from 1 below which no boost is applied
+}
+
+// DefaultRegionalPopularityTuning returns the regional popularity tuning used when none is configured
+func DefaultRegionalPopularityTuning() RegionalPopularityTuning {
+	return RegionalPopularityTuning{
+		LookbackDays:     180,
+		RefreshMinutes:   360,
+		PriorUnits:       50,
+		LiftWeight:       0.5,
+		MaxLift:          5,
+		MinProductUnits:  5,
+		LocationBoost:    0.1,
+		MinLocationDelta: 0.2,
+	}
+}
+
+// regionalStats holds aggregated demand for one prefecture
+type regionalStats struct {
+	units      float64
+	products   map[uuid.UUID]*dto.RegionalPopularity
+	categories map[int]float64
+	ranked     []dto.RegionalPopularity
+}
+
+// RegionalPopularityModel holds per-prefecture product popularity and lifts over national demand
+type RegionalPopularityModel struct {
+	regions          map[string]*regionalStats
+	nationalUnits    float64
+	nationalProducts map[uuid.UUID]float64
+	nationalCategory map[int]float64
+	priorUnits       float64
+	maxLift          float64
+	minProductUnits  float64
+}
+
+// BuildRegionalPopularityModel aggregates regional demand into smoothed shares and lifts.
+// A product's regional share is shrunk towards its national share by PriorUnits pseudo-units,
+// its lift is the smoothed regional share over the national share, and the "popular near you"
+// score is the smoothed share times lift^LiftWeight so that regional specialties rise.
+func BuildRegionalPopularityModel(demand []dto.RegionalDemand, tuning RegionalPopularityTuning) *RegionalPopularityModel {
+	model := &RegionalPopularityModel{
+		regions:          make(map[string]*regionalStats),
+		nationalProducts: make(map[uuid.UUID]float64),
+		nationalCategory: make(map[int]float64),
+		priorUnits:       tuning.PriorUnits,
+		maxLift:          tuning.MaxLift,
+		minProductUnits:  tuning.MinProductUnits,
+	}
+
+	for _, d := range demand {
+		if d.Units <= 0 || d.Prefecture == "" {
+			continue
+		}
+		region := model.regions[d.Prefecture]
+		if region == nil {
+			region = &regionalStats{
+				products:   make(map[uuid.UUID]*dto.RegionalPopularity),
+				categories: make(map[int]float64),
+			}
+			model.regions[d.Prefecture] = region
+		}
+
+		product := region.products[d.ProductID]
+		if product == nil {
+			product = &dto.RegionalPopularity{Prefecture: d.Prefecture, ProductID: d.ProductID, CategoryID: d.CategoryID}
+			region.products[d.ProductID] = product
+		}
+		product.Units += d.Units
+		product.Customers += d.Customers
+
+		region.units += d.Units
+		region.categories[d.CategoryID] += d.Units
+		model.nationalUnits += d.Units
+		model.nationalProducts[d.ProductID] += d.Units
+		model.nationalCategory[d.CategoryID] += d.Units
+	}
+
+	for _, region := range model.regions {
+		region.ranked = make([]dto.RegionalPopularity, 0, len(region.products))
+		for _, product := range region.products {
+			nationalShare := model.nationalProducts[product.ProductID] / model.nationalUnits
+			product.RegionalShare = model.smoothedShare(product.Units, region.units, nationalShare)
+			product.Lift = model.lift(product.RegionalShare, nationalShare)
+			product.Score = product.RegionalShare * math.Pow(product.Lift, tuning.LiftWeight)
+			region.ranked = append(region.ranked, *product)
+		}
+		sort.Slice(region.ranked, func(i, j int) bool {
+			if region.ranked[i].Score != region.ranked[j].Score {
+				return region.ranked[i].Score > region.ranked[j].Score
+			}
+			return region.ranked[i].ProductID.String() < region.ranked[j].ProductID.String()
+		})
+	}
+
+	return model
+}
+
+// smoothedShare shrinks a regional share towards the national share
+func (m *RegionalPopularityModel) smoothedShare(units, regionUnits, nationalShare float64) float64 {
+	return (units + m.priorUnits*nationalShare) / (regionUnits + m.priorUnits)
+}
+
+// lift returns a regional share relative to the national share, capped at MaxLift
+func (m *RegionalPopularityModel) lift(regionalShare, nationalShare float64) float64 {
+	if nationalShare <= 0 {
+		return 1
+	}
+	lift := regionalShare / nationalShare
+	if m.maxLift > 0 {
+		lift = math.Min(lift, m.maxLift)
+	}
+	return lift
+}
+
+// Popular returns the most popular products in a prefecture, excluding the given products
+func (m *RegionalPopularityModel) Popular(prefecture string, limit int, exclude map[uuid.UUID]bool) []dto.RegionalPopularity {
+	region := m.regions[prefecture]
+	if region == nil {
+		return []dto.RegionalPopularity{}
+	}
+
+	popular := make([]dto.RegionalPopularity, 0, limit)
+	for _, product := range region.ranked {
+		if exclude[product.ProductID] {
+			continue
+		}
+		popular = append(popular, product)
+		if len(popular) >= limit {
+			break
+		}
+	}
+
+	return popular
+}
+
+// Lift returns how much more a product sells in a prefecture than nationally and whether the
+// product's own demand or its category's was used. Products with too few regional units use
+// their category's lift so that weather-dependent categories still move; 1 means no regional signal.
+func (m *RegionalPopularityModel) Lift(prefecture string, productID uuid.UUID, categoryID int) (float64, string) {
+	region := m.regions[prefecture]
+	if region == nil || m.nationalUnits <= 0 {
+		return 1, ""
+	}
+
+	if product := region.products[productID]; product != nil && product.Units >= m.minProductUnits {
+		return product.Lift, "product"
+	}
+
+	nationalShare := m.nationalCategory[categoryID] / m.nationalUnits
+	if nationalShare <= 0 {
+		return 1, ""
+	}
+	return m.lift(m.smoothedShare(region.categories[categoryID], region.units, nationalShare), nationalShare), "category"
+}
+
+// RegionalPopularityService serves regional popularity from periodically rebuilt aggregates
+type RegionalPopularityService struct {
+	repo   RegionalPopularityRepositoryInterface
+	tuning RegionalPopularityTuning
+
+	mu      sync.Mutex
+	model   *RegionalPopularityModel
+	builtAt time.Time
+}
+
+// NewRegionalPopularityService creates a new regional popularity service; aggregates are built on first use
+func NewRegionalPopularityService(repo RegionalPopularityRepositoryInterface, tuning RegionalPopularityTuning) *RegionalPopularityService {
+	if tuning.LookbackDays <= 0 {
+		tuning.LookbackDays = 180
+	}
+	if tuning.RefreshMinutes <= 0 {
+		tuning.RefreshMinutes = 360
+	}
+
+	return &RegionalPopularityService{
+		repo:   repo,
+		tuning: tuning,
+	}
+}
+
+// CustomerLocation returns the customer's location, or nil when unknown or when the feature is disabled
+func (rp *RegionalPopularityService) CustomerLocation(ctx context.Context, customerID uuid.UUID) (*dto.CustomerLocation, error) {
+	if rp.tuning.Disabled {
+		return nil, nil
+	}
+
+	location, err := rp.repo.GetCustomerLocation(ctx, customerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get customer location: %w", err)
+	}
+
+	return location, nil
+}
+
+// PopularNear returns the products most popular in a prefecture, excluding the given products
+func (rp *RegionalPopularityService) PopularNear(ctx context.Context, prefecture string, limit int, exclude map[uuid.UUID]bool) ([]dto.RegionalPopularity, error) {
+	model, err := rp.currentModel(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return model.Popular(prefecture, limit, exclude), nil
+}
+
+// ApplyLocationBoost multiplies each confidence score by 1 + LocationBoost * (lift - 1), clamped,
+// for products that sell notably more or less in the prefecture than nationally, and re-sorts
+func (rp *RegionalPopularityService) ApplyLocationBoost(ctx context.Context, location *dto.CustomerLocation, recommendations []dto.ProductRecommendationV2) ([]dto.ProductRecommendationV2, error) {
+	if rp.tuning.Disabled || location == nil || rp.tuning.LocationBoost <= 0 || len(recommendations) == 0 {
+		return recommendations, nil
+	}
+
+	model, err := rp.currentModel(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range recommendations {
+		rec := &recommendations[i]
+		lift, level := model.Lift(location.Prefecture, rec.ProductID, rec.CategoryID)
+		delta := lift - 1
+		if math.Abs(delta) < rp.tuning.MinLocationDelta {
+			continue
+		}
+
+		explanation := fmt.Sprintf("Bought %.1fx as often in %s as nationally", lift, location.Prefecture)
+		if level == "category" {
+			explanation = fmt.Sprintf("%s sells %.1fx as often in %s as nationally", rec.CategoryName, lift, location.Prefecture)
+		}
+
+		rec.ConfidenceScore *= 1 + rp.tuning.LocationBoost*clampUnit(delta)
+		rec.RelevanceContext = append(rec.RelevanceContext, dto.RelevanceContext{
+			ContextType: "regional",
+			Explanation: explanation,
+			Confidence:  math.Min(1, math.Abs(delta)),
+			SourceData:  fmt.Sprintf("prefecture=%s source=%s level=%s lift=%.2f", location.Prefecture, location.Source, level, lift),
+		})
+	}
+
+	sort.SliceStable(recommendations, func(i, j int) bool {
+		return recommendations[i].ConfidenceScore > recommendations[j].ConfidenceScore
+	})
+
+	return recommendations, nil
+}
+
+// currentModel returns the aggregates, rebuilding them when older than the refresh interval
+func (rp *RegionalPopularityService) currentModel(ctx context.Context) (*RegionalPopularityModel, error) {
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+
+	if rp.model != nil && time.Since(rp.builtAt) < time.Duration(rp.tuning.RefreshMinutes)*time.Minute {
+		return rp.model, nil
+	}
+
+	since := time.Now().AddDate(0, 0, -rp.tuning.LookbackDays)
+	demand, err := rp.repo.GetRegionalDemand(ctx, since)
+	if err != nil {
+		// Keep serving stale aggregates rather than failing requests
+		if rp.model != nil {
+			return rp.model, nil
+		}
+		return nil, fmt.Errorf("failed to get regional demand: %w", err)
+	}
+
+	rp.model = BuildRegionalPopularityModel(demand, rp.tuning)
+	rp.builtAt = time.Now()
+
+	return rp.model, nil
+}
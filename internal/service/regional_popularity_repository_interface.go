@@ -0,0 +1,20 @@
+package service
+
+import (
+	"context"
+	"ec-recommend/internal/dto"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RegionalPopularityRepositoryInterface defines queries over customer locations and regional demand
+// This interface is defined in the service package as it is consumed by services
+type RegionalPopularityRepositoryInterface interface {
+	// GetCustomerLocation returns the customer's prefecture from customers.location, falling back to
+	// the most recent shipping address; nil when neither is known
+	GetCustomerLocation(ctx context.Context, customerID uuid.UUID) (*dto.CustomerLocation, error)
+
+	// GetRegionalDemand aggregates units ordered per prefecture and product since the given time
+	GetRegionalDemand(ctx context.Context, since time.Time) ([]dto.RegionalDemand, error)
+}
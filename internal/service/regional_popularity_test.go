@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"ec-recommend/internal/dto"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// fakeRegionalPopularityRepository returns a fixed location and regional demand
+type fakeRegionalPopularityRepository struct {
+	location *dto.CustomerLocation
+	demand   []dto.RegionalDemand
+}
+
+func (f *fakeRegionalPopularityRepository) GetCustomerLocation(ctx context.Context, customerID uuid.UUID) (*dto.CustomerLocation, error) {
+	return f.location, nil
+}
+
+func (f *fakeRegionalPopularityRepository) GetRegionalDemand(ctx context.Context, since time.Time) ([]dto.RegionalDemand, error) {
+	return f.demand, nil
+}
+
+func TestRegionalPopularity(t *testing.T) {
+	tuning := DefaultRegionalPopularityTuning()
+	bestseller := uuid.New() // Sells everywhere
+	specialty := uuid.New()  // Sells mostly in Hokkaido
+	heater := uuid.New()     // Rarely bought, in a category that sells mostly in Hokkaido
+	heaterSibling := uuid.New()
+
+	demand := []dto.RegionalDemand{
+		{Prefecture: "Tokyo", ProductID: bestseller, CategoryID: 1, Units: 500, Customers: 300},
+		{Prefecture: "Tokyo", ProductID: specialty, CategoryID: 7, Units: 10, Customers: 8},
+		{Prefecture: "Tokyo", ProductID: heaterSibling, CategoryID: 3, Units: 5, Customers: 5},
+		{Prefecture: "Hokkaido", ProductID: bestseller, CategoryID: 1, Units: 100, Customers: 80},
+		{Prefecture: "Hokkaido", ProductID: specialty, CategoryID: 7, Units: 80, Customers: 50},
+		{Prefecture: "Hokkaido", ProductID: heaterSibling, CategoryID: 3, Units: 60, Customers: 40},
+		{Prefecture: "Hokkaido", ProductID: heater, CategoryID: 3, Units: 2, Customers: 2},
+	}
+	model := BuildRegionalPopularityModel(demand, tuning)
+
+	t.Run("ranks regional specialties and computes lifts", func(t *testing.T) {
+		popular := model.Popular("Hokkaido", 2, nil)
+		// The specialty sold fewer units than the bestseller but far above its national rate
+		if len(popular) != 2 || popular[0].ProductID != specialty || popular[1].ProductID != bestseller {
+			t.Fatalf("Expected specialty then bestseller in Hokkaido, got %+v", popular)
+		}
+		if popular[0].Lift <= 1.5 {
+			t.Errorf("Expected the specialty to sell well above the national rate, got lift %.2f", popular[0].Lift)
+		}
+
+		if lift, level := model.Lift("Tokyo", specialty, 7); level != "product" || lift >= 1 {
+			t.Errorf("Expected a product lift below 1 in Tokyo, got %.2f (%s)", lift, level)
+		}
+		if popular := model.Popular("Okinawa", 5, nil); len(popular) != 0 {
+			t.Errorf("Expected no products for an unknown prefecture, got %d", len(popular))
+		}
+	})
+
+	t.Run("falls back to the category lift for sparse products", func(t *testing.T) {
+		if lift, level := model.Lift("Hokkaido", heater, 3); level != "category" || lift <= 1 {
+			t.Errorf("Expected a category lift above 1, got %.2f (%s)", lift, level)
+		}
+	})
+
+	t.Run("boosts hybrid results for the customer's prefecture", func(t *testing.T) {
+		location := &dto.CustomerLocation{Prefecture: "Hokkaido", Source: dto.LocationSourceShippingAddress}
+		regional := NewRegionalPopularityService(&fakeRegionalPopularityRepository{location: location, demand: demand}, tuning)
+
+		recommendations := []dto.ProductRecommendationV2{
+			{ProductID: bestseller, CategoryID: 1, ConfidenceScore: 0.8},
+			{ProductID: specialty, CategoryID: 7, ConfidenceScore: 0.75},
+		}
+		boosted, err := regional.ApplyLocationBoost(context.Background(), location, recommendations)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if boosted[0].ProductID != specialty {
+			t.Errorf("Expected the regional specialty first, got %s", boosted[0].ProductID)
+		}
+		if len(boosted[0].RelevanceContext) != 1 || boosted[0].RelevanceContext[0].ContextType != "regional" {
+			t.Errorf("Expected regional relevance context, got %+v", boosted[0].RelevanceContext)
+		}
+	})
+}
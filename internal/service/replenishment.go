@@ -0,0 +1,390 @@
+package service
+
+import (
+	"context"
+	"ec-recommend/internal/dto"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ReplenishmentTuning configures repurchase interval estimation for consumable products
+type ReplenishmentTuning struct {
+	ConsumableCategories []string `json:"consumable_categories"`  // Category names; subcategories of listed parents are included
+	LookbackDays         int      `json:"lookback_days"`          // Order history used to learn intervals
+	MinIntervalDays      float64  `json:"min_interval_days"`      // Lower bound of an estimated interval
+	MaxIntervalDays      float64  `json:"max_interval_days"`      // Upper bound of an estimated interval
+	DueWindowDays        int      `json:"due_window_days"`        // Products expected within this many days are "due"
+	OverdueGraceDays     int      `json:"overdue_grace_days"`     // Products overdue for longer are treated as lapsed
+	DefaultHorizonDays   int      `json:"default_horizon_days"`   // Look-ahead of the reorder list
+	MinPopulationSamples int      `json:"min_population_samples"` // Repurchases required before trusting a population interval
+	RefreshMinutes       int      `json:"refresh_minutes"`        // Population interval cache lifetime
+}
+
+// DefaultReplenishmentTuning returns the replenishment tuning used when none is configured
+func DefaultReplenishmentTuning() ReplenishmentTuning {
+	return ReplenishmentTuning{
+		ConsumableCategories: []string{"skincare", "makeup", "hair_care", "food", "vitamins", "wellness"},
+		LookbackDays:         730,
+		MinIntervalDays:      3,
+		MaxIntervalDays:      365,
+		DueWindowDays:        7,
+		OverdueGraceDays:     60,
+		DefaultHorizonDays:   30,
+		MinPopulationSamples: 5,
+		RefreshMinutes:       360,
+	}
+}
+
+// ReplenishmentPriors holds typical per-unit repurchase intervals across all customers
+type ReplenishmentPriors struct {
+	products   map[uuid.UUID]dto.RepurchaseIntervalPrior
+	categories map[int]dto.RepurchaseIntervalPrior
+}
+
+// BuildReplenishmentPriors indexes product priors and derives category priors as their sample-weighted mean
+func BuildReplenishmentPriors(priors []dto.RepurchaseIntervalPrior) *ReplenishmentPriors {
+	result := &ReplenishmentPriors{
+		products:   make(map[uuid.UUID]dto.RepurchaseIntervalPrior, len(priors)),
+		categories: make(map[int]dto.RepurchaseIntervalPrior),
+	}
+
+	for _, prior := range priors {
+		if prior.Samples <= 0 || prior.IntervalDays <= 0 {
+			continue
+		}
+		result.products[prior.ProductID] = prior
+
+		category := result.categories[prior.CategoryID]
+		category.CategoryID = prior.CategoryID
+		category.IntervalDays += prior.IntervalDays * float64(prior.Samples)
+		category.Samples += prior.Samples
+		result.categories[prior.CategoryID] = category
+	}
+
+	for categoryID, category := range result.categories {
+		category.IntervalDays /= float64(category.Samples)
+		result.categories[categoryID] = category
+	}
+
+	return result
+}
+
+// EstimateReplenishment predicts when a customer will reorder each consumable product they bought.
+// Events must belong to one customer. The per-unit interval comes from the customer's own repurchases
+// of the product, then their repurchases within the category, then the population priors, and is
+// scaled by the quantity of the last purchase. Only products expected within horizonDays and not
+// lapsed are returned, soonest first.
+func EstimateReplenishment(events []dto.PurchaseEvent, priors *ReplenishmentPriors, tuning ReplenishmentTuning, now time.Time, horizonDays int) []dto.ReplenishmentPrediction {
+	sorted := make([]dto.PurchaseEvent, len(events))
+	copy(sorted, events)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].OrderedAt.Before(sorted[j].OrderedAt)
+	})
+
+	byProduct := make(map[uuid.UUID][]dto.PurchaseEvent)
+	byCategory := make(map[int][]dto.PurchaseEvent)
+	var productOrder []uuid.UUID
+	for _, event := range sorted {
+		if _, ok := byProduct[event.ProductID]; !ok {
+			productOrder = append(productOrder, event.ProductID)
+		}
+		byProduct[event.ProductID] = append(byProduct[event.ProductID], event)
+		byCategory[event.CategoryID] = append(byCategory[event.CategoryID], event)
+	}
+
+	categoryGaps := make(map[int][]float64, len(byCategory))
+	for categoryID, categoryEvents := range byCategory {
+		categoryGaps[categoryID] = perUnitGaps(categoryEvents)
+	}
+
+	var predictions []dto.ReplenishmentPrediction
+	for _, productID := range productOrder {
+		purchases := byProduct[productID]
+		last := purchases[len(purchases)-1]
+
+		perUnit, source, confidence := 0.0, "", 0.0
+		if gaps := perUnitGaps(purchases); len(gaps) > 0 {
+			perUnit, source = median(gaps), dto.ReplenishmentSourceCustomerProduct
+			confidence = gapConfidence(gaps)
+		} else if gaps := categoryGaps[last.CategoryID]; len(gaps) > 0 {
+			perUnit, source = median(gaps), dto.ReplenishmentSourceCustomerCategory
+			confidence = 0.6 * gapConfidence(gaps)
+		} else if prior, ok := priors.lookup(productID, last.CategoryID, tuning.MinPopulationSamples); ok {
+			perUnit, source = prior.IntervalDays, dto.ReplenishmentSourcePopulation
+			confidence = 0.4 * float64(prior.Samples) / float64(prior.Samples+10)
+		} else {
+			continue
+		}
+
+		quantity := last.Quantity
+		if quantity <= 0 {
+			quantity = 1
+		}
+		interval := math.Min(math.Max(perUnit*float64(quantity), tuning.MinIntervalDays), tuning.MaxIntervalDays)
+		expected := last.OrderedAt.Add(time.Duration(interval * float64(24*time.Hour)))
+		daysUntil := expected.Sub(now).Hours() / 24
+
+		if daysUntil > float64(horizonDays) || daysUntil < -float64(tuning.OverdueGraceDays) {
+			continue
+		}
+
+		status := dto.ReplenishmentStatusUpcoming
+		if daysUntil < 0 {
+			status = dto.ReplenishmentStatusOverdue
+		} else if daysUntil <= float64(tuning.DueWindowDays) {
+			status = dto.ReplenishmentStatusDue
+		}
+
+		predictions = append(predictions, dto.ReplenishmentPrediction{
+			ProductID:         productID,
+			ProductName:       last.ProductName,
+			CategoryID:        last.CategoryID,
+			CategoryName:      last.CategoryName,
+			Brand:             last.Brand,
+			Price:             last.Price,
+			LastPurchasedAt:   last.OrderedAt,
+			LastQuantity:      last.Quantity,
+			PurchaseCount:     len(purchases),
+			IntervalDays:      interval,
+			ExpectedReorderAt: expected,
+			DaysUntilReorder:  daysUntil,
+			Status:            status,
+			IntervalSource:    source,
+			Confidence:        confidence,
+		})
+	}
+
+	sort.SliceStable(predictions, func(i, j int) bool {
+		if predictions[i].DaysUntilReorder != predictions[j].DaysUntilReorder {
+			return predictions[i].DaysUntilReorder < predictions[j].DaysUntilReorder
+		}
+		return predictions[i].Confidence > predictions[j].Confidence
+	})
+
+	return predictions
+}
+
+// lookup returns the product prior, falling back to the category prior, if it has enough samples
+func (p *ReplenishmentPriors) lookup(productID uuid.UUID, categoryID int, minSamples int) (dto.RepurchaseIntervalPrior, bool) {
+	if p == nil {
+		return dto.RepurchaseIntervalPrior{}, false
+	}
+	if prior, ok := p.products[productID]; ok && prior.Samples >= minSamples {
+		return prior, true
+	}
+	if prior, ok := p.categories[categoryID]; ok && prior.Samples >= minSamples {
+		return prior, true
+	}
+	return dto.RepurchaseIntervalPrior{}, false
+}
+
+// perUnitGaps returns the days between consecutive purchases divided by the quantity bought earlier.
+// Purchases on the same day are treated as one stock-up.
+func perUnitGaps(events []dto.PurchaseEvent) []float64 {
+	var gaps []float64
+	for i := 1; i < len(events); i++ {
+		days := events[i].OrderedAt.Sub(events[i-1].OrderedAt).Hours() / 24
+		if days < 1 {
+			continue
+		}
+		quantity := events[i-1].Quantity
+		if quantity <= 0 {
+			quantity = 1
+		}
+		gaps = append(gaps, days/float64(quantity))
+	}
+	return gaps
+}
+
+// gapConfidence grows with the number of observed intervals and shrinks with their variability
+func gapConfidence(gaps []float64) float64 {
+	n := float64(len(gaps))
+	var mean float64
+	for _, g := range gaps {
+		mean += g
+	}
+	mean /= n
+
+	var variance float64
+	for _, g := range gaps {
+		variance += (g - mean) * (g - mean)
+	}
+	cv := math.Sqrt(variance/n) / mean
+
+	return n / (n + 1) / (1 + cv)
+}
+
+// median returns the median of the values without modifying them
+func median(values []float64) float64 {
+	sorted := make([]float64, len(values))
+	copy(sorted, values)
+	sort.Float64s(sorted)
+
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
+
+// ReplenishmentService serves "time to reorder" lists and exports customers due for replenishment
+type ReplenishmentService struct {
+	repo     ReplenishmentRepositoryInterface
+	tuning   ReplenishmentTuning
+	feedback *FeedbackService
+
+	mu       sync.Mutex
+	priors   *ReplenishmentPriors
+	loadedAt time.Time
+}
+
+// NewReplenishmentService creates a new replenishment service instance
+func NewReplenishmentService(repo ReplenishmentRepositoryInterface, tuning ReplenishmentTuning) *ReplenishmentService {
+	defaults := DefaultReplenishmentTuning()
+	if len(tuning.ConsumableCategories) == 0 {
+		tuning.ConsumableCategories = defaults.ConsumableCategories
+	}
+	if tuning.LookbackDays <= 0 {
+		tuning.LookbackDays = defaults.LookbackDays
+	}
+	if tuning.MaxIntervalDays <= 0 {
+		tuning.MaxIntervalDays = defaults.MaxIntervalDays
+	}
+	if tuning.DefaultHorizonDays <= 0 {
+		tuning.DefaultHorizonDays = defaults.DefaultHorizonDays
+	}
+	if tuning.RefreshMinutes <= 0 {
+		tuning.RefreshMinutes = defaults.RefreshMinutes
+	}
+
+	return &ReplenishmentService{
+		repo:   repo,
+		tuning: tuning,
+	}
+}
+
+// SetFeedbackService excludes products the customer dismissed from replenishment lists and exports
+func (s *ReplenishmentService) SetFeedbackService(feedback *FeedbackService) {
+	s.feedback = feedback
+}
+
+// GetReplenishmentRecommendations returns the consumables a customer is expected to reorder within horizonDays
+func (s *ReplenishmentService) GetReplenishmentRecommendations(ctx context.Context, customerID uuid.UUID, horizonDays, limit int) (*dto.ReplenishmentResponse, error) {
+	if horizonDays <= 0 {
+		horizonDays = s.tuning.DefaultHorizonDays
+	}
+
+	priors, err := s.currentPriors(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	since := time.Now().AddDate(0, 0, -s.tuning.LookbackDays)
+	events, err := s.repo.GetConsumablePurchases(ctx, &customerID, s.tuning.ConsumableCategories, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get consumable purchases: %w", err)
+	}
+
+	items := EstimateReplenishment(events, priors, s.tuning, time.Now(), horizonDays)
+	items = filterSuppressedPredictions(items, s.feedback.suppressionsFor(ctx, &customerID))
+	if limit > 0 && len(items) > limit {
+		items = items[:limit]
+	}
+	if items == nil {
+		items = []dto.ReplenishmentPrediction{}
+	}
+
+	return &dto.ReplenishmentResponse{
+		CustomerID:  customerID,
+		Items:       items,
+		HorizonDays: horizonDays,
+		GeneratedAt: time.Now(),
+	}, nil
+}
+
+// ExportDueCustomers returns every customer and product that is overdue or expected within withinDays
+func (s *ReplenishmentService) ExportDueCustomers(ctx context.Context, withinDays int) ([]dto.ReplenishmentDueCustomer, error) {
+	if withinDays <= 0 {
+		withinDays = s.tuning.DueWindowDays
+	}
+
+	priors, err := s.currentPriors(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	since := time.Now().AddDate(0, 0, -s.tuning.LookbackDays)
+	events, err := s.repo.GetConsumablePurchases(ctx, nil, s.tuning.ConsumableCategories, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get consumable purchases: %w", err)
+	}
+
+	now := time.Now()
+	var due []dto.ReplenishmentDueCustomer
+	for start := 0; start < len(events); {
+		end := start
+		for end < len(events) && events[end].CustomerID == events[start].CustomerID {
+			end++
+		}
+
+		predictions := EstimateReplenishment(events[start:end], priors, s.tuning, now, withinDays)
+		if len(predictions) > 0 {
+			predictions = filterSuppressedPredictions(predictions, s.feedback.suppressionsFor(ctx, &events[start].CustomerID))
+		}
+		for _, prediction := range predictions {
+			due = append(due, dto.ReplenishmentDueCustomer{
+				CustomerID:              events[start].CustomerID,
+				ReplenishmentPrediction: prediction,
+			})
+		}
+		start = end
+	}
+
+	return due, nil
+}
+
+// filterSuppressedPredictions removes predictions for products the customer dismissed
+func filterSuppressedPredictions(predictions []dto.ReplenishmentPrediction, suppressions *FeedbackSuppressions) []dto.ReplenishmentPrediction {
+	if suppressions.Empty() {
+		return predictions
+	}
+
+	kept := make([]dto.ReplenishmentPrediction, 0, len(predictions))
+	for _, prediction := range predictions {
+		if !suppressions.Suppresses(prediction.ProductID, prediction.Brand, prediction.CategoryID) {
+			kept = append(kept, prediction)
+		}
+	}
+
+	return kept
+}
+
+// currentPriors returns the population priors, reloading them when older than the refresh interval
+func (s *ReplenishmentService) currentPriors(ctx context.Context) (*ReplenishmentPriors, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.priors != nil && time.Since(s.loadedAt) < time.Duration(s.tuning.RefreshMinutes)*time.Minute {
+		return s.priors, nil
+	}
+
+	since := time.Now().AddDate(0, 0, -s.tuning.LookbackDays)
+	priors, err := s.repo.GetRepurchaseIntervalPriors(ctx, s.tuning.ConsumableCategories, since)
+	if err != nil {
+		// Keep serving stale priors rather than failing requests
+		if s.priors != nil {
+			return s.priors, nil
+		}
+		return nil, fmt.Errorf("failed to get repurchase interval priors: %w", err)
+	}
+
+	s.priors = BuildReplenishmentPriors(priors)
+	s.loadedAt = time.Now()
+
+	return s.priors, nil
+}
@@ -0,0 +1,20 @@
+package service
+
+import (
+	"context"
+	"ec-recommend/internal/dto"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ReplenishmentRepositoryInterface defines the interface for consumable purchase data access
+// This interface is defined in the service package as it is consumed by services
+type ReplenishmentRepositoryInterface interface {
+	// GetConsumablePurchases returns purchases in the given categories (or their subcategories) since the given time,
+	// ordered by customer and order date. A nil customerID returns purchases of all customers.
+	GetConsumablePurchases(ctx context.Context, customerID *uuid.UUID, categoryNames []string, since time.Time) ([]dto.PurchaseEvent, error)
+
+	// GetRepurchaseIntervalPriors returns the median per-unit repurchase interval of each product across all customers
+	GetRepurchaseIntervalPriors(ctx context.Context, categoryNames []string, since time.Time) ([]dto.RepurchaseIntervalPrior, error)
+}
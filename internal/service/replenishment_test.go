@@ -0,0 +1,84 @@
+package service
+
+import (
+	"ec-recommend/internal/dto"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestEstimateReplenishment(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	daysAgo := func(days int) time.Time { return now.AddDate(0, 0, -days) }
+	tuning := DefaultReplenishmentTuning()
+
+	lotion, serum, shampoo := uuid.New(), uuid.New(), uuid.New()
+	event := func(productID uuid.UUID, categoryID, quantity, ago int) dto.PurchaseEvent {
+		return dto.PurchaseEvent{ProductID: productID, CategoryID: categoryID, Quantity: quantity, OrderedAt: daysAgo(ago)}
+	}
+
+	t.Run("own repurchases scale with the last quantity", func(t *testing.T) {
+		// One bottle lasts 30 days; the last order was two bottles 50 days ago
+		events := []dto.PurchaseEvent{
+			event(lotion, 1, 1, 110),
+			event(lotion, 1, 1, 80),
+			event(lotion, 1, 2, 50),
+		}
+		predictions := EstimateReplenishment(events, nil, tuning, now, 30)
+		if len(predictions) != 1 {
+			t.Fatalf("Expected 1 prediction, got %d", len(predictions))
+		}
+
+		p := predictions[0]
+		if p.IntervalSource != dto.ReplenishmentSourceCustomerProduct {
+			t.Errorf("Expected customer_product source, got %s", p.IntervalSource)
+		}
+		if math.Abs(p.IntervalDays-60) > 1e-9 || math.Abs(p.DaysUntilReorder-10) > 1e-9 {
+			t.Errorf("Expected 60 day interval due in 10 days, got %.1f and %.1f", p.IntervalDays, p.DaysUntilReorder)
+		}
+		if p.Status != dto.ReplenishmentStatusUpcoming || p.PurchaseCount != 3 {
+			t.Errorf("Expected upcoming with 3 purchases, got %s with %d", p.Status, p.PurchaseCount)
+		}
+	})
+
+	t.Run("single purchases fall back to the category and population", func(t *testing.T) {
+		events := []dto.PurchaseEvent{
+			event(lotion, 1, 1, 70),
+			event(serum, 1, 1, 50),
+			event(shampoo, 2, 1, 40),
+		}
+		priors := BuildReplenishmentPriors([]dto.RepurchaseIntervalPrior{
+			{ProductID: uuid.New(), CategoryID: 2, IntervalDays: 42, Samples: 20},
+		})
+
+		predictions := EstimateReplenishment(events, priors, tuning, now, 30)
+		sources := make(map[uuid.UUID]dto.ReplenishmentPrediction)
+		for _, p := range predictions {
+			sources[p.ProductID] = p
+		}
+
+		if p, ok := sources[serum]; !ok || p.IntervalSource != dto.ReplenishmentSourceCustomerCategory || p.Status != dto.ReplenishmentStatusOverdue {
+			t.Errorf("Expected overdue serum from customer_category, got %+v", p)
+		}
+		if p, ok := sources[shampoo]; !ok || p.IntervalSource != dto.ReplenishmentSourcePopulation || p.Status != dto.ReplenishmentStatusDue {
+			t.Errorf("Expected due shampoo from population priors, got %+v", p)
+		}
+		if predictions[0].DaysUntilReorder > predictions[len(predictions)-1].DaysUntilReorder {
+			t.Error("Expected predictions ordered by days until reorder")
+		}
+	})
+
+	t.Run("lapsed and distant products are excluded", func(t *testing.T) {
+		events := []dto.PurchaseEvent{
+			event(lotion, 1, 1, 400),
+			event(lotion, 1, 1, 370),
+			event(serum, 1, 1, 100),
+			event(serum, 1, 1, 10),
+		}
+		if predictions := EstimateReplenishment(events, nil, tuning, now, 30); len(predictions) != 0 {
+			t.Errorf("Expected no predictions, got %d", len(predictions))
+		}
+	})
+}
@@ -0,0 +1,32 @@
+package service
+
+import (
+	"context"
+	"ec-recommend/internal/dto"
+
+	"github.com/google/uuid"
+)
+
+// SearchSynonymRepositoryInterface defines persistence operations for the search query dictionary
+// This interface is defined in the service package as it is consumed by services
+type SearchSynonymRepositoryInterface interface {
+	// ListSearchSynonyms returns all dictionary entries ordered by term
+	ListSearchSynonyms(ctx context.Context) ([]dto.SearchSynonym, error)
+
+	// GetSearchSynonym returns an entry by ID, or nil if it does not exist
+	GetSearchSynonym(ctx context.Context, synonymID uuid.UUID) (*dto.SearchSynonym, error)
+
+	// CreateSearchSynonym stores a new entry; dto.ErrSearchSynonymExists if its normalized term is taken
+	CreateSearchSynonym(ctx context.Context, synonym *dto.SearchSynonym) (*dto.SearchSynonym, error)
+
+	// UpdateSearchSynonym replaces an entry, returning nil if it does not exist and
+	// dto.ErrSearchSynonymExists if its normalized term is taken by another entry
+	UpdateSearchSynonym(ctx context.Context, synonym *dto.SearchSynonym) (*dto.SearchSynonym, error)
+
+	// DeleteSearchSynonym removes an entry, reporting whether it existed
+	DeleteSearchSynonym(ctx context.Context, synonymID uuid.UUID) (bool, error)
+
+	// GetSearchVocabulary returns the names of active products, their brands and tags, and category
+	// names, weighted by popularity
+	GetSearchVocabulary(ctx context.Context) ([]dto.VocabularyEntry, error)
+}
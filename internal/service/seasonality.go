@@ -0,0 +1,551 @@
+package service
+
+import (
+	"context"
+	"ec-recommend/internal/dto"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// seasonalWeeks is the number of weekly buckets per year; every bucket spans seven days except week 1,
+// which also holds the one or two days left over at the end of the previous year
+const seasonalWeeks = 52
+
+// modelRetryBackoff is how long a periodically rebuilt model waits after a failed rebuild before
+// querying the database again; requests in between get the stale model or the last error
+const modelRetryBackoff = time.Minute
+
+// seasonalLocation is the time zone in which weeks and events are resolved
+var seasonalLocation = time.FixedZone("JST", 9*60*60)
+
+// SeasonalEvent is a recurring shopping event with a fixed calendar window
+type SeasonalEvent struct {
+	Name  string `json:"name"`
+	Start string `json:"start"` // "MM-DD"
+	End   string `json:"end"`   // "MM-DD"; an end before the start wraps into the next year
+}
+
+// SeasonalityTuning configures seasonal demand indices and the seasonal ranking boost
+type SeasonalityTuning struct {
+	Disabled       bool            `json:"disabled,omitempty"`
+	LookbackYears  int             `json:"lookback_years"`  // Order history used to learn indices
+	RefreshMinutes int             `json:"refresh_minutes"` // Model rebuild interval
+	MinSupport     float64         `json:"min_support"`     // Units sold at which a learned index carries half weight
+	LeadDays       int             `json:"lead_days"`       // How far ahead demand is anticipated (gifts are bought before the event)
+	MinLift        float64         `json:"min_lift"`        // Index deviation from 1 below which demand is treated as usual
+	MaxRising      int             `json:"max_rising"`      // Rising categories/tags reported per context or event
+	MaxBoost       float64         `json:"max_boost"`       // Largest relative change to a confidence score
+	Events         []SeasonalEvent `json:"events,omitempty"`
+}
+
+// DefaultSeasonalityTuning returns the seasonality tuning used when none is configured
+func DefaultSeasonalityTuning() SeasonalityTuning {
+	return SeasonalityTuning{
+		LookbackYears:  3,
+		RefreshMinutes: 720,
+		MinSupport:     30,
+		LeadDays:       14,
+		MinLift:        0.15,
+		MaxRising:      5,
+		MaxBoost:       0.15,
+		Events: []SeasonalEvent{
+			{Name: "正月", Start: "12-28", End: "01-07"},
+			{Name: "バレンタイン", Start: "02-01", End: "02-14"},
+			{Name: "ホワイトデー", Start: "03-01", End: "03-14"},
+			{Name: "ゴールデンウィーク", Start: "04-29", End: "05-05"},
+			{Name: "お中元", Start: "07-01", End: "07-15"},
+			{Name: "お盆", Start: "08-10", End: "08-16"},
+			{Name: "お歳暮", Start: "12-01", End: "12-25"},
+			{Name: "クリスマス", Start: "12-15", End: "12-25"},
+		},
+	}
+}
+
+// ValidateSeasonalEvent checks that an event window uses valid MM-DD dates
+func ValidateSeasonalEvent(event SeasonalEvent) error {
+	if event.Name == "" {
+		return fmt.Errorf("seasonal events require a name")
+	}
+	for _, date := range []string{event.Start, event.End} {
+		if _, err := time.Parse("01-02", date); err != nil {
+			return fmt.Errorf("seasonal event %s has invalid date %q (expected MM-DD)", event.Name, date)
+		}
+	}
+
+	return nil
+}
+
+// seasonalWeek returns the weekly bucket (1..52) of a time in Japan time.
+// Days after the 52nd full week wrap into week 1 so that week 52 never holds more than seven days.
+func seasonalWeek(t time.Time) int {
+	week := (t.In(seasonalLocation).YearDay()-1)/7 + 1
+	if week > seasonalWeeks {
+		week = 1
+	}
+	return week
+}
+
+// calendarSeason returns the calendar season label of a time
+func calendarSeason(t time.Time) string {
+	switch month := t.In(seasonalLocation).Month(); {
+	case month >= 3 && month <= 5:
+		return "春"
+	case month >= 6 && month <= 8:
+		return "夏"
+	case month >= 9 && month <= 11:
+		return "秋"
+	default:
+		return "冬"
+	}
+}
+
+// seasonalSeries holds the weekly demand index of one category or tag
+type seasonalSeries struct {
+	keyType    string
+	key        string
+	categoryID int
+	support    float64
+	index      [seasonalWeeks]float64
+}
+
+// SeasonalityModel holds learned weekly demand indices per category and tag
+type SeasonalityModel struct {
+	series     map[string]map[string]*seasonalSeries // key type -> key
+	categories map[int]*seasonalSeries
+}
+
+// BuildSeasonalityModel learns weekly demand indices from historical weekly demand.
+// A key's weekly share of all units sold is averaged over the years in which the key sold,
+// divided by its mean weekly share, smoothed with neighbouring weeks and shrunk towards 1
+// for keys with little support. An index of 1.5 means 50% more demand than usual that week.
+func BuildSeasonalityModel(demand []dto.WeeklyDemand, minSupport float64) *SeasonalityModel {
+	model := &SeasonalityModel{
+		series:     make(map[string]map[string]*seasonalSeries),
+		categories: make(map[int]*seasonalSeries),
+	}
+
+	// Every unit belongs to exactly one category, so category rows give the weekly totals
+	type yearWeek struct{ year, week int }
+	totals := make(map[yearWeek]float64)
+	for _, d := range demand {
+		if d.KeyType == dto.SeasonalKeyCategory && d.Week >= 1 && d.Week <= seasonalWeeks {
+			totals[yearWeek{d.Year, d.Week}] += d.Units
+		}
+	}
+
+	type accumulator struct {
+		series *seasonalSeries
+		shares map[int]*[seasonalWeeks]float64 // year -> weekly share
+	}
+	accumulators := make(map[string]map[string]*accumulator)
+	for _, d := range demand {
+		total := totals[yearWeek{d.Year, d.Week}]
+		if total <= 0 || d.Units <= 0 || d.Key == "" {
+			continue
+		}
+		if accumulators[d.KeyType] == nil {
+			accumulators[d.KeyType] = make(map[string]*accumulator)
+		}
+		acc := accumulators[d.KeyType][d.Key]
+		if acc == nil {
+			acc = &accumulator{
+				series: &seasonalSeries{keyType: d.KeyType, key: d.Key, categoryID: d.CategoryID},
+				shares: make(map[int]*[seasonalWeeks]float64),
+			}
+			accumulators[d.KeyType][d.Key] = acc
+		}
+		if acc.shares[d.Year] == nil {
+			acc.shares[d.Year] = &[seasonalWeeks]float64{}
+		}
+		acc.shares[d.Year][d.Week-1] += d.Units / total
+		acc.series.support += d.Units
+	}
+
+	for keyType, byKey := range accumulators {
+		model.series[keyType] = make(map[string]*seasonalSeries, len(byKey))
+		for key, acc := range byKey {
+			// Average the share per week over the key's selling years, skipping weeks without any orders
+			var raw [seasonalWeeks]float64
+			var observed [seasonalWeeks]bool
+			var sum float64
+			var weeks int
+			for w := 0; w < seasonalWeeks; w++ {
+				var share float64
+				var years int
+				for year, shares := range acc.shares {
+					if totals[yearWeek{year, w + 1}] > 0 {
+						share += shares[w]
+						years++
+					}
+				}
+				if years > 0 {
+					raw[w] = share / float64(years)
+					observed[w] = true
+					sum += raw[w]
+					weeks++
+				}
+			}
+			if sum <= 0 {
+				continue
+			}
+			mean := sum / float64(weeks)
+			for w := range raw {
+				if observed[w] {
+					raw[w] /= mean
+				} else {
+					raw[w] = 1
+				}
+			}
+
+			weight := acc.series.support / (acc.series.support + minSupport)
+			for w := range raw {
+				prev := raw[(w+seasonalWeeks-1)%seasonalWeeks]
+				next := raw[(w+1)%seasonalWeeks]
+				smoothed := 0.25*prev + 0.5*raw[w] + 0.25*next
+				acc.series.index[w] = 1 + (smoothed-1)*weight
+			}
+
+			model.series[keyType][key] = acc.series
+			if keyType == dto.SeasonalKeyCategory && acc.series.categoryID != 0 {
+				model.categories[acc.series.categoryID] = acc.series
+			}
+		}
+	}
+
+	return model
+}
+
+// Index returns the demand index of a category or tag at the given time, or 1 when unknown
+func (m *SeasonalityModel) Index(keyType, key string, at time.Time) float64 {
+	if series := m.series[keyType][key]; series != nil {
+		return series.index[seasonalWeek(at)-1]
+	}
+	return 1
+}
+
+// CategoryIndex returns the demand index of a category at the given time, or 1 when unknown
+func (m *SeasonalityModel) CategoryIndex(categoryID int, at time.Time) float64 {
+	if series := m.categories[categoryID]; series != nil {
+		return series.index[seasonalWeek(at)-1]
+	}
+	return 1
+}
+
+// Rising returns the keys whose demand index at the given time exceeds 1 + minLift, highest first
+func (m *SeasonalityModel) Rising(keyType string, at time.Time, minLift float64, limit int) []dto.SeasonalIndex {
+	return m.peaks(keyType, []int{seasonalWeek(at)}, minLift, limit)
+}
+
+// peaks returns the keys whose mean index over the given weeks exceeds 1 + minLift, highest first
+func (m *SeasonalityModel) peaks(keyType string, weeks []int, minLift float64, limit int) []dto.SeasonalIndex {
+	if len(weeks) == 0 {
+		return nil
+	}
+
+	var peaks []dto.SeasonalIndex
+	for key, series := range m.series[keyType] {
+		var sum float64
+		for _, week := range weeks {
+			sum += series.index[week-1]
+		}
+		if index := sum / float64(len(weeks)); index >= 1+minLift {
+			peaks = append(peaks, dto.SeasonalIndex{
+				KeyType:    keyType,
+				Key:        key,
+				CategoryID: series.categoryID,
+				Index:      index,
+				Support:    series.support,
+			})
+		}
+	}
+
+	sort.Slice(peaks, func(i, j int) bool {
+		if peaks[i].Index != peaks[j].Index {
+			return peaks[i].Index > peaks[j].Index
+		}
+		return peaks[i].Key < peaks[j].Key
+	})
+	if limit > 0 && len(peaks) > limit {
+		peaks = peaks[:limit]
+	}
+
+	return peaks
+}
+
+// Context summarizes active events and rising categories and tags, looking LeadDays ahead of now
+func (m *SeasonalityModel) Context(now time.Time, tuning SeasonalityTuning) *dto.SeasonalContext {
+	at := now.AddDate(0, 0, tuning.LeadDays)
+	seasonal := &dto.SeasonalContext{
+		Season:           calendarSeason(now),
+		RisingCategories: m.Rising(dto.SeasonalKeyCategory, at, tuning.MinLift, tuning.MaxRising),
+		RisingTags:       m.Rising(dto.SeasonalKeyTag, at, tuning.MinLift, tuning.MaxRising),
+	}
+
+	for _, event := range tuning.Events {
+		start, end, ok := eventWindow(event, now, tuning.LeadDays)
+		if !ok {
+			continue
+		}
+		weeks := eventWeeks(start, end)
+		seasonal.ActiveEvents = append(seasonal.ActiveEvents, dto.ActiveSeasonalEvent{
+			Name:           event.Name,
+			StartsAt:       start,
+			EndsAt:         end,
+			PeakCategories: m.peaks(dto.SeasonalKeyCategory, weeks, tuning.MinLift, tuning.MaxRising),
+			PeakTags:       m.peaks(dto.SeasonalKeyTag, weeks, tuning.MinLift, tuning.MaxRising),
+		})
+	}
+
+	return seasonal
+}
+
+// eventWindow returns the occurrence of an event that is in progress or starts within leadDays of now
+func eventWindow(event SeasonalEvent, now time.Time, leadDays int) (time.Time, time.Time, bool) {
+	startDate, err := time.Parse("01-02", event.Start)
+	if err != nil {
+		return time.Time{}, time.Time{}, false
+	}
+	endDate, err := time.Parse("01-02", event.End)
+	if err != nil {
+		return time.Time{}, time.Time{}, false
+	}
+
+	local := now.In(seasonalLocation)
+	horizon := local.AddDate(0, 0, leadDays)
+	for year := local.Year() - 1; year <= local.Year()+1; year++ {
+		start := time.Date(year, startDate.Month(), startDate.Day(), 0, 0, 0, 0, seasonalLocation)
+		end := time.Date(year, endDate.Month(), endDate.Day(), 23, 59, 59, 0, seasonalLocation)
+		if end.Before(start) {
+			end = end.AddDate(1, 0, 0)
+		}
+		if !end.Before(local) && !start.After(horizon) {
+			return start, end, true
+		}
+	}
+
+	return time.Time{}, time.Time{}, false
+}
+
+// eventWeeks returns the distinct weekly buckets covered by an event window
+func eventWeeks(start, end time.Time) []int {
+	seen := make(map[int]bool)
+	var weeks []int
+	for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+		if week := seasonalWeek(day); !seen[week] {
+			seen[week] = true
+			weeks = append(weeks, week)
+		}
+	}
+	return weeks
+}
+
+// SeasonalityService serves seasonal demand indices from a periodically rebuilt model
+type SeasonalityService struct {
+	repo   SeasonalityRepositoryInterface
+	tuning SeasonalityTuning
+
+	mu       sync.Mutex
+	model    *SeasonalityModel
+	builtAt  time.Time
+	failedAt time.Time
+	failure  error
+}
+
+// NewSeasonalityService creates a new seasonality service; the model is built on first use
+func NewSeasonalityService(repo SeasonalityRepositoryInterface, tuning SeasonalityTuning) *SeasonalityService {
+	if tuning.LookbackYears <= 0 {
+		tuning.LookbackYears = 3
+	}
+	if tuning.RefreshMinutes <= 0 {
+		tuning.RefreshMinutes = 720
+	}
+	if tuning.MaxRising <= 0 {
+		tuning.MaxRising = 5
+	}
+
+	return &SeasonalityService{
+		repo:   repo,
+		tuning: tuning,
+	}
+}
+
+// Context returns the learned seasonal context at the given time
+func (ss *SeasonalityService) Context(ctx context.Context, now time.Time) (*dto.SeasonalContext, error) {
+	model, err := ss.currentModel(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return model.Context(now, ss.tuning), nil
+}
+
+// currentModel returns the model, rebuilding it when it is older than the refresh interval.
+// After a failed rebuild the database is not queried again until the retry backoff has passed.
+func (ss *SeasonalityService) currentModel(ctx context.Context) (*SeasonalityModel, error) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	if ss.model != nil && time.Since(ss.builtAt) < time.Duration(ss.tuning.RefreshMinutes)*time.Minute {
+		return ss.model, nil
+	}
+	if ss.failure != nil && time.Since(ss.failedAt) < modelRetryBackoff {
+		if ss.model != nil {
+			return ss.model, nil
+		}
+		return nil, ss.failure
+	}
+
+	since := time.Now().AddDate(-ss.tuning.LookbackYears, 0, 0)
+	demand, err := ss.repo.GetWeeklyDemand(ctx, since)
+	if err != nil {
+		ss.failedAt = time.Now()
+		ss.failure = fmt.Errorf("failed to get weekly demand: %w", err)
+		// Keep serving a stale model rather than failing requests
+		if ss.model != nil {
+			return ss.model, nil
+		}
+		return nil, ss.failure
+	}
+	ss.failure = nil
+
+	ss.model = BuildSeasonalityModel(demand, ss.tuning.MinSupport)
+	ss.builtAt = time.Now()
+
+	return ss.model, nil
+}
+
+// SeasonalityReranker boosts products whose category or tags are in seasonal demand
+// and demotes off-season ones
+type SeasonalityReranker struct {
+	seasonality *SeasonalityService
+}
+
+// NewSeasonalityReranker creates a seasonality post-ranking stage
+func NewSeasonalityReranker(seasonality *SeasonalityService) *SeasonalityReranker {
+	return &SeasonalityReranker{
+		seasonality: seasonality,
+	}
+}
+
+// Name returns the stage identifier
+func (sr *SeasonalityReranker) Name() string {
+	return "seasonality"
+}
+
+// Apply multiplies each confidence score by 1 + MaxBoost * lift, where lift is the deviation from 1
+// of the product's strongest category or tag index LeadDays ahead, and re-sorts by confidence
+func (sr *SeasonalityReranker) Apply(ctx context.Context, recommendations []dto.ProductRecommendationV2, rc *RankingContext) ([]dto.ProductRecommendationV2, error) {
+	tuning := sr.seasonality.tuning
+	if tuning.Disabled || len(recommendations) == 0 {
+		return recommendations, nil
+	}
+
+	model, err := sr.seasonality.currentModel(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	at := now.AddDate(0, 0, tuning.LeadDays)
+	seasonal := model.Context(now, tuning)
+
+	for i := range recommendations {
+		rec := &recommendations[i]
+
+		keyType, key, index := dto.SeasonalKeyCategory, rec.CategoryName, model.CategoryIndex(rec.CategoryID, at)
+		for _, tag := range rec.Tags {
+			if tagIndex := model.Index(dto.SeasonalKeyTag, tag, at); math.Abs(tagIndex-1) > math.Abs(index-1) {
+				keyType, key, index = dto.SeasonalKeyTag, tag, tagIndex
+			}
+		}
+		lift := index - 1
+		if math.Abs(lift) < tuning.MinLift {
+			continue
+		}
+
+		event := peakEvent(seasonal, keyType, key)
+		var explanation string
+		switch {
+		case lift > 0 && event != "":
+			explanation = fmt.Sprintf("%s is in peak demand for %s", key, event)
+		case lift > 0:
+			explanation = fmt.Sprintf("Seasonal demand for %s is %.0f%% above usual", key, lift*100)
+		default:
+			explanation = fmt.Sprintf("%s is off-season (%.0f%% below usual demand)", key, -lift*100)
+		}
+
+		sourceData := fmt.Sprintf("key_type=%s key=%s index=%.2f", keyType, key, index)
+		if event != "" {
+			sourceData += " event=" + event
+		}
+
+		rec.ConfidenceScore *= 1 + tuning.MaxBoost*clampUnit(lift)
+		rec.RelevanceContext = append(rec.RelevanceContext, dto.RelevanceContext{
+			ContextType: "seasonal",
+			Explanation: explanation,
+			Confidence:  math.Min(1, math.Abs(lift)),
+			SourceData:  sourceData,
+		})
+	}
+
+	sort.SliceStable(recommendations, func(i, j int) bool {
+		return recommendations[i].ConfidenceScore > recommendations[j].ConfidenceScore
+	})
+
+	return recommendations, nil
+}
+
+// peakEvent returns the first active event at whose peak the key sells, or an empty string
+func peakEvent(seasonal *dto.SeasonalContext, keyType, key string) string {
+	for _, event := range seasonal.ActiveEvents {
+		peaks := event.PeakCategories
+		if keyType == dto.SeasonalKeyTag {
+			peaks = event.PeakTags
+		}
+		for _, peak := range peaks {
+			if peak.Key == key {
+				return event.Name
+			}
+		}
+	}
+	return ""
+}
+
+// seasonalKeywords flattens a seasonal context into event names followed by rising categories and tags
+func seasonalKeywords(seasonal *dto.SeasonalContext) []string {
+	if seasonal == nil {
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	var keywords []string
+	add := func(keyword string) {
+		if keyword != "" && !seen[keyword] {
+			seen[keyword] = true
+			keywords = append(keywords, keyword)
+		}
+	}
+	for _, event := range seasonal.ActiveEvents {
+		add(event.Name)
+	}
+	for _, category := range seasonal.RisingCategories {
+		add(category.Key)
+	}
+	for _, tag := range seasonal.RisingTags {
+		add(tag.Key)
+	}
+
+	return keywords
+}
+
+// formatSeasonalIndices formats indices as "key (+35%)" for prompts
+func formatSeasonalIndices(indices []dto.SeasonalIndex) string {
+	parts := make([]string, len(indices))
+	for i, index := range indices {
+		parts[i] = fmt.Sprintf("%s (+%.0f%%)", index.Key, (index.Index-1)*100)
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,14 @@
+package service
+
+import (
+	"context"
+	"ec-recommend/internal/dto"
+	"time"
+)
+
+// SeasonalityRepositoryInterface defines queries over historical order demand
+// This interface is defined in the service package as it is consumed by services
+type SeasonalityRepositoryInterface interface {
+	// GetWeeklyDemand returns units sold per year and week for every category and product tag since the given time
+	GetWeeklyDemand(ctx context.Context, since time.Time) ([]dto.WeeklyDemand, error)
+}
@@ -0,0 +1,187 @@
+package service
+
+import (
+	"context"
+	"ec-recommend/internal/dto"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// fakeSeasonalityRepository returns fixed weekly demand or a fixed error
+type fakeSeasonalityRepository struct {
+	demand []dto.WeeklyDemand
+	err    error
+	calls  int
+}
+
+func (f *fakeSeasonalityRepository) GetWeeklyDemand(ctx context.Context, since time.Time) ([]dto.WeeklyDemand, error) {
+	f.calls++
+	return f.demand, f.err
+}
+
+// seasonalDemand builds two years of demand where food and the "gift" tag peak in the given weeks
+func seasonalDemand(peakWeeks map[int]bool) []dto.WeeklyDemand {
+	var demand []dto.WeeklyDemand
+	for _, year := range []int{2023, 2024} {
+		for week := 1; week <= seasonalWeeks; week++ {
+			food := 10.0
+			if peakWeeks[week] {
+				food = 100
+			}
+			demand = append(demand,
+				dto.WeeklyDemand{KeyType: dto.SeasonalKeyCategory, Key: "electronics", CategoryID: 1, Year: year, Week: week, Units: 100},
+				dto.WeeklyDemand{KeyType: dto.SeasonalKeyCategory, Key: "food", CategoryID: 7, Year: year, Week: week, Units: food},
+				dto.WeeklyDemand{KeyType: dto.SeasonalKeyTag, Key: "gift", Year: year, Week: week, Units: food},
+			)
+		}
+	}
+	return demand
+}
+
+func TestSeasonalityModel(t *testing.T) {
+	tuning := DefaultSeasonalityTuning()
+	// お中元 (07-01..07-15) covers weeks 26 to 28
+	model := BuildSeasonalityModel(seasonalDemand(map[int]bool{26: true, 27: true, 28: true}), tuning.MinSupport)
+
+	chugen := time.Date(2025, 7, 8, 12, 0, 0, 0, seasonalLocation)
+	spring := time.Date(2025, 3, 10, 12, 0, 0, 0, seasonalLocation)
+
+	t.Run("learns peaks and troughs per category and tag", func(t *testing.T) {
+		if index := model.CategoryIndex(7, chugen); index < 2 {
+			t.Errorf("Expected food index well above 1 in July, got %.2f", index)
+		}
+		if index := model.CategoryIndex(7, spring); index >= 1 {
+			t.Errorf("Expected food index below 1 in March, got %.2f", index)
+		}
+		if index := model.Index(dto.SeasonalKeyTag, "gift", chugen); index < 2 {
+			t.Errorf("Expected gift tag index well above 1 in July, got %.2f", index)
+		}
+		if index := model.Index(dto.SeasonalKeyTag, "unknown", chugen); index != 1 {
+			t.Errorf("Expected unknown keys to have index 1, got %.2f", index)
+		}
+	})
+
+	t.Run("reports active events with their peak categories", func(t *testing.T) {
+		seasonal := model.Context(chugen, tuning)
+		if seasonal.Season != "夏" {
+			t.Errorf("Expected season 夏, got %s", seasonal.Season)
+		}
+
+		var found bool
+		for _, event := range seasonal.ActiveEvents {
+			if event.Name == "お中元" {
+				found = true
+				if len(event.PeakCategories) != 1 || event.PeakCategories[0].Key != "food" {
+					t.Errorf("Expected food to peak for お中元, got %+v", event.PeakCategories)
+				}
+			}
+		}
+		if !found {
+			t.Errorf("Expected お中元 to be active, got %+v", seasonal.ActiveEvents)
+		}
+		if len(seasonal.RisingTags) == 0 || seasonal.RisingTags[0].Key != "gift" {
+			t.Errorf("Expected gift as a rising tag, got %+v", seasonal.RisingTags)
+		}
+	})
+
+	t.Run("shrinks indices with little support", func(t *testing.T) {
+		weak := BuildSeasonalityModel(seasonalDemand(map[int]bool{26: true, 27: true, 28: true}), 1e6)
+		if index := weak.CategoryIndex(7, chugen); index > 1.1 {
+			t.Errorf("Expected index shrunk towards 1, got %.2f", index)
+		}
+	})
+
+	t.Run("event windows wrap the year end and include the lead time", func(t *testing.T) {
+		newYear := SeasonalEvent{Name: "正月", Start: "12-28", End: "01-07"}
+		if _, end, ok := eventWindow(newYear, time.Date(2025, 1, 3, 0, 0, 0, 0, seasonalLocation), 0); !ok || end.Year() != 2025 {
+			t.Errorf("Expected 正月 to be active in early January, got ok=%v end=%v", ok, end)
+		}
+		if _, _, ok := eventWindow(newYear, time.Date(2025, 12, 20, 0, 0, 0, 0, seasonalLocation), 14); !ok {
+			t.Error("Expected 正月 to be approaching within the lead time")
+		}
+		if _, _, ok := eventWindow(newYear, time.Date(2025, 6, 1, 0, 0, 0, 0, seasonalLocation), 14); ok {
+			t.Error("Expected 正月 to be inactive in June")
+		}
+	})
+}
+
+func TestSeasonalityReranker(t *testing.T) {
+	tuning := DefaultSeasonalityTuning()
+	week := seasonalWeek(time.Now().AddDate(0, 0, tuning.LeadDays))
+	repo := &fakeSeasonalityRepository{demand: seasonalDemand(map[int]bool{week: true})}
+	stage := NewSeasonalityReranker(NewSeasonalityService(repo, tuning))
+
+	gadget := dto.ProductRecommendationV2{ProductID: uuid.New(), CategoryID: 1, CategoryName: "electronics", ConfidenceScore: 0.8}
+	snack := dto.ProductRecommendationV2{ProductID: uuid.New(), CategoryID: 7, CategoryName: "food", Tags: []string{"gift"}, ConfidenceScore: 0.75}
+
+	ranked, err := stage.Apply(context.Background(), []dto.ProductRecommendationV2{gadget, snack}, &RankingContext{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if ranked[0].ProductID != snack.ProductID {
+		t.Errorf("Expected the in-season product first, got %s", ranked[0].Name)
+	}
+	if len(ranked[0].RelevanceContext) != 1 || ranked[0].RelevanceContext[0].ContextType != "seasonal" {
+		t.Errorf("Expected seasonal relevance context, got %+v", ranked[0].RelevanceContext)
+	}
+	// Electronics sells steadily, so its share of demand dips while food peaks
+	if ranked[1].ConfidenceScore >= gadget.ConfidenceScore {
+		t.Errorf("Expected the off-season product to be demoted, got %.3f", ranked[1].ConfidenceScore)
+	}
+
+	if _, err := stage.Apply(context.Background(), []dto.ProductRecommendationV2{gadget}, &RankingContext{}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if repo.calls != 1 {
+		t.Errorf("Expected the model to be cached, got %d builds", repo.calls)
+	}
+}
+
+func TestSeasonalWeek(t *testing.T) {
+	tests := []struct {
+		name string
+		date time.Time
+		want int
+	}{
+		{name: "first day of the year", date: time.Date(2025, 1, 1, 12, 0, 0, 0, seasonalLocation), want: 1},
+		{name: "last full week", date: time.Date(2025, 12, 29, 12, 0, 0, 0, seasonalLocation), want: 52},
+		{name: "leftover day wraps into week 1", date: time.Date(2025, 12, 31, 12, 0, 0, 0, seasonalLocation), want: 1},
+		{name: "leap day leftover wraps into week 1", date: time.Date(2024, 12, 30, 12, 0, 0, 0, seasonalLocation), want: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := seasonalWeek(tt.date); got != tt.want {
+				t.Errorf("Expected week %d, got %d", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestSeasonalityServiceBacksOffFailedRebuilds(t *testing.T) {
+	repo := &fakeSeasonalityRepository{err: errors.New("connection refused")}
+	ss := NewSeasonalityService(repo, DefaultSeasonalityTuning())
+
+	for i := 0; i < 3; i++ {
+		if _, err := ss.currentModel(context.Background()); err == nil {
+			t.Fatal("Expected an error without a model")
+		}
+	}
+	if repo.calls != 1 {
+		t.Errorf("Expected one query within the retry backoff, got %d", repo.calls)
+	}
+
+	// Once the backoff has passed the rebuild is retried
+	ss.failedAt = time.Now().Add(-modelRetryBackoff)
+	repo.err = nil
+	repo.demand = seasonalDemand(nil)
+	if _, err := ss.currentModel(context.Background()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if repo.calls != 2 {
+		t.Errorf("Expected the rebuild to be retried, got %d queries", repo.calls)
+	}
+}
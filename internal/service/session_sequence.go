@@ -0,0 +1,301 @@
+package service
+
+import (
+	"context"
+	"ec-recommend/internal/dto"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Prediction sources of the session sequence model
+const (
+	SequenceSourceProduct  = "product_transition"
+	SequenceSourceCategory = "category_transition"
+)
+
+// SessionSequenceTuning configures the session-sequence next-item recommender
+type SessionSequenceTuning struct {
+	LookbackDays               int     `json:"lookback_days"`                 // Activity window used to learn transitions
+	RefreshMinutes             int     `json:"refresh_minutes"`               // Model rebuild interval
+	HistoryLength              int     `json:"history_length"`                // Recent session products used as context
+	HistoryDecay               float64 `json:"history_decay"`                 // Weight multiplier per step back in the session
+	MinSupport                 int     `json:"min_support"`                   // Outgoing transitions required before trusting a product
+	BackoffWeight              float64 `json:"backoff_weight"`                // Weight of category-level predictions
+	BackoffProductsPerCategory int     `json:"backoff_products_per_category"` // Candidates drawn from each predicted category
+}
+
+// SessionItem is a product viewed in the current session
+type SessionItem struct {
+	ProductID  uuid.UUID
+	CategoryID int
+}
+
+// SequencePrediction is a product predicted to be viewed next
+type SequencePrediction struct {
+	ProductID   uuid.UUID
+	Score       float64
+	Source      string    // "product_transition" or "category_transition"
+	AfterItemID uuid.UUID // Session product that contributed most to the prediction
+}
+
+// SessionSequenceModel is a first-order Markov chain over products with category-level back-off
+type SessionSequenceModel struct {
+	productTransitions  map[uuid.UUID]map[uuid.UUID]float64
+	productTotals       map[uuid.UUID]float64
+	categoryTransitions map[int]map[int]float64
+	categoryTotals      map[int]float64
+	categoryProducts    map[int][]weightedProduct // Most frequent transition targets per category
+}
+
+type weightedProduct struct {
+	productID uuid.UUID
+	share     float64
+}
+
+// BuildSessionSequenceModel builds transition probabilities from aggregated session transitions
+func BuildSessionSequenceModel(transitions []dto.ProductTransition, productsPerCategory int) *SessionSequenceModel {
+	model := &SessionSequenceModel{
+		productTransitions:  make(map[uuid.UUID]map[uuid.UUID]float64),
+		productTotals:       make(map[uuid.UUID]float64),
+		categoryTransitions: make(map[int]map[int]float64),
+		categoryTotals:      make(map[int]float64),
+		categoryProducts:    make(map[int][]weightedProduct),
+	}
+
+	incoming := make(map[uuid.UUID]float64)
+	targetCategory := make(map[uuid.UUID]int)
+	for _, t := range transitions {
+		if t.FromProductID == t.ToProductID || t.Count <= 0 {
+			continue
+		}
+		count := float64(t.Count)
+
+		if model.productTransitions[t.FromProductID] == nil {
+			model.productTransitions[t.FromProductID] = make(map[uuid.UUID]float64)
+		}
+		model.productTransitions[t.FromProductID][t.ToProductID] += count
+		model.productTotals[t.FromProductID] += count
+
+		if model.categoryTransitions[t.FromCategoryID] == nil {
+			model.categoryTransitions[t.FromCategoryID] = make(map[int]float64)
+		}
+		model.categoryTransitions[t.FromCategoryID][t.ToCategoryID] += count
+		model.categoryTotals[t.FromCategoryID] += count
+
+		incoming[t.ToProductID] += count
+		targetCategory[t.ToProductID] = t.ToCategoryID
+	}
+
+	byCategory := make(map[int][]weightedProduct)
+	for productID, count := range incoming {
+		categoryID := targetCategory[productID]
+		byCategory[categoryID] = append(byCategory[categoryID], weightedProduct{productID: productID, share: count})
+	}
+	for categoryID, products := range byCategory {
+		sortWeightedProducts(products)
+		if productsPerCategory > 0 && len(products) > productsPerCategory {
+			products = products[:productsPerCategory]
+		}
+
+		var total float64
+		for _, p := range products {
+			total += p.share
+		}
+		for i := range products {
+			products[i].share /= total
+		}
+		model.categoryProducts[categoryID] = products
+	}
+
+	return model
+}
+
+// Predict scores next products given the recent session, most recent item last.
+// Products with fewer than MinSupport outgoing transitions back off to category transitions.
+func (m *SessionSequenceModel) Predict(recent []SessionItem, tuning SessionSequenceTuning, limit int) []SequencePrediction {
+	seen := make(map[uuid.UUID]bool, len(recent))
+	for _, item := range recent {
+		seen[item.ProductID] = true
+	}
+
+	type candidate struct {
+		productScore  float64
+		categoryScore float64
+		best          float64
+		afterItemID   uuid.UUID
+	}
+	candidates := make(map[uuid.UUID]*candidate)
+	add := func(productID uuid.UUID, contribution float64, fromProduct bool, after uuid.UUID) {
+		if seen[productID] || contribution <= 0 {
+			return
+		}
+		c := candidates[productID]
+		if c == nil {
+			c = &candidate{}
+			candidates[productID] = c
+		}
+		if fromProduct {
+			c.productScore += contribution
+		} else {
+			c.categoryScore += contribution
+		}
+		if contribution > c.best {
+			c.best = contribution
+			c.afterItemID = after
+		}
+	}
+
+	weight := 1.0
+	for step := 0; step < tuning.HistoryLength && step < len(recent); step++ {
+		item := recent[len(recent)-1-step]
+
+		if total := m.productTotals[item.ProductID]; total >= float64(tuning.MinSupport) && total > 0 {
+			for next, count := range m.productTransitions[item.ProductID] {
+				add(next, weight*count/total, true, item.ProductID)
+			}
+		} else if total := m.categoryTotals[item.CategoryID]; total > 0 {
+			for nextCategory, count := range m.categoryTransitions[item.CategoryID] {
+				categoryProbability := count / total
+				for _, p := range m.categoryProducts[nextCategory] {
+					add(p.productID, weight*tuning.BackoffWeight*categoryProbability*p.share, false, item.ProductID)
+				}
+			}
+		}
+
+		weight *= tuning.HistoryDecay
+	}
+
+	predictions := make([]SequencePrediction, 0, len(candidates))
+	for productID, c := range candidates {
+		source := SequenceSourceProduct
+		if c.productScore == 0 {
+			source = SequenceSourceCategory
+		}
+		predictions = append(predictions, SequencePrediction{
+			ProductID:   productID,
+			Score:       c.productScore + c.categoryScore,
+			Source:      source,
+			AfterItemID: c.afterItemID,
+		})
+	}
+	sort.Slice(predictions, func(i, j int) bool {
+		if predictions[i].Score != predictions[j].Score {
+			return predictions[i].Score > predictions[j].Score
+		}
+		return predictions[i].ProductID.String() < predictions[j].ProductID.String()
+	})
+
+	if limit > 0 && len(predictions) > limit {
+		predictions = predictions[:limit]
+	}
+
+	return predictions
+}
+
+// SessionSequenceRecommender serves "what to view next" predictions from a periodically rebuilt model
+type SessionSequenceRecommender struct {
+	repo   SessionSequenceRepositoryInterface
+	tuning SessionSequenceTuning
+
+	mu       sync.Mutex
+	model    *SessionSequenceModel
+	builtAt  time.Time
+	failedAt time.Time
+	failure  error
+}
+
+// NewSessionSequenceRecommender creates a new session sequence recommender; the model is built on first use
+func NewSessionSequenceRecommender(repo SessionSequenceRepositoryInterface, tuning SessionSequenceTuning) *SessionSequenceRecommender {
+	if tuning.LookbackDays <= 0 {
+		tuning.LookbackDays = 90
+	}
+	if tuning.RefreshMinutes <= 0 {
+		tuning.RefreshMinutes = 60
+	}
+	if tuning.HistoryLength <= 0 {
+		tuning.HistoryLength = 3
+	}
+	if tuning.HistoryDecay <= 0 || tuning.HistoryDecay > 1 {
+		tuning.HistoryDecay = 0.5
+	}
+
+	return &SessionSequenceRecommender{
+		repo:   repo,
+		tuning: tuning,
+	}
+}
+
+// HistoryLength returns how many recent session products are used as context
+func (sr *SessionSequenceRecommender) HistoryLength() int {
+	return sr.tuning.HistoryLength
+}
+
+// SessionProducts returns the recent products of a customer's browsing session, oldest first
+func (sr *SessionSequenceRecommender) SessionProducts(ctx context.Context, customerID, sessionID uuid.UUID) ([]uuid.UUID, error) {
+	productIDs, err := sr.repo.GetSessionProducts(ctx, customerID, sessionID, sr.tuning.HistoryLength)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session products: %w", err)
+	}
+
+	return productIDs, nil
+}
+
+// Predict returns the products most likely to be viewed after the given session items
+func (sr *SessionSequenceRecommender) Predict(ctx context.Context, recent []SessionItem, limit int) ([]SequencePrediction, error) {
+	model, err := sr.currentModel(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return model.Predict(recent, sr.tuning, limit), nil
+}
+
+// currentModel returns the model, rebuilding it when it is older than the refresh interval.
+// After a failed rebuild the database is not queried again until the retry backoff has passed.
+func (sr *SessionSequenceRecommender) currentModel(ctx context.Context) (*SessionSequenceModel, error) {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+
+	if sr.model != nil && time.Since(sr.builtAt) < time.Duration(sr.tuning.RefreshMinutes)*time.Minute {
+		return sr.model, nil
+	}
+	if sr.failure != nil && time.Since(sr.failedAt) < modelRetryBackoff {
+		if sr.model != nil {
+			return sr.model, nil
+		}
+		return nil, sr.failure
+	}
+
+	since := time.Now().AddDate(0, 0, -sr.tuning.LookbackDays)
+	transitions, err := sr.repo.GetSessionTransitions(ctx, since)
+	if err != nil {
+		sr.failedAt = time.Now()
+		sr.failure = fmt.Errorf("failed to get session transitions: %w", err)
+		// Keep serving a stale model rather than failing requests
+		if sr.model != nil {
+			return sr.model, nil
+		}
+		return nil, sr.failure
+	}
+	sr.failure = nil
+
+	sr.model = BuildSessionSequenceModel(transitions, sr.tuning.BackoffProductsPerCategory)
+	sr.builtAt = time.Now()
+
+	return sr.model, nil
+}
+
+// sortWeightedProducts orders products by weight, breaking ties by ID for determinism
+func sortWeightedProducts(products []weightedProduct) {
+	sort.Slice(products, func(i, j int) bool {
+		if math.Abs(products[i].share-products[j].share) > 1e-12 {
+			return products[i].share > products[j].share
+		}
+		return products[i].productID.String() < products[j].productID.String()
+	})
+}
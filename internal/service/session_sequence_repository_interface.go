@@ -0,0 +1,19 @@
+package service
+
+import (
+	"context"
+	"ec-recommend/internal/dto"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SessionSequenceRepositoryInterface defines queries over in-session browsing activity
+// This interface is defined in the service package as it is consumed by services
+type SessionSequenceRepositoryInterface interface {
+	// GetSessionTransitions aggregates consecutive product activities within sessions since the given time
+	GetSessionTransitions(ctx context.Context, since time.Time) ([]dto.ProductTransition, error)
+
+	// GetSessionProducts returns the most recent products of a customer's session, oldest first
+	GetSessionProducts(ctx context.Context, customerID, sessionID uuid.UUID, limit int) ([]uuid.UUID, error)
+}
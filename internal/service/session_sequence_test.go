@@ -0,0 +1,198 @@
+package service
+
+import (
+	"context"
+	"ec-recommend/internal/dto"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// fakeSessionSequenceRepository returns fixed transitions or a fixed error and counts model rebuilds
+type fakeSessionSequenceRepository struct {
+	transitions []dto.ProductTransition
+	err         error
+	loads       int
+}
+
+func (f *fakeSessionSequenceRepository) GetSessionTransitions(ctx context.Context, since time.Time) ([]dto.ProductTransition, error) {
+	f.loads++
+	return f.transitions, f.err
+}
+
+func (f *fakeSessionSequenceRepository) GetSessionProducts(ctx context.Context, customerID, sessionID uuid.UUID, limit int) ([]uuid.UUID, error) {
+	return nil, nil
+}
+
+func TestSessionSequenceModel(t *testing.T) {
+	phone, caseA, caseB, charger := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	newPhone := uuid.New()
+	transitions := []dto.ProductTransition{
+		{FromProductID: phone, ToProductID: caseA, FromCategoryID: 1, ToCategoryID: 2, Count: 6},
+		{FromProductID: phone, ToProductID: caseB, FromCategoryID: 1, ToCategoryID: 2, Count: 2},
+		{FromProductID: phone, ToProductID: charger, FromCategoryID: 1, ToCategoryID: 3, Count: 2},
+	}
+	tuning := SessionSequenceTuning{
+		HistoryLength:              3,
+		HistoryDecay:               0.5,
+		MinSupport:                 3,
+		BackoffWeight:              0.3,
+		BackoffProductsPerCategory: 5,
+	}
+	model := BuildSessionSequenceModel(transitions, tuning.BackoffProductsPerCategory)
+
+	t.Run("well-supported products use product transitions", func(t *testing.T) {
+		predictions := model.Predict([]SessionItem{{ProductID: phone, CategoryID: 1}}, tuning, 10)
+		if len(predictions) != 3 {
+			t.Fatalf("Expected 3 predictions, got %d", len(predictions))
+		}
+		if predictions[0].ProductID != caseA || predictions[0].Source != SequenceSourceProduct {
+			t.Errorf("Expected caseA from product transitions first, got %s from %s", predictions[0].ProductID, predictions[0].Source)
+		}
+		if predictions[0].Score != 0.6 {
+			t.Errorf("Expected score 0.6, got %f", predictions[0].Score)
+		}
+		if predictions[0].AfterItemID != phone {
+			t.Errorf("Expected prediction to be attributed to the phone, got %s", predictions[0].AfterItemID)
+		}
+	})
+
+	t.Run("unseen products back off to category transitions", func(t *testing.T) {
+		predictions := model.Predict([]SessionItem{{ProductID: newPhone, CategoryID: 1}}, tuning, 10)
+		if len(predictions) != 3 {
+			t.Fatalf("Expected 3 predictions, got %d", len(predictions))
+		}
+		for _, p := range predictions {
+			if p.Source != SequenceSourceCategory {
+				t.Errorf("Expected category source, got %s", p.Source)
+			}
+		}
+		// Category 1 -> 2 has probability 0.8 and caseA holds 75% of category 2 transitions
+		if predictions[0].ProductID != caseA || math.Abs(predictions[0].Score-0.3*0.8*0.75) > 1e-9 {
+			t.Errorf("Expected caseA with back-off score, got %s with %f", predictions[0].ProductID, predictions[0].Score)
+		}
+	})
+
+	t.Run("session products are excluded", func(t *testing.T) {
+		recent := []SessionItem{{ProductID: caseA, CategoryID: 2}, {ProductID: phone, CategoryID: 1}}
+		for _, p := range model.Predict(recent, tuning, 10) {
+			if p.ProductID == caseA || p.ProductID == phone {
+				t.Errorf("Expected viewed product %s to be excluded", p.ProductID)
+			}
+		}
+	})
+
+	t.Run("recommender caches the model between refreshes", func(t *testing.T) {
+		repo := &fakeSessionSequenceRepository{transitions: transitions}
+		recommender := NewSessionSequenceRecommender(repo, tuning)
+
+		for i := 0; i < 3; i++ {
+			if _, err := recommender.Predict(context.Background(), []SessionItem{{ProductID: phone, CategoryID: 1}}, 2); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+		}
+		if repo.loads != 1 {
+			t.Errorf("Expected 1 model build, got %d", repo.loads)
+		}
+	})
+
+	t.Run("recommender backs off after a failed build", func(t *testing.T) {
+		repo := &fakeSessionSequenceRepository{err: errors.New("connection refused")}
+		recommender := NewSessionSequenceRecommender(repo, tuning)
+
+		for i := 0; i < 3; i++ {
+			if _, err := recommender.Predict(context.Background(), []SessionItem{{ProductID: phone, CategoryID: 1}}, 2); err == nil {
+				t.Fatal("Expected an error without a model")
+			}
+		}
+		if repo.loads != 1 {
+			t.Errorf("Expected 1 model build within the retry backoff, got %d", repo.loads)
+		}
+	})
+}
+
+// fakeProductRepositoryV2 serves product lookups;
+// every other method is left to the nil embedded interface
+type fakeProductRepositoryV2 struct {
+	RecommendationRepositoryV2Interface
+	products map[uuid.UUID]dto.ProductRecommendationV2
+}
+
+func (f *fakeProductRepositoryV2) GetProductsByIDs(ctx context.Context, productIDs []uuid.UUID) ([]dto.ProductRecommendationV2, error) {
+	var products []dto.ProductRecommendationV2
+	for _, productID := range productIDs {
+		if product, ok := f.products[productID]; ok {
+			products = append(products, product)
+		}
+	}
+	return products, nil
+}
+
+func TestHybridRecommendationsUseSessionSequenceWeight(t *testing.T) {
+	phone, phoneCase := uuid.New(), uuid.New()
+	repo := &fakeProductRepositoryV2{products: map[uuid.UUID]dto.ProductRecommendationV2{
+		phone:     {ProductID: phone, Name: "phone", CategoryID: 1},
+		phoneCase: {ProductID: phoneCase, Name: "case", CategoryID: 2},
+	}}
+	sequenceRepo := &fakeSessionSequenceRepository{transitions: []dto.ProductTransition{
+		{FromProductID: phone, ToProductID: phoneCase, FromCategoryID: 1, ToCategoryID: 2, Count: 5},
+	}}
+
+	rs := NewRecommendationServiceV2(repo, nil, nil, "", "", "", nil)
+	rs.SetSessionSequenceRecommender(NewSessionSequenceRecommender(sequenceRepo, DefaultRecommendationTuning().SessionSequence))
+
+	blend := rs.blender.Resolve("product_page", nil, map[string]float64{
+		StrategySemantic:        0,
+		StrategyVectorSearch:    0,
+		StrategyKnowledgeBased:  0,
+		StrategyCollaborative:   0,
+		StrategySessionSequence: 0.6,
+	})
+	if blend.Weights[StrategySessionSequence] != 0.6 {
+		t.Fatalf("Expected session sequence weight 0.6, got %f", blend.Weights[StrategySessionSequence])
+	}
+
+	req := &dto.RecommendationRequestV2{CustomerID: uuid.New(), RecentProductIDs: []uuid.UUID{phone}, Limit: 5}
+	recommendations, _, _, err := rs.generateHybridRecommendations(context.Background(), req, nil, blend, &dto.PerformanceMetrics{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(recommendations) != 1 || recommendations[0].ProductID != phoneCase {
+		t.Fatalf("Expected the session prediction to reach the hybrid merge, got %v", recommendations)
+	}
+	found := false
+	for _, relevance := range recommendations[0].RelevanceContext {
+		if relevance.ContextType == "strategy_"+StrategySessionSequence {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("Expected the session sequence strategy to be recorded as a contribution")
+	}
+}
+
+func TestRelativeScore(t *testing.T) {
+	tests := []struct {
+		name     string
+		score    float64
+		maxScore float64
+		want     float64
+	}{
+		{name: "scales by the best score", score: 2, maxScore: 4, want: 0.5},
+		{name: "zero best score", score: 0, maxScore: 0, want: 1},
+		{name: "negative best score", score: -1, maxScore: -0.5, want: 1},
+		{name: "infinite best score", score: 3, maxScore: math.Inf(1), want: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := relativeScore(tt.score, tt.maxScore); got != tt.want {
+				t.Errorf("Expected %f, got %f", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,187 @@
+package service
+
+import (
+	"context"
+	"ec-recommend/internal/dto"
+	"fmt"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Strategy selection policies reported in responses and logs
+const (
+	BanditPolicyThompsonSampling = "thompson_sampling"
+	BanditPolicyFallback         = "fallback" // No bandit available; the default strategy was used
+)
+
+// BanditTuning configures Thompson sampling over recommendation types
+type BanditTuning struct {
+	PriorAlpha        float64 `json:"prior_alpha"`        // Beta prior successes for every arm
+	PriorBeta         float64 `json:"prior_beta"`         // Beta prior failures for every arm
+	ClickReward       float64 `json:"click_reward"`       // Reward when a recommended product is clicked
+	PurchaseReward    float64 `json:"purchase_reward"`    // Reward when a recommended product is purchased
+	PropensitySamples int     `json:"propensity_samples"` // Monte Carlo draws used to estimate propensities
+}
+
+// StrategyBandit chooses a recommendation type per service and context with Thompson sampling.
+// Each arm keeps a Beta posterior over its reward; a request samples every posterior and
+// picks the arm with the highest draw. Rewards arrive later through interaction logging.
+type StrategyBandit struct {
+	repo   StrategyBanditRepositoryInterface
+	tuning BanditTuning
+
+	mu  sync.Mutex
+	rng *rand.Rand
+}
+
+// NewStrategyBandit creates a new strategy bandit
+func NewStrategyBandit(repo StrategyBanditRepositoryInterface, tuning BanditTuning) *StrategyBandit {
+	if tuning.PriorAlpha <= 0 {
+		tuning.PriorAlpha = 1
+	}
+	if tuning.PriorBeta <= 0 {
+		tuning.PriorBeta = 1
+	}
+	if tuning.PropensitySamples <= 0 {
+		tuning.PropensitySamples = 1000
+	}
+
+	return &StrategyBandit{
+		repo:   repo,
+		tuning: tuning,
+		rng:    rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// SelectArm samples the posteriors of the candidate arms and returns the chosen one.
+//
+// Parameters:
+//   - service: BlendServiceV1 or BlendServiceV2
+//   - contextType: request context; posteriors are kept per context
+//   - arms: recommendation types that can serve the request
+//
+// Returns the selection with the estimated propensity of the chosen arm.
+func (sb *StrategyBandit) SelectArm(ctx context.Context, service, contextType string, arms []string) (*dto.StrategySelection, error) {
+	if len(arms) == 0 {
+		return nil, fmt.Errorf("no candidate strategies")
+	}
+
+	stats, err := sb.repo.GetBanditArmStats(ctx, service, contextType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bandit arm stats: %w", err)
+	}
+
+	statsByArm := make(map[string]dto.BanditArmStats, len(stats))
+	for _, s := range stats {
+		statsByArm[s.Arm] = s
+	}
+
+	alphas := make([]float64, len(arms))
+	betas := make([]float64, len(arms))
+	for i, arm := range arms {
+		s := statsByArm[arm]
+		alphas[i] = sb.tuning.PriorAlpha + math.Max(s.Successes, 0)
+		betas[i] = sb.tuning.PriorBeta + math.Max(s.Failures, 0)
+	}
+
+	// The first draw makes the decision; all draws estimate how often the policy picks each arm
+	sb.mu.Lock()
+	wins := make([]int, len(arms))
+	chosen := 0
+	for draw := 0; draw < sb.tuning.PropensitySamples; draw++ {
+		best, bestValue := 0, -1.0
+		for i := range arms {
+			if value := sampleBeta(sb.rng, alphas[i], betas[i]); value > bestValue {
+				best, bestValue = i, value
+			}
+		}
+		wins[best]++
+		if draw == 0 {
+			chosen = best
+		}
+	}
+	sb.mu.Unlock()
+
+	return &dto.StrategySelection{
+		Policy:     BanditPolicyThompsonSampling,
+		Arm:        arms[chosen],
+		Propensity: float64(wins[chosen]) / float64(sb.tuning.PropensitySamples),
+		Candidates: arms,
+	}, nil
+}
+
+// RecordDecision stores the selection for a logged recommendation so later interactions can reward it
+func (sb *StrategyBandit) RecordDecision(ctx context.Context, recommendationID, customerID uuid.UUID, service, contextType string, selection *dto.StrategySelection) error {
+	return sb.repo.RecordBanditDecision(ctx, &dto.BanditDecision{
+		RecommendationID: recommendationID,
+		CustomerID:       customerID,
+		Service:          service,
+		ContextType:      contextType,
+		Arm:              selection.Arm,
+		Propensity:       selection.Propensity,
+	})
+}
+
+// RecordInteraction updates the posterior of the arm that served a recommendation.
+// Recommendations that were not chosen by the bandit are ignored.
+func (sb *StrategyBandit) RecordInteraction(ctx context.Context, analytics *dto.RecommendationAnalytics) error {
+	reward := sb.reward(analytics)
+	if reward <= 0 {
+		return nil
+	}
+
+	if _, err := sb.repo.ApplyBanditReward(ctx, analytics.RecommendationID, reward); err != nil {
+		return fmt.Errorf("failed to apply bandit reward: %w", err)
+	}
+
+	return nil
+}
+
+// reward maps interactions to a reward in [0, 1]; purchases outrank clicks
+func (sb *StrategyBandit) reward(analytics *dto.RecommendationAnalytics) float64 {
+	switch {
+	case len(analytics.PurchasedProducts) > 0:
+		return math.Min(sb.tuning.PurchaseReward, 1)
+	case len(analytics.ClickedProducts) > 0:
+		return math.Min(sb.tuning.ClickReward, 1)
+	default:
+		return 0
+	}
+}
+
+// sampleBeta draws from Beta(alpha, beta) using two Gamma draws
+func sampleBeta(rng *rand.Rand, alpha, beta float64) float64 {
+	x := sampleGamma(rng, alpha)
+	y := sampleGamma(rng, beta)
+	if x+y == 0 {
+		return 0
+	}
+
+	return x / (x + y)
+}
+
+// sampleGamma draws from Gamma(shape, 1) with the Marsaglia-Tsang method
+func sampleGamma(rng *rand.Rand, shape float64) float64 {
+	if shape < 1 {
+		return sampleGamma(rng, shape+1) * math.Pow(rng.Float64(), 1/shape)
+	}
+
+	d := shape - 1.0/3
+	c := 1 / math.Sqrt(9*d)
+	for {
+		x := rng.NormFloat64()
+		v := 1 + c*x
+		if v <= 0 {
+			continue
+		}
+		v = v * v * v
+		u := rng.Float64()
+		if math.Log(u) < 0.5*x*x+d-d*v+d*math.Log(v) {
+			return d * v
+		}
+	}
+}
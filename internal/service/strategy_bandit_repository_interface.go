@@ -0,0 +1,22 @@
+package service
+
+import (
+	"context"
+	"ec-recommend/internal/dto"
+
+	"github.com/google/uuid"
+)
+
+// StrategyBanditRepositoryInterface defines persistence for strategy bandit posteriors and decisions
+// This interface is defined in the service package as it is consumed by services
+type StrategyBanditRepositoryInterface interface {
+	// GetBanditArmStats returns the accumulated rewards of all arms for a service and context
+	GetBanditArmStats(ctx context.Context, service, contextType string) ([]dto.BanditArmStats, error)
+
+	// RecordBanditDecision stores a decision and counts it as an unrewarded pull of its arm
+	RecordBanditDecision(ctx context.Context, decision *dto.BanditDecision) error
+
+	// ApplyBanditReward raises the reward of a decision and moves the difference from failures to successes.
+	// It reports false when no decision exists for the recommendation.
+	ApplyBanditReward(ctx context.Context, recommendationID uuid.UUID, reward float64) (bool, error)
+}
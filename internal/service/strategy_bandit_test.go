@@ -0,0 +1,195 @@
+package service
+
+import (
+	"context"
+	"ec-recommend/internal/dto"
+	"math"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// fakeBanditRepository keeps bandit state in memory
+type fakeBanditRepository struct {
+	arms      map[string]*dto.BanditArmStats
+	decisions map[uuid.UUID]*dto.BanditDecision
+}
+
+func newFakeBanditRepository() *fakeBanditRepository {
+	return &fakeBanditRepository{
+		arms:      make(map[string]*dto.BanditArmStats),
+		decisions: make(map[uuid.UUID]*dto.BanditDecision),
+	}
+}
+
+func (f *fakeBanditRepository) GetBanditArmStats(ctx context.Context, service, contextType string) ([]dto.BanditArmStats, error) {
+	var stats []dto.BanditArmStats
+	for _, s := range f.arms {
+		if s.Service == service && s.ContextType == contextType {
+			stats = append(stats, *s)
+		}
+	}
+	return stats, nil
+}
+
+func (f *fakeBanditRepository) RecordBanditDecision(ctx context.Context, decision *dto.BanditDecision) error {
+	f.decisions[decision.RecommendationID] = decision
+	key := decision.Service + "/" + decision.ContextType + "/" + decision.Arm
+	if f.arms[key] == nil {
+		f.arms[key] = &dto.BanditArmStats{Service: decision.Service, ContextType: decision.ContextType, Arm: decision.Arm}
+	}
+	f.arms[key].Failures++
+	f.arms[key].Pulls++
+	return nil
+}
+
+func (f *fakeBanditRepository) ApplyBanditReward(ctx context.Context, recommendationID uuid.UUID, reward float64) (bool, error) {
+	decision, ok := f.decisions[recommendationID]
+	if !ok {
+		return false, nil
+	}
+	if reward > decision.Reward {
+		arm := f.arms[decision.Service+"/"+decision.ContextType+"/"+decision.Arm]
+		arm.Successes += reward - decision.Reward
+		arm.Failures -= reward - decision.Reward
+		decision.Reward = reward
+	}
+	return true, nil
+}
+
+func TestStrategyBandit(t *testing.T) {
+	ctx := context.Background()
+	arms := []string{"collaborative", "content_based", "hybrid"}
+
+	t.Run("selection reports a propensity among candidates", func(t *testing.T) {
+		bandit := NewStrategyBandit(newFakeBanditRepository(), DefaultRecommendationTuning().Bandit)
+
+		selection, err := bandit.SelectArm(ctx, BlendServiceV1, "homepage", arms)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		if selection.Policy != BanditPolicyThompsonSampling {
+			t.Errorf("Expected policy %s, got %s", BanditPolicyThompsonSampling, selection.Policy)
+		}
+		if selection.Propensity <= 0 || selection.Propensity > 1 {
+			t.Errorf("Expected propensity within (0, 1], got %f", selection.Propensity)
+		}
+		// Uniform priors should give each arm roughly a third of the draws
+		if math.Abs(selection.Propensity-1.0/3) > 0.1 {
+			t.Errorf("Expected propensity near 0.33 with uniform priors, got %f", selection.Propensity)
+		}
+	})
+
+	t.Run("rewarded arm is chosen more often", func(t *testing.T) {
+		repo := newFakeBanditRepository()
+		bandit := NewStrategyBandit(repo, DefaultRecommendationTuning().Bandit)
+
+		// Simulate feedback where only hybrid recommendations get purchases
+		for i := 0; i < 300; i++ {
+			selection, err := bandit.SelectArm(ctx, BlendServiceV1, "homepage", arms)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			recommendationID := uuid.New()
+			if err := bandit.RecordDecision(ctx, recommendationID, uuid.New(), BlendServiceV1, "homepage", selection); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			if selection.Arm == "hybrid" {
+				err := bandit.RecordInteraction(ctx, &dto.RecommendationAnalytics{
+					RecommendationID:  recommendationID,
+					PurchasedProducts: []uuid.UUID{uuid.New()},
+				})
+				if err != nil {
+					t.Fatalf("Expected no error, got %v", err)
+				}
+			}
+		}
+
+		selection, err := bandit.SelectArm(ctx, BlendServiceV1, "homepage", arms)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if selection.Arm != "hybrid" {
+			t.Errorf("Expected hybrid to be chosen, got %s", selection.Arm)
+		}
+		if selection.Propensity < 0.9 {
+			t.Errorf("Expected propensity above 0.9 for the dominant arm, got %f", selection.Propensity)
+		}
+
+		// Other contexts keep their own posteriors
+		other, err := bandit.SelectArm(ctx, BlendServiceV1, "cart", arms)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if other.Propensity > 0.5 {
+			t.Errorf("Expected untouched context to stay uncertain, got propensity %f", other.Propensity)
+		}
+	})
+
+	t.Run("repeated interactions count the highest reward once", func(t *testing.T) {
+		repo := newFakeBanditRepository()
+		bandit := NewStrategyBandit(repo, DefaultRecommendationTuning().Bandit)
+		recommendationID := uuid.New()
+		selection := &dto.StrategySelection{Arm: "hybrid", Propensity: 0.5}
+		if err := bandit.RecordDecision(ctx, recommendationID, uuid.New(), BlendServiceV2, "homepage", selection); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		click := &dto.RecommendationAnalytics{RecommendationID: recommendationID, ClickedProducts: []uuid.UUID{uuid.New()}}
+		purchase := &dto.RecommendationAnalytics{RecommendationID: recommendationID, PurchasedProducts: []uuid.UUID{uuid.New()}}
+		for _, analytics := range []*dto.RecommendationAnalytics{click, purchase, click, purchase} {
+			if err := bandit.RecordInteraction(ctx, analytics); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+		}
+
+		arm := repo.arms[BlendServiceV2+"/homepage/hybrid"]
+		if arm.Successes != 1 || arm.Failures != 0 {
+			t.Errorf("Expected 1 success and 0 failures, got %f and %f", arm.Successes, arm.Failures)
+		}
+	})
+}
+
+// fakeInteractionRepositoryV2 accepts interaction logs;
+// every other method is left to the nil embedded interface
+type fakeInteractionRepositoryV2 struct {
+	RecommendationRepositoryV2Interface
+	logged []*dto.RecommendationAnalytics
+}
+
+func (f *fakeInteractionRepositoryV2) LogRecommendationInteraction(ctx context.Context, analytics *dto.RecommendationAnalytics) error {
+	f.logged = append(f.logged, analytics)
+	return nil
+}
+
+func TestRecommendationServiceV2InteractionRewardsBandit(t *testing.T) {
+	ctx := context.Background()
+	banditRepo := newFakeBanditRepository()
+	bandit := NewStrategyBandit(banditRepo, DefaultRecommendationTuning().Bandit)
+
+	repo := &fakeInteractionRepositoryV2{}
+	rs := NewRecommendationServiceV2(repo, nil, nil, "", "", "", nil)
+	rs.SetStrategyBandit(bandit)
+
+	recommendationID := uuid.New()
+	selection := &dto.StrategySelection{Arm: "semantic", Propensity: 0.5}
+	if err := bandit.RecordDecision(ctx, recommendationID, uuid.New(), BlendServiceV2, "homepage", selection); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	purchase := &dto.RecommendationAnalytics{RecommendationID: recommendationID, PurchasedProducts: []uuid.UUID{uuid.New()}}
+	if err := rs.LogRecommendationInteraction(ctx, purchase); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(repo.logged) != 1 {
+		t.Errorf("Expected the interaction to be logged once, got %d", len(repo.logged))
+	}
+	arm := banditRepo.arms[BlendServiceV2+"/homepage/semantic"]
+	if arm.Successes != 1 {
+		t.Errorf("Expected the V2 arm to be rewarded, got %f successes", arm.Successes)
+	}
+}
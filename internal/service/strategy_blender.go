@@ -0,0 +1,224 @@
+package service
+
+import (
+	"ec-recommend/internal/dto"
+	"fmt"
+	"log"
+)
+
+// Strategy keys used in blend profiles and per-request strategy weight overrides
+const (
+	StrategyCollaborative   = "collaborative"
+	StrategyContentBased    = "content_based"
+	StrategyTrending        = "trending"
+	StrategySemantic        = "semantic"
+	StrategyVectorSearch    = "vector_search"
+	StrategyKnowledgeBased  = "knowledge_based"
+	StrategySessionSequence = "session_sequence"
+	StrategyRegional        = "regional"
+)
+
+// Blend service identifiers used to scope blend profiles
+const (
+	BlendServiceV1 = "v1"
+	BlendServiceV2 = "v2"
+)
+
+// Customer segments used to select blend profiles
+const (
+	SegmentPremium  = "premium"
+	SegmentNew      = "new"
+	SegmentStandard = "standard"
+)
+
+const defaultBlendProfileName = "default"
+
+// defaultV1BlendWeights preserves the original V1 hybrid weighting
+var defaultV1BlendWeights = map[string]float64{
+	StrategyCollaborative: 0.4,
+	StrategyContentBased:  0.4,
+	StrategyTrending:      0.2,
+}
+
+// defaultV2BlendWeights preserves the original V2 hybrid weighting.
+// Strategies with a zero weight are only used when enabled by a blend profile or request override;
+// every V2 hybrid strategy must have a key here, since Resolve drops weights for unknown strategies.
+var defaultV2BlendWeights = map[string]float64{
+	StrategySemantic:        0.4,
+	StrategyVectorSearch:    0.3,
+	StrategyKnowledgeBased:  0.2,
+	StrategyCollaborative:   0.1,
+	StrategySessionSequence: 0,
+	StrategyRegional:        0,
+}
+
+// BlendProfile defines hybrid strategy weights for a context type and customer segment
+type BlendProfile struct {
+	Name         string             `json:"name"`
+	Service      string             `json:"service,omitempty"`      // "v1", "v2"; empty applies to both services
+	ContextType  string             `json:"context_type,omitempty"` // empty or "*" matches any context
+	Segment      string             `json:"segment,omitempty"`      // "premium", "new", "standard"; empty or "*" matches any segment
+	Weights      map[string]float64 `json:"weights"`
+	FusionMethod string             `json:"fusion_method,omitempty"` // "rrf", "normalized_score", "round_robin"; empty uses "rrf"
+}
+
+// BlendResult describes the strategy weights and fusion method resolved for a single request
+type BlendResult struct {
+	ProfileName  string
+	Weights      map[string]float64
+	FusionMethod string
+}
+
+// StrategyBlender resolves hybrid strategy weights from configured blend profiles
+type StrategyBlender struct {
+	service        string
+	defaultWeights map[string]float64
+	profiles       []BlendProfile
+}
+
+// NewStrategyBlender creates a blender for the given service.
+//
+// Parameters:
+//   - service: blend service identifier ("v1" or "v2") used to filter profiles
+//   - defaultWeights: weights used when no profile matches; also defines the valid strategy keys
+//   - profiles: configured blend profiles
+func NewStrategyBlender(service string, defaultWeights map[string]float64, profiles []BlendProfile) *StrategyBlender {
+	applicable := make([]BlendProfile, 0, len(profiles))
+	for _, profile := range profiles {
+		if profile.Service == "" || profile.Service == service {
+			applicable = append(applicable, profile)
+		}
+	}
+
+	return &StrategyBlender{
+		service:        service,
+		defaultWeights: defaultWeights,
+		profiles:       applicable,
+	}
+}
+
+// Resolve returns the strategy weights to apply for a request.
+// The most specific matching profile wins: a context type match outranks a segment match,
+// and among equally specific profiles the one listed first in the configuration wins.
+// A profile's weights are layered over the default weights, so a strategy the profile omits keeps
+// its default weight; set it to 0 to disable it. Per-request overrides replace individual strategy weights on top of the matched profile.
+//
+// Parameters:
+//   - contextType: recommendation context such as "homepage" or "cart"
+//   - profile: customer profile used to determine the segment (may be nil)
+//   - overrides: optional per-request weights keyed by strategy
+//
+// Returns the matched profile name and the weights actually used.
+func (sb *StrategyBlender) Resolve(contextType string, profile *dto.CustomerProfile, overrides map[string]float64) *BlendResult {
+	segment := CustomerSegment(profile)
+
+	result := &BlendResult{
+		ProfileName:  defaultBlendProfileName,
+		Weights:      make(map[string]float64, len(sb.defaultWeights)),
+		FusionMethod: defaultFusionMethod,
+	}
+	var base map[string]float64
+
+	bestScore := -1
+	for _, candidate := range sb.profiles {
+		score, ok := matchBlendProfile(candidate, contextType, segment)
+		if ok && score > bestScore {
+			bestScore = score
+			base = candidate.Weights
+			result.ProfileName = candidate.Name
+			result.FusionMethod = defaultFusionMethod
+			if candidate.FusionMethod != "" {
+				result.FusionMethod = candidate.FusionMethod
+			}
+		}
+	}
+
+	for strategy, weight := range sb.defaultWeights {
+		result.Weights[strategy] = weight
+		if profileWeight, ok := base[strategy]; ok {
+			result.Weights[strategy] = profileWeight
+		}
+	}
+
+	if len(overrides) > 0 {
+		for strategy, weight := range overrides {
+			if _, known := sb.defaultWeights[strategy]; !known {
+				log.Printf("Warning: ignoring weight override for unknown %s strategy: %s", sb.service, strategy)
+				continue
+			}
+			if weight < 0 {
+				continue
+			}
+			result.Weights[strategy] = weight
+		}
+		result.ProfileName += "+override"
+	}
+
+	return result
+}
+
+// CustomerSegment classifies a customer into a coarse segment used for tuning lookups.
+// The premium tier takes precedence over order history, so a premium customer
+// without orders is classified as premium rather than new.
+func CustomerSegment(profile *dto.CustomerProfile) string {
+	switch {
+	case profile == nil:
+		return SegmentNew
+	case profile.IsPremium:
+		return SegmentPremium
+	case profile.OrderCount == 0:
+		return SegmentNew
+	default:
+		return SegmentStandard
+	}
+}
+
+// ValidateBlendProfile checks that a blend profile only references strategies known to its service.
+// Profiles without a service may use any strategy known to either service.
+func ValidateBlendProfile(profile BlendProfile) error {
+	switch profile.Service {
+	case "", BlendServiceV1, BlendServiceV2:
+	default:
+		return fmt.Errorf("unsupported service: %s", profile.Service)
+	}
+	if profile.FusionMethod != "" && !IsValidFusionMethod(profile.FusionMethod) {
+		return fmt.Errorf("unsupported fusion method: %s", profile.FusionMethod)
+	}
+
+	for strategy, weight := range profile.Weights {
+		_, v1 := defaultV1BlendWeights[strategy]
+		_, v2 := defaultV2BlendWeights[strategy]
+		known := (profile.Service != BlendServiceV2 && v1) || (profile.Service != BlendServiceV1 && v2)
+		if !known {
+			return fmt.Errorf("unknown strategy: %s", strategy)
+		}
+		if weight < 0 {
+			return fmt.Errorf("negative weight for strategy %s", strategy)
+		}
+	}
+
+	return nil
+}
+
+// matchBlendProfile reports whether a profile applies and how specific the match is
+func matchBlendProfile(profile BlendProfile, contextType, segment string) (int, bool) {
+	score := 0
+
+	switch profile.ContextType {
+	case "", "*":
+	case contextType:
+		score += 2
+	default:
+		return 0, false
+	}
+
+	switch profile.Segment {
+	case "", "*":
+	case segment:
+		score++
+	default:
+		return 0, false
+	}
+
+	return score, true
+}
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"ec-recommend/internal/dto"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCustomerSegment(t *testing.T) {
+	tests := []struct {
+		name    string
+		profile *dto.CustomerProfile
+		want    string
+	}{
+		{name: "missing profile", profile: nil, want: SegmentNew},
+		{name: "no orders", profile: &dto.CustomerProfile{}, want: SegmentNew},
+		{name: "premium without orders", profile: &dto.CustomerProfile{IsPremium: true}, want: SegmentPremium},
+		{name: "premium with orders", profile: &dto.CustomerProfile{IsPremium: true, OrderCount: 4}, want: SegmentPremium},
+		{name: "standard", profile: &dto.CustomerProfile{OrderCount: 4}, want: SegmentStandard},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CustomerSegment(tt.profile); got != tt.want {
+				t.Errorf("Expected segment %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestStrategyBlenderResolve(t *testing.T) {
+	profiles := []BlendProfile{
+		{Name: "any-premium", Segment: SegmentPremium, Weights: map[string]float64{StrategySemantic: 1, StrategyVectorSearch: 0, StrategyKnowledgeBased: 0, StrategyCollaborative: 0}},
+		{Name: "cart", ContextType: "cart", Weights: map[string]float64{StrategySemantic: 0, StrategyVectorSearch: 1, StrategyKnowledgeBased: 0, StrategyCollaborative: 0}},
+		{Name: "cart-premium", ContextType: "cart", Segment: SegmentPremium, Weights: map[string]float64{StrategySemantic: 0, StrategyVectorSearch: 0, StrategyKnowledgeBased: 1, StrategyCollaborative: 0}},
+		{Name: "search-partial", ContextType: "search", Weights: map[string]float64{StrategySemantic: 0.6, StrategyRegional: 0.2}},
+		{Name: "cart-duplicate", ContextType: "cart", Weights: map[string]float64{StrategyCollaborative: 1}},
+		{Name: "v1-only", Service: BlendServiceV1, ContextType: "homepage", Weights: map[string]float64{StrategyTrending: 1}},
+	}
+	blender := NewStrategyBlender(BlendServiceV2, defaultV2BlendWeights, profiles)
+
+	premium := &dto.CustomerProfile{IsPremium: true}
+	standard := &dto.CustomerProfile{OrderCount: 3}
+
+	tests := []struct {
+		name        string
+		contextType string
+		profile     *dto.CustomerProfile
+		overrides   map[string]float64
+		wantProfile string
+		wantWeights map[string]float64
+	}{
+		{
+			name:        "no match falls back to defaults",
+			contextType: "homepage",
+			profile:     standard,
+			wantProfile: defaultBlendProfileName,
+			wantWeights: defaultV2BlendWeights,
+		},
+		{
+			name:        "segment match",
+			contextType: "homepage",
+			profile:     premium,
+			wantProfile: "any-premium",
+			wantWeights: map[string]float64{StrategySemantic: 1},
+		},
+		{
+			name:        "context match outranks segment match",
+			contextType: "cart",
+			profile:     standard,
+			wantProfile: "cart",
+			wantWeights: map[string]float64{StrategyVectorSearch: 1},
+		},
+		{
+			name:        "context and segment match is most specific",
+			contextType: "cart",
+			profile:     premium,
+			wantProfile: "cart-premium",
+			wantWeights: map[string]float64{StrategyKnowledgeBased: 1},
+		},
+		{
+			name:        "partial profile keeps default weights for omitted strategies",
+			contextType: "search",
+			profile:     standard,
+			wantProfile: "search-partial",
+			wantWeights: map[string]float64{
+				StrategySemantic:       0.6,
+				StrategyVectorSearch:   0.3,
+				StrategyKnowledgeBased: 0.2,
+				StrategyCollaborative:  0.1,
+				StrategyRegional:       0.2,
+			},
+		},
+		{
+			name:        "overrides replace known strategies and ignore unknown ones",
+			contextType: "cart",
+			profile:     standard,
+			overrides:   map[string]float64{StrategySemantic: 0.5, "semantc": 0.9, StrategyVectorSearch: -1},
+			wantProfile: "cart+override",
+			wantWeights: map[string]float64{StrategyVectorSearch: 1, StrategySemantic: 0.5},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := blender.Resolve(tt.contextType, tt.profile, tt.overrides)
+			if result.ProfileName != tt.wantProfile {
+				t.Errorf("Expected profile %s, got %s", tt.wantProfile, result.ProfileName)
+			}
+			if len(result.Weights) != len(defaultV2BlendWeights) {
+				t.Errorf("Expected weights for %d strategies, got %d", len(defaultV2BlendWeights), len(result.Weights))
+			}
+			for strategy := range defaultV2BlendWeights {
+				if result.Weights[strategy] != tt.wantWeights[strategy] {
+					t.Errorf("Expected %s weight %f, got %f", strategy, tt.wantWeights[strategy], result.Weights[strategy])
+				}
+			}
+		})
+	}
+}
+
+func TestValidateBlendProfile(t *testing.T) {
+	tests := []struct {
+		name    string
+		profile BlendProfile
+		wantErr bool
+	}{
+		{name: "v2 strategy without service", profile: BlendProfile{Weights: map[string]float64{StrategySemantic: 1}}},
+		{name: "v1 strategy without service", profile: BlendProfile{Weights: map[string]float64{StrategyTrending: 1}}},
+		{name: "v1 strategy on v1", profile: BlendProfile{Service: BlendServiceV1, Weights: map[string]float64{StrategyContentBased: 1}}},
+		{name: "v2 only strategy on v1", profile: BlendProfile{Service: BlendServiceV1, Weights: map[string]float64{StrategySemantic: 1}}, wantErr: true},
+		{name: "misspelled strategy", profile: BlendProfile{Weights: map[string]float64{"colaborative": 1}}, wantErr: true},
+		{name: "negative weight", profile: BlendProfile{Weights: map[string]float64{StrategySemantic: -0.1}}, wantErr: true},
+		{name: "unknown service", profile: BlendProfile{Service: "v3"}, wantErr: true},
+		{name: "unknown fusion method", profile: BlendProfile{FusionMethod: "borda"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateBlendProfile(tt.profile)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestLoadRecommendationTuningRejectsUnknownBlendStrategy(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tuning.json")
+	data := `{"blend_profiles": [{"name": "typo", "weights": {"semantc": 0.5}}]}`
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatalf("Failed to write tuning file: %v", err)
+	}
+
+	if _, err := LoadRecommendationTuning(path); err == nil {
+		t.Error("Expected unknown strategy key to be rejected")
+	}
+}
@@ -0,0 +1,421 @@
+package service
+
+import (
+	"context"
+	"ec-recommend/internal/dto"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SuggestTuning configures search box completions
+type SuggestTuning struct {
+	DefaultLimit          int                 `json:"default_limit"`          // Completions returned when the request sets no limit
+	QueryLookbackDays     int                 `json:"query_lookback_days"`    // Search log used for query popularity
+	MinQueryCount         int                 `json:"min_query_count"`        // Distinct customers who must have searched a logged query before it is suggested
+	RefreshMinutes        int                 `json:"refresh_minutes"`        // Completion index cache lifetime
+	TypeWeights           map[string]float64  `json:"type_weights"`           // Score weight of "query", "product", "brand" and "category" terms
+	WordMatchFactor       float64             `json:"word_match_factor"`      // Score factor when the query matches a later word rather than the start
+	PersonalizationWeight float64             `json:"personalization_weight"` // Score increase for terms in the customer's strongest category
+	AffinityLookbackDays  int                 `json:"affinity_lookback_days"` // Purchases and activities used for category affinity
+	PurchaseWeight        float64             `json:"purchase_weight"`        // Affinity per unit purchased
+	CartWeight            float64             `json:"cart_weight"`            // Affinity per cart or wishlist addition
+	ViewWeight            float64             `json:"view_weight"`            // Affinity per product view
+	PreferredWeight       float64             `json:"preferred_weight"`       // Affinity of a declared preferred category
+	ParentShare           float64             `json:"parent_share"`           // Share of a subcategory's affinity credited to its parent
+	Readings              map[string][]string `json:"readings,omitempty"`     // Kana readings of kanji and Latin words, e.g. "家具": ["かぐ"]
+}
+
+// DefaultSuggestTuning returns the suggest tuning used when none is configured.
+// The readings cover the kanji of the seeded category names and common brand names;
+// entries in the tuning file are added to them.
+func DefaultSuggestTuning() SuggestTuning {
+	return SuggestTuning{
+		DefaultLimit:      10,
+		QueryLookbackDays: 90,
+		MinQueryCount:     5,
+		RefreshMinutes:    30,
+		TypeWeights: map[string]float64{
+			dto.SuggestionTypeQuery:    1.0,
+			dto.SuggestionTypeCategory: 0.9,
+			dto.SuggestionTypeBrand:    0.8,
+			dto.SuggestionTypeProduct:  0.7,
+		},
+		WordMatchFactor:       0.6,
+		PersonalizationWeight: 0.5,
+		AffinityLookbackDays:  180,
+		PurchaseWeight:        3,
+		CartWeight:            2,
+		ViewWeight:            1,
+		PreferredWeight:       5,
+		ParentShare:           0.5,
+		Readings: map[string][]string{
+			"電子": {"でんし"}, "機器": {"きき"}, "撮影": {"さつえい"}, "機": {"き"},
+			"家具": {"かぐ"}, "寝具": {"しんぐ"}, "照明": {"しょうめい"}, "器具": {"きぐ"}, "収納": {"しゅうのう"}, "用品": {"ようひん"},
+			"書籍": {"しょせき"}, "雑誌": {"ざっし"}, "小説": {"しょうせつ"}, "文学": {"ぶんがく"}, "技術": {"ぎじゅつ"}, "書": {"しょ"}, "漫画": {"まんが"},
+			"靴": {"くつ"}, "美容": {"びよう"}, "香水": {"こうすい"}, "食品": {"しょくひん"}, "飲料": {"いんりょう"}, "菓子": {"かし"},
+			"知育": {"ちいく"}, "玩具": {"がんぐ", "おもちゃ"}, "医療": {"いりょう"}, "栄養": {"えいよう"}, "自動車": {"じどうしゃ"},
+			"無印良品": {"むじるしりょうひん"}, "集英社": {"しゅうえいしゃ"}, "講談社": {"こうだんしゃ"}, "西川": {"にしかわ"},
+			"apple": {"アップル"}, "iphone": {"アイフォン"}, "ipad": {"アイパッド"}, "sony": {"ソニー"}, "samsung": {"サムスン"},
+			"google": {"グーグル"}, "panasonic": {"パナソニック"}, "sharp": {"シャープ"}, "microsoft": {"マイクロソフト"},
+			"muji": {"ムジ"}, "uniqlo": {"ユニクロ"}, "ikea": {"イケア"}, "nitori": {"ニトリ"}, "zara": {"ザラ"}, "nike": {"ナイキ"},
+		},
+	}
+}
+
+// suggestEntry is an indexed completion candidate
+type suggestEntry struct {
+	term       dto.SuggestTerm
+	normalized string
+	base       float64 // Type weight times normalized popularity
+}
+
+// suggestKey is a normalized form a candidate can be found by
+type suggestKey struct {
+	key   string
+	entry int
+	word  bool // A later word of the candidate rather than its start
+}
+
+// SuggestIndex finds completion candidates by prefix of their normalized text, words and readings
+type SuggestIndex struct {
+	entries []suggestEntry
+	keys    []suggestKey // Sorted by key
+}
+
+// BuildSuggestIndex indexes terms under their normalized text, aliases and words, plus the kana
+// readings of each. Popularity is log-scaled against the most popular term of the same type, so
+// searcher counts and popularity scores are comparable. Logged queries searched by fewer than
+// MinQueryCount customers are left out, so one customer cannot publish a query to everyone.
+func BuildSuggestIndex(terms []dto.SuggestTerm, tuning SuggestTuning) *SuggestIndex {
+	dictionary := NewReadingDictionary(tuning.Readings)
+
+	kept := make([]dto.SuggestTerm, 0, len(terms))
+	for _, term := range terms {
+		if term.Type == dto.SuggestionTypeQuery && term.Popularity < float64(tuning.MinQueryCount) {
+			continue
+		}
+		kept = append(kept, term)
+	}
+	terms = kept
+
+	maxPopularity := make(map[string]float64)
+	for _, term := range terms {
+		maxPopularity[term.Type] = math.Max(maxPopularity[term.Type], term.Popularity)
+	}
+
+	index := &SuggestIndex{}
+	for _, term := range terms {
+		normalized := NormalizeSearchText(term.Text)
+		if normalized == "" {
+			continue
+		}
+
+		popularity := 0.0
+		if highest := maxPopularity[term.Type]; highest > 0 && term.Popularity > 0 {
+			popularity = math.Log1p(term.Popularity) / math.Log1p(highest)
+		}
+		// Unpopular terms stay suggestible, ranked below popular ones of the same type
+		base := tuning.TypeWeights[term.Type] * (0.2 + 0.8*popularity)
+		if base <= 0 {
+			continue
+		}
+
+		entry := len(index.entries)
+		index.entries = append(index.entries, suggestEntry{term: term, normalized: normalized, base: base})
+
+		seen := make(map[string]bool)
+		add := func(key string, word bool) {
+			if key == "" || seen[key] {
+				return
+			}
+			seen[key] = true
+			index.keys = append(index.keys, suggestKey{key: key, entry: entry, word: word})
+		}
+
+		forms := []string{normalized}
+		for _, alias := range term.Aliases {
+			forms = append(forms, NormalizeSearchText(alias))
+		}
+		for _, form := range forms {
+			add(form, false)
+			for _, reading := range dictionary.Readings(form) {
+				add(reading, false)
+			}
+			for i, word := range searchWords(form) {
+				add(word, i > 0)
+				for _, reading := range dictionary.Readings(word) {
+					add(reading, i > 0)
+				}
+			}
+		}
+	}
+
+	// Whole-text keys sort before word keys with the same text so they are seen first
+	sort.Slice(index.keys, func(i, j int) bool {
+		if index.keys[i].key != index.keys[j].key {
+			return index.keys[i].key < index.keys[j].key
+		}
+		return !index.keys[i].word && index.keys[j].word
+	})
+
+	return index
+}
+
+// Suggest returns up to limit completions of the query. Each candidate scores its base times the word
+// match factor when only a later word matched, increased by up to the personalization weight by the
+// customer's affinity to its categories. Candidates with the same normalized text keep the best score.
+func (x *SuggestIndex) Suggest(query string, affinity map[int]float64, tuning SuggestTuning, limit int) []dto.Suggestion {
+	return x.SuggestExcluding(query, affinity, tuning, limit, nil)
+}
+
+// SuggestExcluding works like Suggest but skips the candidates for which exclude returns true
+func (x *SuggestIndex) SuggestExcluding(query string, affinity map[int]float64, tuning SuggestTuning, limit int, exclude func(dto.SuggestTerm) bool) []dto.Suggestion {
+	prefix := NormalizeSearchText(query)
+	if x == nil || prefix == "" {
+		return nil
+	}
+
+	best := make(map[string]dto.Suggestion)
+	start := sort.Search(len(x.keys), func(i int) bool { return x.keys[i].key >= prefix })
+	for _, key := range x.keys[start:] {
+		if !strings.HasPrefix(key.key, prefix) {
+			break
+		}
+		entry := x.entries[key.entry]
+		if exclude != nil && exclude(entry.term) {
+			continue
+		}
+
+		score := entry.base
+		if key.word {
+			score *= tuning.WordMatchFactor
+		}
+
+		strongest := 0.0
+		for _, categoryID := range entry.term.CategoryIDs {
+			strongest = math.Max(strongest, affinity[categoryID])
+		}
+		boost := score * tuning.PersonalizationWeight * strongest
+
+		suggestion := dto.Suggestion{
+			Text:       entry.term.Text,
+			Type:       entry.term.Type,
+			ProductID:  entry.term.ProductID,
+			CategoryID: entry.term.CategoryID,
+			MatchedOn:  key.key,
+			Score:      score + boost,
+			Boost:      boost,
+		}
+		if current, ok := best[entry.normalized]; !ok || suggestion.Score > current.Score {
+			best[entry.normalized] = suggestion
+		}
+	}
+
+	suggestions := make([]dto.Suggestion, 0, len(best))
+	for _, suggestion := range best {
+		suggestions = append(suggestions, suggestion)
+	}
+	sort.Slice(suggestions, func(i, j int) bool {
+		if suggestions[i].Score != suggestions[j].Score {
+			return suggestions[i].Score > suggestions[j].Score
+		}
+		if len(suggestions[i].Text) != len(suggestions[j].Text) {
+			return len(suggestions[i].Text) < len(suggestions[j].Text)
+		}
+		return suggestions[i].Text < suggestions[j].Text
+	})
+
+	if limit > 0 && len(suggestions) > limit {
+		suggestions = suggestions[:limit]
+	}
+	return suggestions
+}
+
+// ComputeCategoryAffinity weights a customer's interactions per category, credits a share of each
+// subcategory to its parent and scales the result so the strongest category is 1
+func ComputeCategoryAffinity(interactions []dto.CategoryInteraction, tuning SuggestTuning) map[int]float64 {
+	raw := make(map[int]float64)
+	for _, interaction := range interactions {
+		weight := tuning.PurchaseWeight*float64(interaction.Purchases) +
+			tuning.CartWeight*float64(interaction.CartAdds) +
+			tuning.ViewWeight*float64(interaction.Views)
+		if interaction.Preferred {
+			weight += tuning.PreferredWeight
+		}
+		if weight <= 0 {
+			continue
+		}
+
+		raw[interaction.CategoryID] += weight
+		if interaction.ParentID != nil {
+			raw[*interaction.ParentID] += tuning.ParentShare * weight
+		}
+	}
+
+	strongest := 0.0
+	for _, weight := range raw {
+		strongest = math.Max(strongest, weight)
+	}
+	if strongest == 0 {
+		return nil
+	}
+
+	affinity := make(map[int]float64, len(raw))
+	for categoryID, weight := range raw {
+		affinity[categoryID] = weight / strongest
+	}
+	return affinity
+}
+
+// SuggestService completes search box queries from the search log and the catalog
+type SuggestService struct {
+	repo     SuggestRepositoryInterface
+	tuning   SuggestTuning
+	feedback *FeedbackService
+
+	mu       sync.Mutex
+	index    *SuggestIndex
+	loadedAt time.Time
+	failedAt time.Time
+	failure  error
+}
+
+// NewSuggestService creates a new suggest service instance
+func NewSuggestService(repo SuggestRepositoryInterface, tuning SuggestTuning) *SuggestService {
+	defaults := DefaultSuggestTuning()
+	if tuning.DefaultLimit <= 0 {
+		tuning.DefaultLimit = defaults.DefaultLimit
+	}
+	if tuning.QueryLookbackDays <= 0 {
+		tuning.QueryLookbackDays = defaults.QueryLookbackDays
+	}
+	if tuning.MinQueryCount <= 0 {
+		tuning.MinQueryCount = defaults.MinQueryCount
+	}
+	if tuning.RefreshMinutes <= 0 {
+		tuning.RefreshMinutes = defaults.RefreshMinutes
+	}
+	if len(tuning.TypeWeights) == 0 {
+		tuning.TypeWeights = defaults.TypeWeights
+	}
+	if tuning.AffinityLookbackDays <= 0 {
+		tuning.AffinityLookbackDays = defaults.AffinityLookbackDays
+	}
+
+	return &SuggestService{
+		repo:   repo,
+		tuning: tuning,
+	}
+}
+
+// SetFeedbackService excludes the products, brands and categories the customer dismissed from completions
+func (s *SuggestService) SetFeedbackService(feedback *FeedbackService) {
+	s.feedback = feedback
+}
+
+// Suggest returns completions of the query, personalized by the customer's category affinity when a customer is given
+func (s *SuggestService) Suggest(ctx context.Context, query string, customerID *uuid.UUID, limit int) (*dto.SuggestResponse, error) {
+	if limit <= 0 {
+		limit = s.tuning.DefaultLimit
+	}
+
+	index, err := s.currentIndex(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Personalization is best effort; completions must not fail with it
+	var affinity map[int]float64
+	if customerID != nil && s.tuning.PersonalizationWeight > 0 {
+		since := time.Now().AddDate(0, 0, -s.tuning.AffinityLookbackDays)
+		interactions, err := s.repo.GetCategoryInteractions(ctx, *customerID, since)
+		if err != nil {
+			log.Printf("Warning: failed to get category interactions for suggestions: %v", err)
+		} else {
+			affinity = ComputeCategoryAffinity(interactions, s.tuning)
+		}
+	}
+
+	suggestions := index.SuggestExcluding(query, affinity, s.tuning, limit, suppressedTerms(s.feedback.suppressionsFor(ctx, customerID)))
+	if suggestions == nil {
+		suggestions = []dto.Suggestion{}
+	}
+
+	return &dto.SuggestResponse{
+		Query:           query,
+		NormalizedQuery: NormalizeSearchText(query),
+		CustomerID:      customerID,
+		Suggestions:     suggestions,
+		Personalized:    len(affinity) > 0,
+		GeneratedAt:     time.Now(),
+	}, nil
+}
+
+// suppressedTerms returns a filter matching the product, brand and category completions the customer
+// dismissed, or nil when nothing is suppressed. Logged search queries are never suppressed.
+func suppressedTerms(suppressions *FeedbackSuppressions) func(dto.SuggestTerm) bool {
+	if suppressions.Empty() {
+		return nil
+	}
+
+	return func(term dto.SuggestTerm) bool {
+		switch term.Type {
+		case dto.SuggestionTypeProduct:
+			productID, categoryID := uuid.Nil, 0
+			if term.ProductID != nil {
+				productID = *term.ProductID
+			}
+			if term.CategoryID != nil {
+				categoryID = *term.CategoryID
+			}
+			return suppressions.Suppresses(productID, term.Brand, categoryID)
+		case dto.SuggestionTypeBrand:
+			return term.Brand != "" && suppressions.brands[strings.ToLower(term.Brand)]
+		case dto.SuggestionTypeCategory:
+			return term.CategoryID != nil && suppressions.categories[*term.CategoryID]
+		}
+		return false
+	}
+}
+
+// currentIndex returns the completion index, rebuilding it when older than the refresh interval.
+// After a failed rebuild the database is not queried again until the retry backoff has passed.
+func (s *SuggestService) currentIndex(ctx context.Context) (*SuggestIndex, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.index != nil && time.Since(s.loadedAt) < time.Duration(s.tuning.RefreshMinutes)*time.Minute {
+		return s.index, nil
+	}
+	if s.failure != nil && time.Since(s.failedAt) < modelRetryBackoff {
+		if s.index != nil {
+			return s.index, nil
+		}
+		return nil, s.failure
+	}
+
+	since := time.Now().AddDate(0, 0, -s.tuning.QueryLookbackDays)
+	terms, err := s.repo.GetSuggestTerms(ctx, since, s.tuning.MinQueryCount)
+	if err != nil {
+		s.failedAt = time.Now()
+		s.failure = fmt.Errorf("failed to get suggest terms: %w", err)
+		// Keep serving the stale index rather than failing requests
+		if s.index != nil {
+			return s.index, nil
+		}
+		return nil, s.failure
+	}
+	s.failure = nil
+
+	s.index = BuildSuggestIndex(terms, s.tuning)
+	s.loadedAt = time.Now()
+
+	return s.index, nil
+}
@@ -0,0 +1,21 @@
+package service
+
+import (
+	"context"
+	"ec-recommend/internal/dto"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SuggestRepositoryInterface defines the interface for search suggestion data access
+// This interface is defined in the service package as it is consumed by services
+type SuggestRepositoryInterface interface {
+	// GetSuggestTerms returns search queries logged since the given time at least minQueryCount times,
+	// together with the names of active products, their brands and all categories
+	GetSuggestTerms(ctx context.Context, querySince time.Time, minQueryCount int) ([]dto.SuggestTerm, error)
+
+	// GetCategoryInteractions returns a customer's purchases, cart additions and views per category since the
+	// given time, including their preferred categories even without interactions
+	GetCategoryInteractions(ctx context.Context, customerID uuid.UUID, since time.Time) ([]dto.CategoryInteraction, error)
+}
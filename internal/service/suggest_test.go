@@ -0,0 +1,285 @@
+package service
+
+import (
+	"context"
+	"ec-recommend/internal/dto"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestNormalizeSearchText(t *testing.T) {
+	cases := map[string]string{
+		"ｽﾏｰﾄﾌｫﾝ":         "すまーとふぉん",
+		"スマートフォン":         "すまーとふぉん",
+		"ＩＰｈｏｎｅ　１５":       "iphone 15",
+		"  Galaxy   S24 ": "galaxy s24",
+		"ｶﾞｼﾞｪｯﾄ":         "がじぇっと",
+		"家具":              "家具",
+	}
+	for input, expected := range cases {
+		if got := NormalizeSearchText(input); got != expected {
+			t.Errorf("Expected %q for %q, got %q", expected, input, got)
+		}
+	}
+}
+
+func TestReadingDictionary(t *testing.T) {
+	dictionary := NewReadingDictionary(map[string][]string{
+		"食品":    {"しょくひん"},
+		"家具":    {"かぐ"},
+		"玩具":    {"がんぐ", "おもちゃ"},
+		"sharp": {"シャープ"},
+	})
+
+	t.Run("compound words are transliterated piece by piece", func(t *testing.T) {
+		readings := dictionary.Readings(NormalizeSearchText("オーガニック食品"))
+		if len(readings) != 1 || readings[0] != "おーがにっくしょくひん" {
+			t.Errorf("Expected おーがにっくしょくひん, got %v", readings)
+		}
+	})
+
+	t.Run("whole words return every reading", func(t *testing.T) {
+		readings := dictionary.Readings("玩具")
+		if len(readings) != 2 {
+			t.Errorf("Expected 2 readings, got %v", readings)
+		}
+	})
+
+	t.Run("latin words only match whole words", func(t *testing.T) {
+		if readings := dictionary.Readings("sharpener"); readings != nil {
+			t.Errorf("Expected no reading, got %v", readings)
+		}
+		if readings := dictionary.Readings("sharp aquos"); len(readings) != 1 || readings[0] != "しゃーぷ aquos" {
+			t.Errorf("Expected しゃーぷ aquos, got %v", readings)
+		}
+	})
+}
+
+func suggestTerms() []dto.SuggestTerm {
+	smartphones, furniture := 11, 24
+	return []dto.SuggestTerm{
+		{Text: "スマホケース", Type: dto.SuggestionTypeQuery, Popularity: 40},
+		{Text: "スマートウォッチ", Type: dto.SuggestionTypeQuery, Popularity: 5},
+		{Text: "スマートフォン", Type: dto.SuggestionTypeCategory, Aliases: []string{"smartphones"}, CategoryID: &smartphones, CategoryIDs: []int{11, 1}, Popularity: 500},
+		{Text: "家具", Type: dto.SuggestionTypeCategory, Aliases: []string{"furniture"}, CategoryID: &furniture, CategoryIDs: []int{24, 3}, Popularity: 300},
+		{Text: "iPhone 15 Pro", Type: dto.SuggestionTypeProduct, CategoryIDs: []int{11, 1}, Popularity: 95},
+		{Text: "ソファ 3人掛け", Type: dto.SuggestionTypeProduct, CategoryIDs: []int{24, 3}, Popularity: 60},
+		{Text: "スマートLED電球", Type: dto.SuggestionTypeProduct, CategoryIDs: []int{27, 3}, Popularity: 10},
+		{Text: "Apple", Type: dto.SuggestionTypeBrand, CategoryIDs: []int{11, 12}, Popularity: 700},
+	}
+}
+
+func suggestionTexts(suggestions []dto.Suggestion) []string {
+	texts := make([]string, len(suggestions))
+	for i, s := range suggestions {
+		texts[i] = s.Text
+	}
+	return texts
+}
+
+func TestSuggestIndex(t *testing.T) {
+	tuning := DefaultSuggestTuning()
+	index := BuildSuggestIndex(suggestTerms(), tuning)
+
+	t.Run("katakana, hiragana and half-width input match alike", func(t *testing.T) {
+		for _, query := range []string{"スマ", "すま", "ｽﾏ"} {
+			texts := suggestionTexts(index.Suggest(query, nil, tuning, 10))
+			if len(texts) != 4 {
+				t.Errorf("Expected 4 completions for %s, got %v", query, texts)
+			}
+		}
+	})
+
+	t.Run("more popular terms rank first", func(t *testing.T) {
+		suggestions := index.Suggest("すま", nil, tuning, 10)
+		if suggestions[0].Text != "スマホケース" {
+			t.Errorf("Expected the popular query first, got %v", suggestionTexts(suggestions))
+		}
+		if suggestions[len(suggestions)-1].Text != "スマートLED電球" {
+			t.Errorf("Expected the unpopular product last, got %v", suggestionTexts(suggestions))
+		}
+	})
+
+	t.Run("kanji and latin names match their readings", func(t *testing.T) {
+		if texts := suggestionTexts(index.Suggest("かぐ", nil, tuning, 10)); len(texts) != 1 || texts[0] != "家具" {
+			t.Errorf("Expected 家具, got %v", texts)
+		}
+		if texts := suggestionTexts(index.Suggest("アイフォ", nil, tuning, 10)); len(texts) != 1 || texts[0] != "iPhone 15 Pro" {
+			t.Errorf("Expected iPhone 15 Pro, got %v", texts)
+		}
+		if texts := suggestionTexts(index.Suggest("あっぷ", nil, tuning, 10)); len(texts) != 1 || texts[0] != "Apple" {
+			t.Errorf("Expected Apple, got %v", texts)
+		}
+	})
+
+	t.Run("later words and aliases match", func(t *testing.T) {
+		suggestions := index.Suggest("pro", nil, tuning, 10)
+		if len(suggestions) != 1 || suggestions[0].MatchedOn != "pro" {
+			t.Fatalf("Expected iPhone 15 Pro matched on pro, got %v", suggestions)
+		}
+		whole := index.Suggest("iphone", nil, tuning, 10)
+		if suggestions[0].Score >= whole[0].Score {
+			t.Errorf("Expected a later word to score below the start, got %.3f and %.3f", suggestions[0].Score, whole[0].Score)
+		}
+
+		if texts := suggestionTexts(index.Suggest("furn", nil, tuning, 10)); len(texts) != 1 || texts[0] != "家具" {
+			t.Errorf("Expected 家具 from its English name, got %v", texts)
+		}
+	})
+
+	t.Run("category affinity raises the customer's categories", func(t *testing.T) {
+		affinity := map[int]float64{27: 1, 3: 0.5}
+		suggestions := index.Suggest("すま", affinity, tuning, 10)
+
+		var bulb dto.Suggestion
+		for _, s := range suggestions {
+			if s.Text == "スマートLED電球" {
+				bulb = s
+			}
+		}
+		if bulb.Boost <= 0 || suggestions[len(suggestions)-1].Text == "スマートLED電球" {
+			t.Errorf("Expected the boosted product to move up, got %v", suggestionTexts(suggestions))
+		}
+	})
+
+	t.Run("queries searched by a single customer are not suggested", func(t *testing.T) {
+		terms := append(suggestTerms(), dto.SuggestTerm{Text: "スマホ 山田太郎", Type: dto.SuggestionTypeQuery, Popularity: 1})
+		texts := suggestionTexts(BuildSuggestIndex(terms, tuning).Suggest("スマホ", nil, tuning, 10))
+		if len(texts) != 1 || texts[0] != "スマホケース" {
+			t.Errorf("Expected only the widely searched query, got %v", texts)
+		}
+	})
+
+	t.Run("limit and empty queries", func(t *testing.T) {
+		if suggestions := index.Suggest("す", nil, tuning, 2); len(suggestions) != 2 {
+			t.Errorf("Expected 2 completions, got %d", len(suggestions))
+		}
+		if suggestions := index.Suggest("  ", nil, tuning, 10); suggestions != nil {
+			t.Errorf("Expected no completions, got %v", suggestions)
+		}
+	})
+}
+
+func TestComputeCategoryAffinity(t *testing.T) {
+	electronics := 1
+	affinity := ComputeCategoryAffinity([]dto.CategoryInteraction{
+		{CategoryID: 11, ParentID: &electronics, Purchases: 2, Views: 4},
+		{CategoryID: 24, Preferred: true},
+		{CategoryID: 30},
+	}, DefaultSuggestTuning())
+
+	// smartphones: 3*2 + 4 = 10, electronics: 5, furniture: 5
+	if affinity[11] != 1 || affinity[1] != 0.5 || affinity[24] != 0.5 {
+		t.Errorf("Expected 1, 0.5 and 0.5, got %v", affinity)
+	}
+	if _, ok := affinity[30]; ok {
+		t.Error("Expected categories without interactions to be left out")
+	}
+	if ComputeCategoryAffinity(nil, DefaultSuggestTuning()) != nil {
+		t.Error("Expected nil affinity without interactions")
+	}
+}
+
+// fakeSuggestRepository serves fixed terms and interactions
+type fakeSuggestRepository struct {
+	terms        []dto.SuggestTerm
+	interactions []dto.CategoryInteraction
+	termErr      error
+	termCalls    int
+	affinityErr  error
+}
+
+func (r *fakeSuggestRepository) GetSuggestTerms(ctx context.Context, querySince time.Time, minQueryCount int) ([]dto.SuggestTerm, error) {
+	r.termCalls++
+	return r.terms, r.termErr
+}
+
+func (r *fakeSuggestRepository) GetCategoryInteractions(ctx context.Context, customerID uuid.UUID, since time.Time) ([]dto.CategoryInteraction, error) {
+	return r.interactions, r.affinityErr
+}
+
+func TestSuggestService(t *testing.T) {
+	ctx := context.Background()
+	customerID := uuid.New()
+
+	t.Run("personalizes when the customer has interactions", func(t *testing.T) {
+		repo := &fakeSuggestRepository{
+			terms:        suggestTerms(),
+			interactions: []dto.CategoryInteraction{{CategoryID: 27, Purchases: 1}},
+		}
+		service := NewSuggestService(repo, DefaultSuggestTuning())
+
+		response, err := service.Suggest(ctx, "ｽﾏ", &customerID, 0)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if !response.Personalized || response.NormalizedQuery != "すま" || len(response.Suggestions) != 4 {
+			t.Errorf("Expected 4 personalized completions of すま, got %+v", response)
+		}
+
+		if _, err := service.Suggest(ctx, "か", nil, 0); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if repo.termCalls != 1 {
+			t.Errorf("Expected the index to be cached, got %d loads", repo.termCalls)
+		}
+	})
+
+	t.Run("affinity errors fall back to unpersonalized completions", func(t *testing.T) {
+		repo := &fakeSuggestRepository{terms: suggestTerms(), affinityErr: errors.New("db down")}
+		response, err := NewSuggestService(repo, DefaultSuggestTuning()).Suggest(ctx, "すま", &customerID, 2)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if response.Personalized || len(response.Suggestions) != 2 {
+			t.Errorf("Expected 2 unpersonalized completions, got %+v", response)
+		}
+	})
+
+	t.Run("leaves out dismissed brands and categories", func(t *testing.T) {
+		future := time.Now().Add(24 * time.Hour)
+		feedback := NewFeedbackService(&fakeFeedbackRepository{feedback: []dto.RecommendationFeedback{
+			{ProductID: uuid.New(), Scope: dto.FeedbackScopeBrand, Brand: "apple", CategoryID: 11, SuppressUntil: future},
+			{ProductID: uuid.New(), Scope: dto.FeedbackScopeCategory, CategoryID: 24, SuppressUntil: future},
+		}}, DefaultFeedbackTuning())
+		terms := suggestTerms()
+		terms[4].Brand = "Apple"
+		terms[7].Brand = "Apple"
+		service := NewSuggestService(&fakeSuggestRepository{terms: terms}, DefaultSuggestTuning())
+		service.SetFeedbackService(feedback)
+
+		for query, want := range map[string]int{"あ": 0, "iphone": 0, "かぐ": 0, "すま": 4} {
+			response, err := service.Suggest(ctx, query, &customerID, 0)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if len(response.Suggestions) != want {
+				t.Errorf("Expected %d completions of %s, got %v", want, query, suggestionTexts(response.Suggestions))
+			}
+		}
+
+		response, err := service.Suggest(ctx, "あ", nil, 0)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(response.Suggestions) != 2 {
+			t.Errorf("Expected dismissals to apply only to the customer, got %v", suggestionTexts(response.Suggestions))
+		}
+	})
+
+	t.Run("index errors fail the request and back off", func(t *testing.T) {
+		repo := &fakeSuggestRepository{termErr: errors.New("db down")}
+		service := NewSuggestService(repo, DefaultSuggestTuning())
+		for i := 0; i < 3; i++ {
+			if _, err := service.Suggest(ctx, "すま", nil, 0); err == nil {
+				t.Error("Expected an error")
+			}
+		}
+		if repo.termCalls != 1 {
+			t.Errorf("Expected one load within the retry backoff, got %d", repo.termCalls)
+		}
+	})
+}